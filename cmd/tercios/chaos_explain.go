@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/javiermolinar/tercios/internal/chaos"
+)
+
+// runChaosCommand handles `tercios chaos SUBCOMMAND` and returns the process
+// exit code.
+func runChaosCommand(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] != "explain" {
+		_, _ = fmt.Fprintln(stderr, "usage: tercios chaos explain --chaos-policies-file=FILE --span-file=FILE")
+		return 2
+	}
+	flags := flag.NewFlagSet("tercios chaos explain", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	policiesFile := flags.String("chaos-policies-file", "", "path to chaos policies JSON or YAML file")
+	spanFile := flags.String("span-file", "", "path to a JSON or YAML span (name, kind, attributes, resource) to explain the policies against")
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if *policiesFile == "" || *spanFile == "" {
+		_, _ = fmt.Fprintln(stderr, "chaos explain requires --chaos-policies-file and --span-file")
+		return 2
+	}
+
+	cfg, err := chaos.LoadFromJSON(*policiesFile)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "invalid chaos policies: %v\n", err)
+		return 1
+	}
+	engine, err := chaos.NewEngine(cfg)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "create chaos engine: %v\n", err)
+		return 1
+	}
+	span, err := chaos.LoadSpanFromFile(*spanFile)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "invalid span file: %v\n", err)
+		return 1
+	}
+	_, _ = fmt.Fprintln(stdout, formatExplanations(span.Name, engine.Explain(span)))
+	return 0
+}
+
+func formatExplanations(spanName string, explanations []chaos.PolicyExplanation) string {
+	matched := 0
+	for _, explanation := range explanations {
+		if explanation.Matched && explanation.ShadowedBy == "" {
+			matched++
+		}
+	}
+	lines := []string{fmt.Sprintf("Span %q matches %d of %d policies:", spanName, matched, len(explanations))}
+	for _, explanation := range explanations {
+		switch {
+		case explanation.Matched && explanation.ShadowedBy != "":
+			lines = append(lines, fmt.Sprintf("  - %s: matches, but first_match stops at %s", explanation.Policy, explanation.ShadowedBy))
+		case explanation.Matched:
+			lines = append(lines, fmt.Sprintf("  + %s: matches (probability %g)", explanation.Policy, explanation.Probability))
+		default:
+			lines = append(lines, fmt.Sprintf("  - %s: no match", explanation.Policy))
+			for _, reason := range explanation.Reasons {
+				lines = append(lines, "      "+reason)
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
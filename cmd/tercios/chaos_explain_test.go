@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunChaosCommandExplainsSpan(t *testing.T) {
+	dir := t.TempDir()
+	policies := filepath.Join(dir, "policies.yaml")
+	span := filepath.Join(dir, "span.json")
+	if err := os.WriteFile(policies, []byte(`policies:
+  - name: errors
+    probability: 1
+    match:
+      service_name: checkout
+    actions:
+      - type: set_status
+        code: error
+`), 0o644); err != nil {
+		t.Fatalf("write policies: %v", err)
+	}
+	if err := os.WriteFile(span, []byte(`{"name": "GET /items", "resource": {"service.name": {"type": "string", "value": "api"}}}`), 0o644); err != nil {
+		t.Fatalf("write span: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := runChaosCommand([]string{"explain", "--chaos-policies-file=" + policies, "--span-file=" + span}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	if !strings.Contains(out, `Span "GET /items" matches 0 of 1 policies`) || !strings.Contains(out, `service_name: got "api", want "checkout"`) {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+}
+
+func TestRunChaosCommandRequiresFiles(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := runChaosCommand([]string{"explain"}, &stdout, &stderr); code != 2 {
+		t.Fatalf("expected usage exit code 2, got %d", code)
+	}
+	if code := runChaosCommand(nil, &stdout, &stderr); code != 2 {
+		t.Fatalf("expected usage exit code 2, got %d", code)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"github.com/javiermolinar/tercios/internal/config"
+)
+
+// valueFromFile assigns value to target unless one of the named flags was
+// set on the command line. CLI flags always take precedence over the
+// config file.
+func valueFromFile[T any](isFlagSet func(string) bool, target *T, value T, names ...string) {
+	for _, name := range names {
+		if isFlagSet(name) {
+			return
+		}
+	}
+	*target = value
+}
+
+// mergeHeaders layers CLI headers over config file headers key by key.
+func mergeHeaders(fromFile map[string]string, fromFlags map[string]string) map[string]string {
+	out := make(map[string]string, len(fromFile)+len(fromFlags))
+	for key, value := range fromFile {
+		out[key] = value
+	}
+	for key, value := range fromFlags {
+		out[key] = value
+	}
+	return out
+}
+
+// fileSettings points at the CLI variables a config file can populate.
+type fileSettings struct {
+	endpoint               *string
+	protocol               *string
+	insecure               *bool
+	tlsCACert              *string
+	tlsSkipVerify          *bool
+	tlsClientCert          *string
+	tlsClientKey           *string
+	tlsServerName          *string
+	compression            *string
+	resourceGrouping       *string
+	exporters              *int
+	requestsPerExporter    *int
+	requestIntervalSeconds *float64
+	requestForSeconds      *float64
+	rampUpSeconds          *float64
+	rampWorkersSeconds     *float64
+	totalSpans             *int64
+	arrivalRate            *float64
+	spanRate               *float64
+	maxInFlight            *int
+	exportTimeoutSeconds   *float64
+	exportRetries          *int
+	retryBackoffSeconds    *float64
+	loadProfile            *string
+	scenarioStrategy       *string
+	scenarioRunSeed        *int64
+	idGenerator            *string
+	chaosPoliciesFile      *string
+	chaosSeed              *int64
+	synthetics             *bool
+	healthIntervalSeconds  *float64
+	logFile                *string
+	logMaxBytes            *int64
+	logBackups             *int
+	alertAfterSeconds      *float64
+	alertWebhook           *string
+}
+
+func applyConfigFile(cfg config.Config, isFlagSet func(string) bool, settings fileSettings) {
+	valueFromFile(isFlagSet, settings.endpoint, cfg.Endpoint.Address, "endpoint")
+	valueFromFile(isFlagSet, settings.protocol, string(cfg.Endpoint.Protocol), "protocol")
+	valueFromFile(isFlagSet, settings.insecure, cfg.Endpoint.Insecure, "insecure")
+	if !isFlagSet("tls-ca") {
+		valueFromFile(isFlagSet, settings.tlsCACert, cfg.Endpoint.TLSCACert, "tls-ca-cert")
+	}
+	if !isFlagSet("tls-insecure-skip-verify") {
+		valueFromFile(isFlagSet, settings.tlsSkipVerify, cfg.Endpoint.TLSSkipVerify, "tls-skip-verify")
+	}
+	valueFromFile(isFlagSet, settings.tlsClientCert, cfg.Endpoint.TLSClientCert, "tls-cert")
+	valueFromFile(isFlagSet, settings.tlsClientKey, cfg.Endpoint.TLSClientKey, "tls-key")
+	valueFromFile(isFlagSet, settings.tlsServerName, cfg.Endpoint.TLSServerName, "tls-server-name")
+	valueFromFile(isFlagSet, settings.compression, string(cfg.Endpoint.Compression), "compression")
+	valueFromFile(isFlagSet, settings.resourceGrouping, string(cfg.Endpoint.ResourceGrouping), "resource-grouping")
+	valueFromFile(isFlagSet, settings.exporters, cfg.Concurrency.Exporters, "exporters")
+	valueFromFile(isFlagSet, settings.requestsPerExporter, cfg.Requests.PerExporter, "max-requests")
+	valueFromFile(isFlagSet, settings.requestIntervalSeconds, cfg.Requests.Interval.Seconds(), "request-interval")
+	valueFromFile(isFlagSet, settings.requestForSeconds, cfg.Requests.For.Seconds(), "for")
+	valueFromFile(isFlagSet, settings.rampUpSeconds, cfg.Requests.RampUp.Seconds(), "ramp-up")
+	valueFromFile(isFlagSet, settings.rampWorkersSeconds, cfg.Requests.RampWorkers.Seconds(), "ramp-workers")
+	valueFromFile(isFlagSet, settings.totalSpans, cfg.Requests.TotalSpans, "total-spans")
+	valueFromFile(isFlagSet, settings.arrivalRate, cfg.Requests.ArrivalRate, "arrival-rate")
+	valueFromFile(isFlagSet, settings.spanRate, cfg.Requests.SpanRate, "span-rate")
+	valueFromFile(isFlagSet, settings.maxInFlight, cfg.Requests.MaxInFlight, "max-in-flight")
+	valueFromFile(isFlagSet, settings.exportTimeoutSeconds, cfg.Requests.ExportTimeout.Seconds(), "export-timeout")
+	valueFromFile(isFlagSet, settings.exportRetries, cfg.Requests.Retries, "export-retries")
+	valueFromFile(isFlagSet, settings.retryBackoffSeconds, cfg.Requests.RetryBackoff.Seconds(), "retry-backoff")
+	valueFromFile(isFlagSet, settings.loadProfile, cfg.Requests.Profile, "profile")
+	if cfg.Scenario.Strategy != "" {
+		valueFromFile(isFlagSet, settings.scenarioStrategy, cfg.Scenario.Strategy, "scenario-strategy")
+	}
+	valueFromFile(isFlagSet, settings.scenarioRunSeed, cfg.Scenario.RunSeed, "scenario-run-seed")
+	if cfg.Scenario.IDGenerator != "" {
+		valueFromFile(isFlagSet, settings.idGenerator, cfg.Scenario.IDGenerator, "id-generator")
+	}
+	valueFromFile(isFlagSet, settings.chaosPoliciesFile, cfg.Chaos.PoliciesFile, "chaos-policies-file")
+	valueFromFile(isFlagSet, settings.chaosSeed, cfg.Chaos.Seed, "chaos-seed")
+	valueFromFile(isFlagSet, settings.synthetics, cfg.Synthetics.Enabled, "synthetics")
+	valueFromFile(isFlagSet, settings.logFile, cfg.Synthetics.LogFile, "log-file")
+	valueFromFile(isFlagSet, settings.alertWebhook, cfg.Synthetics.AlertWebhook, "alert-webhook")
+	// Zero keeps the flag defaults, which are not zero.
+	if cfg.Synthetics.HealthInterval.Duration > 0 {
+		valueFromFile(isFlagSet, settings.healthIntervalSeconds, cfg.Synthetics.HealthInterval.Seconds(), "health-interval")
+	}
+	if cfg.Synthetics.LogMaxBytes > 0 {
+		valueFromFile(isFlagSet, settings.logMaxBytes, cfg.Synthetics.LogMaxBytes, "log-max-bytes")
+	}
+	if cfg.Synthetics.LogBackups > 0 {
+		valueFromFile(isFlagSet, settings.logBackups, cfg.Synthetics.LogBackups, "log-backups")
+	}
+	if cfg.Synthetics.AlertAfter.Duration > 0 {
+		valueFromFile(isFlagSet, settings.alertAfterSeconds, cfg.Synthetics.AlertAfter.Seconds(), "alert-after")
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/config"
+)
+
+func TestApplyConfigFile_FlagsOverrideFileValues(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Endpoint.Address = "file-endpoint:4317"
+	cfg.Concurrency.Exporters = 8
+	cfg.Requests.Interval = config.Duration{Duration: 500 * time.Millisecond}
+	cfg.Scenario.Strategy = "random"
+	cfg.Chaos.Seed = 99
+	cfg.Requests.Retries = 3
+	cfg.Requests.RampWorkers = config.Duration{Duration: 20 * time.Second}
+	cfg.Requests.SpanRate = 5000
+	cfg.Scenario.IDGenerator = "sequential"
+	cfg.Synthetics = config.SyntheticsConfig{Enabled: true, LogFile: "/var/log/tercios.log", LogBackups: 5, AlertWebhook: "http://alerts"}
+
+	endpoint := "flag-endpoint:4317"
+	exporters := 1
+	interval := 0.0
+	strategy := "round-robin"
+	idGenerator := "seeded"
+	chaosSeed := int64(0)
+	var (
+		protocol, tlsCACert, chaosFile, profile  string
+		tlsClientCert, tlsClientKey, serverName  string
+		compression, resourceGrouping            string
+		insecure, skipVerify                     bool
+		perExporter, retries, maxInFlight        int
+		forSeconds, rampUpSeconds, exportTimeout float64
+		retryBackoff, rampWorkers, arrivalRate   float64
+		spanRate                                 float64
+		runSeed, totalSpans                      int64
+		synthetics                               bool
+		logFile, alertWebhook                    string
+	)
+	healthInterval := 60.0
+	logMaxBytes := int64(10 << 20)
+	logBackups := 3
+	alertAfter := 300.0
+
+	applyConfigFile(cfg, flagSetFn("endpoint"), fileSettings{
+		endpoint:               &endpoint,
+		protocol:               &protocol,
+		insecure:               &insecure,
+		tlsCACert:              &tlsCACert,
+		tlsSkipVerify:          &skipVerify,
+		tlsClientCert:          &tlsClientCert,
+		tlsClientKey:           &tlsClientKey,
+		tlsServerName:          &serverName,
+		compression:            &compression,
+		resourceGrouping:       &resourceGrouping,
+		exporters:              &exporters,
+		requestsPerExporter:    &perExporter,
+		requestIntervalSeconds: &interval,
+		requestForSeconds:      &forSeconds,
+		rampUpSeconds:          &rampUpSeconds,
+		rampWorkersSeconds:     &rampWorkers,
+		totalSpans:             &totalSpans,
+		arrivalRate:            &arrivalRate,
+		spanRate:               &spanRate,
+		maxInFlight:            &maxInFlight,
+		exportTimeoutSeconds:   &exportTimeout,
+		exportRetries:          &retries,
+		retryBackoffSeconds:    &retryBackoff,
+		loadProfile:            &profile,
+		scenarioStrategy:       &strategy,
+		scenarioRunSeed:        &runSeed,
+		idGenerator:            &idGenerator,
+		chaosPoliciesFile:      &chaosFile,
+		chaosSeed:              &chaosSeed,
+		synthetics:             &synthetics,
+		healthIntervalSeconds:  &healthInterval,
+		logFile:                &logFile,
+		logMaxBytes:            &logMaxBytes,
+		logBackups:             &logBackups,
+		alertAfterSeconds:      &alertAfter,
+		alertWebhook:           &alertWebhook,
+	})
+
+	if endpoint != "flag-endpoint:4317" {
+		t.Fatalf("expected endpoint from flag, got %q", endpoint)
+	}
+	if exporters != 8 {
+		t.Fatalf("expected exporters from file, got %d", exporters)
+	}
+	if interval != 0.5 {
+		t.Fatalf("expected interval 0.5s from file, got %v", interval)
+	}
+	if strategy != "random" {
+		t.Fatalf("expected strategy from file, got %q", strategy)
+	}
+	if idGenerator != "sequential" {
+		t.Fatalf("expected ID generator from file, got %q", idGenerator)
+	}
+	if chaosSeed != 99 {
+		t.Fatalf("expected chaos seed from file, got %d", chaosSeed)
+	}
+	if retries != 3 {
+		t.Fatalf("expected retries from file, got %d", retries)
+	}
+	if spanRate != 5000 {
+		t.Fatalf("expected span rate from file, got %v", spanRate)
+	}
+	if rampWorkers != 20 {
+		t.Fatalf("expected ramp-workers 20s from file, got %v", rampWorkers)
+	}
+	if resourceGrouping != "resource" {
+		t.Fatalf("expected default resource grouping from file, got %q", resourceGrouping)
+	}
+	if exportTimeout != 10 {
+		t.Fatalf("expected default export timeout from file, got %v", exportTimeout)
+	}
+	if !synthetics || logFile != "/var/log/tercios.log" || logBackups != 5 || alertWebhook != "http://alerts" {
+		t.Fatalf("expected synthetics settings from file, got enabled=%v file=%q backups=%d webhook=%q", synthetics, logFile, logBackups, alertWebhook)
+	}
+	if healthInterval != 60 || logMaxBytes != 10<<20 || alertAfter != 300 {
+		t.Fatalf("expected unset synthetics fields to keep flag defaults, got %v, %d and %v", healthInterval, logMaxBytes, alertAfter)
+	}
+}
+
+func TestMergeHeaders_FlagsWinPerKey(t *testing.T) {
+	got := mergeHeaders(
+		map[string]string{"Authorization": "file", "X-Scope-OrgID": "tenant-a"},
+		map[string]string{"Authorization": "flag"},
+	)
+	if got["Authorization"] != "flag" {
+		t.Fatalf("expected flag header to win, got %q", got["Authorization"])
+	}
+	if got["X-Scope-OrgID"] != "tenant-a" {
+		t.Fatalf("expected file header to be kept, got %q", got["X-Scope-OrgID"])
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/config"
+	"github.com/javiermolinar/tercios/internal/demo"
+	"github.com/javiermolinar/tercios/internal/scenario"
+)
+
+// demoMaxTraces is the number of traces the demo sink keeps in memory.
+const demoMaxTraces = 1000
+
+// runDemoCommand handles `tercios demo` and returns the process exit code.
+// It starts an in-memory OTLP sink with a web view on a local port, sends
+// it a short run over OTLP/HTTP, and keeps the web view up until ctx is
+// cancelled, so tercios can be tried without a collector.
+func runDemoCommand(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("tercios demo", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	listen := flags.String("listen", "127.0.0.1:0", "address of the sink and its web view; port 0 picks a free port")
+	var scenarioFiles scenario.FileFlags
+	flags.Var(&scenarioFiles, "scenario-file", "path to scenario JSON or YAML file; repeatable (default: the embedded scenario)")
+	flags.Var(&scenarioFiles, "s", "path to scenario JSON or YAML file (shorthand); repeatable")
+	forSeconds := flags.Float64("for", 10, "seconds to send traces for")
+	intervalSeconds := flags.Float64("request-interval", 0.2, "seconds between requests")
+	serve := flags.Bool("serve", true, "keep the web view up after the run until interrupted")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *forSeconds <= 0 {
+		_, _ = fmt.Fprintln(stderr, "demo requires --for > 0")
+		return 2
+	}
+
+	listener, err := net.Listen("tcp", *listen)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "start sink: %v\n", err)
+		return 1
+	}
+	sink := demo.NewSink(demoMaxTraces)
+	server := &http.Server{Handler: sink, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			_, _ = fmt.Fprintf(stderr, "sink: %v\n", err)
+		}
+	}()
+	defer func() { _ = server.Close() }()
+
+	base := "http://" + listener.Addr().String()
+	cfg := config.DefaultConfig()
+	cfg.Endpoint.Protocol = config.ProtocolHTTP
+	cfg.Endpoint.Address = base + demo.TracesPath
+	cfg.Endpoint.Insecure = true
+	cfg.Scenario.Files = scenarioFiles.Values()
+	cfg.Requests.PerExporter = 0
+	cfg.Requests.For.Duration = time.Duration(*forSeconds * float64(time.Second))
+	cfg.Requests.Interval.Duration = time.Duration(*intervalSeconds * float64(time.Second))
+	if err := cfg.Validate(); err != nil {
+		_, _ = fmt.Fprintf(stderr, "invalid config: %v\n", err)
+		return 1
+	}
+
+	_, _ = fmt.Fprintf(stdout, "Sink listening on %s\nWeb view: %s/\n\nSending traces for %s...\n", cfg.Endpoint.Address, base, cfg.Requests.For.Duration)
+	settings := runSettings{tracesPerRequest: 1}
+	pipe, factory, err := prepareRun(ctx, cfg, settings)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "%v\n", err)
+		return 1
+	}
+	summary, err := executeRun(ctx, pipe, factory, cfg, settings)
+	if err != nil && ctx.Err() == nil {
+		_, _ = fmt.Fprintf(stderr, "demo run failed: %v\n", err)
+		return 1
+	}
+	requests, spans := sink.Stats()
+	_, _ = fmt.Fprintf(stdout, "Sent %d spans; the sink received %d spans in %d requests.\n", summary.SuccessfulSpans, spans, requests)
+
+	if *serve && ctx.Err() == nil {
+		_, _ = fmt.Fprintf(stdout, "\nBrowse the traces at %s/ and press Ctrl-C to stop.\n", base)
+		<-ctx.Done()
+	}
+	return 0
+}
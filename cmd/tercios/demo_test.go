@@ -0,0 +1,23 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRunDemoCommandSendsTracesToTheSink(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := runDemoCommand(context.Background(), []string{"--for=0.3", "--request-interval=0.05", "--serve=false"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code = %d, stderr = %s", code, stderr.String())
+	}
+	out := stdout.String()
+	if !strings.Contains(out, "Web view: http://127.0.0.1:") || !strings.Contains(out, "the sink received") {
+		t.Fatalf("stdout = %q", out)
+	}
+	if strings.Contains(out, "received 0 spans") {
+		t.Fatalf("expected the sink to receive spans, stdout = %q", out)
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/config"
+	"github.com/javiermolinar/tercios/internal/estimate"
+	"github.com/javiermolinar/tercios/internal/loadprofile"
+	"github.com/javiermolinar/tercios/internal/model"
+	"github.com/javiermolinar/tercios/internal/scenario"
+)
+
+// runEstimateCommand handles `tercios estimate` and returns the process
+// exit code. It builds the same pipeline a dry run would, so scenarios and
+// chaos policies shape the sampled batches, but exports nothing.
+func runEstimateCommand(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("tercios estimate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	configFile := flags.String("config", "", "path to a JSON or YAML run configuration file; explicitly set flags override its values")
+	var scenarioFiles scenario.FileFlags
+	flags.Var(&scenarioFiles, "scenario-file", "path to scenario JSON or YAML file; repeatable")
+	flags.Var(&scenarioFiles, "s", "path to scenario JSON or YAML file (shorthand); repeatable")
+	chaosPoliciesFile := flags.String("chaos-policies-file", "", "path to chaos policies JSON or YAML file")
+	exporters := flags.Int("exporters", 0, "number of concurrent exporters")
+	requestsPerExporter := flags.Int("max-requests", 0, "requests per exporter (0 for no request limit)")
+	requestIntervalSeconds := flags.Float64("request-interval", 0, "seconds between requests per exporter")
+	requestForSeconds := flags.Float64("for", 0, "run duration in seconds")
+	loadProfile := flags.String("profile", "", "load profile shaping the total request rate over time (see tercios --help)")
+	tracesPerRequest := flags.Int("traces-per-request", 1, "generated traces sent in each request")
+	samples := flags.Int("samples", 100, "number of batches generated to measure spans and bytes per request")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *tracesPerRequest <= 0 {
+		_, _ = fmt.Fprintln(stderr, "estimate requires --traces-per-request > 0")
+		return 2
+	}
+	if *samples <= 0 {
+		_, _ = fmt.Fprintln(stderr, "estimate requires --samples > 0")
+		return 2
+	}
+
+	cfg := config.DefaultConfig()
+	if *configFile != "" {
+		fileCfg, err := config.LoadFromFile(*configFile)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "invalid config file: %v\n", err)
+			return 1
+		}
+		cfg = fileCfg
+	}
+	isFlagSet := func(name string) bool {
+		set := false
+		flags.Visit(func(f *flag.Flag) { set = set || f.Name == name })
+		return set
+	}
+	if isFlagSet("scenario-file") || isFlagSet("s") {
+		cfg.Scenario.Files = scenarioFiles.Values()
+	}
+	valueFromFlag(isFlagSet, &cfg.Chaos.PoliciesFile, *chaosPoliciesFile, "chaos-policies-file")
+	valueFromFlag(isFlagSet, &cfg.Concurrency.Exporters, *exporters, "exporters")
+	valueFromFlag(isFlagSet, &cfg.Requests.PerExporter, *requestsPerExporter, "max-requests")
+	valueFromFlag(isFlagSet, &cfg.Requests.Interval, config.Duration{Duration: time.Duration(*requestIntervalSeconds * float64(time.Second))}, "request-interval")
+	valueFromFlag(isFlagSet, &cfg.Requests.For, config.Duration{Duration: time.Duration(*requestForSeconds * float64(time.Second))}, "for")
+	valueFromFlag(isFlagSet, &cfg.Requests.Profile, *loadProfile, "profile")
+	if err := cfg.Validate(); err != nil {
+		_, _ = fmt.Fprintf(stderr, "invalid config: %v\n", err)
+		return 1
+	}
+
+	plan := estimate.Plan{
+		Exporters:   cfg.Concurrency.Exporters,
+		PerExporter: cfg.Requests.PerExporter,
+		Interval:    cfg.Requests.Interval.Duration,
+		For:         cfg.Requests.For.Duration,
+	}
+	if cfg.Requests.Profile != "" {
+		profile, err := loadprofile.Parse(cfg.Requests.Profile)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "invalid load profile: %v\n", err)
+			return 1
+		}
+		plan.Profile = &profile
+	}
+
+	ctx := context.Background()
+	pipe, _, err := prepareRun(ctx, cfg, runSettings{dryRun: true, tracesPerRequest: *tracesPerRequest})
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "%v\n", err)
+		return 1
+	}
+	result, err := estimate.Run(ctx, plan, *samples, func(ctx context.Context) ([]model.Span, error) {
+		return pipe.Process(ctx, nil)
+	})
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "estimate failed: %v\n", err)
+		return 1
+	}
+	_, _ = fmt.Fprintln(stdout, formatEstimate(result))
+	return 0
+}
+
+// valueFromFlag is the inverse of valueFromFile: the flag value wins only
+// when the flag was set.
+func valueFromFlag[T any](isFlagSet func(string) bool, target *T, value T, name string) {
+	if isFlagSet(name) {
+		*target = value
+	}
+}
+
+func formatEstimate(result estimate.Result) string {
+	duration := "depends on endpoint latency (unpaced)"
+	if result.Duration > 0 {
+		duration = result.Duration.String()
+	}
+	lines := []string{
+		fmt.Sprintf("Estimated from %d sampled batches:", result.Samples),
+		fmt.Sprintf("  Requests: %d", result.Requests),
+		fmt.Sprintf("  Duration: %s", duration),
+		fmt.Sprintf("  Spans: %.0f (%.1f per request)", result.Spans(), result.SpansPerRequest),
+		fmt.Sprintf("  OTLP protobuf: %s (%s per request)", formatBytes(result.Bytes()), formatBytes(result.BytesPerRequest)),
+		fmt.Sprintf("  OTLP protobuf, gzip: %s (%s per request)", formatBytes(result.GzipBytes()), formatBytes(result.GzipBytesPerRequest)),
+	}
+	if result.Duration > 0 {
+		seconds := result.Duration.Seconds()
+		lines = append(lines, fmt.Sprintf("  Rate: %.1f spans/s, %s/s (%s/s gzip)",
+			result.Spans()/seconds, formatBytes(result.Bytes()/seconds), formatBytes(result.GzipBytes()/seconds)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatBytes uses decimal units, the ones backend and cloud pricing use.
+func formatBytes(value float64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+	unit := 0
+	for value >= 1000 && unit < len(units)-1 {
+		value /= 1000
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%.0f %s", value, units[unit])
+	}
+	return fmt.Sprintf("%.1f %s", value, units[unit])
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRunEstimateCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := runEstimateCommand([]string{"--exporters=10", "--request-interval=0.5", "--for=3600", "--max-requests=0", "--samples=5"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	for _, want := range []string{"Estimated from 5 sampled batches:", "Requests: 72000", "Duration: 1h0m0s", "OTLP protobuf, gzip:", "Rate:"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output:\n%s", want, out)
+		}
+	}
+}
+
+func TestRunEstimateCommandRejectsUnboundedRun(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := runEstimateCommand([]string{"--max-requests=0"}, &stdout, &stderr); code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "no fixed volume") {
+		t.Fatalf("unexpected stderr %q", stderr.String())
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[float64]string{512: "512 B", 4830: "4.8 KB", 348_400_000: "348.4 MB", 2.5e12: "2.5 TB"}
+	for value, want := range tests {
+		if got := formatBytes(value); got != want {
+			t.Fatalf("formatBytes(%v) = %q, want %q", value, got, want)
+		}
+	}
+}
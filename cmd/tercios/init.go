@@ -0,0 +1,348 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/config"
+	"github.com/javiermolinar/tercios/internal/otlp"
+	"gopkg.in/yaml.v3"
+)
+
+// initCheckTimeout bounds the connectivity check of `tercios init`.
+const initCheckTimeout = 5 * time.Second
+
+// runInitCommand handles `tercios init` and returns the process exit code.
+// It asks for the endpoint, protocol, authentication and load of a run,
+// checks that the collector accepts an export, and writes the answers as
+// a run configuration file for --config.
+func runInitCommand(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("tercios init", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	output := flags.String("output", "tercios.yaml", "configuration file to write; JSON when it ends in .json, YAML otherwise")
+	force := flags.Bool("force", false, "overwrite the output file if it exists")
+	skipCheck := flags.Bool("skip-check", false, "do not test the connection to the collector")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 0 {
+		_, _ = fmt.Fprintln(stderr, "usage: tercios init [--output=FILE] [--force] [--skip-check]")
+		return 2
+	}
+	if _, err := os.Stat(*output); err == nil && !*force {
+		_, _ = fmt.Fprintf(stderr, "%s already exists; pass --force to overwrite it\n", *output)
+		return 1
+	}
+
+	p := &prompter{in: bufio.NewScanner(stdin), out: stdout}
+	cfg, err := askRunConfig(p)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "init: %v\n", err)
+		return 1
+	}
+
+	if !*skipCheck {
+		_, _ = fmt.Fprintf(stdout, "\nTesting the connection to %s...\n", cfg.Endpoint.Address)
+		if err := checkConnectivity(cfg); err != nil {
+			_, _ = fmt.Fprintf(stdout, "Connection failed: %v\n", err)
+			save, err := p.askBool("Save the configuration anyway?", false)
+			if err != nil {
+				_, _ = fmt.Fprintf(stderr, "init: %v\n", err)
+				return 1
+			}
+			if !save {
+				return 1
+			}
+		} else {
+			_, _ = fmt.Fprintln(stdout, "Connection OK")
+		}
+	}
+
+	data, err := encodeRunConfig(cfg, *output)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "init: %v\n", err)
+		return 1
+	}
+	if err := os.WriteFile(*output, data, 0o600); err != nil {
+		_, _ = fmt.Fprintf(stderr, "write configuration: %v\n", err)
+		return 1
+	}
+	_, _ = fmt.Fprintf(stdout, "\nWrote %s. Start the run with:\n\n  tercios --config=%s\n", *output, *output)
+	if len(cfg.Endpoint.Headers) > 0 {
+		_, _ = fmt.Fprintln(stdout, "\nThe file holds your credentials; keep it out of version control.")
+	}
+	return 0
+}
+
+// askRunConfig asks every question of the wizard and returns the run
+// configuration the answers describe.
+func askRunConfig(p *prompter) (config.Config, error) {
+	cfg := config.DefaultConfig()
+
+	protocol, err := p.askChoice("Protocol", []string{"grpc", "http", "zipkin"}, "grpc")
+	if err != nil {
+		return config.Config{}, err
+	}
+	cfg.Endpoint.Protocol = config.Protocol(protocol)
+
+	defaultAddress := map[string]string{
+		"grpc":   "localhost:4317",
+		"http":   "http://localhost:4318/v1/traces",
+		"zipkin": "http://localhost:9411",
+	}[protocol]
+	if cfg.Endpoint.Address, err = p.ask("Collector endpoint", defaultAddress); err != nil {
+		return config.Config{}, err
+	}
+	if cfg.Endpoint.Protocol == config.ProtocolGRPC {
+		host, _, err := net.SplitHostPort(strings.TrimPrefix(cfg.Endpoint.Address, "grpcs://"))
+		if err != nil {
+			host = cfg.Endpoint.Address
+		}
+		local := host == "localhost" || host == "127.0.0.1" || host == "::1"
+		tls, err := p.askBool("Use TLS?", !local)
+		if err != nil {
+			return config.Config{}, err
+		}
+		cfg.Endpoint.Insecure = !tls
+	} else {
+		cfg.Endpoint.Insecure = !strings.HasPrefix(cfg.Endpoint.Address, "https://")
+	}
+
+	auth, err := p.askChoice("Authentication", []string{"none", "bearer", "basic", "header"}, "none")
+	if err != nil {
+		return config.Config{}, err
+	}
+	switch auth {
+	case "bearer":
+		token, err := p.askRequired("Bearer token")
+		if err != nil {
+			return config.Config{}, err
+		}
+		cfg.Endpoint.Headers["Authorization"] = "Bearer " + token
+	case "basic":
+		user, err := p.askRequired("Username (e.g. the instance ID)")
+		if err != nil {
+			return config.Config{}, err
+		}
+		password, err := p.askRequired("Password or API token")
+		if err != nil {
+			return config.Config{}, err
+		}
+		cfg.Endpoint.Headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password))
+	case "header":
+		for {
+			header, err := p.askRequired("Header (Key=Value)")
+			if err != nil {
+				return config.Config{}, err
+			}
+			var headers config.HeaderFlags
+			if err := headers.Set(header); err != nil {
+				_, _ = fmt.Fprintf(p.out, "  %v\n", err)
+				continue
+			}
+			for key, value := range headers.Values() {
+				cfg.Endpoint.Headers[key] = value
+			}
+			more, err := p.askBool("Add another header?", false)
+			if err != nil {
+				return config.Config{}, err
+			}
+			if !more {
+				break
+			}
+		}
+	}
+
+	if cfg.Concurrency.Exporters, err = p.askInt("Concurrent exporters", 1, 1); err != nil {
+		return config.Config{}, err
+	}
+	interval, err := p.askFloat("Seconds between requests of each exporter (0 sends as fast as possible)", 1)
+	if err != nil {
+		return config.Config{}, err
+	}
+	cfg.Requests.Interval.Duration = time.Duration(interval * float64(time.Second))
+	duration, err := p.askFloat("Run duration in seconds (0 to stop after a number of requests)", 60)
+	if err != nil {
+		return config.Config{}, err
+	}
+	cfg.Requests.For.Duration = time.Duration(duration * float64(time.Second))
+	cfg.Requests.PerExporter = 0
+	if duration == 0 {
+		if cfg.Requests.PerExporter, err = p.askInt("Requests per exporter", 10, 1); err != nil {
+			return config.Config{}, err
+		}
+	}
+	if interval > 0 {
+		_, _ = fmt.Fprintf(p.out, "That is about %.4g requests per second.\n", float64(cfg.Concurrency.Exporters)/interval)
+	}
+	return cfg, cfg.Validate()
+}
+
+// checkConnectivity sends one empty export to the endpoint of cfg, like
+// the preflight check of a run.
+func checkConnectivity(cfg config.Config) error {
+	ctx, cancel := context.WithTimeout(context.Background(), initCheckTimeout)
+	defer cancel()
+	factory := otlp.ExporterFactory{
+		Protocol:      cfg.Endpoint.Protocol,
+		Endpoint:      cfg.Endpoint.Address,
+		Insecure:      cfg.Endpoint.Insecure,
+		Headers:       cfg.Endpoint.Headers,
+		ExportTimeout: initCheckTimeout,
+	}
+	return otlp.RunPreflight(ctx, factory, initCheckTimeout)
+}
+
+// encodeRunConfig returns the file content for cfg: only the fields the
+// wizard asks about, as JSON or YAML depending on the extension of path.
+// The content is decoded back to make sure --config accepts it.
+func encodeRunConfig(cfg config.Config, path string) ([]byte, error) {
+	endpoint := map[string]any{
+		"address":  cfg.Endpoint.Address,
+		"protocol": cfg.Endpoint.Protocol,
+		"insecure": cfg.Endpoint.Insecure,
+	}
+	if len(cfg.Endpoint.Headers) > 0 {
+		endpoint["headers"] = cfg.Endpoint.Headers
+	}
+	document := map[string]any{
+		"endpoint":    endpoint,
+		"concurrency": map[string]any{"exporters": cfg.Concurrency.Exporters},
+		"requests": map[string]any{
+			"per_exporter": cfg.Requests.PerExporter,
+			"interval":     cfg.Requests.Interval.String(),
+			"for":          cfg.Requests.For.String(),
+		},
+	}
+	data, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := config.DecodeJSON(bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("generated configuration is invalid: %w", err)
+	}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return append(data, '\n'), nil
+	}
+	var generic any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(generic)
+}
+
+// prompter asks questions on out and reads the answers from in, one per
+// line. An empty answer takes the default shown in brackets.
+type prompter struct {
+	in  *bufio.Scanner
+	out io.Writer
+}
+
+var errInputClosed = errors.New("input closed before every question was answered")
+
+func (p *prompter) ask(question, defaultValue string) (string, error) {
+	if defaultValue != "" {
+		_, _ = fmt.Fprintf(p.out, "%s [%s]: ", question, defaultValue)
+	} else {
+		_, _ = fmt.Fprintf(p.out, "%s: ", question)
+	}
+	if !p.in.Scan() {
+		if err := p.in.Err(); err != nil {
+			return "", err
+		}
+		return "", errInputClosed
+	}
+	answer := strings.TrimSpace(p.in.Text())
+	if answer == "" {
+		return defaultValue, nil
+	}
+	return answer, nil
+}
+
+func (p *prompter) askRequired(question string) (string, error) {
+	for {
+		answer, err := p.ask(question, "")
+		if err != nil || answer != "" {
+			return answer, err
+		}
+		_, _ = fmt.Fprintln(p.out, "  a value is required")
+	}
+}
+
+func (p *prompter) askChoice(question string, choices []string, defaultValue string) (string, error) {
+	for {
+		answer, err := p.ask(fmt.Sprintf("%s (%s)", question, strings.Join(choices, ", ")), defaultValue)
+		if err != nil {
+			return "", err
+		}
+		answer = strings.ToLower(answer)
+		if slices.Contains(choices, answer) {
+			return answer, nil
+		}
+		_, _ = fmt.Fprintf(p.out, "  choose one of %s\n", strings.Join(choices, ", "))
+	}
+}
+
+func (p *prompter) askBool(question string, defaultValue bool) (bool, error) {
+	hint := "y/N"
+	if defaultValue {
+		hint = "Y/n"
+	}
+	for {
+		answer, err := p.ask(question+" ("+hint+")", "")
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return defaultValue, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+		_, _ = fmt.Fprintln(p.out, "  answer y or n")
+	}
+}
+
+func (p *prompter) askInt(question string, defaultValue, minValue int) (int, error) {
+	for {
+		answer, err := p.ask(question, strconv.Itoa(defaultValue))
+		if err != nil {
+			return 0, err
+		}
+		n, err := strconv.Atoi(answer)
+		if err == nil && n >= minValue {
+			return n, nil
+		}
+		_, _ = fmt.Fprintf(p.out, "  enter a whole number >= %d\n", minValue)
+	}
+}
+
+func (p *prompter) askFloat(question string, defaultValue float64) (float64, error) {
+	for {
+		answer, err := p.ask(question, strconv.FormatFloat(defaultValue, 'g', -1, 64))
+		if err != nil {
+			return 0, err
+		}
+		n, err := strconv.ParseFloat(answer, 64)
+		if err == nil && n >= 0 {
+			return n, nil
+		}
+		_, _ = fmt.Fprintln(p.out, "  enter a number >= 0")
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/config"
+)
+
+func TestRunInitCommandWritesTestedConfig(t *testing.T) {
+	var authorization string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+
+	output := filepath.Join(t.TempDir(), "tercios.yaml")
+	answers := strings.Join([]string{
+		"http",                       // protocol
+		collector.URL + "/v1/traces", // endpoint
+		"basic", "12345", "secret",   // authentication
+		"4", "0.5", "30", // exporters, interval, duration
+	}, "\n") + "\n"
+	var stdout, stderr bytes.Buffer
+	if code := runInitCommand([]string{"--output=" + output}, strings.NewReader(answers), &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code = %d, stderr = %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Connection OK") {
+		t.Fatalf("stdout = %q, want the connection reported OK", stdout.String())
+	}
+	if want := "Basic MTIzNDU6c2VjcmV0"; authorization != want {
+		t.Fatalf("collector saw Authorization %q, want %q", authorization, want)
+	}
+
+	cfg, err := config.LoadFromFile(output)
+	if err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if cfg.Endpoint.Protocol != config.ProtocolHTTP || !cfg.Endpoint.Insecure || cfg.Endpoint.Headers["Authorization"] != authorization {
+		t.Fatalf("endpoint = %+v", cfg.Endpoint)
+	}
+	if cfg.Concurrency.Exporters != 4 || cfg.Requests.Interval.Duration != 500*time.Millisecond ||
+		cfg.Requests.For.Duration != 30*time.Second || cfg.Requests.PerExporter != 0 {
+		t.Fatalf("load = %+v %+v", cfg.Concurrency, cfg.Requests)
+	}
+
+	if code := runInitCommand([]string{"--output=" + output}, strings.NewReader(answers), &stdout, &stderr); code != 1 {
+		t.Fatalf("exit code for an existing file = %d, want 1", code)
+	}
+}
+
+func TestRunInitCommandDoesNotSaveUnreachableEndpointUnlessAsked(t *testing.T) {
+	collector := httptest.NewServer(http.NotFoundHandler())
+	endpoint := collector.URL + "/v1/traces"
+	collector.Close()
+
+	output := filepath.Join(t.TempDir(), "tercios.json")
+	answers := "http\n" + endpoint + "\nnone\n\n\n0\n5\n"
+	var stdout, stderr bytes.Buffer
+	if code := runInitCommand([]string{"--output=" + output}, strings.NewReader(answers+"n\n"), &stdout, &stderr); code != 1 {
+		t.Fatalf("exit code = %d, want 1", code)
+	}
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Fatalf("expected no file after declining to save, stat error = %v", err)
+	}
+
+	if code := runInitCommand([]string{"--output=" + output}, strings.NewReader(answers+"y\n"), &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code = %d, stderr = %s", code, stderr.String())
+	}
+	cfg, err := config.LoadFromFile(output)
+	if err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if cfg.Requests.PerExporter != 5 || cfg.Requests.For.Duration != 0 {
+		t.Fatalf("requests = %+v, want 5 requests per exporter and no duration", cfg.Requests)
+	}
+}
@@ -2,76 +2,294 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/javiermolinar/tercios/internal/audit"
+	"github.com/javiermolinar/tercios/internal/backendmetrics"
+	"github.com/javiermolinar/tercios/internal/campaign"
 	"github.com/javiermolinar/tercios/internal/chaos"
 	"github.com/javiermolinar/tercios/internal/config"
+	"github.com/javiermolinar/tercios/internal/daemon"
+	"github.com/javiermolinar/tercios/internal/freshness"
+	"github.com/javiermolinar/tercios/internal/idgen"
+	"github.com/javiermolinar/tercios/internal/ingestcheck"
 	"github.com/javiermolinar/tercios/internal/metrics"
 	"github.com/javiermolinar/tercios/internal/otlp"
 	"github.com/javiermolinar/tercios/internal/pipeline"
+	"github.com/javiermolinar/tercios/internal/replay"
+	"github.com/javiermolinar/tercios/internal/reportpush"
 	"github.com/javiermolinar/tercios/internal/scenario"
+	"github.com/javiermolinar/tercios/internal/schema"
+	"github.com/javiermolinar/tercios/internal/synthetics"
+	"github.com/javiermolinar/tercios/internal/timefmt"
+	"github.com/javiermolinar/tercios/pkg/verify"
 )
 
+// syntheticsInterval is the request interval of --synthetics runs that
+// set no pacing of their own: low enough to run 24/7 against production.
+const syntheticsInterval = 10 * time.Second
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "chaos" {
+		os.Exit(runChaosCommand(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "estimate" {
+		os.Exit(runEstimateCommand(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "scenario" {
+		os.Exit(runScenarioCommand(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "schema" {
+		os.Exit(runSchemaCommand(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "preview" {
+		os.Exit(runPreviewCommand(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "demo" {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		code := runDemoCommand(ctx, os.Args[2:], os.Stdout, os.Stderr)
+		stop()
+		os.Exit(code)
+	}
+	if len(os.Args) > 1 && os.Args[1] == "init" {
+		os.Exit(runInitCommand(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+	}
+
 	var (
+		configFile               string
+		campaignFile             string
+		sweep                    string
 		endpoint                 string
 		protocol                 string
 		insecure                 bool
 		tlsCACert                string
 		tlsSkipVerify            bool
+		tlsClientCert            string
+		tlsClientKey             string
+		tlsServerName            string
+		compression              string
+		resourceGrouping         string
 		exporters                int
 		requestsPerExporter      int
 		requestIntervalSeconds   float64
 		requestForSeconds        float64
 		rampUpSeconds            float64
+		rampWorkersSeconds       float64
+		totalSpans               int64
+		arrivalRate              float64
+		spanRate                 float64
+		maxInFlight              int
+		warmup                   bool
 		exportTimeoutSeconds     float64
+		exportRetries            int
+		maxRequestBytes          int
+		oversizeAction           string
+		retryBackoffSeconds      float64
+		loadProfile              string
+		futureFraction           float64
+		futureOffsetSeconds      float64
+		cardinalityAttributes    pipeline.CardinalityFlags
+		attributePrefix          string
+		spanAttributes           pipeline.AttributeFlags
+		resourceAttributes       pipeline.AttributeFlags
+		semconvProfiles          string
+		uniqueSpanNames          bool
+		uniqueNamesPerMinute     float64
+		uniqueResourceAttribute  string
 		scenarioFiles            scenario.FileFlags
 		scenarioStrategy         string
 		scenarioRunSeed          int64
+		idGenerator              string
+		replayFiles              []string
+		replayNewIDs             bool
+		replayNow                bool
+		replaySpeed              string
+		replayLoop               bool
+		replayStream             bool
+		tracesPerRequest         int
+		jitterAttributes         float64
+		jitterSpans              float64
+		jitterSeed               int64
+		seed                     int64
+		eventsPerSpan            int
+		eventsProbability        float64
+		backfillDays             int
+		backfillRequestsPerDay   int
 		chaosPoliciesFile        string
 		chaosSeed                int64
+		chaosMarker              bool
 		dryRun                   bool
 		streaming                bool
+		sdkBatch                 bool
 		output                   string
 		summaryTraceIDs          bool
 		summaryTraceIDsLimit     int
+		summarySlowestRequests   int
+		summaryWorkers           bool
+		reportFile               string
+		reportURL                string
+		reportHeaders            config.HeaderFlags
+		reportIntervalSeconds    float64
+		auditFile                string
+		schemaFile               string
+		schemaFailFast           bool
 		headers                  config.HeaderFlags
+		headerMappings           config.HeaderMappingFlags
 		slowResponseDelaySeconds float64
+		backendMetricsURL        string
+		backendMetricsName       string
+		backendMetricsSettle     float64
+		progressIntervalSeconds  float64
+		alignIntervals           bool
+		verifyBackend            string
+		verifyURL                string
+		freshnessEnabled         bool
+		freshnessSample          float64
+		freshnessTimeout         float64
+		freshnessPollInterval    float64
+		verifyIngestion          bool
+		verifyTimeout            float64
+		verifyMaxTraces          int
+		timeFormat               string
+		timeZone                 string
+		durationUnit             string
+		syntheticsMode           bool
+		healthIntervalSeconds    float64
+		logFile                  string
+		logMaxBytes              int64
+		logBackups               int
+		alertAfterSeconds        float64
+		alertWebhook             string
 	)
 
 	flag.Usage = usage
 	defaults := config.DefaultConfig()
+	flag.StringVar(&configFile, "config", "", "path to a JSON or YAML run configuration file; explicitly set flags override its values")
 	flag.StringVar(&endpoint, "endpoint", defaults.Endpoint.Address, "OTLP endpoint (for HTTP, prefer http(s)://host:port/v1/traces)")
-	flag.StringVar(&protocol, "protocol", string(defaults.Endpoint.Protocol), "OTLP protocol: grpc or http")
+	flag.StringVar(&protocol, "protocol", string(defaults.Endpoint.Protocol), "export protocol: grpc, http (OTLP) or zipkin (Zipkin v2 JSON)")
 	flag.BoolVar(&insecure, "insecure", defaults.Endpoint.Insecure, "send OTLP over plaintext instead of TLS (https/grpcs endpoints default to false)")
 	flag.StringVar(&tlsCACert, "tls-ca-cert", "", "path to PEM CA certificate file for server verification")
+	flag.StringVar(&tlsCACert, "tls-ca", "", "path to PEM CA certificate file for server verification (alias of --tls-ca-cert)")
 	flag.BoolVar(&tlsSkipVerify, "tls-skip-verify", false, "skip TLS certificate verification")
+	flag.BoolVar(&tlsSkipVerify, "tls-insecure-skip-verify", false, "skip TLS certificate verification (alias of --tls-skip-verify)")
+	flag.StringVar(&compression, "compression", string(defaults.Endpoint.Compression), "payload compression for gRPC, HTTP and Zipkin: gzip, zstd or none")
+	flag.StringVar(&resourceGrouping, "resource-grouping", string(defaults.Endpoint.ResourceGrouping), "ResourceSpans layout of OTLP requests: resource (one per distinct resource), span (one per span) or single (all spans under one merged resource)")
+	flag.StringVar(&tlsServerName, "tls-server-name", "", "server name used to verify the collector certificate (defaults to the endpoint host)")
+	flag.StringVar(&tlsClientCert, "tls-cert", "", "path to PEM client certificate presented for mutual TLS (requires --tls-key)")
+	flag.StringVar(&tlsClientKey, "tls-key", "", "path to PEM private key for --tls-cert")
 	flag.IntVar(&exporters, "exporters", defaults.Concurrency.Exporters, "number of concurrent exporters (connections)")
 	flag.IntVar(&requestsPerExporter, "max-requests", defaults.Requests.PerExporter, "requests per exporter (0 for no request limit)")
 	flag.Float64Var(&requestIntervalSeconds, "request-interval", defaults.Requests.Interval.Seconds(), "seconds between requests per exporter (0 for no delay)")
 	flag.Float64Var(&requestForSeconds, "for", defaults.Requests.For.Seconds(), "seconds to send traces per exporter (0 for no duration limit)")
 	flag.Float64Var(&rampUpSeconds, "ramp-up", defaults.Requests.RampUp.Seconds(), "seconds to linearly ramp exporter workers from 0 to max concurrency")
+	flag.Float64Var(&rampWorkersSeconds, "ramp-workers", 0, "seconds to stagger exporter workers over: each opens its connection (and warmup) only at its turn, avoiding a burst of handshakes at start")
+	flag.Int64Var(&totalSpans, "total-spans", 0, "stop the run once this many spans have been exported successfully across all exporters (0 = no span budget; --max-requests and --for still apply)")
+	flag.Float64Var(&arrivalRate, "arrival-rate", 0, "open-loop mode: generate this many requests per second across the run whatever the export latency, so backend slowness shows up as queue wait instead of lower load (0 keeps the closed loop; replaces --request-interval and --profile)")
+	flag.Float64Var(&spanRate, "span-rate", 0, "target spans per second across the run: requests go out at --arrival-rate, or at --exporters requests every --request-interval paced across all workers whatever the export latency, and carry as many whole traces as needed to reach it. The target holds only while the exporters keep up; add --exporters or use --arrival-rate when the summary's span rate falls short (0 sends one trace per request, or --traces-per-request)")
+	flag.IntVar(&maxInFlight, "max-in-flight", 0, "with --arrival-rate, requests allowed to queue or export at once; arrivals beyond it are dropped and counted (0 uses "+strconv.Itoa(pipeline.DefaultMaxInFlight)+")")
+	flag.BoolVar(&warmup, "warmup", false, "open every exporter connection and send one empty export before measuring, so connection setup latency is excluded from the results")
 	flag.Float64Var(&exportTimeoutSeconds, "export-timeout", defaults.Requests.ExportTimeout.Seconds(), "seconds before each export attempt times out; applied to both the pipeline context and the OTLP SDK client (0 disables the pipeline timeout and keeps the SDK default of 10s)")
-	flag.Var(&scenarioFiles, "scenario-file", "path to scenario JSON file; repeatable")
-	flag.Var(&scenarioFiles, "s", "path to scenario JSON file (shorthand); repeatable")
-	flag.StringVar(&scenarioStrategy, "scenario-strategy", string(scenario.SelectionStrategyRoundRobin), "scenario selection strategy when multiple scenarios: round-robin or random")
+	flag.IntVar(&maxRequestBytes, "max-request-bytes", 0, "measure each request as OTLP protobuf and apply --oversize-action to requests over this many bytes, e.g. 4194304 for the collector's default gRPC limit (0 disables)")
+	flag.StringVar(&oversizeAction, "oversize-action", string(otlp.OversizeWarn), "what to do with requests over --max-request-bytes: warn (send anyway) or split (send as several requests, keeping traces whole)")
+	flag.IntVar(&exportRetries, "export-retries", defaults.Requests.Retries, "extra attempts for a failed export; retried spans are reported as potential duplicates and the OTLP SDK's own retries are disabled (0 keeps SDK retries)")
+	flag.Float64Var(&retryBackoffSeconds, "retry-backoff", defaults.Requests.RetryBackoff.Seconds(), "seconds to wait before the first retry, growing linearly per attempt")
+	flag.StringVar(&loadProfile, "profile", "", "load profile shaping the total request rate over time: ramp:FROM-TO/DURATION, step:R1,R2,.../DURATION, spike:BASE-PEAK/PERIOD@SPIKE or sine:MIN-MAX/PERIOD (rates in requests/s; replaces --request-interval)")
+	flag.Var(&scenarioFiles, "scenario-file", "path to scenario JSON or YAML file, or builtin:NAME for an embedded example; repeatable")
+	flag.Var(&scenarioFiles, "s", "path to scenario JSON or YAML file (shorthand); repeatable")
+	flag.StringVar(&scenarioStrategy, "scenario-strategy", string(scenario.SelectionStrategyRoundRobin), "scenario selection strategy when multiple scenarios: round-robin, random or rate (send each scenario's spans_per_second and pace the run by their sum)")
 	flag.Int64Var(&scenarioRunSeed, "scenario-run-seed", 0, "seed namespace for scenario trace/span IDs (0 = auto-random per process)")
-	flag.StringVar(&chaosPoliciesFile, "chaos-policies-file", "", "path to chaos policies JSON file")
+	flag.StringVar(&idGenerator, "id-generator", string(idgen.KindSeeded), "scenario trace/span ID layout: seeded, random, sequential or prefixed (run and worker in the first 6 trace ID bytes)")
+	flag.IntVar(&tracesPerRequest, "traces-per-request", 1, "generated traces sent in each request, for collector-sized payloads of hundreds or thousands of spans")
+	flag.Func("replay-file", "path to an OTLP JSON lines or length-prefixed protobuf (.pb/.binpb) recording, or Jaeger JSON trace export, to re-export instead of generating traces; repeatable", func(value string) error {
+		trimmed := strings.TrimSpace(value)
+		if trimmed == "" {
+			return fmt.Errorf("replay file path cannot be empty")
+		}
+		replayFiles = append(replayFiles, trimmed)
+		return nil
+	})
+	flag.BoolVar(&replayNewIDs, "replay-new-ids", false, "give replayed traces new trace and span IDs, keeping parent and link references consistent")
+	flag.BoolVar(&replayNow, "replay-now", false, "shift each replayed batch so its earliest span starts when it is sent, keeping the recorded offsets between spans")
+	flag.BoolVar(&replayLoop, "replay-loop", false, "replay the recording over and over until --for or --max-requests ends the run, with new IDs and later timestamps on every pass")
+	flag.BoolVar(&replayStream, "replay-stream", false, "read OTLP replay files one request at a time while replaying instead of loading them up front, so memory stays bounded for captures of any size")
+	flag.StringVar(&replaySpeed, "replay-speed", "", "replay the recorded timeline at this speed, e.g. 0.5x, 1x or 2x; max (the default) sends batches as fast as the pacing allows")
+	flag.Float64Var(&jitterAttributes, "jitter-attributes", 0, "relative amount (0-1] numeric span attribute values are randomly moved by per request, e.g. 0.1 for ±10%, to defeat backend caches (0 disables)")
+	flag.Float64Var(&jitterSpans, "jitter-spans", 0, "largest fraction (0-1] of leaf spans randomly dropped per request, so batch sizes vary (0 disables)")
+	flag.Int64Var(&seed, "seed", 0, "seed for every random choice of the run (scenario IDs and selection, jitter, chaos, events, cardinality, replay IDs) so runs are reproducible with any number of exporters; the per-feature seeds still win when set (0 = random per run)")
+	flag.Int64Var(&jitterSeed, "jitter-seed", 0, "seed for --jitter-attributes and --jitter-spans (0 = random per run)")
+	flag.IntVar(&eventsPerSpan, "events-per-span", 0, "synthetic events added to each span: message events on client/server/producer/consumer spans, log events on internal spans, plus an exception event on error spans (0 disables)")
+	flag.Float64Var(&eventsProbability, "events-probability", 1, "chance (0-1] that a span gets --events-per-span events")
+	flag.IntVar(&backfillDays, "backfill-days", 0, "date requests day by day over this many whole UTC days before today, oldest first, to populate retention tests; the run ends once every day is filled (0 disables)")
+	flag.IntVar(&backfillRequestsPerDay, "backfill-requests-per-day", 100, "requests spread evenly over each --backfill-days day")
+	flag.Float64Var(&futureFraction, "future-fraction", 0, "fraction of traces (0-1], chosen by trace ID, sent with timestamps moved --future-offset into the future, to test backend clock validation (0 disables)")
+	flag.Float64Var(&futureOffsetSeconds, "future-offset", 3600, "seconds ahead of generation time that --future-fraction traces are dated")
+	flag.Var(&cardinalityAttributes, "cardinality-attribute", "stress mode: inject span attribute KEY with N distinct values drawn per span, e.g. user.id=100000, or KEY=0 for a unique value per span; repeatable")
+	flag.StringVar(&semconvProfiles, "semconv-profiles", "", "comma-separated semantic convention attribute profiles filled in by span kind: http, db, messaging, rpc, faas (e.g. http,db)")
+	flag.Var(&spanAttributes, "span-attr", "fixed attribute set on every span as key=value:type (type string, int, float or bool; default string), e.g. test.run_id=run-42; repeatable")
+	flag.Var(&resourceAttributes, "resource-attr", "fixed resource attribute set on every span as key=value:type, e.g. deployment.environment=loadtest; repeatable")
+	flag.StringVar(&attributePrefix, "attribute-prefix", "", "prefix added to every attribute key outside the OpenTelemetry semantic convention namespaces, e.g. loadtest, so synthetic attributes are easy to exclude in shared environments")
+	flag.BoolVar(&uniqueSpanNames, "unique-span-names", false, "stress mode: rename spans with a never-repeating suffix, targeting backends' span name dictionaries and autocomplete")
+	flag.Float64Var(&uniqueNamesPerMinute, "unique-span-names-per-minute", 0, "new unique span names introduced per minute with --unique-span-names; other spans keep their name (0 renames every span)")
+	flag.StringVar(&uniqueResourceAttribute, "unique-resource-attribute", "", "resource attribute given the same unique value as each renamed span with --unique-span-names (e.g. host.name)")
+	flag.StringVar(&chaosPoliciesFile, "chaos-policies-file", "", "path to chaos policies JSON or YAML file")
 	flag.Int64Var(&chaosSeed, "chaos-seed", 0, "override chaos policy seed (0 uses file/default)")
+	flag.BoolVar(&chaosMarker, "chaos-marker", false, "stamp spans modified by chaos with a "+chaos.DefaultMarkerAttribute+" attribute listing the applied policies")
 	flag.BoolVar(&dryRun, "dry-run", false, "generate traces without exporting to OTLP")
 	flag.BoolVar(&streaming, "streaming", false, "pace each batch by span EndTime so backends see end_times <= wall-clock-now; required for long-running traces. See docs/streaming.md")
-	flag.StringVar(&output, "output", string(otlp.DryRunOutputSummary), "output format: summary or json")
-	flag.StringVar(&output, "o", string(otlp.DryRunOutputSummary), "output format shorthand: summary or json")
+	flag.BoolVar(&sdkBatch, "sdk-batch", false, "export like an application using the OpenTelemetry SDK BatchSpanProcessor: spans are queued, dropped when the queue is full, and sent in the background by batch size or schedule delay, configured from the OTEL_BSP_* environment variables")
+	flag.StringVar(&output, "output", string(otlp.DryRunOutputSummary), "output format: summary, json, otlp-json to write OTLP JSON lines (ptrace JSON) to stdout, or file://PATH to write them to a file (length-prefixed protobuf for .pb/.binpb paths) instead of exporting")
+	flag.StringVar(&output, "o", string(otlp.DryRunOutputSummary), "output format shorthand: summary, json, otlp-json or file://PATH")
 	flag.BoolVar(&summaryTraceIDs, "summary-trace-ids", false, "include sampled trace IDs in summary output")
 	flag.IntVar(&summaryTraceIDsLimit, "summary-trace-ids-limit", 10, "maximum number of sampled trace IDs to include in summary")
+	flag.IntVar(&summarySlowestRequests, "summary-slowest-requests", 0, "number of slowest export requests (with start time, spans and error) to include in summary (0 disables)")
+	flag.BoolVar(&summaryWorkers, "summary-workers", false, "include requests, failures and avg/p95 latency of each exporter worker in summary")
+	flag.StringVar(&campaignFile, "campaign", "", "path to a JSON or YAML campaign file; runs every combination of its parameter matrix sequentially and reports them together")
+	flag.StringVar(&sweep, "sweep", "", "vary one parameter across back-to-back runs and print a comparison table, e.g. exporters=1,2,4,8 ("+strings.Join(campaign.SweepParameters, ", ")+"); a lighter alternative to --campaign")
+	flag.StringVar(&reportFile, "report-file", "", "write the run summary as JSON to this path")
+	flag.StringVar(&reportURL, "report-url", "", "POST the run summary as JSON, in the --report-file format, to this URL when the run ends")
+	flag.Var(&reportHeaders, "report-header", "header sent with --report-url posts, in Key=Value or Key: Value format, e.g. Authorization=Bearer TOKEN; repeatable")
+	flag.Float64Var(&reportIntervalSeconds, "report-interval", 0, "seconds between posts of the summary so far to --report-url while the run proceeds; 0 posts only the final summary")
+	flag.StringVar(&schemaFile, "schema-file", "", "check every span against a JSON or YAML attribute schema (required keys and types per span kind) before export, and report violations in the summary")
+	flag.BoolVar(&schemaFailFast, "schema-fail-fast", false, "fail the run on the first span that violates --schema-file instead of counting violations")
+	flag.StringVar(&auditFile, "audit", "", "write one NDJSON record per generated batch (generator, chaos policy hits, per-stage span counts) to this path")
 	flag.Var(&headers, "header", "header in Key=Value or Key: Value format; repeatable")
+	flag.Var(&headerMappings, "header-from", "header set per request from a span attribute, in HEADER=resource.KEY or HEADER=span.KEY form, e.g. X-Scope-OrgID=resource.tenant.id; repeatable")
 	flag.Float64Var(&slowResponseDelaySeconds, "slow-response-delay", 0, "seconds to delay reading each HTTP response body, simulating a slow client (HTTP only, 0 disables)")
+	flag.StringVar(&backendMetricsURL, "backend-metrics-url", "", "Prometheus metrics endpoint of the backend; scraped before and after the run to compare sent spans with received spans")
+	flag.StringVar(&backendMetricsName, "backend-metrics-name", backendmetrics.DefaultMetric, "counter of spans received by the backend, summed over all label sets")
+	flag.Float64Var(&backendMetricsSettle, "backend-metrics-settle", 5, "seconds to wait after the run before the final backend metrics scrape")
+	freshnessDefaults := freshness.DefaultConfig()
+	flag.StringVar(&verifyBackend, "verify-backend", "tempo", "query API used to read traces back from the backend: "+strings.Join(verify.Names(), " or "))
+	flag.StringVar(&verifyURL, "verify-url", "", "base URL of the --verify-backend query API (e.g. http://localhost:3200 for Tempo, http://localhost:16686 for Jaeger)")
+	flag.BoolVar(&freshnessEnabled, "freshness", false, "poll sampled traces through --verify-url after export until every span is queryable, and report ingest-to-queryable latency percentiles")
+	flag.Float64Var(&freshnessSample, "freshness-sample", freshnessDefaults.SampleRate, "fraction of traces (0-1] polled for freshness, chosen by trace ID")
+	flag.Float64Var(&freshnessTimeout, "freshness-timeout", freshnessDefaults.Timeout.Seconds(), "seconds after export before a trace that is still incomplete counts as timed out")
+	flag.Float64Var(&freshnessPollInterval, "freshness-poll-interval", freshnessDefaults.PollInterval.Seconds(), "seconds between queries for one trace; bounds the latency resolution")
+	ingestionDefaults := ingestcheck.DefaultConfig()
+	flag.BoolVar(&verifyIngestion, "verify", false, "record the trace IDs sent and, after the run, query each through --verify-url to report traces stored partially or not at all; exits 1 when any is incomplete")
+	flag.Float64Var(&verifyTimeout, "verify-timeout", ingestionDefaults.Timeout.Seconds(), "seconds after the run that --verify keeps querying incomplete traces")
+	flag.IntVar(&verifyMaxTraces, "verify-max-traces", ingestionDefaults.MaxTraces, "traces recorded for --verify; later traces are counted as unchecked")
+	flag.Float64Var(&progressIntervalSeconds, "progress-interval", 5, "seconds between live progress lines on stderr (sent, success, failures, latency); 0 disables")
+	flag.BoolVar(&alignIntervals, "align-intervals", false, "print progress and synthetics health lines on wall-clock multiples of their interval (UTC), e.g. every full minute for 60, so several agents report the same intervals")
+	flag.StringVar(&timeFormat, "time-format", string(timefmt.TimestampRFC3339Nano), "timestamp format in JSON dry-run output and the summary: rfc3339nano, rfc3339ms, rfc3339, unix, unix_ms, unix_us or unix_ns")
+	flag.StringVar(&timeZone, "time-zone", "UTC", "time zone for RFC 3339 timestamps: UTC, Local or an IANA name such as Europe/Madrid")
+	flag.StringVar(&durationUnit, "duration-unit", "auto", "unit for durations in JSON dry-run output, the summary and progress lines: auto, ns, us, ms or s (auto keeps ms for latencies and s for wall time)")
+	flag.BoolVar(&syntheticsMode, "synthetics", false, "run permanently as a low-rate synthetic tracing canary: no request or time limit, one request every "+syntheticsInterval.String()+" unless --request-interval is set, failed exports are reported and reconnected instead of ending the run, and a health line is logged every --health-interval")
+	flag.Float64Var(&healthIntervalSeconds, "health-interval", 60, "seconds between synthetics health lines (status=ok|failing|idle with request, failure and span counts) in the log")
+	flag.StringVar(&logFile, "log-file", "", "write the log to this file instead of stderr, rotated once it reaches --log-max-bytes")
+	flag.Int64Var(&logMaxBytes, "log-max-bytes", 10<<20, "size at which --log-file is rotated")
+	flag.IntVar(&logBackups, "log-backups", 3, "rotated --log-file backups kept as FILE.1 to FILE.N")
+	flag.Float64Var(&alertAfterSeconds, "alert-after", 300, "seconds synthetics exports must keep failing before a \"synthetics event=outage\" line is logged and posted to --alert-webhook; recovery logs and posts \"event=recovered\"")
+	flag.StringVar(&alertWebhook, "alert-webhook", "", "URL that synthetics outage and recovery events are POSTed to as JSON")
 	flag.Parse()
 	if flag.NFlag() == 0 {
 		usage()
@@ -85,6 +303,66 @@ func main() {
 		_, ok := setFlags[name]
 		return ok
 	}
+	headerValues := headers.Values()
+	headerMappingValues := headerMappings.Values()
+	// expect has no flag; it only comes from the config file.
+	var expect *config.ExpectConfig
+	if configFile != "" {
+		fileCfg, err := config.LoadFromFile(configFile)
+		if err != nil {
+			log.Fatalf("invalid config file: %v", err)
+		}
+		expect = fileCfg.Expect
+		applyConfigFile(fileCfg, isFlagSet, fileSettings{
+			endpoint:               &endpoint,
+			protocol:               &protocol,
+			insecure:               &insecure,
+			tlsCACert:              &tlsCACert,
+			tlsSkipVerify:          &tlsSkipVerify,
+			tlsClientCert:          &tlsClientCert,
+			tlsClientKey:           &tlsClientKey,
+			tlsServerName:          &tlsServerName,
+			compression:            &compression,
+			resourceGrouping:       &resourceGrouping,
+			exporters:              &exporters,
+			requestsPerExporter:    &requestsPerExporter,
+			requestIntervalSeconds: &requestIntervalSeconds,
+			requestForSeconds:      &requestForSeconds,
+			rampUpSeconds:          &rampUpSeconds,
+			rampWorkersSeconds:     &rampWorkersSeconds,
+			totalSpans:             &totalSpans,
+			arrivalRate:            &arrivalRate,
+			spanRate:               &spanRate,
+			maxInFlight:            &maxInFlight,
+			exportTimeoutSeconds:   &exportTimeoutSeconds,
+			exportRetries:          &exportRetries,
+			retryBackoffSeconds:    &retryBackoffSeconds,
+			loadProfile:            &loadProfile,
+			scenarioStrategy:       &scenarioStrategy,
+			scenarioRunSeed:        &scenarioRunSeed,
+			idGenerator:            &idGenerator,
+			chaosPoliciesFile:      &chaosPoliciesFile,
+			chaosSeed:              &chaosSeed,
+			synthetics:             &syntheticsMode,
+			healthIntervalSeconds:  &healthIntervalSeconds,
+			logFile:                &logFile,
+			logMaxBytes:            &logMaxBytes,
+			logBackups:             &logBackups,
+			alertAfterSeconds:      &alertAfterSeconds,
+			alertWebhook:           &alertWebhook,
+		})
+		if !isFlagSet("scenario-file") && !isFlagSet("s") {
+			for _, file := range fileCfg.Scenario.Files {
+				if err := scenarioFiles.Set(file); err != nil {
+					log.Fatalf("invalid config file: scenario files: %v", err)
+				}
+			}
+		}
+		headerValues = mergeHeaders(fileCfg.Endpoint.Headers, headerValues)
+		if !isFlagSet("header-from") {
+			headerMappingValues = fileCfg.Endpoint.HeaderMappings
+		}
+	}
 	insecureExplicit := isFlagSet("insecure")
 	if err := applyOTLPEnvOverrides(&endpoint, &protocol, &insecure, isFlagSet); err != nil {
 		log.Fatalf("invalid OTLP environment override: %v", err)
@@ -98,17 +376,73 @@ func main() {
 		log.Fatalf("invalid endpoint security: %v", err)
 	}
 
+	// --seed fills in every seed left unset; each part gets its own
+	// derived value so they do not draw the same sequence. A run without
+	// one gets a random seed, reported in the summary so it can be
+	// repeated.
+	if seed == 0 {
+		seed = pipeline.NewRunSeed()
+	} else if chaosSeed == 0 {
+		// An explicit --seed also overrides a chaos policy file's seed; a
+		// generated one only stands in when the file has none.
+		chaosSeed = pipeline.DerivedSeed(seed, 2)
+	}
+	var eventsSeed, cardinalitySeed, replaySeed int64
+	{
+		if scenarioRunSeed == 0 {
+			scenarioRunSeed = pipeline.DerivedSeed(seed, 1)
+		}
+		if jitterSeed == 0 && (jitterAttributes != 0 || jitterSpans != 0) {
+			jitterSeed = pipeline.DerivedSeed(seed, 3)
+		}
+		eventsSeed = pipeline.DerivedSeed(seed, 4)
+		cardinalitySeed = pipeline.DerivedSeed(seed, 5)
+		replaySeed = pipeline.DerivedSeed(seed, 6)
+	}
+
+	// The rate strategy paces the run itself, so the scenarios'
+	// spans_per_second targets add up to its total span rate.
+	strategy, strategyErr := scenario.ParseSelectionStrategy(scenarioStrategy)
+	ratePaced := strategyErr == nil && strategy == scenario.SelectionStrategyRate && len(scenarioFiles.Values()) > 0
+	if ratePaced && (requestIntervalSeconds > 0 || loadProfile != "" || arrivalRate > 0 || spanRate > 0) {
+		log.Fatalf("invalid scenario config: --scenario-strategy=rate paces the run from the scenarios' spans_per_second and cannot be used with --request-interval, --profile, --arrival-rate or --span-rate")
+	}
+
+	// A synthetics run lasts until stopped and trickles traffic unless
+	// the flags or the config file pace it themselves.
+	if syntheticsMode {
+		if !isFlagSet("max-requests") && requestsPerExporter == defaults.Requests.PerExporter {
+			requestsPerExporter = 0
+		}
+		if requestIntervalSeconds == 0 && loadProfile == "" && arrivalRate == 0 && !ratePaced {
+			requestIntervalSeconds = syntheticsInterval.Seconds()
+		}
+		if !isFlagSet("progress-interval") {
+			progressIntervalSeconds = 0
+		}
+	}
+
 	requestInterval := time.Duration(requestIntervalSeconds * float64(time.Second))
 	requestFor := time.Duration(requestForSeconds * float64(time.Second))
 	rampUp := time.Duration(rampUpSeconds * float64(time.Second))
+	rampWorkers := time.Duration(rampWorkersSeconds * float64(time.Second))
 	exportTimeout := time.Duration(exportTimeoutSeconds * float64(time.Second))
+	retryBackoff := time.Duration(retryBackoffSeconds * float64(time.Second))
 	slowResponseDelay := time.Duration(slowResponseDelaySeconds * float64(time.Second))
 	cfg := config.Config{
 		Endpoint: config.EndpointConfig{
-			Address:  endpoint,
-			Protocol: config.Protocol(protocol),
-			Insecure: insecure,
-			Headers:  headers.Values(),
+			Address:          endpoint,
+			Protocol:         config.Protocol(protocol),
+			Insecure:         insecure,
+			Headers:          headerValues,
+			TLSCACert:        tlsCACert,
+			TLSSkipVerify:    tlsSkipVerify,
+			TLSClientCert:    tlsClientCert,
+			TLSClientKey:     tlsClientKey,
+			TLSServerName:    tlsServerName,
+			Compression:      config.Compression(compression),
+			ResourceGrouping: config.ResourceGrouping(resourceGrouping),
+			HeaderMappings:   headerMappingValues,
 		},
 		Concurrency: config.ConcurrencyConfig{
 			Exporters: exporters,
@@ -118,8 +452,27 @@ func main() {
 			Interval:      config.Duration{Duration: requestInterval},
 			For:           config.Duration{Duration: requestFor},
 			RampUp:        config.Duration{Duration: rampUp},
+			RampWorkers:   config.Duration{Duration: rampWorkers},
+			TotalSpans:    totalSpans,
+			ArrivalRate:   arrivalRate,
+			SpanRate:      spanRate,
+			MaxInFlight:   maxInFlight,
 			ExportTimeout: config.Duration{Duration: exportTimeout},
+			Retries:       exportRetries,
+			RetryBackoff:  config.Duration{Duration: retryBackoff},
+			Profile:       loadProfile,
 		},
+		Scenario: config.ScenarioConfig{
+			Files:       scenarioFiles.Values(),
+			Strategy:    scenarioStrategy,
+			RunSeed:     scenarioRunSeed,
+			IDGenerator: idGenerator,
+		},
+		Chaos: config.ChaosConfig{
+			PoliciesFile: chaosPoliciesFile,
+			Seed:         chaosSeed,
+		},
+		Expect: expect,
 	}
 	if err := cfg.Validate(); err != nil {
 		log.Fatalf("invalid config: %v", err)
@@ -130,6 +483,196 @@ func main() {
 	if summaryTraceIDs && summaryTraceIDsLimit == 0 {
 		log.Fatalf("invalid summary config: --summary-trace-ids requires --summary-trace-ids-limit > 0")
 	}
+	if summarySlowestRequests < 0 {
+		log.Fatalf("invalid summary config: --summary-slowest-requests must be >= 0")
+	}
+
+	if progressIntervalSeconds < 0 {
+		log.Fatalf("invalid output config: --progress-interval must be >= 0")
+	}
+	if futureFraction < 0 || futureFraction > 1 {
+		log.Fatalf("invalid future timestamps config: --future-fraction must be in [0, 1]")
+	}
+	if futureFraction > 0 {
+		if futureOffsetSeconds <= 0 {
+			log.Fatalf("invalid future timestamps config: --future-offset must be > 0")
+		}
+		if streaming {
+			log.Fatalf("invalid future timestamps config: --future-fraction cannot be used with --streaming, which waits for span end times")
+		}
+	}
+	if reportURL == "" && (len(reportHeaders.Values()) > 0 || reportIntervalSeconds != 0) {
+		log.Fatalf("invalid report config: --report-header and --report-interval require --report-url")
+	}
+	if reportIntervalSeconds < 0 {
+		log.Fatalf("invalid report config: --report-interval must be >= 0")
+	}
+	if reportURL != "" && (campaignFile != "" || sweep != "") {
+		log.Fatalf("invalid report config: --report-url cannot be used with --campaign or --sweep")
+	}
+	if sweep != "" && campaignFile != "" {
+		log.Fatalf("invalid sweep: --sweep cannot be used with --campaign")
+	}
+	if syntheticsMode && (campaignFile != "" || sweep != "") {
+		log.Fatalf("invalid synthetics config: --synthetics cannot be used with --campaign or --sweep")
+	}
+	if healthIntervalSeconds <= 0 {
+		log.Fatalf("invalid synthetics config: --health-interval must be > 0")
+	}
+	if alertAfterSeconds < 0 {
+		log.Fatalf("invalid synthetics config: --alert-after must be >= 0")
+	}
+	if alertWebhook != "" && !syntheticsMode {
+		log.Fatalf("invalid synthetics config: --alert-webhook requires --synthetics")
+	}
+	if logMaxBytes <= 0 {
+		log.Fatalf("invalid log config: --log-max-bytes must be > 0")
+	}
+	if logBackups < 0 {
+		log.Fatalf("invalid log config: --log-backups must be >= 0")
+	}
+	if logFile == "" && (isFlagSet("log-max-bytes") || isFlagSet("log-backups")) {
+		log.Fatalf("invalid log config: --log-max-bytes and --log-backups require --log-file")
+	}
+	if len(replayFiles) > 0 && len(scenarioFiles.Values()) > 0 {
+		log.Fatalf("invalid replay config: --replay-file cannot be used with --scenario-file")
+	}
+	if tracesPerRequest <= 0 {
+		log.Fatalf("invalid scenario config: --traces-per-request must be > 0")
+	}
+	if len(replayFiles) > 0 && tracesPerRequest != 1 {
+		log.Fatalf("invalid replay config: --traces-per-request cannot be used with --replay-file; replay sends each recorded request as recorded")
+	}
+	if spanRate > 0 && (len(replayFiles) > 0 || tracesPerRequest != 1) {
+		log.Fatalf("invalid span rate config: --span-rate sizes requests itself and cannot be used with --replay-file or --traces-per-request")
+	}
+	if len(replayFiles) == 0 && (replayNewIDs || replayNow || replaySpeed != "" || replayLoop || replayStream) {
+		log.Fatalf("invalid replay config: --replay-new-ids, --replay-now, --replay-speed, --replay-loop and --replay-stream require --replay-file")
+	}
+	var replaySpeedValue float64
+	if replaySpeed != "" {
+		parsed, err := replay.ParseSpeed(replaySpeed)
+		if err != nil {
+			log.Fatalf("invalid replay config: %v", err)
+		}
+		replaySpeedValue = parsed
+	}
+	if jitterAttributes < 0 || jitterAttributes > 1 {
+		log.Fatalf("invalid jitter config: --jitter-attributes must be in [0, 1]")
+	}
+	if jitterSpans < 0 || jitterSpans > 1 {
+		log.Fatalf("invalid jitter config: --jitter-spans must be in [0, 1]")
+	}
+	if jitterAttributes == 0 && jitterSpans == 0 && jitterSeed != 0 {
+		log.Fatalf("invalid jitter config: --jitter-seed requires --jitter-attributes or --jitter-spans")
+	}
+	if backfillDays < 0 {
+		log.Fatalf("invalid backfill config: --backfill-days must be >= 0")
+	}
+	if backfillRequestsPerDay <= 0 {
+		log.Fatalf("invalid backfill config: --backfill-requests-per-day must be > 0")
+	}
+	if backfillDays == 0 && isFlagSet("backfill-requests-per-day") {
+		log.Fatalf("invalid backfill config: --backfill-requests-per-day requires --backfill-days")
+	}
+	if backfillDays > 0 {
+		if streaming {
+			log.Fatalf("invalid backfill config: --backfill-days cannot be used with --streaming, which waits for span end times")
+		}
+		if futureFraction > 0 || replayNow {
+			log.Fatalf("invalid backfill config: --backfill-days cannot be used with --future-fraction or --replay-now")
+		}
+	}
+	if maxRequestBytes < 0 {
+		log.Fatalf("invalid request size config: --max-request-bytes must be >= 0")
+	}
+	parsedOversizeAction, err := otlp.ParseOversizeAction(oversizeAction)
+	if err != nil {
+		log.Fatalf("invalid request size config: --oversize-action: %v", err)
+	}
+	if maxRequestBytes == 0 && isFlagSet("oversize-action") {
+		log.Fatalf("invalid request size config: --oversize-action requires --max-request-bytes")
+	}
+	var sdkBatchSettings *otlp.BatchProcessorSettings
+	if sdkBatch {
+		if exportRetries > 0 || syntheticsMode {
+			log.Fatalf("invalid SDK batch config: --sdk-batch cannot be used with --export-retries or --synthetics, which need export results")
+		}
+		parsed, err := otlp.BatchProcessorSettingsFromEnv(os.LookupEnv)
+		if err != nil {
+			log.Fatalf("invalid SDK batch config: %v", err)
+		}
+		sdkBatchSettings = &parsed
+	}
+	if eventsPerSpan < 0 {
+		log.Fatalf("invalid events config: --events-per-span must be >= 0")
+	}
+	if eventsProbability <= 0 || eventsProbability > 1 {
+		log.Fatalf("invalid events config: --events-probability must be in (0, 1]")
+	}
+	if eventsPerSpan == 0 && isFlagSet("events-probability") {
+		log.Fatalf("invalid events config: --events-probability requires --events-per-span")
+	}
+	if uniqueNamesPerMinute < 0 {
+		log.Fatalf("invalid unique names config: --unique-span-names-per-minute must be >= 0")
+	}
+	if !uniqueSpanNames && (isFlagSet("unique-span-names-per-minute") || uniqueResourceAttribute != "") {
+		log.Fatalf("invalid unique names config: --unique-span-names-per-minute and --unique-resource-attribute require --unique-span-names")
+	}
+
+	// -o file://PATH writes OTLP to a file instead of an endpoint, so it
+	// runs like --dry-run with a file exporter in place of the dry-run one.
+	fileOutputPath, fileOutputFormat, fileOutput, err := otlp.ParseFileOutput(output)
+	if err != nil {
+		log.Fatalf("invalid output config: %v", err)
+	}
+	if fileOutput {
+		dryRun = true
+		output = string(otlp.DryRunOutputSummary)
+	}
+
+	if backendMetricsURL != "" && dryRun {
+		log.Fatalf("invalid backend metrics config: --backend-metrics-url cannot be used with --dry-run")
+	}
+	freshnessConfig := freshness.Config{
+		SampleRate:   freshnessSample,
+		PollInterval: time.Duration(freshnessPollInterval * float64(time.Second)),
+		Timeout:      time.Duration(freshnessTimeout * float64(time.Second)),
+		MaxInFlight:  freshnessDefaults.MaxInFlight,
+	}
+	var verifier verify.VerificationBackend
+	if verifyURL != "" {
+		if dryRun {
+			log.Fatalf("invalid verify config: --verify-url cannot be used with --dry-run")
+		}
+		backend, err := verify.New(verifyBackend, verifyURL)
+		if err != nil {
+			log.Fatalf("invalid verify config: %v", err)
+		}
+		verifier = backend
+	}
+	if freshnessEnabled {
+		if verifier == nil {
+			log.Fatalf("invalid freshness config: --freshness requires --verify-url")
+		}
+		if err := freshnessConfig.Validate(); err != nil {
+			log.Fatalf("invalid freshness config: %v", err)
+		}
+	}
+	ingestionConfig := ingestionDefaults
+	ingestionConfig.Timeout = time.Duration(verifyTimeout * float64(time.Second))
+	ingestionConfig.MaxTraces = verifyMaxTraces
+	if verifyIngestion {
+		if verifier == nil {
+			log.Fatalf("invalid verify config: --verify requires --verify-url")
+		}
+		if err := ingestionConfig.Validate(); err != nil {
+			log.Fatalf("invalid verify config: %v", err)
+		}
+	}
+	if backendMetricsSettle < 0 {
+		log.Fatalf("invalid backend metrics config: --backend-metrics-settle must be >= 0")
+	}
 
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
@@ -141,104 +684,349 @@ func main() {
 	if !dryRun && outputFormat != otlp.DryRunOutputSummary {
 		log.Fatalf("-o/--output=%s requires --dry-run", outputFormat)
 	}
-	if !dryRun {
-		if err := validateTLSConfiguration(insecure, tlsCACert, tlsSkipVerify); err != nil {
-			log.Fatalf("invalid TLS configuration: %v", err)
-		}
+
+	timeOptions, err := timefmt.Parse(timeFormat, timeZone, durationUnit)
+	if err != nil {
+		log.Fatalf("invalid time output config: %v", err)
 	}
 
-	var factory pipeline.ExporterFactory
-	if dryRun {
-		dryRunFactory := otlp.NewDryRunExporterFactory(outputFormat, os.Stdout)
-		factory = dryRunFactory
-	} else {
-		if slowResponseDelay > 0 && cfg.Endpoint.Protocol != config.ProtocolHTTP {
-			log.Printf("warning: --slow-response-delay has no effect with protocol=%s (HTTP only)", cfg.Endpoint.Protocol)
+	traceIDSampleLimit := 0
+	if summaryTraceIDs {
+		traceIDSampleLimit = summaryTraceIDsLimit
+	}
+	settings := runSettings{
+		seed:                 seed,
+		dryRun:               dryRun,
+		tracesPerRequest:     tracesPerRequest,
+		outputFormat:         outputFormat,
+		streaming:            streaming,
+		maxRequestBytes:      maxRequestBytes,
+		oversizeAction:       parsedOversizeAction,
+		sdkBatch:             sdkBatchSettings,
+		warmup:               warmup,
+		slowResponseDelay:    slowResponseDelay,
+		insecureExplicit:     insecureExplicit,
+		traceIDSampleLimit:   traceIDSampleLimit,
+		slowestRequestsLimit: summarySlowestRequests,
+		workerBreakdown:      summaryWorkers,
+		progressInterval:     time.Duration(progressIntervalSeconds * float64(time.Second)),
+		alignProgress:        alignIntervals,
+		progressWriter:       os.Stderr,
+		backendMetricsSettle: time.Duration(backendMetricsSettle * float64(time.Second)),
+		timeFormat:           timeOptions,
+		chaosMarker:          chaosMarker,
+	}
+	if logFile != "" {
+		rotated, err := synthetics.OpenLogFile(logFile, logMaxBytes, logBackups)
+		if err != nil {
+			log.Fatalf("invalid log config: %v", err)
+		}
+		defer func() { _ = rotated.Close() }()
+		log.SetOutput(rotated)
+		settings.progressWriter = rotated
+	}
+	var pusher *reportpush.Pusher
+	if reportURL != "" {
+		pusher = reportpush.New(reportURL, reportHeaders.Values())
+		if reportIntervalSeconds > 0 {
+			settings.summaryInterval = time.Duration(reportIntervalSeconds * float64(time.Second))
+			settings.onSummary = func(summary metrics.Summary, at time.Time) {
+				summary.Seed = seed
+				pusher.Interval(summary, at)
+			}
+		}
+	}
+	var health *synthetics.Health
+	var webhook *synthetics.Webhook
+	if syntheticsMode {
+		health = synthetics.NewHealth()
+		if alertWebhook != "" {
+			webhook = synthetics.NewWebhook(alertWebhook)
 		}
-		otlpFactory := otlp.ExporterFactory{
-			Protocol:          cfg.Endpoint.Protocol,
-			Endpoint:          cfg.Endpoint.Address,
-			Insecure:          cfg.Endpoint.Insecure,
-			Headers:           cfg.Endpoint.Headers,
-			SlowResponseDelay: slowResponseDelay,
-			TLSCACert:         tlsCACert,
-			TLSSkipVerify:     tlsSkipVerify,
-			ExportTimeout:     cfg.Requests.ExportTimeout.Duration,
+		outage := synthetics.NewOutage(time.Duration(alertAfterSeconds*float64(time.Second)), func(event synthetics.Event) {
+			log.Print(event)
+			if webhook != nil && !webhook.Notify(event) {
+				log.Printf("alert webhook: queue full, dropped %s event", event.Type)
+			}
+		})
+		settings.continueOnError = true
+		settings.onResult = func(spans int, err error) {
+			health.Record(spans, err)
+			outage.Record(spans, err)
 		}
-		factory = otlpFactory
-		_, _ = fmt.Fprintln(os.Stderr, "Running exporter preflight check...")
-		if err := otlp.RunPreflight(ctx, otlpFactory, cfg.Requests.ExportTimeout.Duration); err != nil {
-			log.Fatalf("preflight failed: %v", err)
+	}
+	if freshnessEnabled {
+		settings.freshness = &freshnessSettings{
+			counter: verifier,
+			config:  freshnessConfig,
+		}
+	}
+	if verifyIngestion {
+		settings.ingestion = &ingestionSettings{
+			counter: verifier,
+			config:  ingestionConfig,
+		}
+	}
+	if futureFraction > 0 {
+		settings.futureTimestamps = &pipeline.FutureTimestampsConfig{
+			Fraction: futureFraction,
+			Offset:   time.Duration(futureOffsetSeconds * float64(time.Second)),
+		}
+	}
+	if backfillDays > 0 {
+		settings.backfill = &pipeline.BackfillConfig{Days: backfillDays, RequestsPerDay: backfillRequestsPerDay}
+	}
+	if eventsPerSpan > 0 {
+		settings.events = &pipeline.EventsConfig{PerSpan: eventsPerSpan, Probability: eventsProbability, Seed: eventsSeed}
+	}
+	if jitterAttributes > 0 || jitterSpans > 0 {
+		settings.jitter = &pipeline.JitterConfig{
+			Attributes: jitterAttributes,
+			Spans:      jitterSpans,
+			Seed:       jitterSeed,
+		}
+	}
+	if attrs := cardinalityAttributes.Values(); len(attrs) > 0 {
+		settings.cardinality = &pipeline.CardinalityConfig{Attributes: attrs, Seed: cardinalitySeed}
+	}
+	if semconvProfiles != "" {
+		profiles, err := pipeline.ParseSemconvProfiles(semconvProfiles)
+		if err != nil {
+			log.Fatalf("invalid semconv profiles: %v", err)
+		}
+		settings.semconvProfiles = profiles
+	}
+	if schemaFailFast && schemaFile == "" {
+		log.Fatalf("invalid schema config: --schema-fail-fast requires --schema-file")
+	}
+	if schemaFile != "" {
+		attributeSchema, err := schema.LoadFromJSON(schemaFile)
+		if err != nil {
+			log.Fatalf("invalid schema file: %v", err)
+		}
+		settings.schema = &pipeline.SchemaConfig{Schema: attributeSchema, FailFast: schemaFailFast}
+	}
+	settings.attributePrefix = strings.TrimSpace(attributePrefix)
+	if span, resource := spanAttributes.Values(), resourceAttributes.Values(); len(span) > 0 || len(resource) > 0 {
+		settings.staticAttributes = &pipeline.StaticAttributesConfig{Span: span, Resource: resource}
+	}
+	if uniqueSpanNames {
+		settings.uniqueNames = &pipeline.UniqueNamesConfig{
+			PerMinute:         uniqueNamesPerMinute,
+			ResourceAttribute: uniqueResourceAttribute,
+		}
+	}
+	if len(replayFiles) > 0 {
+		settings.replay = &replay.Config{
+			Files:       replayFiles,
+			NewIDs:      replayNewIDs,
+			Retimestamp: replayNow,
+			Speed:       replaySpeedValue,
+			Loop:        replayLoop,
+			Seed:        replaySeed,
+			Stream:      replayStream,
 		}
-		_, _ = fmt.Fprintln(os.Stderr, "Preflight check passed")
+	}
+	if backendMetricsURL != "" {
+		scraper := backendmetrics.NewScraper(backendMetricsURL, backendMetricsName)
+		settings.backendMetrics = &scraper
 	}
 
-	if streaming {
-		factory = otlp.NewStreamingExporterFactory(factory)
+	closeFileOutput := func() {}
+	if fileOutput && fileOutputPath == otlp.StdoutPath {
+		fileFactory := otlp.NewFileExporterFactory(fileOutputFormat, os.Stdout)
+		fileFactory.ResourceGrouping = cfg.Endpoint.ResourceGrouping
+		settings.fileOutput = fileFactory
+	} else if fileOutput {
+		file, err := os.Create(fileOutputPath)
+		if err != nil {
+			log.Fatalf("invalid output config: %v", err)
+		}
+		fileFactory := otlp.NewFileExporterFactory(fileOutputFormat, file)
+		fileFactory.ResourceGrouping = cfg.Endpoint.ResourceGrouping
+		settings.fileOutput = fileFactory
+		closeFileOutput = func() {
+			if err := file.Close(); err != nil {
+				log.Printf("close output file: %v", err)
+			}
+		}
+	}
+	// Anything else printed to stdout would corrupt the OTLP stream.
+	stdoutTaken := (dryRun && outputFormat == otlp.DryRunOutputJSON) || (fileOutput && fileOutputPath == otlp.StdoutPath)
+	resultWriter := os.Stdout
+	if stdoutTaken {
+		resultWriter = os.Stderr
 	}
 
-	runner := pipeline.NewConcurrencyRunner(cfg.Concurrency.Exporters, cfg.Requests.PerExporter)
-	stages := make([]pipeline.BatchStage, 0, 2)
-	files := scenarioFiles.Values()
-	if len(files) > 0 {
-		strategy, err := scenario.ParseSelectionStrategy(scenarioStrategy)
+	closeAudit := func() {}
+	if auditFile != "" {
+		file, err := os.Create(auditFile)
 		if err != nil {
-			log.Fatalf("invalid scenario strategy: %v", err)
+			log.Fatalf("invalid audit config: %v", err)
+		}
+		settings.audit = audit.NewLog(file)
+		closeAudit = func() {
+			if err := file.Close(); err != nil {
+				log.Printf("close audit file: %v", err)
+			}
 		}
-		scenarioGenerator, err := scenario.NewBatchGeneratorFromFilesWithRunSeed(files, strategy, scenarioRunSeed)
+	}
+
+	var campaignCfg campaign.Config
+	if sweep != "" {
+		campaignCfg, err = campaign.ParseSweep(sweep)
 		if err != nil {
-			log.Fatalf("invalid scenario setup: %v", err)
+			log.Fatalf("invalid sweep: %v", err)
 		}
-		stages = append(stages, pipeline.NewScenarioStage(scenarioGenerator))
-	} else {
-		defaultGenerator, err := scenario.DefaultGenerator(scenarioRunSeed)
+	} else if campaignFile != "" {
+		campaignCfg, err = campaign.LoadFromFile(campaignFile)
 		if err != nil {
-			log.Fatalf("embedded scenario failed: %v", err)
+			log.Fatalf("invalid campaign file: %v", err)
 		}
-		stages = append(stages, pipeline.NewScenarioStage(defaultGenerator))
 	}
-	if chaosPoliciesFile != "" {
-		chaosCfg, err := chaos.LoadFromJSON(chaosPoliciesFile)
-		if err != nil {
-			log.Fatalf("invalid chaos policies: %v", err)
+
+	// Under systemd (Type=notify) or the Windows service control manager,
+	// report readiness, watchdog pings and the exit status; elsewhere the
+	// supervisor does nothing. It starts once the flags are validated, so
+	// every later failure goes through exit and reaches the manager.
+	supervisor, err := daemon.Start(stop)
+	if err != nil {
+		log.Fatalf("invalid service manager environment: %v", err)
+	}
+	exit := func(code int) {
+		supervisor.Stop(code)
+		os.Exit(code)
+	}
+	settings.heartbeat = supervisor.Progress
+
+	if campaignFile != "" || sweep != "" {
+		supervisor.Ready()
+		go supervisor.Watchdog(ctx)
+		results, err := runCampaign(ctx, campaignCfg, cfg, settings)
+		closeAudit()
+		closeFileOutput()
+		if results == nil && err != nil {
+			log.Printf("invalid campaign: %v", err)
+			exit(1)
 		}
-		if chaosSeed != 0 {
-			chaosCfg.Seed = chaosSeed
+		if sweep != "" {
+			_, _ = fmt.Fprintln(resultWriter, campaign.FormatSweep(campaignCfg.Name, results))
+		} else {
+			_, _ = fmt.Fprintln(resultWriter, campaign.FormatResults(campaignCfg.Name, results))
+		}
+		if reportFile != "" {
+			if reportErr := writeCampaignReportFile(reportFile, campaignCfg.Name, results); reportErr != nil {
+				log.Printf("write report: %v", reportErr)
+				exit(1)
+			}
 		}
-		chaosEngine, err := chaos.NewEngine(chaosCfg)
 		if err != nil {
-			log.Fatalf("create chaos engine: %v", err)
+			log.Printf("campaign stopped: %v", err)
+			exit(1)
+		}
+		for _, result := range results {
+			if result.Err != nil {
+				exit(1)
+			}
 		}
-		chaosDecider := chaos.NewSeededShouldApply(chaosCfg.Seed)
-		stages = append(stages, pipeline.NewChaosStage(chaosEngine, chaosDecider))
+		supervisor.Stop(0)
+		return
 	}
 
-	pipe := pipeline.New(stages...)
-	traceIDSampleLimit := 0
-	if summaryTraceIDs {
-		traceIDSampleLimit = summaryTraceIDsLimit
+	pipe, factory, err := prepareRun(ctx, cfg, settings)
+	if err != nil {
+		log.Print(err)
+		exit(1)
 	}
-	progressInterval := 5 * time.Second
-	// Streaming exports pace each batch by span EndTime, so a single
-	// ExportBatch call can take the full scenario duration. The pipeline's
-	// per-batch timeout would kill that; disable it when streaming. The
-	// OTLP SDK's own per-request timeout (also set from --export-timeout)
-	// still applies to each inner request inside the streaming exporter.
-	pipelineExportTimeout := cfg.Requests.ExportTimeout.Duration
-	if streaming {
-		pipelineExportTimeout = 0
-	}
-	err = pipe.RunWithProgress(ctx, runner, factory, cfg.Requests.Interval.Duration, cfg.Requests.For.Duration, cfg.Requests.RampUp.Duration, pipelineExportTimeout, traceIDSampleLimit, progressInterval, os.Stderr)
-	summary := metrics.FormatSummary(pipe.Summary())
-	if dryRun && outputFormat == otlp.DryRunOutputJSON {
-		_, _ = fmt.Fprintln(os.Stderr, summary)
-	} else {
-		_, _ = fmt.Println(summary)
+	supervisor.Ready()
+	go supervisor.Watchdog(ctx)
+	if health != nil {
+		go synthetics.ReportEvery(ctx, time.Duration(healthIntervalSeconds*float64(time.Second)), alignIntervals, health, func(line string) {
+			log.Print(line)
+		})
+	}
+	if webhook != nil {
+		go webhook.Run(ctx, func(err error) {
+			log.Print(err)
+		})
+	}
+	// stopPushes ends the interval reports and waits for the last one,
+	// so the final report is always the last delivered.
+	stopPushes := func() {}
+	if pusher != nil && settings.onSummary != nil {
+		pushCtx, cancelPushes := context.WithCancel(ctx)
+		pushesDone := make(chan struct{})
+		go func() {
+			defer close(pushesDone)
+			pusher.Run(pushCtx, func(err error) {
+				log.Print(err)
+			})
+		}()
+		stopPushes = func() {
+			cancelPushes()
+			<-pushesDone
+		}
+	}
+	runSummary, err := executeRun(ctx, pipe, factory, cfg, settings)
+	runSummary.Seed = seed
+	if syntheticsMode && errors.Is(err, context.Canceled) && ctx.Err() != nil {
+		// Stopping is how a synthetics run ends, not a failure.
+		err = nil
+	}
+	closeAudit()
+	closeFileOutput()
+	_, _ = fmt.Fprintln(resultWriter, metrics.FormatSummaryWith(runSummary, settings.timeFormat))
+	if reportFile != "" {
+		if reportErr := writeReportFile(reportFile, runSummary); reportErr != nil {
+			log.Printf("write report: %v", reportErr)
+			if err == nil {
+				exit(1)
+			}
+		}
+	}
+	stopPushes()
+	if pusher != nil {
+		// ctx is done once a synthetics run is stopped, so the final
+		// report gets a context of its own.
+		pushCtx, cancelPush := context.WithTimeout(context.Background(), 30*time.Second)
+		pushErr := pusher.Push(pushCtx, runSummary, time.Now())
+		cancelPush()
+		if pushErr != nil {
+			log.Printf("push report: %v", pushErr)
+			if err == nil {
+				exit(1)
+			}
+		}
 	}
 	if err != nil {
 		log.Printf("pipeline failed: %v", err)
-		os.Exit(1)
+		exit(1)
 	}
+	if runSummary.Ingestion != nil && runSummary.Ingestion.Failed() {
+		log.Printf("ingestion check failed: %d partial and %d missing traces", runSummary.Ingestion.Partial, runSummary.Ingestion.Missing)
+		exit(1)
+	}
+	if cfg.Expect != nil && runSummary.TraceShape != nil {
+		if failures := cfg.Expect.Check(*runSummary.TraceShape); len(failures) > 0 {
+			for _, failure := range failures {
+				log.Printf("expectation failed: %s", failure)
+			}
+			exit(1)
+		}
+	}
+	supervisor.Stop(0)
+}
+
+func writeReportFile(path string, summary metrics.Summary) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := metrics.WriteReport(file, summary); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
 }
 
 func usage() {
@@ -247,6 +1035,14 @@ func usage() {
 
 Usage:
   tercios [flags]
+  tercios chaos explain --chaos-policies-file=FILE --span-file=FILE
+  tercios demo [-s FILE] [--for=S] [--listen=ADDR]
+  tercios estimate [--config=FILE] [-s FILE] [--exporters=N] [--max-requests=N] [--request-interval=S] [--for=S] [--profile=SPEC]
+  tercios init [--output=FILE]
+  tercios preview [--config=FILE] [-s FILE] [--chaos-policies-file=FILE] [--n=N]
+  tercios scenario from-service-graph --prometheus-url=URL [--window=S]
+  tercios scenario graph -f FILE [--format=dot|mermaid]
+  tercios schema config|chaos|scenario [FILE...]
 
 Examples:
   # Quick local test (embedded 5-service scenario, no collector needed)
@@ -261,17 +1057,40 @@ Examples:
   # Custom scenario + chaos
   tercios -s my-scenario.json --chaos-policies-file=my-chaos.json --exporters=10 --max-requests=100
 
+  # Run from a config file, overriding one value
+  tercios --config=run.yaml --exporters=20
+
+  # Compare span throughput at increasing concurrency
+  tercios --endpoint=localhost:4317 --max-requests=0 --for=30 --sweep=exporters=1,2,4,8
+
+  # Replay a recorded capture as fresh traces, once through
+  tercios --replay-file=capture.json --replay-new-ids --replay-now --max-requests=0
+
+  # Soak test for an hour by looping a small capture
+  tercios --endpoint=localhost:4317 --replay-file=capture.json --replay-loop --replay-now --max-requests=0 --for=3600
+
+  # Replay a capture at twice its recorded pace
+  tercios --replay-file=capture.json --replay-speed=2x --replay-now --max-requests=0 --request-interval=0
+
+  # Permanent synthetic canary, one request every 10s, health line every minute
+  tercios --config=canary.yaml --synthetics --log-file=/var/log/tercios/canary.log
+
+  # Backfill 30 days of history, 1000 requests per day
+  tercios --endpoint=localhost:4317 --backfill-days=30 --backfill-requests-per-day=1000 --max-requests=0 --request-interval=0
+
 Connection:
 `)
-	printFlag(w, "endpoint", "protocol", "insecure", "header", "tls-ca-cert", "tls-skip-verify")
+	printFlag(w, "config", "endpoint", "protocol", "insecure", "compression", "resource-grouping", "header", "header-from", "tls-ca-cert", "tls-skip-verify", "tls-server-name", "tls-cert", "tls-key")
 	_, _ = fmt.Fprintf(w, "\nLoad:\n")
-	printFlag(w, "exporters", "max-requests", "request-interval", "for", "ramp-up", "export-timeout", "slow-response-delay")
+	printFlag(w, "campaign", "sweep", "exporters", "max-requests", "total-spans", "request-interval", "arrival-rate", "span-rate", "max-in-flight", "for", "ramp-up", "ramp-workers", "warmup", "export-timeout", "export-retries", "retry-backoff", "max-request-bytes", "oversize-action", "sdk-batch", "profile", "slow-response-delay")
 	_, _ = fmt.Fprintf(w, "\nScenarios:\n")
-	printFlag(w, "scenario-file", "scenario-strategy", "scenario-run-seed")
+	printFlag(w, "scenario-file", "scenario-strategy", "scenario-run-seed", "id-generator", "traces-per-request", "seed", "replay-file", "replay-new-ids", "replay-now", "replay-speed", "replay-loop", "replay-stream", "jitter-attributes", "jitter-spans", "jitter-seed", "events-per-span", "events-probability", "semconv-profiles", "cardinality-attribute", "attribute-prefix", "span-attr", "resource-attr", "unique-span-names", "unique-span-names-per-minute", "unique-resource-attribute", "future-fraction", "future-offset", "backfill-days", "backfill-requests-per-day")
 	_, _ = fmt.Fprintf(w, "\nChaos:\n")
-	printFlag(w, "chaos-policies-file", "chaos-seed")
+	printFlag(w, "chaos-policies-file", "chaos-seed", "chaos-marker")
+	_, _ = fmt.Fprintf(w, "\nSynthetics:\n")
+	printFlag(w, "synthetics", "health-interval", "alert-after", "alert-webhook", "log-file", "log-max-bytes", "log-backups")
 	_, _ = fmt.Fprintf(w, "\nOutput:\n")
-	printFlag(w, "dry-run", "output", "summary-trace-ids", "summary-trace-ids-limit")
+	printFlag(w, "dry-run", "output", "summary-trace-ids", "summary-trace-ids-limit", "summary-slowest-requests", "summary-workers", "report-file", "report-url", "report-header", "report-interval", "audit", "schema-file", "schema-fail-fast", "progress-interval", "align-intervals", "time-format", "time-zone", "duration-unit", "backend-metrics-url", "backend-metrics-name", "backend-metrics-settle", "verify-backend", "verify-url", "verify", "verify-timeout", "verify-max-traces", "freshness", "freshness-sample", "freshness-timeout", "freshness-poll-interval")
 }
 
 func printFlag(w *os.File, names ...string) {
@@ -6,6 +6,8 @@ import (
 	"os"
 	"strconv"
 	"strings"
+
+	"github.com/javiermolinar/tercios/internal/config"
 )
 
 const (
@@ -90,16 +92,22 @@ func endpointSchemeUsesTLS(raw string) (usesTLS bool, scheme string, ok bool, er
 	}
 }
 
-func validateTLSConfiguration(insecure bool, tlsCACert string, tlsSkipVerify bool) error {
-	if !insecure {
+func validateTLSConfiguration(endpoint config.EndpointConfig) error {
+	if !endpoint.Insecure {
 		return nil
 	}
-	if strings.TrimSpace(tlsCACert) != "" {
+	if strings.TrimSpace(endpoint.TLSCACert) != "" {
 		return fmt.Errorf("--tls-ca-cert requires TLS; use --insecure=false or an https/grpcs endpoint")
 	}
-	if tlsSkipVerify {
+	if endpoint.TLSSkipVerify {
 		return fmt.Errorf("--tls-skip-verify requires TLS; use --insecure=false or an https/grpcs endpoint")
 	}
+	if strings.TrimSpace(endpoint.TLSClientCert) != "" || strings.TrimSpace(endpoint.TLSClientKey) != "" {
+		return fmt.Errorf("--tls-cert and --tls-key require TLS; use --insecure=false or an https/grpcs endpoint")
+	}
+	if strings.TrimSpace(endpoint.TLSServerName) != "" {
+		return fmt.Errorf("--tls-server-name requires TLS; use --insecure=false or an https/grpcs endpoint")
+	}
 	return nil
 }
 
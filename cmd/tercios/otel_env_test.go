@@ -2,6 +2,8 @@ package main
 
 import (
 	"testing"
+
+	"github.com/javiermolinar/tercios/internal/config"
 )
 
 func TestApplyOTLPEnvOverrides_UsesTraceSpecificEndpoint(t *testing.T) {
@@ -124,13 +126,19 @@ func TestApplyEndpointSchemeSecurityDefaults_ExplicitInsecureFalseWinsForHostPor
 }
 
 func TestValidateTLSConfigurationRejectsTLSFlagsWhenInsecure(t *testing.T) {
-	if err := validateTLSConfiguration(true, "ca.pem", false); err == nil {
+	if err := validateTLSConfiguration(config.EndpointConfig{Insecure: true, TLSCACert: "ca.pem"}); err == nil {
 		t.Fatalf("expected --tls-ca-cert to require TLS")
 	}
-	if err := validateTLSConfiguration(true, "", true); err == nil {
+	if err := validateTLSConfiguration(config.EndpointConfig{Insecure: true, TLSSkipVerify: true}); err == nil {
 		t.Fatalf("expected --tls-skip-verify to require TLS")
 	}
-	if err := validateTLSConfiguration(false, "ca.pem", true); err != nil {
+	if err := validateTLSConfiguration(config.EndpointConfig{Insecure: true, TLSClientCert: "client.pem", TLSClientKey: "client-key.pem"}); err == nil {
+		t.Fatalf("expected --tls-cert and --tls-key to require TLS")
+	}
+	if err := validateTLSConfiguration(config.EndpointConfig{Insecure: true, TLSServerName: "collector.internal"}); err == nil {
+		t.Fatalf("expected --tls-server-name to require TLS")
+	}
+	if err := validateTLSConfiguration(config.EndpointConfig{TLSCACert: "ca.pem", TLSSkipVerify: true, TLSClientCert: "client.pem", TLSClientKey: "client-key.pem"}); err != nil {
 		t.Fatalf("expected TLS options to be valid when TLS is enabled: %v", err)
 	}
 }
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/config"
+	"github.com/javiermolinar/tercios/internal/model"
+	"github.com/javiermolinar/tercios/internal/scenario"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// runPreviewCommand handles `tercios preview` and returns the process exit
+// code. It generates a few traces through the same pipeline a dry run
+// would, so scenarios and chaos policies shape them, and prints each as a
+// tree followed by aggregate stats.
+func runPreviewCommand(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("tercios preview", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	configFile := flags.String("config", "", "path to a JSON or YAML run configuration file; explicitly set flags override its values")
+	var scenarioFiles scenario.FileFlags
+	flags.Var(&scenarioFiles, "scenario-file", "path to scenario JSON or YAML file; repeatable")
+	flags.Var(&scenarioFiles, "s", "path to scenario JSON or YAML file (shorthand); repeatable")
+	chaosPoliciesFile := flags.String("chaos-policies-file", "", "path to chaos policies JSON or YAML file")
+	traces := flags.Int("n", 5, "number of traces to generate")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *traces <= 0 {
+		_, _ = fmt.Fprintln(stderr, "preview requires --n > 0")
+		return 2
+	}
+
+	cfg := config.DefaultConfig()
+	if *configFile != "" {
+		fileCfg, err := config.LoadFromFile(*configFile)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "invalid config file: %v\n", err)
+			return 1
+		}
+		cfg = fileCfg
+	}
+	isFlagSet := func(name string) bool {
+		set := false
+		flags.Visit(func(f *flag.Flag) { set = set || f.Name == name })
+		return set
+	}
+	if isFlagSet("scenario-file") || isFlagSet("s") {
+		cfg.Scenario.Files = scenarioFiles.Values()
+	}
+	valueFromFlag(isFlagSet, &cfg.Chaos.PoliciesFile, *chaosPoliciesFile, "chaos-policies-file")
+	if err := cfg.Validate(); err != nil {
+		_, _ = fmt.Fprintf(stderr, "invalid config: %v\n", err)
+		return 1
+	}
+
+	ctx := context.Background()
+	pipe, _, err := prepareRun(ctx, cfg, runSettings{dryRun: true, tracesPerRequest: 1})
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "%v\n", err)
+		return 1
+	}
+	var spans []model.Span
+	for range *traces {
+		batch, err := pipe.Process(ctx, nil)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "preview failed: %v\n", err)
+			return 1
+		}
+		spans = append(spans, batch...)
+	}
+
+	groups := groupByTrace(spans)
+	for i, trace := range groups {
+		_, _ = fmt.Fprintf(stdout, "Trace %d/%d %s (%d spans)\n", i+1, len(groups), trace[0].TraceID, len(trace))
+		_, _ = fmt.Fprint(stdout, formatTraceTree(trace))
+		_, _ = fmt.Fprintln(stdout)
+	}
+	_, _ = fmt.Fprintln(stdout, formatPreviewStats(groups))
+	return 0
+}
+
+// groupByTrace splits spans by trace ID, in the order the traces first
+// appear.
+func groupByTrace(spans []model.Span) [][]model.Span {
+	index := map[oteltrace.TraceID]int{}
+	var groups [][]model.Span
+	for _, span := range spans {
+		i, ok := index[span.TraceID]
+		if !ok {
+			i = len(groups)
+			index[span.TraceID] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], span)
+	}
+	return groups
+}
+
+// formatTraceTree prints the spans of one trace indented under their
+// parents, siblings by start time. Spans whose parent is not in the trace,
+// such as after a chaos drop, are printed as roots.
+func formatTraceTree(trace []model.Span) string {
+	present := make(map[oteltrace.SpanID]bool, len(trace))
+	for _, span := range trace {
+		present[span.SpanID] = true
+	}
+	children := map[oteltrace.SpanID][]model.Span{}
+	var roots []model.Span
+	for _, span := range trace {
+		if span.ParentSpanID.IsValid() && present[span.ParentSpanID] {
+			children[span.ParentSpanID] = append(children[span.ParentSpanID], span)
+		} else {
+			roots = append(roots, span)
+		}
+	}
+	byStart := func(a, b model.Span) int { return a.StartTime.Compare(b.StartTime) }
+	slices.SortStableFunc(roots, byStart)
+
+	var b strings.Builder
+	traceStart := roots[0].StartTime
+	var write func(span model.Span, depth int)
+	write = func(span model.Span, depth int) {
+		service := "?"
+		if name, ok := span.ResourceAttributes["service.name"]; ok {
+			service = name.Emit()
+		}
+		fmt.Fprintf(&b, "%s%s [%s, %s] +%s %s", strings.Repeat("  ", depth+1), span.Name, service, span.Kind,
+			span.StartTime.Sub(traceStart), span.EndTime.Sub(span.StartTime))
+		if span.StatusCode == codes.Error {
+			b.WriteString(" ERROR")
+			if span.StatusDescription != "" {
+				fmt.Fprintf(&b, " (%s)", span.StatusDescription)
+			}
+		}
+		b.WriteByte('\n')
+		kids := children[span.SpanID]
+		slices.SortStableFunc(kids, byStart)
+		for _, child := range kids {
+			write(child, depth+1)
+		}
+	}
+	for _, root := range roots {
+		write(root, 0)
+	}
+	return b.String()
+}
+
+func formatPreviewStats(traces [][]model.Span) string {
+	var spans, errors int
+	minSpans, maxSpans := -1, 0
+	var totalDuration time.Duration
+	services := map[string]bool{}
+	for _, trace := range traces {
+		spans += len(trace)
+		if minSpans < 0 || len(trace) < minSpans {
+			minSpans = len(trace)
+		}
+		maxSpans = max(maxSpans, len(trace))
+		start, end := trace[0].StartTime, trace[0].EndTime
+		for _, span := range trace {
+			if span.StatusCode == codes.Error {
+				errors++
+			}
+			if name, ok := span.ResourceAttributes["service.name"]; ok {
+				services[name.Emit()] = true
+			}
+			if span.StartTime.Before(start) {
+				start = span.StartTime
+			}
+			if span.EndTime.After(end) {
+				end = span.EndTime
+			}
+		}
+		totalDuration += end.Sub(start)
+	}
+	if len(traces) == 0 {
+		return "No spans generated."
+	}
+	lines := []string{
+		fmt.Sprintf("Previewed %d traces:", len(traces)),
+		fmt.Sprintf("  Spans: %d (%d-%d per trace, %.1f average)", spans, minSpans, maxSpans, float64(spans)/float64(len(traces))),
+		fmt.Sprintf("  Errors: %d spans (%.1f%%)", errors, 100*float64(errors)/float64(spans)),
+		fmt.Sprintf("  Services: %d", len(services)),
+		fmt.Sprintf("  Trace duration: %s average", totalDuration/time.Duration(len(traces))),
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRunPreviewCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := runPreviewCommand([]string{"--n=3"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	for _, want := range []string{"Trace 1/3 ", "Trace 3/3 ", "Previewed 3 traces:", "Spans: ", "Services: "} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output:\n%s", want, out)
+		}
+	}
+	// Child spans are indented under their parent.
+	if !strings.Contains(out, "\n    ") {
+		t.Fatalf("expected nested spans in output:\n%s", out)
+	}
+
+	if code := runPreviewCommand([]string{"--n=0"}, &stdout, &stderr); code != 2 {
+		t.Fatalf("exit code for --n=0 = %d, want 2", code)
+	}
+}
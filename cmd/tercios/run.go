@@ -0,0 +1,440 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/audit"
+	"github.com/javiermolinar/tercios/internal/backendmetrics"
+	"github.com/javiermolinar/tercios/internal/campaign"
+	"github.com/javiermolinar/tercios/internal/config"
+	"github.com/javiermolinar/tercios/internal/freshness"
+	"github.com/javiermolinar/tercios/internal/ingestcheck"
+	"github.com/javiermolinar/tercios/internal/loadprofile"
+	"github.com/javiermolinar/tercios/internal/metrics"
+	"github.com/javiermolinar/tercios/internal/model"
+	"github.com/javiermolinar/tercios/internal/otlp"
+	"github.com/javiermolinar/tercios/internal/pipeline"
+	"github.com/javiermolinar/tercios/internal/replay"
+	"github.com/javiermolinar/tercios/internal/scenario"
+	"github.com/javiermolinar/tercios/internal/timefmt"
+)
+
+// runSettings holds the CLI options that stay fixed for every run. Anything
+// a campaign can vary between runs lives in config.Config instead.
+type runSettings struct {
+	// seed is the run seed, given with --seed or generated; it seeds
+	// chaos when neither --chaos-seed nor the policies file does.
+	seed            int64
+	dryRun          bool
+	outputFormat    otlp.DryRunOutput
+	streaming       bool
+	maxRequestBytes int
+	oversizeAction  otlp.OversizeAction
+	// sdkBatch queues spans like an SDK BatchSpanProcessor when set.
+	sdkBatch             *otlp.BatchProcessorSettings
+	warmup               bool
+	slowResponseDelay    time.Duration
+	insecureExplicit     bool
+	traceIDSampleLimit   int
+	slowestRequestsLimit int
+	// workerBreakdown adds a per-worker table to the summary.
+	workerBreakdown  bool
+	progressInterval time.Duration
+	// alignProgress prints progress lines on wall-clock boundaries.
+	alignProgress  bool
+	progressWriter io.Writer
+	// onSummary receives the summary so far every summaryInterval, for
+	// the interval reports of --report-url.
+	summaryInterval time.Duration
+	onSummary       func(summary metrics.Summary, at time.Time)
+	// backendMetrics, when set, is scraped before and after each run to
+	// compare sent spans with the backend's ingest counter.
+	backendMetrics       *backendmetrics.Scraper
+	backendMetricsSettle time.Duration
+	// freshness, when set, polls sampled traces in the backend after
+	// export to measure ingest-to-queryable latency.
+	freshness *freshnessSettings
+	// ingestion, when set, records the traces sent and reads each back
+	// from the backend after the run.
+	ingestion *ingestionSettings
+	// uniqueNames enables the unique span name stress mode when set.
+	uniqueNames *pipeline.UniqueNamesConfig
+	// jitter varies batch contents per request when set.
+	jitter *pipeline.JitterConfig
+	// semconvProfiles fills in semantic convention attributes when set.
+	semconvProfiles []pipeline.SemconvProfile
+	// cardinality injects high-cardinality span attributes when set.
+	cardinality *pipeline.CardinalityConfig
+	// attributePrefix, when not empty, prefixes non-semconv attribute keys.
+	attributePrefix string
+	// staticAttributes sets fixed span and resource attributes when set.
+	staticAttributes *pipeline.StaticAttributesConfig
+	// events adds synthetic span events when set.
+	events *pipeline.EventsConfig
+	// backfill dates batches day by day into the past when set.
+	backfill *pipeline.BackfillConfig
+	// futureTimestamps moves a fraction of traces into the future when set.
+	futureTimestamps *pipeline.FutureTimestampsConfig
+	// schema checks every span against an attribute schema when set.
+	schema *pipeline.SchemaConfig
+	// fileOutput, when set, replaces the dry-run exporter so generated
+	// batches are written to a file (-o file://PATH).
+	fileOutput pipeline.ExporterFactory
+	// replay, when set, re-exports recorded files instead of generating
+	// scenario traces. Each run reads the files again from the start.
+	replay *replay.Config
+	// tracesPerRequest is the number of generated traces in each batch.
+	tracesPerRequest int
+	// chaosMarker stamps chaos-modified spans with the policies applied.
+	chaosMarker bool
+	// audit, when set, receives one NDJSON record per generated batch.
+	audit *audit.Log
+	// heartbeat is called whenever the pipeline shows progress, to feed
+	// the service manager's watchdog.
+	heartbeat func()
+	// continueOnError keeps a synthetics run going through endpoint
+	// outages: a failed preflight is only logged, and failed exports are
+	// recorded and reconnected instead of ending the run.
+	continueOnError bool
+	// onResult receives the outcome of every export.
+	onResult func(spans int, err error)
+	// timeFormat controls timestamps and durations in every output except
+	// the --report-file JSON, whose schema is fixed.
+	timeFormat timefmt.Options
+}
+
+type freshnessSettings struct {
+	counter freshness.SpanCounter
+	config  freshness.Config
+}
+
+type ingestionSettings struct {
+	counter ingestcheck.SpanCounter
+	config  ingestcheck.Config
+}
+
+// prepareRun builds the exporter factory and pipeline for cfg, running the
+// exporter preflight check when exporting to a real endpoint.
+func prepareRun(ctx context.Context, cfg config.Config, settings runSettings) (*pipeline.Pipeline, pipeline.ExporterFactory, error) {
+	var factory pipeline.ExporterFactory
+	if settings.fileOutput != nil {
+		factory = settings.fileOutput
+	} else if settings.dryRun {
+		dryRunFactory := otlp.NewDryRunExporterFactory(settings.outputFormat, os.Stdout)
+		dryRunFactory.TimeFormat = settings.timeFormat
+		factory = dryRunFactory
+	} else {
+		if err := validateTLSConfiguration(cfg.Endpoint); err != nil {
+			return nil, nil, fmt.Errorf("invalid TLS configuration: %w", err)
+		}
+		if settings.slowResponseDelay > 0 && cfg.Endpoint.Protocol == config.ProtocolGRPC {
+			log.Printf("warning: --slow-response-delay has no effect with protocol=%s (HTTP and Zipkin only)", cfg.Endpoint.Protocol)
+		}
+		if cfg.Endpoint.ResourceGrouping != "" && cfg.Endpoint.ResourceGrouping != config.ResourceGroupingResource && cfg.Endpoint.Protocol == config.ProtocolZipkin {
+			log.Printf("warning: --resource-grouping has no effect with protocol=%s (OTLP only)", cfg.Endpoint.Protocol)
+		}
+		otlpFactory := otlp.ExporterFactory{
+			Protocol:          cfg.Endpoint.Protocol,
+			Endpoint:          cfg.Endpoint.Address,
+			Insecure:          cfg.Endpoint.Insecure,
+			Headers:           cfg.Endpoint.Headers,
+			SlowResponseDelay: settings.slowResponseDelay,
+			TLSCACert:         cfg.Endpoint.TLSCACert,
+			TLSSkipVerify:     cfg.Endpoint.TLSSkipVerify,
+			TLSClientCert:     cfg.Endpoint.TLSClientCert,
+			TLSClientKey:      cfg.Endpoint.TLSClientKey,
+			TLSServerName:     cfg.Endpoint.TLSServerName,
+			Compression:       cfg.Endpoint.Compression,
+			ExportTimeout:     cfg.Requests.ExportTimeout.Duration,
+			DisableRetry:      cfg.Requests.Retries > 0,
+			ResourceGrouping:  cfg.Endpoint.ResourceGrouping,
+		}
+		factory = otlpFactory
+		if len(cfg.Endpoint.HeaderMappings) > 0 {
+			factory = otlp.NewHeaderRoutingExporterFactory(otlpFactory, cfg.Endpoint.HeaderMappings)
+		}
+		_, _ = fmt.Fprintln(os.Stderr, "Running exporter preflight check...")
+		if err := otlp.RunPreflight(ctx, otlpFactory, cfg.Requests.ExportTimeout.Duration); err != nil {
+			if !settings.continueOnError {
+				return nil, nil, fmt.Errorf("preflight failed: %w", err)
+			}
+			log.Printf("warning: preflight failed, sending anyway: %v", err)
+		} else {
+			_, _ = fmt.Fprintln(os.Stderr, "Preflight check passed")
+		}
+	}
+
+	// The size check wraps the exporter itself, so streaming's smaller
+	// per-EndTime requests are measured one by one.
+	if settings.maxRequestBytes > 0 {
+		sizeFactory := otlp.NewSizeLimitExporterFactory(factory, settings.maxRequestBytes, settings.oversizeAction)
+		sizeFactory.ResourceGrouping = cfg.Endpoint.ResourceGrouping
+		factory = sizeFactory
+	}
+	// Streaming ends spans in wall-clock order, so it feeds the batch
+	// processor as an instrumented application would.
+	if settings.sdkBatch != nil {
+		factory = otlp.NewBatchProcessorExporterFactory(factory, *settings.sdkBatch)
+	}
+	if settings.streaming {
+		factory = otlp.NewStreamingExporterFactory(factory)
+	}
+
+	var source pipeline.BatchStage
+	if settings.replay != nil {
+		replayGenerator, err := replay.NewGenerator(*settings.replay)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid replay setup: %w", err)
+		}
+		if settings.replay.Stream {
+			_, _ = fmt.Fprintf(os.Stderr, "Streaming %d replay files\n", len(settings.replay.Files))
+		} else {
+			_, _ = fmt.Fprintf(os.Stderr, "Replaying %d recorded batches\n", replayGenerator.Batches())
+		}
+		source = pipeline.NewReplayStage(replayGenerator)
+	}
+	stages, err := pipeline.NewStages(pipeline.StageOptions{
+		Source:            source,
+		ScenarioFiles:     cfg.Scenario.Files,
+		Strategy:          cfg.Scenario.Strategy,
+		RunSeed:           cfg.Scenario.RunSeed,
+		IDGenerator:       cfg.Scenario.IDGenerator,
+		TracesPerRequest:  settings.tracesPerRequest,
+		SpansPerRequest:   cfg.SpansPerRequest(),
+		ChaosPoliciesFile: cfg.Chaos.PoliciesFile,
+		ChaosSeed:         cfg.Chaos.Seed,
+		Seed:              settings.seed,
+		ChaosMarker:       settings.chaosMarker,
+		Jitter:            settings.jitter,
+		SemconvProfiles:   settings.semconvProfiles,
+		Events:            settings.events,
+		Cardinality:       settings.cardinality,
+		UniqueNames:       settings.uniqueNames,
+		AttributePrefix:   settings.attributePrefix,
+		StaticAttributes:  settings.staticAttributes,
+		FutureTimestamps:  settings.futureTimestamps,
+		Backfill:          settings.backfill,
+		Shape:             cfg.Expect != nil,
+		Schema:            settings.schema,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pipeline.New(stages...), factory, nil
+}
+
+// rateStrategyProfile paces runs of the rate scenario strategy so the
+// spans_per_second targets of the scenarios add up to the total span rate:
+// the target trace rate, split into requests of tracesPerRequest traces.
+// It returns nil for other runs.
+func rateStrategyProfile(cfg config.Config, tracesPerRequest int) (*loadprofile.Profile, error) {
+	if len(cfg.Scenario.Files) == 0 {
+		return nil, nil
+	}
+	strategy, err := scenario.ParseSelectionStrategy(cfg.Scenario.Strategy)
+	if err != nil || strategy != scenario.SelectionStrategyRate {
+		return nil, nil
+	}
+	traceRate, err := scenario.TargetTraceRate(cfg.Scenario.Files)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scenario setup: %w", err)
+	}
+	profile := loadprofile.Constant(traceRate / float64(max(tracesPerRequest, 1)))
+	return &profile, nil
+}
+
+// executeRun runs pipe with the pacing and limits from cfg and returns the
+// run summary together with the pipeline error, if any.
+func executeRun(ctx context.Context, pipe *pipeline.Pipeline, factory pipeline.ExporterFactory, cfg config.Config, settings runSettings) (metrics.Summary, error) {
+	runner := pipeline.NewConcurrencyRunner(cfg.Concurrency.Exporters, cfg.Requests.PerExporter)
+	// Streaming exports pace each batch by span EndTime, so a single
+	// ExportBatch call can take the full scenario duration. The pipeline's
+	// per-batch timeout would kill that; disable it when streaming. The
+	// OTLP SDK's own per-request timeout (also set from --export-timeout)
+	// still applies to each inner request inside the streaming exporter.
+	pipelineExportTimeout := cfg.Requests.ExportTimeout.Duration
+	if settings.streaming {
+		pipelineExportTimeout = 0
+	}
+	profile, err := rateStrategyProfile(cfg, settings.tracesPerRequest)
+	if err != nil {
+		return metrics.Summary{}, err
+	}
+	if cfg.Requests.Profile != "" {
+		parsed, err := loadprofile.Parse(cfg.Requests.Profile)
+		if err != nil {
+			return metrics.Summary{}, err
+		}
+		profile = &parsed
+	}
+	requestInterval := cfg.Requests.Interval.Duration
+	if cfg.Requests.SpanRate > 0 && cfg.Requests.ArrivalRate == 0 {
+		// Requests are sized for Exporters requests every Interval. A
+		// shared pacer holds that rate; sleeping Interval after each
+		// export would let export latency stretch it and undershoot.
+		spanRateProfile := loadprofile.Constant(cfg.RequestRate())
+		profile = &spanRateProfile
+		requestInterval = 0
+	}
+	var backendBefore float64
+	scrapeBackend := settings.backendMetrics != nil
+	if scrapeBackend {
+		value, scrapeErr := settings.backendMetrics.Scrape(ctx)
+		if scrapeErr != nil {
+			log.Printf("warning: backend metrics baseline scrape failed, skipping comparison: %v", scrapeErr)
+			scrapeBackend = false
+		}
+		backendBefore = value
+	}
+	var prober *freshness.Prober
+	var onExported func(model.Batch, time.Time)
+	if settings.freshness != nil {
+		prober = freshness.NewProber(ctx, settings.freshness.counter, settings.freshness.config)
+		onExported = prober.Observe
+	}
+	var checker *ingestcheck.Checker
+	if settings.ingestion != nil {
+		checker = ingestcheck.NewChecker(settings.ingestion.config)
+		if onExported == nil {
+			onExported = checker.Observe
+		} else {
+			observeFreshness := onExported
+			onExported = func(batch model.Batch, exportedAt time.Time) {
+				observeFreshness(batch, exportedAt)
+				checker.Observe(batch, exportedAt)
+			}
+		}
+	}
+	err = pipe.RunWithOptions(ctx, runner, factory, pipeline.RunOptions{
+		RequestInterval:      requestInterval,
+		RequestDuration:      cfg.Requests.For.Duration,
+		RampUpDuration:       cfg.Requests.RampUp.Duration,
+		RampWorkersDuration:  cfg.Requests.RampWorkers.Duration,
+		TotalSpans:           cfg.Requests.TotalSpans,
+		ArrivalRate:          cfg.Requests.ArrivalRate,
+		MaxInFlight:          cfg.Requests.MaxInFlight,
+		ExportTimeout:        pipelineExportTimeout,
+		TraceIDSampleLimit:   settings.traceIDSampleLimit,
+		ProgressInterval:     settings.progressInterval,
+		AlignProgress:        settings.alignProgress,
+		ProgressWriter:       settings.progressWriter,
+		SummaryInterval:      settings.summaryInterval,
+		OnSummary:            settings.onSummary,
+		TimeFormat:           settings.timeFormat,
+		Warmup:               settings.warmup,
+		SlowestRequestsLimit: settings.slowestRequestsLimit,
+		WorkerBreakdown:      settings.workerBreakdown,
+		RequestSize: func(batch model.Batch) int {
+			return otlp.RequestSize(batch, cfg.Endpoint.ResourceGrouping)
+		},
+		ExportRetries:   cfg.Requests.Retries,
+		RetryBackoff:    cfg.Requests.RetryBackoff.Duration,
+		LoadProfile:     profile,
+		OnExported:      onExported,
+		Audit:           settings.audit,
+		Heartbeat:       settings.heartbeat,
+		ContinueOnError: settings.continueOnError,
+		OnResult:        settings.onResult,
+	})
+	summary := pipe.Summary()
+	if prober != nil {
+		_, _ = fmt.Fprintln(os.Stderr, "Waiting for freshness probes to finish...")
+		result := prober.Wait()
+		summary.Freshness = &result
+	}
+	if checker != nil && ctx.Err() == nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Verifying ingestion of the traces sent (up to %s)...\n", settings.ingestion.config.Timeout)
+		result := checker.Check(ctx, settings.ingestion.counter)
+		summary.Ingestion = &result
+	}
+	if scrapeBackend {
+		summary.Backend = compareBackend(ctx, settings, backendBefore, summary.SuccessfulSpans)
+	}
+	return summary, err
+}
+
+// compareBackend waits for the backend to flush what it received, scrapes
+// its ingest counter again and compares the increase with sent. It returns
+// nil when the final scrape fails or ctx is cancelled while waiting.
+func compareBackend(ctx context.Context, settings runSettings, before float64, sent int) *metrics.BackendComparison {
+	if settings.backendMetricsSettle > 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "Waiting %s for backend metrics to settle...\n", settings.backendMetricsSettle)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(settings.backendMetricsSettle):
+		}
+	}
+	after, err := settings.backendMetrics.Scrape(ctx)
+	if err != nil {
+		log.Printf("warning: backend metrics scrape failed, skipping comparison: %v", err)
+		return nil
+	}
+	return &metrics.BackendComparison{
+		Metric: settings.backendMetrics.Metric,
+		Before: before,
+		After:  after,
+		Sent:   sent,
+	}
+}
+
+// runCampaign executes every run of the campaign sequentially, each with a
+// fresh pipeline and exporters. A failed run is recorded and the campaign
+// moves on; cancelling ctx stops it early.
+func runCampaign(ctx context.Context, campaignCfg campaign.Config, base config.Config, settings runSettings) ([]campaign.Result, error) {
+	runs, err := campaignCfg.Expand(base)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]campaign.Result, 0, len(runs))
+	for i, run := range runs {
+		if i > 0 && campaignCfg.Pause.Duration > 0 {
+			select {
+			case <-ctx.Done():
+				return results, ctx.Err()
+			case <-time.After(campaignCfg.Pause.Duration):
+			}
+		}
+		if ctx.Err() != nil {
+			return results, ctx.Err()
+		}
+
+		_, _ = fmt.Fprintf(os.Stderr, "Campaign run %d/%d: %s\n", i+1, len(runs), run.Name)
+		err := applyEndpointSchemeSecurityDefaults(run.Config.Endpoint.Address, &run.Config.Endpoint.Insecure, settings.insecureExplicit)
+		result := campaign.Result{Run: run}
+		if err != nil {
+			result.Err = fmt.Errorf("invalid endpoint security: %w", err)
+			results = append(results, result)
+			continue
+		}
+		pipe, factory, err := prepareRun(ctx, run.Config, settings)
+		if err != nil {
+			result.Err = err
+			results = append(results, result)
+			continue
+		}
+		result.Summary, result.Err = executeRun(ctx, pipe, factory, run.Config, settings)
+		_, _ = fmt.Fprintln(os.Stderr, metrics.FormatSummaryWith(result.Summary, settings.timeFormat))
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func writeCampaignReportFile(path string, name string, results []campaign.Result) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := campaign.WriteReport(file, name, results); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/config"
+	"github.com/javiermolinar/tercios/internal/scenario"
+	"github.com/javiermolinar/tercios/internal/servicegraph"
+)
+
+// runScenarioFromServiceGraphCommand handles `tercios scenario
+// from-service-graph` and returns the process exit code. It queries the
+// service graph metrics in Prometheus and writes the inferred scenario.
+func runScenarioFromServiceGraphCommand(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("tercios scenario from-service-graph", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	prometheusURL := flags.String("prometheus-url", "", "Prometheus-compatible API holding the service graph metrics, e.g. http://localhost:9090")
+	windowSeconds := flags.Float64("window", 300, "seconds of traffic to average rates and latencies over")
+	prefix := flags.String("metric-prefix", servicegraph.DefaultPrefix, "service graph metric name prefix")
+	name := flags.String("name", "service-graph", "scenario name")
+	output := flags.String("output", "", "write the scenario JSON to this file instead of stdout")
+	var headers config.HeaderFlags
+	flags.Var(&headers, "header", "header to send to Prometheus as KEY=VALUE; repeatable")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *prometheusURL == "" || flags.NArg() > 0 {
+		_, _ = fmt.Fprintln(stderr, "usage: tercios scenario from-service-graph --prometheus-url=URL [--window=S] [--metric-prefix=PREFIX] [--name=NAME] [--output=FILE]")
+		return 2
+	}
+	if *windowSeconds < 1 {
+		_, _ = fmt.Fprintln(stderr, "scenario from-service-graph requires --window >= 1")
+		return 2
+	}
+
+	querier := servicegraph.NewQuerier(*prometheusURL, time.Duration(*windowSeconds*float64(time.Second)))
+	querier.Prefix = *prefix
+	querier.Headers = headers.Values()
+	edges, err := querier.Edges(ctx)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "query service graph: %v\n", err)
+		return 1
+	}
+	cfg, warnings, err := scenario.FromServiceGraph(*name, edges)
+	for _, warning := range warnings {
+		_, _ = fmt.Fprintf(stderr, "warning: %s\n", warning)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "infer scenario: %v\n", err)
+		return 1
+	}
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "encode scenario: %v\n", err)
+		return 1
+	}
+	data = append(data, '\n')
+	if *output == "" {
+		_, _ = stdout.Write(data)
+		return 0
+	}
+	if err := os.WriteFile(*output, data, 0o644); err != nil {
+		_, _ = fmt.Fprintf(stderr, "write scenario: %v\n", err)
+		return 1
+	}
+	_, _ = fmt.Fprintf(stderr, "Wrote scenario %q (%d services, %d edges) to %s\n", cfg.Name, len(cfg.Services), len(cfg.Edges), *output)
+	return 0
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/javiermolinar/tercios/internal/model"
+	"github.com/javiermolinar/tercios/internal/replay"
+	"github.com/javiermolinar/tercios/internal/scenario"
+)
+
+// runScenarioCommand handles `tercios scenario SUBCOMMAND` and returns the
+// process exit code.
+func runScenarioCommand(args []string, stdout, stderr io.Writer) int {
+	if len(args) > 0 && args[0] == "graph" {
+		return runScenarioGraphCommand(args[1:], stdout, stderr)
+	}
+	if len(args) > 0 && args[0] == "from-service-graph" {
+		return runScenarioFromServiceGraphCommand(context.Background(), args[1:], stdout, stderr)
+	}
+	if len(args) == 0 || args[0] != "from-trace" {
+		_, _ = fmt.Fprintln(stderr, "usage: tercios scenario from-trace [--trace-id=ID] [--name=NAME] [--output=FILE] TRACE_FILE")
+		_, _ = fmt.Fprintln(stderr, "       tercios scenario graph -f SCENARIO_FILE [--format=dot|mermaid] [--output=FILE]")
+		_, _ = fmt.Fprintln(stderr, "       tercios scenario from-service-graph --prometheus-url=URL [--window=S] [--name=NAME] [--output=FILE]")
+		return 2
+	}
+	flags := flag.NewFlagSet("tercios scenario from-trace", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	traceID := flags.String("trace-id", "", "trace to infer the scenario from when the file holds several (default: the one with the most spans)")
+	name := flags.String("name", "", "scenario name (default: the trace file name)")
+	output := flags.String("output", "", "write the scenario JSON to this file instead of stdout")
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		_, _ = fmt.Fprintln(stderr, "scenario from-trace requires exactly one OTLP or Jaeger JSON trace file")
+		return 2
+	}
+	path := flags.Arg(0)
+
+	batches, err := replay.ReadFile(path)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "read trace file: %v\n", err)
+		return 1
+	}
+	spans, err := pickTrace(batches, strings.TrimSpace(*traceID))
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "%v\n", err)
+		return 1
+	}
+	scenarioName := *name
+	if scenarioName == "" {
+		scenarioName = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	cfg, err := scenario.FromTrace(scenarioName, spans)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "infer scenario: %v\n", err)
+		return 1
+	}
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "encode scenario: %v\n", err)
+		return 1
+	}
+	data = append(data, '\n')
+	if *output == "" {
+		_, _ = stdout.Write(data)
+		return 0
+	}
+	if err := os.WriteFile(*output, data, 0o644); err != nil {
+		_, _ = fmt.Fprintf(stderr, "write scenario: %v\n", err)
+		return 1
+	}
+	_, _ = fmt.Fprintf(stderr, "Wrote scenario %q (%d services, %d nodes, %d edges) to %s\n", cfg.Name, len(cfg.Services), len(cfg.Nodes), len(cfg.Edges), *output)
+	return 0
+}
+
+// pickTrace groups the spans of every batch by trace and returns the trace
+// with traceID, or the largest one when traceID is empty.
+func pickTrace(batches []model.Batch, traceID string) ([]model.Span, error) {
+	traces := map[string][]model.Span{}
+	var order []string
+	for _, batch := range batches {
+		for _, span := range batch {
+			id := span.TraceID.String()
+			if _, ok := traces[id]; !ok {
+				order = append(order, id)
+			}
+			traces[id] = append(traces[id], span)
+		}
+	}
+	if traceID != "" {
+		spans, ok := traces[strings.ToLower(traceID)]
+		if !ok {
+			return nil, fmt.Errorf("trace %s not found in the file", traceID)
+		}
+		return spans, nil
+	}
+	var largest []model.Span
+	for _, id := range order {
+		if len(traces[id]) > len(largest) {
+			largest = traces[id]
+		}
+	}
+	if len(largest) == 0 {
+		return nil, fmt.Errorf("trace file contains no spans")
+	}
+	return largest, nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/javiermolinar/tercios/internal/scenario"
+)
+
+// runScenarioGraphCommand handles `tercios scenario graph` and returns the
+// process exit code. It renders the topology of a scenario file as a
+// Graphviz DOT or Mermaid graph, to review it before a run.
+func runScenarioGraphCommand(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("tercios scenario graph", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	var file string
+	flags.StringVar(&file, "f", "", "scenario JSON or YAML file to render")
+	flags.StringVar(&file, "scenario-file", "", "scenario JSON or YAML file to render")
+	format := flags.String("format", string(scenario.GraphFormatDOT), "graph format: dot or mermaid")
+	output := flags.String("output", "", "write the graph to this file instead of stdout")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if file == "" && flags.NArg() == 1 {
+		file = flags.Arg(0)
+	} else if flags.NArg() > 0 {
+		_, _ = fmt.Fprintln(stderr, "usage: tercios scenario graph -f SCENARIO_FILE [--format=dot|mermaid] [--output=FILE]")
+		return 2
+	}
+	if file == "" {
+		_, _ = fmt.Fprintln(stderr, "scenario graph requires a scenario file (-f)")
+		return 2
+	}
+	graphFormat, err := scenario.ParseGraphFormat(*format)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "%v\n", err)
+		return 2
+	}
+
+	cfg, err := scenario.LoadFromJSON(file)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "invalid scenario: %v\n", err)
+		return 1
+	}
+	graph := scenario.RenderGraph(cfg, graphFormat)
+	if *output == "" {
+		_, _ = io.WriteString(stdout, graph)
+		return 0
+	}
+	if err := os.WriteFile(*output, []byte(graph), 0o644); err != nil {
+		_, _ = fmt.Fprintf(stderr, "write graph: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/javiermolinar/tercios/internal/chaos"
+	"github.com/javiermolinar/tercios/internal/config"
+	"github.com/javiermolinar/tercios/internal/jsonschema"
+	"github.com/javiermolinar/tercios/internal/scenario"
+)
+
+// schemaKind is one file format `tercios schema` knows: its JSON Schema
+// and the loader that validates a file of that format.
+type schemaKind struct {
+	schema func() *jsonschema.Schema
+	load   func(path string) error
+}
+
+var schemaKinds = map[string]schemaKind{
+	"config": {
+		schema: config.JSONSchema,
+		load: func(path string) error {
+			_, err := config.LoadFromFile(path)
+			return err
+		},
+	},
+	"chaos": {
+		schema: chaos.JSONSchema,
+		load: func(path string) error {
+			_, err := chaos.LoadFromJSON(path)
+			return err
+		},
+	},
+	"scenario": {
+		schema: scenario.JSONSchema,
+		load: func(path string) error {
+			_, err := scenario.LoadFromJSON(path)
+			return err
+		},
+	},
+}
+
+// runSchemaCommand handles `tercios schema KIND [FILE...]` and returns the
+// process exit code. Without files it prints the JSON Schema of KIND;
+// with files it validates each of them as a file of that kind.
+func runSchemaCommand(args []string, stdout, stderr io.Writer) int {
+	kinds := slices.Sorted(maps.Keys(schemaKinds))
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		_, _ = fmt.Fprintf(stderr, "usage: tercios schema %s [FILE...]\n", strings.Join(kinds, "|"))
+		return 2
+	}
+	kind, ok := schemaKinds[args[0]]
+	if !ok {
+		_, _ = fmt.Fprintf(stderr, "unknown schema %q (%s)\n", args[0], strings.Join(kinds, ", "))
+		return 2
+	}
+	files := args[1:]
+	if len(files) == 0 {
+		data, err := json.MarshalIndent(kind.schema(), "", "  ")
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "encode schema: %v\n", err)
+			return 1
+		}
+		_, _ = stdout.Write(append(data, '\n'))
+		return 0
+	}
+	code := 0
+	for _, path := range files {
+		if err := kind.load(path); err != nil {
+			_, _ = fmt.Fprintf(stderr, "%s: %v\n", path, err)
+			code = 1
+			continue
+		}
+		_, _ = fmt.Fprintf(stdout, "%s: ok\n", path)
+	}
+	return code
+}
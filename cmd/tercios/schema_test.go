@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPublishedSchemasAreUpToDate(t *testing.T) {
+	for kind := range schemaKinds {
+		var stdout, stderr bytes.Buffer
+		if code := runSchemaCommand([]string{kind}, &stdout, &stderr); code != 0 {
+			t.Fatalf("schema %s: exit %d: %s", kind, code, stderr.String())
+		}
+		path := filepath.Join("..", "..", "docs", "schemas", kind+".schema.json")
+		published, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read %s: %v", path, err)
+		}
+		if !bytes.Equal(published, stdout.Bytes()) {
+			t.Fatalf("%s is stale; run make schemas", path)
+		}
+	}
+}
+
+func TestRunSchemaCommandValidatesFiles(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "policies.yaml")
+	invalid := filepath.Join(dir, "typo.json")
+	if err := os.WriteFile(valid, []byte(`$schema: ./chaos.schema.json
+policies:
+  - name: errors
+    probability: 1
+    match:
+      service_name: checkout
+    actions:
+      - type: set_status
+        code: error
+`), 0o644); err != nil {
+		t.Fatalf("write policies: %v", err)
+	}
+	if err := os.WriteFile(invalid, []byte(`{"policies": [{"name": "slow", "probability": 1, "match": {"servce_name": "api"}, "actions": []}]}`), 0o644); err != nil {
+		t.Fatalf("write policies: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runSchemaCommand([]string{"chaos", valid, invalid}, &stdout, &stderr); code != 1 {
+		t.Fatalf("exit code = %d, want 1", code)
+	}
+	if !strings.Contains(stdout.String(), valid+": ok") {
+		t.Fatalf("stdout = %q, want %s reported ok", stdout.String(), valid)
+	}
+	if want := `policies[0].match: unknown field "servce_name"`; !strings.Contains(stderr.String(), want) {
+		t.Fatalf("stderr = %q, want it to contain %q", stderr.String(), want)
+	}
+
+	if code := runSchemaCommand([]string{"campaign"}, &stdout, &stderr); code != 2 {
+		t.Fatalf("exit code for an unknown kind = %d, want 2", code)
+	}
+}
@@ -0,0 +1,95 @@
+// Package audit records, per generated batch, which generator produced it,
+// which chaos policies hit which spans, and how each pipeline stage changed
+// the span count, as NDJSON for post-hoc debugging of exported data.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// StageCount is the number of spans a pipeline stage received and returned.
+type StageCount struct {
+	Stage    string `json:"stage"`
+	SpansIn  int    `json:"spans_in"`
+	SpansOut int    `json:"spans_out"`
+}
+
+// PolicyHit is one chaos policy applied to one span. SpanName is the name
+// the span had before the policy's actions ran.
+type PolicyHit struct {
+	Policy   string `json:"policy"`
+	TraceID  string `json:"trace_id"`
+	SpanID   string `json:"span_id"`
+	SpanName string `json:"span_name"`
+}
+
+// Record is the audit entry for one batch. It is filled by a single
+// producer goroutine while the batch moves through the pipeline, so its
+// methods are not safe for concurrent use. All methods accept a nil
+// receiver, so callers can record unconditionally.
+type Record struct {
+	Time      time.Time    `json:"time"`
+	Worker    int          `json:"worker"`
+	Request   int          `json:"request"`
+	Generator string       `json:"generator,omitempty"`
+	Stages    []StageCount `json:"stages"`
+	Chaos     []PolicyHit  `json:"chaos,omitempty"`
+	Spans     int          `json:"spans"`
+}
+
+func (r *Record) SetGenerator(name string) {
+	if r == nil {
+		return
+	}
+	r.Generator = name
+}
+
+func (r *Record) AddStage(stage string, spansIn int, spansOut int) {
+	if r == nil {
+		return
+	}
+	r.Stages = append(r.Stages, StageCount{Stage: stage, SpansIn: spansIn, SpansOut: spansOut})
+}
+
+func (r *Record) AddPolicyHit(hit PolicyHit) {
+	if r == nil {
+		return
+	}
+	r.Chaos = append(r.Chaos, hit)
+}
+
+type recordKey struct{}
+
+// WithRecord returns a context carrying record, so stages and generators
+// deep in the pipeline can add to the batch's audit entry.
+func WithRecord(ctx context.Context, record *Record) context.Context {
+	return context.WithValue(ctx, recordKey{}, record)
+}
+
+// FromContext returns the record carried by ctx, or nil when the batch is
+// not audited.
+func FromContext(ctx context.Context) *Record {
+	record, _ := ctx.Value(recordKey{}).(*Record)
+	return record
+}
+
+// Log writes records as newline-delimited JSON. It is safe for concurrent
+// use by every producer worker.
+type Log struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+func NewLog(w io.Writer) *Log {
+	return &Log{encoder: json.NewEncoder(w)}
+}
+
+func (l *Log) Write(record *Record) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.encoder.Encode(record)
+}
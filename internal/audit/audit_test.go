@@ -0,0 +1,51 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestLogWritesOneLinePerRecord(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewLog(&buf)
+
+	first := &Record{Worker: 1, Request: 2}
+	first.SetGenerator("scenario:checkout")
+	first.AddStage("scenario", 0, 3)
+	first.AddPolicyHit(PolicyHit{Policy: "slow-db", SpanName: "SELECT"})
+	first.Spans = 3
+	if err := log.Write(first); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := log.Write(&Record{Worker: 0}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 NDJSON lines, got %d: %q", len(lines), buf.String())
+	}
+	var decoded Record
+	if err := json.Unmarshal([]byte(lines[0]), &decoded); err != nil {
+		t.Fatalf("decode first line: %v", err)
+	}
+	if decoded.Generator != "scenario:checkout" || decoded.Spans != 3 || len(decoded.Stages) != 1 || decoded.Chaos[0].Policy != "slow-db" {
+		t.Fatalf("unexpected record %+v", decoded)
+	}
+	if strings.Contains(lines[1], `"chaos"`) {
+		t.Fatalf("expected empty chaos hits to be omitted, got %s", lines[1])
+	}
+}
+
+func TestRecordFromContextIsNilSafe(t *testing.T) {
+	record := FromContext(context.Background())
+	if record != nil {
+		t.Fatalf("expected no record in a plain context")
+	}
+	record.SetGenerator("ignored")
+	record.AddStage("ignored", 0, 0)
+	record.AddPolicyHit(PolicyHit{})
+}
@@ -0,0 +1,135 @@
+// Package backendmetrics reads a tracing backend's own ingest counters from
+// a Prometheus text endpoint, so a run can compare the spans it sent with
+// the spans the backend says it received.
+package backendmetrics
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMetric is the OpenTelemetry Collector's accepted spans counter.
+const DefaultMetric = "otelcol_receiver_accepted_spans_total"
+
+type Scraper struct {
+	URL    string
+	Metric string
+	Client *http.Client
+}
+
+func NewScraper(url string, metric string) Scraper {
+	if metric == "" {
+		metric = DefaultMetric
+	}
+	return Scraper{
+		URL:    url,
+		Metric: metric,
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Scrape returns the sum of every sample of s.Metric across all label sets.
+func (s Scraper) Scrape(ctx context.Context) (float64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return 0, err
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("scrape %s: unexpected status %s", s.URL, resp.Status)
+	}
+	total, found, err := SumMetric(resp.Body, s.Metric)
+	if err != nil {
+		return 0, fmt.Errorf("scrape %s: %w", s.URL, err)
+	}
+	if !found {
+		return 0, fmt.Errorf("scrape %s: metric %q not found", s.URL, s.Metric)
+	}
+	return total, nil
+}
+
+// SumMetric parses the Prometheus text exposition format from r and sums
+// the samples named metric, ignoring labels and timestamps. found reports
+// whether at least one sample was seen.
+func SumMetric(r io.Reader, metric string) (total float64, found bool, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, rest := splitSample(line)
+		if name != metric {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			return 0, false, fmt.Errorf("sample %q has no value", line)
+		}
+		value, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return 0, false, fmt.Errorf("sample %q: %w", line, err)
+		}
+		total += value
+		found = true
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, false, err
+	}
+	return total, found, nil
+}
+
+// splitSample splits a sample line into its metric name and the text after
+// the label set.
+func splitSample(line string) (string, string) {
+	end := strings.IndexAny(line, "{ \t")
+	if end < 0 {
+		return line, ""
+	}
+	name := line[:end]
+	rest := line[end:]
+	if rest[0] == '{' {
+		closing := labelSetEnd(rest)
+		if closing < 0 {
+			return name, ""
+		}
+		rest = rest[closing+1:]
+	}
+	return name, rest
+}
+
+// labelSetEnd returns the index of the } closing the label set at the start
+// of s, skipping braces inside quoted label values.
+func labelSetEnd(s string) int {
+	inQuotes := false
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if inQuotes {
+				i++
+			}
+		case '"':
+			inQuotes = !inQuotes
+		case '}':
+			if !inQuotes {
+				return i
+			}
+		}
+	}
+	return -1
+}
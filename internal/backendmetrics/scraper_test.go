@@ -0,0 +1,54 @@
+package backendmetrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const exposition = `# HELP otelcol_receiver_accepted_spans_total Number of spans successfully pushed into the pipeline.
+# TYPE otelcol_receiver_accepted_spans_total counter
+otelcol_receiver_accepted_spans_total{receiver="otlp",transport="grpc"} 1200
+otelcol_receiver_accepted_spans_total{receiver="otlp",transport="http",note="a } b"} 300 1712345678000
+otelcol_receiver_refused_spans_total{receiver="otlp",transport="grpc"} 7
+otelcol_receiver_accepted_spans_total_created 1.7e+09
+`
+
+func TestSumMetric(t *testing.T) {
+	total, found, err := SumMetric(strings.NewReader(exposition), DefaultMetric)
+	if err != nil {
+		t.Fatalf("SumMetric() error = %v", err)
+	}
+	if !found || total != 1500 {
+		t.Fatalf("expected 1500 accepted spans, got %v (found=%v)", total, found)
+	}
+
+	if _, found, err := SumMetric(strings.NewReader(exposition), "missing_total"); err != nil || found {
+		t.Fatalf("expected missing metric to be not found, got found=%v err=%v", found, err)
+	}
+	if _, _, err := SumMetric(strings.NewReader("spans_total abc\n"), "spans_total"); err == nil {
+		t.Fatalf("expected error for invalid sample value")
+	}
+}
+
+func TestScraperScrape(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, exposition)
+	}))
+	defer server.Close()
+
+	total, err := NewScraper(server.URL, "").Scrape(context.Background())
+	if err != nil {
+		t.Fatalf("Scrape() error = %v", err)
+	}
+	if total != 1500 {
+		t.Fatalf("expected 1500, got %v", total)
+	}
+
+	if _, err := NewScraper(server.URL, "missing_total").Scrape(context.Background()); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
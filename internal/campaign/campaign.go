@@ -0,0 +1,254 @@
+// Package campaign expands a parameter matrix into a sequence of isolated
+// runs, so capacity characterization sweeps (rates × batch sizes ×
+// protocols) can be executed with a single command and reported together.
+package campaign
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/config"
+	"github.com/javiermolinar/tercios/internal/fileformat"
+	"github.com/javiermolinar/tercios/internal/metrics"
+)
+
+// Target is one protocol/endpoint combination. An empty Endpoint keeps the
+// base run's endpoint, which is only useful when it speaks both protocols.
+type Target struct {
+	Protocol config.Protocol `json:"protocol"`
+	Endpoint string          `json:"endpoint,omitempty"`
+}
+
+// Matrix lists the values swept by a campaign. Every combination becomes one
+// run; an empty dimension keeps the base value. Scenario files control the
+// spans sent per request, so they are the batch-size dimension.
+type Matrix struct {
+	Exporters        []int             `json:"exporters,omitempty"`
+	RequestIntervals []config.Duration `json:"request_intervals,omitempty"`
+	ScenarioFiles    []string          `json:"scenario_files,omitempty"`
+	Targets          []Target          `json:"targets,omitempty"`
+}
+
+type Config struct {
+	Name string `json:"name,omitempty"`
+	// Pause is the idle time between runs, giving the backend a chance to
+	// drain so one run's backlog does not skew the next.
+	Pause  config.Duration `json:"pause,omitempty"`
+	Matrix Matrix          `json:"matrix"`
+}
+
+// Run is one expanded combination of the matrix.
+type Run struct {
+	Name   string
+	Config config.Config
+}
+
+// LoadFromFile reads a campaign from a JSON file, or a YAML file when path
+// ends in .yaml/.yml. Relative scenario files are resolved against the
+// directory of path.
+func LoadFromFile(path string) (Config, error) {
+	reader, err := fileformat.Open(path)
+	if err != nil {
+		return Config{}, err
+	}
+	defer func() { _ = reader.Close() }()
+	cfg, err := DecodeJSON(reader)
+	if err != nil {
+		return Config{}, err
+	}
+	for i, file := range cfg.Matrix.ScenarioFiles {
+		if !filepath.IsAbs(file) {
+			cfg.Matrix.ScenarioFiles[i] = filepath.Join(filepath.Dir(path), file)
+		}
+	}
+	return cfg, nil
+}
+
+func DecodeJSON(r io.Reader) (Config, error) {
+	var cfg Config
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&cfg); err != nil {
+		return Config{}, err
+	}
+	if err := decoder.Decode(&struct{}{}); err != io.EOF {
+		return Config{}, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+func (c Config) Validate() error {
+	if c.Pause.Duration < 0 {
+		return fmt.Errorf("pause must be >= 0")
+	}
+	for _, exporters := range c.Matrix.Exporters {
+		if exporters <= 0 {
+			return fmt.Errorf("matrix exporters must be > 0")
+		}
+	}
+	for _, interval := range c.Matrix.RequestIntervals {
+		if interval.Duration < 0 {
+			return fmt.Errorf("matrix request intervals must be >= 0")
+		}
+	}
+	for _, file := range c.Matrix.ScenarioFiles {
+		if strings.TrimSpace(file) == "" {
+			return fmt.Errorf("matrix scenario files cannot be empty")
+		}
+	}
+	for _, target := range c.Matrix.Targets {
+		if target.Protocol != config.ProtocolGRPC && target.Protocol != config.ProtocolHTTP && target.Protocol != config.ProtocolZipkin {
+			return fmt.Errorf("matrix target: unsupported protocol %q", target.Protocol)
+		}
+	}
+	return nil
+}
+
+// Expand returns one run per matrix combination, applied on top of base.
+// Runs are ordered with targets varying slowest and exporters fastest.
+func (c Config) Expand(base config.Config) ([]Run, error) {
+	targets := c.Matrix.Targets
+	if len(targets) == 0 {
+		targets = []Target{{}}
+	}
+	scenarioFiles := c.Matrix.ScenarioFiles
+	if len(scenarioFiles) == 0 {
+		scenarioFiles = []string{""}
+	}
+	intervals := c.Matrix.RequestIntervals
+	if len(intervals) == 0 {
+		intervals = []config.Duration{{Duration: -1}}
+	}
+	exporters := c.Matrix.Exporters
+	if len(exporters) == 0 {
+		exporters = []int{0}
+	}
+
+	runs := make([]Run, 0, len(targets)*len(scenarioFiles)*len(intervals)*len(exporters))
+	for _, target := range targets {
+		for _, file := range scenarioFiles {
+			for _, interval := range intervals {
+				for _, count := range exporters {
+					cfg := cloneConfig(base)
+					var parts []string
+					if target.Protocol != "" {
+						cfg.Endpoint.Protocol = target.Protocol
+						parts = append(parts, "protocol="+string(target.Protocol))
+					}
+					if target.Endpoint != "" {
+						cfg.Endpoint.Address = target.Endpoint
+					}
+					if file != "" {
+						cfg.Scenario.Files = []string{file}
+						parts = append(parts, "scenario="+filepath.Base(file))
+					}
+					if interval.Duration >= 0 {
+						cfg.Requests.Interval = interval
+						parts = append(parts, "interval="+interval.String())
+					}
+					if count > 0 {
+						cfg.Concurrency.Exporters = count
+						parts = append(parts, fmt.Sprintf("exporters=%d", count))
+					}
+					name := strings.Join(parts, " ")
+					if name == "" {
+						name = "base"
+					}
+					if err := cfg.Validate(); err != nil {
+						return nil, fmt.Errorf("run %q: %w", name, err)
+					}
+					runs = append(runs, Run{Name: name, Config: cfg})
+				}
+			}
+		}
+	}
+	return runs, nil
+}
+
+func cloneConfig(in config.Config) config.Config {
+	out := in
+	out.Endpoint.Headers = make(map[string]string, len(in.Endpoint.Headers))
+	for key, value := range in.Endpoint.Headers {
+		out.Endpoint.Headers[key] = value
+	}
+	out.Scenario.Files = append([]string(nil), in.Scenario.Files...)
+	return out
+}
+
+// Result is the outcome of one campaign run.
+type Result struct {
+	Run     Run
+	Summary metrics.Summary
+	Err     error
+}
+
+// Report is the combined machine-readable campaign report.
+type Report struct {
+	Name string      `json:"name,omitempty"`
+	Runs []RunReport `json:"runs"`
+}
+
+type RunReport struct {
+	Name            string         `json:"name"`
+	Protocol        string         `json:"protocol"`
+	Endpoint        string         `json:"endpoint"`
+	Exporters       int            `json:"exporters"`
+	RequestInterval string         `json:"request_interval"`
+	ScenarioFiles   []string       `json:"scenario_files,omitempty"`
+	Error           string         `json:"error,omitempty"`
+	Summary         metrics.Report `json:"summary"`
+}
+
+func NewReport(name string, results []Result) Report {
+	report := Report{Name: name, Runs: make([]RunReport, 0, len(results))}
+	for _, result := range results {
+		cfg := result.Run.Config
+		run := RunReport{
+			Name:            result.Run.Name,
+			Protocol:        string(cfg.Endpoint.Protocol),
+			Endpoint:        cfg.Endpoint.Address,
+			Exporters:       cfg.Concurrency.Exporters,
+			RequestInterval: cfg.Requests.Interval.String(),
+			ScenarioFiles:   cfg.Scenario.Files,
+			Summary:         metrics.NewReport(result.Summary),
+		}
+		if result.Err != nil {
+			run.Error = result.Err.Error()
+		}
+		report.Runs = append(report.Runs, run)
+	}
+	return report
+}
+
+// WriteReport writes the combined campaign report to w as indented JSON.
+func WriteReport(w io.Writer, name string, results []Result) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(NewReport(name, results))
+}
+
+// FormatResults renders one line per run for the terminal.
+func FormatResults(name string, results []Result) string {
+	title := "Campaign results"
+	if name != "" {
+		title = fmt.Sprintf("Campaign %q results", name)
+	}
+	lines := []string{fmt.Sprintf("%s (%d runs):", title, len(results))}
+	for _, result := range results {
+		summary := result.Summary
+		line := fmt.Sprintf("  - %s: %d requests, %d failures, %.2f spans/s, p95 %s",
+			result.Run.Name, summary.Total, summary.Failures, summary.SuccessfulSpansPerSecond, summary.P95Latency.Round(time.Microsecond))
+		if result.Err != nil {
+			line += ", error: " + result.Err.Error()
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
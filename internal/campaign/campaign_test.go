@@ -0,0 +1,144 @@
+package campaign
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/config"
+	"github.com/javiermolinar/tercios/internal/metrics"
+)
+
+func TestExpandBuildsEveryCombination(t *testing.T) {
+	cfg := Config{Matrix: Matrix{
+		Exporters:        []int{1, 4},
+		RequestIntervals: []config.Duration{{Duration: 0}, {Duration: 100 * time.Millisecond}},
+		Targets: []Target{
+			{Protocol: config.ProtocolGRPC, Endpoint: "localhost:4317"},
+			{Protocol: config.ProtocolHTTP, Endpoint: "http://localhost:4318/v1/traces"},
+		},
+	}}
+
+	runs, err := cfg.Expand(config.DefaultConfig())
+	if err != nil {
+		t.Fatalf("Expand() error = %v", err)
+	}
+	if len(runs) != 8 {
+		t.Fatalf("expected 8 runs, got %d", len(runs))
+	}
+	first := runs[0]
+	if first.Name != "protocol=grpc interval=0s exporters=1" {
+		t.Fatalf("unexpected first run name %q", first.Name)
+	}
+	last := runs[len(runs)-1].Config
+	if last.Endpoint.Protocol != config.ProtocolHTTP || last.Endpoint.Address != "http://localhost:4318/v1/traces" {
+		t.Fatalf("unexpected last target: %+v", last.Endpoint)
+	}
+	if last.Concurrency.Exporters != 4 || last.Requests.Interval.Duration != 100*time.Millisecond {
+		t.Fatalf("unexpected last run parameters: exporters=%d interval=%s", last.Concurrency.Exporters, last.Requests.Interval)
+	}
+}
+
+func TestExpandWithEmptyMatrixKeepsBase(t *testing.T) {
+	base := config.DefaultConfig()
+	base.Concurrency.Exporters = 3
+
+	runs, err := Config{}.Expand(base)
+	if err != nil {
+		t.Fatalf("Expand() error = %v", err)
+	}
+	if len(runs) != 1 || runs[0].Name != "base" || runs[0].Config.Concurrency.Exporters != 3 {
+		t.Fatalf("expected a single base run, got %+v", runs)
+	}
+}
+
+func TestExpandDoesNotShareHeadersBetweenRuns(t *testing.T) {
+	base := config.DefaultConfig()
+	base.Endpoint.Headers = map[string]string{"X-Scope-OrgID": "tenant"}
+
+	runs, err := Config{Matrix: Matrix{Exporters: []int{1, 2}}}.Expand(base)
+	if err != nil {
+		t.Fatalf("Expand() error = %v", err)
+	}
+	runs[0].Config.Endpoint.Headers["X-Scope-OrgID"] = "changed"
+	if got := runs[1].Config.Endpoint.Headers["X-Scope-OrgID"]; got != "tenant" {
+		t.Fatalf("expected headers to be copied per run, got %q", got)
+	}
+}
+
+func TestDecodeJSONRejectsInvalidMatrix(t *testing.T) {
+	_, err := DecodeJSON(strings.NewReader(`{"matrix": {"exporters": [0]}}`))
+	if err == nil || !strings.Contains(err.Error(), "exporters") {
+		t.Fatalf("expected exporters error, got %v", err)
+	}
+	_, err = DecodeJSON(strings.NewReader(`{"matrix": {"targets": [{"protocol": "udp"}]}}`))
+	if err == nil || !strings.Contains(err.Error(), "protocol") {
+		t.Fatalf("expected protocol error, got %v", err)
+	}
+}
+
+func TestLoadFromFileResolvesScenarioFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "campaign.yaml")
+	input := `name: batch-sizes
+pause: 2s
+matrix:
+  scenario_files: [small.json, /abs/large.json]
+`
+	if err := os.WriteFile(path, []byte(input), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	cfg, err := LoadFromFile(path)
+	if err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if cfg.Pause.Duration != 2*time.Second {
+		t.Fatalf("expected 2s pause, got %s", cfg.Pause)
+	}
+	want := []string{filepath.Join(dir, "small.json"), "/abs/large.json"}
+	for i, file := range want {
+		if cfg.Matrix.ScenarioFiles[i] != file {
+			t.Fatalf("scenario file %d: expected %q, got %q", i, file, cfg.Matrix.ScenarioFiles[i])
+		}
+	}
+}
+
+func TestWriteReportCombinesRuns(t *testing.T) {
+	runs, err := Config{Matrix: Matrix{Exporters: []int{1, 2}}}.Expand(config.DefaultConfig())
+	if err != nil {
+		t.Fatalf("Expand() error = %v", err)
+	}
+	results := []Result{
+		{Run: runs[0], Summary: metrics.Summary{Total: 5, Successes: 5}},
+		{Run: runs[1], Summary: metrics.Summary{Total: 1, Failures: 1}, Err: errors.New("boom")},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, "sweep", results); err != nil {
+		t.Fatalf("WriteReport() error = %v", err)
+	}
+	var report Report
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("decode report: %v", err)
+	}
+	if report.Name != "sweep" || len(report.Runs) != 2 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if report.Runs[0].Exporters != 1 || report.Runs[0].Summary.TotalRequests != 5 {
+		t.Fatalf("unexpected first run: %+v", report.Runs[0])
+	}
+	if report.Runs[1].Error != "boom" {
+		t.Fatalf("expected second run error, got %q", report.Runs[1].Error)
+	}
+
+	formatted := FormatResults("sweep", results)
+	if !strings.Contains(formatted, `Campaign "sweep" results (2 runs):`) || !strings.Contains(formatted, "error: boom") {
+		t.Fatalf("unexpected formatted results: %q", formatted)
+	}
+}
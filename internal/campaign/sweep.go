@@ -0,0 +1,112 @@
+package campaign
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/config"
+)
+
+// SweepParameters lists the names accepted by ParseSweep, one per matrix
+// dimension.
+var SweepParameters = []string{"exporters", "request-interval", "scenario-file", "protocol"}
+
+// ParseSweep turns a --sweep value such as exporters=1,2,4,8 into a
+// campaign that varies that one parameter, back to back with no pause.
+// Request intervals accept Go durations (100ms) or seconds (0.1), like the
+// --request-interval flag.
+func ParseSweep(value string) (Config, error) {
+	name, list, ok := strings.Cut(strings.TrimSpace(value), "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return Config{}, fmt.Errorf("sweep must be PARAMETER=V1,V2,... (parameters: %s)", strings.Join(SweepParameters, ", "))
+	}
+	var values []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	if len(values) < 2 {
+		return Config{}, fmt.Errorf("sweep %s needs at least two values to compare", name)
+	}
+
+	cfg := Config{Name: "sweep " + name}
+	for _, item := range values {
+		switch name {
+		case "exporters":
+			count, err := strconv.Atoi(item)
+			if err != nil {
+				return Config{}, fmt.Errorf("sweep exporters: invalid value %q", item)
+			}
+			cfg.Matrix.Exporters = append(cfg.Matrix.Exporters, count)
+		case "request-interval":
+			interval, err := parseSweepDuration(item)
+			if err != nil {
+				return Config{}, fmt.Errorf("sweep request-interval: %w", err)
+			}
+			cfg.Matrix.RequestIntervals = append(cfg.Matrix.RequestIntervals, config.Duration{Duration: interval})
+		case "scenario-file":
+			cfg.Matrix.ScenarioFiles = append(cfg.Matrix.ScenarioFiles, item)
+		case "protocol":
+			cfg.Matrix.Targets = append(cfg.Matrix.Targets, Target{Protocol: config.Protocol(item)})
+		default:
+			return Config{}, fmt.Errorf("unsupported sweep parameter %q (parameters: %s)", name, strings.Join(SweepParameters, ", "))
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+func parseSweepDuration(value string) (time.Duration, error) {
+	if parsed, err := time.ParseDuration(value); err == nil {
+		return parsed, nil
+	}
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", value)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// FormatSweep renders a comparison table of sweep runs, with span rate and
+// p95 latency shown as a change against the first run that succeeded.
+func FormatSweep(name string, results []Result) string {
+	var out strings.Builder
+	_, _ = fmt.Fprintf(&out, "Sweep %q results (%d runs, deltas vs. first run):\n", strings.TrimPrefix(name, "sweep "), len(results))
+	table := tabwriter.NewWriter(&out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(table, "  run\trequests\tfailures\tspans/s\tΔ spans/s\tp95\tΔ p95")
+	var baseline *Result
+	for i := range results {
+		result := results[i]
+		summary := result.Summary
+		if result.Err != nil {
+			_, _ = fmt.Fprintf(table, "  %s\terror: %v\n", result.Run.Name, result.Err)
+			continue
+		}
+		rateDelta, latencyDelta := "-", "-"
+		if baseline == nil {
+			baseline = &results[i]
+		} else {
+			rateDelta = percentChange(baseline.Summary.SuccessfulSpansPerSecond, summary.SuccessfulSpansPerSecond)
+			latencyDelta = percentChange(float64(baseline.Summary.P95Latency), float64(summary.P95Latency))
+		}
+		_, _ = fmt.Fprintf(table, "  %s\t%d\t%d\t%.2f\t%s\t%s\t%s\n",
+			result.Run.Name, summary.Total, summary.Failures, summary.SuccessfulSpansPerSecond, rateDelta,
+			summary.P95Latency.Round(time.Microsecond), latencyDelta)
+	}
+	_ = table.Flush()
+	return strings.TrimRight(out.String(), "\n")
+}
+
+func percentChange(before, after float64) string {
+	if before == 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("%+.1f%%", (after-before)/before*100)
+}
@@ -0,0 +1,66 @@
+package campaign
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/config"
+	"github.com/javiermolinar/tercios/internal/metrics"
+)
+
+func TestParseSweepBuildsOneDimension(t *testing.T) {
+	cfg, err := ParseSweep("exporters=1, 2,4,8")
+	if err != nil {
+		t.Fatalf("ParseSweep() error = %v", err)
+	}
+	runs, err := cfg.Expand(config.DefaultConfig())
+	if err != nil {
+		t.Fatalf("Expand() error = %v", err)
+	}
+	if len(runs) != 4 || runs[3].Name != "exporters=8" || runs[3].Config.Concurrency.Exporters != 8 {
+		t.Fatalf("unexpected runs: %+v", runs)
+	}
+	if cfg.Pause.Duration != 0 {
+		t.Fatalf("expected runs back to back, got pause %s", cfg.Pause)
+	}
+}
+
+func TestParseSweepRequestIntervalAcceptsDurationsAndSeconds(t *testing.T) {
+	cfg, err := ParseSweep("request-interval=100ms,0.5")
+	if err != nil {
+		t.Fatalf("ParseSweep() error = %v", err)
+	}
+	got := cfg.Matrix.RequestIntervals
+	if len(got) != 2 || got[0].Duration != 100*time.Millisecond || got[1].Duration != 500*time.Millisecond {
+		t.Fatalf("unexpected intervals %v", got)
+	}
+}
+
+func TestParseSweepRejectsInvalidValues(t *testing.T) {
+	for _, value := range []string{"exporters", "exporters=4", "exporters=1,x", "exporters=0,2", "protocol=grpc,udp", "batch=1,2"} {
+		if _, err := ParseSweep(value); err == nil {
+			t.Fatalf("ParseSweep(%q) expected error", value)
+		}
+	}
+}
+
+func TestFormatSweepShowsDeltasAgainstFirstRun(t *testing.T) {
+	results := []Result{
+		{Run: Run{Name: "exporters=1"}, Summary: metrics.Summary{Total: 10, SuccessfulSpansPerSecond: 100, P95Latency: 10 * time.Millisecond}},
+		{Run: Run{Name: "exporters=2"}, Summary: metrics.Summary{Total: 20, SuccessfulSpansPerSecond: 150, P95Latency: 12 * time.Millisecond}},
+		{Run: Run{Name: "exporters=4"}, Err: errors.New("preflight failed")},
+	}
+	out := FormatSweep("sweep exporters", results)
+	if !strings.HasPrefix(out, `Sweep "exporters" results (3 runs`) {
+		t.Fatalf("unexpected title in:\n%s", out)
+	}
+	lines := strings.Split(out, "\n")
+	if !strings.Contains(lines[3], "+50.0%") || !strings.Contains(lines[3], "+20.0%") {
+		t.Fatalf("expected deltas on the second run, got %q", lines[3])
+	}
+	if !strings.Contains(lines[4], "error: preflight failed") {
+		t.Fatalf("expected failed run to show its error, got %q", lines[4])
+	}
+}
@@ -1,13 +1,19 @@
 package chaos
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
-	"os"
+	"maps"
+	"slices"
 	"strings"
 
+	"github.com/javiermolinar/tercios/internal/fileformat"
+	"github.com/javiermolinar/tercios/internal/httpstatus"
+	"github.com/javiermolinar/tercios/internal/jsonschema"
 	"github.com/javiermolinar/tercios/internal/typedvalue"
+	"github.com/javiermolinar/tercios/internal/validation"
 )
 
 type PolicyMode string
@@ -18,11 +24,34 @@ const (
 )
 
 type Config struct {
+	// Schema points editors at the JSON Schema of the file; see
+	// JSONSchema. It is otherwise ignored.
+	Schema     string     `json:"$schema,omitempty"`
 	Seed       int64      `json:"seed"`
 	PolicyMode PolicyMode `json:"policy_mode"`
 	Policies   []Policy   `json:"policies"`
+	// HTTPStatusMapping decides the span status after a set_attribute
+	// action changes the HTTP status code. Nil uses
+	// httpstatus.DefaultMapping.
+	HTTPStatusMapping *httpstatus.Mapping `json:"http_status_mapping,omitempty"`
+	// MarkerAttribute, when set, names a span attribute listing the
+	// policies applied to each modified span, so chaos-affected spans can
+	// be found in the backend. Usually DefaultMarkerAttribute.
+	MarkerAttribute string `json:"marker_attribute,omitempty"`
 }
 
+func (PolicyMode) JSONSchema() *jsonschema.Schema {
+	return jsonschema.Enum(PolicyModeAll, PolicyModeFirstMatch)
+}
+
+// JSONSchema returns the JSON Schema of chaos policy files.
+func JSONSchema() *jsonschema.Schema {
+	return jsonschema.Generate(Config{}, "tercios chaos policies")
+}
+
+// DefaultMarkerAttribute is the attribute --chaos-marker stamps on spans.
+const DefaultMarkerAttribute = "tercios.chaos.policy"
+
 type Policy struct {
 	Name        string   `json:"name"`
 	Probability float64  `json:"probability"`
@@ -42,16 +71,17 @@ const (
 type TypedValue = typedvalue.TypedValue
 
 type Match struct {
-	ServiceName string                `json:"service_name"`
-	SpanName    string                `json:"span_name"`
-	SpanKinds   []string              `json:"span_kinds"`
-	Attributes  map[string]TypedValue `json:"attributes"`
+	ServiceName string                      `json:"service_name"`
+	SpanName    string                      `json:"span_name"`
+	SpanKinds   []string                    `json:"span_kinds"`
+	Attributes  map[string]AttributeMatcher `json:"attributes"`
 }
 
 type Action struct {
 	Type string `json:"type"`
 
-	// set_attribute
+	// set_attribute, remove_attribute (no value); set_name uses Name as
+	// the new name template
 	Scope string     `json:"scope,omitempty"`
 	Name  string     `json:"name,omitempty"`
 	Value TypedValue `json:"value,omitempty"`
@@ -60,16 +90,24 @@ type Action struct {
 	Code    string `json:"code,omitempty"`
 	Message string `json:"message,omitempty"`
 
-	// add_latency
+	// add_latency, shift_time
 	DeltaMs int64 `json:"delta_ms,omitempty"`
+
+	// add_event (uses Name for the event name)
+	Attributes map[string]TypedValue `json:"attributes,omitempty"`
+	// OffsetMs places the event relative to span start. Nil places it at
+	// the span midpoint, like scenario span events.
+	OffsetMs *int64 `json:"offset_ms,omitempty"`
 }
 
 func DefaultConfig() Config {
 	return Config{PolicyMode: PolicyModeAll}
 }
 
+// LoadFromJSON reads a chaos policies config from path. Files ending in .yaml or
+// .yml are parsed as YAML and decoded with the same strict JSON rules.
 func LoadFromJSON(path string) (Config, error) {
-	file, err := os.Open(path)
+	file, err := fileformat.Open(path)
 	if err != nil {
 		return Config{}, err
 	}
@@ -79,10 +117,14 @@ func LoadFromJSON(path string) (Config, error) {
 
 func DecodeJSON(r io.Reader) (Config, error) {
 	cfg := DefaultConfig()
-	decoder := json.NewDecoder(r)
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Config{}, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
 	decoder.DisallowUnknownFields()
 	if err := decoder.Decode(&cfg); err != nil {
-		return Config{}, err
+		return Config{}, JSONSchema().DecodeError(data, err)
 	}
 	if err := decoder.Decode(&struct{}{}); err != io.EOF {
 		return Config{}, fmt.Errorf("invalid JSON: %w", err)
@@ -93,57 +135,81 @@ func DecodeJSON(r io.Reader) (Config, error) {
 	return cfg, nil
 }
 
+// Validate reports every invalid field at once, each under the JSON path
+// of its policy or action.
 func (c Config) Validate() error {
+	var errs validation.Errors
 	if c.PolicyMode != "" && c.PolicyMode != PolicyModeAll && c.PolicyMode != PolicyModeFirstMatch {
-		return fmt.Errorf("unsupported policy mode %q", c.PolicyMode)
+		errs.Addf("", "unsupported policy mode %q", c.PolicyMode)
 	}
 	for i, policy := range c.Policies {
+		path := validation.Index("policies", i)
 		if strings.TrimSpace(policy.Name) == "" {
-			return fmt.Errorf("policy %d: name is required", i)
+			errs.Addf(path, "name is required")
 		}
 		if policy.Probability < 0 || policy.Probability > 1 {
-			return fmt.Errorf("policy %s: probability must be between 0 and 1", policy.Name)
+			errs.Addf(path, "probability must be between 0 and 1")
 		}
 		if len(policy.Actions) == 0 {
-			return fmt.Errorf("policy %s: at least one action is required", policy.Name)
+			errs.Addf(path, "at least one action is required")
 		}
-		for key, value := range policy.Match.Attributes {
-			if err := value.Validate(fmt.Sprintf("policy %s: match attribute %q", policy.Name, key)); err != nil {
-				return err
-			}
+		for _, key := range slices.Sorted(maps.Keys(policy.Match.Attributes)) {
+			errs.Add("", policy.Match.Attributes[key].Validate(validation.Key(validation.Field(path, "match.attributes"), key)))
 		}
-		for _, action := range policy.Actions {
-			if err := validateAction(policy.Name, action); err != nil {
-				return err
-			}
+		for j, action := range policy.Actions {
+			validateAction(&errs, validation.Index(validation.Field(path, "actions"), j), action)
 		}
 	}
-	return nil
+	return errs.Err()
 }
 
-func validateAction(policyName string, action Action) error {
+func validateAction(errs *validation.Errors, path string, action Action) {
 	typeName := strings.ToLower(strings.TrimSpace(action.Type))
 	switch typeName {
 	case "set_attribute":
 		scope := strings.ToLower(strings.TrimSpace(action.Scope))
 		if scope != "span" && scope != "resource" {
-			return fmt.Errorf("policy %s: set_attribute scope must be span or resource", policyName)
+			errs.Addf(path, "set_attribute scope must be span or resource")
 		}
 		if strings.TrimSpace(action.Name) == "" {
-			return fmt.Errorf("policy %s: set_attribute requires name", policyName)
+			errs.Addf(path, "set_attribute requires name")
 		}
-		if err := action.Value.Validate(fmt.Sprintf("policy %s: set_attribute %q", policyName, action.Name)); err != nil {
-			return err
+		errs.Add("", action.Value.Validate(validation.Field(path, "value")))
+	case "remove_attribute":
+		scope := strings.ToLower(strings.TrimSpace(action.Scope))
+		if scope != "span" && scope != "resource" {
+			errs.Addf(path, "remove_attribute scope must be span or resource")
+		}
+		if strings.TrimSpace(action.Name) == "" {
+			errs.Addf(path, "remove_attribute requires name")
+		}
+		if action.Value.Type != "" || action.Value.Value != nil {
+			errs.Addf(path, "remove_attribute %q does not take a value", action.Name)
+		}
+	case "set_name":
+		if strings.TrimSpace(action.Name) == "" {
+			errs.Addf(path, "set_name requires name")
+		} else if _, err := parseNameTemplate(action.Name); err != nil {
+			errs.Addf(path, "set_name: %v", err)
 		}
 	case "set_status":
 		code := strings.ToLower(strings.TrimSpace(action.Code))
 		if code != "ok" && code != "error" && code != "unset" {
-			return fmt.Errorf("policy %s: set_status code must be ok, error, or unset", policyName)
+			errs.Addf(path, "set_status code must be ok, error, or unset")
+		}
+	case "add_event":
+		if strings.TrimSpace(action.Name) == "" {
+			errs.Addf(path, "add_event requires name")
+		}
+		if action.OffsetMs != nil && *action.OffsetMs < 0 {
+			errs.Addf(path, "add_event %q offset_ms must be >= 0", action.Name)
+		}
+		for _, key := range slices.Sorted(maps.Keys(action.Attributes)) {
+			errs.Add("", action.Attributes[key].Validate(validation.Key(validation.Field(path, "attributes"), key)))
 		}
-	case "add_latency":
+	case "add_latency", "shift_time":
 		// delta_ms can be positive or negative. A zero delta is a valid no-op.
 	default:
-		return fmt.Errorf("policy %s: unsupported action type %q", policyName, action.Type)
+		errs.Addf(path, "unsupported action type %q", action.Type)
 	}
-	return nil
 }
@@ -1,6 +1,8 @@
 package chaos
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -61,6 +63,79 @@ func TestDecodeJSONInvalidActionScope(t *testing.T) {
 	}
 }
 
+func TestDecodeJSONRemoveAttributeRejectsValue(t *testing.T) {
+	input := `{
+  "policies": [
+    {
+      "name": "remove-with-value",
+      "probability": 1,
+      "match": {},
+      "actions": [
+        { "type": "remove_attribute", "scope": "span", "name": "http.route", "value": { "type": "string", "value": "/items" } }
+      ]
+    }
+  ]
+}`
+
+	_, err := DecodeJSON(strings.NewReader(input))
+	if err == nil || !strings.Contains(err.Error(), "does not take a value") {
+		t.Fatalf("expected value error, got %v", err)
+	}
+}
+
+func TestDecodeJSONAddEventRejectsNegativeOffset(t *testing.T) {
+	input := `{
+  "policies": [
+    {
+      "name": "early-event",
+      "probability": 1,
+      "match": {},
+      "actions": [
+        { "type": "add_event", "name": "exception", "offset_ms": -5 }
+      ]
+    }
+  ]
+}`
+
+	_, err := DecodeJSON(strings.NewReader(input))
+	if err == nil || !strings.Contains(err.Error(), "offset_ms must be >= 0") {
+		t.Fatalf("expected offset error, got %v", err)
+	}
+}
+
+func TestDecodeJSONReportsEveryProblem(t *testing.T) {
+	input := `{
+  "policy_mode": "some",
+  "policies": [
+    {
+      "name": "bad-status",
+      "probability": 2,
+      "match": {},
+      "actions": [
+        { "type": "set_status", "code": "broken" },
+        { "type": "teleport" }
+      ]
+    }
+  ]
+}`
+
+	_, err := DecodeJSON(strings.NewReader(input))
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	for _, want := range []string{
+		"4 problems:",
+		`unsupported policy mode "some"`,
+		"policies[0]: probability must be between 0 and 1",
+		"policies[0].actions[0]: set_status code must be ok, error, or unset",
+		`policies[0].actions[1]: unsupported action type "teleport"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected error containing %q, got %v", want, err)
+		}
+	}
+}
+
 func TestDecodeJSONInvalidProbability(t *testing.T) {
 	input := `{
   "policies": [
@@ -120,3 +195,47 @@ func TestDecodeJSONAddLatencyAllowsNegative(t *testing.T) {
 		t.Fatalf("expected valid config, got error: %v", err)
 	}
 }
+
+func TestLoadFromJSONAcceptsYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chaos.yaml")
+	input := `seed: 7
+policies:
+  - name: post-500
+    probability: 1
+    match:
+      service_name: post-service
+      attributes:
+        http.route: { type: string, value: /posts }
+    actions:
+      - type: set_attribute
+        scope: span
+        name: http.response.status_code
+        value: { type: int, value: 500 }
+      - type: add_latency
+        delta_ms: 50
+`
+	if err := os.WriteFile(path, []byte(input), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	cfg, err := LoadFromJSON(path)
+	if err != nil {
+		t.Fatalf("LoadFromJSON() error = %v", err)
+	}
+	if cfg.Seed != 7 || cfg.PolicyMode != PolicyModeAll {
+		t.Fatalf("unexpected config: seed=%d mode=%q", cfg.Seed, cfg.PolicyMode)
+	}
+	if len(cfg.Policies) != 1 || len(cfg.Policies[0].Actions) != 2 {
+		t.Fatalf("expected 1 policy with 2 actions, got %+v", cfg.Policies)
+	}
+}
+
+func TestLoadFromJSONRejectsUnknownYAMLFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chaos.yml")
+	if err := os.WriteFile(path, []byte("policy_mod: all\n"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if _, err := LoadFromJSON(path); err == nil || !strings.Contains(err.Error(), "policy_mod") {
+		t.Fatalf("expected unknown field error, got %v", err)
+	}
+}
@@ -3,10 +3,12 @@ package chaos
 import (
 	"fmt"
 	"maps"
+	"slices"
 	"strings"
 	"sync/atomic"
 	"time"
 
+	"github.com/javiermolinar/tercios/internal/httpstatus"
 	"github.com/javiermolinar/tercios/internal/model"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
@@ -17,11 +19,14 @@ type Span = model.Span
 type ShouldApplyFunc func(probability float64) bool
 
 type Engine struct {
-	mode     PolicyMode
-	policies []compiledPolicy
+	mode       PolicyMode
+	policies   []compiledPolicy
+	httpStatus httpstatus.Mapping
+	marker     string
 }
 
 type compiledPolicy struct {
+	name        string
 	probability float64
 	match       compiledMatch
 	actions     []compiledAction
@@ -31,7 +36,7 @@ type compiledMatch struct {
 	serviceName string
 	spanName    string
 	spanKinds   map[string]struct{}
-	attributes  map[string]attribute.Value
+	attributes  []compiledAttributeMatcher
 }
 
 type actionKind int
@@ -40,6 +45,10 @@ const (
 	actionKindSetAttribute actionKind = iota + 1
 	actionKindSetStatus
 	actionKindAddLatency
+	actionKindRemoveAttribute
+	actionKindAddEvent
+	actionKindShiftTime
+	actionKindSetName
 )
 
 type compiledAction struct {
@@ -52,7 +61,13 @@ type compiledAction struct {
 	statusCode    codes.Code
 	statusMessage string
 
+	// latencyDelta is the add_latency and shift_time delta.
 	latencyDelta time.Duration
+
+	eventAttributes []attribute.KeyValue
+	eventOffset     *time.Duration
+
+	nameTemplate nameTemplate
 }
 
 func NewEngine(cfg Config) (*Engine, error) {
@@ -70,9 +85,16 @@ func NewEngine(cfg Config) (*Engine, error) {
 		return nil, err
 	}
 
+	httpStatus := httpstatus.DefaultMapping()
+	if cfg.HTTPStatusMapping != nil {
+		httpStatus = *cfg.HTTPStatusMapping
+	}
+
 	return &Engine{
-		mode:     mode,
-		policies: policies,
+		mode:       mode,
+		policies:   policies,
+		httpStatus: httpStatus,
+		marker:     strings.TrimSpace(cfg.MarkerAttribute),
 	}, nil
 }
 
@@ -114,6 +136,12 @@ func splitmix64(x uint64) uint64 {
 }
 
 func (e *Engine) Apply(spans []Span, shouldApply ShouldApplyFunc) []Span {
+	return e.ApplyObserved(spans, shouldApply, nil)
+}
+
+// ApplyObserved is Apply, calling observe (when non-nil) with the span as it
+// was before each policy that is applied to it, and the policy's name.
+func (e *Engine) ApplyObserved(spans []Span, shouldApply ShouldApplyFunc, observe func(span *Span, policy string)) []Span {
 	if e == nil || len(spans) == 0 || len(e.policies) == 0 {
 		return spans
 	}
@@ -145,6 +173,7 @@ func (e *Engine) Apply(spans []Span, shouldApply ShouldApplyFunc) []Span {
 			current = &spans[i]
 		}
 
+		var applied []string
 		for _, policy := range e.policies {
 			if !matches(current, policy.match) {
 				continue
@@ -154,8 +183,15 @@ func (e *Engine) Apply(spans []Span, shouldApply ShouldApplyFunc) []Span {
 			}
 
 			target := ensureWritable(i)
+			applied = append(applied, policy.name)
+			if observe != nil {
+				observe(target, policy.name)
+			}
 			for _, action := range policy.actions {
 				applyAction(target, action)
+				if action.kind == actionKindSetAttribute && action.scope == "span" && httpstatus.IsStatusAttribute(action.name) {
+					e.httpStatus.Apply(target)
+				}
 			}
 			current = target
 
@@ -163,6 +199,9 @@ func (e *Engine) Apply(spans []Span, shouldApply ShouldApplyFunc) []Span {
 				break
 			}
 		}
+		if e.marker != "" && len(applied) > 0 {
+			current.Attributes[e.marker] = attribute.StringSliceValue(applied)
+		}
 	}
 
 	if out == nil {
@@ -193,6 +232,7 @@ func compilePolicy(policy Policy) (compiledPolicy, error) {
 		return compiledPolicy{}, err
 	}
 	return compiledPolicy{
+		name:        policy.Name,
 		probability: policy.Probability,
 		match:       compiledMatch,
 		actions:     actions,
@@ -204,7 +244,7 @@ func compileMatch(match Match) (compiledMatch, error) {
 		serviceName: strings.TrimSpace(match.ServiceName),
 		spanName:    strings.TrimSpace(match.SpanName),
 		spanKinds:   make(map[string]struct{}, len(match.SpanKinds)),
-		attributes:  make(map[string]attribute.Value, len(match.Attributes)),
+		attributes:  make([]compiledAttributeMatcher, 0, len(match.Attributes)),
 	}
 	for _, kind := range match.SpanKinds {
 		normalized := strings.ToLower(strings.TrimSpace(kind))
@@ -213,12 +253,12 @@ func compileMatch(match Match) (compiledMatch, error) {
 		}
 		compiled.spanKinds[normalized] = struct{}{}
 	}
-	for key, value := range match.Attributes {
-		normalized, err := compileTypedValue(value)
+	for key, matcher := range match.Attributes {
+		attributeMatcher, err := compileAttributeMatcher(key, matcher)
 		if err != nil {
 			return compiledMatch{}, fmt.Errorf("invalid match attribute %q: %w", key, err)
 		}
-		compiled.attributes[key] = normalized
+		compiled.attributes = append(compiled.attributes, attributeMatcher)
 	}
 	return compiled, nil
 }
@@ -248,6 +288,40 @@ func compileAction(action Action) (compiledAction, error) {
 			name:  strings.TrimSpace(action.Name),
 			value: normalized,
 		}, nil
+	case "remove_attribute":
+		return compiledAction{
+			kind:  actionKindRemoveAttribute,
+			scope: strings.ToLower(strings.TrimSpace(action.Scope)),
+			name:  strings.TrimSpace(action.Name),
+		}, nil
+	case "add_event":
+		attrs := make(map[string]attribute.Value, len(action.Attributes))
+		for key, value := range action.Attributes {
+			normalized, err := compileTypedValue(value)
+			if err != nil {
+				return compiledAction{}, fmt.Errorf("invalid add_event attribute %q: %w", key, err)
+			}
+			attrs[key] = normalized
+		}
+		compiled := compiledAction{
+			kind:            actionKindAddEvent,
+			name:            strings.TrimSpace(action.Name),
+			eventAttributes: model.AttributesFromMap(attrs),
+		}
+		if action.OffsetMs != nil {
+			offset := time.Duration(*action.OffsetMs) * time.Millisecond
+			compiled.eventOffset = &offset
+		}
+		return compiled, nil
+	case "set_name":
+		template, err := parseNameTemplate(strings.TrimSpace(action.Name))
+		if err != nil {
+			return compiledAction{}, err
+		}
+		return compiledAction{
+			kind:         actionKindSetName,
+			nameTemplate: template,
+		}, nil
 	case "set_status":
 		statusCode, err := parseStatusCode(action.Code)
 		if err != nil {
@@ -263,6 +337,11 @@ func compileAction(action Action) (compiledAction, error) {
 			kind:         actionKindAddLatency,
 			latencyDelta: time.Duration(action.DeltaMs) * time.Millisecond,
 		}, nil
+	case "shift_time":
+		return compiledAction{
+			kind:         actionKindShiftTime,
+			latencyDelta: time.Duration(action.DeltaMs) * time.Millisecond,
+		}, nil
 	default:
 		return compiledAction{}, fmt.Errorf("unsupported action type %q", action.Type)
 	}
@@ -311,12 +390,8 @@ func matches(span *Span, match compiledMatch) bool {
 			return false
 		}
 	}
-	for key, want := range match.attributes {
-		got, ok := span.Attributes[key]
-		if !ok {
-			got, ok = span.ResourceAttributes[key]
-		}
-		if !ok || !matchesTypedValue(got, want) {
+	for _, matcher := range match.attributes {
+		if !matcher.matches(span) {
 			return false
 		}
 	}
@@ -352,6 +427,59 @@ func applyAction(span *Span, action compiledAction) {
 		span.StatusDescription = action.statusMessage
 	case actionKindAddLatency:
 		applyLatency(span, action.latencyDelta)
+	case actionKindRemoveAttribute:
+		applyRemoveAttribute(span, action)
+	case actionKindAddEvent:
+		applyAddEvent(span, action)
+	case actionKindShiftTime:
+		applyShiftTime(span, action.latencyDelta)
+	case actionKindSetName:
+		span.Name = action.nameTemplate.render(span)
+	}
+}
+
+// applyShiftTime moves the whole span, events included, by delta to
+// simulate a skewed clock on the emitting host. Events is copied first so
+// the input span's events are left untouched.
+func applyShiftTime(span *Span, delta time.Duration) {
+	if delta == 0 {
+		return
+	}
+	span.StartTime = span.StartTime.Add(delta)
+	span.EndTime = span.EndTime.Add(delta)
+	if len(span.Events) == 0 {
+		return
+	}
+	events := make([]model.Event, len(span.Events))
+	copy(events, span.Events)
+	for i := range events {
+		events[i].Time = events[i].Time.Add(delta)
+	}
+	span.Events = events
+}
+
+// applyAddEvent appends an event at the configured offset from span start,
+// clamped to the span end. Events is clipped first so the append never
+// writes into a backing array shared with the input span.
+func applyAddEvent(span *Span, action compiledAction) {
+	duration := span.EndTime.Sub(span.StartTime)
+	offset := duration / 2
+	if action.eventOffset != nil {
+		offset = min(*action.eventOffset, duration)
+	}
+	span.Events = append(slices.Clip(span.Events), model.Event{
+		Name:       action.name,
+		Time:       span.StartTime.Add(offset),
+		Attributes: action.eventAttributes,
+	})
+}
+
+func applyRemoveAttribute(span *Span, action compiledAction) {
+	switch action.scope {
+	case "span":
+		delete(span.Attributes, action.name)
+	case "resource":
+		delete(span.ResourceAttributes, action.name)
 	}
 }
 
@@ -6,6 +6,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/javiermolinar/tercios/internal/httpstatus"
+	"github.com/javiermolinar/tercios/internal/model"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	oteltrace "go.opentelemetry.io/otel/trace"
@@ -129,8 +131,8 @@ func TestEngineMatchAttributesTyped(t *testing.T) {
 		Policies: []Policy{{
 			Name:        "match-int",
 			Probability: 1,
-			Match: Match{Attributes: map[string]TypedValue{
-				"http.response.status_code": {Type: ValueTypeInt, Value: 200},
+			Match: Match{Attributes: map[string]AttributeMatcher{
+				"http.response.status_code": {TypedValue: TypedValue{Type: ValueTypeInt, Value: 200}},
 			}},
 			Actions: []Action{{Type: "set_status", Code: "error"}},
 		}},
@@ -323,3 +325,267 @@ func TestEngineDoesNotMutateInput(t *testing.T) {
 		t.Fatalf("expected output duration 150ms, got %s", out[0].EndTime.Sub(out[0].StartTime))
 	}
 }
+
+func TestEngineSetHTTPStatusCodeDerivesSpanStatus(t *testing.T) {
+	newEngine := func(mapping *httpstatus.Mapping) *Engine {
+		engine, err := NewEngine(Config{
+			Policies: []Policy{{
+				Name:        "not-found",
+				Probability: 1,
+				Actions: []Action{
+					{Type: "set_attribute", Scope: "span", Name: "http.response.status_code", Value: TypedValue{Type: ValueTypeInt, Value: 404}},
+				},
+			}},
+			HTTPStatusMapping: mapping,
+		})
+		if err != nil {
+			t.Fatalf("NewEngine() error = %v", err)
+		}
+		return engine
+	}
+	input := []Span{
+		{Name: "client", Kind: oteltrace.SpanKindClient, Attributes: map[string]attribute.Value{"http.response.status_code": attribute.Int64Value(200)}, StatusCode: codes.Ok},
+		{Name: "server", Kind: oteltrace.SpanKindServer, Attributes: map[string]attribute.Value{"http.response.status_code": attribute.Int64Value(200)}, StatusCode: codes.Ok},
+	}
+
+	out := newEngine(nil).Apply(input, nil)
+	if out[0].StatusCode != codes.Error || out[0].StatusDescription != "HTTP 404" {
+		t.Fatalf("expected client 404 to be an error by default, got %v %q", out[0].StatusCode, out[0].StatusDescription)
+	}
+	if out[1].StatusCode != codes.Ok {
+		t.Fatalf("expected server 404 to stay ok by default, got %v", out[1].StatusCode)
+	}
+
+	strict := &httpstatus.Mapping{
+		ClientErrors: httpstatus.Ranges{{Min: 400, Max: 599}},
+		ServerErrors: httpstatus.Ranges{{Min: 400, Max: 599}},
+	}
+	out = newEngine(strict).Apply(input, nil)
+	if out[1].StatusCode != codes.Error {
+		t.Fatalf("expected server 404 to be an error with custom mapping, got %v", out[1].StatusCode)
+	}
+}
+
+func TestEngineExplicitSetStatusWinsOverHTTPStatusMapping(t *testing.T) {
+	engine, err := NewEngine(Config{
+		Policies: []Policy{{
+			Name:        "500-but-ok",
+			Probability: 1,
+			Actions: []Action{
+				{Type: "set_attribute", Scope: "span", Name: "http.response.status_code", Value: TypedValue{Type: ValueTypeInt, Value: 500}},
+				{Type: "set_status", Code: "ok"},
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+	input := []Span{{Kind: oteltrace.SpanKindServer, Attributes: map[string]attribute.Value{"http.response.status_code": attribute.Int64Value(200)}}}
+
+	out := engine.Apply(input, nil)
+	if out[0].StatusCode != codes.Ok {
+		t.Fatalf("expected explicit set_status to win, got %v", out[0].StatusCode)
+	}
+}
+
+func TestEngineRemoveAttribute(t *testing.T) {
+	engine, err := NewEngine(Config{
+		Policies: []Policy{{
+			Name:        "drop-instrumentation",
+			Probability: 1,
+			Match:       Match{SpanName: "GET /items"},
+			Actions: []Action{
+				{Type: "remove_attribute", Scope: "span", Name: "http.route"},
+				{Type: "remove_attribute", Scope: "resource", Name: "service.name"},
+				{Type: "remove_attribute", Scope: "span", Name: "non.existing"},
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+
+	in := []Span{{
+		Name:               "GET /items",
+		Attributes:         map[string]attribute.Value{"http.route": attribute.StringValue("/items"), "http.request.method": attribute.StringValue("GET")},
+		ResourceAttributes: map[string]attribute.Value{"service.name": attribute.StringValue("api"), "service.version": attribute.StringValue("1.0.0")},
+	}}
+
+	out := engine.Apply(in, nil)
+	if _, exists := out[0].Attributes["http.route"]; exists {
+		t.Fatalf("expected http.route to be removed")
+	}
+	if _, exists := out[0].Attributes["http.request.method"]; !exists {
+		t.Fatalf("expected http.request.method to be kept")
+	}
+	if _, exists := out[0].ResourceAttributes["service.name"]; exists {
+		t.Fatalf("expected resource service.name to be removed")
+	}
+	if _, exists := out[0].ResourceAttributes["service.version"]; !exists {
+		t.Fatalf("expected resource service.version to be kept")
+	}
+	if _, exists := in[0].ResourceAttributes["service.name"]; !exists {
+		t.Fatalf("expected input resource attributes to remain unchanged")
+	}
+}
+
+func TestEngineAddEvent(t *testing.T) {
+	start := time.Date(2026, time.January, 27, 12, 0, 0, 0, time.UTC)
+	offset := int64(20)
+	lateOffset := int64(500)
+	engine, err := NewEngine(Config{
+		Policies: []Policy{{
+			Name:        "exceptions",
+			Probability: 1,
+			Match:       Match{SpanName: "GET /items"},
+			Actions: []Action{
+				{
+					Type:     "add_event",
+					Name:     "exception",
+					OffsetMs: &offset,
+					Attributes: map[string]TypedValue{
+						"exception.type":    {Type: ValueTypeString, Value: "TimeoutError"},
+						"exception.message": {Type: ValueTypeString, Value: "upstream timed out"},
+					},
+				},
+				{Type: "add_event", Name: "retry"},
+				{Type: "add_event", Name: "late", OffsetMs: &lateOffset},
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+
+	existing := make([]model.Event, 1, 4)
+	existing[0] = model.Event{Name: "cache.miss", Time: start}
+	in := []Span{{
+		Name:      "GET /items",
+		StartTime: start,
+		EndTime:   start.Add(100 * time.Millisecond),
+		Events:    existing,
+	}}
+
+	out := engine.Apply(in, nil)
+	if len(in[0].Events) != 1 || existing[:2][1].Name != "" {
+		t.Fatalf("expected input events to remain unchanged")
+	}
+	events := out[0].Events
+	if len(events) != 4 {
+		t.Fatalf("expected 4 events, got %d", len(events))
+	}
+	if events[1].Name != "exception" || !events[1].Time.Equal(start.Add(20*time.Millisecond)) {
+		t.Fatalf("unexpected exception event %+v", events[1])
+	}
+	if len(events[1].Attributes) != 2 || events[1].Attributes[0].Key != "exception.message" {
+		t.Fatalf("expected sorted exception attributes, got %v", events[1].Attributes)
+	}
+	if !events[2].Time.Equal(start.Add(50 * time.Millisecond)) {
+		t.Fatalf("expected default event at span midpoint, got %s", events[2].Time)
+	}
+	if !events[3].Time.Equal(out[0].EndTime) {
+		t.Fatalf("expected late event clamped to span end, got %s", events[3].Time)
+	}
+}
+
+func TestEngineShiftTimeMovesSpanAndEvents(t *testing.T) {
+	start := time.Date(2026, time.January, 27, 12, 0, 0, 0, time.UTC)
+	end := start.Add(100 * time.Millisecond)
+	engine, err := NewEngine(Config{
+		Policies: []Policy{{
+			Name:        "skewed-clock",
+			Probability: 1,
+			Match:       Match{ServiceName: "billing"},
+			Actions:     []Action{{Type: "shift_time", DeltaMs: -90_000}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+
+	in := []Span{{
+		Name:               "charge",
+		StartTime:          start,
+		EndTime:            end,
+		ResourceAttributes: map[string]attribute.Value{"service.name": attribute.StringValue("billing")},
+		Events:             []model.Event{{Name: "retry", Time: start.Add(10 * time.Millisecond)}},
+	}}
+
+	out := engine.Apply(in, nil)
+	shift := -90 * time.Second
+	if !out[0].StartTime.Equal(start.Add(shift)) || !out[0].EndTime.Equal(end.Add(shift)) {
+		t.Fatalf("expected span shifted by %s, got %s - %s", shift, out[0].StartTime, out[0].EndTime)
+	}
+	if !out[0].Events[0].Time.Equal(start.Add(10*time.Millisecond + shift)) {
+		t.Fatalf("expected event shifted with the span, got %s", out[0].Events[0].Time)
+	}
+	if !in[0].StartTime.Equal(start) || !in[0].Events[0].Time.Equal(start.Add(10*time.Millisecond)) {
+		t.Fatalf("expected input span to remain unchanged")
+	}
+}
+
+func TestEngineSetName(t *testing.T) {
+	engine, err := NewEngine(Config{
+		Policies: []Policy{{
+			Name:        "cardinality-explosion",
+			Probability: 1,
+			Match:       Match{SpanName: "GET"},
+			Actions:     []Action{{Type: "set_name", Name: "{name} {http.target}"}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+
+	in := []Span{{
+		Name:       "GET",
+		Attributes: map[string]attribute.Value{"http.target": attribute.StringValue("/items/42")},
+	}}
+	out := engine.Apply(in, nil)
+	if out[0].Name != "GET /items/42" {
+		t.Fatalf("expected renamed span, got %q", out[0].Name)
+	}
+	if in[0].Name != "GET" {
+		t.Fatalf("expected input name unchanged, got %q", in[0].Name)
+	}
+}
+
+func TestEngineMarkerAttributeListsAppliedPolicies(t *testing.T) {
+	engine, err := NewEngine(Config{
+		MarkerAttribute: DefaultMarkerAttribute,
+		Policies: []Policy{
+			{Name: "slow", Probability: 1, Match: Match{SpanName: "op"}, Actions: []Action{{Type: "add_latency", DeltaMs: 5}}},
+			{Name: "never", Probability: 0, Match: Match{SpanName: "op"}, Actions: []Action{{Type: "add_latency", DeltaMs: 5}}},
+			{Name: "fail", Probability: 1, Match: Match{SpanName: "op"}, Actions: []Action{{Type: "set_status", Code: "error"}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+
+	input := []Span{{Name: "op"}, {Name: "other", Attributes: map[string]attribute.Value{}}}
+	out := engine.Apply(input, func(float64) bool { return true })
+	marker := out[0].Attributes[DefaultMarkerAttribute]
+	if got := marker.AsStringSlice(); len(got) != 2 || got[0] != "slow" || got[1] != "fail" {
+		t.Fatalf("expected marker [slow fail], got %v", marker.Emit())
+	}
+	if _, ok := out[1].Attributes[DefaultMarkerAttribute]; ok {
+		t.Fatalf("expected untouched span to stay unmarked")
+	}
+	if _, ok := input[0].Attributes[DefaultMarkerAttribute]; ok {
+		t.Fatalf("expected input span to stay unmarked")
+	}
+}
+
+func TestEngineWithoutMarkerAttributeAddsNothing(t *testing.T) {
+	engine, err := NewEngine(Config{
+		Policies: []Policy{{Name: "slow", Probability: 1, Match: Match{SpanName: "op"}, Actions: []Action{{Type: "add_latency", DeltaMs: 5}}}},
+	})
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+	out := engine.Apply([]Span{{Name: "op"}}, nil)
+	if len(out[0].Attributes) != 0 {
+		t.Fatalf("expected no attributes, got %v", out[0].Attributes)
+	}
+}
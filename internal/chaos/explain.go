@@ -0,0 +1,212 @@
+package chaos
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/javiermolinar/tercios/internal/fileformat"
+	"github.com/javiermolinar/tercios/internal/httpstatus"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// PolicyExplanation tells whether one policy matches a span and, when it
+// does not, every match criterion that failed.
+type PolicyExplanation struct {
+	Policy      string
+	Probability float64
+	Matched     bool
+	// Reasons lists the failed criteria of a policy that did not match.
+	Reasons []string
+	// ShadowedBy names the earlier policy that stops evaluation before
+	// this one in first_match mode.
+	ShadowedBy string
+}
+
+// Explain reports, in policy order, which policies match span and why the
+// others do not. It assumes every matching policy with a probability above
+// zero fires, so later policies are checked against the span as modified
+// by earlier ones, the way Apply sees it. span itself is not modified.
+func (e *Engine) Explain(span Span) []PolicyExplanation {
+	if e == nil {
+		return nil
+	}
+	current := span
+	current.Attributes = cloneMap(span.Attributes)
+	current.ResourceAttributes = cloneMap(span.ResourceAttributes)
+
+	out := make([]PolicyExplanation, 0, len(e.policies))
+	shadowedBy := ""
+	for _, policy := range e.policies {
+		reasons := explainMatch(&current, policy.match)
+		explanation := PolicyExplanation{
+			Policy:      policy.name,
+			Probability: policy.probability,
+			Matched:     len(reasons) == 0,
+			Reasons:     reasons,
+			ShadowedBy:  shadowedBy,
+		}
+		out = append(out, explanation)
+		if !explanation.Matched || shadowedBy != "" || policy.probability <= 0 {
+			continue
+		}
+		for _, action := range policy.actions {
+			applyAction(&current, action)
+			if action.kind == actionKindSetAttribute && action.scope == "span" && httpstatus.IsStatusAttribute(action.name) {
+				e.httpStatus.Apply(&current)
+			}
+		}
+		if e.mode == PolicyModeFirstMatch {
+			shadowedBy = policy.name
+		}
+	}
+	return out
+}
+
+func explainMatch(span *Span, match compiledMatch) []string {
+	var reasons []string
+	if match.spanName != "" && span.Name != match.spanName {
+		reasons = append(reasons, fmt.Sprintf("span_name: got %q, want %q", span.Name, match.spanName))
+	}
+	if len(match.spanKinds) > 0 {
+		kind := strings.ToLower(span.Kind.String())
+		if _, ok := match.spanKinds[kind]; !ok {
+			kinds := make([]string, 0, len(match.spanKinds))
+			for name := range match.spanKinds {
+				kinds = append(kinds, name)
+			}
+			sort.Strings(kinds)
+			reasons = append(reasons, fmt.Sprintf("span_kinds: got %q, want one of %s", kind, strings.Join(kinds, ", ")))
+		}
+	}
+	if match.serviceName != "" && !hasServiceName(span, match.serviceName) {
+		reasons = append(reasons, fmt.Sprintf("service_name: got %s, want %q", describeServiceName(span), match.serviceName))
+	}
+	// Attribute matchers come from a map; sort their reasons so the
+	// output is stable.
+	var attributeReasons []string
+	for _, matcher := range match.attributes {
+		if !matcher.matches(span) {
+			attributeReasons = append(attributeReasons, matcher.explain(span))
+		}
+	}
+	sort.Strings(attributeReasons)
+	return append(reasons, attributeReasons...)
+}
+
+func describeServiceName(span *Span) string {
+	if got, ok := span.Attributes["service.name"]; ok {
+		return fmt.Sprintf("%q", got.Emit())
+	}
+	if got, ok := span.ResourceAttributes["service.name"]; ok {
+		return fmt.Sprintf("%q", got.Emit())
+	}
+	return "no service.name"
+}
+
+func (m compiledAttributeMatcher) explain(span *Span) string {
+	got, ok := span.Attributes[m.key]
+	if !ok {
+		got, ok = span.ResourceAttributes[m.key]
+	}
+	if !ok {
+		return fmt.Sprintf("attribute %q: missing", m.key)
+	}
+	var want string
+	switch m.op {
+	case MatchOpEqual:
+		want = fmt.Sprintf("== %s %s", strings.ToLower(m.value.Type().String()), m.value.Emit())
+	case MatchOpPrefix, MatchOpContains:
+		want = fmt.Sprintf("%s %q (string)", m.op, m.text)
+	default:
+		want = fmt.Sprintf("%s %v (int or float)", m.op, m.number)
+	}
+	return fmt.Sprintf("attribute %q: got %s %s, want %s", m.key, strings.ToLower(got.Type().String()), got.Emit(), want)
+}
+
+// SpanFile describes one span to explain policies against. Attributes
+// use the typed values of scenario and policy files.
+type SpanFile struct {
+	Name       string                `json:"name"`
+	Kind       string                `json:"kind,omitempty"`
+	Attributes map[string]TypedValue `json:"attributes,omitempty"`
+	Resource   map[string]TypedValue `json:"resource,omitempty"`
+}
+
+// LoadSpanFromFile reads a SpanFile from a JSON file, or a YAML file when
+// path ends in .yaml/.yml.
+func LoadSpanFromFile(path string) (Span, error) {
+	file, err := fileformat.Open(path)
+	if err != nil {
+		return Span{}, err
+	}
+	defer func() { _ = file.Close() }()
+	return DecodeSpanJSON(file)
+}
+
+func DecodeSpanJSON(r io.Reader) (Span, error) {
+	var spanFile SpanFile
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&spanFile); err != nil {
+		return Span{}, err
+	}
+	if err := decoder.Decode(&struct{}{}); err != io.EOF {
+		return Span{}, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return spanFile.Span()
+}
+
+// Span builds the model span the file describes.
+func (f SpanFile) Span() (Span, error) {
+	kind, err := parseSpanKind(f.Kind)
+	if err != nil {
+		return Span{}, err
+	}
+	attributes, err := attributeValues("attributes", f.Attributes)
+	if err != nil {
+		return Span{}, err
+	}
+	resource, err := attributeValues("resource", f.Resource)
+	if err != nil {
+		return Span{}, err
+	}
+	return Span{Name: f.Name, Kind: kind, Attributes: attributes, ResourceAttributes: resource}, nil
+}
+
+func attributeValues(field string, values map[string]TypedValue) (map[string]attribute.Value, error) {
+	out := make(map[string]attribute.Value, len(values))
+	for key, value := range values {
+		if err := value.Validate(fmt.Sprintf("%s.%s", field, key)); err != nil {
+			return nil, err
+		}
+		converted, err := compileTypedValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", field, key, err)
+		}
+		out[key] = converted
+	}
+	return out, nil
+}
+
+func parseSpanKind(value string) (oteltrace.SpanKind, error) {
+	normalized := strings.ToLower(strings.TrimSpace(value))
+	if normalized == "" {
+		return oteltrace.SpanKindInternal, nil
+	}
+	for _, kind := range []oteltrace.SpanKind{
+		oteltrace.SpanKindInternal,
+		oteltrace.SpanKindServer,
+		oteltrace.SpanKindClient,
+		oteltrace.SpanKindProducer,
+		oteltrace.SpanKindConsumer,
+	} {
+		if kind.String() == normalized {
+			return kind, nil
+		}
+	}
+	return oteltrace.SpanKindUnspecified, fmt.Errorf("kind: unsupported span kind %q", value)
+}
@@ -0,0 +1,129 @@
+package chaos
+
+import (
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+func TestEngineExplainReportsEveryFailedCriterion(t *testing.T) {
+	engine, err := NewEngine(Config{
+		Policies: []Policy{
+			{
+				Name:        "slow-checkout",
+				Probability: 0.5,
+				Match: Match{
+					ServiceName: "checkout",
+					SpanName:    "POST /pay",
+					SpanKinds:   []string{"client"},
+					Attributes: map[string]AttributeMatcher{
+						"http.response.status_code": {TypedValue: TypedValue{Value: 500}, Op: MatchOpGreaterE},
+						"region":                    {Op: MatchOpExists},
+					},
+				},
+				Actions: []Action{{Type: "add_latency", DeltaMs: 100}},
+			},
+			{
+				Name:        "any-api",
+				Probability: 1,
+				Match:       Match{ServiceName: "api"},
+				Actions:     []Action{{Type: "add_latency", DeltaMs: 1}},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+
+	span := Span{
+		Name:               "GET /items",
+		Kind:               oteltrace.SpanKindServer,
+		Attributes:         map[string]attribute.Value{"http.response.status_code": attribute.Int64Value(200)},
+		ResourceAttributes: map[string]attribute.Value{"service.name": attribute.StringValue("api")},
+	}
+	explanations := engine.Explain(span)
+	if len(explanations) != 2 {
+		t.Fatalf("expected one explanation per policy, got %d", len(explanations))
+	}
+	miss := explanations[0]
+	if miss.Matched || len(miss.Reasons) != 5 {
+		t.Fatalf("expected 5 failed criteria, got %+v", miss)
+	}
+	want := []string{
+		`span_name: got "GET /items", want "POST /pay"`,
+		`span_kinds: got "server", want one of client`,
+		`service_name: got "api", want "checkout"`,
+		`attribute "http.response.status_code": got int64 200, want >= 500 (int or float)`,
+		`attribute "region": missing`,
+	}
+	for i, reason := range want {
+		if miss.Reasons[i] != reason {
+			t.Fatalf("reason %d = %q, want %q", i, miss.Reasons[i], reason)
+		}
+	}
+	if hit := explanations[1]; !hit.Matched || len(hit.Reasons) != 0 {
+		t.Fatalf("expected any-api to match, got %+v", hit)
+	}
+	if !span.StartTime.IsZero() || span.Attributes["http.response.status_code"].AsInt64() != 200 {
+		t.Fatalf("expected Explain to leave the span untouched")
+	}
+}
+
+func TestEngineExplainSeesEarlierPoliciesActions(t *testing.T) {
+	engine, err := NewEngine(Config{
+		PolicyMode: PolicyModeAll,
+		Policies: []Policy{
+			{
+				Name:        "rename",
+				Probability: 1,
+				Match:       Match{SpanName: "old"},
+				Actions:     []Action{{Type: "set_name", Name: "new"}},
+			},
+			{Name: "on-new", Probability: 1, Match: Match{SpanName: "new"}, Actions: []Action{{Type: "add_latency", DeltaMs: 1}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+	explanations := engine.Explain(Span{Name: "old"})
+	if !explanations[0].Matched || !explanations[1].Matched {
+		t.Fatalf("expected the second policy to match the renamed span, got %+v", explanations)
+	}
+}
+
+func TestEngineExplainMarksPoliciesShadowedInFirstMatchMode(t *testing.T) {
+	engine, err := NewEngine(Config{
+		PolicyMode: PolicyModeFirstMatch,
+		Policies: []Policy{
+			{Name: "first", Probability: 1, Match: Match{SpanName: "op"}, Actions: []Action{{Type: "add_latency", DeltaMs: 1}}},
+			{Name: "second", Probability: 1, Match: Match{SpanName: "op"}, Actions: []Action{{Type: "add_latency", DeltaMs: 1}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+	explanations := engine.Explain(Span{Name: "op"})
+	if explanations[0].ShadowedBy != "" || explanations[1].ShadowedBy != "first" {
+		t.Fatalf("expected second policy shadowed by first, got %+v", explanations)
+	}
+}
+
+func TestDecodeSpanJSON(t *testing.T) {
+	span, err := DecodeSpanJSON(strings.NewReader(`{
+		"name": "GET /items",
+		"kind": "server",
+		"attributes": {"http.response.status_code": {"type": "int", "value": 503}},
+		"resource": {"service.name": {"type": "string", "value": "api"}}
+	}`))
+	if err != nil {
+		t.Fatalf("DecodeSpanJSON() error = %v", err)
+	}
+	if span.Kind != oteltrace.SpanKindServer || span.Attributes["http.response.status_code"].AsInt64() != 503 || span.ResourceAttributes["service.name"].AsString() != "api" {
+		t.Fatalf("unexpected span %+v", span)
+	}
+	if _, err := DecodeSpanJSON(strings.NewReader(`{"name": "x", "kind": "sideways"}`)); err == nil {
+		t.Fatalf("expected error for unknown span kind")
+	}
+}
@@ -0,0 +1,127 @@
+package chaos
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/javiermolinar/tercios/internal/typedvalue"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type MatchOp string
+
+const (
+	MatchOpEqual    MatchOp = "=="
+	MatchOpExists   MatchOp = "exists"
+	MatchOpPrefix   MatchOp = "prefix"
+	MatchOpContains MatchOp = "contains"
+	MatchOpGreater  MatchOp = ">"
+	MatchOpGreaterE MatchOp = ">="
+	MatchOpLess     MatchOp = "<"
+	MatchOpLessE    MatchOp = "<="
+)
+
+// AttributeMatcher matches one span or resource attribute. Without Op it is
+// an equality match against the typed value. Other operators ignore Type:
+// exists takes no value, prefix and contains take a string, and the
+// numeric comparisons take a number and match int and float attributes.
+type AttributeMatcher struct {
+	TypedValue
+	Op MatchOp `json:"op,omitempty"`
+}
+
+type compiledAttributeMatcher struct {
+	key    string
+	op     MatchOp
+	value  attribute.Value
+	text   string
+	number float64
+}
+
+func (m AttributeMatcher) op() MatchOp {
+	op := MatchOp(strings.ToLower(strings.TrimSpace(string(m.Op))))
+	if op == "" || op == "=" {
+		return MatchOpEqual
+	}
+	return op
+}
+
+func (m AttributeMatcher) Validate(field string) error {
+	switch m.op() {
+	case MatchOpEqual:
+		return m.TypedValue.Validate(field)
+	case MatchOpExists:
+		if m.Value != nil {
+			return fmt.Errorf("%s: exists takes no value", field)
+		}
+	case MatchOpPrefix, MatchOpContains:
+		if _, ok := m.Value.(string); !ok {
+			return fmt.Errorf("%s: %s requires a string value", field, m.op())
+		}
+	case MatchOpGreater, MatchOpGreaterE, MatchOpLess, MatchOpLessE:
+		if _, ok := typedvalue.ToFloat64(m.Value); !ok {
+			return fmt.Errorf("%s: %s requires a numeric value", field, m.op())
+		}
+	default:
+		return fmt.Errorf("%s: unsupported match op %q", field, m.Op)
+	}
+	return nil
+}
+
+func compileAttributeMatcher(key string, matcher AttributeMatcher) (compiledAttributeMatcher, error) {
+	compiled := compiledAttributeMatcher{key: key, op: matcher.op()}
+	switch compiled.op {
+	case MatchOpEqual:
+		value, err := compileTypedValue(matcher.TypedValue)
+		if err != nil {
+			return compiledAttributeMatcher{}, err
+		}
+		compiled.value = value
+	case MatchOpPrefix, MatchOpContains:
+		compiled.text, _ = matcher.Value.(string)
+	case MatchOpGreater, MatchOpGreaterE, MatchOpLess, MatchOpLessE:
+		compiled.number, _ = typedvalue.ToFloat64(matcher.Value)
+	}
+	return compiled, nil
+}
+
+func (m compiledAttributeMatcher) matches(span *Span) bool {
+	got, ok := span.Attributes[m.key]
+	if !ok {
+		got, ok = span.ResourceAttributes[m.key]
+	}
+	if !ok {
+		return false
+	}
+	switch m.op {
+	case MatchOpEqual:
+		return matchesTypedValue(got, m.value)
+	case MatchOpExists:
+		return true
+	case MatchOpPrefix:
+		return got.Type() == attribute.STRING && strings.HasPrefix(got.AsString(), m.text)
+	case MatchOpContains:
+		return got.Type() == attribute.STRING && strings.Contains(got.AsString(), m.text)
+	}
+
+	var number float64
+	switch got.Type() {
+	case attribute.INT64:
+		number = float64(got.AsInt64())
+	case attribute.FLOAT64:
+		number = got.AsFloat64()
+	default:
+		return false
+	}
+	switch m.op {
+	case MatchOpGreater:
+		return number > m.number
+	case MatchOpGreaterE:
+		return number >= m.number
+	case MatchOpLess:
+		return number < m.number
+	case MatchOpLessE:
+		return number <= m.number
+	}
+	return false
+}
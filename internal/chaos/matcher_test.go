@@ -0,0 +1,111 @@
+package chaos
+
+import (
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+func TestAttributeMatcherOperators(t *testing.T) {
+	span := &Span{
+		Attributes: map[string]attribute.Value{
+			"http.response.status_code": attribute.Int64Value(503),
+			"http.route":                attribute.StringValue("/api/v1/items"),
+			"duration.ratio":            attribute.Float64Value(0.75),
+		},
+		ResourceAttributes: map[string]attribute.Value{
+			"db.system": attribute.StringValue("postgresql"),
+		},
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		matcher AttributeMatcher
+		want    bool
+	}{
+		{"equality", "http.response.status_code", AttributeMatcher{TypedValue: TypedValue{Type: ValueTypeInt, Value: 503}}, true},
+		{"exists on resource", "db.system", AttributeMatcher{Op: MatchOpExists}, true},
+		{"exists missing", "db.statement", AttributeMatcher{Op: MatchOpExists}, false},
+		{"prefix", "http.route", AttributeMatcher{Op: MatchOpPrefix, TypedValue: TypedValue{Value: "/api/"}}, true},
+		{"prefix mismatch", "http.route", AttributeMatcher{Op: MatchOpPrefix, TypedValue: TypedValue{Value: "/v1"}}, false},
+		{"contains", "http.route", AttributeMatcher{Op: MatchOpContains, TypedValue: TypedValue{Value: "v1"}}, true},
+		{"contains on int", "http.response.status_code", AttributeMatcher{Op: MatchOpContains, TypedValue: TypedValue{Value: "5"}}, false},
+		{"gte int", "http.response.status_code", AttributeMatcher{Op: MatchOpGreaterE, TypedValue: TypedValue{Value: 500}}, true},
+		{"gt int", "http.response.status_code", AttributeMatcher{Op: MatchOpGreater, TypedValue: TypedValue{Value: 503}}, false},
+		{"lt float", "duration.ratio", AttributeMatcher{Op: MatchOpLess, TypedValue: TypedValue{Value: 1}}, true},
+		{"lte float", "duration.ratio", AttributeMatcher{Op: MatchOpLessE, TypedValue: TypedValue{Value: 0.5}}, false},
+		{"numeric on string", "http.route", AttributeMatcher{Op: MatchOpGreater, TypedValue: TypedValue{Value: 0}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compiled, err := compileAttributeMatcher(tt.key, tt.matcher)
+			if err != nil {
+				t.Fatalf("compileAttributeMatcher() error = %v", err)
+			}
+			if got := compiled.matches(span); got != tt.want {
+				t.Fatalf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeJSONAttributeMatcherOperators(t *testing.T) {
+	input := `{
+  "policies": [
+    {
+      "name": "server-errors",
+      "probability": 1,
+      "match": {
+        "attributes": {
+          "http.response.status_code": { "op": ">=", "value": 500 },
+          "db.system": { "op": "exists" },
+          "http.route": { "type": "string", "value": "/items" }
+        }
+      },
+      "actions": [{ "type": "set_status", "code": "error" }]
+    }
+  ]
+}`
+
+	cfg, err := DecodeJSON(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("DecodeJSON() error = %v", err)
+	}
+	engine, err := NewEngine(cfg)
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+
+	newSpan := func(status int64) Span {
+		return Span{Attributes: map[string]attribute.Value{
+			"http.response.status_code": attribute.Int64Value(status),
+			"db.system":                 attribute.StringValue("redis"),
+			"http.route":                attribute.StringValue("/items"),
+		}}
+	}
+	out := engine.Apply([]Span{newSpan(502), newSpan(404)}, nil)
+	if out[0].StatusCode != codes.Error {
+		t.Fatalf("expected 502 span to match")
+	}
+	if out[1].StatusCode == codes.Error {
+		t.Fatalf("expected 404 span not to match")
+	}
+}
+
+func TestDecodeJSONRejectsInvalidAttributeMatcher(t *testing.T) {
+	tests := map[string]string{
+		`{ "op": "between", "value": 1 }`: "unsupported match op",
+		`{ "op": ">", "value": "high" }`:  "numeric value",
+		`{ "op": "prefix", "value": 5 }`:  "string value",
+		`{ "op": "exists", "value": 1 }`:  "no value",
+	}
+	for matcher, want := range tests {
+		input := `{"policies": [{"name": "p", "probability": 1, "match": {"attributes": {"k": ` + matcher + `}}, "actions": [{"type": "set_status", "code": "error"}]}]}`
+		if _, err := DecodeJSON(strings.NewReader(input)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("matcher %s: expected error containing %q, got %v", matcher, want, err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package chaos
+
+import (
+	"fmt"
+	"strings"
+)
+
+// nameTemplate is a compiled set_name template. Placeholders in braces are
+// {name} (the current span name), {span_id}, {trace_id}, or an attribute
+// key looked up on the span and then on its resource. Missing attributes
+// render as an empty string.
+type nameTemplate struct {
+	parts []templatePart
+}
+
+type templatePart struct {
+	literal     string
+	placeholder string
+}
+
+func parseNameTemplate(template string) (nameTemplate, error) {
+	var parts []templatePart
+	rest := template
+	for rest != "" {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			open = len(rest)
+		}
+		if strings.IndexByte(rest[:open], '}') >= 0 {
+			return nameTemplate{}, fmt.Errorf("unexpected } in template %q", template)
+		}
+		if open > 0 {
+			parts = append(parts, templatePart{literal: rest[:open]})
+		}
+		if open == len(rest) {
+			break
+		}
+		closing := strings.IndexByte(rest[open:], '}')
+		if closing < 0 {
+			return nameTemplate{}, fmt.Errorf("unclosed { in template %q", template)
+		}
+		key := strings.TrimSpace(rest[open+1 : open+closing])
+		if key == "" || strings.ContainsRune(key, '{') {
+			return nameTemplate{}, fmt.Errorf("invalid placeholder in template %q", template)
+		}
+		parts = append(parts, templatePart{placeholder: key})
+		rest = rest[open+closing+1:]
+	}
+	return nameTemplate{parts: parts}, nil
+}
+
+func (t nameTemplate) render(span *Span) string {
+	var b strings.Builder
+	for _, part := range t.parts {
+		if part.placeholder == "" {
+			b.WriteString(part.literal)
+			continue
+		}
+		b.WriteString(templateValue(span, part.placeholder))
+	}
+	return b.String()
+}
+
+func templateValue(span *Span, key string) string {
+	switch key {
+	case "name":
+		return span.Name
+	case "span_id":
+		return span.SpanID.String()
+	case "trace_id":
+		return span.TraceID.String()
+	}
+	if value, ok := span.Attributes[key]; ok {
+		return value.Emit()
+	}
+	if value, ok := span.ResourceAttributes[key]; ok {
+		return value.Emit()
+	}
+	return ""
+}
@@ -0,0 +1,43 @@
+package chaos
+
+import (
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+func TestNameTemplateRender(t *testing.T) {
+	span := &Span{
+		Name:               "GET",
+		SpanID:             oteltrace.SpanID{0, 0, 0, 0, 0, 0, 0, 0x2a},
+		Attributes:         map[string]attribute.Value{"http.route": attribute.StringValue("/items/{id}"), "http.response.status_code": attribute.Int64Value(200)},
+		ResourceAttributes: map[string]attribute.Value{"service.version": attribute.StringValue("2.0.0")},
+	}
+
+	tests := map[string]string{
+		"{name} {http.route}":         "GET /items/{id}",
+		"{name}-{span_id}":            "GET-000000000000002a",
+		"v{service.version}: {name}":  "v2.0.0: GET",
+		"{http.response.status_code}": "200",
+		"{name} {missing}":            "GET ",
+		"renamed":                     "renamed",
+	}
+	for input, want := range tests {
+		template, err := parseNameTemplate(input)
+		if err != nil {
+			t.Fatalf("parseNameTemplate(%q) error = %v", input, err)
+		}
+		if got := template.render(span); got != want {
+			t.Fatalf("render(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestParseNameTemplateRejectsMalformed(t *testing.T) {
+	for _, input := range []string{"{name", "name}", "{}", "{{name}}"} {
+		if _, err := parseNameTemplate(input); err == nil {
+			t.Fatalf("parseNameTemplate(%q): expected error", input)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Package clock provides tickers for periodic reports. An aligned ticker
+// fires on wall-clock boundaries instead of at offsets from its start, so
+// reports of several tercios processes cover the same intervals and can
+// be merged.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Ticker delivers ticks on C until Stop is called. Like time.Ticker, it
+// drops ticks a slow receiver misses.
+type Ticker struct {
+	C    <-chan time.Time
+	stop chan struct{}
+	once sync.Once
+	// ticker is set for an unaligned ticker.
+	ticker *time.Ticker
+}
+
+// NewTicker returns a ticker firing every interval, on wall-clock
+// multiples of it when align is set (see NextBoundary).
+func NewTicker(interval time.Duration, align bool) *Ticker {
+	if !align {
+		ticker := time.NewTicker(interval)
+		return &Ticker{C: ticker.C, ticker: ticker}
+	}
+	c := make(chan time.Time, 1)
+	t := &Ticker{C: c, stop: make(chan struct{})}
+	go func() {
+		timer := time.NewTimer(time.Until(NextBoundary(time.Now(), interval)))
+		defer timer.Stop()
+		for {
+			select {
+			case <-t.stop:
+				return
+			case now := <-timer.C:
+				// Timers can fire a little early or late, so the tick
+				// carries the boundary it was due at, and each wait is
+				// computed afresh so ticks do not drift off the boundaries.
+				boundary := NextBoundary(now.Add(-interval/2), interval)
+				select {
+				case c <- boundary:
+				default:
+				}
+				timer.Reset(time.Until(boundary.Add(interval)))
+			}
+		}
+	}()
+	return t
+}
+
+// Stop turns the ticker off. It does not close C.
+func (t *Ticker) Stop() {
+	if t.ticker != nil {
+		t.ticker.Stop()
+		return
+	}
+	t.once.Do(func() { close(t.stop) })
+}
+
+// NextBoundary returns the first multiple of interval after now, counted
+// from the zero time in UTC, so a 1m interval ticks on every full minute
+// and a 1h interval on every full hour.
+func NextBoundary(now time.Time, interval time.Duration) time.Time {
+	return now.UTC().Truncate(interval).Add(interval)
+}
@@ -0,0 +1,47 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextBoundary(t *testing.T) {
+	now := time.Date(2026, 3, 4, 10, 17, 42, 5, time.UTC)
+	for interval, want := range map[time.Duration]time.Time{
+		time.Minute:      time.Date(2026, 3, 4, 10, 18, 0, 0, time.UTC),
+		15 * time.Minute: time.Date(2026, 3, 4, 10, 30, 0, 0, time.UTC),
+		time.Hour:        time.Date(2026, 3, 4, 11, 0, 0, 0, time.UTC),
+	} {
+		if got := NextBoundary(now, interval); !got.Equal(want) {
+			t.Fatalf("NextBoundary(%s) = %s, want %s", interval, got, want)
+		}
+	}
+	if got := NextBoundary(time.Date(2026, 3, 4, 10, 18, 0, 0, time.UTC), time.Minute); got.Minute() != 19 {
+		t.Fatalf("expected a boundary strictly after now, got %s", got)
+	}
+	if got := NextBoundary(time.Date(2026, 3, 4, 12, 17, 0, 0, time.FixedZone("CEST", 2*3600)), time.Hour); got.Hour() != 11 || got.Location() != time.UTC {
+		t.Fatalf("expected UTC hour boundaries, got %s", got)
+	}
+}
+
+func TestAlignedTickerFiresOnBoundaries(t *testing.T) {
+	const interval = 100 * time.Millisecond
+	ticker := NewTicker(interval, true)
+	defer ticker.Stop()
+
+	var previous time.Time
+	for range 3 {
+		select {
+		case tick := <-ticker.C:
+			if !tick.Equal(tick.Truncate(interval)) {
+				t.Fatalf("expected tick on a %s boundary, got %s", interval, tick.Format(time.RFC3339Nano))
+			}
+			if !previous.IsZero() && (tick.Sub(previous) <= 0 || tick.Sub(previous)%interval != 0) {
+				t.Fatalf("expected later boundaries, got %s after %s", tick.Format(time.RFC3339Nano), previous.Format(time.RFC3339Nano))
+			}
+			previous = tick
+		case <-time.After(2 * time.Second):
+			t.Fatalf("aligned ticker did not fire")
+		}
+	}
+}
@@ -1,9 +1,19 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
 	"time"
+
+	"github.com/javiermolinar/tercios/internal/fileformat"
+	"github.com/javiermolinar/tercios/internal/jsonschema"
+	"github.com/javiermolinar/tercios/internal/loadprofile"
+	"github.com/javiermolinar/tercios/internal/scenario/library"
+	"github.com/javiermolinar/tercios/internal/validation"
 )
 
 type Protocol string
@@ -11,8 +21,49 @@ type Protocol string
 const (
 	ProtocolGRPC Protocol = "grpc"
 	ProtocolHTTP Protocol = "http"
+	// ProtocolZipkin posts Zipkin v2 JSON instead of OTLP.
+	ProtocolZipkin Protocol = "zipkin"
+)
+
+// Compression is the payload compression of every protocol. The SDK
+// exporters only offer gzip, so the otlp package adds zstd itself.
+type Compression string
+
+const (
+	CompressionNone Compression = "none"
+	CompressionGzip Compression = "gzip"
+	CompressionZstd Compression = "zstd"
+)
+
+// ResourceGrouping decides how spans are laid out in ResourceSpans in each
+// OTLP request. Collectors and backends differ in how they handle each
+// shape, so all three can be reproduced.
+type ResourceGrouping string
+
+const (
+	// ResourceGroupingResource emits one ResourceSpans per distinct
+	// resource, like the OpenTelemetry SDK batch processor.
+	ResourceGroupingResource ResourceGrouping = "resource"
+	// ResourceGroupingSpan emits one ResourceSpans per span, repeating the
+	// resource every time.
+	ResourceGroupingSpan ResourceGrouping = "span"
+	// ResourceGroupingSingle merges every resource of the request into
+	// one ResourceSpans.
+	ResourceGroupingSingle ResourceGrouping = "single"
 )
 
+func (Protocol) JSONSchema() *jsonschema.Schema {
+	return jsonschema.Enum(ProtocolGRPC, ProtocolHTTP, ProtocolZipkin)
+}
+
+func (Compression) JSONSchema() *jsonschema.Schema {
+	return jsonschema.Enum(CompressionNone, CompressionGzip, CompressionZstd)
+}
+
+func (ResourceGrouping) JSONSchema() *jsonschema.Schema {
+	return jsonschema.Enum(ResourceGroupingResource, ResourceGroupingSpan, ResourceGroupingSingle)
+}
+
 type Duration struct {
 	time.Duration
 }
@@ -21,6 +72,14 @@ func (d Duration) MarshalJSON() ([]byte, error) {
 	return json.Marshal(d.String())
 }
 
+// JSONSchema describes the two forms UnmarshalJSON accepts.
+func (Duration) JSONSchema() *jsonschema.Schema {
+	return &jsonschema.Schema{
+		Type:        jsonschema.Types{"string", "number"},
+		Description: `A Go duration such as "500ms" or "1m30s", or a number of seconds`,
+	}
+}
+
 func (d *Duration) UnmarshalJSON(data []byte) error {
 	var s string
 	if err := json.Unmarshal(data, &s); err == nil {
@@ -56,6 +115,16 @@ type EndpointConfig struct {
 	Headers       map[string]string `json:"headers,omitempty"`
 	TLSCACert     string            `json:"tls_ca_cert,omitempty"`
 	TLSSkipVerify bool              `json:"tls_skip_verify,omitempty"`
+	TLSClientCert string            `json:"tls_client_cert,omitempty"`
+	TLSClientKey  string            `json:"tls_client_key,omitempty"`
+	TLSServerName string            `json:"tls_server_name,omitempty"`
+	Compression   Compression       `json:"compression,omitempty"`
+	// ResourceGrouping shapes the ResourceSpans of OTLP requests. The
+	// empty value is ResourceGroupingResource.
+	ResourceGrouping ResourceGrouping `json:"resource_grouping,omitempty"`
+	// HeaderMappings derive headers of each request from the attributes
+	// of the spans it carries; see HeaderMapping.
+	HeaderMappings []HeaderMapping `json:"header_mappings,omitempty"`
 }
 
 type ConcurrencyConfig struct {
@@ -63,26 +132,99 @@ type ConcurrencyConfig struct {
 }
 
 type RequestConfig struct {
-	PerExporter   int      `json:"per_exporter"`
-	Interval      Duration `json:"interval"`
-	For           Duration `json:"for"`
-	RampUp        Duration `json:"ramp_up"`
+	PerExporter int      `json:"per_exporter"`
+	Interval    Duration `json:"interval"`
+	For         Duration `json:"for"`
+	RampUp      Duration `json:"ramp_up"`
+	// RampWorkers staggers when each exporter worker opens its connection
+	// and starts sending, spread evenly over the duration.
+	RampWorkers Duration `json:"ramp_workers,omitempty"`
+	// TotalSpans stops the run once that many spans have been exported
+	// successfully across all exporters. Zero means no span budget.
+	TotalSpans int64 `json:"total_spans,omitempty"`
+	// ArrivalRate switches to open-loop load: requests per second are
+	// generated at this rate whatever the export latency. Zero keeps the
+	// closed loop paced by Interval or Profile.
+	ArrivalRate float64 `json:"arrival_rate,omitempty"`
+	// SpanRate is a target of spans per second across the run. The
+	// request rate still comes from ArrivalRate, or from Exporters and
+	// Interval; each request then carries as many whole traces as needed
+	// to reach the target on average. Without ArrivalRate the run is paced
+	// at Exporters requests every Interval across all workers, so export
+	// latency does not lower it, but the target is only met while the
+	// exporters keep up. Zero sends one trace per request.
+	SpanRate float64 `json:"span_rate,omitempty"`
+	// MaxInFlight bounds open-loop requests queued or being exported.
+	MaxInFlight   int      `json:"max_in_flight,omitempty"`
 	ExportTimeout Duration `json:"export_timeout"`
+	Retries       int      `json:"retries,omitempty"`
+	RetryBackoff  Duration `json:"retry_backoff,omitempty"`
+	// Profile is a load profile spec (see loadprofile.Parse) that shapes
+	// the total request rate over time. It replaces Interval.
+	Profile string `json:"profile,omitempty"`
+}
+
+// ScenarioConfig selects the trace topology. Strategy, RunSeed and
+// IDGenerator mirror --scenario-strategy, --scenario-run-seed and
+// --id-generator.
+type ScenarioConfig struct {
+	Files       []string `json:"files,omitempty"`
+	Strategy    string   `json:"strategy,omitempty"`
+	RunSeed     int64    `json:"run_seed,omitempty"`
+	IDGenerator string   `json:"id_generator,omitempty"`
+}
+
+type ChaosConfig struct {
+	PoliciesFile string `json:"policies_file,omitempty"`
+	Seed         int64  `json:"seed,omitempty"`
+}
+
+// SyntheticsConfig runs tercios permanently at a low rate as a synthetic
+// tracing canary. It mirrors the --synthetics flags; zero values keep the
+// flag defaults.
+type SyntheticsConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// HealthInterval is how often a health status line is logged.
+	HealthInterval Duration `json:"health_interval,omitempty"`
+	// LogFile receives the log instead of stderr, rotated by size.
+	LogFile     string `json:"log_file,omitempty"`
+	LogMaxBytes int64  `json:"log_max_bytes,omitempty"`
+	LogBackups  int    `json:"log_backups,omitempty"`
+	// AlertAfter is how long exports must keep failing before an outage
+	// event is logged and posted to AlertWebhook.
+	AlertAfter   Duration `json:"alert_after,omitempty"`
+	AlertWebhook string   `json:"alert_webhook,omitempty"`
 }
 
 type Config struct {
+	// Schema points editors at the JSON Schema of the file; see
+	// JSONSchema. It is otherwise ignored.
+	Schema      string            `json:"$schema,omitempty"`
 	Endpoint    EndpointConfig    `json:"endpoint"`
 	Concurrency ConcurrencyConfig `json:"concurrency"`
 	Requests    RequestConfig     `json:"requests"`
+	Scenario    ScenarioConfig    `json:"scenario"`
+	Chaos       ChaosConfig       `json:"chaos"`
+	Synthetics  SyntheticsConfig  `json:"synthetics"`
+	// Expect, when set, checks the shape of the generated traces after
+	// the run.
+	Expect *ExpectConfig `json:"expect,omitempty"`
+}
+
+// JSONSchema returns the JSON Schema of run configuration files.
+func JSONSchema() *jsonschema.Schema {
+	return jsonschema.Generate(Config{}, "tercios config")
 }
 
 func DefaultConfig() Config {
 	return Config{
 		Endpoint: EndpointConfig{
-			Address:  "localhost:4317",
-			Protocol: ProtocolGRPC,
-			Insecure: true,
-			Headers:  map[string]string{},
+			Address:          "localhost:4317",
+			Protocol:         ProtocolGRPC,
+			Insecure:         true,
+			Headers:          map[string]string{},
+			Compression:      CompressionNone,
+			ResourceGrouping: ResourceGroupingResource,
 		},
 		Concurrency: ConcurrencyConfig{
 			Exporters: 1,
@@ -97,30 +239,195 @@ func DefaultConfig() Config {
 	}
 }
 
+// LoadFromFile reads a run configuration from a JSON file, or a YAML file
+// when path ends in .yaml/.yml. Unset fields keep DefaultConfig values.
+// Relative file references (scenario files, chaos policies, CA cert) are
+// resolved against the directory of path.
+func LoadFromFile(path string) (Config, error) {
+	reader, err := fileformat.Open(path)
+	if err != nil {
+		return Config{}, err
+	}
+	defer func() { _ = reader.Close() }()
+	cfg, err := DecodeJSON(reader)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.resolvePaths(filepath.Dir(path))
+	return cfg, nil
+}
+
+func DecodeJSON(r io.Reader) (Config, error) {
+	cfg := DefaultConfig()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Config{}, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&cfg); err != nil {
+		return Config{}, JSONSchema().DecodeError(data, err)
+	}
+	if err := decoder.Decode(&struct{}{}); err != io.EOF {
+		return Config{}, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) resolvePaths(baseDir string) {
+	resolve := func(path string) string {
+		if path == "" || filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(baseDir, path)
+	}
+	for i, file := range c.Scenario.Files {
+		// Embedded scenarios are names, not paths.
+		if _, ok := library.Name(file); !ok {
+			c.Scenario.Files[i] = resolve(file)
+		}
+	}
+	c.Chaos.PoliciesFile = resolve(c.Chaos.PoliciesFile)
+	c.Synthetics.LogFile = resolve(c.Synthetics.LogFile)
+	c.Endpoint.TLSCACert = resolve(c.Endpoint.TLSCACert)
+	c.Endpoint.TLSClientCert = resolve(c.Endpoint.TLSClientCert)
+	c.Endpoint.TLSClientKey = resolve(c.Endpoint.TLSClientKey)
+}
+
+// RequestRate returns the total requests per second Requests.SpanRate is
+// spread over: ArrivalRate, or Exporters requests every Interval. It is
+// zero when neither sets a rate.
+func (c Config) RequestRate() float64 {
+	switch {
+	case c.Requests.ArrivalRate > 0:
+		return c.Requests.ArrivalRate
+	case c.Requests.Interval.Duration > 0 && c.Concurrency.Exporters > 0:
+		return float64(c.Concurrency.Exporters) / c.Requests.Interval.Seconds()
+	default:
+		return 0
+	}
+}
+
+// SpansPerRequest returns the spans each request carries on average to
+// reach Requests.SpanRate at RequestRate. It is zero without a span rate.
+func (c Config) SpansPerRequest() float64 {
+	rate := c.RequestRate()
+	if c.Requests.SpanRate <= 0 || rate <= 0 {
+		return 0
+	}
+	return c.Requests.SpanRate / rate
+}
+
+// Validate reports every invalid field at once, each under the JSON path
+// of its section.
 func (c Config) Validate() error {
+	var errs validation.Errors
 	if c.Endpoint.Address == "" {
-		return fmt.Errorf("endpoint is required")
+		errs.Addf("endpoint", "address is required")
+	}
+	if c.Endpoint.Protocol != ProtocolGRPC && c.Endpoint.Protocol != ProtocolHTTP && c.Endpoint.Protocol != ProtocolZipkin {
+		errs.Addf("endpoint", "unsupported protocol %q", c.Endpoint.Protocol)
+	}
+	switch c.Endpoint.Compression {
+	case "", CompressionNone, CompressionGzip, CompressionZstd:
+	default:
+		errs.Addf("endpoint", "unsupported compression %q", c.Endpoint.Compression)
 	}
-	if c.Endpoint.Protocol != ProtocolGRPC && c.Endpoint.Protocol != ProtocolHTTP {
-		return fmt.Errorf("unsupported protocol %q", c.Endpoint.Protocol)
+	switch c.Endpoint.ResourceGrouping {
+	case "", ResourceGroupingResource, ResourceGroupingSpan, ResourceGroupingSingle:
+	default:
+		errs.Addf("endpoint", "unsupported resource grouping %q (resource, span or single)", c.Endpoint.ResourceGrouping)
+	}
+	if (c.Endpoint.TLSClientCert == "") != (c.Endpoint.TLSClientKey == "") {
+		errs.Addf("endpoint", "tls client cert and key must be set together")
+	}
+	mappedHeaders := map[string]bool{}
+	for i, mapping := range c.Endpoint.HeaderMappings {
+		path := validation.Index("endpoint.header_mappings", i)
+		mapping.validate(&errs, path)
+		if key := strings.ToLower(mapping.Header); mappedHeaders[key] {
+			errs.Addf(path, "header %q is mapped more than once", mapping.Header)
+		} else {
+			mappedHeaders[key] = true
+		}
 	}
 	if c.Concurrency.Exporters <= 0 {
-		return fmt.Errorf("exporters must be > 0")
+		errs.Addf("concurrency", "exporters must be > 0")
 	}
 	if c.Requests.PerExporter < 0 {
-		return fmt.Errorf("max requests must be >= 0")
+		errs.Addf("requests", "max requests must be >= 0")
 	}
 	if c.Requests.Interval.Duration < 0 {
-		return fmt.Errorf("request interval must be >= 0")
+		errs.Addf("requests", "request interval must be >= 0")
 	}
 	if c.Requests.For.Duration < 0 {
-		return fmt.Errorf("request duration must be >= 0")
+		errs.Addf("requests", "request duration must be >= 0")
 	}
 	if c.Requests.RampUp.Duration < 0 {
-		return fmt.Errorf("ramp-up must be >= 0")
+		errs.Addf("requests", "ramp-up must be >= 0")
+	}
+	if c.Requests.RampWorkers.Duration < 0 {
+		errs.Addf("requests", "ramp-workers must be >= 0")
+	}
+	if c.Requests.TotalSpans < 0 {
+		errs.Addf("requests", "total spans must be >= 0")
+	}
+	if c.Requests.ArrivalRate < 0 {
+		errs.Addf("requests", "arrival rate must be >= 0")
+	}
+	if c.Requests.MaxInFlight < 0 {
+		errs.Addf("requests", "max in-flight must be >= 0")
+	}
+	if c.Requests.SpanRate < 0 {
+		errs.Addf("requests", "span rate must be >= 0")
+	} else if c.Requests.SpanRate > 0 {
+		if c.Requests.Profile != "" {
+			errs.Addf("requests", "span rate cannot be combined with a load profile")
+		} else if c.Requests.ArrivalRate == 0 && c.Requests.Interval.Duration <= 0 {
+			errs.Addf("requests", "span rate requires a request interval or an arrival rate")
+		}
+	}
+	if c.Requests.ArrivalRate > 0 {
+		if c.Requests.Interval.Duration > 0 || c.Requests.RampUp.Duration > 0 || c.Requests.Profile != "" {
+			errs.Addf("requests", "arrival rate cannot be combined with a request interval, ramp-up or load profile")
+		}
+	} else if c.Requests.MaxInFlight > 0 {
+		errs.Addf("requests", "max in-flight requires an arrival rate")
 	}
 	if c.Requests.ExportTimeout.Duration < 0 {
-		return fmt.Errorf("export timeout must be >= 0")
+		errs.Addf("requests", "export timeout must be >= 0")
+	}
+	if c.Requests.Retries < 0 {
+		errs.Addf("requests", "export retries must be >= 0")
+	}
+	if c.Requests.RetryBackoff.Duration < 0 {
+		errs.Addf("requests", "retry backoff must be >= 0")
+	}
+	if c.Requests.Profile != "" {
+		if _, err := loadprofile.Parse(c.Requests.Profile); err != nil {
+			errs.Add("requests.profile", err)
+		}
+		if c.Requests.Interval.Duration > 0 {
+			errs.Addf("requests", "request interval cannot be combined with a load profile")
+		}
+	}
+	if c.Synthetics.HealthInterval.Duration < 0 {
+		errs.Addf("synthetics", "health interval must be >= 0")
+	}
+	if c.Synthetics.LogMaxBytes < 0 {
+		errs.Addf("synthetics", "log max bytes must be >= 0")
+	}
+	if c.Synthetics.LogBackups < 0 {
+		errs.Addf("synthetics", "log backups must be >= 0")
+	}
+	if c.Synthetics.AlertAfter.Duration < 0 {
+		errs.Addf("synthetics", "alert after must be >= 0")
+	}
+	if c.Expect != nil {
+		c.Expect.validate(&errs, "expect")
 	}
-	return nil
+	return errs.Err()
 }
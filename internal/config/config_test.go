@@ -1,6 +1,9 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -29,6 +32,84 @@ func TestValidateRejectsNegativeRampUp(t *testing.T) {
 	}
 }
 
+func TestValidateRejectsNegativeRampWorkers(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Requests.RampWorkers = Duration{Duration: -1 * time.Second}
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error for negative ramp-workers")
+	}
+}
+
+func TestValidateRejectsNegativeTotalSpans(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Requests.TotalSpans = -1
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error for negative total spans")
+	}
+}
+
+func TestValidateRejectsArrivalRateWithClosedLoopPacing(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Requests.ArrivalRate = 100
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected arrival rate alone to be valid, got %v", err)
+	}
+	cfg.Requests.Interval = Duration{Duration: time.Second}
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error combining arrival rate with a request interval")
+	}
+}
+
+func TestSpanRateNeedsAKnownRequestRate(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Requests.SpanRate = 1000
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error for a span rate without a request interval or arrival rate")
+	}
+	cfg.Concurrency.Exporters = 4
+	cfg.Requests.Interval = Duration{Duration: 200 * time.Millisecond}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected span rate with a request interval to be valid, got %v", err)
+	}
+	if got := cfg.RequestRate(); got != 20 {
+		t.Fatalf("RequestRate() = %v, want 20", got)
+	}
+	if got := cfg.SpansPerRequest(); got != 50 {
+		t.Fatalf("SpansPerRequest() = %v, want 50", got)
+	}
+	cfg.Requests.Interval = Duration{}
+	cfg.Requests.ArrivalRate = 20
+	if got := cfg.SpansPerRequest(); got != 50 {
+		t.Fatalf("SpansPerRequest() = %v, want 50", got)
+	}
+	cfg.Requests.ArrivalRate = 0
+	cfg.Requests.Profile = "ramp:1-10/60s"
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error combining a span rate with a load profile")
+	}
+}
+
+func TestValidateRequiresArrivalRateForMaxInFlight(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Requests.MaxInFlight = 10
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error for max in-flight without an arrival rate")
+	}
+}
+
+func TestValidateRejectsNegativeSyntheticsSettings(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Synthetics.HealthInterval = Duration{Duration: -time.Second}
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error for a negative health interval")
+	}
+	cfg = DefaultConfig()
+	cfg.Synthetics.LogBackups = -1
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error for negative log backups")
+	}
+}
+
 func TestValidateRejectsNegativeExportTimeout(t *testing.T) {
 	cfg := DefaultConfig()
 	cfg.Requests.ExportTimeout = Duration{Duration: -1 * time.Second}
@@ -36,3 +117,165 @@ func TestValidateRejectsNegativeExportTimeout(t *testing.T) {
 		t.Fatalf("expected error for negative export-timeout")
 	}
 }
+
+func TestValidateRequiresTLSClientCertAndKeyTogether(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Endpoint.TLSClientCert = "client.pem"
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error for tls client cert without key")
+	}
+	cfg.Endpoint.TLSClientKey = "client-key.pem"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected client cert and key to validate: %v", err)
+	}
+}
+
+func TestValidateChecksCompression(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Endpoint.Compression = CompressionGzip
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected gzip to validate: %v", err)
+	}
+	cfg.Endpoint.Compression = CompressionZstd
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected zstd to validate: %v", err)
+	}
+	cfg.Endpoint.Compression = "brotli"
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error for brotli compression")
+	}
+}
+
+func TestValidateChecksLoadProfile(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Requests.Profile = "ramp:0-100/1m"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected valid load profile, got %v", err)
+	}
+
+	cfg.Requests.Interval = Duration{Duration: time.Second}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "load profile") {
+		t.Fatalf("expected error combining interval and load profile, got %v", err)
+	}
+
+	cfg.Requests.Interval = Duration{}
+	cfg.Requests.Profile = "ramp:0-100"
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error for invalid load profile")
+	}
+}
+
+func TestDecodeJSONKeepsDefaultsForUnsetFields(t *testing.T) {
+	cfg, err := DecodeJSON(strings.NewReader(`{"concurrency": {"exporters": 4}, "requests": {"interval": "250ms"}}`))
+	if err != nil {
+		t.Fatalf("DecodeJSON() error = %v", err)
+	}
+	if cfg.Concurrency.Exporters != 4 {
+		t.Fatalf("expected exporters=4, got %d", cfg.Concurrency.Exporters)
+	}
+	if cfg.Requests.Interval.Duration != 250*time.Millisecond {
+		t.Fatalf("expected interval=250ms, got %s", cfg.Requests.Interval.Duration)
+	}
+	if cfg.Endpoint.Address != "localhost:4317" || cfg.Requests.ExportTimeout.Duration != 10*time.Second {
+		t.Fatalf("expected defaults to be kept, got %+v", cfg)
+	}
+}
+
+func TestDecodeJSONRejectsUnknownFields(t *testing.T) {
+	if _, err := DecodeJSON(strings.NewReader(`{"concurrency": {"workers": 4}}`)); err == nil {
+		t.Fatalf("expected error for unknown field")
+	}
+}
+
+func TestLoadFromFileYAMLResolvesRelativePaths(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "run.yaml")
+	content := `
+endpoint:
+  address: collector:4317
+  insecure: false
+  headers:
+    X-Scope-OrgID: tenant-a
+requests:
+  per_exporter: 0
+  for: 30
+scenario:
+  files: [scenarios/shop.json, /abs/other.json, builtin:ecommerce]
+  strategy: random
+chaos:
+  policies_file: chaos.yaml
+  seed: 7
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err := LoadFromFile(path)
+	if err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if cfg.Endpoint.Address != "collector:4317" || cfg.Endpoint.Insecure {
+		t.Fatalf("unexpected endpoint config %+v", cfg.Endpoint)
+	}
+	if cfg.Endpoint.Headers["X-Scope-OrgID"] != "tenant-a" {
+		t.Fatalf("expected header from file, got %v", cfg.Endpoint.Headers)
+	}
+	if cfg.Requests.For.Duration != 30*time.Second {
+		t.Fatalf("expected for=30s, got %s", cfg.Requests.For.Duration)
+	}
+	if got := cfg.Scenario.Files; len(got) != 3 || got[0] != filepath.Join(dir, "scenarios/shop.json") || got[1] != "/abs/other.json" || got[2] != "builtin:ecommerce" {
+		t.Fatalf("unexpected scenario files %v", got)
+	}
+	if cfg.Chaos.PoliciesFile != filepath.Join(dir, "chaos.yaml") || cfg.Chaos.Seed != 7 {
+		t.Fatalf("unexpected chaos config %+v", cfg.Chaos)
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Endpoint.Address = ""
+	cfg.Concurrency.Exporters = 0
+	cfg.Requests.RampUp = Duration{Duration: -time.Second}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	for _, want := range []string{"3 problems:", "endpoint: address is required", "concurrency: exporters must be > 0", "requests: ramp-up must be >= 0"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected error containing %q, got %v", want, err)
+		}
+	}
+}
+
+func TestHeaderMappings(t *testing.T) {
+	mapping, err := ParseHeaderMapping("X-Scope-OrgID=resource.tenant.id")
+	if err != nil {
+		t.Fatalf("ParseHeaderMapping() error = %v", err)
+	}
+	if mapping != (HeaderMapping{Header: "X-Scope-OrgID", Attribute: "tenant.id", Source: HeaderMappingSourceResource}) {
+		t.Fatalf("ParseHeaderMapping() = %+v", mapping)
+	}
+	for _, value := range []string{"X-Scope-OrgID", "X-Scope-OrgID=tenant.id", "=span.tenant", "X-Tenant=span."} {
+		if _, err := ParseHeaderMapping(value); err == nil {
+			t.Fatalf("expected an error for %q", value)
+		}
+	}
+
+	cfg, err := DecodeJSON(strings.NewReader(`{"endpoint": {"header_mappings": [
+		{"header": "X-Scope-OrgID", "attribute": "tenant.id", "default": "anonymous"},
+		{"header": "x-scope-orgid", "attribute": "tenant", "source": "span"},
+		{"header": "X-Region", "attribute": "cloud.region", "source": "scope"}
+	]}}`))
+	if err == nil {
+		t.Fatalf("expected an error, got %+v", cfg.Endpoint.HeaderMappings)
+	}
+	for _, want := range []string{
+		`endpoint.header_mappings[1]: header "x-scope-orgid" is mapped more than once`,
+		`endpoint.header_mappings[2]: source must be resource or span, got "scope"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected error containing %q, got %v", want, err)
+		}
+	}
+}
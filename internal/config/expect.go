@@ -0,0 +1,101 @@
+package config
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/javiermolinar/tercios/internal/metrics"
+	"github.com/javiermolinar/tercios/internal/validation"
+)
+
+// ExpectConfig declares properties the generated traces must have. A run
+// whose traces miss one exits nonzero after its summary, so generator
+// regressions fail the CI pipelines of teams that depend on tercios.
+type ExpectConfig struct {
+	// SpansPerTrace bounds the average number of spans per trace.
+	SpansPerTrace *RangeConfig `json:"spans_per_trace,omitempty"`
+	// Services bounds the number of distinct service.name values.
+	Services *RangeConfig `json:"services,omitempty"`
+	// ErrorRate is the expected fraction of spans with error status.
+	ErrorRate *ToleranceConfig `json:"error_rate,omitempty"`
+}
+
+// RangeConfig is an inclusive range; an unset bound is open.
+type RangeConfig struct {
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+}
+
+// ToleranceConfig accepts values within Tolerance of Value.
+type ToleranceConfig struct {
+	Value     float64 `json:"value"`
+	Tolerance float64 `json:"tolerance"`
+}
+
+func (e ExpectConfig) validate(errs *validation.Errors, path string) {
+	if e.SpansPerTrace == nil && e.Services == nil && e.ErrorRate == nil {
+		errs.Addf(path, "at least one expectation is required")
+	}
+	if e.SpansPerTrace != nil {
+		e.SpansPerTrace.validate(errs, validation.Field(path, "spans_per_trace"))
+	}
+	if e.Services != nil {
+		e.Services.validate(errs, validation.Field(path, "services"))
+	}
+	if e.ErrorRate != nil {
+		if e.ErrorRate.Value < 0 || e.ErrorRate.Value > 1 {
+			errs.Addf(validation.Field(path, "error_rate"), "value must be in [0, 1]")
+		}
+		if e.ErrorRate.Tolerance < 0 {
+			errs.Addf(validation.Field(path, "error_rate"), "tolerance must be >= 0")
+		}
+	}
+}
+
+func (r RangeConfig) validate(errs *validation.Errors, path string) {
+	switch {
+	case r.Min == nil && r.Max == nil:
+		errs.Addf(path, "min or max is required")
+	case r.Min != nil && *r.Min < 0:
+		errs.Addf(path, "min must be >= 0")
+	case r.Min != nil && r.Max != nil && *r.Min > *r.Max:
+		errs.Addf(path, "min must be <= max")
+	}
+}
+
+func (r RangeConfig) contains(value float64) bool {
+	return (r.Min == nil || value >= *r.Min) && (r.Max == nil || value <= *r.Max)
+}
+
+func (r RangeConfig) String() string {
+	switch {
+	case r.Min == nil:
+		return fmt.Sprintf("<= %g", *r.Max)
+	case r.Max == nil:
+		return fmt.Sprintf(">= %g", *r.Min)
+	default:
+		return fmt.Sprintf("%g-%g", *r.Min, *r.Max)
+	}
+}
+
+// Check returns one message per expectation shape does not meet, or none.
+func (e ExpectConfig) Check(shape metrics.TraceShape) []string {
+	var failures []string
+	if shape.Traces == 0 {
+		return []string{"no traces were generated"}
+	}
+	if r := e.SpansPerTrace; r != nil && !r.contains(shape.SpansPerTrace()) {
+		failures = append(failures, fmt.Sprintf("spans per trace %.2f, want %s", shape.SpansPerTrace(), r))
+	}
+	if r := e.Services; r != nil && !r.contains(float64(shape.Services)) {
+		failures = append(failures, fmt.Sprintf("services %d, want %s", shape.Services, r))
+	}
+	if t := e.ErrorRate; t != nil {
+		// The epsilon keeps rates exactly on a bound, such as 0.05 for
+		// 0.04 ± 0.01, from failing on float rounding.
+		if rate := shape.ErrorRate(); math.Abs(rate-t.Value) > t.Tolerance+1e-9 {
+			failures = append(failures, fmt.Sprintf("error rate %.4f, want %g ± %g", rate, t.Value, t.Tolerance))
+		}
+	}
+	return failures
+}
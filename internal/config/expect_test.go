@@ -0,0 +1,48 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/javiermolinar/tercios/internal/metrics"
+)
+
+func TestDecodeJSONValidatesExpectations(t *testing.T) {
+	_, err := DecodeJSON(strings.NewReader(`{"expect": {"spans_per_trace": {"min": 30, "max": 20}, "services": {}, "error_rate": {"value": 2, "tolerance": -1}}}`))
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	for _, want := range []string{
+		"expect.spans_per_trace: min must be <= max",
+		"expect.services: min or max is required",
+		"expect.error_rate: value must be in [0, 1]",
+		"expect.error_rate: tolerance must be >= 0",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected error containing %q, got %v", want, err)
+		}
+	}
+}
+
+func TestExpectConfigCheck(t *testing.T) {
+	cfg, err := DecodeJSON(strings.NewReader(`{"expect": {"spans_per_trace": {"min": 20, "max": 22}, "services": {"min": 5}, "error_rate": {"value": 0.04, "tolerance": 0.01}}}`))
+	if err != nil {
+		t.Fatalf("DecodeJSON() error = %v", err)
+	}
+
+	if failures := cfg.Expect.Check(metrics.TraceShape{Traces: 10, Spans: 210, ErrorSpans: 10, Services: 5}); len(failures) != 0 {
+		t.Fatalf("expected the shape to pass, got %v", failures)
+	}
+	failures := cfg.Expect.Check(metrics.TraceShape{Traces: 10, Spans: 150, ErrorSpans: 15, Services: 4})
+	want := []string{
+		"spans per trace 15.00, want 20-22",
+		"services 4, want >= 5",
+		"error rate 0.1000, want 0.04 ± 0.01",
+	}
+	if strings.Join(failures, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("failures = %q, want %q", failures, want)
+	}
+	if failures := cfg.Expect.Check(metrics.TraceShape{}); len(failures) != 1 || failures[0] != "no traces were generated" {
+		t.Fatalf("failures for an empty run = %q", failures)
+	}
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/javiermolinar/tercios/internal/jsonschema"
+	"github.com/javiermolinar/tercios/internal/validation"
+)
+
+// HeaderMapping sets a request header, or gRPC metadata entry, from an
+// attribute of the exported spans, e.g. X-Scope-OrgID from the tenant.id
+// resource attribute, so routing layers keyed on headers receive requests
+// whose payload matches them. Batches are split so that every request
+// carries a single value per mapped header.
+type HeaderMapping struct {
+	Header    string `json:"header"`
+	Attribute string `json:"attribute"`
+	// Source is where Attribute is looked up: the resource (default) or
+	// the span attributes.
+	Source HeaderMappingSource `json:"source,omitempty"`
+	// Default is sent for spans without the attribute. Without a default
+	// such spans are sent without the header, or with the static header
+	// of the same name.
+	Default string `json:"default,omitempty"`
+}
+
+// HeaderMappingSource is the attribute set a HeaderMapping reads.
+type HeaderMappingSource string
+
+const (
+	HeaderMappingSourceResource HeaderMappingSource = "resource"
+	HeaderMappingSourceSpan     HeaderMappingSource = "span"
+)
+
+func (HeaderMappingSource) JSONSchema() *jsonschema.Schema {
+	return jsonschema.Enum(HeaderMappingSourceResource, HeaderMappingSourceSpan)
+}
+
+// ParseHeaderMapping parses the --header-from form HEADER=SOURCE.KEY, e.g.
+// X-Scope-OrgID=resource.tenant.id.
+func ParseHeaderMapping(value string) (HeaderMapping, error) {
+	header, attribute, ok := strings.Cut(value, "=")
+	if !ok {
+		return HeaderMapping{}, fmt.Errorf("header mapping must be in HEADER=resource.KEY or HEADER=span.KEY form")
+	}
+	source, key, ok := strings.Cut(strings.TrimSpace(attribute), ".")
+	if !ok || (source != string(HeaderMappingSourceResource) && source != string(HeaderMappingSourceSpan)) {
+		return HeaderMapping{}, fmt.Errorf("header mapping attribute %q must start with resource. or span.", attribute)
+	}
+	mapping := HeaderMapping{Header: strings.TrimSpace(header), Attribute: key, Source: HeaderMappingSource(source)}
+	var errs validation.Errors
+	mapping.validate(&errs, "")
+	return mapping, errs.Err()
+}
+
+func (m HeaderMapping) validate(errs *validation.Errors, path string) {
+	if m.Header == "" {
+		errs.Addf(path, "header is required")
+	}
+	if m.Attribute == "" {
+		errs.Addf(path, "attribute is required")
+	}
+	if m.Source != "" && m.Source != HeaderMappingSourceResource && m.Source != HeaderMappingSourceSpan {
+		errs.Addf(path, "source must be resource or span, got %q", m.Source)
+	}
+}
+
+// HeaderMappingFlags collects repeated --header-from flags.
+type HeaderMappingFlags struct {
+	values []HeaderMapping
+}
+
+func (h *HeaderMappingFlags) String() string {
+	return ""
+}
+
+func (h *HeaderMappingFlags) Set(value string) error {
+	mapping, err := ParseHeaderMapping(value)
+	if err != nil {
+		return err
+	}
+	h.values = append(h.values, mapping)
+	return nil
+}
+
+func (h *HeaderMappingFlags) Values() []HeaderMapping {
+	return h.values
+}
@@ -0,0 +1,105 @@
+// Package daemon reports the lifecycle of a tercios process to the service
+// manager that started it: systemd through the sd_notify protocol
+// (readiness, watchdog pings and stopping) and, on Windows, the service
+// control manager. Outside a service manager every call is a no-op, so
+// permanent synthetic-traffic deployments can restart tercios on failure
+// without a wrapper script.
+package daemon
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// Supervisor forwards run lifecycle events to the service manager, if any.
+// A nil *Supervisor is valid and does nothing.
+type Supervisor struct {
+	notifier *Notifier
+	platform *platformService
+	// progressed is set by Progress and cleared by each watchdog ping.
+	progressed atomic.Bool
+}
+
+// Start detects the service manager from the environment. cancel is called
+// when the manager asks the process to stop, e.g. a Windows service Stop
+// control; systemd sends SIGTERM, which the caller handles itself.
+func Start(cancel context.CancelFunc) (*Supervisor, error) {
+	notifier, err := NotifierFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	platform, err := startPlatform(cancel)
+	if err != nil {
+		return nil, err
+	}
+	return &Supervisor{notifier: notifier, platform: platform}, nil
+}
+
+// Ready tells the service manager that startup finished and traces are
+// being sent: READY=1 for systemd, the running state for Windows.
+func (s *Supervisor) Ready() {
+	if s == nil {
+		return
+	}
+	if s.notifier != nil {
+		if err := s.notifier.Notify(StateReady); err != nil {
+			log.Printf("warning: sd_notify ready: %v", err)
+		}
+	}
+	if s.platform != nil {
+		s.platform.ready()
+	}
+}
+
+// Progress records that the run is making progress. Watchdog only pings
+// after progress was recorded, so a hung export or a deadlocked pipeline
+// lets the watchdog expire and the service manager restart tercios.
+func (s *Supervisor) Progress() {
+	if s == nil {
+		return
+	}
+	s.progressed.Store(true)
+}
+
+// Watchdog pings the systemd watchdog at half the WatchdogSec interval
+// until ctx is done, skipping intervals without Progress. It returns at
+// once when no watchdog is configured.
+func (s *Supervisor) Watchdog(ctx context.Context) {
+	if s == nil || s.notifier == nil || s.notifier.WatchdogInterval() <= 0 {
+		return
+	}
+	ticker := time.NewTicker(s.notifier.WatchdogInterval() / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !s.progressed.Swap(false) {
+				continue
+			}
+			if err := s.notifier.Notify(StateWatchdog); err != nil {
+				log.Printf("warning: sd_notify watchdog: %v", err)
+			}
+		}
+	}
+}
+
+// Stop reports that the process is shutting down with exitCode. Under the
+// Windows service control manager it blocks until the stopped state has
+// been reported.
+func (s *Supervisor) Stop(exitCode int) {
+	if s == nil {
+		return
+	}
+	if s.notifier != nil {
+		if err := s.notifier.Notify(StateStopping); err != nil {
+			log.Printf("warning: sd_notify stopping: %v", err)
+		}
+	}
+	if s.platform != nil {
+		s.platform.stop(exitCode)
+	}
+}
@@ -0,0 +1,78 @@
+package daemon
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// sd_notify states sent by tercios. See sd_notify(3).
+const (
+	StateReady    = "READY=1"
+	StateStopping = "STOPPING=1"
+	StateWatchdog = "WATCHDOG=1"
+)
+
+const (
+	envNotifySocket = "NOTIFY_SOCKET"
+	envWatchdogUsec = "WATCHDOG_USEC"
+	envWatchdogPID  = "WATCHDOG_PID"
+)
+
+// Notifier sends sd_notify datagrams to the socket systemd passed in
+// NOTIFY_SOCKET.
+type Notifier struct {
+	socket   string
+	watchdog time.Duration
+}
+
+// NotifierFromEnv returns a Notifier for the systemd notification socket,
+// or nil when NOTIFY_SOCKET is unset (the unit is not Type=notify). The
+// watchdog interval comes from WATCHDOG_USEC, unless WATCHDOG_PID names
+// another process.
+func NotifierFromEnv() (*Notifier, error) {
+	socket := os.Getenv(envNotifySocket)
+	if socket == "" {
+		return nil, nil
+	}
+	notifier := &Notifier{socket: socket}
+	usec := os.Getenv(envWatchdogUsec)
+	if usec == "" {
+		return notifier, nil
+	}
+	if pid := os.Getenv(envWatchdogPID); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return notifier, nil
+	}
+	value, err := strconv.ParseInt(usec, 10, 64)
+	if err != nil || value <= 0 {
+		return nil, fmt.Errorf("invalid %s %q: must be a positive number of microseconds", envWatchdogUsec, usec)
+	}
+	notifier.watchdog = time.Duration(value) * time.Microsecond
+	return notifier, nil
+}
+
+// WatchdogInterval is the systemd WatchdogSec setting, or zero when the
+// watchdog is disabled.
+func (n *Notifier) WatchdogInterval() time.Duration {
+	return n.watchdog
+}
+
+// Notify sends state, one or more newline-separated KEY=VALUE assignments.
+func (n *Notifier) Notify(state string) error {
+	name := n.socket
+	// A leading @ names a socket in the Linux abstract namespace.
+	if name[0] == '@' {
+		name = "\x00" + name[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: name, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	if _, err := conn.Write([]byte(state)); err != nil {
+		_ = conn.Close()
+		return err
+	}
+	return conn.Close()
+}
@@ -0,0 +1,135 @@
+//go:build unix
+
+package daemon
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func listenNotifySocket(t *testing.T) *net.UnixConn {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	t.Setenv("NOTIFY_SOCKET", path)
+	return conn
+}
+
+func readState(t *testing.T, conn *net.UnixConn) string {
+	t.Helper()
+	buf := make([]byte, 256)
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("read notification: %v", err)
+	}
+	return string(buf[:n])
+}
+
+func TestNotifierFromEnvUnsetIsNil(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	notifier, err := NotifierFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if notifier != nil {
+		t.Fatalf("expected no notifier without NOTIFY_SOCKET")
+	}
+}
+
+func TestNotifierFromEnvRejectsInvalidWatchdog(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "/run/notify")
+	t.Setenv("WATCHDOG_PID", "")
+	t.Setenv("WATCHDOG_USEC", "soon")
+	if _, err := NotifierFromEnv(); err == nil {
+		t.Fatalf("expected error for invalid WATCHDOG_USEC")
+	}
+}
+
+func TestNotifierFromEnvIgnoresWatchdogForOtherPID(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "/run/notify")
+	t.Setenv("WATCHDOG_USEC", "1000000")
+	t.Setenv("WATCHDOG_PID", "1")
+	notifier, err := NotifierFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := notifier.WatchdogInterval(); got != 0 {
+		t.Fatalf("expected watchdog disabled, got %s", got)
+	}
+}
+
+func TestSupervisorSendsReadyWatchdogAndStopping(t *testing.T) {
+	conn := listenNotifySocket(t)
+	t.Setenv("WATCHDOG_PID", "")
+	t.Setenv("WATCHDOG_USEC", "20000")
+
+	supervisor, err := Start(func() {})
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	supervisor.Ready()
+	if got := readState(t, conn); got != StateReady {
+		t.Fatalf("expected %q, got %q", StateReady, got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		supervisor.Watchdog(ctx)
+		close(done)
+	}()
+	supervisor.Progress()
+	if got := readState(t, conn); got != StateWatchdog {
+		t.Fatalf("expected %q, got %q", StateWatchdog, got)
+	}
+	cancel()
+	<-done
+
+	supervisor.Stop(0)
+	for {
+		got := readState(t, conn)
+		if got == StateWatchdog {
+			continue
+		}
+		if got != StateStopping {
+			t.Fatalf("expected %q, got %q", StateStopping, got)
+		}
+		break
+	}
+}
+
+func TestSupervisorWatchdogSkipsPingsWithoutProgress(t *testing.T) {
+	conn := listenNotifySocket(t)
+	t.Setenv("WATCHDOG_PID", "")
+	t.Setenv("WATCHDOG_USEC", "10000")
+
+	supervisor, err := Start(func() {})
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	supervisor.Watchdog(ctx)
+
+	buf := make([]byte, 64)
+	_ = conn.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+	if n, err := conn.Read(buf); err == nil {
+		t.Fatalf("expected no watchdog ping without progress, got %q", buf[:n])
+	}
+}
+
+func TestNilSupervisorIsNoop(t *testing.T) {
+	var supervisor *Supervisor
+	supervisor.Ready()
+	supervisor.Progress()
+	supervisor.Watchdog(context.Background())
+	supervisor.Stop(1)
+}
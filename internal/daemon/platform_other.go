@@ -0,0 +1,16 @@
+//go:build !windows
+
+package daemon
+
+import "context"
+
+// platformService is only implemented on Windows.
+type platformService struct{}
+
+func startPlatform(context.CancelFunc) (*platformService, error) {
+	return nil, nil
+}
+
+func (p *platformService) ready() {}
+
+func (p *platformService) stop(int) {}
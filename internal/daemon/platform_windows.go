@@ -0,0 +1,91 @@
+//go:build windows
+
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/windows/svc"
+)
+
+// serviceName is passed to the control dispatcher; it is ignored for
+// services running in their own process, which tercios always is.
+const serviceName = "tercios"
+
+// stopTimeout bounds how long Stop waits for the control manager to
+// acknowledge the stopped state before the process exits anyway.
+const stopTimeout = 5 * time.Second
+
+// platformService runs the Windows service control handler while tercios
+// runs as a service.
+type platformService struct {
+	readyOnce sync.Once
+	readyCh   chan struct{}
+	exit      chan uint32
+	done      chan struct{}
+}
+
+func startPlatform(cancel context.CancelFunc) (*platformService, error) {
+	isService, err := svc.IsWindowsService()
+	if err != nil {
+		return nil, fmt.Errorf("detect windows service: %w", err)
+	}
+	if !isService {
+		return nil, nil
+	}
+	p := &platformService{readyCh: make(chan struct{}), exit: make(chan uint32, 1), done: make(chan struct{})}
+	go func() {
+		defer close(p.done)
+		_ = svc.Run(serviceName, handler{cancel: cancel, ready: p.readyCh, exit: p.exit})
+	}()
+	return p, nil
+}
+
+func (p *platformService) ready() {
+	p.readyOnce.Do(func() { close(p.readyCh) })
+}
+
+func (p *platformService) stop(exitCode int) {
+	p.exit <- uint32(exitCode)
+	select {
+	case <-p.done:
+	case <-time.After(stopTimeout):
+	}
+}
+
+type handler struct {
+	cancel context.CancelFunc
+	ready  <-chan struct{}
+	exit   <-chan uint32
+}
+
+// Execute reports the service as starting until Ready, then as running,
+// and cancels the run on Stop or Shutdown. It returns, reporting the
+// service stopped, once the run has finished. A non-zero exit code is
+// reported as a service-specific error, so the manager's recovery actions
+// apply.
+func (h handler) Execute(_ []string, requests <-chan svc.ChangeRequest, changes chan<- svc.Status) (bool, uint32) {
+	accepts := svc.AcceptStop | svc.AcceptShutdown
+	changes <- svc.Status{State: svc.StartPending, Accepts: accepts}
+	ready := h.ready
+	for {
+		select {
+		case <-ready:
+			changes <- svc.Status{State: svc.Running, Accepts: accepts}
+			ready = nil
+		case request := <-requests:
+			switch request.Cmd {
+			case svc.Interrogate:
+				changes <- request.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				changes <- svc.Status{State: svc.StopPending}
+				h.cancel()
+			}
+		case code := <-h.exit:
+			return code != 0, code
+		}
+	}
+}
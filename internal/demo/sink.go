@@ -0,0 +1,143 @@
+// Package demo is the in-memory OTLP sink behind `tercios demo`: it
+// accepts OTLP/HTTP trace exports, keeps the most recent traces, and
+// serves a small web view of them, so the output of tercios can be seen
+// without running a collector or a tracing backend.
+package demo
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"sync"
+
+	"github.com/javiermolinar/tercios/internal/model"
+	"github.com/javiermolinar/tercios/internal/otlp"
+	"github.com/klauspost/compress/zstd"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// TracesPath is the path the sink receives OTLP/HTTP exports on, the
+// default path of OTLP/HTTP exporters.
+const TracesPath = "/v1/traces"
+
+// maxRequestBytes bounds the decompressed body of one export.
+const maxRequestBytes = 64 << 20
+
+// Sink is an http.Handler that stores exported traces in memory and
+// renders them on GET /. Once it holds maxTraces traces, the oldest trace
+// is dropped for each new one.
+type Sink struct {
+	mux       *http.ServeMux
+	maxTraces int
+
+	mu       sync.Mutex
+	traces   map[oteltrace.TraceID][]model.Span
+	order    []oteltrace.TraceID // oldest first
+	requests int
+	spans    int
+}
+
+// NewSink returns a sink that keeps up to maxTraces traces.
+func NewSink(maxTraces int) *Sink {
+	s := &Sink{
+		mux:       http.NewServeMux(),
+		maxTraces: max(maxTraces, 1),
+		traces:    map[oteltrace.TraceID][]model.Span{},
+	}
+	s.mux.HandleFunc("POST "+TracesPath, s.receive)
+	s.mux.HandleFunc("GET /{$}", s.view)
+	return s
+}
+
+func (s *Sink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Stats returns the number of non-empty export requests and the number of
+// spans received so far.
+func (s *Sink) Stats() (requests, spans int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests, s.spans
+}
+
+// Traces returns the stored traces, most recent first. Spans are in the
+// order they were received.
+func (s *Sink) Traces() [][]model.Span {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	traces := make([][]model.Span, 0, len(s.order))
+	for i := len(s.order) - 1; i >= 0; i-- {
+		traces = append(traces, append([]model.Span(nil), s.traces[s.order[i]]...))
+	}
+	return traces
+}
+
+func (s *Sink) receive(w http.ResponseWriter, r *http.Request) {
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		reader, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer func() { _ = reader.Close() }()
+		body = reader
+	} else if r.Header.Get("Content-Encoding") == "zstd" {
+		decoder, err := zstd.NewReader(r.Body, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer decoder.Close()
+		body = decoder
+	}
+	data, err := io.ReadAll(io.LimitReader(body, maxRequestBytes+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(data) > maxRequestBytes {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	contentType := r.Header.Get("Content-Type")
+	batch, err := otlp.DecodeExportRequest(data, contentType)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.store(batch)
+
+	// An empty ExportTraceServiceResponse is an empty protobuf message,
+	// or an empty object in JSON.
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == "application/json" {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{}"))
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Sink) store(batch model.Batch) {
+	if len(batch) == 0 {
+		// Preflight checks export an empty request.
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+	s.spans += len(batch)
+	for _, span := range batch {
+		if _, ok := s.traces[span.TraceID]; !ok {
+			if len(s.order) == s.maxTraces {
+				delete(s.traces, s.order[0])
+				s.order = s.order[1:]
+			}
+			s.order = append(s.order, span.TraceID)
+		}
+		s.traces[span.TraceID] = append(s.traces[span.TraceID], span)
+	}
+}
@@ -0,0 +1,113 @@
+package demo
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/config"
+	"github.com/javiermolinar/tercios/internal/model"
+	"github.com/javiermolinar/tercios/internal/otlp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+func demoSpan(trace, span, parent byte, name string, start time.Time, d time.Duration) model.Span {
+	s := model.Span{
+		TraceID:            oteltrace.TraceID{trace},
+		SpanID:             oteltrace.SpanID{span},
+		Name:               name,
+		Kind:               oteltrace.SpanKindServer,
+		StartTime:          start,
+		EndTime:            start.Add(d),
+		ResourceAttributes: map[string]attribute.Value{"service.name": attribute.StringValue("api")},
+	}
+	if parent != 0 {
+		s.ParentSpanID = oteltrace.SpanID{parent}
+	}
+	return s
+}
+
+func TestSinkReceivesExportsAndRendersTraces(t *testing.T) {
+	sink := NewSink(3)
+	server := httptest.NewServer(sink)
+	defer server.Close()
+
+	for i, compression := range []config.Compression{config.CompressionNone, config.CompressionGzip, config.CompressionZstd} {
+		factory := otlp.ExporterFactory{
+			Protocol:    config.ProtocolHTTP,
+			Endpoint:    server.URL + TracesPath,
+			Insecure:    true,
+			Compression: compression,
+		}
+		exporter, err := factory.NewBatchExporter(context.Background())
+		if err != nil {
+			t.Fatalf("NewBatchExporter() error = %v", err)
+		}
+		start := time.Date(2026, time.January, 27, 12, 0, 0, 0, time.UTC)
+		trace := byte(i + 1)
+		root := demoSpan(trace, 1, 0, "GET /items", start, 50*time.Millisecond)
+		child := demoSpan(trace, 2, 1, "SELECT items", start.Add(10*time.Millisecond), 20*time.Millisecond)
+		child.StatusCode = codes.Error
+		if err := exporter.ExportBatch(context.Background(), model.Batch{child, root}); err != nil {
+			t.Fatalf("ExportBatch(%s) error = %v", compression, err)
+		}
+		if err := exporter.Shutdown(context.Background()); err != nil {
+			t.Fatalf("Shutdown() error = %v", err)
+		}
+	}
+
+	if requests, spans := sink.Stats(); requests != 3 || spans != 6 {
+		t.Fatalf("Stats() = %d requests, %d spans, want 3 and 6", requests, spans)
+	}
+	traces := sink.Traces()
+	if len(traces) != 3 || traces[0][0].TraceID != (oteltrace.TraceID{3}) {
+		t.Fatalf("expected 3 traces, most recent first, got %d", len(traces))
+	}
+
+	view := newTraceView(traces[0])
+	if view.Root != "GET /items" || len(view.Spans) != 2 || view.Errors != 1 {
+		t.Fatalf("trace view = %+v", view)
+	}
+	if child := view.Spans[1]; child.Depth != 1 || child.Offset != 20 || child.Width != 40 {
+		t.Fatalf("child row = %+v, want depth 1 at 20%% for 40%% of the trace", child)
+	}
+
+	response, err := http.Get(server.URL + "/")
+	if err != nil {
+		t.Fatalf("GET / error = %v", err)
+	}
+	defer func() { _ = response.Body.Close() }()
+	body, _ := io.ReadAll(response.Body)
+	if response.StatusCode != http.StatusOK || !strings.Contains(string(body), "SELECT items") {
+		t.Fatalf("GET / = %d, body missing the spans:\n%s", response.StatusCode, body)
+	}
+}
+
+func TestSinkDropsOldestTraces(t *testing.T) {
+	sink := NewSink(2)
+	start := time.Date(2026, time.January, 27, 12, 0, 0, 0, time.UTC)
+	for trace := byte(1); trace <= 3; trace++ {
+		sink.store(model.Batch{demoSpan(trace, 1, 0, "GET /items", start, time.Millisecond)})
+	}
+	traces := sink.Traces()
+	if len(traces) != 2 || traces[0][0].TraceID != (oteltrace.TraceID{3}) || traces[1][0].TraceID != (oteltrace.TraceID{2}) {
+		t.Fatalf("expected traces 3 and 2 to be kept, got %d traces", len(traces))
+	}
+}
+
+func TestSinkRejectsInvalidExports(t *testing.T) {
+	sink := NewSink(10)
+	request := httptest.NewRequest(http.MethodPost, TracesPath, strings.NewReader("not json"))
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	sink.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusBadRequest)
+	}
+}
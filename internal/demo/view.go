@@ -0,0 +1,163 @@
+package demo
+
+import (
+	"html/template"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/model"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// viewTraces is the number of most recent traces the web view shows.
+const viewTraces = 50
+
+// traceView is one trace of the web view.
+type traceView struct {
+	ID       string
+	Root     string
+	Duration time.Duration
+	Spans    []spanRow
+	Errors   int
+}
+
+// spanRow is one span of a trace, indented under its parent, with its
+// position on the timeline of the trace in percent.
+type spanRow struct {
+	Depth    int
+	Name     string
+	Service  string
+	Kind     string
+	Duration time.Duration
+	Offset   float64
+	Width    float64
+	Error    bool
+}
+
+func (s *Sink) view(w http.ResponseWriter, _ *http.Request) {
+	requests, spans := s.Stats()
+	traces := s.Traces()
+	data := struct {
+		Requests int
+		Spans    int
+		Stored   int
+		Traces   []traceView
+	}{Requests: requests, Spans: spans, Stored: len(traces)}
+	for _, trace := range traces[:min(len(traces), viewTraces)] {
+		data.Traces = append(data.Traces, newTraceView(trace))
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := viewTemplate.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// newTraceView lays out the spans of trace depth first, siblings by start
+// time. Spans whose parent was not received are shown as roots.
+func newTraceView(trace []model.Span) traceView {
+	present := make(map[oteltrace.SpanID]bool, len(trace))
+	start, end := trace[0].StartTime, trace[0].EndTime
+	for _, span := range trace {
+		present[span.SpanID] = true
+		if span.StartTime.Before(start) {
+			start = span.StartTime
+		}
+		if span.EndTime.After(end) {
+			end = span.EndTime
+		}
+	}
+	children := map[oteltrace.SpanID][]model.Span{}
+	var roots []model.Span
+	for _, span := range trace {
+		if span.ParentSpanID.IsValid() && present[span.ParentSpanID] {
+			children[span.ParentSpanID] = append(children[span.ParentSpanID], span)
+		} else {
+			roots = append(roots, span)
+		}
+	}
+	byStart := func(a, b model.Span) int { return a.StartTime.Compare(b.StartTime) }
+	slices.SortStableFunc(roots, byStart)
+
+	total := end.Sub(start)
+	view := traceView{ID: trace[0].TraceID.String(), Root: roots[0].Name, Duration: total}
+	var add func(span model.Span, depth int)
+	add = func(span model.Span, depth int) {
+		row := spanRow{
+			Depth:    depth,
+			Name:     span.Name,
+			Service:  "?",
+			Kind:     span.Kind.String(),
+			Duration: span.EndTime.Sub(span.StartTime),
+			Width:    100,
+			Error:    span.StatusCode == codes.Error,
+		}
+		if name, ok := span.ResourceAttributes["service.name"]; ok {
+			row.Service = name.Emit()
+		}
+		if total > 0 {
+			row.Offset = 100 * float64(span.StartTime.Sub(start)) / float64(total)
+			row.Width = max(100*float64(row.Duration)/float64(total), 0.5)
+		}
+		if row.Error {
+			view.Errors++
+		}
+		view.Spans = append(view.Spans, row)
+		next := children[span.SpanID]
+		slices.SortStableFunc(next, byStart)
+		for _, child := range next {
+			add(child, depth+1)
+		}
+	}
+	for _, root := range roots {
+		add(root, 0)
+	}
+	return view
+}
+
+var viewTemplate = template.Must(template.New("view").Funcs(template.FuncMap{
+	"indent": func(depth int) int { return depth * 16 },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="2">
+<title>tercios demo</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+details { border: 1px solid #ddd; border-radius: 4px; margin: 0.5em 0; padding: 0.4em 0.8em; }
+summary { cursor: pointer; }
+table { width: 100%; border-collapse: collapse; font-size: 0.9em; margin-top: 0.5em; }
+td { padding: 2px 6px; white-space: nowrap; }
+td.timeline { width: 40%; }
+.bar { height: 10px; background: #4a90d9; border-radius: 2px; }
+.error { color: #c0392b; }
+.error .bar { background: #c0392b; }
+.muted { color: #888; }
+</style>
+</head>
+<body>
+<h1>tercios demo</h1>
+<p>{{.Requests}} export requests, {{.Spans}} spans received. Showing the {{len .Traces}} most recent of {{.Stored}} stored traces; the page refreshes every 2 seconds.</p>
+{{range .Traces}}
+<details>
+<summary><strong>{{.Root}}</strong> <span class="muted">{{.ID}} · {{len .Spans}} spans · {{.Duration}}</span>{{if .Errors}} <span class="error">{{.Errors}} errors</span>{{end}}</summary>
+<table>
+{{range .Spans}}
+<tr{{if .Error}} class="error"{{end}}>
+<td style="padding-left: {{indent .Depth}}px">{{.Name}}</td>
+<td class="muted">{{.Service}}</td>
+<td class="muted">{{.Kind}}</td>
+<td>{{.Duration}}</td>
+<td class="timeline"><div class="bar" style="margin-left: {{printf "%.2f" .Offset}}%; width: {{printf "%.2f" .Width}}%"></div></td>
+</tr>
+{{end}}
+</table>
+</details>
+{{else}}
+<p class="muted">No traces received yet.</p>
+{{end}}
+</body>
+</html>
+`))
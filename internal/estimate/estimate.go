@@ -0,0 +1,147 @@
+// Package estimate predicts the requests, spans and payload bytes of a run
+// from its pacing and a sample of generated batches, without sending
+// anything.
+package estimate
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/loadprofile"
+	"github.com/javiermolinar/tercios/internal/model"
+	"github.com/javiermolinar/tercios/internal/otlp"
+)
+
+// Plan is the pacing of a run, as set by --exporters, --max-requests,
+// --request-interval, --for and --profile.
+type Plan struct {
+	Exporters   int
+	PerExporter int
+	Interval    time.Duration
+	For         time.Duration
+	Profile     *loadprofile.Profile
+}
+
+// Requests returns the number of requests the run sends and how long it
+// takes. The duration is zero when it depends on how fast the endpoint
+// answers: an unpaced run limited only by --max-requests.
+func (p Plan) Requests() (int64, time.Duration, error) {
+	limit := int64(p.Exporters) * int64(p.PerExporter)
+	switch {
+	case p.Profile != nil:
+		if p.For <= 0 {
+			return 0, 0, fmt.Errorf("a load profile needs --for to estimate")
+		}
+		total := int64(math.Round(profileRequests(*p.Profile, p.For)))
+		if limit > 0 && limit < total {
+			return limit, 0, nil
+		}
+		return total, p.For, nil
+	case p.Interval > 0 && p.For > 0:
+		perExporter := int64(p.For / p.Interval)
+		if p.PerExporter > 0 && int64(p.PerExporter) < perExporter {
+			return limit, time.Duration(p.PerExporter) * p.Interval, nil
+		}
+		return int64(p.Exporters) * perExporter, p.For, nil
+	case limit > 0:
+		return limit, time.Duration(p.PerExporter) * p.Interval, nil
+	default:
+		return 0, 0, fmt.Errorf("an unpaced run without --max-requests has no fixed volume; set --max-requests, or --request-interval with --for")
+	}
+}
+
+// profileRequests integrates the profile's total request rate over the run
+// with the midpoint rule in 100ms steps.
+func profileRequests(profile loadprofile.Profile, duration time.Duration) float64 {
+	const step = 100 * time.Millisecond
+	total := 0.0
+	for elapsed := time.Duration(0); elapsed < duration; elapsed += step {
+		width := min(step, duration-elapsed)
+		total += profile.Rate(elapsed+width/2) * width.Seconds()
+	}
+	return total
+}
+
+// Result is the predicted volume of a run. Per-request values are the
+// averages of the sampled batches.
+type Result struct {
+	Requests int64
+	// Duration is zero when the run is not time-bound.
+	Duration            time.Duration
+	Samples             int
+	SpansPerRequest     float64
+	BytesPerRequest     float64
+	GzipBytesPerRequest float64
+}
+
+func (r Result) Spans() float64 {
+	return float64(r.Requests) * r.SpansPerRequest
+}
+
+func (r Result) Bytes() float64 {
+	return float64(r.Requests) * r.BytesPerRequest
+}
+
+func (r Result) GzipBytes() float64 {
+	return float64(r.Requests) * r.GzipBytesPerRequest
+}
+
+// Run estimates plan by drawing up to samples batches from generate and
+// measuring their span count and OTLP protobuf size, raw and gzipped.
+// generate may return io.EOF to end sampling early.
+func Run(ctx context.Context, plan Plan, samples int, generate func(context.Context) ([]model.Span, error)) (Result, error) {
+	requests, duration, err := plan.Requests()
+	if err != nil {
+		return Result{}, err
+	}
+	result := Result{Requests: requests, Duration: duration}
+
+	var spans, raw, compressed int
+	for range samples {
+		batch, err := generate(ctx)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Result{}, fmt.Errorf("generate sample batch: %w", err)
+		}
+		payload, err := otlp.MarshalBatch(batch)
+		if err != nil {
+			return Result{}, fmt.Errorf("encode sample batch: %w", err)
+		}
+		gzipped, err := gzipSize(payload)
+		if err != nil {
+			return Result{}, err
+		}
+		result.Samples++
+		spans += len(batch)
+		raw += len(payload)
+		compressed += gzipped
+	}
+	if result.Samples == 0 {
+		return Result{}, fmt.Errorf("no sample batches were generated")
+	}
+	n := float64(result.Samples)
+	result.SpansPerRequest = float64(spans) / n
+	result.BytesPerRequest = float64(raw) / n
+	result.GzipBytesPerRequest = float64(compressed) / n
+	return result, nil
+}
+
+func gzipSize(payload []byte) (int, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(payload); err != nil {
+		return 0, fmt.Errorf("gzip sample batch: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return 0, fmt.Errorf("gzip sample batch: %w", err)
+	}
+	return buf.Len(), nil
+}
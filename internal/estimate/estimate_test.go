@@ -0,0 +1,80 @@
+package estimate
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/loadprofile"
+	"github.com/javiermolinar/tercios/internal/model"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+func TestPlanRequests(t *testing.T) {
+	ramp, err := loadprofile.Parse("ramp:0-100/1m")
+	if err != nil {
+		t.Fatalf("loadprofile.Parse() error = %v", err)
+	}
+	tests := []struct {
+		name     string
+		plan     Plan
+		requests int64
+		duration time.Duration
+		wantErr  bool
+	}{
+		{name: "paced for a duration", plan: Plan{Exporters: 10, Interval: 500 * time.Millisecond, For: time.Hour}, requests: 72000, duration: time.Hour},
+		{name: "max requests ends first", plan: Plan{Exporters: 2, PerExporter: 5, Interval: time.Second, For: time.Hour}, requests: 10, duration: 5 * time.Second},
+		{name: "max requests only", plan: Plan{Exporters: 4, PerExporter: 25, Interval: time.Second}, requests: 100, duration: 25 * time.Second},
+		{name: "unpaced max requests", plan: Plan{Exporters: 4, PerExporter: 25}, requests: 100},
+		{name: "load profile", plan: Plan{Exporters: 4, For: time.Minute, Profile: &ramp}, requests: 3000, duration: time.Minute},
+		{name: "unbounded", plan: Plan{Exporters: 4, For: time.Minute}, wantErr: true},
+		{name: "profile without duration", plan: Plan{Exporters: 4, Profile: &ramp}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests, duration, err := tt.plan.Requests()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %d requests", requests)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Requests() error = %v", err)
+			}
+			if requests != tt.requests || duration != tt.duration {
+				t.Fatalf("Requests() = %d, %v; want %d, %v", requests, duration, tt.requests, tt.duration)
+			}
+		})
+	}
+}
+
+func TestRunMeasuresSampledBatches(t *testing.T) {
+	calls := 0
+	generate := func(context.Context) ([]model.Span, error) {
+		calls++
+		if calls > 4 {
+			return nil, io.EOF
+		}
+		batch := make([]model.Span, calls)
+		for i := range batch {
+			batch[i] = model.Span{TraceID: oteltrace.TraceID{0x01}, SpanID: oteltrace.SpanID{byte(i + 1)}, Name: "GET /items"}
+		}
+		return batch, nil
+	}
+
+	result, err := Run(context.Background(), Plan{Exporters: 2, PerExporter: 10}, 100, generate)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Samples != 4 || result.SpansPerRequest != 2.5 {
+		t.Fatalf("expected 4 samples averaging 2.5 spans, got %+v", result)
+	}
+	if result.Spans() != 50 {
+		t.Fatalf("expected 50 spans for 20 requests, got %v", result.Spans())
+	}
+	if result.BytesPerRequest <= 0 || result.GzipBytesPerRequest <= 0 || result.Bytes() != 20*result.BytesPerRequest {
+		t.Fatalf("unexpected byte estimate %+v", result)
+	}
+}
@@ -0,0 +1,108 @@
+// Package fileformat lets the JSON-tagged config types in tercios be
+// authored as YAML. YAML documents are converted to JSON and then go
+// through the same strict JSON decoders, so validation, unknown-field
+// rejection and custom UnmarshalJSON methods behave identically.
+package fileformat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// IsYAML reports whether path has a .yaml or .yml extension.
+func IsYAML(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return true
+	default:
+		return false
+	}
+}
+
+// Open opens path and returns a reader of JSON content: JSON files are
+// returned as-is, YAML files are converted first.
+func Open(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !IsYAML(path) {
+		return file, nil
+	}
+	defer func() { _ = file.Close() }()
+	data, err := YAMLToJSON(file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid YAML in %q: %w", path, err)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// YAMLToJSON converts a single YAML document into its JSON equivalent.
+func YAMLToJSON(r io.Reader) ([]byte, error) {
+	decoder := yaml.NewDecoder(r)
+	var document any
+	if err := decoder.Decode(&document); err != nil {
+		if err == io.EOF {
+			return nil, fmt.Errorf("empty document")
+		}
+		return nil, err
+	}
+	var extra any
+	if err := decoder.Decode(&extra); err != io.EOF {
+		if err == nil {
+			return nil, fmt.Errorf("multiple documents are not supported")
+		}
+		return nil, err
+	}
+	normalized, err := normalize(document)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(normalized)
+}
+
+// normalize rewrites map[any]any (produced for non-string YAML keys) into
+// map[string]any so the result can be marshaled as JSON.
+func normalize(value any) (any, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			normalized, err := normalize(item)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = normalized
+		}
+		return out, nil
+	case map[any]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			normalized, err := normalize(item)
+			if err != nil {
+				return nil, err
+			}
+			out[fmt.Sprint(key)] = normalized
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			normalized, err := normalize(item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = normalized
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
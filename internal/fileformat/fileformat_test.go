@@ -0,0 +1,75 @@
+package fileformat
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestYAMLToJSONConvertsNestedDocument(t *testing.T) {
+	input := `
+name: demo
+seed: 42
+edges:
+  - from: a
+    to: b
+    repeat: 2
+resource:
+  service.name: {type: string, value: frontend}
+`
+	data, err := YAMLToJSON(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("YAMLToJSON() error = %v", err)
+	}
+	got := string(data)
+	for _, want := range []string{`"name":"demo"`, `"seed":42`, `"repeat":2`, `"service.name":{"type":"string","value":"frontend"}`} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("expected %s in %s", want, got)
+		}
+	}
+}
+
+func TestYAMLToJSONStringifiesNonStringKeys(t *testing.T) {
+	data, err := YAMLToJSON(strings.NewReader("codes:\n  200: ok\n"))
+	if err != nil {
+		t.Fatalf("YAMLToJSON() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"200":"ok"`) {
+		t.Fatalf("expected stringified key, got %s", data)
+	}
+}
+
+func TestYAMLToJSONRejectsMultipleDocuments(t *testing.T) {
+	if _, err := YAMLToJSON(strings.NewReader("a: 1\n---\nb: 2\n")); err == nil {
+		t.Fatalf("expected error for multiple documents")
+	}
+}
+
+func TestOpenPassesJSONThroughAndConvertsYAML(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "config.json")
+	yamlPath := filepath.Join(dir, "config.yml")
+	if err := os.WriteFile(jsonPath, []byte(`{"a": 1}`), 0o600); err != nil {
+		t.Fatalf("write json: %v", err)
+	}
+	if err := os.WriteFile(yamlPath, []byte("a: 1\n"), 0o600); err != nil {
+		t.Fatalf("write yaml: %v", err)
+	}
+
+	for path, want := range map[string]string{jsonPath: `{"a": 1}`, yamlPath: `{"a":1}`} {
+		reader, err := Open(path)
+		if err != nil {
+			t.Fatalf("Open(%q) error = %v", path, err)
+		}
+		data, err := io.ReadAll(reader)
+		_ = reader.Close()
+		if err != nil {
+			t.Fatalf("read %q: %v", path, err)
+		}
+		if string(data) != want {
+			t.Fatalf("Open(%q) = %s, want %s", path, data, want)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+// Package freshness measures how long exported traces take to become fully
+// queryable in the backend, so a run reports end-to-end freshness and not
+// only export latency.
+package freshness
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/metrics"
+	"github.com/javiermolinar/tercios/internal/model"
+)
+
+type Config struct {
+	// SampleRate is the fraction of traces probed, chosen by trace ID.
+	SampleRate float64
+	// PollInterval is the delay between queries for one trace; it bounds
+	// the resolution of the measured latency.
+	PollInterval time.Duration
+	// Timeout is how long after export a trace may take to become
+	// complete before it is counted as timed out.
+	Timeout time.Duration
+	// MaxInFlight caps concurrent probes. Traces sampled while the cap is
+	// reached are counted as skipped.
+	MaxInFlight int
+}
+
+func DefaultConfig() Config {
+	return Config{
+		SampleRate:   0.01,
+		PollInterval: time.Second,
+		Timeout:      time.Minute,
+		MaxInFlight:  64,
+	}
+}
+
+func (c Config) Validate() error {
+	if c.SampleRate <= 0 || c.SampleRate > 1 {
+		return fmt.Errorf("sample rate must be in (0, 1]")
+	}
+	if c.PollInterval <= 0 {
+		return fmt.Errorf("poll interval must be > 0")
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("timeout must be > 0")
+	}
+	if c.MaxInFlight <= 0 {
+		return fmt.Errorf("max in-flight probes must be > 0")
+	}
+	return nil
+}
+
+// SpanCounter reports how many spans of a trace a backend can return.
+// Every verify.VerificationBackend satisfies it.
+type SpanCounter interface {
+	CountSpans(ctx context.Context, traceID string) (int, error)
+}
+
+// Prober polls the backend for sampled traces after they are exported.
+type Prober struct {
+	ctx     context.Context
+	counter SpanCounter
+	config  Config
+
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	inFlight int
+	result   metrics.Freshness
+}
+
+// NewProber returns a prober whose probes stop when ctx ends.
+func NewProber(ctx context.Context, counter SpanCounter, cfg Config) *Prober {
+	return &Prober{ctx: ctx, counter: counter, config: cfg}
+}
+
+// Observe starts probes for the sampled traces of batch, which finished
+// exporting at exportedAt. It does not block and is safe for concurrent
+// use by export workers.
+func (p *Prober) Observe(batch model.Batch, exportedAt time.Time) {
+	expected := make(map[string]int)
+	var order []string
+	for _, span := range batch {
+		if !p.sampled(span.TraceID) {
+			continue
+		}
+		traceID := span.TraceID.String()
+		if _, ok := expected[traceID]; !ok {
+			order = append(order, traceID)
+		}
+		expected[traceID]++
+	}
+
+	for _, traceID := range order {
+		p.mu.Lock()
+		if p.inFlight >= p.config.MaxInFlight {
+			p.result.Skipped++
+			p.mu.Unlock()
+			continue
+		}
+		p.inFlight++
+		p.result.Probed++
+		p.mu.Unlock()
+
+		p.wg.Add(1)
+		go p.probe(traceID, expected[traceID], exportedAt)
+	}
+}
+
+// Wait blocks until every started probe finished or timed out and returns
+// the collected result.
+func (p *Prober) Wait() metrics.Freshness {
+	p.wg.Wait()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	result := p.result
+	result.Latencies = append([]time.Duration(nil), p.result.Latencies...)
+	return result
+}
+
+func (p *Prober) sampled(traceID [16]byte) bool {
+	if p.config.SampleRate >= 1 {
+		return true
+	}
+	return float64(binary.BigEndian.Uint64(traceID[8:])) < p.config.SampleRate*math.MaxUint64
+}
+
+func (p *Prober) probe(traceID string, expected int, exportedAt time.Time) {
+	defer p.wg.Done()
+	ctx, cancel := context.WithDeadline(p.ctx, exportedAt.Add(p.config.Timeout))
+	defer cancel()
+
+	ticker := time.NewTicker(p.config.PollInterval)
+	defer ticker.Stop()
+	for {
+		// Errors are retried until the deadline: a backend still ingesting
+		// may fail queries for a trace it has only partially stored.
+		if count, err := p.counter.CountSpans(ctx, traceID); err == nil && count >= expected {
+			p.finish(time.Since(exportedAt), true)
+			return
+		}
+		select {
+		case <-ctx.Done():
+			p.finish(0, false)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Prober) finish(latency time.Duration, queryable bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.inFlight--
+	if queryable {
+		p.result.Queryable++
+		p.result.Latencies = append(p.result.Latencies, latency)
+	} else {
+		p.result.TimedOut++
+	}
+}
@@ -0,0 +1,113 @@
+package freshness
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/model"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// fakeCounter returns the full span count once a trace was queried
+// readyAfter times, and zero before.
+type fakeCounter struct {
+	mu         sync.Mutex
+	readyAfter int
+	full       map[string]int
+	calls      map[string]int
+}
+
+func (c *fakeCounter) CountSpans(_ context.Context, traceID string) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls[traceID]++
+	if c.calls[traceID] < c.readyAfter {
+		return 0, nil
+	}
+	return c.full[traceID], nil
+}
+
+func testConfig() Config {
+	return Config{SampleRate: 1, PollInterval: time.Millisecond, Timeout: 500 * time.Millisecond, MaxInFlight: 10}
+}
+
+func traceBatch(traceID byte, spans int) model.Batch {
+	batch := make(model.Batch, spans)
+	for i := range batch {
+		batch[i].TraceID = oteltrace.TraceID{traceID}
+	}
+	return batch
+}
+
+func TestProberMeasuresQueryableTraces(t *testing.T) {
+	first := oteltrace.TraceID{1}.String()
+	second := oteltrace.TraceID{2}.String()
+	counter := &fakeCounter{
+		readyAfter: 3,
+		full:       map[string]int{first: 2, second: 1},
+		calls:      map[string]int{},
+	}
+	prober := NewProber(context.Background(), counter, testConfig())
+
+	batch := append(traceBatch(1, 2), traceBatch(2, 3)...)
+	prober.Observe(batch, time.Now())
+	result := prober.Wait()
+
+	if result.Probed != 2 || result.Queryable != 1 || result.TimedOut != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if len(result.Latencies) != 1 || result.Latencies[0] <= 0 {
+		t.Fatalf("expected one positive latency, got %v", result.Latencies)
+	}
+	if counter.calls[first] != 3 {
+		t.Fatalf("expected complete trace to stop polling after 3 queries, got %d", counter.calls[first])
+	}
+}
+
+func TestProberSkipsTracesOverInFlightCap(t *testing.T) {
+	counter := &fakeCounter{readyAfter: 1, full: map[string]int{}, calls: map[string]int{}}
+	cfg := testConfig()
+	cfg.MaxInFlight = 1
+	cfg.Timeout = 20 * time.Millisecond
+	prober := NewProber(context.Background(), counter, cfg)
+
+	prober.Observe(append(traceBatch(1, 1), traceBatch(2, 1)...), time.Now())
+	result := prober.Wait()
+	if result.Probed != 1 || result.Skipped != 1 {
+		t.Fatalf("expected one probed and one skipped trace, got %+v", result)
+	}
+}
+
+func TestProberSamplesByTraceID(t *testing.T) {
+	cfg := testConfig()
+	cfg.SampleRate = 0.5
+	prober := NewProber(context.Background(), &fakeCounter{}, cfg)
+
+	low := oteltrace.TraceID{}
+	high := oteltrace.TraceID{}
+	high[8] = 0xff
+	if !prober.sampled(low) {
+		t.Fatalf("expected trace ID in the lower half to be sampled")
+	}
+	if prober.sampled(high) {
+		t.Fatalf("expected trace ID in the upper half to be skipped")
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Fatalf("expected default config to be valid, got %v", err)
+	}
+	cfg := DefaultConfig()
+	cfg.SampleRate = 0
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error for zero sample rate")
+	}
+	cfg = DefaultConfig()
+	cfg.PollInterval = 0
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error for zero poll interval")
+	}
+}
@@ -0,0 +1,163 @@
+// Package httpstatus maps HTTP response status codes to span status. The
+// scenario generator and the chaos engine share it so an injected or
+// generated status code always yields the same span status.
+package httpstatus
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/javiermolinar/tercios/internal/jsonschema"
+	"github.com/javiermolinar/tercios/internal/model"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// AttributeKeys are the span attributes read as the HTTP response status,
+// current semantic convention first.
+var AttributeKeys = []string{"http.response.status_code", "http.status_code"}
+
+// Range is an inclusive range of status codes.
+type Range struct {
+	Min int64
+	Max int64
+}
+
+// Ranges is a set of status code ranges. In JSON it is a list of entries
+// such as "5xx", "429" or "500-504"; plain numbers are accepted too.
+type Ranges []Range
+
+// Mapping decides which status codes mark a span as an error. Client spans
+// (the caller's view) and all other spans are configured separately because
+// backends differ on whether a 4xx is the server's fault.
+type Mapping struct {
+	ClientErrors Ranges `json:"client_errors"`
+	ServerErrors Ranges `json:"server_errors"`
+}
+
+// DefaultMapping follows the OpenTelemetry HTTP semantic conventions: 4xx
+// and 5xx are errors on client spans, only 5xx on server spans.
+func DefaultMapping() Mapping {
+	return Mapping{
+		ClientErrors: Ranges{{Min: 400, Max: 599}},
+		ServerErrors: Ranges{{Min: 500, Max: 599}},
+	}
+}
+
+// IsError reports whether code marks a span of the given kind as an error.
+func (m Mapping) IsError(kind oteltrace.SpanKind, code int64) bool {
+	if kind == oteltrace.SpanKindClient {
+		return m.ClientErrors.Contains(code)
+	}
+	return m.ServerErrors.Contains(code)
+}
+
+// Apply sets the span status from its HTTP status code attribute, if any:
+// Error with an "HTTP <code>" description for error codes, Ok otherwise.
+// It reports whether the span carried a status code.
+func (m Mapping) Apply(span *model.Span) bool {
+	if span == nil {
+		return false
+	}
+	code, ok := StatusCode(span.Attributes)
+	if !ok {
+		return false
+	}
+	if m.IsError(span.Kind, code) {
+		span.StatusCode = codes.Error
+		span.StatusDescription = fmt.Sprintf("HTTP %d", code)
+	} else {
+		span.StatusCode = codes.Ok
+		span.StatusDescription = ""
+	}
+	return true
+}
+
+// StatusCode returns the integer HTTP status code found in attrs.
+func StatusCode(attrs map[string]attribute.Value) (int64, bool) {
+	for _, key := range AttributeKeys {
+		value, ok := attrs[key]
+		if ok && value.Type() == attribute.INT64 {
+			return value.AsInt64(), true
+		}
+	}
+	return 0, false
+}
+
+// IsStatusAttribute reports whether key is one of AttributeKeys.
+func IsStatusAttribute(key string) bool {
+	for _, candidate := range AttributeKeys {
+		if key == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Ranges) Contains(code int64) bool {
+	for _, rng := range r {
+		if code >= rng.Min && code <= rng.Max {
+			return true
+		}
+	}
+	return false
+}
+
+// JSONSchema describes the entries UnmarshalJSON accepts.
+func (Ranges) JSONSchema() *jsonschema.Schema {
+	return &jsonschema.Schema{
+		Type:  jsonschema.Types{"array"},
+		Items: &jsonschema.Schema{Type: jsonschema.Types{"integer", "string"}, Description: `A status code, a class such as "5xx" or a range such as "500-504"`},
+	}
+}
+
+func (r *Ranges) UnmarshalJSON(data []byte) error {
+	var entries []json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("status code ranges must be a list: %w", err)
+	}
+	out := make(Ranges, 0, len(entries))
+	for _, entry := range entries {
+		var number int64
+		if err := json.Unmarshal(entry, &number); err == nil {
+			out = append(out, Range{Min: number, Max: number})
+			continue
+		}
+		var text string
+		if err := json.Unmarshal(entry, &text); err != nil {
+			return fmt.Errorf("invalid status code range %s", string(entry))
+		}
+		rng, err := ParseRange(text)
+		if err != nil {
+			return err
+		}
+		out = append(out, rng)
+	}
+	*r = out
+	return nil
+}
+
+// ParseRange parses "5xx", "429" or "500-504".
+func ParseRange(raw string) (Range, error) {
+	text := strings.ToLower(strings.TrimSpace(raw))
+	if len(text) == 3 && strings.HasSuffix(text, "xx") && text[0] >= '1' && text[0] <= '5' {
+		class := int64(text[0]-'0') * 100
+		return Range{Min: class, Max: class + 99}, nil
+	}
+	if low, high, ok := strings.Cut(text, "-"); ok {
+		min, errMin := strconv.ParseInt(strings.TrimSpace(low), 10, 64)
+		max, errMax := strconv.ParseInt(strings.TrimSpace(high), 10, 64)
+		if errMin != nil || errMax != nil || min > max {
+			return Range{}, fmt.Errorf("invalid status code range %q", raw)
+		}
+		return Range{Min: min, Max: max}, nil
+	}
+	code, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return Range{}, fmt.Errorf("invalid status code range %q", raw)
+	}
+	return Range{Min: code, Max: code}, nil
+}
@@ -0,0 +1,83 @@
+package httpstatus
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/javiermolinar/tercios/internal/model"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+func TestParseRange(t *testing.T) {
+	tests := map[string]Range{
+		"5xx":     {Min: 500, Max: 599},
+		"4XX":     {Min: 400, Max: 499},
+		"429":     {Min: 429, Max: 429},
+		"500-504": {Min: 500, Max: 504},
+	}
+	for input, want := range tests {
+		got, err := ParseRange(input)
+		if err != nil {
+			t.Fatalf("ParseRange(%q) error = %v", input, err)
+		}
+		if got != want {
+			t.Fatalf("ParseRange(%q) = %+v, want %+v", input, got, want)
+		}
+	}
+	for _, input := range []string{"6xx", "abc", "504-500", ""} {
+		if _, err := ParseRange(input); err == nil {
+			t.Fatalf("ParseRange(%q) expected error", input)
+		}
+	}
+}
+
+func TestMappingUnmarshalJSON(t *testing.T) {
+	var mapping Mapping
+	if err := json.Unmarshal([]byte(`{"client_errors": ["5xx", 429], "server_errors": ["500-502"]}`), &mapping); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !mapping.IsError(oteltrace.SpanKindClient, 429) || mapping.IsError(oteltrace.SpanKindClient, 404) {
+		t.Fatalf("unexpected client ranges: %+v", mapping.ClientErrors)
+	}
+	if !mapping.IsError(oteltrace.SpanKindServer, 502) || mapping.IsError(oteltrace.SpanKindServer, 503) {
+		t.Fatalf("unexpected server ranges: %+v", mapping.ServerErrors)
+	}
+}
+
+func TestDefaultMappingApplyBySpanKind(t *testing.T) {
+	mapping := DefaultMapping()
+	newSpan := func(kind oteltrace.SpanKind, code int64) model.Span {
+		return model.Span{
+			Kind:       kind,
+			Attributes: map[string]attribute.Value{"http.response.status_code": attribute.Int64Value(code)},
+			StatusCode: codes.Ok,
+		}
+	}
+
+	client := newSpan(oteltrace.SpanKindClient, 404)
+	if !mapping.Apply(&client) || client.StatusCode != codes.Error || client.StatusDescription != "HTTP 404" {
+		t.Fatalf("expected client 404 to be an error, got %v %q", client.StatusCode, client.StatusDescription)
+	}
+	server := newSpan(oteltrace.SpanKindServer, 404)
+	if !mapping.Apply(&server) || server.StatusCode != codes.Ok {
+		t.Fatalf("expected server 404 to be ok, got %v", server.StatusCode)
+	}
+	server = newSpan(oteltrace.SpanKindServer, 503)
+	if mapping.Apply(&server); server.StatusCode != codes.Error {
+		t.Fatalf("expected server 503 to be an error, got %v", server.StatusCode)
+	}
+
+	noStatus := model.Span{StatusCode: codes.Ok}
+	if mapping.Apply(&noStatus) || noStatus.StatusCode != codes.Ok {
+		t.Fatalf("expected span without status code to be untouched")
+	}
+}
+
+func TestStatusCodeReadsLegacyAttribute(t *testing.T) {
+	code, ok := StatusCode(map[string]attribute.Value{"http.status_code": attribute.Int64Value(500)})
+	if !ok || code != 500 {
+		t.Fatalf("expected legacy status code 500, got %d %v", code, ok)
+	}
+}
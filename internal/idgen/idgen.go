@@ -0,0 +1,174 @@
+// Package idgen generates the trace and span IDs of scenario traces. The
+// default seeded generator keeps runs reproducible; the others give IDs a
+// structure, so backends that shard or route by ID can be probed with
+// IDs whose layout is known.
+package idgen
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// Generator returns the IDs of scenario traces. seed identifies the
+// scenario within the run, sequence numbers its traces from 1, and index
+// numbers the span IDs of one trace from 1. Generators must be safe for
+// concurrent use and never return invalid (all-zero) IDs.
+type Generator interface {
+	TraceID(ctx context.Context, seed, sequence uint64) oteltrace.TraceID
+	SpanID(seed, sequence, index uint64) oteltrace.SpanID
+}
+
+// Kind names a Generator for the CLI and the config file.
+type Kind string
+
+const (
+	KindSeeded     Kind = "seeded"
+	KindRandom     Kind = "random"
+	KindSequential Kind = "sequential"
+	KindPrefixed   Kind = "prefixed"
+)
+
+func ParseKind(value string) (Kind, error) {
+	switch strings.TrimSpace(strings.ToLower(value)) {
+	case "", string(KindSeeded):
+		return KindSeeded, nil
+	case string(KindRandom):
+		return KindRandom, nil
+	case string(KindSequential):
+		return KindSequential, nil
+	case string(KindPrefixed), "prefix":
+		return KindPrefixed, nil
+	default:
+		return "", fmt.Errorf("unsupported ID generator %q (supported: %s, %s, %s, %s)", value, KindSeeded, KindRandom, KindSequential, KindPrefixed)
+	}
+}
+
+// New returns the generator of kind. run is the run identifier the
+// prefixed generator encodes; the others ignore it.
+func New(kind Kind, run uint32) (Generator, error) {
+	switch kind {
+	case "", KindSeeded:
+		return Seeded{}, nil
+	case KindRandom:
+		return Random{}, nil
+	case KindSequential:
+		return Sequential{}, nil
+	case KindPrefixed:
+		return Prefixed{Run: run}, nil
+	default:
+		return nil, fmt.Errorf("unsupported ID generator %q", kind)
+	}
+}
+
+// Seeded derives every ID from the seed and sequence, so the same seed
+// gives the same IDs on every run. It is the default.
+type Seeded struct{}
+
+func (Seeded) TraceID(_ context.Context, seed, sequence uint64) oteltrace.TraceID {
+	a := splitmix64(seed ^ sequence)
+	b := splitmix64(a ^ 0x9e3779b97f4a7c15)
+	var id oteltrace.TraceID
+	binary.BigEndian.PutUint64(id[0:8], a)
+	binary.BigEndian.PutUint64(id[8:16], b)
+	if !id.IsValid() {
+		id[15] = 1
+	}
+	return id
+}
+
+func (Seeded) SpanID(seed, sequence, index uint64) oteltrace.SpanID {
+	return spanID(splitmix64(seed ^ sequence ^ index))
+}
+
+// Random draws every ID at random, like an SDK does. Runs are not
+// reproducible with it.
+type Random struct{}
+
+func (Random) TraceID(context.Context, uint64, uint64) oteltrace.TraceID {
+	var id oteltrace.TraceID
+	binary.BigEndian.PutUint64(id[0:8], rand.Uint64())
+	binary.BigEndian.PutUint64(id[8:16], rand.Uint64())
+	if !id.IsValid() {
+		id[15] = 1
+	}
+	return id
+}
+
+func (Random) SpanID(uint64, uint64, uint64) oteltrace.SpanID {
+	return spanID(rand.Uint64())
+}
+
+// Sequential numbers IDs in order: a trace ID is the scenario seed
+// followed by the trace's sequence number, and a span ID is the low 32
+// bits of the sequence number followed by the span's index.
+type Sequential struct{}
+
+func (Sequential) TraceID(_ context.Context, seed, sequence uint64) oteltrace.TraceID {
+	var id oteltrace.TraceID
+	binary.BigEndian.PutUint64(id[0:8], seed)
+	binary.BigEndian.PutUint64(id[8:16], sequence)
+	if !id.IsValid() {
+		id[15] = 1
+	}
+	return id
+}
+
+func (Sequential) SpanID(_, sequence, index uint64) oteltrace.SpanID {
+	return spanID(sequence<<32 | index&0xffffffff)
+}
+
+// Prefixed starts every trace ID with the run and the worker that
+// generated it: 4 bytes of Run, then 2 bytes of the worker set on the
+// context with WithWorker, then 10 seeded bytes. Span IDs are seeded.
+type Prefixed struct {
+	Run uint32
+}
+
+func (p Prefixed) TraceID(ctx context.Context, seed, sequence uint64) oteltrace.TraceID {
+	id := Seeded{}.TraceID(ctx, seed, sequence)
+	binary.BigEndian.PutUint32(id[0:4], p.Run)
+	binary.BigEndian.PutUint16(id[4:6], uint16(Worker(ctx)))
+	if !id.IsValid() {
+		id[15] = 1
+	}
+	return id
+}
+
+func (Prefixed) SpanID(seed, sequence, index uint64) oteltrace.SpanID {
+	return Seeded{}.SpanID(seed, sequence, index)
+}
+
+type workerKey struct{}
+
+// WithWorker returns a context recording the pipeline worker generating
+// a trace, for generators that encode it.
+func WithWorker(ctx context.Context, worker int) context.Context {
+	return context.WithValue(ctx, workerKey{}, worker)
+}
+
+// Worker returns the worker recorded with WithWorker, or 0.
+func Worker(ctx context.Context) int {
+	worker, _ := ctx.Value(workerKey{}).(int)
+	return worker
+}
+
+func spanID(v uint64) oteltrace.SpanID {
+	if v == 0 {
+		v = 1
+	}
+	var id oteltrace.SpanID
+	binary.BigEndian.PutUint64(id[:], v)
+	return id
+}
+
+func splitmix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
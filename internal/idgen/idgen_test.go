@@ -0,0 +1,75 @@
+package idgen
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+func TestParseKind(t *testing.T) {
+	for value, want := range map[string]Kind{"": KindSeeded, "Seeded": KindSeeded, "random": KindRandom, "sequential": KindSequential, "prefix": KindPrefixed} {
+		got, err := ParseKind(value)
+		if err != nil || got != want {
+			t.Fatalf("ParseKind(%q) = %q, %v; want %q", value, got, err, want)
+		}
+	}
+	if _, err := ParseKind("uuid"); err == nil {
+		t.Fatalf("expected an error for an unknown generator")
+	}
+}
+
+func TestSeededIsReproducible(t *testing.T) {
+	ctx := context.Background()
+	if (Seeded{}).TraceID(ctx, 7, 1) != (Seeded{}).TraceID(ctx, 7, 1) {
+		t.Fatalf("expected the same trace ID for the same seed and sequence")
+	}
+	if (Seeded{}).TraceID(ctx, 7, 1) == (Seeded{}).TraceID(ctx, 7, 2) {
+		t.Fatalf("expected distinct trace IDs for distinct sequences")
+	}
+	if (Seeded{}).SpanID(7, 1, 1) == (Seeded{}).SpanID(7, 1, 2) {
+		t.Fatalf("expected distinct span IDs within a trace")
+	}
+}
+
+func TestSequentialLayout(t *testing.T) {
+	id := Sequential{}.TraceID(context.Background(), 0xabc, 5)
+	if binary.BigEndian.Uint64(id[0:8]) != 0xabc || binary.BigEndian.Uint64(id[8:16]) != 5 {
+		t.Fatalf("unexpected sequential trace ID %s", id)
+	}
+	span := Sequential{}.SpanID(0xabc, 5, 3)
+	if binary.BigEndian.Uint64(span[:]) != 5<<32|3 {
+		t.Fatalf("unexpected sequential span ID %s", span)
+	}
+	if !(Sequential{}).TraceID(context.Background(), 0, 0).IsValid() {
+		t.Fatalf("expected a valid trace ID for zero seed and sequence")
+	}
+}
+
+func TestPrefixedEncodesRunAndWorker(t *testing.T) {
+	ids := Prefixed{Run: 0xdeadbeef}
+	ctx := WithWorker(context.Background(), 3)
+
+	id := ids.TraceID(ctx, 7, 1)
+	if binary.BigEndian.Uint32(id[0:4]) != 0xdeadbeef || binary.BigEndian.Uint16(id[4:6]) != 3 {
+		t.Fatalf("expected run and worker prefix, got %s", id)
+	}
+	if other := ids.TraceID(ctx, 7, 2); other == id || [6]byte(other[:6]) != [6]byte(id[:6]) {
+		t.Fatalf("expected distinct trace IDs with the same prefix, got %s and %s", id, other)
+	}
+}
+
+func TestRandomIDsAreValid(t *testing.T) {
+	seen := map[oteltrace.TraceID]bool{}
+	for range 100 {
+		id := Random{}.TraceID(context.Background(), 0, 0)
+		if !id.IsValid() || seen[id] {
+			t.Fatalf("expected valid, distinct random trace IDs, got %s", id)
+		}
+		seen[id] = true
+		if !(Random{}).SpanID(0, 0, 0).IsValid() {
+			t.Fatalf("expected a valid random span ID")
+		}
+	}
+}
@@ -0,0 +1,168 @@
+// Package ingestcheck records the traces a run sent and, after the run,
+// reads each one back from the backend to report the traces it stored
+// only partially or not at all.
+package ingestcheck
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/metrics"
+	"github.com/javiermolinar/tercios/internal/model"
+)
+
+// maxSampleIDs bounds the partial and missing trace IDs kept for the
+// summary.
+const maxSampleIDs = 10
+
+type Config struct {
+	// MaxTraces caps the traces recorded; later traces are counted as
+	// unchecked, so long runs do not grow without bound.
+	MaxTraces int
+	// Timeout is how long after the run incomplete traces are queried
+	// again before they count as partial or missing.
+	Timeout time.Duration
+	// PollInterval is the delay between queries for one trace.
+	PollInterval time.Duration
+	// Concurrency caps the queries in flight.
+	Concurrency int
+}
+
+func DefaultConfig() Config {
+	return Config{
+		MaxTraces:    10000,
+		Timeout:      time.Minute,
+		PollInterval: 2 * time.Second,
+		Concurrency:  16,
+	}
+}
+
+func (c Config) Validate() error {
+	if c.MaxTraces <= 0 {
+		return fmt.Errorf("max traces must be > 0")
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("timeout must be > 0")
+	}
+	if c.PollInterval <= 0 {
+		return fmt.Errorf("poll interval must be > 0")
+	}
+	if c.Concurrency <= 0 {
+		return fmt.Errorf("concurrency must be > 0")
+	}
+	return nil
+}
+
+// SpanCounter reports how many spans of a trace a backend can return.
+// Every verify.VerificationBackend satisfies it.
+type SpanCounter interface {
+	CountSpans(ctx context.Context, traceID string) (int, error)
+}
+
+// Checker records the span count sent for every trace.
+type Checker struct {
+	config Config
+
+	mu        sync.Mutex
+	expected  map[string]int
+	order     []string
+	unchecked map[string]bool
+}
+
+func NewChecker(cfg Config) *Checker {
+	return &Checker{config: cfg, expected: map[string]int{}, unchecked: map[string]bool{}}
+}
+
+// Observe records the spans of batch, which was exported successfully.
+// Its signature matches pipeline.RunOptions.OnExported; it is safe for
+// concurrent use by export workers. A trace split across several batches
+// is expected with all of its spans.
+func (c *Checker) Observe(batch model.Batch, _ time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, span := range batch {
+		traceID := span.TraceID.String()
+		if _, ok := c.expected[traceID]; !ok {
+			if len(c.order) >= c.config.MaxTraces {
+				c.unchecked[traceID] = true
+				continue
+			}
+			c.order = append(c.order, traceID)
+		}
+		c.expected[traceID]++
+	}
+}
+
+// Check queries every recorded trace until the backend returns all of its
+// spans or the timeout passes, and returns how many traces were complete,
+// partial and missing.
+func (c *Checker) Check(ctx context.Context, counter SpanCounter) metrics.Ingestion {
+	c.mu.Lock()
+	order := append([]string(nil), c.order...)
+	expected := make([]int, len(order))
+	for i, traceID := range order {
+		expected[i] = c.expected[traceID]
+	}
+	result := metrics.Ingestion{Checked: len(order), Unchecked: len(c.unchecked)}
+	c.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+	found := make([]int, len(order))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(c.config.Concurrency, len(order)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				found[i] = c.poll(ctx, counter, order[i], expected[i])
+			}
+		}()
+	}
+	for i := range order {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	for i, traceID := range order {
+		switch {
+		case found[i] >= expected[i]:
+			result.Complete++
+		case found[i] > 0:
+			result.Partial++
+			if len(result.PartialTraceIDs) < maxSampleIDs {
+				result.PartialTraceIDs = append(result.PartialTraceIDs, traceID)
+			}
+		default:
+			result.Missing++
+			if len(result.MissingTraceIDs) < maxSampleIDs {
+				result.MissingTraceIDs = append(result.MissingTraceIDs, traceID)
+			}
+		}
+	}
+	return result
+}
+
+// poll returns the span count the backend last returned for traceID.
+func (c *Checker) poll(ctx context.Context, counter SpanCounter, traceID string, expected int) int {
+	found := 0
+	for {
+		// Errors are retried until the deadline: Tempo answers 404 for a
+		// trace it has not flushed yet.
+		if count, err := counter.CountSpans(ctx, traceID); err == nil {
+			found = max(found, count)
+			if found >= expected {
+				return found
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return found
+		case <-time.After(c.config.PollInterval):
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package ingestcheck
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/model"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// fakeBackend returns the stored span count of a trace once it was
+// queried readyAfter times, and an error before, as Tempo does for a trace
+// it has not flushed.
+type fakeBackend struct {
+	mu         sync.Mutex
+	readyAfter int
+	stored     map[string]int
+	calls      map[string]int
+}
+
+func (b *fakeBackend) CountSpans(_ context.Context, traceID string) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.calls[traceID]++
+	if b.calls[traceID] < b.readyAfter {
+		return 0, errors.New("trace not found")
+	}
+	return b.stored[traceID], nil
+}
+
+func traceBatch(traceID byte, spans int) model.Batch {
+	batch := make(model.Batch, spans)
+	for i := range batch {
+		batch[i].TraceID = oteltrace.TraceID{traceID}
+	}
+	return batch
+}
+
+func testConfig() Config {
+	return Config{MaxTraces: 3, Timeout: 200 * time.Millisecond, PollInterval: time.Millisecond, Concurrency: 2}
+}
+
+func TestCheckerReportsPartialAndMissingTraces(t *testing.T) {
+	checker := NewChecker(testConfig())
+	checker.Observe(traceBatch(1, 3), time.Now())
+	checker.Observe(traceBatch(2, 2), time.Now())
+	checker.Observe(traceBatch(1, 1), time.Now())
+	checker.Observe(append(traceBatch(3, 1), traceBatch(4, 5)...), time.Now())
+
+	id := func(b byte) string { return oteltrace.TraceID{b}.String() }
+	backend := &fakeBackend{
+		readyAfter: 3,
+		stored:     map[string]int{id(1): 4, id(2): 1},
+		calls:      map[string]int{},
+	}
+	result := checker.Check(context.Background(), backend)
+	if result.Checked != 3 || result.Complete != 1 || result.Partial != 1 || result.Missing != 1 || result.Unchecked != 1 {
+		t.Fatalf("result = %+v", result)
+	}
+	if len(result.PartialTraceIDs) != 1 || result.PartialTraceIDs[0] != id(2) || len(result.MissingTraceIDs) != 1 || result.MissingTraceIDs[0] != id(3) {
+		t.Fatalf("trace IDs = %v, %v", result.PartialTraceIDs, result.MissingTraceIDs)
+	}
+	if !result.Failed() {
+		t.Fatal("expected the check to fail")
+	}
+	if backend.calls[id(1)] != 3 {
+		t.Fatalf("complete trace queried %d times, want 3", backend.calls[id(1)])
+	}
+}
+
+func TestCheckerWithoutTraces(t *testing.T) {
+	result := NewChecker(testConfig()).Check(context.Background(), &fakeBackend{calls: map[string]int{}})
+	if result.Checked != 0 || result.Failed() {
+		t.Fatalf("result = %+v", result)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Fatalf("default config: %v", err)
+	}
+	cfg := DefaultConfig()
+	cfg.MaxTraces = 0
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected an error for max traces 0")
+	}
+}
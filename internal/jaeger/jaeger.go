@@ -0,0 +1,352 @@
+// Package jaeger reads trace exports in the JSON format of the Jaeger UI
+// and query API ({"data": [trace, ...]}) into model spans, so traces saved
+// from Jaeger can be replayed like OTLP recordings.
+package jaeger
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/model"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+type export struct {
+	Data []trace `json:"data"`
+}
+
+type trace struct {
+	TraceID   string             `json:"traceID"`
+	Spans     []span             `json:"spans"`
+	Processes map[string]process `json:"processes"`
+}
+
+type span struct {
+	TraceID       string      `json:"traceID"`
+	SpanID        string      `json:"spanID"`
+	ParentSpanID  string      `json:"parentSpanID"`
+	OperationName string      `json:"operationName"`
+	References    []reference `json:"references"`
+	StartTime     int64       `json:"startTime"`
+	Duration      int64       `json:"duration"`
+	Tags          []tag       `json:"tags"`
+	Logs          []logEntry  `json:"logs"`
+	ProcessID     string      `json:"processID"`
+	Process       *process    `json:"process"`
+}
+
+type reference struct {
+	RefType string `json:"refType"`
+	TraceID string `json:"traceID"`
+	SpanID  string `json:"spanID"`
+}
+
+type process struct {
+	ServiceName string `json:"serviceName"`
+	Tags        []tag  `json:"tags"`
+}
+
+type tag struct {
+	Key   string          `json:"key"`
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+type logEntry struct {
+	Timestamp int64 `json:"timestamp"`
+	Fields    []tag `json:"fields"`
+}
+
+// IsExport reports whether data, the start of a file, looks like a Jaeger
+// JSON export rather than OTLP JSON: an array of traces, or an object whose
+// first key is one Jaeger uses.
+func IsExport(data []byte) bool {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	token, err := decoder.Token()
+	if err != nil {
+		return false
+	}
+	switch token {
+	case json.Delim('['):
+		return true
+	case json.Delim('{'):
+		key, err := decoder.Token()
+		if err != nil {
+			return false
+		}
+		switch key {
+		case "data", "traceID", "spans", "processes":
+			return true
+		}
+	}
+	return false
+}
+
+// ReadFile reads the export at path, one batch per trace.
+func ReadFile(path string) ([]model.Batch, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+	batches, err := Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return batches, nil
+}
+
+// Decode reads a Jaeger export: the {"data": [...]} envelope of the UI's
+// "Download JSON" and the query API, a bare array of traces, or a single
+// trace. Each trace becomes one batch.
+func Decode(r io.Reader) ([]model.Batch, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	raw = bytes.TrimSpace(raw)
+	var traces []trace
+	switch {
+	case len(raw) > 0 && raw[0] == '[':
+		err = json.Unmarshal(raw, &traces)
+	default:
+		var envelope struct {
+			export
+			trace
+		}
+		err = json.Unmarshal(raw, &envelope)
+		traces = envelope.Data
+		if len(traces) == 0 && len(envelope.Spans) > 0 {
+			traces = []trace{envelope.trace}
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decode Jaeger JSON: %w", err)
+	}
+	batches := make([]model.Batch, 0, len(traces))
+	for i, t := range traces {
+		batch, err := t.toModel()
+		if err != nil {
+			return nil, fmt.Errorf("trace %d: %w", i, err)
+		}
+		if len(batch) > 0 {
+			batches = append(batches, batch)
+		}
+	}
+	return batches, nil
+}
+
+func (t trace) toModel() (model.Batch, error) {
+	resources := make(map[string]map[string]attribute.Value, len(t.Processes))
+	for id, p := range t.Processes {
+		resources[id] = p.resource()
+	}
+	batch := make(model.Batch, 0, len(t.Spans))
+	for _, s := range t.Spans {
+		resource, ok := resources[s.ProcessID]
+		if s.Process != nil {
+			resource, ok = s.Process.resource(), true
+		}
+		if !ok {
+			resource = map[string]attribute.Value{}
+		}
+		converted, err := s.toModel(t.TraceID, resource)
+		if err != nil {
+			return nil, fmt.Errorf("span %q: %w", s.SpanID, err)
+		}
+		batch = append(batch, converted)
+	}
+	return batch, nil
+}
+
+func (p process) resource() map[string]attribute.Value {
+	out := make(map[string]attribute.Value, len(p.Tags)+1)
+	for _, t := range p.Tags {
+		out[t.Key] = t.attributeValue()
+	}
+	if p.ServiceName != "" {
+		out["service.name"] = attribute.StringValue(p.ServiceName)
+	}
+	return out
+}
+
+func (s span) toModel(fallbackTraceID string, resource map[string]attribute.Value) (model.Span, error) {
+	traceIDHex := s.TraceID
+	if traceIDHex == "" {
+		traceIDHex = fallbackTraceID
+	}
+	traceID, err := parseTraceID(traceIDHex)
+	if err != nil {
+		return model.Span{}, err
+	}
+	spanID, err := parseSpanID(s.SpanID)
+	if err != nil {
+		return model.Span{}, err
+	}
+	start := time.UnixMicro(s.StartTime).UTC()
+	out := model.Span{
+		TraceID:            traceID,
+		SpanID:             spanID,
+		Name:               s.OperationName,
+		StartTime:          start,
+		EndTime:            start.Add(time.Duration(s.Duration) * time.Microsecond),
+		Attributes:         make(map[string]attribute.Value, len(s.Tags)),
+		ResourceAttributes: resource,
+	}
+	if s.ParentSpanID != "" && strings.Trim(s.ParentSpanID, "0") != "" {
+		if out.ParentSpanID, err = parseSpanID(s.ParentSpanID); err != nil {
+			return model.Span{}, err
+		}
+	}
+
+	// The first CHILD_OF reference in the same trace is the parent, as
+	// Jaeger itself reads it; every other reference becomes a link.
+	for _, ref := range s.References {
+		refTraceID, err := parseTraceID(ref.TraceID)
+		if err != nil {
+			return model.Span{}, err
+		}
+		refSpanID, err := parseSpanID(ref.SpanID)
+		if err != nil {
+			return model.Span{}, err
+		}
+		if ref.RefType == "CHILD_OF" && refTraceID == traceID && !out.ParentSpanID.IsValid() {
+			out.ParentSpanID = refSpanID
+			continue
+		}
+		if refTraceID == traceID && refSpanID == out.ParentSpanID {
+			continue
+		}
+		out.Links = append(out.Links, model.Link{
+			SpanContext: oteltrace.NewSpanContext(oteltrace.SpanContextConfig{TraceID: refTraceID, SpanID: refSpanID}),
+		})
+	}
+
+	// Jaeger keeps span kind and status as tags; they move to the span's
+	// own fields.
+	failed := false
+	for _, t := range s.Tags {
+		switch t.Key {
+		case "span.kind":
+			out.Kind = spanKind(t.stringValue())
+		case "error":
+			failed = failed || t.attributeValue().Emit() == "true"
+		case "otel.status_code":
+			switch t.stringValue() {
+			case "ERROR":
+				failed = true
+			case "OK":
+				out.StatusCode = codes.Ok
+			}
+		case "otel.status_description":
+			out.StatusDescription = t.stringValue()
+		default:
+			out.Attributes[t.Key] = t.attributeValue()
+		}
+	}
+	if failed {
+		out.StatusCode = codes.Error
+	}
+
+	for _, l := range s.Logs {
+		event := model.Event{Name: "log", Time: time.UnixMicro(l.Timestamp).UTC()}
+		for _, field := range l.Fields {
+			if field.Key == "event" {
+				event.Name = field.stringValue()
+				continue
+			}
+			event.Attributes = append(event.Attributes, attribute.KeyValue{Key: attribute.Key(field.Key), Value: field.attributeValue()})
+		}
+		out.Events = append(out.Events, event)
+	}
+	return out, nil
+}
+
+func spanKind(value string) oteltrace.SpanKind {
+	switch strings.ToLower(value) {
+	case "server":
+		return oteltrace.SpanKindServer
+	case "client":
+		return oteltrace.SpanKindClient
+	case "producer":
+		return oteltrace.SpanKindProducer
+	case "consumer":
+		return oteltrace.SpanKindConsumer
+	case "internal":
+		return oteltrace.SpanKindInternal
+	default:
+		return oteltrace.SpanKindUnspecified
+	}
+}
+
+func (t tag) stringValue() string {
+	var s string
+	if err := json.Unmarshal(t.Value, &s); err == nil {
+		return s
+	}
+	return string(t.Value)
+}
+
+// attributeValue converts a tag by its declared type. Values that do not
+// match their type are kept as strings rather than dropped; binary values
+// are kept in their base64 form.
+func (t tag) attributeValue() attribute.Value {
+	switch strings.ToLower(t.Type) {
+	case "bool":
+		var b bool
+		if err := json.Unmarshal(t.Value, &b); err == nil {
+			return attribute.BoolValue(b)
+		}
+		if parsed, err := strconv.ParseBool(t.stringValue()); err == nil {
+			return attribute.BoolValue(parsed)
+		}
+	case "int64":
+		// Large values are often quoted to survive JavaScript clients.
+		if parsed, err := strconv.ParseInt(strings.Trim(string(t.Value), `"`), 10, 64); err == nil {
+			return attribute.Int64Value(parsed)
+		}
+	case "float64":
+		if parsed, err := strconv.ParseFloat(strings.Trim(string(t.Value), `"`), 64); err == nil {
+			return attribute.Float64Value(parsed)
+		}
+	}
+	return attribute.StringValue(t.stringValue())
+}
+
+// parseTraceID accepts Jaeger's 64-bit trace IDs (16 hex characters or
+// fewer, leading zeros dropped) as well as 128-bit ones.
+func parseTraceID(value string) (oteltrace.TraceID, error) {
+	var id oteltrace.TraceID
+	decoded, err := decodeHexID(value, len(id))
+	if err != nil {
+		return id, fmt.Errorf("invalid trace ID %q: %w", value, err)
+	}
+	copy(id[:], decoded)
+	return id, nil
+}
+
+func parseSpanID(value string) (oteltrace.SpanID, error) {
+	var id oteltrace.SpanID
+	decoded, err := decodeHexID(value, len(id))
+	if err != nil {
+		return id, fmt.Errorf("invalid span ID %q: %w", value, err)
+	}
+	copy(id[:], decoded)
+	return id, nil
+}
+
+func decodeHexID(value string, size int) ([]byte, error) {
+	if len(value) > size*2 {
+		return nil, fmt.Errorf("longer than %d bytes", size)
+	}
+	return hex.DecodeString(strings.Repeat("0", size*2-len(value)) + value)
+}
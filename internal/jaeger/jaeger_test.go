@@ -0,0 +1,138 @@
+package jaeger
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+const uiExport = `{
+  "data": [{
+    "traceID": "5af7183fb1d4cf5f",
+    "spans": [
+      {
+        "traceID": "5af7183fb1d4cf5f",
+        "spanID": "5af7183fb1d4cf5f",
+        "operationName": "HTTP GET /dispatch",
+        "references": [],
+        "startTime": 1700000000000000,
+        "duration": 700000,
+        "tags": [
+          {"key": "span.kind", "type": "string", "value": "server"},
+          {"key": "http.status_code", "type": "int64", "value": 200},
+          {"key": "sampler.param", "type": "bool", "value": true}
+        ],
+        "logs": [
+          {"timestamp": 1700000000100000, "fields": [
+            {"key": "event", "type": "string", "value": "Getting customer"},
+            {"key": "customer_id", "type": "string", "value": "123"}
+          ]}
+        ],
+        "processID": "p1"
+      },
+      {
+        "traceID": "5af7183fb1d4cf5f",
+        "spanID": "2e2c5e7b0c6d1a3f",
+        "operationName": "SQL SELECT",
+        "references": [
+          {"refType": "CHILD_OF", "traceID": "5af7183fb1d4cf5f", "spanID": "5af7183fb1d4cf5f"},
+          {"refType": "FOLLOWS_FROM", "traceID": "0000000000000000000000000000abcd", "spanID": "00000000000000ef"}
+        ],
+        "startTime": 1700000000200000,
+        "duration": 300000,
+        "tags": [
+          {"key": "span.kind", "type": "string", "value": "client"},
+          {"key": "error", "type": "bool", "value": true},
+          {"key": "db.rows", "type": "float64", "value": 2.5}
+        ],
+        "processID": "p2"
+      }
+    ],
+    "processes": {
+      "p1": {"serviceName": "frontend", "tags": [{"key": "hostname", "type": "string", "value": "web-1"}]},
+      "p2": {"serviceName": "mysql", "tags": []}
+    }
+  }],
+  "total": 0, "limit": 0, "offset": 0, "errors": null
+}`
+
+func TestDecodeUIExport(t *testing.T) {
+	batches, err := Decode(strings.NewReader(uiExport))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if len(batches) != 1 || len(batches[0]) != 2 {
+		t.Fatalf("expected one trace of 2 spans, got %+v", batches)
+	}
+	root, child := batches[0][0], batches[0][1]
+
+	wantTrace := oteltrace.TraceID{8: 0x5a, 9: 0xf7, 10: 0x18, 11: 0x3f, 12: 0xb1, 13: 0xd4, 14: 0xcf, 15: 0x5f}
+	if root.TraceID != wantTrace || child.TraceID != wantTrace {
+		t.Fatalf("expected the 64-bit trace ID zero-padded, got %s and %s", root.TraceID, child.TraceID)
+	}
+	if root.ParentSpanID.IsValid() || child.ParentSpanID != root.SpanID {
+		t.Fatalf("unexpected parents: root %s, child %s (root span %s)", root.ParentSpanID, child.ParentSpanID, root.SpanID)
+	}
+	if root.Kind != oteltrace.SpanKindServer || child.Kind != oteltrace.SpanKindClient {
+		t.Fatalf("unexpected kinds %v and %v", root.Kind, child.Kind)
+	}
+	if _, ok := root.Attributes["span.kind"]; ok {
+		t.Fatalf("span.kind should move to the span kind, got attributes %v", root.Attributes)
+	}
+	if root.Attributes["http.status_code"].AsInt64() != 200 || !root.Attributes["sampler.param"].AsBool() || child.Attributes["db.rows"].AsFloat64() != 2.5 {
+		t.Fatalf("tags not converted by type: %v, %v", root.Attributes, child.Attributes)
+	}
+	if child.StatusCode != codes.Error || root.StatusCode != codes.Unset {
+		t.Fatalf("unexpected statuses %v and %v", root.StatusCode, child.StatusCode)
+	}
+	if want := time.UnixMicro(1700000000000000).UTC(); !root.StartTime.Equal(want) || root.EndTime.Sub(root.StartTime) != 700*time.Millisecond {
+		t.Fatalf("unexpected timing %s - %s", root.StartTime, root.EndTime)
+	}
+	if root.ResourceAttributes["service.name"].AsString() != "frontend" || root.ResourceAttributes["hostname"].AsString() != "web-1" || child.ResourceAttributes["service.name"].AsString() != "mysql" {
+		t.Fatalf("unexpected resources %v and %v", root.ResourceAttributes, child.ResourceAttributes)
+	}
+	if len(root.Events) != 1 || root.Events[0].Name != "Getting customer" || len(root.Events[0].Attributes) != 1 {
+		t.Fatalf("unexpected events %+v", root.Events)
+	}
+	if len(child.Links) != 1 || child.Links[0].SpanContext.SpanID() != (oteltrace.SpanID{7: 0xef}) {
+		t.Fatalf("expected the FOLLOWS_FROM reference as a link, got %+v", child.Links)
+	}
+}
+
+func TestDecodeBareTraces(t *testing.T) {
+	single := `{"traceID": "01", "spans": [{"spanID": "02", "operationName": "op", "startTime": 1, "duration": 1}]}`
+	for name, input := range map[string]string{
+		"single trace": single,
+		"array":        "[" + single + "," + single + "]",
+	} {
+		batches, err := Decode(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("%s: Decode() error = %v", name, err)
+		}
+		if len(batches) == 0 || batches[0][0].TraceID != (oteltrace.TraceID{15: 0x01}) {
+			t.Fatalf("%s: unexpected batches %+v", name, batches)
+		}
+	}
+	if _, err := Decode(strings.NewReader(`{"data": [{"spans": [{"spanID": "zz"}]}]}`)); err == nil {
+		t.Fatalf("expected error for an invalid span ID")
+	}
+}
+
+func TestIsExport(t *testing.T) {
+	tests := map[string]bool{
+		`{"data": [`:           true,
+		`  [{"traceID": "1"`:   true,
+		`{"traceID": "1"`:      true,
+		`{"resourceSpans": [{`: false,
+		`not json`:             false,
+		``:                     false,
+	}
+	for input, want := range tests {
+		if got := IsExport([]byte(input)); got != want {
+			t.Fatalf("IsExport(%q) = %v, want %v", input, got, want)
+		}
+	}
+}
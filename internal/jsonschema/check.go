@@ -0,0 +1,112 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/javiermolinar/tercios/internal/validation"
+)
+
+// Check reports every place where the JSON document data does not match
+// s, each under its JSON path: unknown fields, values of the wrong type
+// and strings outside an enum. It returns nil for a matching document.
+func (s *Schema) Check(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var document any
+	if err := decoder.Decode(&document); err != nil {
+		return err
+	}
+	var errs validation.Errors
+	s.check(&errs, s, "", document)
+	return errs.Err()
+}
+
+// DecodeError explains err, returned by a strict decoder for data, with
+// the problems Check finds. The decoder stops at the first problem and
+// does not say where an unknown field is, so the schema reports them all
+// with their paths instead. err is returned as-is when the schema finds
+// nothing, e.g. for a value that only the type's own UnmarshalJSON
+// rejects.
+func (s *Schema) DecodeError(data []byte, err error) error {
+	var problems validation.List
+	if errors.As(s.Check(data), &problems) {
+		return problems
+	}
+	return err
+}
+
+func (s *Schema) check(errs *validation.Errors, root *Schema, path string, value any) {
+	if s.Ref != "" {
+		name := strings.TrimPrefix(s.Ref, "#/$defs/")
+		s = root.Defs[name]
+	}
+	if value == nil {
+		// encoding/json leaves the field unset on null.
+		return
+	}
+	if got := typeOf(value); len(s.Type) > 0 && !s.allows(got) {
+		errs.Addf(path, "expected %s, got %s", strings.Join(s.Type, " or "), got)
+		return
+	}
+	switch value := value.(type) {
+	case string:
+		if len(s.Enum) > 0 && !slices.Contains(s.Enum, value) {
+			errs.Addf(path, "%q is not one of %s", value, strings.Join(s.Enum, ", "))
+		}
+	case []any:
+		if s.Items != nil {
+			for i, item := range value {
+				s.Items.check(errs, root, validation.Index(path, i), item)
+			}
+		}
+	case map[string]any:
+		for _, key := range slices.Sorted(maps.Keys(value)) {
+			if property, ok := s.Properties[key]; ok {
+				property.check(errs, root, validation.Field(path, key), value[key])
+				continue
+			}
+			switch additional := s.AdditionalProperties.(type) {
+			case bool:
+				if !additional {
+					errs.Addf(path, "unknown field %q", key)
+				}
+			case *Schema:
+				additional.check(errs, root, validation.Key(path, key), value[key])
+			}
+		}
+	}
+}
+
+// allows reports whether s accepts values of JSON type typ. Integers are
+// numbers too.
+func (s *Schema) allows(typ string) bool {
+	return slices.Contains(s.Type, typ) || typ == "integer" && slices.Contains(s.Type, "number")
+}
+
+// typeOf returns the JSON Schema type of a value decoded with UseNumber.
+func typeOf(value any) string {
+	switch value := value.(type) {
+	case bool:
+		return "boolean"
+	case json.Number:
+		if _, err := strconv.ParseInt(value.String(), 10, 64); err == nil {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
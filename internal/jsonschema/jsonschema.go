@@ -0,0 +1,152 @@
+// Package jsonschema derives JSON Schemas from the JSON-tagged config
+// types of tercios, so editors can complete and check config, chaos and
+// scenario files as they are written, and checks decoded documents against
+// them to point at the exact field a strict decoder rejected.
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// Draft is the JSON Schema dialect of generated schemas.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema document or subschema. Only the keywords tercios
+// needs are modeled.
+type Schema struct {
+	Draft       string             `json:"$schema,omitempty"`
+	Ref         string             `json:"$ref,omitempty"`
+	Title       string             `json:"title,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Type        Types              `json:"type,omitempty"`
+	Enum        []string           `json:"enum,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	// AdditionalProperties is false for objects with a fixed set of
+	// fields, and the schema of every value for maps.
+	AdditionalProperties any                `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// Types is the type keyword: one JSON type, or several a value may take.
+type Types []string
+
+func (t Types) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// Describer is implemented by types whose JSON form is not their Go
+// fields, such as types with a custom UnmarshalJSON or a fixed set of
+// string values.
+type Describer interface {
+	JSONSchema() *Schema
+}
+
+var describerType = reflect.TypeFor[Describer]()
+
+// Generate returns the schema of the JSON form of v, titled title. Named
+// struct types are placed under $defs and referenced, so the schema stays
+// readable when a type such as a typed value is used in many places.
+func Generate(v any, title string) *Schema {
+	g := generator{defs: map[string]*Schema{}}
+	t := reflect.TypeOf(v)
+	schema := g.object(t)
+	schema.Draft = Draft
+	schema.Title = title
+	if len(g.defs) > 0 {
+		schema.Defs = g.defs
+	}
+	return schema
+}
+
+type generator struct {
+	defs map[string]*Schema
+}
+
+func (g *generator) schema(t reflect.Type) *Schema {
+	if t.Implements(describerType) {
+		return reflect.Zero(t).Interface().(Describer).JSONSchema()
+	}
+	if reflect.PointerTo(t).Implements(describerType) {
+		return reflect.New(t).Interface().(Describer).JSONSchema()
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.schema(t.Elem())
+	case reflect.Bool:
+		return &Schema{Type: Types{"boolean"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: Types{"integer"}}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: Types{"number"}}
+	case reflect.String:
+		return &Schema{Type: Types{"string"}}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: Types{"array"}, Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: Types{"object"}, AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		if _, ok := g.defs[t.Name()]; !ok {
+			// Reserve the name first, in case the type refers to itself.
+			g.defs[t.Name()] = nil
+			g.defs[t.Name()] = g.object(t)
+		}
+		return &Schema{Ref: "#/$defs/" + t.Name()}
+	default:
+		// Interfaces and anything else accept any value.
+		return &Schema{}
+	}
+}
+
+// object returns the schema of struct type t, with the fields of embedded
+// structs inlined as encoding/json does.
+func (g *generator) object(t reflect.Type) *Schema {
+	schema := &Schema{Type: Types{"object"}, Properties: map[string]*Schema{}, AdditionalProperties: false}
+	g.fields(t, schema.Properties)
+	return schema
+}
+
+func (g *generator) fields(t reflect.Type, properties map[string]*Schema) {
+	for field := range t.Fields() {
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.fields(embedded, properties)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = g.schema(field.Type)
+	}
+}
+
+// Enum returns the schema of a string that takes one of values.
+func Enum[T ~string](values ...T) *Schema {
+	enum := make([]string, len(values))
+	for i, value := range values {
+		enum[i] = string(value)
+	}
+	return &Schema{Type: Types{"string"}, Enum: enum}
+}
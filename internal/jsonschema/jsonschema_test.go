@@ -0,0 +1,103 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type level string
+
+func (level) JSONSchema() *Schema {
+	return Enum[level]("low", "high")
+}
+
+type typed struct {
+	Type  string `json:"type"`
+	Value any    `json:"value"`
+}
+
+type matcher struct {
+	typed
+	Op string `json:"op,omitempty"`
+}
+
+type document struct {
+	Name     string             `json:"name"`
+	Count    int                `json:"count,omitempty"`
+	Ratio    *float64           `json:"ratio,omitempty"`
+	Level    level              `json:"level"`
+	Tags     []string           `json:"tags"`
+	Values   map[string]typed   `json:"values"`
+	Matchers map[string]matcher `json:"matchers"`
+	Ignored  string             `json:"-"`
+}
+
+func TestGenerate(t *testing.T) {
+	schema := Generate(document{}, "test document")
+	data, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	for _, want := range []string{
+		`"$schema":"` + Draft + `"`,
+		`"title":"test document"`,
+		`"count":{"type":"integer"}`,
+		`"ratio":{"type":"number"}`,
+		`"level":{"type":"string","enum":["low","high"]}`,
+		`"tags":{"type":"array","items":{"type":"string"}}`,
+		`"values":{"type":"object","additionalProperties":{"$ref":"#/$defs/typed"}}`,
+		// The embedded typed fields are inlined.
+		`"matcher":{"type":"object","properties":{"op":{"type":"string"},"type":{"type":"string"},"value":{}},"additionalProperties":false}`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("schema %s does not contain %s", data, want)
+		}
+	}
+	if _, ok := schema.Properties["Ignored"]; ok {
+		t.Fatalf("expected fields tagged json:\"-\" to be left out")
+	}
+}
+
+func TestCheckReportsEveryProblemWithItsPath(t *testing.T) {
+	schema := Generate(document{}, "test document")
+	err := schema.Check([]byte(`{
+  "name": "a",
+  "count": 1.5,
+  "ratio": 2,
+  "level": "medium",
+  "tags": ["x", 1],
+  "values": {"k": {"type": "int", "value": 3, "size": 1}},
+  "matchers": {"m": {"type": "string", "value": "v", "op": null}},
+  "extra": true
+}`))
+	if err == nil {
+		t.Fatalf("expected problems, got nil")
+	}
+	for _, line := range []string{
+		`unknown field "extra"`,
+		`count: expected integer, got number`,
+		`level: "medium" is not one of low, high`,
+		`tags[1]: expected string, got integer`,
+		`values["k"]: unknown field "size"`,
+	} {
+		if !strings.Contains(err.Error(), line) {
+			t.Fatalf("Check() error = %v, want it to contain %q", err, line)
+		}
+	}
+	if strings.Contains(err.Error(), "ratio") || strings.Contains(err.Error(), "matchers") {
+		t.Fatalf("Check() error = %v, want integers accepted as numbers and null accepted anywhere", err)
+	}
+
+	if err := schema.Check([]byte(`{"name": "a", "tags": [], "values": {}}`)); err != nil {
+		t.Fatalf("Check() error = %v for a valid document", err)
+	}
+}
+
+func TestDecodeErrorKeepsErrorsTheSchemaCannotExplain(t *testing.T) {
+	schema := Generate(document{}, "test document")
+	original := json.Unmarshal([]byte(`{"name": "a"} trailing`), &document{})
+	if err := schema.DecodeError([]byte(`{"name": "a"}`), original); err != original {
+		t.Fatalf("DecodeError() = %v, want the decoder error", err)
+	}
+}
@@ -0,0 +1,83 @@
+package loadprofile
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// integrationStep is the resolution used to integrate the rate curve
+	// when the next request is more than one step away.
+	integrationStep = 10 * time.Millisecond
+	// searchWindow bounds how far ahead a single reservation looks, so a
+	// profile sitting at zero keeps checking for cancellation.
+	searchWindow = time.Minute
+)
+
+// Pacer hands out request slots following a Profile. It is shared by all
+// workers of a run, so the profile shapes the total rate rather than each
+// worker's. A worker that falls behind is not allowed to burst: slots are
+// never scheduled in the past.
+type Pacer struct {
+	profile Profile
+	start   time.Time
+
+	mu   sync.Mutex
+	next time.Duration
+	// owed is the part of a request still to be integrated when the last
+	// reservation ran out of search window; zero means a whole request.
+	owed float64
+}
+
+func NewPacer(profile Profile, start time.Time) *Pacer {
+	return &Pacer{profile: profile, start: start}
+}
+
+// Wait blocks until the caller's next request slot. It returns ctx.Err()
+// when ctx ends first.
+func (p *Pacer) Wait(ctx context.Context) error {
+	for {
+		slot, ok := p.reserve(time.Since(p.start))
+		timer := time.NewTimer(time.Until(p.start.Add(slot)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		if ok {
+			return nil
+		}
+	}
+}
+
+// reserve returns the offset from start of the next slot: the point where
+// the integral of the rate since the previous slot reaches one request. ok
+// is false when the search window passed without reaching it.
+func (p *Pacer) reserve(now time.Duration) (time.Duration, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.next < now {
+		p.next = now
+	}
+	t := p.next
+	need := p.owed
+	if need <= 0 {
+		need = 1
+	}
+	for limit := p.next + searchWindow; t < limit; t += integrationStep {
+		rate := p.profile.Rate(t)
+		if rate > 0 && need/rate <= integrationStep.Seconds() {
+			t += time.Duration(need / rate * float64(time.Second))
+			p.next = t
+			p.owed = 0
+			return t, true
+		}
+		need -= rate * integrationStep.Seconds()
+	}
+	p.next = t
+	p.owed = need
+	return t, false
+}
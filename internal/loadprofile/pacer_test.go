@@ -0,0 +1,80 @@
+package loadprofile
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPacerReserveFollowsProfile(t *testing.T) {
+	profile, err := Parse("step:10,100/1s")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	pacer := NewPacer(profile, time.Now())
+
+	// 10 req/s for the first second, then 100 req/s: 10 + 100 slots by 2s.
+	count := 0
+	for {
+		slot, ok := pacer.reserve(0)
+		if !ok {
+			t.Fatalf("unexpected empty reservation at %s", slot)
+		}
+		if slot > 2*time.Second {
+			break
+		}
+		count++
+	}
+	if count < 109 || count > 111 {
+		t.Fatalf("expected about 110 slots in 2s, got %d", count)
+	}
+}
+
+func TestPacerReserveIntegratesLowRates(t *testing.T) {
+	// The rate starts at zero, so the first slot is where the ramp's
+	// integral reaches one request: 0.5*t*(t/10) = 1 => t = sqrt(20)s.
+	profile, err := Parse("ramp:0-1/10s")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	pacer := NewPacer(profile, time.Now())
+	slot, ok := pacer.reserve(0)
+	if !ok {
+		t.Fatalf("expected a slot within the search window")
+	}
+	if slot < 4400*time.Millisecond || slot > 4550*time.Millisecond {
+		t.Fatalf("expected first slot near 4.47s, got %s", slot)
+	}
+}
+
+func TestPacerReserveCarriesOwedRequestAcrossWindows(t *testing.T) {
+	// One request every 90s is longer than the search window.
+	profile, err := Parse("step:0.0111111111/1h")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	pacer := NewPacer(profile, time.Now())
+	if _, ok := pacer.reserve(0); ok {
+		t.Fatalf("expected first reservation to exhaust the search window")
+	}
+	slot, ok := pacer.reserve(0)
+	if !ok {
+		t.Fatalf("expected second reservation to complete the request")
+	}
+	if slot < 89*time.Second || slot > 91*time.Second {
+		t.Fatalf("expected slot near 90s, got %s", slot)
+	}
+}
+
+func TestPacerWaitStopsOnCancel(t *testing.T) {
+	profile, err := Parse("step:0.001/1h")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	pacer := NewPacer(profile, time.Now())
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := pacer.Wait(ctx); err == nil {
+		t.Fatalf("expected Wait to return the context error")
+	}
+}
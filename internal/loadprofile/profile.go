@@ -0,0 +1,169 @@
+// Package loadprofile modulates the total request rate of a run over time
+// (linear ramp, steps, periodic spikes, sine wave), so autoscaling of an
+// ingest path can be exercised with a single command.
+package loadprofile
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Pattern string
+
+const (
+	// PatternRamp moves linearly from From to To over Period, then holds To.
+	PatternRamp Pattern = "ramp"
+	// PatternStep holds each of Steps for Period, then holds the last one.
+	PatternStep Pattern = "step"
+	// PatternSpike sends From, jumping to To for the last Spike of every
+	// Period.
+	PatternSpike Pattern = "spike"
+	// PatternSine oscillates between From and To with the given Period,
+	// starting at From.
+	PatternSine Pattern = "sine"
+)
+
+// Profile is a parsed load profile. Rates are total requests per second
+// across all exporters.
+type Profile struct {
+	Pattern Pattern
+	From    float64
+	To      float64
+	Steps   []float64
+	Period  time.Duration
+	Spike   time.Duration
+}
+
+// Parse reads a profile spec:
+//
+//	ramp:FROM-TO/DURATION        e.g. ramp:0-5000/5m
+//	step:R1,R2,...,RN/DURATION   e.g. step:100,500,1000/1m
+//	spike:BASE-PEAK/PERIOD@SPIKE e.g. spike:100-5000/1m@10s
+//	sine:MIN-MAX/PERIOD          e.g. sine:100-1000/2m
+func Parse(spec string) (Profile, error) {
+	name, rest, ok := strings.Cut(strings.TrimSpace(spec), ":")
+	if !ok {
+		return Profile{}, fmt.Errorf("invalid load profile %q: expected PATTERN:RATES/DURATION", spec)
+	}
+	rates, timing, ok := strings.Cut(rest, "/")
+	if !ok {
+		return Profile{}, fmt.Errorf("invalid load profile %q: missing /DURATION", spec)
+	}
+
+	profile := Profile{Pattern: Pattern(strings.ToLower(strings.TrimSpace(name)))}
+	if profile.Pattern == PatternSpike {
+		period, spike, ok := strings.Cut(timing, "@")
+		if !ok {
+			return Profile{}, fmt.Errorf("invalid load profile %q: spike needs PERIOD@SPIKE", spec)
+		}
+		timing = period
+		duration, err := time.ParseDuration(strings.TrimSpace(spike))
+		if err != nil {
+			return Profile{}, fmt.Errorf("invalid load profile %q: spike duration: %w", spec, err)
+		}
+		profile.Spike = duration
+	}
+	period, err := time.ParseDuration(strings.TrimSpace(timing))
+	if err != nil {
+		return Profile{}, fmt.Errorf("invalid load profile %q: duration: %w", spec, err)
+	}
+	profile.Period = period
+
+	switch profile.Pattern {
+	case PatternStep:
+		for _, field := range strings.Split(rates, ",") {
+			rate, err := parseRate(field)
+			if err != nil {
+				return Profile{}, fmt.Errorf("invalid load profile %q: %w", spec, err)
+			}
+			profile.Steps = append(profile.Steps, rate)
+		}
+	case PatternRamp, PatternSpike, PatternSine:
+		from, to, ok := strings.Cut(rates, "-")
+		if !ok {
+			return Profile{}, fmt.Errorf("invalid load profile %q: expected FROM-TO rates", spec)
+		}
+		if profile.From, err = parseRate(from); err != nil {
+			return Profile{}, fmt.Errorf("invalid load profile %q: %w", spec, err)
+		}
+		if profile.To, err = parseRate(to); err != nil {
+			return Profile{}, fmt.Errorf("invalid load profile %q: %w", spec, err)
+		}
+	default:
+		return Profile{}, fmt.Errorf("unsupported load profile pattern %q (supported: %s, %s, %s, %s)", name, PatternRamp, PatternStep, PatternSpike, PatternSine)
+	}
+
+	if err := profile.Validate(); err != nil {
+		return Profile{}, fmt.Errorf("invalid load profile %q: %w", spec, err)
+	}
+	return profile, nil
+}
+
+// Constant returns a profile that holds rate requests per second.
+func Constant(rate float64) Profile {
+	return Profile{Pattern: PatternStep, Steps: []float64{rate}, Period: time.Second}
+}
+
+func parseRate(value string) (float64, error) {
+	rate, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid rate %q", value)
+	}
+	return rate, nil
+}
+
+func (p Profile) Validate() error {
+	if p.Period <= 0 {
+		return fmt.Errorf("duration must be > 0")
+	}
+	rates := p.Steps
+	if p.Pattern != PatternStep {
+		rates = []float64{p.From, p.To}
+	} else if len(rates) == 0 {
+		return fmt.Errorf("step needs at least one rate")
+	}
+	positive := false
+	for _, rate := range rates {
+		if rate < 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+			return fmt.Errorf("rates must be finite and >= 0")
+		}
+		positive = positive || rate > 0
+	}
+	if !positive {
+		return fmt.Errorf("at least one rate must be > 0")
+	}
+	if p.Pattern == PatternSpike && (p.Spike <= 0 || p.Spike >= p.Period) {
+		return fmt.Errorf("spike duration must be > 0 and shorter than the period")
+	}
+	return nil
+}
+
+// Rate returns the target requests per second at elapsed time into the run.
+func (p Profile) Rate(elapsed time.Duration) float64 {
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	switch p.Pattern {
+	case PatternRamp:
+		if elapsed >= p.Period {
+			return p.To
+		}
+		return p.From + (p.To-p.From)*float64(elapsed)/float64(p.Period)
+	case PatternStep:
+		index := int(elapsed / p.Period)
+		return p.Steps[min(index, len(p.Steps)-1)]
+	case PatternSpike:
+		if elapsed%p.Period >= p.Period-p.Spike {
+			return p.To
+		}
+		return p.From
+	case PatternSine:
+		phase := 2 * math.Pi * float64(elapsed%p.Period) / float64(p.Period)
+		return p.From + (p.To-p.From)*(1-math.Cos(phase))/2
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,90 @@
+package loadprofile
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []map[string]string{
+		{"spec": "ramp:0-5000/5m", "want": ""},
+		{"spec": "RAMP: 10 - 0.5 /30s", "want": ""},
+		{"spec": "step:100,500,1000/1m", "want": ""},
+		{"spec": "step:0,10/1m", "want": ""},
+		{"spec": "spike:100-5000/1m@10s", "want": ""},
+		{"spec": "sine:0-1000/2m", "want": ""},
+		{"spec": "ramp", "want": "expected PATTERN:RATES/DURATION"},
+		{"spec": "ramp:0-10", "want": "missing /DURATION"},
+		{"spec": "ramp:10/1m", "want": "expected FROM-TO"},
+		{"spec": "ramp:a-10/1m", "want": "invalid rate"},
+		{"spec": "ramp:0-10/soon", "want": "duration"},
+		{"spec": "ramp:0-10/0s", "want": "duration must be > 0"},
+		{"spec": "ramp:0-0/1m", "want": "at least one rate must be > 0"},
+		{"spec": "step:10,-1/1m", "want": "finite and >= 0"},
+		{"spec": "spike:1-10/1m", "want": "PERIOD@SPIKE"},
+		{"spec": "spike:1-10/1m@1m", "want": "shorter than the period"},
+		{"spec": "square:1-10/1m", "want": "unsupported load profile pattern"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt["spec"], func(t *testing.T) {
+			_, err := Parse(tt["spec"])
+			if tt["want"] == "" {
+				if err != nil {
+					t.Fatalf("Parse() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt["want"]) {
+				t.Fatalf("Parse() error = %v, want %q", err, tt["want"])
+			}
+		})
+	}
+}
+
+func TestProfileRate(t *testing.T) {
+	tests := []struct {
+		spec    string
+		elapsed time.Duration
+		want    float64
+	}{
+		{spec: "ramp:0-100/10s", elapsed: 0, want: 0},
+		{spec: "ramp:0-100/10s", elapsed: 5 * time.Second, want: 50},
+		{spec: "ramp:0-100/10s", elapsed: time.Minute, want: 100},
+		{spec: "ramp:100-0/10s", elapsed: 2 * time.Second, want: 80},
+		{spec: "step:10,20,30/1m", elapsed: 30 * time.Second, want: 10},
+		{spec: "step:10,20,30/1m", elapsed: 90 * time.Second, want: 20},
+		{spec: "step:10,20,30/1m", elapsed: time.Hour, want: 30},
+		{spec: "spike:10-500/1m@10s", elapsed: 49 * time.Second, want: 10},
+		{spec: "spike:10-500/1m@10s", elapsed: 55 * time.Second, want: 500},
+		{spec: "spike:10-500/1m@10s", elapsed: 65 * time.Second, want: 10},
+		{spec: "sine:0-100/1m", elapsed: 0, want: 0},
+		{spec: "sine:0-100/1m", elapsed: 15 * time.Second, want: 50},
+		{spec: "sine:0-100/1m", elapsed: 30 * time.Second, want: 100},
+		{spec: "sine:0-100/1m", elapsed: 60 * time.Second, want: 0},
+	}
+
+	for _, tt := range tests {
+		profile, err := Parse(tt.spec)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.spec, err)
+		}
+		if got := profile.Rate(tt.elapsed); math.Abs(got-tt.want) > 1e-9 {
+			t.Fatalf("%s at %s: Rate() = %v, want %v", tt.spec, tt.elapsed, got, tt.want)
+		}
+	}
+}
+
+func TestConstantHoldsRate(t *testing.T) {
+	profile := Constant(42)
+	if err := profile.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	for _, elapsed := range []time.Duration{0, time.Second, time.Hour} {
+		if got := profile.Rate(elapsed); got != 42 {
+			t.Fatalf("Rate(%s) = %v, want 42", elapsed, got)
+		}
+	}
+}
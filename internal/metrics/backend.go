@@ -0,0 +1,34 @@
+package metrics
+
+import "fmt"
+
+// BackendComparison compares the spans a run sent with the spans the
+// backend's ingest counter grew by over the same run.
+type BackendComparison struct {
+	Metric string
+	// Before and After are the counter values scraped around the run.
+	Before float64
+	After  float64
+	Sent   int
+}
+
+// Received is the counter increase. A counter that went down was reset
+// during the run, so After is the best available lower bound.
+func (c BackendComparison) Received() float64 {
+	if c.After < c.Before {
+		return c.After
+	}
+	return c.After - c.Before
+}
+
+// Missing is the number of sent spans the backend did not report. It is
+// negative when the backend counted more spans than this run sent, e.g.
+// because other clients were writing to it or retries were duplicated.
+func (c BackendComparison) Missing() float64 {
+	return float64(c.Sent) - c.Received()
+}
+
+func formatBackendComparison(c BackendComparison) string {
+	return fmt.Sprintf("Backend received spans: %s of %s sent (missing %s, %s)",
+		formatCount(int(c.Received())), formatCount(c.Sent), formatCount(int(c.Missing())), c.Metric)
+}
@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"maps"
+	"net"
+	"regexp"
+	"slices"
+	"strings"
+	"syscall"
+	"unicode"
+
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// httpStatusPattern finds the response status in the errors of the OTLP
+// HTTP exporter ("failed to send to URL: 400 Bad Request") and the Zipkin
+// exporter ("zipkin responded 400 Bad Request").
+var httpStatusPattern = regexp.MustCompile(`(?:failed to send to \S+:|responded) ([1-5][0-9][0-9]) `)
+
+// classifyError returns the failure class of an export error: timeout,
+// canceled, connection_refused, dns, tls, unauthenticated,
+// permission_denied or unavailable when the cause is clear from either
+// protocol, grpc_<code> for other gRPC status codes, http_<status> for
+// other HTTP responses, http_retryable for the 429/502/503/504 responses
+// whose status the OTLP HTTP exporter drops once its retries run out, and
+// other for the rest.
+func classifyError(err error) string {
+	if err == nil {
+		return "other"
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "timeout"
+	}
+	if errors.Is(err, context.Canceled) {
+		return "canceled"
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return "dns"
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return "connection_refused"
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return "timeout"
+	}
+
+	if st, ok := status.FromError(err); ok {
+		switch st.Code() {
+		case grpccodes.DeadlineExceeded:
+			return "timeout"
+		case grpccodes.Canceled:
+			return "canceled"
+		case grpccodes.Unavailable:
+			if strings.Contains(strings.ToLower(st.Message()), "connection refused") {
+				return "connection_refused"
+			}
+			return "unavailable"
+		case grpccodes.Unauthenticated:
+			return "unauthenticated"
+		case grpccodes.PermissionDenied:
+			return "permission_denied"
+		case grpccodes.Unknown:
+			// Errors without a gRPC status also end up here; their
+			// message says more.
+		default:
+			return "grpc_" + snakeCase(st.Code().String())
+		}
+	}
+
+	msg := strings.ToLower(err.Error())
+	if match := httpStatusPattern.FindStringSubmatch(msg); match != nil {
+		switch match[1] {
+		case "401":
+			return "unauthenticated"
+		case "403":
+			return "permission_denied"
+		case "408", "504":
+			return "timeout"
+		default:
+			return "http_" + match[1]
+		}
+	}
+	switch {
+	case strings.Contains(msg, "deadline exceeded"), strings.Contains(msg, "timed out"), strings.Contains(msg, "timeout"):
+		return "timeout"
+	case strings.Contains(msg, "connection refused"):
+		return "connection_refused"
+	case strings.Contains(msg, "no such host"), strings.Contains(msg, "name resolution"):
+		return "dns"
+	case strings.Contains(msg, "x509"), strings.Contains(msg, "tls"), strings.Contains(msg, "certificate"):
+		return "tls"
+	case strings.Contains(msg, "unauthenticated"), strings.Contains(msg, "unauthorized"):
+		return "unauthenticated"
+	case strings.Contains(msg, "permission denied"):
+		return "permission_denied"
+	case strings.Contains(msg, "retry-able request failure"):
+		return "http_retryable"
+	case strings.Contains(msg, "unavailable"):
+		return "unavailable"
+	default:
+		return "other"
+	}
+}
+
+// failureClassesByCount returns the classes of breakdown, most frequent
+// first and by name among equals.
+func failureClassesByCount(breakdown map[string]int) []string {
+	return slices.SortedFunc(maps.Keys(breakdown), func(a, b string) int {
+		return cmp.Or(cmp.Compare(breakdown[b], breakdown[a]), cmp.Compare(a, b))
+	})
+}
+
+// snakeCase turns a gRPC code name such as ResourceExhausted into
+// resource_exhausted.
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
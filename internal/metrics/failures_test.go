@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"deadline", fmt.Errorf("upload traces: %w", context.DeadlineExceeded), "timeout"},
+		{"canceled", context.Canceled, "canceled"},
+		{"refused", &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, "connection_refused"},
+		{"refused message", errors.New("dial tcp 127.0.0.1:4317: connect: connection refused"), "connection_refused"},
+		{"dns", &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "collector"}}, "dns"},
+		{"grpc unavailable", status.Error(grpccodes.Unavailable, "connection error"), "unavailable"},
+		{"grpc refused", status.Error(grpccodes.Unavailable, "dial tcp: connect: connection refused"), "connection_refused"},
+		{"grpc resource exhausted", status.Error(grpccodes.ResourceExhausted, "rate limited"), "grpc_resource_exhausted"},
+		{"grpc invalid argument", fmt.Errorf("upload traces protocol=grpc: %w", status.Error(grpccodes.InvalidArgument, "bad span")), "grpc_invalid_argument"},
+		{"http status", errors.New("upload traces protocol=http: failed to send to http://localhost:4318/v1/traces: 400 Bad Request (body: invalid)"), "http_400"},
+		{"http unauthenticated", errors.New("failed to send to http://localhost:4318/v1/traces: 401 Unauthorized (body: (empty))"), "unauthenticated"},
+		{"http gateway timeout", errors.New("failed to send to http://localhost:4318/v1/traces: 504 Gateway Timeout (body: (empty))"), "timeout"},
+		{"zipkin status", errors.New("zipkin responded 413 Request Entity Too Large: too big"), "http_413"},
+		{"http retryable", errors.New("max retry time elapsed: retry-able request failure: body: (empty)"), "http_retryable"},
+		{"other", errors.New("boom"), "other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyError(tt.err); got != tt.want {
+				t.Fatalf("classifyError(%q) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatSummaryOrdersFailureBreakdownByCount(t *testing.T) {
+	summary := Summary{
+		Total:    4,
+		Failures: 4,
+		FailureBreakdown: map[string]int{
+			"http_400": 1,
+			"timeout":  3,
+		},
+	}
+
+	formatted := FormatSummary(summary)
+	timeout := strings.Index(formatted, "- timeout: 3 (75.0%)")
+	badRequest := strings.Index(formatted, "- http_400: 1 (25.0%)")
+	if timeout < 0 || badRequest < 0 || timeout > badRequest {
+		t.Fatalf("expected the breakdown ordered by count with shares, got %q", formatted)
+	}
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/timefmt"
+)
+
+// Freshness is the ingest-to-queryable measurement of sampled traces: the
+// time from a successful export until the backend returned every span.
+type Freshness struct {
+	Probed    int
+	Queryable int
+	TimedOut  int
+	// Skipped traces were sampled while the probe concurrency cap was
+	// reached.
+	Skipped   int
+	Latencies []time.Duration
+}
+
+// Percentile returns the q-th quantile (0..1) of the queryable latencies,
+// or zero when no trace became queryable.
+func (f Freshness) Percentile(q float64) time.Duration {
+	if len(f.Latencies) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(f.Latencies)
+	slices.Sort(sorted)
+	return sorted[int(float64(len(sorted)-1)*q)]
+}
+
+func formatFreshness(f Freshness, format timefmt.Options) string {
+	line := fmt.Sprintf("Trace freshness: %s of %s probed traces queryable", formatCount(f.Queryable), formatCount(f.Probed))
+	if f.TimedOut > 0 {
+		line += fmt.Sprintf(", %s timed out", formatCount(f.TimedOut))
+	}
+	if f.Skipped > 0 {
+		line += fmt.Sprintf(", %s skipped", formatCount(f.Skipped))
+	}
+	if f.Queryable > 0 {
+		line += fmt.Sprintf(" (p50 %s, p95 %s, p99 %s, max %s)",
+			formatLatency(f.Percentile(0.5), format),
+			formatLatency(f.Percentile(0.95), format),
+			formatLatency(f.Percentile(0.99), format),
+			formatLatency(f.Percentile(1), format))
+	}
+	return line
+}
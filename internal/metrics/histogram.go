@@ -0,0 +1,171 @@
+package metrics
+
+import (
+	"math/bits"
+	"slices"
+	"time"
+)
+
+// exactDurationLimit is the number of durations a durationHistogram keeps
+// as they are. Percentiles of shorter runs are exact; beyond it the
+// durations move into log-linear buckets, so long runs use bounded memory.
+const exactDurationLimit = 10000
+
+// subBucketBits sets the precision of the buckets: every power of two is
+// split into 2^(subBucketBits-1) buckets, so a bucket is less than 0.8%
+// wide relative to its values, and its midpoint within 0.4% of them.
+const subBucketBits = 8
+
+// durationHistogram records durations in the manner of an HDR histogram:
+// count, sum, min and max are exact, and percentiles come from buckets
+// whose width grows with their value.
+type durationHistogram struct {
+	count    int
+	sum      time.Duration
+	min, max time.Duration
+	// exact holds every duration until exactDurationLimit is reached;
+	// buckets holds the counts afterwards.
+	exact   []time.Duration
+	buckets []int
+}
+
+func (h *durationHistogram) record(d time.Duration) {
+	d = max(d, 0)
+	if h.count == 0 || d < h.min {
+		h.min = d
+	}
+	h.max = max(h.max, d)
+	h.count++
+	h.sum += d
+	if h.buckets == nil && len(h.exact) < exactDurationLimit {
+		h.exact = append(h.exact, d)
+		return
+	}
+	h.spill()
+	h.addBucket(d, 1)
+}
+
+// spill moves the exact durations into buckets.
+func (h *durationHistogram) spill() {
+	if h.buckets != nil {
+		return
+	}
+	h.buckets = []int{}
+	for _, d := range h.exact {
+		h.addBucket(d, 1)
+	}
+	h.exact = nil
+}
+
+func (h *durationHistogram) addBucket(d time.Duration, n int) {
+	index := bucketIndex(uint64(d))
+	if index >= len(h.buckets) {
+		h.buckets = append(h.buckets, make([]int, index+1-len(h.buckets))...)
+	}
+	h.buckets[index] += n
+}
+
+// merge adds the durations of other.
+func (h *durationHistogram) merge(other *durationHistogram) {
+	if other == nil || other.count == 0 {
+		return
+	}
+	if h.count == 0 || other.min < h.min {
+		h.min = other.min
+	}
+	h.max = max(h.max, other.max)
+	h.count += other.count
+	h.sum += other.sum
+	if h.buckets == nil && other.buckets == nil && len(h.exact)+len(other.exact) <= exactDurationLimit {
+		h.exact = append(h.exact, other.exact...)
+		return
+	}
+	h.spill()
+	for _, d := range other.exact {
+		h.addBucket(d, 1)
+	}
+	for index, n := range other.buckets {
+		if n > 0 {
+			h.addBucket(time.Duration(bucketLowest(index)), n)
+		}
+	}
+}
+
+func (h *durationHistogram) average() time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	return time.Duration(int64(h.sum) / int64(h.count))
+}
+
+// percentiles returns the q-quantiles of the durations, for q in [0, 1],
+// using the same nearest rank below as the summary always has.
+func (h *durationHistogram) percentiles(qs ...float64) []time.Duration {
+	out := make([]time.Duration, len(qs))
+	if h.count == 0 {
+		return out
+	}
+	if h.buckets == nil {
+		sorted := slices.Clone(h.exact)
+		slices.Sort(sorted)
+		for i, q := range qs {
+			out[i] = sorted[int(float64(len(sorted)-1)*q)]
+		}
+		return out
+	}
+	for i, q := range qs {
+		rank := int(float64(h.count-1)*q) + 1
+		seen := 0
+		for index, n := range h.buckets {
+			seen += n
+			if seen >= rank {
+				mid := bucketLowest(index) + bucketWidth(index)/2
+				out[i] = min(max(time.Duration(mid), h.min), h.max)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// averageAndP95 returns the mean and 95th percentile of the durations, or
+// zeros when there are none.
+func (h *durationHistogram) averageAndP95() (time.Duration, time.Duration) {
+	return h.average(), h.percentiles(0.95)[0]
+}
+
+// setLatencies fills in the request latencies of summary from h.
+func (h *durationHistogram) setLatencies(summary *Summary) {
+	p := h.percentiles(0.5, 0.9, 0.95, 0.99, 0.999)
+	summary.AvgLatency = h.average()
+	summary.MinLatency, summary.MaxLatency = h.min, h.max
+	summary.P50Latency, summary.P90Latency, summary.P95Latency, summary.P99Latency, summary.P999Latency = p[0], p[1], p[2], p[3], p[4]
+}
+
+// bucketIndex returns the bucket of v: values below 2^subBucketBits get a
+// bucket each, and every further power of two gets 2^(subBucketBits-1).
+func bucketIndex(v uint64) int {
+	const half = 1 << (subBucketBits - 1)
+	if v < 1<<subBucketBits {
+		return int(v)
+	}
+	shift := bits.Len64(v) - subBucketBits
+	return 1<<subBucketBits + (shift-1)*half + int(v>>shift) - half
+}
+
+func bucketLowest(index int) uint64 {
+	const half = 1 << (subBucketBits - 1)
+	if index < 1<<subBucketBits {
+		return uint64(index)
+	}
+	shift := (index-1<<subBucketBits)/half + 1
+	return uint64((index-1<<subBucketBits)%half+half) << shift
+}
+
+func bucketWidth(index int) uint64 {
+	const half = 1 << (subBucketBits - 1)
+	if index < 1<<subBucketBits {
+		return 1
+	}
+	return 1 << ((index-1<<subBucketBits)/half + 1)
+}
@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDurationHistogramIsExactForShortRuns(t *testing.T) {
+	var h durationHistogram
+	for i := 1; i <= 1000; i++ {
+		h.record(time.Duration(i) * time.Millisecond)
+	}
+
+	var summary Summary
+	h.setLatencies(&summary)
+	if summary.MinLatency != time.Millisecond || summary.MaxLatency != time.Second {
+		t.Fatalf("unexpected min/max: %s/%s", summary.MinLatency, summary.MaxLatency)
+	}
+	if summary.P50Latency != 500*time.Millisecond || summary.P99Latency != 990*time.Millisecond || summary.P999Latency != 999*time.Millisecond {
+		t.Fatalf("unexpected percentiles: %+v", summary)
+	}
+	if h.exact == nil || h.buckets != nil {
+		t.Fatalf("expected exact durations below the limit")
+	}
+}
+
+func TestDurationHistogramBoundsLongRuns(t *testing.T) {
+	var h durationHistogram
+	const n = 100000
+	for i := 1; i <= n; i++ {
+		h.record(time.Duration(i) * time.Microsecond)
+	}
+	if h.exact != nil {
+		t.Fatalf("expected durations to move into buckets past the limit")
+	}
+	if len(h.buckets) > 4000 {
+		t.Fatalf("expected a bounded number of buckets, got %d", len(h.buckets))
+	}
+
+	var summary Summary
+	h.setLatencies(&summary)
+	if summary.MinLatency != time.Microsecond || summary.MaxLatency != n*time.Microsecond {
+		t.Fatalf("expected exact min/max, got %s/%s", summary.MinLatency, summary.MaxLatency)
+	}
+	if summary.AvgLatency != (n+1)*time.Microsecond/2 {
+		t.Fatalf("expected an exact average, got %s", summary.AvgLatency)
+	}
+	for q, got := range map[float64]time.Duration{0.5: summary.P50Latency, 0.9: summary.P90Latency, 0.99: summary.P99Latency, 0.999: summary.P999Latency} {
+		want := float64(int(float64(n-1)*q)+1) * float64(time.Microsecond)
+		if math.Abs(float64(got)-want)/want > 0.004 {
+			t.Fatalf("p%g = %s, want within 0.4%% of %s", 100*q, got, time.Duration(want))
+		}
+	}
+}
+
+func TestDurationHistogramMerge(t *testing.T) {
+	var exact, spilled, merged durationHistogram
+	for i := 1; i <= 10; i++ {
+		exact.record(time.Duration(i) * time.Millisecond)
+	}
+	for i := 1; i <= exactDurationLimit+1; i++ {
+		spilled.record(time.Second)
+	}
+	merged.merge(&exact)
+	merged.merge(&spilled)
+
+	if merged.count != exactDurationLimit+11 || merged.min != time.Millisecond || merged.max != time.Second {
+		t.Fatalf("unexpected merged histogram: count %d, min %s, max %s", merged.count, merged.min, merged.max)
+	}
+	if p50 := merged.percentiles(0.5)[0]; math.Abs(float64(p50-time.Second))/float64(time.Second) > 0.004 {
+		t.Fatalf("expected p50 near 1s, got %s", p50)
+	}
+}
+
+func TestBucketBoundsContainTheirValues(t *testing.T) {
+	for _, v := range []uint64{0, 1, 255, 256, 257, 511, 512, 1000, 123456789, math.MaxInt64} {
+		index := bucketIndex(v)
+		if low := bucketLowest(index); v < low || v >= low+bucketWidth(index) && low+bucketWidth(index) > low {
+			t.Fatalf("value %d outside bucket %d [%d, +%d)", v, index, low, bucketWidth(index))
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Ingestion is the result of reading every recorded trace back from the
+// backend after a run: whether it was stored with all, some or none of
+// the spans sent for it.
+type Ingestion struct {
+	Checked  int
+	Complete int
+	Partial  int
+	Missing  int
+	// Unchecked traces were sent after the recording limit was reached.
+	Unchecked int
+	// PartialTraceIDs and MissingTraceIDs hold the first incomplete
+	// traces, to look them up in the backend.
+	PartialTraceIDs []string
+	MissingTraceIDs []string
+}
+
+// Failed reports whether any checked trace was not stored completely.
+func (i Ingestion) Failed() bool {
+	return i.Partial > 0 || i.Missing > 0
+}
+
+func formatIngestion(i Ingestion) []string {
+	line := fmt.Sprintf("Ingestion: %s of %s traces complete", formatCount(i.Complete), formatCount(i.Checked))
+	if i.Partial > 0 {
+		line += fmt.Sprintf(", %s partial", formatCount(i.Partial))
+	}
+	if i.Missing > 0 {
+		line += fmt.Sprintf(", %s missing", formatCount(i.Missing))
+	}
+	if i.Unchecked > 0 {
+		line += fmt.Sprintf(", %s unchecked", formatCount(i.Unchecked))
+	}
+	lines := []string{line}
+	if len(i.PartialTraceIDs) > 0 {
+		lines = append(lines, "  - partial: "+strings.Join(i.PartialTraceIDs, ", "))
+	}
+	if len(i.MissingTraceIDs) > 0 {
+		lines = append(lines, "  - missing: "+strings.Join(i.MissingTraceIDs, ", "))
+	}
+	return lines
+}
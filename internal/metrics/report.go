@@ -0,0 +1,211 @@
+package metrics
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// Report is the machine-readable form of a Summary written by --report-file.
+// Durations are expressed in milliseconds and rates per second.
+type Report struct {
+	TotalRequests               int                 `json:"total_requests"`
+	SuccessfulRequests          int                 `json:"successful_requests"`
+	FailedRequests              int                 `json:"failed_requests"`
+	WallTimeSeconds             float64             `json:"wall_time_seconds"`
+	RateWindowSeconds           float64             `json:"rate_window_seconds,omitempty"`
+	PartialIntervalRequests     int                 `json:"partial_interval_requests,omitempty"`
+	RequestsPerSecond           float64             `json:"requests_per_second"`
+	SuccessfulRequestsPerSecond float64             `json:"successful_requests_per_second"`
+	TotalSpans                  int                 `json:"total_spans"`
+	SuccessfulSpans             int                 `json:"successful_spans"`
+	FailedSpans                 int                 `json:"failed_spans"`
+	SpansPerSecond              float64             `json:"spans_per_second"`
+	SuccessfulSpansPerSecond    float64             `json:"successful_spans_per_second"`
+	AverageSpansPerRequest      float64             `json:"average_spans_per_request"`
+	SentBytes                   int64               `json:"sent_bytes,omitempty"`
+	BytesPerSecond              float64             `json:"bytes_per_second,omitempty"`
+	Retries                     int                 `json:"retries"`
+	RetriedRequests             int                 `json:"retried_requests"`
+	PotentialDuplicateSpans     int                 `json:"potential_duplicate_spans"`
+	AvgLatencyMs                float64             `json:"avg_latency_ms"`
+	P95LatencyMs                float64             `json:"p95_latency_ms"`
+	MinLatencyMs                float64             `json:"min_latency_ms"`
+	P50LatencyMs                float64             `json:"p50_latency_ms"`
+	P90LatencyMs                float64             `json:"p90_latency_ms"`
+	P99LatencyMs                float64             `json:"p99_latency_ms"`
+	P999LatencyMs               float64             `json:"p999_latency_ms"`
+	MaxLatencyMs                float64             `json:"max_latency_ms"`
+	AvgQueueWaitMs              float64             `json:"avg_queue_wait_ms,omitempty"`
+	P95QueueWaitMs              float64             `json:"p95_queue_wait_ms,omitempty"`
+	DroppedArrivals             int                 `json:"dropped_arrivals,omitempty"`
+	FailureBreakdown            map[string]int      `json:"failure_breakdown,omitempty"`
+	FailureSamples              map[string][]string `json:"failure_samples,omitempty"`
+	TraceIDSamples              []string            `json:"trace_id_samples,omitempty"`
+	FailedTraceIDSamples        []string            `json:"failed_trace_id_samples,omitempty"`
+	SlowestRequests             []ReportRequest     `json:"slowest_requests,omitempty"`
+	FutureDatedSpans            int                 `json:"future_dated_spans,omitempty"`
+	SchemaViolatingSpans        int                 `json:"schema_violating_spans,omitempty"`
+	SchemaViolations            map[string]int      `json:"schema_violations,omitempty"`
+	TraceShape                  *TraceShape         `json:"trace_shape,omitempty"`
+	Backend                     *ReportBackend      `json:"backend,omitempty"`
+	Freshness                   *ReportFreshness    `json:"freshness,omitempty"`
+	Ingestion                   *ReportIngestion    `json:"ingestion,omitempty"`
+	Workers                     []ReportWorker      `json:"workers,omitempty"`
+	Seed                        int64               `json:"seed,omitempty"`
+}
+
+// ReportBackend is the sent-versus-received comparison against the
+// backend's ingest counter.
+type ReportBackend struct {
+	Metric        string  `json:"metric"`
+	SentSpans     int     `json:"sent_spans"`
+	ReceivedSpans float64 `json:"received_spans"`
+	MissingSpans  float64 `json:"missing_spans"`
+}
+
+// ReportFreshness is the ingest-to-queryable latency of sampled traces.
+type ReportFreshness struct {
+	ProbedTraces    int     `json:"probed_traces"`
+	QueryableTraces int     `json:"queryable_traces"`
+	TimedOutTraces  int     `json:"timed_out_traces"`
+	SkippedTraces   int     `json:"skipped_traces"`
+	P50Ms           float64 `json:"p50_ms"`
+	P95Ms           float64 `json:"p95_ms"`
+	P99Ms           float64 `json:"p99_ms"`
+	MaxMs           float64 `json:"max_ms"`
+}
+
+// ReportIngestion is the read-back check of the traces sent.
+type ReportIngestion struct {
+	CheckedTraces   int      `json:"checked_traces"`
+	CompleteTraces  int      `json:"complete_traces"`
+	PartialTraces   int      `json:"partial_traces"`
+	MissingTraces   int      `json:"missing_traces"`
+	UncheckedTraces int      `json:"unchecked_traces"`
+	PartialTraceIDs []string `json:"partial_trace_ids,omitempty"`
+	MissingTraceIDs []string `json:"missing_trace_ids,omitempty"`
+}
+
+// ReportWorker is the export results of one worker.
+type ReportWorker struct {
+	Worker       int     `json:"worker"`
+	Requests     int     `json:"requests"`
+	Failures     int     `json:"failures"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+	P95LatencyMs float64 `json:"p95_latency_ms"`
+}
+
+// ReportRequest is one entry of Report.SlowestRequests.
+type ReportRequest struct {
+	StartedAt  time.Time `json:"started_at"`
+	DurationMs float64   `json:"duration_ms"`
+	Spans      int       `json:"spans"`
+	Error      string    `json:"error,omitempty"`
+}
+
+func NewReport(summary Summary) Report {
+	report := Report{
+		TotalRequests:               summary.Total,
+		SuccessfulRequests:          summary.Successes,
+		FailedRequests:              summary.Failures,
+		WallTimeSeconds:             summary.WallTime.Seconds(),
+		RateWindowSeconds:           summary.RateWindow.Seconds(),
+		PartialIntervalRequests:     summary.PartialIntervalRequests,
+		RequestsPerSecond:           summary.RequestsPerSecond,
+		SuccessfulRequestsPerSecond: summary.SuccessfulRequestsPerSecond,
+		TotalSpans:                  summary.TotalSpans,
+		SuccessfulSpans:             summary.SuccessfulSpans,
+		FailedSpans:                 summary.FailedSpans,
+		SpansPerSecond:              summary.SpansPerSecond,
+		SuccessfulSpansPerSecond:    summary.SuccessfulSpansPerSecond,
+		AverageSpansPerRequest:      summary.AverageSpansPerRequest,
+		SentBytes:                   summary.SentBytes,
+		BytesPerSecond:              summary.BytesPerSecond,
+		Retries:                     summary.Retries,
+		RetriedRequests:             summary.RetriedRequests,
+		PotentialDuplicateSpans:     summary.PotentialDuplicateSpans,
+		AvgLatencyMs:                durationMillis(summary.AvgLatency),
+		P95LatencyMs:                durationMillis(summary.P95Latency),
+		MinLatencyMs:                durationMillis(summary.MinLatency),
+		P50LatencyMs:                durationMillis(summary.P50Latency),
+		P90LatencyMs:                durationMillis(summary.P90Latency),
+		P99LatencyMs:                durationMillis(summary.P99Latency),
+		P999LatencyMs:               durationMillis(summary.P999Latency),
+		MaxLatencyMs:                durationMillis(summary.MaxLatency),
+		AvgQueueWaitMs:              durationMillis(summary.AvgQueueWait),
+		P95QueueWaitMs:              durationMillis(summary.P95QueueWait),
+		DroppedArrivals:             summary.DroppedArrivals,
+		FailureBreakdown:            summary.FailureBreakdown,
+		FailureSamples:              summary.FailureSamples,
+		TraceIDSamples:              summary.TraceIDSamples,
+		FailedTraceIDSamples:        summary.FailedTraceIDSamples,
+		FutureDatedSpans:            summary.FutureDatedSpans,
+		SchemaViolatingSpans:        summary.SchemaViolatingSpans,
+		SchemaViolations:            summary.SchemaViolations,
+		TraceShape:                  summary.TraceShape,
+		Seed:                        summary.Seed,
+	}
+	for _, worker := range summary.Workers {
+		report.Workers = append(report.Workers, ReportWorker{
+			Worker:       worker.Worker,
+			Requests:     worker.Requests,
+			Failures:     worker.Failures,
+			AvgLatencyMs: durationMillis(worker.AvgLatency),
+			P95LatencyMs: durationMillis(worker.P95Latency),
+		})
+	}
+	for _, sample := range summary.SlowestRequests {
+		report.SlowestRequests = append(report.SlowestRequests, ReportRequest{
+			StartedAt:  sample.StartedAt.UTC(),
+			DurationMs: durationMillis(sample.Duration),
+			Spans:      sample.Spans,
+			Error:      sample.Error,
+		})
+	}
+	if summary.Backend != nil {
+		report.Backend = &ReportBackend{
+			Metric:        summary.Backend.Metric,
+			SentSpans:     summary.Backend.Sent,
+			ReceivedSpans: summary.Backend.Received(),
+			MissingSpans:  summary.Backend.Missing(),
+		}
+	}
+	if summary.Freshness != nil {
+		freshness := summary.Freshness
+		report.Freshness = &ReportFreshness{
+			ProbedTraces:    freshness.Probed,
+			QueryableTraces: freshness.Queryable,
+			TimedOutTraces:  freshness.TimedOut,
+			SkippedTraces:   freshness.Skipped,
+			P50Ms:           durationMillis(freshness.Percentile(0.5)),
+			P95Ms:           durationMillis(freshness.Percentile(0.95)),
+			P99Ms:           durationMillis(freshness.Percentile(0.99)),
+			MaxMs:           durationMillis(freshness.Percentile(1)),
+		}
+	}
+	if summary.Ingestion != nil {
+		ingestion := summary.Ingestion
+		report.Ingestion = &ReportIngestion{
+			CheckedTraces:   ingestion.Checked,
+			CompleteTraces:  ingestion.Complete,
+			PartialTraces:   ingestion.Partial,
+			MissingTraces:   ingestion.Missing,
+			UncheckedTraces: ingestion.Unchecked,
+			PartialTraceIDs: ingestion.PartialTraceIDs,
+			MissingTraceIDs: ingestion.MissingTraceIDs,
+		}
+	}
+	return report
+}
+
+// WriteReport writes summary to w as indented JSON.
+func WriteReport(w io.Writer, summary Summary) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(NewReport(summary))
+}
+
+func durationMillis(duration time.Duration) float64 {
+	return float64(duration) / float64(time.Millisecond)
+}
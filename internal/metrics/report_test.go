@@ -0,0 +1,143 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteReportIncludesSlowestRequests(t *testing.T) {
+	summary := Summary{
+		Total:      2,
+		Successes:  1,
+		Failures:   1,
+		WallTime:   2 * time.Second,
+		AvgLatency: 1500 * time.Microsecond,
+		SlowestRequests: []RequestSample{{
+			StartedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+			Duration:  25 * time.Millisecond,
+			Spans:     7,
+			Error:     "boom",
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, summary); err != nil {
+		t.Fatalf("write report: %v", err)
+	}
+
+	var report Report
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("decode report: %v", err)
+	}
+	if report.TotalRequests != 2 || report.FailedRequests != 1 {
+		t.Fatalf("unexpected request counts: %+v", report)
+	}
+	if report.WallTimeSeconds != 2 || report.AvgLatencyMs != 1.5 {
+		t.Fatalf("unexpected timings: wall=%v avg=%v", report.WallTimeSeconds, report.AvgLatencyMs)
+	}
+	if len(report.SlowestRequests) != 1 {
+		t.Fatalf("expected 1 slowest request, got %d", len(report.SlowestRequests))
+	}
+	got := report.SlowestRequests[0]
+	if got.DurationMs != 25 || got.Spans != 7 || got.Error != "boom" || !got.StartedAt.Equal(summary.SlowestRequests[0].StartedAt) {
+		t.Fatalf("unexpected slowest request: %+v", got)
+	}
+}
+
+func TestBackendComparisonInReportAndSummary(t *testing.T) {
+	summary := Summary{
+		SuccessfulSpans: 1000,
+		Backend:         &BackendComparison{Metric: "spans_received_total", Before: 500, After: 1480, Sent: 1000},
+	}
+
+	report := NewReport(summary)
+	if report.Backend == nil {
+		t.Fatalf("expected backend section in report")
+	}
+	if report.Backend.ReceivedSpans != 980 || report.Backend.MissingSpans != 20 || report.Backend.SentSpans != 1000 {
+		t.Fatalf("unexpected backend comparison: %+v", report.Backend)
+	}
+	if got := FormatSummary(summary); !strings.Contains(got, "Backend received spans: 980 of 1k sent (missing 20, spans_received_total)") {
+		t.Fatalf("expected backend line in summary, got:\n%s", got)
+	}
+
+	reset := BackendComparison{Before: 5000, After: 900, Sent: 1000}
+	if reset.Received() != 900 {
+		t.Fatalf("expected counter reset to fall back to the final value, got %v", reset.Received())
+	}
+}
+
+func TestFreshnessInReportAndSummary(t *testing.T) {
+	summary := Summary{
+		Freshness: &Freshness{
+			Probed:    4,
+			Queryable: 3,
+			TimedOut:  1,
+			Latencies: []time.Duration{3 * time.Second, time.Second, 2 * time.Second},
+		},
+	}
+
+	report := NewReport(summary)
+	if report.Freshness == nil {
+		t.Fatalf("expected freshness section in report")
+	}
+	if report.Freshness.QueryableTraces != 3 || report.Freshness.TimedOutTraces != 1 {
+		t.Fatalf("unexpected freshness counts: %+v", report.Freshness)
+	}
+	if report.Freshness.P50Ms != 2000 || report.Freshness.MaxMs != 3000 {
+		t.Fatalf("unexpected freshness percentiles: %+v", report.Freshness)
+	}
+
+	formatted := FormatSummary(summary)
+	if !strings.Contains(formatted, "Trace freshness: 3 of 4 probed traces queryable, 1 timed out (p50 2000ms") {
+		t.Fatalf("expected freshness line in summary, got %q", formatted)
+	}
+}
+
+func TestIngestionInReportAndSummary(t *testing.T) {
+	summary := Summary{
+		Ingestion: &Ingestion{
+			Checked:         4,
+			Complete:        2,
+			Partial:         1,
+			Missing:         1,
+			Unchecked:       3,
+			MissingTraceIDs: []string{"0af7651916cd43dd8448eb211c80319c"},
+		},
+	}
+
+	report := NewReport(summary)
+	if report.Ingestion == nil || report.Ingestion.MissingTraces != 1 || report.Ingestion.UncheckedTraces != 3 {
+		t.Fatalf("unexpected ingestion section: %+v", report.Ingestion)
+	}
+
+	formatted := FormatSummary(summary)
+	if !strings.Contains(formatted, "Ingestion: 2 of 4 traces complete, 1 partial, 1 missing, 3 unchecked\n  - missing: 0af7651916cd43dd8448eb211c80319c") {
+		t.Fatalf("expected ingestion lines in summary, got %q", formatted)
+	}
+}
+
+func TestSeedInReportAndSummary(t *testing.T) {
+	summary := Summary{Total: 1, Successes: 1, Seed: 8345}
+
+	if report := NewReport(summary); report.Seed != 8345 {
+		t.Fatalf("expected seed in report, got %d", report.Seed)
+	}
+	formatted := FormatSummary(summary)
+	if !strings.Contains(formatted, "Seed: 8345 (repeat with --seed=8345)") {
+		t.Fatalf("expected seed line in summary, got %q", formatted)
+	}
+	if strings.Contains(FormatSummary(Summary{}), "Seed:") {
+		t.Fatalf("expected no seed line without a seed")
+	}
+}
+
+func TestWorkersInReport(t *testing.T) {
+	report := NewReport(Summary{Workers: []WorkerSummary{{Worker: 2, Requests: 5, Failures: 1, AvgLatency: 30 * time.Millisecond, P95Latency: 80 * time.Millisecond}}})
+	if len(report.Workers) != 1 || report.Workers[0] != (ReportWorker{Worker: 2, Requests: 5, Failures: 1, AvgLatencyMs: 30, P95LatencyMs: 80}) {
+		t.Fatalf("unexpected workers in report: %+v", report.Workers)
+	}
+}
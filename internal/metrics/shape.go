@@ -0,0 +1,35 @@
+package metrics
+
+import "fmt"
+
+// TraceShape describes the generated traces of a run, measured on spans as
+// they leave the pipeline, so expectations about the generator can be
+// checked after a dry run.
+type TraceShape struct {
+	Traces     int `json:"traces"`
+	Spans      int `json:"spans"`
+	ErrorSpans int `json:"error_spans"`
+	// Services counts distinct service.name resource attributes.
+	Services int `json:"services"`
+}
+
+// SpansPerTrace returns the average number of spans per trace.
+func (s TraceShape) SpansPerTrace() float64 {
+	if s.Traces == 0 {
+		return 0
+	}
+	return float64(s.Spans) / float64(s.Traces)
+}
+
+// ErrorRate returns the fraction of spans with error status.
+func (s TraceShape) ErrorRate() float64 {
+	if s.Spans == 0 {
+		return 0
+	}
+	return float64(s.ErrorSpans) / float64(s.Spans)
+}
+
+func formatTraceShape(shape TraceShape) string {
+	return fmt.Sprintf("Trace shape: %s traces, %.1f spans/trace, %d services, %.2f%% error spans",
+		formatCount(shape.Traces), shape.SpansPerTrace(), shape.Services, 100*shape.ErrorRate())
+}
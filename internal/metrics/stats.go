@@ -2,27 +2,25 @@ package metrics
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"net"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/javiermolinar/tercios/internal/model"
-	grpccodes "google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
+	"github.com/javiermolinar/tercios/internal/timefmt"
 )
 
 const maxFailureSamplesPerClass = 3
 
 type Stats struct {
-	durations            []time.Duration
+	latencies            durationHistogram
 	successes            int
 	failures             int
 	attemptedSpans       int
 	successfulSpans      int
 	failedSpans          int
+	sentBytes            int64
 	failureBreakdown     map[string]int
 	failureSamples       map[string][]string
 	traceIDSampleLimit   int
@@ -30,6 +28,36 @@ type Stats struct {
 	failedTraceIDSamples []string
 	seenTraceIDs         map[string]struct{}
 	seenFailedTraceIDs   map[string]struct{}
+	slowestLimit         int
+	slowest              []RequestSample
+	retries              int
+	retriedRequests      int
+	duplicateSpans       int
+	queueWaits           durationHistogram
+	rateStart            time.Time
+	rateInterval         time.Duration
+	rateBuckets          []rateBucket
+	// workers is nil unless the worker breakdown is enabled.
+	workers map[int]*workerStats
+}
+
+// rateBucket counts the requests whose export started within one rate
+// interval of the run.
+type rateBucket struct {
+	requests        int
+	successes       int
+	spans           int
+	successfulSpans int
+	sentBytes       int64
+}
+
+// RequestSample describes one export request kept for the slowest-requests
+// report, so latency spikes can be correlated with backend events.
+type RequestSample struct {
+	StartedAt time.Time
+	Duration  time.Duration
+	Spans     int
+	Error     string
 }
 
 func NewStats() *Stats {
@@ -49,6 +77,27 @@ func NewStatsWithTraceIDSampleLimit(limit int) *Stats {
 	}
 }
 
+// SetSlowestRequestsLimit keeps the n slowest export requests for the
+// summary. Zero (the default) disables tracking.
+func (s *Stats) SetSlowestRequestsLimit(n int) {
+	if n < 0 {
+		n = 0
+	}
+	s.slowestLimit = n
+	if len(s.slowest) > n {
+		s.slowest = s.slowest[:n]
+	}
+}
+
+// SetRateInterval buckets recorded requests by start time into intervals of
+// the given length from start, so SummaryExcludingPartialInterval can leave
+// the final, partially completed interval out of the rates.
+func (s *Stats) SetRateInterval(start time.Time, interval time.Duration) {
+	s.rateStart = start
+	s.rateInterval = interval
+	s.rateBuckets = nil
+}
+
 func (s *Stats) Record(duration time.Duration, err error) {
 	s.RecordBatchWithTraceIDs(duration, err, nil, 0)
 }
@@ -58,11 +107,16 @@ func (s *Stats) RecordWithTraceIDs(duration time.Duration, err error, traceIDs [
 }
 
 func (s *Stats) RecordBatchWithTraceIDs(duration time.Duration, err error, traceIDs []string, spans int) {
+	s.RecordBatchAt(time.Now().Add(-duration), duration, err, traceIDs, spans)
+}
+
+// RecordBatchAt records one export request that started at startedAt.
+func (s *Stats) RecordBatchAt(startedAt time.Time, duration time.Duration, err error, traceIDs []string, spans int) {
 	if spans < 0 {
 		spans = 0
 	}
 
-	s.durations = append(s.durations, duration)
+	s.latencies.record(duration)
 	s.attemptedSpans += spans
 	if err != nil {
 		s.failures++
@@ -75,6 +129,94 @@ func (s *Stats) RecordBatchWithTraceIDs(duration time.Duration, err error, trace
 		s.successfulSpans += spans
 	}
 	s.recordTraceIDSamples(traceIDs, err != nil)
+	s.recordSlowest(startedAt, duration, err, spans)
+	s.recordRateBucket(startedAt, err, spans)
+}
+
+func (s *Stats) recordRateBucket(startedAt time.Time, err error, spans int) {
+	bucket := s.rateBucket(startedAt)
+	if bucket == nil {
+		return
+	}
+	bucket.requests++
+	bucket.spans += spans
+	if err == nil {
+		bucket.successes++
+		bucket.successfulSpans += spans
+	}
+}
+
+// RecordSentBytes records that a request started at startedAt delivered
+// bytes of OTLP payload.
+func (s *Stats) RecordSentBytes(startedAt time.Time, bytes int) {
+	s.sentBytes += int64(bytes)
+	if bucket := s.rateBucket(startedAt); bucket != nil {
+		bucket.sentBytes += int64(bytes)
+	}
+}
+
+// rateBucket returns the bucket of a request started at startedAt, or nil
+// when no rate interval is set.
+func (s *Stats) rateBucket(startedAt time.Time) *rateBucket {
+	if s.rateInterval <= 0 {
+		return nil
+	}
+	index := max(int(startedAt.Sub(s.rateStart)/s.rateInterval), 0)
+	for len(s.rateBuckets) <= index {
+		s.rateBuckets = append(s.rateBuckets, rateBucket{})
+	}
+	return &s.rateBuckets[index]
+}
+
+// RecordRetries records that a request of spans spans needed retries extra
+// attempts. Any failed attempt may still have been accepted by the backend,
+// so each retry counts the batch's spans as potential duplicates.
+func (s *Stats) RecordRetries(retries int, spans int) {
+	if retries <= 0 {
+		return
+	}
+	if spans < 0 {
+		spans = 0
+	}
+	s.retries += retries
+	s.retriedRequests++
+	s.duplicateSpans += retries * spans
+}
+
+// RecordQueueWait records how long an open-loop request waited between its
+// scheduled arrival and the start of its export.
+func (s *Stats) RecordQueueWait(wait time.Duration) {
+	s.queueWaits.record(wait)
+}
+
+func (s *Stats) recordSlowest(startedAt time.Time, duration time.Duration, err error, spans int) {
+	if s.slowestLimit <= 0 {
+		return
+	}
+	if len(s.slowest) >= s.slowestLimit && duration <= s.slowest[len(s.slowest)-1].Duration {
+		return
+	}
+	sample := RequestSample{StartedAt: startedAt, Duration: duration, Spans: spans}
+	if err != nil {
+		sample.Error = normalizeErrorMessage(err.Error())
+	}
+	s.slowest = insertSlowest(s.slowest, sample, s.slowestLimit)
+}
+
+// insertSlowest inserts sample into samples, which is sorted by descending
+// duration, and truncates the result to limit entries.
+func insertSlowest(samples []RequestSample, sample RequestSample, limit int) []RequestSample {
+	index := sort.Search(len(samples), func(i int) bool { return samples[i].Duration < sample.Duration })
+	if index >= limit {
+		return samples
+	}
+	samples = append(samples, RequestSample{})
+	copy(samples[index+1:], samples[index:])
+	samples[index] = sample
+	if len(samples) > limit {
+		samples = samples[:limit]
+	}
+	return samples
 }
 
 func (s *Stats) recordFailureSample(class string, err error) {
@@ -143,59 +285,91 @@ type Summary struct {
 	SpansPerSecond              float64
 	SuccessfulSpansPerSecond    float64
 	AverageSpansPerRequest      float64
-	AvgLatency                  time.Duration
-	P95Latency                  time.Duration
-	FailureBreakdown            map[string]int
-	FailureSamples              map[string][]string
-	TraceIDSamples              []string
-	FailedTraceIDSamples        []string
+	// SentBytes is the uncompressed OTLP protobuf size of the requests
+	// exported successfully, when the run measured it.
+	SentBytes      int64
+	BytesPerSecond float64
+	AvgLatency     time.Duration
+	P95Latency     time.Duration
+	// MinLatency through MaxLatency complete the latency distribution.
+	// They are exact up to exactDurationLimit requests and within 0.4%
+	// beyond, except for the exact min and max.
+	MinLatency              time.Duration
+	P50Latency              time.Duration
+	P90Latency              time.Duration
+	P99Latency              time.Duration
+	P999Latency             time.Duration
+	MaxLatency              time.Duration
+	FailureBreakdown        map[string]int
+	FailureSamples          map[string][]string
+	TraceIDSamples          []string
+	FailedTraceIDSamples    []string
+	SlowestRequests         []RequestSample
+	Retries                 int
+	RetriedRequests         int
+	PotentialDuplicateSpans int
+	// Backend is set when the backend's ingest metrics were scraped.
+	Backend *BackendComparison
+	// AvgQueueWait and P95QueueWait are set for open-loop runs: the time
+	// requests waited between their scheduled arrival and their export,
+	// which is where backend slowness shows up at a fixed arrival rate.
+	AvgQueueWait time.Duration
+	P95QueueWait time.Duration
+	// DroppedArrivals counts open-loop arrivals skipped because the
+	// in-flight limit was reached.
+	DroppedArrivals int
+	// FutureDatedSpans counts spans sent with timestamps moved into the
+	// future to test backend clock validation.
+	FutureDatedSpans int
+	// SchemaViolatingSpans counts spans that broke the --schema-file
+	// rules, and SchemaViolations counts each violation, e.g.
+	// "server: missing http.route".
+	SchemaViolatingSpans int
+	SchemaViolations     map[string]int
+	// Freshness is set when sampled traces were polled in the backend
+	// until queryable.
+	Freshness *Freshness
+	// Ingestion is set when the traces sent were read back from the
+	// backend after the run.
+	Ingestion *Ingestion
+	// TraceShape is set when the run measured its traces for the
+	// expectations of its config.
+	TraceShape *TraceShape
+	// RateWindow is set when the run was cut short by its duration limit
+	// or cancellation: rates then cover only this many whole rate
+	// intervals, and the PartialIntervalRequests started after it are left
+	// out so the last, partially completed interval does not drag them down.
+	RateWindow              time.Duration
+	PartialIntervalRequests int
+	// Workers is set when the worker breakdown is enabled: the results
+	// of each export worker, ordered by worker.
+	Workers []WorkerSummary
+	// Seed is the --seed of the run, given or generated, so a run can be
+	// repeated; 0 leaves it out of the summary.
+	Seed int64
 }
 
 func (s *Stats) Summary() Summary {
-	totalRequests := len(s.durations)
-	if totalRequests == 0 {
-		summary := Summary{
-			Total:                0,
-			Successes:            s.successes,
-			Failures:             s.failures,
-			TotalSpans:           s.attemptedSpans,
-			SuccessfulSpans:      s.successfulSpans,
-			FailedSpans:          s.failedSpans,
-			FailureBreakdown:     cloneBreakdown(s.failureBreakdown),
-			FailureSamples:       cloneSamples(s.failureSamples),
-			TraceIDSamples:       cloneStrings(s.traceIDSamples),
-			FailedTraceIDSamples: cloneStrings(s.failedTraceIDSamples),
-		}
-		populateDerivedSummary(&summary)
-		return summary
-	}
-
-	durations := make([]time.Duration, len(s.durations))
-	copy(durations, s.durations)
-	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
-
-	var sum time.Duration
-	for _, d := range durations {
-		sum += d
-	}
-	avg := time.Duration(int64(sum) / int64(len(durations)))
-	p95Index := int(float64(len(durations)-1) * 0.95)
-	p95 := durations[p95Index]
-
 	summary := Summary{
-		Total:                totalRequests,
-		Successes:            s.successes,
-		Failures:             s.failures,
-		TotalSpans:           s.attemptedSpans,
-		SuccessfulSpans:      s.successfulSpans,
-		FailedSpans:          s.failedSpans,
-		AvgLatency:           avg,
-		P95Latency:           p95,
-		FailureBreakdown:     cloneBreakdown(s.failureBreakdown),
-		FailureSamples:       cloneSamples(s.failureSamples),
-		TraceIDSamples:       cloneStrings(s.traceIDSamples),
-		FailedTraceIDSamples: cloneStrings(s.failedTraceIDSamples),
-	}
+		Total:                   s.latencies.count,
+		Successes:               s.successes,
+		Failures:                s.failures,
+		TotalSpans:              s.attemptedSpans,
+		SuccessfulSpans:         s.successfulSpans,
+		FailedSpans:             s.failedSpans,
+		SentBytes:               s.sentBytes,
+		FailureBreakdown:        cloneBreakdown(s.failureBreakdown),
+		FailureSamples:          cloneSamples(s.failureSamples),
+		TraceIDSamples:          cloneStrings(s.traceIDSamples),
+		FailedTraceIDSamples:    cloneStrings(s.failedTraceIDSamples),
+		SlowestRequests:         cloneRequestSamples(s.slowest),
+		Retries:                 s.retries,
+		RetriedRequests:         s.retriedRequests,
+		PotentialDuplicateSpans: s.duplicateSpans,
+	}
+	s.latencies.setLatencies(&summary)
+	summary.AvgQueueWait, summary.P95QueueWait = s.queueWaits.averageAndP95()
+	summary.Workers = workerSummaries(s)
 	populateDerivedSummary(&summary)
 	return summary
 }
@@ -207,6 +381,41 @@ func (s *Stats) SummaryWithElapsed(elapsed time.Duration) Summary {
 	return summary
 }
 
+// SummaryExcludingPartialInterval is SummaryWithElapsed for a run that was
+// cut short after elapsed. Requests and spans are still totalled over the
+// whole run, but rates are computed over the whole rate intervals only. It
+// falls back to SummaryWithElapsed when no rate interval is set or no
+// whole interval completed.
+func (s *Stats) SummaryExcludingPartialInterval(elapsed time.Duration) Summary {
+	summary := s.SummaryWithElapsed(elapsed)
+	if s.rateInterval <= 0 {
+		return summary
+	}
+	whole := int(elapsed / s.rateInterval)
+	window := time.Duration(whole) * s.rateInterval
+	if whole == 0 || window == elapsed {
+		return summary
+	}
+
+	var counted rateBucket
+	for _, bucket := range s.rateBuckets[:min(whole, len(s.rateBuckets))] {
+		counted.requests += bucket.requests
+		counted.successes += bucket.successes
+		counted.spans += bucket.spans
+		counted.successfulSpans += bucket.successfulSpans
+		counted.sentBytes += bucket.sentBytes
+	}
+	seconds := window.Seconds()
+	summary.RateWindow = window
+	summary.PartialIntervalRequests = summary.Total - counted.requests
+	summary.RequestsPerSecond = float64(counted.requests) / seconds
+	summary.SuccessfulRequestsPerSecond = float64(counted.successes) / seconds
+	summary.SpansPerSecond = float64(counted.spans) / seconds
+	summary.SuccessfulSpansPerSecond = float64(counted.successfulSpans) / seconds
+	summary.BytesPerSecond = float64(counted.sentBytes) / seconds
+	return summary
+}
+
 func Summarize(stats []*Stats) Summary {
 	var total int
 	var successes int
@@ -214,9 +423,15 @@ func Summarize(stats []*Stats) Summary {
 	var totalSpans int
 	var successfulSpans int
 	var failedSpans int
+	var retries int
+	var retriedRequests int
+	var duplicateSpans int
+	var sentBytes int64
 	failureBreakdown := make(map[string]int)
 	failureSamples := make(map[string][]string)
 	traceIDLimit := 0
+	slowestLimit := 0
+	var slowest []RequestSample
 	traceIDSamples := make([]string, 0)
 	failedTraceIDSamples := make([]string, 0)
 
@@ -224,17 +439,24 @@ func Summarize(stats []*Stats) Summary {
 		if stat == nil {
 			continue
 		}
-		total += len(stat.durations)
+		total += stat.latencies.count
 		successes += stat.successes
 		failures += stat.failures
 		totalSpans += stat.attemptedSpans
 		successfulSpans += stat.successfulSpans
 		failedSpans += stat.failedSpans
+		retries += stat.retries
+		retriedRequests += stat.retriedRequests
+		duplicateSpans += stat.duplicateSpans
+		sentBytes += stat.sentBytes
 		mergeBreakdown(failureBreakdown, stat.failureBreakdown)
 		mergeSamples(failureSamples, stat.failureSamples)
 		if stat.traceIDSampleLimit > traceIDLimit {
 			traceIDLimit = stat.traceIDSampleLimit
 		}
+		if stat.slowestLimit > slowestLimit {
+			slowestLimit = stat.slowestLimit
+		}
 	}
 	for _, stat := range stats {
 		if stat == nil {
@@ -242,40 +464,40 @@ func Summarize(stats []*Stats) Summary {
 		}
 		traceIDSamples = mergeStringSamples(traceIDSamples, stat.traceIDSamples, traceIDLimit)
 		failedTraceIDSamples = mergeStringSamples(failedTraceIDSamples, stat.failedTraceIDSamples, traceIDLimit)
+		for _, sample := range stat.slowest {
+			slowest = insertSlowest(slowest, sample, slowestLimit)
+		}
 	}
 
 	summary := Summary{
-		Total:                total,
-		Successes:            successes,
-		Failures:             failures,
-		TotalSpans:           totalSpans,
-		SuccessfulSpans:      successfulSpans,
-		FailedSpans:          failedSpans,
-		FailureBreakdown:     failureBreakdown,
-		FailureSamples:       failureSamples,
-		TraceIDSamples:       traceIDSamples,
-		FailedTraceIDSamples: failedTraceIDSamples,
-	}
-
-	durations := make([]time.Duration, 0, total)
+		Total:                   total,
+		Successes:               successes,
+		Failures:                failures,
+		TotalSpans:              totalSpans,
+		SuccessfulSpans:         successfulSpans,
+		FailedSpans:             failedSpans,
+		FailureBreakdown:        failureBreakdown,
+		FailureSamples:          failureSamples,
+		TraceIDSamples:          traceIDSamples,
+		FailedTraceIDSamples:    failedTraceIDSamples,
+		SlowestRequests:         slowest,
+		Retries:                 retries,
+		RetriedRequests:         retriedRequests,
+		PotentialDuplicateSpans: duplicateSpans,
+		SentBytes:               sentBytes,
+	}
+
+	var latencies, queueWaits durationHistogram
 	for _, stat := range stats {
 		if stat == nil {
 			continue
 		}
-		durations = append(durations, stat.durations...)
+		latencies.merge(&stat.latencies)
+		queueWaits.merge(&stat.queueWaits)
 	}
-	if len(durations) == 0 {
-		populateDerivedSummary(&summary)
-		return summary
-	}
-	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
-
-	var sum time.Duration
-	for _, d := range durations {
-		sum += d
-	}
-	summary.AvgLatency = time.Duration(int64(sum) / int64(len(durations)))
-	summary.P95Latency = durations[int(float64(len(durations)-1)*0.95)]
+	latencies.setLatencies(&summary)
+	summary.AvgQueueWait, summary.P95QueueWait = queueWaits.averageAndP95()
+	summary.Workers = workerSummaries(stats...)
 	populateDerivedSummary(&summary)
 	return summary
 }
@@ -316,19 +538,35 @@ func populateDerivedSummary(summary *Summary) {
 			summary.SuccessfulRequestsPerSecond = float64(summary.Successes) / seconds
 			summary.SpansPerSecond = float64(summary.TotalSpans) / seconds
 			summary.SuccessfulSpansPerSecond = float64(summary.SuccessfulSpans) / seconds
+			summary.BytesPerSecond = float64(summary.SentBytes) / seconds
 		}
 	}
 }
 
 func FormatSummary(summary Summary) string {
+	return FormatSummaryWith(summary, timefmt.DefaultOptions())
+}
+
+// FormatSummaryWith renders the summary with timestamps and durations in
+// the given format.
+func FormatSummaryWith(summary Summary, format timefmt.Options) string {
 	lines := []string{
 		fmt.Sprintf("Sent %s requests", formatCount(summary.Total)),
 		fmt.Sprintf("Success: %s", formatCount(summary.Successes)),
 		fmt.Sprintf("Failures: %s", formatCount(summary.Failures)),
 	}
+	if summary.Seed != 0 {
+		lines = append(lines, fmt.Sprintf("Seed: %d (repeat with --seed=%d)", summary.Seed, summary.Seed))
+	}
 	if summary.WallTime > 0 {
 		lines = append(lines,
-			fmt.Sprintf("Wall time: %s", formatWallTime(summary.WallTime)),
+			fmt.Sprintf("Wall time: %s", formatWallTime(summary.WallTime, format)),
+		)
+		if summary.RateWindow > 0 {
+			lines = append(lines, fmt.Sprintf("Rate window: %s (final partial interval excluded: %s requests)",
+				formatWallTime(summary.RateWindow, format), formatCount(summary.PartialIntervalRequests)))
+		}
+		lines = append(lines,
 			fmt.Sprintf("Request rate: %s req/s", formatRate(summary.RequestsPerSecond)),
 			fmt.Sprintf("Successful request rate: %s req/s", formatRate(summary.SuccessfulRequestsPerSecond)),
 		)
@@ -351,21 +589,80 @@ func FormatSummary(summary Summary) string {
 			lines = append(lines, fmt.Sprintf("Avg spans/request: %.1f", summary.AverageSpansPerRequest))
 		}
 	}
+	if summary.SentBytes > 0 {
+		line := fmt.Sprintf("Sent bytes: %s", formatBytes(float64(summary.SentBytes)))
+		var details []string
+		if summary.WallTime > 0 {
+			details = append(details, formatBytes(summary.BytesPerSecond)+"/s")
+		}
+		if summary.SuccessfulSpans > 0 {
+			details = append(details, formatBytes(float64(summary.SentBytes)/float64(summary.SuccessfulSpans))+"/span")
+		}
+		if len(details) > 0 {
+			line += " (" + strings.Join(details, ", ") + ")"
+		}
+		lines = append(lines, line)
+	}
+	if summary.Retries > 0 {
+		lines = append(lines,
+			fmt.Sprintf("Retries: %s (%s requests retried)", formatCount(summary.Retries), formatCount(summary.RetriedRequests)),
+			fmt.Sprintf("Potential duplicate spans: %s", formatCount(summary.PotentialDuplicateSpans)),
+		)
+	}
+	if summary.DroppedArrivals > 0 {
+		lines = append(lines, fmt.Sprintf("Dropped arrivals: %s (in-flight limit reached)", formatCount(summary.DroppedArrivals)))
+	}
+	if summary.FutureDatedSpans > 0 {
+		lines = append(lines, fmt.Sprintf("Future-dated spans: %s", formatCount(summary.FutureDatedSpans)))
+	}
+	if summary.SchemaViolatingSpans > 0 {
+		lines = append(lines, fmt.Sprintf("Schema violations: %s spans", formatCount(summary.SchemaViolatingSpans)))
+		violations := make([]string, 0, len(summary.SchemaViolations))
+		for violation := range summary.SchemaViolations {
+			violations = append(violations, violation)
+		}
+		sort.Strings(violations)
+		for _, violation := range violations {
+			lines = append(lines, fmt.Sprintf("  - %s: %s", violation, formatCount(summary.SchemaViolations[violation])))
+		}
+	}
+	if summary.TraceShape != nil {
+		lines = append(lines, formatTraceShape(*summary.TraceShape))
+	}
+	if summary.Backend != nil {
+		lines = append(lines, formatBackendComparison(*summary.Backend))
+	}
+	if summary.Freshness != nil {
+		lines = append(lines, formatFreshness(*summary.Freshness, format))
+	}
+	if summary.Ingestion != nil {
+		lines = append(lines, formatIngestion(*summary.Ingestion)...)
+	}
 	lines = append(lines,
-		fmt.Sprintf("Avg latency: %s", formatLatency(summary.AvgLatency)),
-		fmt.Sprintf("P95 latency: %s", formatLatency(summary.P95Latency)),
+		fmt.Sprintf("Avg latency: %s", formatLatency(summary.AvgLatency, format)),
+		fmt.Sprintf("P95 latency: %s", formatLatency(summary.P95Latency, format)),
 	)
+	if summary.Total > 0 {
+		lines = append(lines, fmt.Sprintf("Latency distribution: min %s, p50 %s, p90 %s, p99 %s, p99.9 %s, max %s",
+			formatLatency(summary.MinLatency, format), formatLatency(summary.P50Latency, format),
+			formatLatency(summary.P90Latency, format), formatLatency(summary.P99Latency, format),
+			formatLatency(summary.P999Latency, format), formatLatency(summary.MaxLatency, format)))
+	}
+	if summary.AvgQueueWait > 0 || summary.P95QueueWait > 0 {
+		lines = append(lines,
+			fmt.Sprintf("Avg queue wait: %s", formatLatency(summary.AvgQueueWait, format)),
+			fmt.Sprintf("P95 queue wait: %s", formatLatency(summary.P95QueueWait, format)),
+		)
+	}
+	if len(summary.Workers) > 0 {
+		lines = append(lines, formatWorkers(summary.Workers, format)...)
+	}
 
 	if summary.Failures > 0 && len(summary.FailureBreakdown) > 0 {
-		keys := make([]string, 0, len(summary.FailureBreakdown))
-		for key := range summary.FailureBreakdown {
-			keys = append(keys, key)
-		}
-		sort.Strings(keys)
-
 		lines = append(lines, "Failure breakdown:")
-		for _, key := range keys {
-			lines = append(lines, fmt.Sprintf("  - %s: %s", key, formatCount(summary.FailureBreakdown[key])))
+		for _, key := range failureClassesByCount(summary.FailureBreakdown) {
+			count := summary.FailureBreakdown[key]
+			lines = append(lines, fmt.Sprintf("  - %s: %s (%.1f%%)", key, formatCount(count), 100*float64(count)/float64(summary.Failures)))
 			samples := summary.FailureSamples[key]
 			for _, sample := range samples {
 				lines = append(lines, fmt.Sprintf("    sample: %s", sample))
@@ -386,22 +683,40 @@ func FormatSummary(summary Summary) string {
 		}
 	}
 
+	if len(summary.SlowestRequests) > 0 {
+		lines = append(lines, fmt.Sprintf("Slowest requests (%d):", len(summary.SlowestRequests)))
+		for _, sample := range summary.SlowestRequests {
+			line := fmt.Sprintf("  - %s at %s, %s spans", formatLatency(sample.Duration, format), format.FormatTime(sample.StartedAt), formatCount(sample.Spans))
+			if sample.Error != "" {
+				line += ", error: " + sample.Error
+			}
+			lines = append(lines, line)
+		}
+	}
+
 	return strings.Join(lines, "\n")
 }
 
 func FormatProgress(summary Summary, expected int) string {
+	return FormatProgressWith(summary, expected, timefmt.DefaultOptions())
+}
+
+// FormatProgressWith renders a progress line with durations in the given
+// format.
+func FormatProgressWith(summary Summary, expected int, format timefmt.Options) string {
 	expectedText := formatCount(expected)
 	if expected <= 0 {
 		expectedText = "?"
 	}
 	return fmt.Sprintf(
-		"Progress: %s/%s sent | Success: %s | Failures: %s | Avg: %s | P95: %s",
+		"Progress: %s/%s sent | Elapsed: %s | Success: %s | Failures: %s | Avg: %s | P95: %s",
 		formatCount(summary.Total),
 		expectedText,
+		formatWallTime(summary.WallTime, format),
 		formatCount(summary.Successes),
 		formatCount(summary.Failures),
-		formatLatency(summary.AvgLatency),
-		formatLatency(summary.P95Latency),
+		formatLatency(summary.AvgLatency, format),
+		formatLatency(summary.P95Latency, format),
 	)
 }
 
@@ -419,11 +734,25 @@ func formatCount(count int) string {
 	return fmt.Sprintf("%d", count)
 }
 
-func formatLatency(duration time.Duration) string {
-	if duration <= 0 {
-		return "0ms"
+// formatBytes renders a byte count with a binary unit, e.g. "1.5 MiB".
+func formatBytes(bytes float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	unit := 0
+	for bytes >= 1024 && unit < len(units)-1 {
+		bytes /= 1024
+		unit++
 	}
-	return fmt.Sprintf("%dms", duration.Milliseconds())
+	if unit == 0 {
+		return fmt.Sprintf("%.0f B", bytes)
+	}
+	return fmt.Sprintf("%.1f %s", bytes, units[unit])
+}
+
+func formatLatency(duration time.Duration, format timefmt.Options) string {
+	if duration < 0 {
+		duration = 0
+	}
+	return format.FormatDuration(duration, timefmt.DurationMillis)
 }
 
 func formatRate(value float64) string {
@@ -436,11 +765,11 @@ func formatRate(value float64) string {
 	return fmt.Sprintf("%.1f", value)
 }
 
-func formatWallTime(duration time.Duration) string {
-	if duration <= 0 {
-		return "0s"
+func formatWallTime(duration time.Duration, format timefmt.Options) string {
+	if duration < 0 {
+		duration = 0
 	}
-	return fmt.Sprintf("%.3fs", duration.Seconds())
+	return format.FormatDuration(duration, timefmt.DurationSeconds)
 }
 
 func cloneBreakdown(in map[string]int) map[string]int {
@@ -472,6 +801,13 @@ func cloneStrings(in []string) []string {
 	return append([]string(nil), in...)
 }
 
+func cloneRequestSamples(in []RequestSample) []RequestSample {
+	if len(in) == 0 {
+		return nil
+	}
+	return append([]RequestSample(nil), in...)
+}
+
 func mergeBreakdown(dst map[string]int, src map[string]int) {
 	for key, value := range src {
 		dst[key] += value
@@ -525,59 +861,3 @@ func normalizeErrorMessage(message string) string {
 	trimmed = strings.ReplaceAll(trimmed, "\n", " | ")
 	return strings.Join(strings.Fields(trimmed), " ")
 }
-
-func classifyError(err error) string {
-	if err == nil {
-		return "other"
-	}
-
-	if errors.Is(err, context.DeadlineExceeded) {
-		return "timeout"
-	}
-	if errors.Is(err, context.Canceled) {
-		return "canceled"
-	}
-
-	var netErr net.Error
-	if errors.As(err, &netErr) && netErr.Timeout() {
-		return "timeout"
-	}
-
-	if st, ok := status.FromError(err); ok {
-		switch st.Code() {
-		case grpccodes.DeadlineExceeded:
-			return "timeout"
-		case grpccodes.Canceled:
-			return "canceled"
-		case grpccodes.Unavailable:
-			if strings.Contains(strings.ToLower(st.Message()), "connection refused") {
-				return "connection_refused"
-			}
-			return "unavailable"
-		case grpccodes.Unauthenticated:
-			return "unauthenticated"
-		case grpccodes.PermissionDenied:
-			return "permission_denied"
-		}
-	}
-
-	msg := strings.ToLower(err.Error())
-	switch {
-	case strings.Contains(msg, "deadline exceeded"), strings.Contains(msg, "timed out"), strings.Contains(msg, "timeout"):
-		return "timeout"
-	case strings.Contains(msg, "connection refused"):
-		return "connection_refused"
-	case strings.Contains(msg, "no such host"), strings.Contains(msg, "name resolution"):
-		return "dns"
-	case strings.Contains(msg, "x509"), strings.Contains(msg, "tls"), strings.Contains(msg, "certificate"):
-		return "tls"
-	case strings.Contains(msg, "unauthenticated"), strings.Contains(msg, "unauthorized"), strings.Contains(msg, "401"):
-		return "unauthenticated"
-	case strings.Contains(msg, "permission denied"), strings.Contains(msg, "403"):
-		return "permission_denied"
-	case strings.Contains(msg, "unavailable"):
-		return "unavailable"
-	default:
-		return "other"
-	}
-}
@@ -3,9 +3,12 @@ package metrics
 import (
 	"context"
 	"errors"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/javiermolinar/tercios/internal/timefmt"
 )
 
 func TestStatsSummaryIncludesFailureBreakdown(t *testing.T) {
@@ -157,3 +160,289 @@ func TestFormatSummaryPrintsSenderMetrics(t *testing.T) {
 		}
 	}
 }
+
+func TestStatsKeepsSlowestRequests(t *testing.T) {
+	stats := NewStats()
+	stats.SetSlowestRequestsLimit(2)
+	base := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	stats.RecordBatchAt(base, 5*time.Millisecond, nil, nil, 10)
+	stats.RecordBatchAt(base.Add(time.Second), 30*time.Millisecond, errors.New("boom"), nil, 20)
+	stats.RecordBatchAt(base.Add(2*time.Second), 1*time.Millisecond, nil, nil, 30)
+	stats.RecordBatchAt(base.Add(3*time.Second), 12*time.Millisecond, nil, nil, 40)
+
+	slowest := stats.Summary().SlowestRequests
+	if len(slowest) != 2 {
+		t.Fatalf("expected 2 slowest requests, got %d", len(slowest))
+	}
+	if slowest[0].Duration != 30*time.Millisecond || slowest[0].Spans != 20 || slowest[0].Error != "boom" {
+		t.Fatalf("unexpected slowest request: %+v", slowest[0])
+	}
+	if !slowest[0].StartedAt.Equal(base.Add(time.Second)) {
+		t.Fatalf("unexpected start time: %v", slowest[0].StartedAt)
+	}
+	if slowest[1].Duration != 12*time.Millisecond || slowest[1].Error != "" {
+		t.Fatalf("unexpected second slowest request: %+v", slowest[1])
+	}
+}
+
+func TestStatsSlowestRequestsDisabledByDefault(t *testing.T) {
+	stats := NewStats()
+	stats.Record(5*time.Millisecond, nil)
+	if got := stats.Summary().SlowestRequests; len(got) != 0 {
+		t.Fatalf("expected no slowest requests, got %+v", got)
+	}
+}
+
+func TestSummarizeMergesSlowestRequests(t *testing.T) {
+	first := NewStats()
+	first.SetSlowestRequestsLimit(2)
+	first.Record(10*time.Millisecond, nil)
+	first.Record(40*time.Millisecond, nil)
+	second := NewStats()
+	second.SetSlowestRequestsLimit(2)
+	second.Record(20*time.Millisecond, nil)
+	second.Record(50*time.Millisecond, nil)
+
+	slowest := Summarize([]*Stats{first, second}).SlowestRequests
+	if len(slowest) != 2 || slowest[0].Duration != 50*time.Millisecond || slowest[1].Duration != 40*time.Millisecond {
+		t.Fatalf("unexpected merged slowest requests: %+v", slowest)
+	}
+}
+
+func TestFormatSummaryPrintsSlowestRequests(t *testing.T) {
+	summary := Summary{
+		Total: 1,
+		SlowestRequests: []RequestSample{{
+			StartedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+			Duration:  25 * time.Millisecond,
+			Spans:     7,
+			Error:     "boom",
+		}},
+	}
+
+	formatted := FormatSummary(summary)
+	if !strings.Contains(formatted, "Slowest requests (1):") {
+		t.Fatalf("expected slowest requests section, got %q", formatted)
+	}
+	if !strings.Contains(formatted, "2026-01-02T03:04:05.000000000Z") || !strings.Contains(formatted, "error: boom") {
+		t.Fatalf("expected slowest request line, got %q", formatted)
+	}
+}
+
+func TestFormatSummaryWithAppliesTimeFormat(t *testing.T) {
+	summary := Summary{
+		Total:      1,
+		WallTime:   2 * time.Second,
+		AvgLatency: 1500 * time.Microsecond,
+		P95Latency: 3 * time.Millisecond,
+		SlowestRequests: []RequestSample{{
+			StartedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+			Duration:  25 * time.Millisecond,
+			Spans:     7,
+		}},
+	}
+
+	formatted := FormatSummaryWith(summary, timefmt.Options{Timestamp: timefmt.TimestampUnix, DurationUnit: timefmt.DurationMicros})
+	for _, want := range []string{"Wall time: 2000000us", "Avg latency: 1500us", "P95 latency: 3000us", "25000us at 1767323045,"} {
+		if !strings.Contains(formatted, want) {
+			t.Fatalf("expected %q in summary, got %q", want, formatted)
+		}
+	}
+
+	progress := FormatProgressWith(summary, 10, timefmt.Options{DurationUnit: timefmt.DurationSeconds})
+	if !strings.Contains(progress, "Avg: 0.002s") || !strings.Contains(progress, "P95: 0.003s") {
+		t.Fatalf("expected durations in seconds, got %q", progress)
+	}
+}
+
+func TestStatsRecordRetriesCountsPotentialDuplicates(t *testing.T) {
+	stats := NewStats()
+	stats.RecordRetries(0, 10)
+	stats.RecordRetries(2, 5)
+	stats.RecordRetries(1, 3)
+
+	summary := stats.Summary()
+	if summary.Retries != 3 || summary.RetriedRequests != 2 {
+		t.Fatalf("expected 3 retries on 2 requests, got retries=%d retried=%d", summary.Retries, summary.RetriedRequests)
+	}
+	if summary.PotentialDuplicateSpans != 13 {
+		t.Fatalf("expected 13 potential duplicate spans, got %d", summary.PotentialDuplicateSpans)
+	}
+	if formatted := FormatSummary(summary); !strings.Contains(formatted, "Potential duplicate spans: 13") {
+		t.Fatalf("expected duplicate spans line, got %q", formatted)
+	}
+}
+
+func TestStatsSummaryExcludingPartialIntervalUsesWholeIntervals(t *testing.T) {
+	start := time.Date(2026, time.January, 27, 12, 0, 0, 0, time.UTC)
+	stats := NewStats()
+	stats.SetRateInterval(start, 10*time.Second)
+	// One request every 10s, with the run stopped 5s into the third interval.
+	stats.RecordBatchAt(start, 10*time.Millisecond, nil, nil, 4)
+	stats.RecordBatchAt(start.Add(10*time.Second), 10*time.Millisecond, nil, nil, 4)
+	stats.RecordBatchAt(start.Add(20*time.Second), 10*time.Millisecond, errors.New("boom"), nil, 4)
+
+	summary := stats.SummaryExcludingPartialInterval(25 * time.Second)
+	if summary.Total != 3 || summary.TotalSpans != 12 {
+		t.Fatalf("expected totals to cover the whole run, got %d requests and %d spans", summary.Total, summary.TotalSpans)
+	}
+	if summary.RateWindow != 20*time.Second || summary.PartialIntervalRequests != 1 {
+		t.Fatalf("expected a 20s rate window excluding 1 request, got %s and %d", summary.RateWindow, summary.PartialIntervalRequests)
+	}
+	if summary.RequestsPerSecond != 0.1 || summary.SpansPerSecond != 0.4 || summary.SuccessfulRequestsPerSecond != 0.1 {
+		t.Fatalf("unexpected rates %+v", summary)
+	}
+	if !strings.Contains(FormatSummary(summary), "Rate window: 20.000s (final partial interval excluded: 1 requests)") {
+		t.Fatalf("expected rate window line, got:\n%s", FormatSummary(summary))
+	}
+}
+
+func TestStatsSummaryExcludingPartialIntervalKeepsWholeRunRates(t *testing.T) {
+	start := time.Date(2026, time.January, 27, 12, 0, 0, 0, time.UTC)
+	stats := NewStats()
+	stats.SetRateInterval(start, time.Second)
+	stats.RecordBatchAt(start, 10*time.Millisecond, nil, nil, 1)
+
+	if summary := stats.SummaryExcludingPartialInterval(2 * time.Second); summary.RateWindow != 0 || summary.RequestsPerSecond != 0.5 {
+		t.Fatalf("expected whole-interval run to keep plain rates, got %+v", summary)
+	}
+	if summary := stats.SummaryExcludingPartialInterval(500 * time.Millisecond); summary.RateWindow != 0 || summary.RequestsPerSecond != 2 {
+		t.Fatalf("expected run shorter than one interval to keep plain rates, got %+v", summary)
+	}
+}
+
+func TestStatsSummaryIncludesQueueWait(t *testing.T) {
+	stats := NewStats()
+	for i := 1; i <= 20; i++ {
+		stats.Record(time.Millisecond, nil)
+		stats.RecordQueueWait(time.Duration(i) * time.Millisecond)
+	}
+	stats.RecordQueueWait(-time.Millisecond)
+
+	summary := stats.Summary()
+	if summary.AvgQueueWait != 10*time.Millisecond {
+		t.Fatalf("expected avg queue wait 10ms, got %s", summary.AvgQueueWait)
+	}
+	if summary.P95QueueWait != 19*time.Millisecond {
+		t.Fatalf("expected p95 queue wait 19ms, got %s", summary.P95QueueWait)
+	}
+	merged := Summarize([]*Stats{stats, NewStats()})
+	if merged.P95QueueWait != summary.P95QueueWait {
+		t.Fatalf("expected Summarize to keep queue waits, got p95 %s", merged.P95QueueWait)
+	}
+}
+
+func TestStatsSummaryOmitsQueueWaitForClosedLoop(t *testing.T) {
+	stats := NewStats()
+	stats.Record(time.Millisecond, nil)
+	summary := stats.Summary()
+	if summary.AvgQueueWait != 0 || summary.P95QueueWait != 0 {
+		t.Fatalf("expected no queue wait, got avg %s p95 %s", summary.AvgQueueWait, summary.P95QueueWait)
+	}
+	if formatted := FormatSummary(summary); strings.Contains(formatted, "queue wait") {
+		t.Fatalf("expected no queue wait lines, got %q", formatted)
+	}
+}
+
+func TestFormatSummaryPrintsQueueWaitAndDroppedArrivals(t *testing.T) {
+	formatted := FormatSummary(Summary{
+		Total:           5,
+		Successes:       5,
+		AvgQueueWait:    4 * time.Millisecond,
+		P95QueueWait:    9 * time.Millisecond,
+		DroppedArrivals: 3,
+	})
+	for _, want := range []string{"Avg queue wait: 4", "P95 queue wait: 9", "Dropped arrivals: 3"} {
+		if !strings.Contains(formatted, want) {
+			t.Fatalf("expected %q in summary, got %q", want, formatted)
+		}
+	}
+}
+
+func TestStatsWorkerBreakdown(t *testing.T) {
+	stats := NewStats()
+	stats.SetWorkerBreakdown(true)
+	for _, record := range []struct {
+		worker   int
+		duration time.Duration
+		err      error
+	}{
+		{1, 300 * time.Millisecond, errors.New("boom")},
+		{0, 10 * time.Millisecond, nil},
+		{0, 30 * time.Millisecond, nil},
+		{1, 100 * time.Millisecond, nil},
+	} {
+		stats.Record(record.duration, record.err)
+		stats.RecordWorker(record.worker, record.duration, record.err)
+	}
+
+	workers := stats.Summary().Workers
+	want := []WorkerSummary{
+		{Worker: 0, Requests: 2, AvgLatency: 20 * time.Millisecond, P95Latency: 10 * time.Millisecond},
+		{Worker: 1, Requests: 2, Failures: 1, AvgLatency: 200 * time.Millisecond, P95Latency: 100 * time.Millisecond},
+	}
+	if !reflect.DeepEqual(workers, want) {
+		t.Fatalf("unexpected workers: %+v", workers)
+	}
+
+	formatted := FormatSummary(stats.Summary())
+	if !strings.Contains(formatted, "Workers (2):\n  - worker 0: 2 requests, 0 failures, avg 20ms, p95 10ms\n  - worker 1: 2 requests, 1 failures") {
+		t.Fatalf("expected worker table in summary, got %q", formatted)
+	}
+}
+
+func TestStatsWorkerBreakdownDisabledByDefault(t *testing.T) {
+	stats := NewStats()
+	stats.Record(10*time.Millisecond, nil)
+	stats.RecordWorker(0, 10*time.Millisecond, nil)
+
+	if workers := stats.Summary().Workers; workers != nil {
+		t.Fatalf("expected no workers without the breakdown, got %+v", workers)
+	}
+}
+
+func TestFormatSummaryPrintsLatencyDistribution(t *testing.T) {
+	stats := NewStats()
+	for _, ms := range []int{4, 1, 3, 2} {
+		stats.Record(time.Duration(ms)*time.Millisecond, nil)
+	}
+
+	summary := stats.Summary()
+	if summary.MinLatency != time.Millisecond || summary.P50Latency != 2*time.Millisecond || summary.MaxLatency != 4*time.Millisecond {
+		t.Fatalf("unexpected latency distribution: %+v", summary)
+	}
+	formatted := FormatSummary(summary)
+	if !strings.Contains(formatted, "Latency distribution: min 1ms, p50 2ms, p90 3ms, p99 3ms, p99.9 3ms, max 4ms") {
+		t.Fatalf("expected latency distribution line, got %q", formatted)
+	}
+	if report := NewReport(summary); report.MaxLatencyMs != 4 || report.P50LatencyMs != 2 {
+		t.Fatalf("unexpected report latencies: %+v", report)
+	}
+}
+
+func TestStatsSummaryIncludesSentBytes(t *testing.T) {
+	start := time.Date(2026, time.January, 27, 12, 0, 0, 0, time.UTC)
+	stats := NewStats()
+	stats.SetRateInterval(start, 10*time.Second)
+	stats.RecordBatchAt(start, 10*time.Millisecond, nil, nil, 4)
+	stats.RecordSentBytes(start, 2048)
+	stats.RecordBatchAt(start.Add(10*time.Second), 10*time.Millisecond, nil, nil, 4)
+	stats.RecordSentBytes(start.Add(10*time.Second), 2048)
+	stats.RecordBatchAt(start.Add(20*time.Second), 10*time.Millisecond, nil, nil, 4)
+	stats.RecordSentBytes(start.Add(20*time.Second), 2048)
+
+	summary := stats.SummaryExcludingPartialInterval(25 * time.Second)
+	if summary.SentBytes != 6144 || summary.BytesPerSecond != 204.8 {
+		t.Fatalf("expected all bytes with the rate over the whole intervals, got %d bytes at %v/s", summary.SentBytes, summary.BytesPerSecond)
+	}
+	formatted := FormatSummary(summary)
+	if !strings.Contains(formatted, "Sent bytes: 6.0 KiB (205 B/s, 512 B/span)") {
+		t.Fatalf("expected sent bytes line, got:\n%s", formatted)
+	}
+	if merged := Summarize([]*Stats{stats, stats}); merged.SentBytes != 12288 {
+		t.Fatalf("expected merged sent bytes, got %d", merged.SentBytes)
+	}
+	if report := NewReport(summary); report.SentBytes != 6144 || report.BytesPerSecond != 204.8 {
+		t.Fatalf("unexpected report bytes: %d at %v/s", report.SentBytes, report.BytesPerSecond)
+	}
+}
@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/timefmt"
+)
+
+// WorkerSummary is the export results of one worker. Each worker holds its
+// own exporter and connection, so one slow or failing connection stands
+// out here when it barely moves the aggregate.
+type WorkerSummary struct {
+	Worker     int
+	Requests   int
+	Failures   int
+	AvgLatency time.Duration
+	P95Latency time.Duration
+}
+
+type workerStats struct {
+	failures  int
+	latencies durationHistogram
+}
+
+// SetWorkerBreakdown keeps the results of every worker apart for the
+// summary's per-worker table. It is off by default.
+func (s *Stats) SetWorkerBreakdown(enabled bool) {
+	if !enabled {
+		s.workers = nil
+		return
+	}
+	if s.workers == nil {
+		s.workers = map[int]*workerStats{}
+	}
+}
+
+// RecordWorker attributes one export request, already recorded with
+// RecordBatchAt, to worker. It does nothing unless the worker breakdown
+// is enabled.
+func (s *Stats) RecordWorker(worker int, duration time.Duration, err error) {
+	if s.workers == nil {
+		return
+	}
+	w := s.workers[worker]
+	if w == nil {
+		w = &workerStats{}
+		s.workers[worker] = w
+	}
+	w.latencies.record(duration)
+	if err != nil {
+		w.failures++
+	}
+}
+
+// workerSummaries merges the worker results of stats, ordered by worker.
+func workerSummaries(stats ...*Stats) []WorkerSummary {
+	merged := map[int]*workerStats{}
+	for _, stat := range stats {
+		if stat == nil {
+			continue
+		}
+		for worker, w := range stat.workers {
+			m := merged[worker]
+			if m == nil {
+				m = &workerStats{}
+				merged[worker] = m
+			}
+			m.failures += w.failures
+			m.latencies.merge(&w.latencies)
+		}
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+	summaries := make([]WorkerSummary, 0, len(merged))
+	for _, worker := range slices.Sorted(maps.Keys(merged)) {
+		w := merged[worker]
+		summary := WorkerSummary{Worker: worker, Requests: w.latencies.count, Failures: w.failures}
+		summary.AvgLatency, summary.P95Latency = w.latencies.averageAndP95()
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+func formatWorkers(workers []WorkerSummary, format timefmt.Options) []string {
+	lines := []string{fmt.Sprintf("Workers (%d):", len(workers))}
+	for _, w := range workers {
+		lines = append(lines, fmt.Sprintf("  - worker %d: %s requests, %s failures, avg %s, p95 %s",
+			w.Worker, formatCount(w.Requests), formatCount(w.Failures),
+			formatLatency(w.AvgLatency, format), formatLatency(w.P95Latency, format)))
+	}
+	return lines
+}
@@ -10,3 +10,9 @@ type BatchExporter interface {
 type BatchExporterFactory interface {
 	NewBatchExporter(ctx context.Context) (BatchExporter, error)
 }
+
+// Warmer is implemented by exporters that can establish and verify their
+// connection with a throwaway export before any measured request is sent.
+type Warmer interface {
+	Warmup(ctx context.Context) error
+}
@@ -0,0 +1,255 @@
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/model"
+)
+
+// Environment variables of the OpenTelemetry SDK BatchSpanProcessor.
+const (
+	envBSPScheduleDelay      = "OTEL_BSP_SCHEDULE_DELAY"
+	envBSPExportTimeout      = "OTEL_BSP_EXPORT_TIMEOUT"
+	envBSPMaxQueueSize       = "OTEL_BSP_MAX_QUEUE_SIZE"
+	envBSPMaxExportBatchSize = "OTEL_BSP_MAX_EXPORT_BATCH_SIZE"
+)
+
+// BatchProcessorSettings are the options of the OpenTelemetry SDK
+// BatchSpanProcessor.
+type BatchProcessorSettings struct {
+	// MaxQueueSize bounds the spans waiting for export. Spans arriving at
+	// a full queue are dropped, as the SDK does by default.
+	MaxQueueSize int
+	// MaxExportBatchSize is the most spans sent in one request. A full
+	// batch is sent without waiting for ScheduleDelay.
+	MaxExportBatchSize int
+	// ScheduleDelay is the longest a queued span waits before its batch
+	// is sent.
+	ScheduleDelay time.Duration
+	// ExportTimeout bounds each request. Zero means no timeout.
+	ExportTimeout time.Duration
+}
+
+// DefaultBatchProcessorSettings returns the SDK defaults.
+func DefaultBatchProcessorSettings() BatchProcessorSettings {
+	return BatchProcessorSettings{
+		MaxQueueSize:       2048,
+		MaxExportBatchSize: 512,
+		ScheduleDelay:      5 * time.Second,
+		ExportTimeout:      30 * time.Second,
+	}
+}
+
+// BatchProcessorSettingsFromEnv returns the SDK defaults overridden by
+// the OTEL_BSP_* environment variables, with delays and timeouts in
+// milliseconds as the specification defines them. Unlike the SDK, which
+// ignores invalid values, it reports them.
+func BatchProcessorSettingsFromEnv(lookupEnv func(string) (string, bool)) (BatchProcessorSettings, error) {
+	settings := DefaultBatchProcessorSettings()
+	ints := []struct {
+		key    string
+		target *int
+	}{
+		{envBSPMaxQueueSize, &settings.MaxQueueSize},
+		{envBSPMaxExportBatchSize, &settings.MaxExportBatchSize},
+	}
+	for _, v := range ints {
+		value, ok := lookupNonEmptyEnv(lookupEnv, v.key)
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return BatchProcessorSettings{}, fmt.Errorf("%s must be a positive integer, got %q", v.key, value)
+		}
+		*v.target = n
+	}
+	durations := []struct {
+		key    string
+		target *time.Duration
+	}{
+		{envBSPScheduleDelay, &settings.ScheduleDelay},
+		{envBSPExportTimeout, &settings.ExportTimeout},
+	}
+	for _, v := range durations {
+		value, ok := lookupNonEmptyEnv(lookupEnv, v.key)
+		if !ok {
+			continue
+		}
+		ms, err := strconv.Atoi(value)
+		if err != nil || ms < 0 {
+			return BatchProcessorSettings{}, fmt.Errorf("%s must be a number of milliseconds >= 0, got %q", v.key, value)
+		}
+		*v.target = time.Duration(ms) * time.Millisecond
+	}
+	if settings.ScheduleDelay <= 0 {
+		return BatchProcessorSettings{}, fmt.Errorf("%s must be > 0", envBSPScheduleDelay)
+	}
+	// The SDK caps the batch size at the queue size.
+	settings.MaxExportBatchSize = min(settings.MaxExportBatchSize, settings.MaxQueueSize)
+	return settings, nil
+}
+
+// batchProcessorStats is shared by every exporter of one factory.
+type batchProcessorStats struct {
+	open       atomic.Int64
+	queued     atomic.Int64
+	dropped    atomic.Int64
+	failed     atomic.Int64
+	requests   atomic.Int64
+	firstError sync.Once
+}
+
+// batchProcessorExporter hands spans to the inner exporter the way an
+// application instrumented with the OpenTelemetry SDK does: ExportBatch
+// only queues the spans, and a background loop sends them in batches of
+// up to MaxExportBatchSize, when a batch fills or ScheduleDelay passes.
+// Export errors are logged rather than returned, as the SDK reports them
+// to its error handler and never to the code that ended the span.
+type batchProcessorExporter struct {
+	inner    model.BatchExporter
+	settings BatchProcessorSettings
+	stats    *batchProcessorStats
+
+	queue    chan model.Span
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newBatchProcessorExporter(inner model.BatchExporter, settings BatchProcessorSettings, stats *batchProcessorStats) *batchProcessorExporter {
+	e := &batchProcessorExporter{
+		inner:    inner,
+		settings: settings,
+		stats:    stats,
+		queue:    make(chan model.Span, settings.MaxQueueSize),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+// ExportBatch queues the spans of batch, dropping those that do not fit.
+func (e *batchProcessorExporter) ExportBatch(_ context.Context, batch model.Batch) error {
+	for _, span := range batch {
+		select {
+		case e.queue <- span:
+			e.stats.queued.Add(1)
+		default:
+			e.stats.dropped.Add(1)
+		}
+	}
+	return nil
+}
+
+func (e *batchProcessorExporter) run() {
+	defer close(e.done)
+	timer := time.NewTimer(e.settings.ScheduleDelay)
+	defer timer.Stop()
+	batch := make(model.Batch, 0, e.settings.MaxExportBatchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			e.send(batch)
+			batch = make(model.Batch, 0, e.settings.MaxExportBatchSize)
+		}
+		timer.Reset(e.settings.ScheduleDelay)
+	}
+	for {
+		select {
+		case span := <-e.queue:
+			batch = append(batch, span)
+			if len(batch) >= e.settings.MaxExportBatchSize {
+				flush()
+			}
+		case <-timer.C:
+			flush()
+		case <-e.stop:
+			// Drain what was queued before Shutdown, like ForceFlush.
+			for {
+				select {
+				case span := <-e.queue:
+					batch = append(batch, span)
+					if len(batch) >= e.settings.MaxExportBatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (e *batchProcessorExporter) send(batch model.Batch) {
+	ctx := context.Background()
+	if e.settings.ExportTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.settings.ExportTimeout)
+		defer cancel()
+	}
+	e.stats.requests.Add(1)
+	if err := e.inner.ExportBatch(ctx, batch); err != nil {
+		e.stats.failed.Add(int64(len(batch)))
+		e.stats.firstError.Do(func() {
+			log.Printf("warning: SDK batch export of %d spans failed: %v", len(batch), err)
+		})
+	}
+}
+
+// Warmup forwards to the inner exporter when it supports warm-up.
+func (e *batchProcessorExporter) Warmup(ctx context.Context) error {
+	if warmer, ok := e.inner.(model.Warmer); ok {
+		return warmer.Warmup(ctx)
+	}
+	return nil
+}
+
+// Shutdown sends every queued span, then stops the inner exporter. The
+// last exporter of the factory to shut down logs the spans that were
+// dropped or failed to export.
+func (e *batchProcessorExporter) Shutdown(ctx context.Context) error {
+	e.stopOnce.Do(func() { close(e.stop) })
+	select {
+	case <-e.done:
+	case <-ctx.Done():
+		return fmt.Errorf("flush SDK batch queue: %w", ctx.Err())
+	}
+	if e.stats.open.Add(-1) == 0 {
+		if dropped, failed := e.stats.dropped.Load(), e.stats.failed.Load(); dropped > 0 || failed > 0 {
+			log.Printf("warning: SDK batch processor: %d of %d spans dropped on a full queue (OTEL_BSP_MAX_QUEUE_SIZE=%d), %d spans in failed exports out of %d requests",
+				dropped, dropped+e.stats.queued.Load(), e.settings.MaxQueueSize, failed, e.stats.requests.Load())
+		}
+	}
+	return e.inner.Shutdown(ctx)
+}
+
+// BatchProcessorExporterFactory wraps another ExporterFactory so that
+// every BatchExporter it produces behaves like an SDK BatchSpanProcessor
+// in front of the inner exporter. The CLI installs this wrapper when
+// --sdk-batch is set.
+type BatchProcessorExporterFactory struct {
+	Inner    model.BatchExporterFactory
+	Settings BatchProcessorSettings
+	stats    *batchProcessorStats
+}
+
+func NewBatchProcessorExporterFactory(inner model.BatchExporterFactory, settings BatchProcessorSettings) BatchProcessorExporterFactory {
+	return BatchProcessorExporterFactory{Inner: inner, Settings: settings, stats: &batchProcessorStats{}}
+}
+
+func (f BatchProcessorExporterFactory) NewBatchExporter(ctx context.Context) (model.BatchExporter, error) {
+	inner, err := f.Inner.NewBatchExporter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	f.stats.open.Add(1)
+	return newBatchProcessorExporter(inner, f.Settings, f.stats), nil
+}
@@ -0,0 +1,148 @@
+package otlp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/model"
+)
+
+func batchProcessorTestBatch(n int) model.Batch {
+	base := time.Date(2026, time.January, 27, 12, 0, 0, 0, time.UTC)
+	batch := make(model.Batch, n)
+	for i := range batch {
+		batch[i] = makeSpan("GET /items", time.Duration(i)*time.Millisecond, base)
+	}
+	return batch
+}
+
+func waitForEmits(t *testing.T, inner *fakeBatchExporter, want int) []recordedEmit {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		emits := inner.snapshot()
+		if len(emits) >= want || time.Now().After(deadline) {
+			return emits
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestBatchProcessorSettingsFromEnv(t *testing.T) {
+	env := func(values map[string]string) func(string) (string, bool) {
+		return func(key string) (string, bool) {
+			value, ok := values[key]
+			return value, ok
+		}
+	}
+
+	settings, err := BatchProcessorSettingsFromEnv(env(nil))
+	if err != nil {
+		t.Fatalf("BatchProcessorSettingsFromEnv() error = %v", err)
+	}
+	if settings != DefaultBatchProcessorSettings() {
+		t.Fatalf("settings without env = %+v, want the SDK defaults", settings)
+	}
+
+	settings, err = BatchProcessorSettingsFromEnv(env(map[string]string{
+		"OTEL_BSP_SCHEDULE_DELAY":        "200",
+		"OTEL_BSP_EXPORT_TIMEOUT":        "0",
+		"OTEL_BSP_MAX_QUEUE_SIZE":        "100",
+		"OTEL_BSP_MAX_EXPORT_BATCH_SIZE": "512",
+	}))
+	if err != nil {
+		t.Fatalf("BatchProcessorSettingsFromEnv() error = %v", err)
+	}
+	want := BatchProcessorSettings{MaxQueueSize: 100, MaxExportBatchSize: 100, ScheduleDelay: 200 * time.Millisecond}
+	if settings != want {
+		t.Fatalf("settings = %+v, want %+v", settings, want)
+	}
+
+	for _, values := range []map[string]string{
+		{"OTEL_BSP_MAX_QUEUE_SIZE": "0"},
+		{"OTEL_BSP_MAX_EXPORT_BATCH_SIZE": "many"},
+		{"OTEL_BSP_SCHEDULE_DELAY": "0"},
+		{"OTEL_BSP_EXPORT_TIMEOUT": "5s"},
+	} {
+		if _, err := BatchProcessorSettingsFromEnv(env(values)); err == nil {
+			t.Fatalf("expected an error for %v", values)
+		}
+	}
+}
+
+func TestBatchProcessorExporterSendsFullBatchesAndFlushesOnShutdown(t *testing.T) {
+	inner := &fakeBatchExporter{}
+	settings := BatchProcessorSettings{MaxQueueSize: 10, MaxExportBatchSize: 3, ScheduleDelay: time.Hour}
+	exporter, err := NewBatchProcessorExporterFactory(fakeFactory{inner: inner}, settings).NewBatchExporter(context.Background())
+	if err != nil {
+		t.Fatalf("NewBatchExporter() error = %v", err)
+	}
+
+	if err := exporter.ExportBatch(context.Background(), batchProcessorTestBatch(7)); err != nil {
+		t.Fatalf("ExportBatch() error = %v", err)
+	}
+	emits := waitForEmits(t, inner, 2)
+	if len(emits) != 2 || len(emits[0].spans) != 3 || len(emits[1].spans) != 3 {
+		t.Fatalf("expected two full batches of 3 spans before the schedule delay, got %d requests", len(emits))
+	}
+
+	if err := exporter.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	emits = inner.snapshot()
+	if len(emits) != 3 || len(emits[2].spans) != 1 {
+		t.Fatalf("expected Shutdown to flush the last span, got %d requests", len(emits))
+	}
+	if inner.shutdown != 1 {
+		t.Fatalf("expected the inner exporter to be shut down once, got %d", inner.shutdown)
+	}
+}
+
+func TestBatchProcessorExporterSendsPartialBatchAfterScheduleDelay(t *testing.T) {
+	inner := &fakeBatchExporter{}
+	settings := BatchProcessorSettings{MaxQueueSize: 10, MaxExportBatchSize: 5, ScheduleDelay: 20 * time.Millisecond}
+	exporter, err := NewBatchProcessorExporterFactory(fakeFactory{inner: inner}, settings).NewBatchExporter(context.Background())
+	if err != nil {
+		t.Fatalf("NewBatchExporter() error = %v", err)
+	}
+	defer func() { _ = exporter.Shutdown(context.Background()) }()
+
+	if err := exporter.ExportBatch(context.Background(), batchProcessorTestBatch(2)); err != nil {
+		t.Fatalf("ExportBatch() error = %v", err)
+	}
+	emits := waitForEmits(t, inner, 1)
+	if len(emits) != 1 || len(emits[0].spans) != 2 {
+		t.Fatalf("expected one request of 2 spans after the schedule delay, got %d requests", len(emits))
+	}
+}
+
+func TestBatchProcessorExporterDropsSpansOnFullQueue(t *testing.T) {
+	// No run loop, so nothing drains the queue.
+	exporter := &batchProcessorExporter{queue: make(chan model.Span, 2), stats: &batchProcessorStats{}}
+	if err := exporter.ExportBatch(context.Background(), batchProcessorTestBatch(5)); err != nil {
+		t.Fatalf("ExportBatch() error = %v", err)
+	}
+	if queued, dropped := exporter.stats.queued.Load(), exporter.stats.dropped.Load(); queued != 2 || dropped != 3 {
+		t.Fatalf("queued=%d dropped=%d, want 2 and 3", queued, dropped)
+	}
+}
+
+func TestBatchProcessorExporterDoesNotReturnExportErrors(t *testing.T) {
+	inner := &fakeBatchExporter{exportErr: errors.New("unavailable")}
+	factory := NewBatchProcessorExporterFactory(fakeFactory{inner: inner}, BatchProcessorSettings{MaxQueueSize: 10, MaxExportBatchSize: 10, ScheduleDelay: time.Hour})
+	exporter, err := factory.NewBatchExporter(context.Background())
+	if err != nil {
+		t.Fatalf("NewBatchExporter() error = %v", err)
+	}
+	if err := exporter.ExportBatch(context.Background(), batchProcessorTestBatch(4)); err != nil {
+		t.Fatalf("ExportBatch() error = %v, want nil as the SDK never reports export errors to callers", err)
+	}
+	if err := exporter.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if failed := factory.stats.failed.Load(); failed != 4 {
+		t.Fatalf("failed spans = %d, want 4", failed)
+	}
+}
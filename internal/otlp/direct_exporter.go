@@ -10,20 +10,24 @@ import (
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/encoding/gzip"
 )
 
 type directBatchExporter struct {
 	client   otlptrace.Client
 	protocol config.Protocol
 	endpoint string
+	grouping config.ResourceGrouping
 }
 
 func (e *directBatchExporter) ExportBatch(ctx context.Context, batch model.Batch) error {
 	if len(batch) == 0 {
 		return nil
 	}
-	resourceSpans := modelBatchToProto(batch)
+	resourceSpans := modelBatchToProto(batch, e.grouping)
 	if len(resourceSpans) == 0 {
 		return nil
 	}
@@ -33,6 +37,15 @@ func (e *directBatchExporter) ExportBatch(ctx context.Context, batch model.Batch
 	return nil
 }
 
+// Warmup sends an empty OTLP export so the underlying connection (gRPC
+// channel or HTTP keep-alive) is established before measurement begins.
+func (e *directBatchExporter) Warmup(ctx context.Context) error {
+	if err := e.client.UploadTraces(ctx, []*tracepb.ResourceSpans{}); err != nil {
+		return fmt.Errorf("warmup export protocol=%s endpoint=%s: %w", e.protocol, e.endpoint, err)
+	}
+	return nil
+}
+
 func (e *directBatchExporter) Shutdown(ctx context.Context) error {
 	if e == nil || e.client == nil {
 		return nil
@@ -44,6 +57,9 @@ func (e *directBatchExporter) Shutdown(ctx context.Context) error {
 }
 
 func (f ExporterFactory) NewBatchExporter(ctx context.Context) (model.BatchExporter, error) {
+	if f.Protocol == config.ProtocolZipkin {
+		return f.newZipkinExporter()
+	}
 	client, err := f.newOTLPClient()
 	if err != nil {
 		return nil, err
@@ -51,7 +67,7 @@ func (f ExporterFactory) NewBatchExporter(ctx context.Context) (model.BatchExpor
 	if err := client.Start(ctx); err != nil {
 		return nil, fmt.Errorf("start otlp client protocol=%s endpoint=%s: %w", f.Protocol, f.Endpoint, err)
 	}
-	return &directBatchExporter{client: client, protocol: f.Protocol, endpoint: f.Endpoint}, nil
+	return &directBatchExporter{client: client, protocol: f.Protocol, endpoint: f.Endpoint, grouping: f.ResourceGrouping}, nil
 }
 
 func (f ExporterFactory) newOTLPClient() (otlptrace.Client, error) {
@@ -74,16 +90,27 @@ func (f ExporterFactory) newOTLPClient() (otlptrace.Client, error) {
 		if f.ExportTimeout > 0 {
 			options = append(options, otlptracehttp.WithTimeout(f.ExportTimeout))
 		}
-		if f.SlowResponseDelay > 0 {
+		if f.DisableRetry {
+			options = append(options, otlptracehttp.WithRetry(otlptracehttp.RetryConfig{Enabled: false}))
+		}
+		if f.Compression == config.CompressionGzip {
+			options = append(options, otlptracehttp.WithCompression(otlptracehttp.GzipCompression))
+		}
+		if f.SlowResponseDelay > 0 || f.Compression == config.CompressionZstd {
 			base := http.DefaultTransport.(*http.Transport).Clone()
 			if tlsCfg, err := f.tlsConfig(); err != nil {
 				return nil, err
 			} else if tlsCfg != nil {
 				base.TLSClientConfig = tlsCfg
 			}
-			options = append(options, otlptracehttp.WithHTTPClient(&http.Client{
-				Transport: &slowRoundTripper{wrapped: base, delay: f.SlowResponseDelay},
-			}))
+			var transport http.RoundTripper = base
+			if f.SlowResponseDelay > 0 {
+				transport = &slowRoundTripper{wrapped: transport, delay: f.SlowResponseDelay}
+			}
+			if f.Compression == config.CompressionZstd {
+				transport = &zstdRoundTripper{wrapped: transport}
+			}
+			options = append(options, otlptracehttp.WithHTTPClient(&http.Client{Transport: transport}))
 		} else if tlsCfg, err := f.tlsConfig(); err != nil {
 			return nil, err
 		} else if tlsCfg != nil {
@@ -106,5 +133,15 @@ func (f ExporterFactory) newOTLPClient() (otlptrace.Client, error) {
 	if f.ExportTimeout > 0 {
 		options = append(options, otlptracegrpc.WithTimeout(f.ExportTimeout))
 	}
+	if f.DisableRetry {
+		options = append(options, otlptracegrpc.WithRetry(otlptracegrpc.RetryConfig{Enabled: false}))
+	}
+	switch f.Compression {
+	case config.CompressionGzip:
+		options = append(options, otlptracegrpc.WithCompressor(gzip.Name))
+	case config.CompressionZstd:
+		// WithCompressor only knows gzip, so zstd is a default call option.
+		options = append(options, otlptracegrpc.WithDialOption(grpc.WithDefaultCallOptions(grpc.UseCompressor(zstdName))))
+	}
 	return otlptracegrpc.NewClient(options...), nil
 }
@@ -3,6 +3,8 @@ package otlp
 import (
 	"context"
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
@@ -85,3 +87,54 @@ func TestDirectBatchExporterShutdownWrapsErrorWithDiagnostics(t *testing.T) {
 		t.Fatalf("expected endpoint in error message, got %q", message)
 	}
 }
+
+func TestDirectBatchExporterWarmupWrapsErrorWithDiagnostics(t *testing.T) {
+	exporter := &directBatchExporter{
+		client:   &fakeOTLPClient{uploadErr: errors.New("connection refused")},
+		protocol: config.ProtocolGRPC,
+		endpoint: "localhost:4317",
+	}
+
+	err := exporter.Warmup(context.Background())
+	if err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+	if !strings.Contains(err.Error(), "warmup export protocol=grpc endpoint=localhost:4317") {
+		t.Fatalf("expected warmup diagnostics in error message, got %q", err.Error())
+	}
+}
+
+func TestDirectBatchExporterGzipCompressesHTTPPayload(t *testing.T) {
+	encodings := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodings <- r.Header.Get("Content-Encoding")
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	exporter, err := (ExporterFactory{
+		Protocol:    config.ProtocolHTTP,
+		Endpoint:    server.URL + "/v1/traces",
+		Insecure:    true,
+		Compression: config.CompressionGzip,
+	}).NewBatchExporter(context.Background())
+	if err != nil {
+		t.Fatalf("NewBatchExporter() error = %v", err)
+	}
+	defer func() { _ = exporter.Shutdown(context.Background()) }()
+
+	span := model.Span{
+		TraceID:   oteltrace.TraceID{0x01},
+		SpanID:    oteltrace.SpanID{0x01},
+		Name:      "GET /",
+		StartTime: time.Now(),
+		EndTime:   time.Now(),
+	}
+	if err := exporter.ExportBatch(context.Background(), model.Batch{span}); err != nil {
+		t.Fatalf("ExportBatch() error = %v", err)
+	}
+	if got := <-encodings; got != "gzip" {
+		t.Fatalf("expected gzip Content-Encoding, got %q", got)
+	}
+}
@@ -8,8 +8,10 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/javiermolinar/tercios/internal/model"
+	"github.com/javiermolinar/tercios/internal/timefmt"
 	"go.opentelemetry.io/otel/attribute"
 )
 
@@ -34,6 +36,9 @@ func ParseDryRunOutput(value string) (DryRunOutput, error) {
 type DryRunExporterFactory struct {
 	Output DryRunOutput
 	Writer io.Writer
+	// TimeFormat controls how timestamps and durations are written in JSON
+	// output. The zero value keeps RFC 3339 UTC timestamps and duration_ms.
+	TimeFormat timefmt.Options
 
 	lock *sync.Mutex
 }
@@ -52,7 +57,7 @@ func NewDryRunExporterFactory(output DryRunOutput, writer io.Writer) DryRunExpor
 func (f DryRunExporterFactory) NewBatchExporter(_ context.Context) (model.BatchExporter, error) {
 	switch f.Output {
 	case DryRunOutputJSON:
-		return &jsonBatchExporter{writer: f.Writer, lock: f.lock}, nil
+		return &jsonBatchExporter{writer: f.Writer, lock: f.lock, timeFormat: f.TimeFormat}, nil
 	case DryRunOutputSummary:
 		fallthrough
 	default:
@@ -71,8 +76,9 @@ func (noopBatchExporter) Shutdown(_ context.Context) error {
 }
 
 type jsonBatchExporter struct {
-	writer io.Writer
-	lock   *sync.Mutex
+	writer     io.Writer
+	lock       *sync.Mutex
+	timeFormat timefmt.Options
 }
 
 func (e *jsonBatchExporter) ExportBatch(_ context.Context, batch model.Batch) error {
@@ -82,7 +88,7 @@ func (e *jsonBatchExporter) ExportBatch(_ context.Context, batch model.Batch) er
 
 	payload := jsonBatch{Spans: make([]jsonSpan, 0, len(batch))}
 	for _, span := range batch {
-		payload.Spans = append(payload.Spans, toJSONSpanFromModel(span))
+		payload.Spans = append(payload.Spans, toJSONSpanFromModel(span, e.timeFormat))
 	}
 
 	return writeJSONBatch(e.writer, e.lock, payload)
@@ -105,24 +111,28 @@ type jsonBatch struct {
 }
 
 type jsonSpan struct {
-	TraceID      string         `json:"trace_id"`
-	SpanID       string         `json:"span_id"`
-	ParentSpanID string         `json:"parent_span_id,omitempty"`
-	Name         string         `json:"name"`
-	Kind         string         `json:"kind"`
-	StartTime    string         `json:"start_time"`
-	EndTime      string         `json:"end_time"`
-	DurationMs   int64          `json:"duration_ms"`
-	Attributes   map[string]any `json:"attributes,omitempty"`
-	Resource     map[string]any `json:"resource,omitempty"`
-	Events       []jsonEvent    `json:"events,omitempty"`
-	Links        []jsonLink     `json:"links,omitempty"`
-	Status       jsonStatus     `json:"status"`
+	TraceID      string `json:"trace_id"`
+	SpanID       string `json:"span_id"`
+	ParentSpanID string `json:"parent_span_id,omitempty"`
+	Name         string `json:"name"`
+	Kind         string `json:"kind"`
+	StartTime    any    `json:"start_time"`
+	EndTime      any    `json:"end_time"`
+	// Exactly one duration field is set, named after the selected unit.
+	DurationMs *int64         `json:"duration_ms,omitempty"`
+	DurationUs *int64         `json:"duration_us,omitempty"`
+	DurationNs *int64         `json:"duration_ns,omitempty"`
+	DurationS  *float64       `json:"duration_s,omitempty"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+	Resource   map[string]any `json:"resource,omitempty"`
+	Events     []jsonEvent    `json:"events,omitempty"`
+	Links      []jsonLink     `json:"links,omitempty"`
+	Status     jsonStatus     `json:"status"`
 }
 
 type jsonEvent struct {
 	Name       string         `json:"name"`
-	Time       string         `json:"time,omitempty"`
+	Time       any            `json:"time,omitempty"`
 	Attributes map[string]any `json:"attributes,omitempty"`
 }
 
@@ -137,41 +147,59 @@ type jsonStatus struct {
 	Message string `json:"message,omitempty"`
 }
 
-func toJSONSpanFromModel(span model.Span) jsonSpan {
+func toJSONSpanFromModel(span model.Span, format timefmt.Options) jsonSpan {
 	parentSpanID := ""
 	if span.ParentSpanID.IsValid() {
 		parentSpanID = span.ParentSpanID.String()
 	}
 
-	return jsonSpan{
+	out := jsonSpan{
 		TraceID:      span.TraceID.String(),
 		SpanID:       span.SpanID.String(),
 		ParentSpanID: parentSpanID,
 		Name:         span.Name,
 		Kind:         span.Kind.String(),
-		StartTime:    span.StartTime.UTC().Format("2006-01-02T15:04:05.000000000Z07:00"),
-		EndTime:      span.EndTime.UTC().Format("2006-01-02T15:04:05.000000000Z07:00"),
-		DurationMs:   span.EndTime.Sub(span.StartTime).Milliseconds(),
+		StartTime:    format.TimeValue(span.StartTime),
+		EndTime:      format.TimeValue(span.EndTime),
 		Attributes:   attributeMapToAnyMap(span.Attributes),
 		Resource:     attributeMapToAnyMap(span.ResourceAttributes),
-		Events:       eventsToJSON(span.Events),
+		Events:       eventsToJSON(span.Events, format),
 		Links:        linksToJSON(span.Links),
 		Status: jsonStatus{
 			Code:    span.StatusCode.String(),
 			Message: span.StatusDescription,
 		},
 	}
+	setJSONDuration(&out, span.EndTime.Sub(span.StartTime), format)
+	return out
+}
+
+func setJSONDuration(span *jsonSpan, duration time.Duration, format timefmt.Options) {
+	switch format.Unit(timefmt.DurationMillis) {
+	case timefmt.DurationMicros:
+		us := duration.Microseconds()
+		span.DurationUs = &us
+	case timefmt.DurationNanoseconds:
+		ns := duration.Nanoseconds()
+		span.DurationNs = &ns
+	case timefmt.DurationSeconds:
+		s := duration.Seconds()
+		span.DurationS = &s
+	default:
+		ms := duration.Milliseconds()
+		span.DurationMs = &ms
+	}
 }
 
-func eventsToJSON(events []model.Event) []jsonEvent {
+func eventsToJSON(events []model.Event, format timefmt.Options) []jsonEvent {
 	if len(events) == 0 {
 		return nil
 	}
 	out := make([]jsonEvent, len(events))
 	for i, e := range events {
-		var t string
+		var t any
 		if !e.Time.IsZero() {
-			t = e.Time.UTC().Format("2006-01-02T15:04:05.000000000Z07:00")
+			t = format.TimeValue(e.Time)
 		}
 		out[i] = jsonEvent{
 			Name:       e.Name,
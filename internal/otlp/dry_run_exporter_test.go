@@ -9,6 +9,7 @@ import (
 
 	"github.com/javiermolinar/tercios/internal/model"
 	"github.com/javiermolinar/tercios/internal/scenario"
+	"github.com/javiermolinar/tercios/internal/timefmt"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	oteltrace "go.opentelemetry.io/otel/trace"
@@ -91,8 +92,8 @@ func TestDryRunJSONBatchExporterWritesBatch(t *testing.T) {
 	if span.Kind != "client" {
 		t.Fatalf("expected kind=client, got %q", span.Kind)
 	}
-	if span.DurationMs != 42 {
-		t.Fatalf("expected duration_ms=42, got %d", span.DurationMs)
+	if span.DurationMs == nil || *span.DurationMs != 42 {
+		t.Fatalf("expected duration_ms=42, got %v", span.DurationMs)
 	}
 	if got := span.Resource["service.name"]; got != "payment-service" {
 		t.Fatalf("expected resource service.name=payment-service, got %#v", got)
@@ -102,6 +103,50 @@ func TestDryRunJSONBatchExporterWritesBatch(t *testing.T) {
 	}
 }
 
+func TestDryRunJSONBatchExporterAppliesTimeFormat(t *testing.T) {
+	start := time.Date(2026, time.March, 1, 10, 0, 0, 0, time.UTC)
+	batch := model.Batch{{
+		TraceID:   oteltrace.TraceID{0x01},
+		SpanID:    oteltrace.SpanID{0x02},
+		Name:      "payment",
+		StartTime: start,
+		EndTime:   start.Add(1500 * time.Microsecond),
+		Events:    []model.Event{{Name: "retry", Time: start.Add(time.Millisecond)}},
+	}}
+
+	var out bytes.Buffer
+	factory := NewDryRunExporterFactory(DryRunOutputJSON, &out)
+	factory.TimeFormat = timefmt.Options{Timestamp: timefmt.TimestampUnixMilli, DurationUnit: timefmt.DurationMicros}
+	exporter, err := factory.NewBatchExporter(context.Background())
+	if err != nil {
+		t.Fatalf("NewBatchExporter() error = %v", err)
+	}
+	if err := exporter.ExportBatch(context.Background(), batch); err != nil {
+		t.Fatalf("ExportBatch() error = %v", err)
+	}
+
+	var payload struct {
+		Spans []map[string]any `json:"spans"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+		t.Fatalf("invalid json output: %v", err)
+	}
+	span := payload.Spans[0]
+	if got := span["start_time"]; got != float64(start.UnixMilli()) {
+		t.Fatalf("expected numeric start_time, got %#v", got)
+	}
+	if got := span["duration_us"]; got != float64(1500) {
+		t.Fatalf("expected duration_us=1500, got %#v", got)
+	}
+	if _, ok := span["duration_ms"]; ok {
+		t.Fatalf("expected duration_ms to be omitted, got %#v", span)
+	}
+	event := span["events"].([]any)[0].(map[string]any)
+	if got := event["time"]; got != float64(start.Add(time.Millisecond).UnixMilli()) {
+		t.Fatalf("expected numeric event time, got %#v", got)
+	}
+}
+
 func TestDryRunJSONExporterWritesDefaultScenarioBatch(t *testing.T) {
 	gen, err := scenario.DefaultGenerator(42)
 	if err != nil {
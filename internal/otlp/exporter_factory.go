@@ -29,9 +29,25 @@ type ExporterFactory struct {
 	SlowResponseDelay time.Duration
 	TLSCACert         string
 	TLSSkipVerify     bool
+	// TLSClientCert and TLSClientKey are PEM files presented to the collector
+	// for mutual TLS. They take precedence over the OTEL client cert env vars.
+	TLSClientCert string
+	TLSClientKey  string
+	// TLSServerName overrides the host name used to verify the collector
+	// certificate, for endpoints reached by IP or through a SAN-mismatched alias.
+	TLSServerName string
+	// Compression selects the payload compression for both protocols. The
+	// empty value and config.CompressionNone send uncompressed payloads.
+	Compression config.Compression
 	// ExportTimeout is forwarded to the OTLP SDK as the per-export timeout.
 	// A value of 0 leaves the SDK default in place (10s for both gRPC and HTTP).
 	ExportTimeout time.Duration
+	// DisableRetry turns off the OTLP SDK's built-in retries. The CLI sets it
+	// when the pipeline retries exports itself, so every attempt is counted.
+	DisableRetry bool
+	// ResourceGrouping shapes the ResourceSpans of each OTLP request. It
+	// has no effect on Zipkin, which has no resources.
+	ResourceGrouping config.ResourceGrouping
 }
 
 func (f ExporterFactory) tlsConfig() (*tls.Config, error) {
@@ -57,10 +73,18 @@ func mergedTLSConfig(
 		}
 		cfg.RootCAs = pool
 	}
+	if f.TLSClientCert != "" || f.TLSClientKey != "" {
+		cert, err := loadClientCertificate(f.TLSClientCert, f.TLSClientKey, readFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
 	if f.TLSSkipVerify {
 		cfg.InsecureSkipVerify = true //nolint:gosec
 	}
-	if cfg.RootCAs == nil && len(cfg.Certificates) == 0 && !cfg.InsecureSkipVerify {
+	cfg.ServerName = f.TLSServerName
+	if cfg.RootCAs == nil && len(cfg.Certificates) == 0 && !cfg.InsecureSkipVerify && cfg.ServerName == "" {
 		return nil, nil
 	}
 	return cfg, nil
@@ -114,6 +138,25 @@ func applyClientCertificateFromEnv(
 	cfg.Certificates = []tls.Certificate{cert}
 }
 
+func loadClientCertificate(certPath, keyPath string, readFile func(string) ([]byte, error)) (tls.Certificate, error) {
+	if certPath == "" || keyPath == "" {
+		return tls.Certificate{}, fmt.Errorf("TLS client cert and key must be set together")
+	}
+	certPEM, err := readFile(certPath)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("read TLS client cert %q: %w", certPath, err)
+	}
+	keyPEM, err := readFile(keyPath)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("read TLS client key %q: %w", keyPath, err)
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("load TLS client key pair: %w", err)
+	}
+	return cert, nil
+}
+
 func loadCertPool(path string, readFile func(string) ([]byte, error)) (*x509.CertPool, error) {
 	pem, err := readFile(path)
 	if err != nil {
@@ -108,6 +108,56 @@ func TestMergedTLSConfig_FallsBackToGenericEnvClientCertificateWhenTraceSpecific
 	}
 }
 
+func TestMergedTLSConfig_ClientCertificateFlagsOverrideEnv(t *testing.T) {
+	envCertPEM, envKeyPEM := testCertificatePairPEM(t)
+	flagCertPEM, flagKeyPEM := testCertificatePairPEM(t)
+
+	dir := t.TempDir()
+	envCertPath := writeTestPEMFile(t, dir, "env-client-cert.pem", envCertPEM)
+	envKeyPath := writeTestPEMFile(t, dir, "env-client-key.pem", envKeyPEM)
+	flagCertPath := writeTestPEMFile(t, dir, "flag-client-cert.pem", flagCertPEM)
+	flagKeyPath := writeTestPEMFile(t, dir, "flag-client-key.pem", flagKeyPEM)
+
+	cfg, err := mergedTLSConfig(
+		ExporterFactory{TLSClientCert: flagCertPath, TLSClientKey: flagKeyPath},
+		testEnvLookup(map[string]string{
+			envOTLPClientCertificate: envCertPath,
+			envOTLPClientKey:         envKeyPath,
+		}),
+		os.ReadFile,
+	)
+	if err != nil {
+		t.Fatalf("mergedTLSConfig() error = %v", err)
+	}
+	if cfg == nil || len(cfg.Certificates) != 1 {
+		t.Fatalf("expected one client certificate, got %+v", cfg)
+	}
+	block, _ := pem.Decode(flagCertPEM)
+	if string(cfg.Certificates[0].Certificate[0]) != string(block.Bytes) {
+		t.Fatalf("expected --tls-cert to take precedence over env client certificate")
+	}
+}
+
+func TestMergedTLSConfig_SetsServerName(t *testing.T) {
+	cfg, err := mergedTLSConfig(ExporterFactory{TLSServerName: "collector.internal"}, testEnvLookup(nil), os.ReadFile)
+	if err != nil {
+		t.Fatalf("mergedTLSConfig() error = %v", err)
+	}
+	if cfg == nil || cfg.ServerName != "collector.internal" {
+		t.Fatalf("expected server name override, got %+v", cfg)
+	}
+}
+
+func TestMergedTLSConfig_RejectsIncompleteClientCertificateFlags(t *testing.T) {
+	certPEM, _ := testCertificatePairPEM(t)
+	certPath := writeTestPEMFile(t, t.TempDir(), "client-cert.pem", certPEM)
+
+	_, err := mergedTLSConfig(ExporterFactory{TLSClientCert: certPath}, testEnvLookup(nil), os.ReadFile)
+	if err == nil || !strings.Contains(err.Error(), "must be set together") {
+		t.Fatalf("expected missing key error, got %v", err)
+	}
+}
+
 func TestNewBatchExporter_HonorsTLSConfigForHTTP(t *testing.T) {
 	_, err := (ExporterFactory{
 		Protocol:  config.ProtocolHTTP,
@@ -0,0 +1,292 @@
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/javiermolinar/tercios/internal/config"
+	"github.com/javiermolinar/tercios/internal/model"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// FileFormat is the encoding of batches written by the file exporter. Both
+// match what the OpenTelemetry Collector's file exporter writes, so the
+// files can be replayed by the collector's otlpjsonfile receiver or other
+// OTLP tooling.
+type FileFormat string
+
+const (
+	// FileFormatJSON writes one OTLP JSON ExportTraceServiceRequest per line.
+	FileFormatJSON FileFormat = "json"
+	// FileFormatProto writes each ExportTraceServiceRequest as protobuf
+	// preceded by its length as a 4-byte big-endian integer.
+	FileFormatProto FileFormat = "proto"
+)
+
+const fileOutputScheme = "file://"
+
+// StdoutOutput is the output value that writes OTLP JSON lines to stdout,
+// for piping into tools that read ptrace JSON. ParseFileOutput returns it
+// as the path StdoutPath.
+const (
+	StdoutOutput = "otlp-json"
+	StdoutPath   = "-"
+)
+
+// ParseFileOutput recognises file://PATH output targets and StdoutOutput.
+// ok is false when value is neither. The format follows FileFormatForPath,
+// and file://- also means stdout.
+func ParseFileOutput(value string) (path string, format FileFormat, ok bool, err error) {
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(value, StdoutOutput) {
+		return StdoutPath, FileFormatJSON, true, nil
+	}
+	if !strings.HasPrefix(strings.ToLower(value), fileOutputScheme) {
+		return "", "", false, nil
+	}
+	path = value[len(fileOutputScheme):]
+	if path == "" {
+		return "", "", true, fmt.Errorf("file output requires a path (file://PATH)")
+	}
+	return path, FileFormatForPath(path), true, nil
+}
+
+// FileFormatForPath picks the format from the file extension: .pb and
+// .binpb are length-prefixed protobuf, anything else is OTLP JSON lines.
+func FileFormatForPath(path string) FileFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".pb", ".binpb":
+		return FileFormatProto
+	default:
+		return FileFormatJSON
+	}
+}
+
+type FileExporterFactory struct {
+	Format FileFormat
+	Writer io.Writer
+	// ResourceGrouping shapes the ResourceSpans of each written request.
+	ResourceGrouping config.ResourceGrouping
+
+	lock *sync.Mutex
+}
+
+// NewFileExporterFactory returns a factory whose exporters all append to
+// writer. The caller owns writer and closes it after the run.
+func NewFileExporterFactory(format FileFormat, writer io.Writer) FileExporterFactory {
+	return FileExporterFactory{
+		Format: format,
+		Writer: writer,
+		lock:   &sync.Mutex{},
+	}
+}
+
+func (f FileExporterFactory) NewBatchExporter(_ context.Context) (model.BatchExporter, error) {
+	if f.Writer == nil {
+		return nil, fmt.Errorf("file exporter writer not configured")
+	}
+	switch f.Format {
+	case FileFormatJSON, FileFormatProto:
+	default:
+		return nil, fmt.Errorf("unsupported file format %q", f.Format)
+	}
+	return &fileBatchExporter{format: f.Format, writer: f.Writer, lock: f.lock, grouping: f.ResourceGrouping}, nil
+}
+
+type fileBatchExporter struct {
+	format   FileFormat
+	writer   io.Writer
+	lock     *sync.Mutex
+	grouping config.ResourceGrouping
+}
+
+func (e *fileBatchExporter) ExportBatch(_ context.Context, batch model.Batch) error {
+	resourceSpans := modelBatchToProto(batch, e.grouping)
+	if len(resourceSpans) == 0 {
+		return nil
+	}
+	request := &coltracepb.ExportTraceServiceRequest{ResourceSpans: resourceSpans}
+
+	var payload []byte
+	var err error
+	if e.format == FileFormatProto {
+		payload, err = encodeLengthPrefixed(request)
+	} else {
+		payload, err = encodeOTLPJSONLine(request)
+	}
+	if err != nil {
+		return fmt.Errorf("encode %s batch: %w", e.format, err)
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if _, err := e.writer.Write(payload); err != nil {
+		return fmt.Errorf("write %s batch: %w", e.format, err)
+	}
+	return nil
+}
+
+func (e *fileBatchExporter) Shutdown(_ context.Context) error {
+	return nil
+}
+
+func encodeLengthPrefixed(request *coltracepb.ExportTraceServiceRequest) ([]byte, error) {
+	body, err := proto.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 4, 4+len(body))
+	binary.BigEndian.PutUint32(out, uint32(len(body)))
+	return append(out, body...), nil
+}
+
+// encodeOTLPJSONLine renders request as OTLP JSON in the byte layout of
+// the collector's ptrace JSON marshaler, so files and stdout output can be
+// fed to collector receivers and pdata-based tools unchanged. protojson
+// already uses the lowerCamelCase field names, proto field order and
+// string int64s OTLP JSON expects, but encodes bytes as base64 where OTLP
+// JSON requires hex trace and span IDs, and its whitespace is randomized,
+// so its output is re-encoded token by token.
+func encodeOTLPJSONLine(request *coltracepb.ExportTraceServiceRequest) ([]byte, error) {
+	// pdata always writes the resource and span status, empty or not.
+	for _, resourceSpans := range request.GetResourceSpans() {
+		if resourceSpans.Resource == nil {
+			resourceSpans.Resource = &resourcepb.Resource{}
+		}
+		for _, scopeSpans := range resourceSpans.GetScopeSpans() {
+			for _, span := range scopeSpans.GetSpans() {
+				if span.Status == nil {
+					span.Status = &tracepb.Status{}
+				}
+			}
+		}
+	}
+	raw, err := protojson.MarshalOptions{UseEnumNumbers: true}.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	out, err := compactOTLPJSON(raw)
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// compactOTLPJSON rewrites protojson output without whitespace, keeping
+// field order and turning base64 trace and span IDs into hex.
+func compactOTLPJSON(raw []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var out bytes.Buffer
+	out.Grow(len(raw))
+	// Each open object or array pushes its member count; objects also
+	// record the key of the value being read.
+	type frame struct {
+		object  bool
+		members int
+		key     string
+	}
+	var stack []frame
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return out.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var top *frame
+		if len(stack) > 0 {
+			top = &stack[len(stack)-1]
+		}
+		if delim, ok := token.(json.Delim); ok && (delim == '}' || delim == ']') {
+			out.WriteByte(byte(delim))
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		key := ""
+		if top != nil {
+			if top.object && top.members%2 == 0 {
+				// An object key.
+				if top.members > 0 {
+					out.WriteByte(',')
+				}
+				top.key, _ = token.(string)
+				top.members++
+				writeJSONString(&out, top.key)
+				out.WriteByte(':')
+				continue
+			}
+			if !top.object && top.members > 0 {
+				out.WriteByte(',')
+			}
+			top.members++
+			if top.object {
+				key = top.key
+			}
+		}
+
+		switch typed := token.(type) {
+		case json.Delim:
+			out.WriteByte(byte(typed))
+			stack = append(stack, frame{object: typed == '{'})
+		case string:
+			if otlpIDFields[key] {
+				decoded, err := base64.StdEncoding.DecodeString(typed)
+				if err != nil {
+					return nil, fmt.Errorf("decode %s: %w", key, err)
+				}
+				typed = hex.EncodeToString(decoded)
+			}
+			writeJSONString(&out, typed)
+		case json.Number:
+			writeJSONNumber(&out, typed)
+		case bool:
+			out.WriteString(strconv.FormatBool(typed))
+		case nil:
+			out.WriteString("null")
+		}
+	}
+}
+
+// writeJSONNumber keeps the two-digit exponent strconv produces (1e-07)
+// where protojson shortens it (1e-7), matching pdata's marshaler.
+func writeJSONNumber(out *bytes.Buffer, number json.Number) {
+	text := number.String()
+	if strings.ContainsAny(text, "eE") {
+		if value, err := number.Float64(); err == nil {
+			text = strconv.FormatFloat(value, 'e', -1, 64)
+		}
+	}
+	out.WriteString(text)
+}
+
+// writeJSONString writes value as a JSON string without escaping HTML
+// characters, like pdata's marshaler.
+func writeJSONString(out *bytes.Buffer, value string) {
+	var encoded bytes.Buffer
+	encoder := json.NewEncoder(&encoded)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(value)
+	out.Write(bytes.TrimSuffix(encoded.Bytes(), []byte("\n")))
+}
+
+// otlpIDFields are the OTLP JSON fields holding hex trace and span IDs
+// instead of protojson's base64 bytes.
+var otlpIDFields = map[string]bool{"traceId": true, "spanId": true, "parentSpanId": true}
@@ -0,0 +1,146 @@
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/model"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func fileExporterTestBatch() model.Batch {
+	start := time.Date(2026, time.January, 27, 12, 0, 0, 0, time.UTC)
+	return model.Batch{{
+		TraceID:            oteltrace.TraceID{0xab, 0xcd},
+		SpanID:             oteltrace.SpanID{0x01, 0x02},
+		ParentSpanID:       oteltrace.SpanID{0x03},
+		Name:               "GET /items",
+		Kind:               oteltrace.SpanKindServer,
+		StartTime:          start,
+		EndTime:            start.Add(10 * time.Millisecond),
+		ResourceAttributes: map[string]attribute.Value{"service.name": attribute.StringValue("api")},
+	}}
+}
+
+func TestParseFileOutput(t *testing.T) {
+	tests := []struct {
+		value  string
+		path   string
+		format FileFormat
+		ok     bool
+	}{
+		{value: "json"},
+		{value: "file://out/traces.json", path: "out/traces.json", format: FileFormatJSON, ok: true},
+		{value: "file:///tmp/corpus.pb", path: "/tmp/corpus.pb", format: FileFormatProto, ok: true},
+		{value: "FILE://corpus.binpb", path: "corpus.binpb", format: FileFormatProto, ok: true},
+		{value: "otlp-json", path: StdoutPath, format: FileFormatJSON, ok: true},
+	}
+	for _, tt := range tests {
+		path, format, ok, err := ParseFileOutput(tt.value)
+		if err != nil {
+			t.Fatalf("ParseFileOutput(%q) error = %v", tt.value, err)
+		}
+		if path != tt.path || format != tt.format || ok != tt.ok {
+			t.Fatalf("ParseFileOutput(%q) = %q, %q, %v", tt.value, path, format, ok)
+		}
+	}
+	if _, _, _, err := ParseFileOutput("file://"); err == nil {
+		t.Fatalf("expected error for file output without a path")
+	}
+}
+
+func TestFileExporterWritesOTLPJSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	exporter, err := NewFileExporterFactory(FileFormatJSON, &buf).NewBatchExporter(context.Background())
+	if err != nil {
+		t.Fatalf("NewBatchExporter() error = %v", err)
+	}
+	for range 2 {
+		if err := exporter.ExportBatch(context.Background(), fileExporterTestBatch()); err != nil {
+			t.Fatalf("ExportBatch() error = %v", err)
+		}
+	}
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("expected one line per batch, got %d", len(lines))
+	}
+	var request struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []struct {
+					TraceID           string `json:"traceId"`
+					SpanID            string `json:"spanId"`
+					ParentSpanID      string `json:"parentSpanId"`
+					Kind              int    `json:"kind"`
+					StartTimeUnixNano string `json:"startTimeUnixNano"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	if err := json.Unmarshal(lines[0], &request); err != nil {
+		t.Fatalf("decode OTLP JSON: %v", err)
+	}
+	span := request.ResourceSpans[0].ScopeSpans[0].Spans[0]
+	if span.TraceID != "abcd0000000000000000000000000000" || span.SpanID != "0102000000000000" || span.ParentSpanID != "0300000000000000" {
+		t.Fatalf("expected hex IDs, got %+v", span)
+	}
+	if span.Kind != 2 {
+		t.Fatalf("expected numeric SPAN_KIND_SERVER (2), got %d", span.Kind)
+	}
+	if span.StartTimeUnixNano != "1769515200000000000" {
+		t.Fatalf("unexpected start time %q", span.StartTimeUnixNano)
+	}
+}
+
+// TestFileExporterMatchesPdataJSONLayout pins the exact bytes of a line:
+// the collector's ptrace JSON marshaler writes fields in proto order,
+// without whitespace, and always includes the span status.
+func TestFileExporterMatchesPdataJSONLayout(t *testing.T) {
+	var buf bytes.Buffer
+	exporter, err := NewFileExporterFactory(FileFormatJSON, &buf).NewBatchExporter(context.Background())
+	if err != nil {
+		t.Fatalf("NewBatchExporter() error = %v", err)
+	}
+	batch := fileExporterTestBatch()
+	batch[0].Attributes = map[string]attribute.Value{"note": attribute.StringValue("a<b>&c"), "ratio": attribute.Float64Value(1e-7)}
+	if err := exporter.ExportBatch(context.Background(), batch); err != nil {
+		t.Fatalf("ExportBatch() error = %v", err)
+	}
+
+	want := `{"resourceSpans":[{"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"api"}}]},"scopeSpans":[{"scope":{"name":"tercios"},"spans":[{"traceId":"abcd0000000000000000000000000000","spanId":"0102000000000000","parentSpanId":"0300000000000000","name":"GET /items","kind":2,"startTimeUnixNano":"1769515200000000000","endTimeUnixNano":"1769515200010000000","attributes":[{"key":"note","value":{"stringValue":"a<b>&c"}},{"key":"ratio","value":{"doubleValue":1e-07}}],"status":{}}]}]}]}` + "\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("unexpected OTLP JSON line:\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func TestFileExporterWritesLengthPrefixedProtobuf(t *testing.T) {
+	var buf bytes.Buffer
+	exporter, err := NewFileExporterFactory(FileFormatProto, &buf).NewBatchExporter(context.Background())
+	if err != nil {
+		t.Fatalf("NewBatchExporter() error = %v", err)
+	}
+	if err := exporter.ExportBatch(context.Background(), fileExporterTestBatch()); err != nil {
+		t.Fatalf("ExportBatch() error = %v", err)
+	}
+
+	data := buf.Bytes()
+	size := binary.BigEndian.Uint32(data[:4])
+	if int(size) != len(data)-4 {
+		t.Fatalf("length prefix %d does not match payload of %d bytes", size, len(data)-4)
+	}
+	var request coltracepb.ExportTraceServiceRequest
+	if err := proto.Unmarshal(data[4:], &request); err != nil {
+		t.Fatalf("decode protobuf: %v", err)
+	}
+	if got := request.ResourceSpans[0].ScopeSpans[0].Spans[0].Name; got != "GET /items" {
+		t.Fatalf("unexpected span name %q", got)
+	}
+}
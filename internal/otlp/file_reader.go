@@ -0,0 +1,164 @@
+package otlp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/javiermolinar/tercios/internal/model"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxProtoRecordSize bounds the length prefix of a protobuf record, so a
+// corrupt or foreign file fails fast instead of allocating gigabytes.
+const maxProtoRecordSize = 256 << 20
+
+// FileReader reads batches back from files written by the file exporter or
+// by the collector's file exporter, one ExportTraceServiceRequest at a time.
+type FileReader struct {
+	format FileFormat
+	reader *bufio.Reader
+}
+
+func NewFileReader(r io.Reader, format FileFormat) *FileReader {
+	return &FileReader{format: format, reader: bufio.NewReaderSize(r, 1<<20)}
+}
+
+// Next returns the spans of the next request in the file, or io.EOF after
+// the last one.
+func (r *FileReader) Next() (model.Batch, error) {
+	if r.format == FileFormatProto {
+		return r.nextProto()
+	}
+	return r.nextJSON()
+}
+
+func (r *FileReader) nextProto() (model.Batch, error) {
+	var prefix [4]byte
+	if _, err := io.ReadFull(r.reader, prefix[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("read record length: %w", err)
+		}
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(prefix[:])
+	if size > maxProtoRecordSize {
+		return nil, fmt.Errorf("record of %d bytes exceeds the %d byte limit; is this a length-prefixed OTLP file?", size, maxProtoRecordSize)
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r.reader, body); err != nil {
+		return nil, fmt.Errorf("read record body: %w", noEOF(err))
+	}
+	var request coltracepb.ExportTraceServiceRequest
+	if err := proto.Unmarshal(body, &request); err != nil {
+		return nil, fmt.Errorf("decode protobuf record: %w", err)
+	}
+	return protoToModelBatch(request.GetResourceSpans()), nil
+}
+
+func (r *FileReader) nextJSON() (model.Batch, error) {
+	for {
+		line, err := r.reader.ReadBytes('\n')
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			request, decodeErr := decodeOTLPJSON(line)
+			if decodeErr != nil {
+				return nil, fmt.Errorf("decode OTLP JSON line: %w", decodeErr)
+			}
+			return protoToModelBatch(request.GetResourceSpans()), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// decodeOTLPJSON is the inverse of encodeOTLPJSONLine: hex trace and span
+// IDs are turned back into the base64 protojson expects.
+func decodeOTLPJSON(line []byte) (*coltracepb.ExportTraceServiceRequest, error) {
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	decoder.UseNumber()
+	var document any
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+	if err := base64EncodeIDs(document); err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+	var request coltracepb.ExportTraceServiceRequest
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(raw, &request); err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+func base64EncodeIDs(value any) error {
+	switch typed := value.(type) {
+	case map[string]any:
+		for key, field := range typed {
+			if encoded, ok := field.(string); ok && otlpIDFields[key] {
+				decoded, err := hex.DecodeString(encoded)
+				if err != nil {
+					return fmt.Errorf("decode %s %q: %w", key, encoded, err)
+				}
+				typed[key] = base64.StdEncoding.EncodeToString(decoded)
+				continue
+			}
+			if err := base64EncodeIDs(field); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, item := range typed {
+			if err := base64EncodeIDs(item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ReadFile reads every batch of the file at path, picking the format with
+// FileFormatForPath.
+func ReadFile(path string) ([]model.Batch, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	reader := NewFileReader(file, FileFormatForPath(path))
+	var batches []model.Batch
+	for {
+		batch, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return batches, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if len(batch) > 0 {
+			batches = append(batches, batch)
+		}
+	}
+}
+
+func noEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
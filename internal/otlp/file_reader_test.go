@@ -0,0 +1,69 @@
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestFileReaderRoundTripsFileExporterOutput(t *testing.T) {
+	for _, format := range []FileFormat{FileFormatJSON, FileFormatProto} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			exporter, err := NewFileExporterFactory(format, &buf).NewBatchExporter(context.Background())
+			if err != nil {
+				t.Fatalf("NewBatchExporter() error = %v", err)
+			}
+			for range 2 {
+				if err := exporter.ExportBatch(context.Background(), fileExporterTestBatch()); err != nil {
+					t.Fatalf("ExportBatch() error = %v", err)
+				}
+			}
+
+			reader := NewFileReader(&buf, format)
+			want := fileExporterTestBatch()[0]
+			for i := range 2 {
+				batch, err := reader.Next()
+				if err != nil {
+					t.Fatalf("Next() #%d error = %v", i, err)
+				}
+				if len(batch) != 1 {
+					t.Fatalf("expected 1 span, got %d", len(batch))
+				}
+				got := batch[0]
+				if got.TraceID != want.TraceID || got.SpanID != want.SpanID || got.ParentSpanID != want.ParentSpanID {
+					t.Fatalf("IDs not preserved: got %s/%s/%s", got.TraceID, got.SpanID, got.ParentSpanID)
+				}
+				if got.Name != want.Name || got.Kind != want.Kind {
+					t.Fatalf("unexpected span %q kind %v", got.Name, got.Kind)
+				}
+				if !got.StartTime.Equal(want.StartTime) || !got.EndTime.Equal(want.EndTime) {
+					t.Fatalf("timestamps not preserved: %s - %s", got.StartTime, got.EndTime)
+				}
+				if got.ResourceAttributes["service.name"].AsString() != "api" {
+					t.Fatalf("resource attributes not preserved: %v", got.ResourceAttributes)
+				}
+			}
+			if _, err := reader.Next(); !errors.Is(err, io.EOF) {
+				t.Fatalf("expected io.EOF after the last batch, got %v", err)
+			}
+		})
+	}
+}
+
+func TestFileReaderRejectsTruncatedProtobuf(t *testing.T) {
+	reader := NewFileReader(bytes.NewReader([]byte{0, 0, 0, 10, 1, 2}), FileFormatProto)
+	if _, err := reader.Next(); err == nil || errors.Is(err, io.EOF) {
+		t.Fatalf("expected truncation error, got %v", err)
+	}
+}
+
+func TestFileReaderRejectsInvalidJSONLine(t *testing.T) {
+	reader := NewFileReader(strings.NewReader(`{"resourceSpans":[{"scopeSpans":[{"spans":[{"traceId":"zz"}]}]}]}`+"\n"), FileFormatJSON)
+	if _, err := reader.Next(); err == nil {
+		t.Fatalf("expected error for non-hex trace ID")
+	}
+}
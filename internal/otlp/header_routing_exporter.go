@@ -0,0 +1,168 @@
+package otlp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/javiermolinar/tercios/internal/config"
+	"github.com/javiermolinar/tercios/internal/model"
+)
+
+// maxRoutedExporters bounds the distinct header value combinations of one
+// routing exporter, each of which holds its own connection, so a mapping
+// on a high-cardinality attribute fails instead of exhausting sockets.
+const maxRoutedExporters = 256
+
+// HeaderRoutingExporterFactory wraps an ExporterFactory so that every
+// batch is split by the values its spans give the mapped headers, and each
+// part is sent with those headers. gRPC sends them as metadata. The CLI
+// installs this wrapper when endpoint header mappings are configured.
+type HeaderRoutingExporterFactory struct {
+	Base     ExporterFactory
+	Mappings []config.HeaderMapping
+}
+
+func NewHeaderRoutingExporterFactory(base ExporterFactory, mappings []config.HeaderMapping) HeaderRoutingExporterFactory {
+	return HeaderRoutingExporterFactory{Base: base, Mappings: mappings}
+}
+
+// NewBatchExporter returns an exporter that opens one inner exporter per
+// distinct combination of header values, on first use.
+func (f HeaderRoutingExporterFactory) NewBatchExporter(ctx context.Context) (model.BatchExporter, error) {
+	e := &headerRoutingExporter{factory: f, exporters: map[string]model.BatchExporter{}}
+	// The exporter without mapped headers is opened up front, so
+	// connection errors surface when the exporter is created.
+	if _, err := e.exporter(ctx, nil); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+type headerRoutingExporter struct {
+	factory HeaderRoutingExporterFactory
+
+	mu        sync.Mutex
+	exporters map[string]model.BatchExporter
+}
+
+func (e *headerRoutingExporter) ExportBatch(ctx context.Context, batch model.Batch) error {
+	type group struct {
+		headers map[string]string
+		spans   model.Batch
+	}
+	groups := map[string]*group{}
+	var order []string
+	for _, span := range batch {
+		headers := e.headers(span)
+		key := routingKey(headers)
+		g, ok := groups[key]
+		if !ok {
+			g = &group{headers: headers}
+			groups[key] = g
+			order = append(order, key)
+		}
+		g.spans = append(g.spans, span)
+	}
+	var errs []error
+	for _, key := range order {
+		exporter, err := e.exporter(ctx, groups[key].headers)
+		if err == nil {
+			err = exporter.ExportBatch(ctx, groups[key].spans)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// headers returns the mapped header values of span, without the headers
+// the span has no value for.
+func (e *headerRoutingExporter) headers(span model.Span) map[string]string {
+	headers := map[string]string{}
+	for _, mapping := range e.factory.Mappings {
+		attributes := span.ResourceAttributes
+		if mapping.Source == config.HeaderMappingSourceSpan {
+			attributes = span.Attributes
+		}
+		value := mapping.Default
+		if attribute, ok := attributes[mapping.Attribute]; ok {
+			value = attribute.Emit()
+		}
+		if value != "" {
+			headers[mapping.Header] = value
+		}
+	}
+	return headers
+}
+
+func routingKey(headers map[string]string) string {
+	var b strings.Builder
+	for _, name := range slices.Sorted(maps.Keys(headers)) {
+		b.WriteString(name)
+		b.WriteByte(0)
+		b.WriteString(headers[name])
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+// exporter returns the inner exporter for headers, opening it with the
+// static headers of the base factory overridden by headers.
+func (e *headerRoutingExporter) exporter(ctx context.Context, headers map[string]string) (model.BatchExporter, error) {
+	key := routingKey(headers)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if exporter, ok := e.exporters[key]; ok {
+		return exporter, nil
+	}
+	if len(e.exporters) == maxRoutedExporters {
+		return nil, fmt.Errorf("header mappings produced more than %d distinct header values; map a lower-cardinality attribute", maxRoutedExporters)
+	}
+	factory := e.factory.Base
+	factory.Headers = maps.Clone(factory.Headers)
+	if factory.Headers == nil {
+		factory.Headers = map[string]string{}
+	}
+	for name, value := range headers {
+		for existing := range factory.Headers {
+			if strings.EqualFold(existing, name) {
+				delete(factory.Headers, existing)
+			}
+		}
+		factory.Headers[name] = value
+	}
+	exporter, err := factory.NewBatchExporter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	e.exporters[key] = exporter
+	return exporter, nil
+}
+
+// Warmup warms up the exporter without mapped headers.
+func (e *headerRoutingExporter) Warmup(ctx context.Context) error {
+	exporter, err := e.exporter(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if warmer, ok := exporter.(model.Warmer); ok {
+		return warmer.Warmup(ctx)
+	}
+	return nil
+}
+
+func (e *headerRoutingExporter) Shutdown(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var errs []error
+	for _, exporter := range e.exporters {
+		errs = append(errs, exporter.Shutdown(ctx))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,95 @@
+package otlp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/config"
+	"github.com/javiermolinar/tercios/internal/model"
+	"go.opentelemetry.io/otel/attribute"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestHeaderRoutingExporterSplitsBatchesByMappedHeaders(t *testing.T) {
+	var mu sync.Mutex
+	spansByTenant := map[string]int{}
+	var auth []string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var request coltracepb.ExportTraceServiceRequest
+		if err := proto.Unmarshal(body, &request); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		mu.Lock()
+		spansByTenant[r.Header.Get("X-Scope-OrgID")] += len(protoToModelBatch(request.GetResourceSpans()))
+		auth = append(auth, r.Header.Get("Authorization"))
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+
+	base := ExporterFactory{
+		Protocol: config.ProtocolHTTP,
+		Endpoint: collector.URL + "/v1/traces",
+		Insecure: true,
+		Headers:  map[string]string{"Authorization": "Bearer token", "x-scope-orgid": "static"},
+	}
+	factory := NewHeaderRoutingExporterFactory(base, []config.HeaderMapping{
+		{Header: "X-Scope-OrgID", Attribute: "tenant.id"},
+	})
+	exporter, err := factory.NewBatchExporter(context.Background())
+	if err != nil {
+		t.Fatalf("NewBatchExporter() error = %v", err)
+	}
+
+	tenant := func(id string) model.Span {
+		span := makeSpan("GET /items", time.Millisecond, time.Date(2026, time.January, 27, 12, 0, 0, 0, time.UTC))
+		span.ResourceAttributes = map[string]attribute.Value{}
+		if id != "" {
+			span.ResourceAttributes["tenant.id"] = attribute.StringValue(id)
+		}
+		return span
+	}
+	batch := model.Batch{tenant("a"), tenant("b"), tenant("a"), tenant("")}
+	if err := exporter.ExportBatch(context.Background(), batch); err != nil {
+		t.Fatalf("ExportBatch() error = %v", err)
+	}
+	if err := exporter.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	want := map[string]int{"a": 2, "b": 1, "static": 1}
+	if len(spansByTenant) != len(want) {
+		t.Fatalf("spans by X-Scope-OrgID = %v, want %v", spansByTenant, want)
+	}
+	for tenant, spans := range want {
+		if spansByTenant[tenant] != spans {
+			t.Fatalf("spans by X-Scope-OrgID = %v, want %v", spansByTenant, want)
+		}
+	}
+	for _, value := range auth {
+		if value != "Bearer token" {
+			t.Fatalf("expected every request to keep the static Authorization header, got %v", auth)
+		}
+	}
+}
+
+func TestHeaderRoutingExporterUsesDefaultAndSpanAttributes(t *testing.T) {
+	e := &headerRoutingExporter{factory: HeaderRoutingExporterFactory{Mappings: []config.HeaderMapping{
+		{Header: "X-Tenant", Attribute: "tenant", Source: config.HeaderMappingSourceSpan, Default: "anonymous"},
+		{Header: "X-Region", Attribute: "cloud.region"},
+	}}}
+	span := model.Span{Attributes: map[string]attribute.Value{"tenant": attribute.IntValue(42)}}
+	if got := e.headers(span); len(got) != 1 || got["X-Tenant"] != "42" {
+		t.Fatalf("headers() = %v, want X-Tenant=42 only", got)
+	}
+	if got := e.headers(model.Span{}); len(got) != 1 || got["X-Tenant"] != "anonymous" {
+		t.Fatalf("headers() = %v, want the default X-Tenant", got)
+	}
+}
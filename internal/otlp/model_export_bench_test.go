@@ -16,7 +16,7 @@ func BenchmarkModelExportPath_ToProto(b *testing.B) {
 	benchmarkModelBatchSizes(b, func(b *testing.B, batch model.Batch) {
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			resourceSpans := modelBatchToProto(batch)
+			resourceSpans := modelBatchToProto(batch, "")
 			if len(resourceSpans) == 0 {
 				b.Fatalf("expected non-empty resource spans")
 			}
@@ -4,19 +4,51 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/javiermolinar/tercios/internal/config"
 	"github.com/javiermolinar/tercios/internal/model"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	oteltrace "go.opentelemetry.io/otel/trace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
 	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
 	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
 	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
 )
 
-func modelBatchToProto(batch model.Batch) []*tracepb.ResourceSpans {
+// MarshalBatch encodes batch as the protobuf ExportTraceServiceRequest
+// body an OTLP exporter sends for it with the default resource grouping.
+func MarshalBatch(batch model.Batch) ([]byte, error) {
+	return proto.Marshal(&coltracepb.ExportTraceServiceRequest{ResourceSpans: modelBatchToProto(batch, config.ResourceGroupingResource)})
+}
+
+// modelBatchToProto converts batch into the ResourceSpans of one request,
+// laid out as grouping says; the empty value groups by resource.
+func modelBatchToProto(batch model.Batch, grouping config.ResourceGrouping) []*tracepb.ResourceSpans {
 	if len(batch) == 0 {
 		return nil
 	}
+	switch grouping {
+	case config.ResourceGroupingSpan:
+		out := make([]*tracepb.ResourceSpans, 0, len(batch))
+		for _, span := range batch {
+			out = append(out, resourceSpansProto(span.ResourceAttributes, []*tracepb.Span{modelSpanToProto(span)}))
+		}
+		return out
+	case config.ResourceGroupingSingle:
+		// On conflicting values the first span's resource wins.
+		merged := map[string]attribute.Value{}
+		spans := make([]*tracepb.Span, 0, len(batch))
+		for _, span := range batch {
+			for key, value := range span.ResourceAttributes {
+				if _, exists := merged[key]; !exists {
+					merged[key] = value
+				}
+			}
+			spans = append(spans, modelSpanToProto(span))
+		}
+		return []*tracepb.ResourceSpans{resourceSpansProto(merged, spans)}
+	}
 
 	type grouped struct {
 		resourceAttrs map[string]attribute.Value
@@ -39,17 +71,21 @@ func modelBatchToProto(batch model.Batch) []*tracepb.ResourceSpans {
 	out := make([]*tracepb.ResourceSpans, 0, len(order))
 	for _, key := range order {
 		group := groups[key]
-		out = append(out, &tracepb.ResourceSpans{
-			Resource: &resourcepb.Resource{Attributes: attrsFromValueMap(group.resourceAttrs)},
-			ScopeSpans: []*tracepb.ScopeSpans{{
-				Scope: &commonpb.InstrumentationScope{Name: "tercios"},
-				Spans: group.spans,
-			}},
-		})
+		out = append(out, resourceSpansProto(group.resourceAttrs, group.spans))
 	}
 	return out
 }
 
+func resourceSpansProto(resourceAttrs map[string]attribute.Value, spans []*tracepb.Span) *tracepb.ResourceSpans {
+	return &tracepb.ResourceSpans{
+		Resource: &resourcepb.Resource{Attributes: attrsFromValueMap(resourceAttrs)},
+		ScopeSpans: []*tracepb.ScopeSpans{{
+			Scope: &commonpb.InstrumentationScope{Name: "tercios"},
+			Spans: spans,
+		}},
+	}
+}
+
 func modelSpanToProto(span model.Span) *tracepb.Span {
 	pb := &tracepb.Span{
 		TraceId:           append([]byte(nil), span.TraceID[:]...),
@@ -1,16 +1,49 @@
 package otlp
 
 import (
+	"context"
+	"reflect"
 	"testing"
 	"time"
 
+	"github.com/javiermolinar/tercios/internal/config"
 	"github.com/javiermolinar/tercios/internal/model"
+	"github.com/javiermolinar/tercios/internal/scenario"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	oteltrace "go.opentelemetry.io/otel/trace"
 	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
 )
 
+func TestModelBatchToProtoGroupsDefaultScenarioByService(t *testing.T) {
+	generator, err := scenario.DefaultGenerator(1)
+	if err != nil {
+		t.Fatalf("DefaultGenerator() error = %v", err)
+	}
+	batch, err := generator.GenerateBatch(context.Background())
+	if err != nil {
+		t.Fatalf("GenerateBatch() error = %v", err)
+	}
+	services := map[string]int{}
+	for _, span := range batch {
+		services[span.ResourceAttributes["service.name"].AsString()]++
+	}
+	if len(services) < 2 {
+		t.Fatalf("expected the default scenario to span several services, got %v", services)
+	}
+
+	resourceSpans := modelBatchToProto(batch, "")
+	if len(resourceSpans) != len(services) {
+		t.Fatalf("expected one resource per service (%d), got %d", len(services), len(resourceSpans))
+	}
+	for _, rs := range resourceSpans {
+		name := valueMapFromProto(rs.GetResource().GetAttributes())["service.name"].AsString()
+		if got := len(rs.GetScopeSpans()[0].GetSpans()); got != services[name] {
+			t.Fatalf("resource %q holds %d spans, want %d", name, got, services[name])
+		}
+	}
+}
+
 func TestModelBatchToProto(t *testing.T) {
 	traceID := oteltrace.TraceID{0x01}
 	spanID := oteltrace.SpanID{0x02}
@@ -31,7 +64,7 @@ func TestModelBatchToProto(t *testing.T) {
 		StatusCode: codes.Ok,
 	}}
 
-	resourceSpans := modelBatchToProto(batch)
+	resourceSpans := modelBatchToProto(batch, "")
 	if len(resourceSpans) != 1 {
 		t.Fatalf("expected 1 resource spans, got %d", len(resourceSpans))
 	}
@@ -55,3 +88,48 @@ func TestModelBatchToProto(t *testing.T) {
 		t.Fatalf("expected status OK, got %s", pbSpan.Status.GetCode())
 	}
 }
+
+func TestModelBatchToProtoResourceGrouping(t *testing.T) {
+	resource := func(service, zone string) map[string]attribute.Value {
+		return map[string]attribute.Value{
+			"service.name": attribute.StringValue(service),
+			"cloud.zone":   attribute.StringValue(zone),
+		}
+	}
+	batch := model.Batch{
+		{SpanID: oteltrace.SpanID{1}, Name: "a", ResourceAttributes: resource("api", "eu-1")},
+		{SpanID: oteltrace.SpanID{2}, Name: "b", ResourceAttributes: resource("api", "eu-1")},
+		{SpanID: oteltrace.SpanID{3}, Name: "c", ResourceAttributes: resource("db", "eu-2")},
+	}
+
+	tests := []struct {
+		grouping config.ResourceGrouping
+		want     []int
+	}{
+		{grouping: "", want: []int{2, 1}},
+		{grouping: config.ResourceGroupingResource, want: []int{2, 1}},
+		{grouping: config.ResourceGroupingSpan, want: []int{1, 1, 1}},
+		{grouping: config.ResourceGroupingSingle, want: []int{3}},
+	}
+	for _, tt := range tests {
+		resourceSpans := modelBatchToProto(batch, tt.grouping)
+		got := make([]int, 0, len(resourceSpans))
+		for _, rs := range resourceSpans {
+			got = append(got, len(rs.GetScopeSpans()[0].GetSpans()))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("grouping %q: spans per resource = %v, want %v", tt.grouping, got, tt.want)
+		}
+	}
+
+	// A merged resource keeps the first span's value on conflicts.
+	merged := valueMapFromProto(modelBatchToProto(batch, config.ResourceGroupingSingle)[0].GetResource().GetAttributes())
+	if merged["service.name"].AsString() != "api" || merged["cloud.zone"].AsString() != "eu-1" || len(merged) != 2 {
+		t.Fatalf("unexpected merged resource %v", merged)
+	}
+	// One resource per span repeats it in span order.
+	perSpan := modelBatchToProto(batch, config.ResourceGroupingSpan)
+	if name := valueMapFromProto(perSpan[2].GetResource().GetAttributes())["service.name"].AsString(); name != "db" {
+		t.Fatalf("expected the third resource to be db, got %q", name)
+	}
+}
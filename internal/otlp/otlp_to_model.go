@@ -0,0 +1,230 @@
+package otlp
+
+import (
+	"fmt"
+	"mime"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/model"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// DecodeExportRequest returns the spans of an OTLP/HTTP trace export body:
+// OTLP JSON when contentType is application/json, protobuf otherwise.
+func DecodeExportRequest(body []byte, contentType string) (model.Batch, error) {
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == "application/json" {
+		request, err := decodeOTLPJSON(body)
+		if err != nil {
+			return nil, fmt.Errorf("decode OTLP JSON: %w", err)
+		}
+		return protoToModelBatch(request.GetResourceSpans()), nil
+	}
+	var request coltracepb.ExportTraceServiceRequest
+	if err := proto.Unmarshal(body, &request); err != nil {
+		return nil, fmt.Errorf("decode OTLP protobuf: %w", err)
+	}
+	return protoToModelBatch(request.GetResourceSpans()), nil
+}
+
+// protoToModelBatch is the inverse of modelBatchToProto. Resource
+// attributes are copied onto every span of their resource; instrumentation
+// scopes are dropped, since model.Span does not carry them.
+func protoToModelBatch(resourceSpans []*tracepb.ResourceSpans) model.Batch {
+	var batch model.Batch
+	for _, rs := range resourceSpans {
+		resourceAttrs := valueMapFromProto(rs.GetResource().GetAttributes())
+		for _, ss := range rs.GetScopeSpans() {
+			for _, span := range ss.GetSpans() {
+				batch = append(batch, protoSpanToModel(span, resourceAttrs))
+			}
+		}
+	}
+	return batch
+}
+
+func protoSpanToModel(pb *tracepb.Span, resourceAttrs map[string]attribute.Value) model.Span {
+	span := model.Span{
+		Name:               pb.GetName(),
+		Kind:               spanKindFromProto(pb.GetKind()),
+		StartTime:          unixNanoToTime(pb.GetStartTimeUnixNano()),
+		EndTime:            unixNanoToTime(pb.GetEndTimeUnixNano()),
+		Attributes:         valueMapFromProto(pb.GetAttributes()),
+		ResourceAttributes: resourceAttrs,
+		Events:             eventsFromProto(pb.GetEvents()),
+		Links:              linksFromProto(pb.GetLinks()),
+		StatusCode:         statusCodeFromProto(pb.GetStatus().GetCode()),
+		StatusDescription:  pb.GetStatus().GetMessage(),
+	}
+	copy(span.TraceID[:], pb.GetTraceId())
+	copy(span.SpanID[:], pb.GetSpanId())
+	copy(span.ParentSpanID[:], pb.GetParentSpanId())
+	return span
+}
+
+func unixNanoToTime(nanos uint64) time.Time {
+	return time.Unix(0, int64(nanos)).UTC()
+}
+
+func eventsFromProto(events []*tracepb.Span_Event) []model.Event {
+	if len(events) == 0 {
+		return nil
+	}
+	out := make([]model.Event, 0, len(events))
+	for _, event := range events {
+		out = append(out, model.Event{
+			Name:       event.GetName(),
+			Time:       unixNanoToTime(event.GetTimeUnixNano()),
+			Attributes: keyValuesFromProto(event.GetAttributes()),
+		})
+	}
+	return out
+}
+
+func linksFromProto(links []*tracepb.Span_Link) []model.Link {
+	if len(links) == 0 {
+		return nil
+	}
+	out := make([]model.Link, 0, len(links))
+	for _, link := range links {
+		var traceID oteltrace.TraceID
+		var spanID oteltrace.SpanID
+		copy(traceID[:], link.GetTraceId())
+		copy(spanID[:], link.GetSpanId())
+		config := oteltrace.SpanContextConfig{TraceID: traceID, SpanID: spanID}
+		if state, err := oteltrace.ParseTraceState(link.GetTraceState()); err == nil {
+			config.TraceState = state
+		}
+		out = append(out, model.Link{
+			SpanContext: oteltrace.NewSpanContext(config),
+			Attributes:  keyValuesFromProto(link.GetAttributes()),
+		})
+	}
+	return out
+}
+
+func keyValuesFromProto(attributes []*commonpb.KeyValue) []attribute.KeyValue {
+	if len(attributes) == 0 {
+		return nil
+	}
+	out := make([]attribute.KeyValue, 0, len(attributes))
+	for _, kv := range attributes {
+		out = append(out, attribute.KeyValue{Key: attribute.Key(kv.GetKey()), Value: attributeValueFromAny(kv.GetValue())})
+	}
+	return out
+}
+
+func valueMapFromProto(attributes []*commonpb.KeyValue) map[string]attribute.Value {
+	out := make(map[string]attribute.Value, len(attributes))
+	for _, kv := range attributes {
+		out[kv.GetKey()] = attributeValueFromAny(kv.GetValue())
+	}
+	return out
+}
+
+// attributeValueFromAny converts an OTLP AnyValue. Arrays become typed
+// slices when every element has the first element's type; anything the
+// attribute package cannot hold (maps, bytes, mixed arrays) is kept as its
+// string form.
+func attributeValueFromAny(value *commonpb.AnyValue) attribute.Value {
+	switch typed := value.GetValue().(type) {
+	case *commonpb.AnyValue_StringValue:
+		return attribute.StringValue(typed.StringValue)
+	case *commonpb.AnyValue_BoolValue:
+		return attribute.BoolValue(typed.BoolValue)
+	case *commonpb.AnyValue_IntValue:
+		return attribute.Int64Value(typed.IntValue)
+	case *commonpb.AnyValue_DoubleValue:
+		return attribute.Float64Value(typed.DoubleValue)
+	case *commonpb.AnyValue_ArrayValue:
+		if converted, ok := attributeSliceFromArray(typed.ArrayValue.GetValues()); ok {
+			return converted
+		}
+	case nil:
+		return attribute.StringValue("")
+	}
+	return attribute.StringValue(value.String())
+}
+
+func attributeSliceFromArray(values []*commonpb.AnyValue) (attribute.Value, bool) {
+	if len(values) == 0 {
+		return attribute.StringSliceValue(nil), true
+	}
+	switch values[0].GetValue().(type) {
+	case *commonpb.AnyValue_StringValue:
+		out := make([]string, 0, len(values))
+		for _, value := range values {
+			typed, ok := value.GetValue().(*commonpb.AnyValue_StringValue)
+			if !ok {
+				return attribute.Value{}, false
+			}
+			out = append(out, typed.StringValue)
+		}
+		return attribute.StringSliceValue(out), true
+	case *commonpb.AnyValue_BoolValue:
+		out := make([]bool, 0, len(values))
+		for _, value := range values {
+			typed, ok := value.GetValue().(*commonpb.AnyValue_BoolValue)
+			if !ok {
+				return attribute.Value{}, false
+			}
+			out = append(out, typed.BoolValue)
+		}
+		return attribute.BoolSliceValue(out), true
+	case *commonpb.AnyValue_IntValue:
+		out := make([]int64, 0, len(values))
+		for _, value := range values {
+			typed, ok := value.GetValue().(*commonpb.AnyValue_IntValue)
+			if !ok {
+				return attribute.Value{}, false
+			}
+			out = append(out, typed.IntValue)
+		}
+		return attribute.Int64SliceValue(out), true
+	case *commonpb.AnyValue_DoubleValue:
+		out := make([]float64, 0, len(values))
+		for _, value := range values {
+			typed, ok := value.GetValue().(*commonpb.AnyValue_DoubleValue)
+			if !ok {
+				return attribute.Value{}, false
+			}
+			out = append(out, typed.DoubleValue)
+		}
+		return attribute.Float64SliceValue(out), true
+	default:
+		return attribute.Value{}, false
+	}
+}
+
+func spanKindFromProto(kind tracepb.Span_SpanKind) oteltrace.SpanKind {
+	switch kind {
+	case tracepb.Span_SPAN_KIND_SERVER:
+		return oteltrace.SpanKindServer
+	case tracepb.Span_SPAN_KIND_CLIENT:
+		return oteltrace.SpanKindClient
+	case tracepb.Span_SPAN_KIND_PRODUCER:
+		return oteltrace.SpanKindProducer
+	case tracepb.Span_SPAN_KIND_CONSUMER:
+		return oteltrace.SpanKindConsumer
+	case tracepb.Span_SPAN_KIND_INTERNAL:
+		return oteltrace.SpanKindInternal
+	default:
+		return oteltrace.SpanKindUnspecified
+	}
+}
+
+func statusCodeFromProto(code tracepb.Status_StatusCode) codes.Code {
+	switch code {
+	case tracepb.Status_STATUS_CODE_OK:
+		return codes.Ok
+	case tracepb.Status_STATUS_CODE_ERROR:
+		return codes.Error
+	default:
+		return codes.Unset
+	}
+}
@@ -17,6 +17,9 @@ type preflightClient interface {
 
 func RunPreflight(ctx context.Context, factory ExporterFactory, exportTimeout time.Duration) error {
 	newClient := func() (preflightClient, error) {
+		if factory.Protocol == config.ProtocolZipkin {
+			return factory.newZipkinExporter()
+		}
 		return factory.newOTLPClient()
 	}
 	return runPreflight(ctx, factory.Protocol, factory.Endpoint, exportTimeout, newClient)
@@ -0,0 +1,207 @@
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+
+	"github.com/javiermolinar/tercios/internal/config"
+	"github.com/javiermolinar/tercios/internal/model"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// OversizeAction is what a size-limited exporter does with a request over
+// the limit.
+type OversizeAction string
+
+const (
+	// OversizeWarn sends the request anyway and logs a warning, so the
+	// endpoint's own limit can be observed.
+	OversizeWarn OversizeAction = "warn"
+	// OversizeSplit sends the batch as several requests under the limit.
+	OversizeSplit OversizeAction = "split"
+)
+
+func ParseOversizeAction(value string) (OversizeAction, error) {
+	switch action := OversizeAction(value); action {
+	case OversizeWarn, OversizeSplit:
+		return action, nil
+	default:
+		return "", fmt.Errorf("unsupported oversize action %q (warn or split)", value)
+	}
+}
+
+// sizeLimitStats is shared by every exporter of one factory.
+type sizeLimitStats struct {
+	open      atomic.Int64
+	requests  atomic.Int64
+	oversized atomic.Int64
+	split     atomic.Int64
+	firstOnce sync.Once
+}
+
+// sizeLimitBatchExporter measures each batch as the OTLP protobuf request
+// it becomes before handing it to the inner exporter. Collectors reject
+// requests over their limit (4MiB by default on gRPC) with an opaque
+// ResourceExhausted error; this finds them before they are sent.
+type sizeLimitBatchExporter struct {
+	inner    model.BatchExporter
+	maxBytes int
+	action   OversizeAction
+	stats    *sizeLimitStats
+	grouping config.ResourceGrouping
+
+	// resume holds the parts of a split batch still to be sent after one
+	// of them failed. When the pipeline retries that same batch, sending
+	// picks up at the failed part, so parts the endpoint already accepted
+	// are not sent twice.
+	mu          sync.Mutex
+	resumeBatch model.Batch
+	resume      []model.Batch
+}
+
+func (e *sizeLimitBatchExporter) ExportBatch(ctx context.Context, batch model.Batch) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	if parts := e.takeResume(batch); parts != nil {
+		return e.exportParts(ctx, batch, parts)
+	}
+	e.stats.requests.Add(1)
+	size := RequestSize(batch, e.grouping)
+	if size <= e.maxBytes {
+		return e.inner.ExportBatch(ctx, batch)
+	}
+	e.stats.oversized.Add(1)
+	e.stats.firstOnce.Do(func() {
+		log.Printf("warning: request of %d spans is %d bytes as OTLP protobuf, over --max-request-bytes=%d (oversize action: %s)", len(batch), size, e.maxBytes, e.action)
+	})
+	if e.action != OversizeSplit {
+		return e.inner.ExportBatch(ctx, batch)
+	}
+	e.stats.split.Add(1)
+	return e.exportParts(ctx, batch, e.splitParts(nil, batch, size))
+}
+
+// exportParts sends parts in order. If one fails, it and the parts after
+// it are kept for a retry of batch.
+func (e *sizeLimitBatchExporter) exportParts(ctx context.Context, batch model.Batch, parts []model.Batch) error {
+	for i, part := range parts {
+		if err := e.inner.ExportBatch(ctx, part); err != nil {
+			e.mu.Lock()
+			e.resumeBatch, e.resume = batch, parts[i:]
+			e.mu.Unlock()
+			return err
+		}
+	}
+	return nil
+}
+
+// takeResume returns the unsent parts kept for batch, if the last split
+// of batch failed part way, and forgets them.
+func (e *sizeLimitBatchExporter) takeResume(batch model.Batch) []model.Batch {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	parts := e.resume
+	same := len(e.resumeBatch) == len(batch) && len(batch) > 0 && &e.resumeBatch[0] == &batch[0]
+	e.resumeBatch, e.resume = nil, nil
+	if !same {
+		return nil
+	}
+	return parts
+}
+
+// splitParts halves batch until every part fits, cutting between traces
+// while it has more than one so backends see each trace in one request.
+// A single span over the limit is sent on its own. Parts are appended to
+// parts in sending order.
+func (e *sizeLimitBatchExporter) splitParts(parts []model.Batch, batch model.Batch, size int) []model.Batch {
+	if size <= e.maxBytes || len(batch) == 1 {
+		return append(parts, batch)
+	}
+	first, second := splitBatch(batch)
+	parts = e.splitParts(parts, first, RequestSize(first, e.grouping))
+	return e.splitParts(parts, second, RequestSize(second, e.grouping))
+}
+
+// splitBatch groups batch by trace, keeping first-seen order, and returns
+// the first half of the traces and the rest. A batch holding one trace is
+// split in the middle of its spans instead.
+func splitBatch(batch model.Batch) (model.Batch, model.Batch) {
+	order := make([]oteltrace.TraceID, 0)
+	traces := make(map[oteltrace.TraceID]model.Batch)
+	for _, span := range batch {
+		if _, ok := traces[span.TraceID]; !ok {
+			order = append(order, span.TraceID)
+		}
+		traces[span.TraceID] = append(traces[span.TraceID], span)
+	}
+	if len(order) == 1 {
+		half := len(batch) / 2
+		return batch[:half], batch[half:]
+	}
+	var first, second model.Batch
+	for i, traceID := range order {
+		if i < len(order)/2 {
+			first = append(first, traces[traceID]...)
+		} else {
+			second = append(second, traces[traceID]...)
+		}
+	}
+	return first, second
+}
+
+// RequestSize returns the size in bytes of batch as an uncompressed OTLP
+// protobuf request, laid out as grouping says.
+func RequestSize(batch model.Batch, grouping config.ResourceGrouping) int {
+	return proto.Size(&coltracepb.ExportTraceServiceRequest{ResourceSpans: modelBatchToProto(batch, grouping)})
+}
+
+// Warmup forwards to the inner exporter when it supports warm-up.
+func (e *sizeLimitBatchExporter) Warmup(ctx context.Context) error {
+	if warmer, ok := e.inner.(model.Warmer); ok {
+		return warmer.Warmup(ctx)
+	}
+	return nil
+}
+
+// Shutdown stops the inner exporter. The last exporter of the factory to
+// shut down logs how many requests were over the limit.
+func (e *sizeLimitBatchExporter) Shutdown(ctx context.Context) error {
+	if e.stats.open.Add(-1) == 0 {
+		if oversized := e.stats.oversized.Load(); oversized > 0 {
+			log.Printf("warning: %d of %d requests exceeded --max-request-bytes=%d; %d were split", oversized, e.stats.requests.Load(), e.maxBytes, e.stats.split.Load())
+		}
+	}
+	return e.inner.Shutdown(ctx)
+}
+
+// SizeLimitExporterFactory wraps another ExporterFactory so that every
+// BatchExporter it produces checks request sizes. The CLI installs this
+// wrapper when --max-request-bytes is set.
+type SizeLimitExporterFactory struct {
+	Inner    model.BatchExporterFactory
+	MaxBytes int
+	Action   OversizeAction
+	// ResourceGrouping must match the inner exporter's so sizes are
+	// measured on the requests it sends.
+	ResourceGrouping config.ResourceGrouping
+	stats            *sizeLimitStats
+}
+
+func NewSizeLimitExporterFactory(inner model.BatchExporterFactory, maxBytes int, action OversizeAction) SizeLimitExporterFactory {
+	return SizeLimitExporterFactory{Inner: inner, MaxBytes: maxBytes, Action: action, stats: &sizeLimitStats{}}
+}
+
+func (f SizeLimitExporterFactory) NewBatchExporter(ctx context.Context) (model.BatchExporter, error) {
+	inner, err := f.Inner.NewBatchExporter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	f.stats.open.Add(1)
+	return &sizeLimitBatchExporter{inner: inner, maxBytes: f.MaxBytes, action: f.Action, stats: f.stats, grouping: f.ResourceGrouping}, nil
+}
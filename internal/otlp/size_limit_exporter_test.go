@@ -0,0 +1,167 @@
+package otlp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/model"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// sizeLimitTestBatch returns traces spans of perTrace spans each, every
+// span carrying a 1KB attribute so sizes are easy to reason about.
+func sizeLimitTestBatch(traces, perTrace int) model.Batch {
+	start := time.Date(2026, time.January, 27, 12, 0, 0, 0, time.UTC)
+	payload := attribute.StringValue(strings.Repeat("x", 1024))
+	var batch model.Batch
+	for trace := range traces {
+		for span := range perTrace {
+			batch = append(batch, model.Span{
+				TraceID:    oteltrace.TraceID{byte(trace + 1)},
+				SpanID:     oteltrace.SpanID{byte(trace + 1), byte(span + 1)},
+				Name:       "GET /items",
+				StartTime:  start,
+				EndTime:    start.Add(time.Millisecond),
+				Attributes: map[string]attribute.Value{"payload": payload},
+			})
+		}
+	}
+	return batch
+}
+
+func TestSizeLimitExporterSplitsOversizedBatchesByTrace(t *testing.T) {
+	inner := &fakeBatchExporter{}
+	factory := NewSizeLimitExporterFactory(fakeFactory{inner: inner}, 5*1024, OversizeSplit)
+	exporter, err := factory.NewBatchExporter(context.Background())
+	if err != nil {
+		t.Fatalf("NewBatchExporter() error = %v", err)
+	}
+
+	if err := exporter.ExportBatch(context.Background(), sizeLimitTestBatch(4, 2)); err != nil {
+		t.Fatalf("ExportBatch() error = %v", err)
+	}
+	emits := inner.snapshot()
+	if len(emits) != 2 {
+		t.Fatalf("expected the 8KB batch to be sent as 2 requests, got %d", len(emits))
+	}
+	total := 0
+	for _, emit := range emits {
+		if size := RequestSize(emit.spans, ""); size > 5*1024 {
+			t.Fatalf("split request is %d bytes, over the limit", size)
+		}
+		traces := map[oteltrace.TraceID]int{}
+		for _, span := range emit.spans {
+			traces[span.TraceID]++
+		}
+		for traceID, count := range traces {
+			if count != 2 {
+				t.Fatalf("trace %s was split across requests", traceID)
+			}
+		}
+		total += len(emit.spans)
+	}
+	if total != 8 {
+		t.Fatalf("expected all 8 spans to be sent, got %d", total)
+	}
+	if err := exporter.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if factory.stats.oversized.Load() != 1 || factory.stats.split.Load() != 1 {
+		t.Fatalf("expected one oversized, split request, got %d and %d", factory.stats.oversized.Load(), factory.stats.split.Load())
+	}
+}
+
+func TestSizeLimitExporterSplitsSingleTraceAndSendsOversizedSpan(t *testing.T) {
+	inner := &fakeBatchExporter{}
+	exporter, err := NewSizeLimitExporterFactory(fakeFactory{inner: inner}, 512, OversizeSplit).NewBatchExporter(context.Background())
+	if err != nil {
+		t.Fatalf("NewBatchExporter() error = %v", err)
+	}
+	if err := exporter.ExportBatch(context.Background(), sizeLimitTestBatch(1, 3)); err != nil {
+		t.Fatalf("ExportBatch() error = %v", err)
+	}
+	if emits := inner.snapshot(); len(emits) != 3 {
+		t.Fatalf("expected each 1KB span on its own despite the 512B limit, got %d requests", len(emits))
+	}
+}
+
+func TestSizeLimitExporterWarnSendsUnchanged(t *testing.T) {
+	inner := &fakeBatchExporter{}
+	factory := NewSizeLimitExporterFactory(fakeFactory{inner: inner}, 1024, OversizeWarn)
+	exporter, err := factory.NewBatchExporter(context.Background())
+	if err != nil {
+		t.Fatalf("NewBatchExporter() error = %v", err)
+	}
+	if err := exporter.ExportBatch(context.Background(), sizeLimitTestBatch(2, 2)); err != nil {
+		t.Fatalf("ExportBatch() error = %v", err)
+	}
+	if emits := inner.snapshot(); len(emits) != 1 || len(emits[0].spans) != 4 {
+		t.Fatalf("expected the batch to be sent whole, got %+v", emits)
+	}
+	if factory.stats.oversized.Load() != 1 || factory.stats.split.Load() != 0 {
+		t.Fatalf("expected one oversized request and no split")
+	}
+}
+
+func TestParseOversizeAction(t *testing.T) {
+	for _, value := range []string{"warn", "split"} {
+		if action, err := ParseOversizeAction(value); err != nil || string(action) != value {
+			t.Fatalf("ParseOversizeAction(%q) = %q, %v", value, action, err)
+		}
+	}
+	if _, err := ParseOversizeAction("drop"); err == nil {
+		t.Fatalf("expected error for unsupported action")
+	}
+}
+
+// failingPartExporter fails the failAt-th export (1-based) once.
+type failingPartExporter struct {
+	*fakeBatchExporter
+	calls  int
+	failAt int
+}
+
+func (f *failingPartExporter) ExportBatch(ctx context.Context, batch model.Batch) error {
+	f.calls++
+	if f.calls == f.failAt {
+		return errors.New("unavailable")
+	}
+	return f.fakeBatchExporter.ExportBatch(ctx, batch)
+}
+
+func TestSizeLimitExporterRetryResumesFromFailedPart(t *testing.T) {
+	inner := &failingPartExporter{fakeBatchExporter: &fakeBatchExporter{}, failAt: 3}
+	exporter, err := NewSizeLimitExporterFactory(fakeFactory{inner: inner}, 1536, OversizeSplit).NewBatchExporter(context.Background())
+	if err != nil {
+		t.Fatalf("NewBatchExporter() error = %v", err)
+	}
+
+	batch := sizeLimitTestBatch(4, 1)
+	if err := exporter.ExportBatch(context.Background(), batch); err == nil {
+		t.Fatalf("expected the third part to fail")
+	}
+	if sent := len(inner.snapshot()); sent != 2 {
+		t.Fatalf("expected 2 parts sent before the failure, got %d", sent)
+	}
+	if err := exporter.ExportBatch(context.Background(), batch); err != nil {
+		t.Fatalf("retry ExportBatch() error = %v", err)
+	}
+	seen := map[oteltrace.TraceID]int{}
+	for _, emit := range inner.snapshot() {
+		for _, span := range emit.spans {
+			seen[span.TraceID]++
+		}
+	}
+	if len(seen) != 4 {
+		t.Fatalf("expected all 4 traces to be sent, got %d", len(seen))
+	}
+	for traceID, count := range seen {
+		if count != 1 {
+			t.Fatalf("trace %s sent %d times; the retry should resume at the failed part", traceID, count)
+		}
+	}
+}
@@ -80,6 +80,14 @@ func (e *streamingBatchExporter) ExportBatch(ctx context.Context, batch model.Ba
 	return nil
 }
 
+// Warmup forwards to the inner exporter when it supports warm-up.
+func (e *streamingBatchExporter) Warmup(ctx context.Context) error {
+	if warmer, ok := e.inner.(model.Warmer); ok {
+		return warmer.Warmup(ctx)
+	}
+	return nil
+}
+
 func (e *streamingBatchExporter) Shutdown(ctx context.Context) error {
 	if e == nil || e.inner == nil {
 		return nil
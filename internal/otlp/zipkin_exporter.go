@@ -0,0 +1,264 @@
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/config"
+	"github.com/javiermolinar/tercios/internal/model"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// zipkinDefaultPath is the span intake path of the Zipkin v2 API, used when
+// the endpoint does not name one.
+const zipkinDefaultPath = "/api/v2/spans"
+
+// zipkinDefaultTimeout matches the OTLP SDK default export timeout.
+const zipkinDefaultTimeout = 10 * time.Second
+
+// zipkinExporter posts batches as Zipkin v2 JSON span lists.
+type zipkinExporter struct {
+	client      *http.Client
+	url         string
+	endpoint    string
+	headers     map[string]string
+	compression config.Compression
+}
+
+func (f ExporterFactory) newZipkinExporter() (*zipkinExporter, error) {
+	host, path, err := parseEndpoint(f.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if path == "" || path == "/" {
+		path = zipkinDefaultPath
+	}
+	scheme := "https"
+	if f.Insecure {
+		scheme = "http"
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !f.Insecure {
+		tlsCfg, err := f.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsCfg
+	}
+	var roundTripper http.RoundTripper = transport
+	if f.SlowResponseDelay > 0 {
+		roundTripper = &slowRoundTripper{wrapped: transport, delay: f.SlowResponseDelay}
+	}
+	timeout := f.ExportTimeout
+	if timeout <= 0 {
+		timeout = zipkinDefaultTimeout
+	}
+	return &zipkinExporter{
+		client:      &http.Client{Transport: roundTripper, Timeout: timeout},
+		url:         scheme + "://" + host + path,
+		endpoint:    f.Endpoint,
+		headers:     f.Headers,
+		compression: f.Compression,
+	}, nil
+}
+
+func (e *zipkinExporter) ExportBatch(ctx context.Context, batch model.Batch) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	if err := e.post(ctx, modelBatchToZipkin(batch)); err != nil {
+		return fmt.Errorf("upload traces protocol=%s endpoint=%s: %w", config.ProtocolZipkin, e.endpoint, err)
+	}
+	return nil
+}
+
+// Warmup posts an empty span list, which Zipkin accepts without storing
+// anything, so the keep-alive connection is open before measurement.
+func (e *zipkinExporter) Warmup(ctx context.Context) error {
+	if err := e.post(ctx, []zipkinSpan{}); err != nil {
+		return fmt.Errorf("warmup export protocol=%s endpoint=%s: %w", config.ProtocolZipkin, e.endpoint, err)
+	}
+	return nil
+}
+
+func (e *zipkinExporter) Shutdown(context.Context) error {
+	e.client.CloseIdleConnections()
+	return nil
+}
+
+// Start, Stop and UploadTraces let the preflight check drive a Zipkin
+// exporter like an OTLP client.
+func (e *zipkinExporter) Start(context.Context) error {
+	return nil
+}
+
+func (e *zipkinExporter) Stop(ctx context.Context) error {
+	return e.Shutdown(ctx)
+}
+
+func (e *zipkinExporter) UploadTraces(ctx context.Context, resourceSpans []*tracepb.ResourceSpans) error {
+	return e.post(ctx, modelBatchToZipkin(protoToModelBatch(resourceSpans)))
+}
+
+func (e *zipkinExporter) post(ctx context.Context, spans []zipkinSpan) error {
+	payload, err := json.Marshal(spans)
+	if err != nil {
+		return fmt.Errorf("encode zipkin spans: %w", err)
+	}
+	payload, encoding, err := compressBody(e.compression, payload)
+	if err != nil {
+		return fmt.Errorf("compress zipkin spans: %w", err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if encoding != "" {
+		request.Header.Set("Content-Encoding", encoding)
+	}
+	for key, value := range e.headers {
+		request.Header.Set(key, value)
+	}
+
+	response, err := e.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = response.Body.Close() }()
+	body, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("zipkin responded %s: %s", response.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// zipkinSpan is one span of the Zipkin v2 JSON API.
+type zipkinSpan struct {
+	TraceID       string             `json:"traceId"`
+	ID            string             `json:"id"`
+	ParentID      string             `json:"parentId,omitempty"`
+	Name          string             `json:"name,omitempty"`
+	Kind          string             `json:"kind,omitempty"`
+	Timestamp     int64              `json:"timestamp,omitempty"`
+	Duration      int64              `json:"duration,omitempty"`
+	LocalEndpoint *zipkinEndpoint    `json:"localEndpoint,omitempty"`
+	Annotations   []zipkinAnnotation `json:"annotations,omitempty"`
+	Tags          map[string]string  `json:"tags,omitempty"`
+}
+
+type zipkinEndpoint struct {
+	ServiceName string `json:"serviceName,omitempty"`
+}
+
+type zipkinAnnotation struct {
+	Timestamp int64  `json:"timestamp"`
+	Value     string `json:"value"`
+}
+
+// modelBatchToZipkin maps spans the way the collector's Zipkin exporter
+// does: service.name becomes the local endpoint, other span and resource
+// attributes become string tags, events become annotations and an error
+// status sets the error tag.
+func modelBatchToZipkin(batch model.Batch) []zipkinSpan {
+	out := make([]zipkinSpan, 0, len(batch))
+	for _, span := range batch {
+		out = append(out, modelSpanToZipkin(span))
+	}
+	return out
+}
+
+func modelSpanToZipkin(span model.Span) zipkinSpan {
+	zs := zipkinSpan{
+		TraceID:   span.TraceID.String(),
+		ID:        span.SpanID.String(),
+		Name:      span.Name,
+		Kind:      zipkinKind(span.Kind),
+		Timestamp: span.StartTime.UnixMicro(),
+		Duration:  span.EndTime.Sub(span.StartTime).Microseconds(),
+	}
+	if span.ParentSpanID.IsValid() {
+		zs.ParentID = span.ParentSpanID.String()
+	}
+	// Zipkin rejects a zero duration; round sub-microsecond spans up.
+	if zs.Duration <= 0 && span.EndTime.After(span.StartTime) {
+		zs.Duration = 1
+	}
+
+	tags := make(map[string]string, len(span.Attributes)+len(span.ResourceAttributes))
+	for key, value := range span.ResourceAttributes {
+		tags[key] = zipkinTagValue(value)
+	}
+	for key, value := range span.Attributes {
+		tags[key] = zipkinTagValue(value)
+	}
+	if serviceName, ok := tags["service.name"]; ok {
+		zs.LocalEndpoint = &zipkinEndpoint{ServiceName: serviceName}
+		delete(tags, "service.name")
+	}
+	switch span.StatusCode {
+	case codes.Error:
+		tags["otel.status_code"] = "ERROR"
+		tags["error"] = span.StatusDescription
+		if span.StatusDescription == "" {
+			tags["error"] = "true"
+		}
+	case codes.Ok:
+		tags["otel.status_code"] = "OK"
+	}
+	if len(tags) > 0 {
+		zs.Tags = tags
+	}
+
+	for _, event := range span.Events {
+		zs.Annotations = append(zs.Annotations, zipkinAnnotation{
+			Timestamp: event.Time.UnixMicro(),
+			Value:     event.Name,
+		})
+	}
+	return zs
+}
+
+func zipkinKind(kind oteltrace.SpanKind) string {
+	switch kind {
+	case oteltrace.SpanKindServer:
+		return "SERVER"
+	case oteltrace.SpanKindClient:
+		return "CLIENT"
+	case oteltrace.SpanKindProducer:
+		return "PRODUCER"
+	case oteltrace.SpanKindConsumer:
+		return "CONSUMER"
+	default:
+		return ""
+	}
+}
+
+// zipkinTagValue renders an attribute as a Zipkin tag: scalars in their
+// plain text form, slices as JSON arrays.
+func zipkinTagValue(value attribute.Value) string {
+	switch value.Type() {
+	case attribute.STRING:
+		return value.AsString()
+	case attribute.BOOL:
+		return strconv.FormatBool(value.AsBool())
+	case attribute.INT64:
+		return strconv.FormatInt(value.AsInt64(), 10)
+	case attribute.FLOAT64:
+		return strconv.FormatFloat(value.AsFloat64(), 'g', -1, 64)
+	default:
+		return value.Emit()
+	}
+}
@@ -0,0 +1,167 @@
+package otlp
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/config"
+	"github.com/javiermolinar/tercios/internal/model"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+func TestModelSpanToZipkin(t *testing.T) {
+	start := time.Date(2026, time.January, 27, 12, 0, 0, 0, time.UTC)
+	span := model.Span{
+		TraceID:      oteltrace.TraceID{0xab, 0xcd},
+		SpanID:       oteltrace.SpanID{0x01, 0x02},
+		ParentSpanID: oteltrace.SpanID{0x03},
+		Name:         "GET /items",
+		Kind:         oteltrace.SpanKindServer,
+		StartTime:    start,
+		EndTime:      start.Add(1500 * time.Microsecond),
+		Attributes: map[string]attribute.Value{
+			"http.response.status_code": attribute.Int64Value(503),
+			"retry":                     attribute.BoolValue(true),
+			"tags":                      attribute.StringSliceValue([]string{"a", "b"}),
+		},
+		ResourceAttributes: map[string]attribute.Value{
+			"service.name": attribute.StringValue("api"),
+			"region":       attribute.StringValue("eu"),
+		},
+		Events:            []model.Event{{Name: "retrying", Time: start.Add(time.Millisecond)}},
+		StatusCode:        codes.Error,
+		StatusDescription: "upstream unavailable",
+	}
+
+	got := modelSpanToZipkin(span)
+	if got.TraceID != "abcd0000000000000000000000000000" || got.ID != "0102000000000000" || got.ParentID != "0300000000000000" {
+		t.Fatalf("unexpected IDs %+v", got)
+	}
+	if got.Kind != "SERVER" || got.Timestamp != start.UnixMicro() || got.Duration != 1500 {
+		t.Fatalf("unexpected kind or timing %+v", got)
+	}
+	if got.LocalEndpoint == nil || got.LocalEndpoint.ServiceName != "api" {
+		t.Fatalf("expected local endpoint service api, got %+v", got.LocalEndpoint)
+	}
+	wantTags := map[string]string{
+		"http.response.status_code": "503",
+		"retry":                     "true",
+		"tags":                      `["a","b"]`,
+		"region":                    "eu",
+		"otel.status_code":          "ERROR",
+		"error":                     "upstream unavailable",
+	}
+	if len(got.Tags) != len(wantTags) {
+		t.Fatalf("unexpected tags %v", got.Tags)
+	}
+	for key, want := range wantTags {
+		if got.Tags[key] != want {
+			t.Fatalf("tag %q = %q, want %q", key, got.Tags[key], want)
+		}
+	}
+	if len(got.Annotations) != 1 || got.Annotations[0].Value != "retrying" || got.Annotations[0].Timestamp != start.Add(time.Millisecond).UnixMicro() {
+		t.Fatalf("unexpected annotations %+v", got.Annotations)
+	}
+}
+
+func TestModelSpanToZipkinOmitsInternalKindAndRootParent(t *testing.T) {
+	start := time.Now()
+	got := modelSpanToZipkin(model.Span{
+		TraceID:   oteltrace.TraceID{0x01},
+		SpanID:    oteltrace.SpanID{0x01},
+		Kind:      oteltrace.SpanKindInternal,
+		StartTime: start,
+		EndTime:   start.Add(100 * time.Nanosecond),
+	})
+	if got.Kind != "" || got.ParentID != "" || got.Tags != nil {
+		t.Fatalf("expected no kind, parent or tags, got %+v", got)
+	}
+	if got.Duration != 1 {
+		t.Fatalf("expected sub-microsecond duration rounded up to 1, got %d", got.Duration)
+	}
+}
+
+func TestZipkinExporterPostsGzipJSONToDefaultPath(t *testing.T) {
+	type received struct {
+		path    string
+		header  http.Header
+		payload []zipkinSpan
+	}
+	requests := make(chan received, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			reader, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Errorf("gzip.NewReader() error = %v", err)
+				return
+			}
+			body = reader
+		}
+		var payload []zipkinSpan
+		if err := json.NewDecoder(body).Decode(&payload); err != nil {
+			t.Errorf("decode zipkin payload: %v", err)
+		}
+		requests <- received{path: r.URL.Path, header: r.Header, payload: payload}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	exporter, err := (ExporterFactory{
+		Protocol:    config.ProtocolZipkin,
+		Endpoint:    server.URL,
+		Insecure:    true,
+		Headers:     map[string]string{"X-Tenant": "load"},
+		Compression: config.CompressionGzip,
+	}).NewBatchExporter(context.Background())
+	if err != nil {
+		t.Fatalf("NewBatchExporter() error = %v", err)
+	}
+	defer func() { _ = exporter.Shutdown(context.Background()) }()
+
+	if err := exporter.ExportBatch(context.Background(), fileExporterTestBatch()); err != nil {
+		t.Fatalf("ExportBatch() error = %v", err)
+	}
+	got := <-requests
+	if got.path != zipkinDefaultPath {
+		t.Fatalf("expected path %q, got %q", zipkinDefaultPath, got.path)
+	}
+	if got.header.Get("Content-Type") != "application/json" || got.header.Get("X-Tenant") != "load" {
+		t.Fatalf("unexpected headers %v", got.header)
+	}
+	if len(got.payload) != 1 || got.payload[0].Name != "GET /items" {
+		t.Fatalf("unexpected payload %+v", got.payload)
+	}
+}
+
+func TestZipkinExporterReportsRejectedBatches(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "storage full", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	factory := ExporterFactory{Protocol: config.ProtocolZipkin, Endpoint: server.URL + "/custom/spans", Insecure: true}
+	exporter, err := factory.NewBatchExporter(context.Background())
+	if err != nil {
+		t.Fatalf("NewBatchExporter() error = %v", err)
+	}
+	err = exporter.ExportBatch(context.Background(), fileExporterTestBatch())
+	if err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+	if !strings.Contains(err.Error(), "protocol=zipkin") || !strings.Contains(err.Error(), "503") || !strings.Contains(err.Error(), "storage full") {
+		t.Fatalf("expected status and body in error, got %q", err.Error())
+	}
+	if err := RunPreflight(context.Background(), factory, time.Second); err == nil {
+		t.Fatalf("expected preflight to fail against a rejecting endpoint")
+	}
+}
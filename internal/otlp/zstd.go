@@ -0,0 +1,153 @@
+package otlp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/javiermolinar/tercios/internal/config"
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/grpc/encoding"
+)
+
+// zstdName is the gRPC compressor and HTTP Content-Encoding name of zstd.
+const zstdName = "zstd"
+
+func init() {
+	encoding.RegisterCompressor(&zstdCompressor{})
+}
+
+// zstdCompressor is the gRPC encoding.Compressor for zstd, which grpc-go
+// does not ship. Collectors register the same name, so --compression=zstd
+// negotiates with them like gzip does.
+type zstdCompressor struct {
+	encoders sync.Pool
+	decoders sync.Pool
+}
+
+func (c *zstdCompressor) Name() string {
+	return zstdName
+}
+
+func (c *zstdCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	encoder, ok := c.encoders.Get().(*zstd.Encoder)
+	if !ok {
+		var err error
+		encoder, err = zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+	}
+	encoder.Reset(w)
+	return &zstdWriter{Encoder: encoder, pool: &c.encoders}, nil
+}
+
+func (c *zstdCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	decoder, ok := c.decoders.Get().(*zstd.Decoder)
+	if !ok {
+		var err error
+		decoder, err = zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := decoder.Reset(r); err != nil {
+		c.decoders.Put(decoder)
+		return nil, err
+	}
+	return &zstdReader{Decoder: decoder, pool: &c.decoders}, nil
+}
+
+// zstdWriter returns its encoder to the pool once the message is written.
+type zstdWriter struct {
+	*zstd.Encoder
+	pool *sync.Pool
+}
+
+func (w *zstdWriter) Close() error {
+	err := w.Encoder.Close()
+	w.pool.Put(w.Encoder)
+	return err
+}
+
+// zstdReader returns its decoder to the pool at the end of the message.
+type zstdReader struct {
+	*zstd.Decoder
+	pool *sync.Pool
+}
+
+func (r *zstdReader) Read(p []byte) (int, error) {
+	if r.Decoder == nil {
+		return 0, io.EOF
+	}
+	n, err := r.Decoder.Read(p)
+	if err == io.EOF {
+		r.pool.Put(r.Decoder)
+		r.Decoder = nil
+	}
+	return n, err
+}
+
+// bodyEncoder is shared by every exporter: EncodeAll is safe for
+// concurrent use.
+var bodyEncoder = sync.OnceValues(func() (*zstd.Encoder, error) {
+	return zstd.NewWriter(nil)
+})
+
+// compressBody compresses an HTTP request body with compression, and
+// returns the Content-Encoding to send it with, or "" when it is sent as
+// it is.
+func compressBody(compression config.Compression, body []byte) ([]byte, string, error) {
+	var compressed bytes.Buffer
+	switch compression {
+	case config.CompressionGzip:
+		writer := gzip.NewWriter(&compressed)
+		if _, err := writer.Write(body); err != nil {
+			return nil, "", err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, "", err
+		}
+		return compressed.Bytes(), "gzip", nil
+	case config.CompressionZstd:
+		encoder, err := bodyEncoder()
+		if err != nil {
+			return nil, "", err
+		}
+		return encoder.EncodeAll(body, nil), zstdName, nil
+	default:
+		return body, "", nil
+	}
+}
+
+// zstdRoundTripper compresses the bodies of the OTLP/HTTP exporter with
+// zstd on their way out, as the SDK exporter only offers gzip.
+type zstdRoundTripper struct {
+	wrapped http.RoundTripper
+}
+
+func (t *zstdRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return t.wrapped.RoundTrip(req)
+	}
+	body, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("read OTLP request body: %w", err)
+	}
+	compressed, encoding, err := compressBody(config.CompressionZstd, body)
+	if err != nil {
+		return nil, fmt.Errorf("zstd OTLP request body: %w", err)
+	}
+	out := req.Clone(req.Context())
+	out.Body = io.NopCloser(bytes.NewReader(compressed))
+	out.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(compressed)), nil
+	}
+	out.ContentLength = int64(len(compressed))
+	out.Header.Set("Content-Encoding", encoding)
+	return t.wrapped.RoundTrip(out)
+}
@@ -0,0 +1,188 @@
+package otlp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/config"
+	"github.com/javiermolinar/tercios/internal/model"
+	"github.com/klauspost/compress/zstd"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	collectortracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/protobuf/proto"
+)
+
+func zstdTestBatch() model.Batch {
+	return model.Batch{{
+		TraceID:   oteltrace.TraceID{0x01},
+		SpanID:    oteltrace.SpanID{0x01},
+		Name:      "GET /zstd",
+		StartTime: time.Now(),
+		EndTime:   time.Now(),
+	}}
+}
+
+func decodeZstd(t *testing.T, r io.Reader) []byte {
+	t.Helper()
+	decoder, err := zstd.NewReader(r)
+	if err != nil {
+		t.Fatalf("zstd.NewReader() error = %v", err)
+	}
+	defer decoder.Close()
+	body, err := io.ReadAll(decoder)
+	if err != nil {
+		t.Fatalf("decode zstd body: %v", err)
+	}
+	return body
+}
+
+func TestDirectBatchExporterZstdCompressesHTTPPayload(t *testing.T) {
+	type received struct {
+		encoding string
+		request  *collectortracepb.ExportTraceServiceRequest
+	}
+	requests := make(chan received, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := &collectortracepb.ExportTraceServiceRequest{}
+		if err := proto.Unmarshal(decodeZstd(t, r.Body), request); err != nil {
+			t.Errorf("unmarshal OTLP request: %v", err)
+		}
+		requests <- received{encoding: r.Header.Get("Content-Encoding"), request: request}
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	exporter, err := (ExporterFactory{
+		Protocol:    config.ProtocolHTTP,
+		Endpoint:    server.URL + "/v1/traces",
+		Insecure:    true,
+		Compression: config.CompressionZstd,
+	}).NewBatchExporter(context.Background())
+	if err != nil {
+		t.Fatalf("NewBatchExporter() error = %v", err)
+	}
+	defer func() { _ = exporter.Shutdown(context.Background()) }()
+
+	if err := exporter.ExportBatch(context.Background(), zstdTestBatch()); err != nil {
+		t.Fatalf("ExportBatch() error = %v", err)
+	}
+	got := <-requests
+	if got.encoding != "zstd" {
+		t.Fatalf("expected zstd Content-Encoding, got %q", got.encoding)
+	}
+	if name := got.request.GetResourceSpans()[0].GetScopeSpans()[0].GetSpans()[0].GetName(); name != "GET /zstd" {
+		t.Fatalf("expected the exported span, got %q", name)
+	}
+}
+
+func TestZipkinExporterZstdCompressesPayload(t *testing.T) {
+	type received struct {
+		encoding string
+		payload  []zipkinSpan
+	}
+	requests := make(chan received, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload []zipkinSpan
+		if err := json.Unmarshal(decodeZstd(t, r.Body), &payload); err != nil {
+			t.Errorf("decode zipkin payload: %v", err)
+		}
+		requests <- received{encoding: r.Header.Get("Content-Encoding"), payload: payload}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	exporter, err := (ExporterFactory{
+		Protocol:    config.ProtocolZipkin,
+		Endpoint:    server.URL,
+		Insecure:    true,
+		Compression: config.CompressionZstd,
+	}).NewBatchExporter(context.Background())
+	if err != nil {
+		t.Fatalf("NewBatchExporter() error = %v", err)
+	}
+	defer func() { _ = exporter.Shutdown(context.Background()) }()
+
+	if err := exporter.ExportBatch(context.Background(), zstdTestBatch()); err != nil {
+		t.Fatalf("ExportBatch() error = %v", err)
+	}
+	got := <-requests
+	if got.encoding != "zstd" || len(got.payload) != 1 || got.payload[0].Name != "GET /zstd" {
+		t.Fatalf("expected one zstd compressed span, got %q and %+v", got.encoding, got.payload)
+	}
+}
+
+type recordingTraceService struct {
+	collectortracepb.UnimplementedTraceServiceServer
+	requests chan *collectortracepb.ExportTraceServiceRequest
+}
+
+func (s *recordingTraceService) Export(_ context.Context, request *collectortracepb.ExportTraceServiceRequest) (*collectortracepb.ExportTraceServiceResponse, error) {
+	s.requests <- request
+	return &collectortracepb.ExportTraceServiceResponse{}, nil
+}
+
+// encodingRecorder records the grpc-encoding of the calls a server
+// receives, which grpc keeps out of the incoming metadata.
+type encodingRecorder struct {
+	encodings chan string
+}
+
+func (r *encodingRecorder) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (r *encodingRecorder) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (r *encodingRecorder) HandleConn(context.Context, stats.ConnStats) {}
+
+func (r *encodingRecorder) HandleRPC(_ context.Context, s stats.RPCStats) {
+	if header, ok := s.(*stats.InHeader); ok {
+		r.encodings <- header.Compression
+	}
+}
+
+func TestDirectBatchExporterZstdCompressesGRPCPayload(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	service := &recordingTraceService{requests: make(chan *collectortracepb.ExportTraceServiceRequest, 1)}
+	recorder := &encodingRecorder{encodings: make(chan string, 1)}
+	server := grpc.NewServer(grpc.StatsHandler(recorder))
+	collectortracepb.RegisterTraceServiceServer(server, service)
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	exporter, err := (ExporterFactory{
+		Protocol:    config.ProtocolGRPC,
+		Endpoint:    listener.Addr().String(),
+		Insecure:    true,
+		Compression: config.CompressionZstd,
+	}).NewBatchExporter(context.Background())
+	if err != nil {
+		t.Fatalf("NewBatchExporter() error = %v", err)
+	}
+	defer func() { _ = exporter.Shutdown(context.Background()) }()
+
+	if err := exporter.ExportBatch(context.Background(), zstdTestBatch()); err != nil {
+		t.Fatalf("ExportBatch() error = %v", err)
+	}
+	if encoding := <-recorder.encodings; encoding != "zstd" {
+		t.Fatalf("expected zstd grpc-encoding, got %q", encoding)
+	}
+	request := <-service.requests
+	if name := request.GetResourceSpans()[0].GetScopeSpans()[0].GetSpans()[0].GetName(); name != "GET /zstd" {
+		t.Fatalf("expected the exported span, got %q", name)
+	}
+}
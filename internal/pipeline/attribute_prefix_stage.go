@@ -0,0 +1,116 @@
+package pipeline
+
+import (
+	"context"
+	"strings"
+
+	"github.com/javiermolinar/tercios/internal/model"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// semconvNamespaces are the top-level namespaces of the OpenTelemetry
+// semantic conventions. Keys in them keep their name so backends still
+// recognize them; tercios.* keys already mark synthetic data.
+var semconvNamespaces = map[string]struct{}{
+	"android": {}, "app": {}, "artifact": {}, "aspnetcore": {}, "aws": {}, "azure": {},
+	"browser": {}, "cicd": {}, "client": {}, "cloud": {}, "cloudevents": {}, "code": {},
+	"container": {}, "db": {}, "deployment": {}, "destination": {}, "device": {}, "dns": {},
+	"dotnet": {}, "enduser": {}, "error": {}, "event": {}, "exception": {}, "faas": {},
+	"feature_flag": {}, "file": {}, "gcp": {}, "gen_ai": {}, "geo": {}, "go": {},
+	"graphql": {}, "heroku": {}, "host": {}, "http": {}, "hw": {}, "ios": {}, "jvm": {},
+	"k8s": {}, "log": {}, "message": {}, "messaging": {}, "net": {}, "network": {},
+	"nodejs": {}, "oci": {}, "os": {}, "otel": {}, "peer": {}, "process": {}, "rpc": {},
+	"server": {}, "service": {}, "session": {}, "signalr": {}, "source": {}, "system": {},
+	"telemetry": {}, "thread": {}, "tls": {}, "url": {}, "user": {}, "user_agent": {},
+	"v8js": {}, "vcs": {}, "webengine": {},
+	"tercios": {},
+}
+
+type attributePrefixStage struct {
+	prefix string
+}
+
+// NewAttributePrefixStage prefixes every span, resource, event and link
+// attribute key outside the semantic convention namespaces with prefix,
+// so synthetic attributes are easy to spot and exclude from dashboards.
+func NewAttributePrefixStage(prefix string) BatchStage {
+	return &attributePrefixStage{prefix: strings.TrimSuffix(prefix, ".") + "."}
+}
+
+func (s *attributePrefixStage) name() string {
+	return "attribute-prefix"
+}
+
+func (s *attributePrefixStage) process(_ context.Context, spans []model.Span) ([]model.Span, error) {
+	for i := range spans {
+		span := &spans[i]
+		span.Attributes = s.prefixMap(span.Attributes)
+		span.ResourceAttributes = s.prefixMap(span.ResourceAttributes)
+		if len(span.Events) > 0 {
+			events := make([]model.Event, len(span.Events))
+			copy(events, span.Events)
+			for j := range events {
+				events[j].Attributes = s.prefixList(events[j].Attributes)
+			}
+			span.Events = events
+		}
+		if len(span.Links) > 0 {
+			links := make([]model.Link, len(span.Links))
+			copy(links, span.Links)
+			for j := range links {
+				links[j].Attributes = s.prefixList(links[j].Attributes)
+			}
+			span.Links = links
+		}
+	}
+	return spans, nil
+}
+
+func (s *attributePrefixStage) needsPrefix(key string) bool {
+	if strings.HasPrefix(key, s.prefix) {
+		return false
+	}
+	namespace, _, _ := strings.Cut(key, ".")
+	_, semconv := semconvNamespaces[namespace]
+	return !semconv
+}
+
+// prefixMap returns attrs unchanged when no key needs a prefix, and a
+// renamed copy otherwise; the input may be shared with other spans.
+func (s *attributePrefixStage) prefixMap(attrs map[string]attribute.Value) map[string]attribute.Value {
+	var out map[string]attribute.Value
+	for key := range attrs {
+		if s.needsPrefix(key) {
+			out = make(map[string]attribute.Value, len(attrs))
+			break
+		}
+	}
+	if out == nil {
+		return attrs
+	}
+	for key, value := range attrs {
+		if s.needsPrefix(key) {
+			key = s.prefix + key
+		}
+		out[key] = value
+	}
+	return out
+}
+
+func (s *attributePrefixStage) prefixList(attrs []attribute.KeyValue) []attribute.KeyValue {
+	var out []attribute.KeyValue
+	for i, kv := range attrs {
+		if !s.needsPrefix(string(kv.Key)) {
+			continue
+		}
+		if out == nil {
+			out = make([]attribute.KeyValue, len(attrs))
+			copy(out, attrs)
+		}
+		out[i].Key = attribute.Key(s.prefix + string(kv.Key))
+	}
+	if out == nil {
+		return attrs
+	}
+	return out
+}
@@ -0,0 +1,53 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/javiermolinar/tercios/internal/model"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestAttributePrefixStageRenamesNonSemconvKeys(t *testing.T) {
+	stage := NewAttributePrefixStage("loadtest")
+	spanAttrs := map[string]attribute.Value{
+		"http.route":           attribute.StringValue("/items"),
+		"payload":              attribute.StringValue("x"),
+		"checkout.step":        attribute.Int64Value(3),
+		"loadtest.run":         attribute.StringValue("r1"),
+		"tercios.chaos.policy": attribute.StringValue("slow"),
+	}
+	eventAttrs := []attribute.KeyValue{attribute.String("exception.type", "E"), attribute.String("retry.reason", "busy")}
+	spans := []model.Span{{
+		Attributes:         spanAttrs,
+		ResourceAttributes: map[string]attribute.Value{"service.name": attribute.StringValue("api"), "team": attribute.StringValue("core")},
+		Events:             []model.Event{{Name: "exception", Attributes: eventAttrs}},
+		Links:              []model.Link{{Attributes: []attribute.KeyValue{attribute.String("link.kind", "follows")}}},
+	}}
+
+	out, err := stage.process(context.Background(), spans)
+	if err != nil {
+		t.Fatalf("process() error = %v", err)
+	}
+	span := out[0]
+	for _, key := range []string{"http.route", "loadtest.payload", "loadtest.checkout.step", "loadtest.run", "tercios.chaos.policy"} {
+		if _, ok := span.Attributes[key]; !ok {
+			t.Fatalf("expected span attribute %q, got %v", key, span.Attributes)
+		}
+	}
+	if len(span.Attributes) != 5 {
+		t.Fatalf("unexpected span attributes %v", span.Attributes)
+	}
+	if _, ok := span.ResourceAttributes["loadtest.team"]; !ok || span.ResourceAttributes["service.name"].AsString() != "api" {
+		t.Fatalf("unexpected resource attributes %v", span.ResourceAttributes)
+	}
+	if got := span.Events[0].Attributes; got[0].Key != "exception.type" || got[1].Key != "loadtest.retry.reason" {
+		t.Fatalf("unexpected event attributes %v", got)
+	}
+	if got := span.Links[0].Attributes[0].Key; got != "loadtest.link.kind" {
+		t.Fatalf("unexpected link attribute %q", got)
+	}
+	if _, ok := spanAttrs["payload"]; !ok || eventAttrs[1].Key != "retry.reason" {
+		t.Fatalf("expected the input attributes to stay untouched")
+	}
+}
@@ -0,0 +1,89 @@
+package pipeline
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/model"
+)
+
+const backfillDay = 24 * time.Hour
+
+// BackfillConfig configures backfill mode, which dates generated batches
+// day by day into the past to populate retention and compaction tests with
+// weeks of history in one run.
+type BackfillConfig struct {
+	// Days is the number of whole UTC days before today to fill, oldest
+	// first.
+	Days int
+	// RequestsPerDay is the daily volume. A day's requests are spread
+	// evenly over its 24 hours; once every day is filled the stage ends
+	// the run.
+	RequestsPerDay int
+	// Now anchors the days; zero means the time the stage is created.
+	Now time.Time
+}
+
+type backfillStage struct {
+	config  BackfillConfig
+	today   time.Time
+	counter atomic.Int64
+}
+
+func NewBackfillStage(cfg BackfillConfig) BatchStage {
+	now := cfg.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	return &backfillStage{config: cfg, today: now.UTC().Truncate(backfillDay)}
+}
+
+func (s *backfillStage) name() string {
+	return "backfill"
+}
+
+func (s *backfillStage) process(_ context.Context, spans []model.Span) ([]model.Span, error) {
+	request := s.counter.Add(1) - 1
+	perDay := int64(s.config.RequestsPerDay)
+	if request >= int64(s.config.Days)*perDay {
+		return nil, io.EOF
+	}
+	if len(spans) == 0 {
+		return spans, nil
+	}
+
+	day := request / perDay
+	slot := request % perDay
+	target := s.today.
+		Add(-time.Duration(int64(s.config.Days)-day) * backfillDay).
+		Add(backfillDay * time.Duration(slot) / time.Duration(perDay))
+
+	earliest := spans[0].StartTime
+	for _, span := range spans[1:] {
+		if span.StartTime.Before(earliest) {
+			earliest = span.StartTime
+		}
+	}
+	shiftBatch(spans, target.Sub(earliest))
+	return spans, nil
+}
+
+// shiftBatch moves every span, events included, by delta. Events are
+// copied first so slices shared with other batches are left untouched.
+func shiftBatch(spans []model.Span, delta time.Duration) {
+	for i := range spans {
+		span := &spans[i]
+		span.StartTime = span.StartTime.Add(delta)
+		span.EndTime = span.EndTime.Add(delta)
+		if len(span.Events) > 0 {
+			events := make([]model.Event, len(span.Events))
+			copy(events, span.Events)
+			for j := range events {
+				events[j].Time = events[j].Time.Add(delta)
+			}
+			span.Events = events
+		}
+	}
+}
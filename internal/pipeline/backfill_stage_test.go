@@ -0,0 +1,50 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/model"
+)
+
+func TestBackfillStageDatesRequestsDayByDay(t *testing.T) {
+	now := time.Date(2026, time.March, 10, 15, 30, 0, 0, time.UTC)
+	stage := NewBackfillStage(BackfillConfig{Days: 3, RequestsPerDay: 2, Now: now})
+	generated := now.Add(-time.Minute)
+	event := []model.Event{{Name: "retry", Time: generated.Add(5 * time.Millisecond)}}
+
+	want := []time.Time{
+		time.Date(2026, time.March, 7, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, time.March, 7, 12, 0, 0, 0, time.UTC),
+		time.Date(2026, time.March, 8, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, time.March, 8, 12, 0, 0, 0, time.UTC),
+		time.Date(2026, time.March, 9, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, time.March, 9, 12, 0, 0, 0, time.UTC),
+	}
+	for i, start := range want {
+		spans := []model.Span{
+			{Name: "child", StartTime: generated.Add(time.Millisecond), EndTime: generated.Add(9 * time.Millisecond), Events: event},
+			{Name: "root", StartTime: generated, EndTime: generated.Add(10 * time.Millisecond)},
+		}
+		out, err := stage.process(context.Background(), spans)
+		if err != nil {
+			t.Fatalf("process() request %d error = %v", i, err)
+		}
+		if !out[1].StartTime.Equal(start) || out[1].EndTime.Sub(out[1].StartTime) != 10*time.Millisecond {
+			t.Fatalf("request %d root = %v-%v, want start %v", i, out[1].StartTime, out[1].EndTime, start)
+		}
+		if !out[0].StartTime.Equal(start.Add(time.Millisecond)) || !out[0].Events[0].Time.Equal(start.Add(5*time.Millisecond)) {
+			t.Fatalf("request %d child timing not preserved: %+v", i, out[0])
+		}
+	}
+	if !event[0].Time.Equal(generated.Add(5 * time.Millisecond)) {
+		t.Fatalf("expected the shared event slice to stay untouched")
+	}
+
+	if _, err := stage.process(context.Background(), []model.Span{{StartTime: generated}}); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected io.EOF once every day is filled, got %v", err)
+	}
+}
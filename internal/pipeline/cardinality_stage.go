@@ -0,0 +1,114 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/model"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// CardinalityAttribute is one span attribute injected by the cardinality
+// stage.
+type CardinalityAttribute struct {
+	Key string
+	// Cardinality is the number of distinct values, drawn uniformly per
+	// span. Zero gives every span a value never used before.
+	Cardinality int
+}
+
+// CardinalityConfig configures high-cardinality attribute injection, which
+// stresses backends' attribute indexes and finds their cardinality limits.
+type CardinalityConfig struct {
+	Attributes []CardinalityAttribute
+	// Seed makes the drawn values, and the token of unique values,
+	// reproducible. Zero picks a random seed.
+	Seed int64
+}
+
+// CardinalityFlags collects repeated --cardinality-attribute KEY=N flags.
+type CardinalityFlags struct {
+	attributes []CardinalityAttribute
+}
+
+func (f *CardinalityFlags) String() string {
+	if f == nil {
+		return ""
+	}
+	parts := make([]string, 0, len(f.attributes))
+	for _, attr := range f.attributes {
+		parts = append(parts, attr.Key+"="+strconv.Itoa(attr.Cardinality))
+	}
+	return strings.Join(parts, ",")
+}
+
+func (f *CardinalityFlags) Set(value string) error {
+	key, count, ok := strings.Cut(value, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return fmt.Errorf("cardinality attribute must be KEY=N")
+	}
+	cardinality, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || cardinality < 0 {
+		return fmt.Errorf("cardinality of %q must be an integer >= 0 (0 for a unique value per span)", key)
+	}
+	f.attributes = append(f.attributes, CardinalityAttribute{Key: key, Cardinality: cardinality})
+	return nil
+}
+
+func (f *CardinalityFlags) Values() []CardinalityAttribute {
+	if f == nil || len(f.attributes) == 0 {
+		return nil
+	}
+	out := make([]CardinalityAttribute, len(f.attributes))
+	copy(out, f.attributes)
+	return out
+}
+
+type cardinalityStage struct {
+	config CardinalityConfig
+	seed   uint64
+	// token keeps unique values unique across runs against the same
+	// backend, not only within one run.
+	token string
+}
+
+func NewCardinalityStage(cfg CardinalityConfig) BatchStage {
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &cardinalityStage{config: cfg, seed: uint64(seed), token: strconv.FormatUint(uint64(seed), 36)}
+}
+
+func (s *cardinalityStage) name() string {
+	return "cardinality"
+}
+
+func (s *cardinalityStage) process(_ context.Context, spans []model.Span) ([]model.Span, error) {
+	for i := range spans {
+		// Attributes may be shared with other spans or batches; write to
+		// a copy.
+		attrs := make(map[string]attribute.Value, len(spans[i].Attributes)+len(s.config.Attributes))
+		maps.Copy(attrs, spans[i].Attributes)
+		for _, attr := range s.config.Attributes {
+			attrs[attr.Key] = attribute.StringValue(s.value(&spans[i], attr))
+		}
+		spans[i].Attributes = attrs
+	}
+	return spans, nil
+}
+
+// value draws attr's value for span from the span's IDs, so a seed
+// reproduces the values whichever worker processes the span.
+func (s *cardinalityStage) value(span *model.Span, attr CardinalityAttribute) string {
+	random := spanRandom(s.seed, span, keyDraw(attr.Key))
+	if attr.Cardinality == 0 {
+		return s.token + "-" + strconv.FormatUint(random, 36)
+	}
+	return strconv.FormatUint(random%uint64(attr.Cardinality), 10)
+}
@@ -0,0 +1,63 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestCardinalityStageBoundsDistinctValues(t *testing.T) {
+	stage := NewCardinalityStage(CardinalityConfig{Attributes: []CardinalityAttribute{
+		{Key: "user.id", Cardinality: 50},
+		{Key: "request.id"},
+	}})
+	shared := map[string]attribute.Value{"http.route": attribute.StringValue("/items")}
+
+	users := map[string]struct{}{}
+	requests := map[string]struct{}{}
+	for batch := range 10 {
+		spans := spansWithIDs(100, batch*100)
+		for i := range spans {
+			spans[i].Attributes = shared
+		}
+		out, err := stage.process(context.Background(), spans)
+		if err != nil {
+			t.Fatalf("process() error = %v", err)
+		}
+		for _, span := range out {
+			users[span.Attributes["user.id"].AsString()] = struct{}{}
+			requests[span.Attributes["request.id"].AsString()] = struct{}{}
+			if span.Attributes["http.route"].AsString() != "/items" {
+				t.Fatalf("expected existing attributes to be kept, got %v", span.Attributes)
+			}
+		}
+	}
+	if len(users) != 50 {
+		t.Fatalf("expected 50 distinct user.id values over 1000 spans, got %d", len(users))
+	}
+	if len(requests) != 1000 {
+		t.Fatalf("expected a unique request.id per span, got %d distinct of 1000", len(requests))
+	}
+	if len(shared) != 1 {
+		t.Fatalf("expected the shared input map to stay untouched, got %v", shared)
+	}
+}
+
+func TestCardinalityFlags(t *testing.T) {
+	var flags CardinalityFlags
+	for _, value := range []string{"user.id=1000", " request.id = 0 "} {
+		if err := flags.Set(value); err != nil {
+			t.Fatalf("Set(%q) error = %v", value, err)
+		}
+	}
+	got := flags.Values()
+	if len(got) != 2 || got[0] != (CardinalityAttribute{Key: "user.id", Cardinality: 1000}) || got[1] != (CardinalityAttribute{Key: "request.id"}) {
+		t.Fatalf("unexpected values %+v", got)
+	}
+	for _, value := range []string{"user.id", "=10", "user.id=-1", "user.id=many"} {
+		if err := flags.Set(value); err == nil {
+			t.Fatalf("expected Set(%q) to fail", value)
+		}
+	}
+}
@@ -3,7 +3,9 @@ package pipeline
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/javiermolinar/tercios/internal/audit"
 	"github.com/javiermolinar/tercios/internal/chaos"
 	"github.com/javiermolinar/tercios/internal/model"
 )
@@ -11,19 +13,51 @@ import (
 type chaosStage struct {
 	engine      *chaos.Engine
 	shouldApply chaos.ShouldApplyFunc
+	// seed is used when shouldApply is nil: each batch gets its own
+	// decider, seeded from its first span, so decisions do not depend on
+	// which exporter worker draws the batch.
+	seed uint64
 }
 
 func NewChaosStage(engine *chaos.Engine, shouldApply chaos.ShouldApplyFunc) BatchStage {
 	return &chaosStage{engine: engine, shouldApply: shouldApply}
 }
 
+// NewSeededChaosStage returns a chaos stage whose probability decisions are
+// reproducible per batch for a seed, however many workers share the stage.
+// Zero picks a random seed.
+func NewSeededChaosStage(engine *chaos.Engine, seed int64) BatchStage {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &chaosStage{engine: engine, seed: uint64(seed)}
+}
+
 func (s *chaosStage) name() string {
 	return "chaos"
 }
 
-func (s *chaosStage) process(_ context.Context, spans []model.Span) ([]model.Span, error) {
+func (s *chaosStage) process(ctx context.Context, spans []model.Span) ([]model.Span, error) {
 	if s == nil || s.engine == nil {
 		return nil, fmt.Errorf("chaos engine not configured")
 	}
-	return s.engine.Apply(spans, s.shouldApply), nil
+	shouldApply := s.shouldApply
+	if shouldApply == nil {
+		if len(spans) == 0 {
+			return spans, nil
+		}
+		shouldApply = chaos.NewSeededShouldApply(int64(spanRandom(s.seed, &spans[0], 0) | 1))
+	}
+	record := audit.FromContext(ctx)
+	if record == nil {
+		return s.engine.Apply(spans, shouldApply), nil
+	}
+	return s.engine.ApplyObserved(spans, shouldApply, func(span *model.Span, policy string) {
+		record.AddPolicyHit(audit.PolicyHit{
+			Policy:   policy,
+			TraceID:  span.TraceID.String(),
+			SpanID:   span.SpanID.String(),
+			SpanName: span.Name,
+		})
+	}), nil
 }
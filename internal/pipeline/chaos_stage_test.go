@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/javiermolinar/tercios/internal/audit"
 	"github.com/javiermolinar/tercios/internal/chaos"
 	"github.com/javiermolinar/tercios/internal/model"
 	"go.opentelemetry.io/otel/attribute"
@@ -49,6 +50,37 @@ func TestChaosStageAppliesPolicy(t *testing.T) {
 	}
 }
 
+func TestChaosStageRecordsPolicyHitsInAudit(t *testing.T) {
+	engine, err := chaos.NewEngine(chaos.Config{
+		Policies: []chaos.Policy{
+			{
+				Name:        "rename",
+				Probability: 1,
+				Match:       chaos.Match{SpanName: "GET /"},
+				Actions:     []chaos.Action{{Type: "set_name", Name: "renamed"}},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+
+	record := &audit.Record{}
+	ctx := audit.WithRecord(context.Background(), record)
+	input := []model.Span{{Name: "GET /", SpanID: oteltrace.SpanID{0x01}}, {Name: "GET /other"}}
+	if _, err := NewChaosStage(engine, chaos.NewSeededShouldApply(1)).process(ctx, input); err != nil {
+		t.Fatalf("process() error = %v", err)
+	}
+
+	if len(record.Chaos) != 1 {
+		t.Fatalf("expected one policy hit, got %+v", record.Chaos)
+	}
+	hit := record.Chaos[0]
+	if hit.Policy != "rename" || hit.SpanName != "GET /" || hit.SpanID != "0100000000000000" {
+		t.Fatalf("unexpected policy hit %+v", hit)
+	}
+}
+
 func TestChaosStageRequiresEngine(t *testing.T) {
 	stage := NewChaosStage(nil, nil)
 	_, err := stage.process(context.Background(), nil)
@@ -56,3 +88,50 @@ func TestChaosStageRequiresEngine(t *testing.T) {
 		t.Fatalf("expected error when chaos engine is nil")
 	}
 }
+
+func TestSeededChaosStageDecisionsDoNotDependOnBatchOrder(t *testing.T) {
+	engine, err := chaos.NewEngine(chaos.Config{
+		Policies: []chaos.Policy{{
+			Name:        "flaky",
+			Probability: 0.5,
+			Match:       chaos.Match{SpanName: "GET /"},
+			Actions:     []chaos.Action{{Type: "set_status", Code: "error"}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+	run := func(order []int) map[oteltrace.SpanID]codes.Code {
+		stage := NewSeededChaosStage(engine, 42)
+		statuses := map[oteltrace.SpanID]codes.Code{}
+		for _, batch := range order {
+			spans := spansWithIDs(8, batch*8)
+			for i := range spans {
+				spans[i].Name = "GET /"
+			}
+			out, err := stage.process(context.Background(), spans)
+			if err != nil {
+				t.Fatalf("process() error = %v", err)
+			}
+			for _, span := range out {
+				statuses[span.SpanID] = span.StatusCode
+			}
+		}
+		return statuses
+	}
+
+	forward := run([]int{0, 1, 2, 3})
+	shuffled := run([]int{3, 1, 0, 2})
+	errors := 0
+	for id, want := range forward {
+		if shuffled[id] != want {
+			t.Fatalf("span %s status differs with batch order", id)
+		}
+		if want == codes.Error {
+			errors++
+		}
+	}
+	if errors == 0 || errors == len(forward) {
+		t.Fatalf("expected the 0.5 policy to hit some spans, got %d of %d", errors, len(forward))
+	}
+}
@@ -0,0 +1,119 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/model"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// EventsConfig configures synthetic span events, so backends that index
+// events receive representative data without every scenario declaring them.
+type EventsConfig struct {
+	// PerSpan is the number of events added to each selected span: message
+	// events on spans that cross a process boundary, log events on
+	// internal spans. Error spans also get one exception event.
+	PerSpan int
+	// Probability is the chance each span is selected, in (0, 1].
+	Probability float64
+	// Seed makes event selection reproducible. Zero picks a random seed.
+	Seed int64
+}
+
+type eventsStage struct {
+	config EventsConfig
+	seed   uint64
+}
+
+func NewEventsStage(cfg EventsConfig) BatchStage {
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &eventsStage{config: cfg, seed: uint64(seed)}
+}
+
+func (s *eventsStage) name() string {
+	return "events"
+}
+
+func (s *eventsStage) process(_ context.Context, spans []model.Span) ([]model.Span, error) {
+	for i := range spans {
+		if s.config.Probability < 1 && spanUnit(s.seed, &spans[i], 0) >= s.config.Probability {
+			continue
+		}
+		s.addEvents(&spans[i])
+	}
+	return spans, nil
+}
+
+// addEvents spreads the events evenly over the span and puts the exception
+// at its end. Events is clipped first so the appends never write into a
+// backing array shared with another span.
+func (s *eventsStage) addEvents(span *model.Span) {
+	duration := span.EndTime.Sub(span.StartTime)
+	events := slices.Clip(span.Events)
+	for i := range s.config.PerSpan {
+		at := span.StartTime.Add(duration * time.Duration(i+1) / time.Duration(s.config.PerSpan+1))
+		events = append(events, s.event(span, i, at))
+	}
+	if span.StatusCode == codes.Error {
+		events = append(events, exceptionEvent(span, span.EndTime))
+	}
+	span.Events = events
+}
+
+// event follows the OpenTelemetry RPC message event conventions: callers
+// send first, receivers receive first, and the direction alternates.
+func (s *eventsStage) event(span *model.Span, index int, at time.Time) model.Event {
+	switch kind := span.Kind; kind {
+	case oteltrace.SpanKindClient, oteltrace.SpanKindServer, oteltrace.SpanKindProducer, oteltrace.SpanKindConsumer:
+		sends := kind == oteltrace.SpanKindClient || kind == oteltrace.SpanKindProducer
+		if index%2 == 1 {
+			sends = !sends
+		}
+		messageType := "RECEIVED"
+		if sends {
+			messageType = "SENT"
+		}
+		return model.Event{
+			Name: "message",
+			Time: at,
+			Attributes: []attribute.KeyValue{
+				attribute.String("message.type", messageType),
+				attribute.Int("message.id", index+1),
+				attribute.Int64("message.uncompressed_size", 64+int64(spanUnit(s.seed, span, uint64(index)+1)*4032)),
+			},
+		}
+	default:
+		return model.Event{
+			Name: "log",
+			Time: at,
+			Attributes: []attribute.KeyValue{
+				attribute.String("log.severity", "INFO"),
+				attribute.String("log.message", fmt.Sprintf("step %d completed", index+1)),
+			},
+		}
+	}
+}
+
+func exceptionEvent(span *model.Span, at time.Time) model.Event {
+	message := span.StatusDescription
+	if message == "" {
+		message = span.Name + " failed"
+	}
+	return model.Event{
+		Name: "exception",
+		Time: at,
+		Attributes: []attribute.KeyValue{
+			attribute.String("exception.type", "tercios.SyntheticError"),
+			attribute.String("exception.message", message),
+			attribute.String("exception.stacktrace", fmt.Sprintf("tercios.SyntheticError: %s\n\tat %s", message, span.Name)),
+		},
+	}
+}
@@ -0,0 +1,114 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/model"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+func TestEventsStageAddsMessageEventsWithinSpan(t *testing.T) {
+	stage := NewEventsStage(EventsConfig{PerSpan: 3, Probability: 1})
+	start := time.Date(2026, time.March, 1, 10, 0, 0, 0, time.UTC)
+	existing := []model.Event{{Name: "scenario", Time: start}}
+	spans := []model.Span{
+		{Name: "GET /items", Kind: oteltrace.SpanKindClient, StartTime: start, EndTime: start.Add(400 * time.Millisecond), Events: existing},
+		{Name: "render", Kind: oteltrace.SpanKindInternal, StartTime: start, EndTime: start.Add(400 * time.Millisecond)},
+	}
+
+	out, err := stage.process(context.Background(), spans)
+	if err != nil {
+		t.Fatalf("process() error = %v", err)
+	}
+	client := out[0].Events
+	if len(client) != 4 || client[0].Name != "scenario" {
+		t.Fatalf("expected the scenario event plus 3 added events, got %+v", client)
+	}
+	wantTypes := []string{"SENT", "RECEIVED", "SENT"}
+	for i, event := range client[1:] {
+		if event.Name != "message" {
+			t.Fatalf("expected message event, got %q", event.Name)
+		}
+		if got := model.AttributesToMap(event.Attributes)["message.type"].AsString(); got != wantTypes[i] {
+			t.Fatalf("event %d message.type = %q, want %q", i, got, wantTypes[i])
+		}
+		if want := start.Add(time.Duration(i+1) * 100 * time.Millisecond); !event.Time.Equal(want) {
+			t.Fatalf("event %d time = %v, want %v", i, event.Time, want)
+		}
+	}
+	if len(existing) != 1 || cap(existing) != 1 {
+		t.Fatalf("expected the input events to stay untouched")
+	}
+	if internal := out[1].Events; len(internal) != 3 || internal[0].Name != "log" {
+		t.Fatalf("expected 3 log events on the internal span, got %+v", internal)
+	}
+}
+
+func TestEventsStageAddsExceptionToErrorSpans(t *testing.T) {
+	stage := NewEventsStage(EventsConfig{PerSpan: 1, Probability: 1})
+	start := time.Date(2026, time.March, 1, 10, 0, 0, 0, time.UTC)
+	out, err := stage.process(context.Background(), []model.Span{{
+		Name:              "charge",
+		Kind:              oteltrace.SpanKindServer,
+		StartTime:         start,
+		EndTime:           start.Add(time.Second),
+		StatusCode:        codes.Error,
+		StatusDescription: "card declined",
+	}})
+	if err != nil {
+		t.Fatalf("process() error = %v", err)
+	}
+	events := out[0].Events
+	if len(events) != 2 {
+		t.Fatalf("expected a message and an exception event, got %+v", events)
+	}
+	exception := events[1]
+	attrs := model.AttributesToMap(exception.Attributes)
+	if exception.Name != "exception" || !exception.Time.Equal(start.Add(time.Second)) || attrs["exception.message"].AsString() != "card declined" {
+		t.Fatalf("unexpected exception event %+v", exception)
+	}
+}
+
+func TestEventsStageHonorsProbability(t *testing.T) {
+	stage := NewEventsStage(EventsConfig{PerSpan: 1, Probability: 0.5})
+	spans := spansWithIDs(1000, 0)
+	out, err := stage.process(context.Background(), spans)
+	if err != nil {
+		t.Fatalf("process() error = %v", err)
+	}
+	selected := 0
+	for _, span := range out {
+		if len(span.Events) > 0 {
+			selected++
+		}
+	}
+	if selected < 400 || selected > 600 {
+		t.Fatalf("expected about half of the spans to get events, got %d of 1000", selected)
+	}
+}
+
+func TestEventsStageSeedIsReproducible(t *testing.T) {
+	selected := func(seed int64) []int {
+		stage := NewEventsStage(EventsConfig{PerSpan: 1, Probability: 0.5, Seed: seed})
+		spans := spansWithIDs(64, 0)
+		out, err := stage.process(context.Background(), spans)
+		if err != nil {
+			t.Fatalf("process() error = %v", err)
+		}
+		var indexes []int
+		for i, span := range out {
+			if len(span.Events) > 0 {
+				indexes = append(indexes, i)
+			}
+		}
+		return indexes
+	}
+	first, second := selected(42), selected(42)
+	if len(first) == 0 || len(first) == 64 || fmt.Sprint(first) != fmt.Sprint(second) {
+		t.Fatalf("expected the same seed to select the same spans, got %v and %v", first, second)
+	}
+}
@@ -0,0 +1,71 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/metrics"
+	"github.com/javiermolinar/tercios/internal/model"
+)
+
+// FutureTimestampsConfig configures the future timestamp mode, which
+// quantifies how a backend validates and rejects clock-skewed data.
+type FutureTimestampsConfig struct {
+	// Fraction of traces, chosen by trace ID, whose spans are moved into
+	// the future. Whole traces move so parent/child timing stays valid.
+	Fraction float64
+	// Offset is how far ahead of their generated time the spans are sent.
+	Offset time.Duration
+}
+
+type futureTimestampsStage struct {
+	config  FutureTimestampsConfig
+	shifted atomic.Int64
+}
+
+func NewFutureTimestampsStage(cfg FutureTimestampsConfig) BatchStage {
+	return &futureTimestampsStage{config: cfg}
+}
+
+func (s *futureTimestampsStage) name() string {
+	return "future-timestamps"
+}
+
+func (s *futureTimestampsStage) process(_ context.Context, spans []model.Span) ([]model.Span, error) {
+	shifted := 0
+	for i := range spans {
+		if !s.selected(spans[i].TraceID) {
+			continue
+		}
+		span := &spans[i]
+		span.StartTime = span.StartTime.Add(s.config.Offset)
+		span.EndTime = span.EndTime.Add(s.config.Offset)
+		if len(span.Events) > 0 {
+			events := make([]model.Event, len(span.Events))
+			copy(events, span.Events)
+			for j := range events {
+				events[j].Time = events[j].Time.Add(s.config.Offset)
+			}
+			span.Events = events
+		}
+		shifted++
+	}
+	s.shifted.Add(int64(shifted))
+	return spans, nil
+}
+
+// selected uses the high half of the trace ID; freshness sampling uses the
+// low half, so the two selections stay independent.
+func (s *futureTimestampsStage) selected(traceID [16]byte) bool {
+	if s.config.Fraction >= 1 {
+		return true
+	}
+	return float64(binary.BigEndian.Uint64(traceID[:8])) < s.config.Fraction*math.MaxUint64
+}
+
+func (s *futureTimestampsStage) annotate(summary *metrics.Summary) {
+	summary.FutureDatedSpans = int(s.shifted.Load())
+}
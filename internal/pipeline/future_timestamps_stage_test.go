@@ -0,0 +1,52 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/model"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+func TestFutureTimestampsStageShiftsSelectedTraces(t *testing.T) {
+	start := time.Date(2026, time.March, 1, 10, 0, 0, 0, time.UTC)
+	stage := NewFutureTimestampsStage(FutureTimestampsConfig{Fraction: 0.5, Offset: time.Hour}).(*futureTimestampsStage)
+
+	lowTrace := oteltrace.TraceID{0x10}
+	highTrace := oteltrace.TraceID{0xf0}
+	events := []model.Event{{Name: "retry", Time: start.Add(time.Millisecond)}}
+	spans := []model.Span{
+		{TraceID: lowTrace, StartTime: start, EndTime: start.Add(time.Second), Events: events},
+		{TraceID: highTrace, StartTime: start, EndTime: start.Add(time.Second)},
+	}
+
+	out, err := stage.process(context.Background(), spans)
+	if err != nil {
+		t.Fatalf("process() error = %v", err)
+	}
+	if !out[0].StartTime.Equal(start.Add(time.Hour)) || !out[0].EndTime.Equal(start.Add(time.Hour+time.Second)) {
+		t.Fatalf("expected selected span to move one hour ahead, got %s-%s", out[0].StartTime, out[0].EndTime)
+	}
+	if !out[0].Events[0].Time.Equal(start.Add(time.Hour + time.Millisecond)) {
+		t.Fatalf("expected event to move with its span, got %s", out[0].Events[0].Time)
+	}
+	if !events[0].Time.Equal(start.Add(time.Millisecond)) {
+		t.Fatalf("expected input events to stay untouched")
+	}
+	if !out[1].StartTime.Equal(start) {
+		t.Fatalf("expected unselected span to keep its time, got %s", out[1].StartTime)
+	}
+}
+
+func TestPipelineSummaryCountsFutureDatedSpans(t *testing.T) {
+	runner := NewConcurrencyRunner(1, 3)
+	pipe := New(fixedModelStage{}, NewFutureTimestampsStage(FutureTimestampsConfig{Fraction: 1, Offset: time.Minute}))
+
+	if err := pipe.RunWithOptions(context.Background(), runner, noopBatchExporterFactory{}, RunOptions{}); err != nil {
+		t.Fatalf("pipeline run error: %v", err)
+	}
+	if got := pipe.Summary().FutureDatedSpans; got != 3 {
+		t.Fatalf("expected 3 future-dated spans, got %d", got)
+	}
+}
@@ -0,0 +1,141 @@
+package pipeline
+
+import (
+	"context"
+	"maps"
+	"math"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/model"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// JitterConfig configures per-request jitter, which keeps consecutive
+// batches from being identical so backend caches and dictionary
+// compression cannot make repeated or replayed load unrealistically cheap.
+type JitterConfig struct {
+	// Attributes is the relative amount numeric span attribute values are
+	// moved by, in either direction: 0.1 scales each by a factor in
+	// [0.9, 1.1]. Integers stay integers. Zero disables it.
+	Attributes float64
+	// Spans is the largest fraction of leaf spans dropped from a batch.
+	// Each batch drops a random share between none and Spans; only leaves
+	// are dropped so no remaining span loses its parent. Zero disables it.
+	Spans float64
+	// Seed makes the jitter reproducible. Zero picks a random seed.
+	Seed int64
+}
+
+type jitterStage struct {
+	config JitterConfig
+	seed   uint64
+}
+
+func NewJitterStage(cfg JitterConfig) BatchStage {
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &jitterStage{config: cfg, seed: uint64(seed)}
+}
+
+func (s *jitterStage) name() string {
+	return "jitter"
+}
+
+func (s *jitterStage) process(_ context.Context, spans []model.Span) ([]model.Span, error) {
+	if s.config.Spans > 0 {
+		spans = s.dropLeaves(spans)
+	}
+	if s.config.Attributes > 0 {
+		for i := range spans {
+			spans[i].Attributes = s.jitterAttributes(&spans[i])
+		}
+	}
+	return spans, nil
+}
+
+// dropLeaves removes a random number of spans that no other span in the
+// batch names as its parent. Root spans are never dropped. The number is
+// keyed on the batch's first span and the choice on each leaf.
+func (s *jitterStage) dropLeaves(spans []model.Span) []model.Span {
+	if len(spans) == 0 {
+		return spans
+	}
+	parents := make(map[oteltrace.SpanID]struct{}, len(spans))
+	for _, span := range spans {
+		if span.ParentSpanID.IsValid() {
+			parents[span.ParentSpanID] = struct{}{}
+		}
+	}
+	leaves := 0
+	for _, span := range spans {
+		if isDroppableLeaf(span, parents) {
+			leaves++
+		}
+	}
+	drop := int(spanUnit(s.seed, &spans[0], 0) * s.config.Spans * float64(leaves+1))
+	if drop > leaves {
+		drop = leaves
+	}
+	if drop == 0 {
+		return spans
+	}
+
+	out := make([]model.Span, 0, len(spans)-drop)
+	for i, span := range spans {
+		// Selection sampling: keep each leaf with the probability that
+		// leaves exactly drop of them dropped by the end.
+		if drop > 0 && isDroppableLeaf(span, parents) {
+			if spanUnit(s.seed, &spans[i], 1)*float64(leaves) < float64(drop) {
+				drop--
+				leaves--
+				continue
+			}
+			leaves--
+		}
+		out = append(out, spans[i])
+	}
+	return out
+}
+
+func isDroppableLeaf(span model.Span, parents map[oteltrace.SpanID]struct{}) bool {
+	if !span.ParentSpanID.IsValid() {
+		return false
+	}
+	_, isParent := parents[span.SpanID]
+	return !isParent
+}
+
+// jitterAttributes returns a copy of the span's attributes with numeric
+// values jittered; the input map may be shared with other batches and is
+// left untouched.
+func (s *jitterStage) jitterAttributes(span *model.Span) map[string]attribute.Value {
+	attrs := span.Attributes
+	var out map[string]attribute.Value
+	for key, value := range attrs {
+		var jittered attribute.Value
+		switch value.Type() {
+		case attribute.INT64:
+			jittered = attribute.Int64Value(int64(math.Round(float64(value.AsInt64()) * s.factor(span, key))))
+		case attribute.FLOAT64:
+			jittered = attribute.Float64Value(value.AsFloat64() * s.factor(span, key))
+		default:
+			continue
+		}
+		if out == nil {
+			out = make(map[string]attribute.Value, len(attrs))
+			maps.Copy(out, attrs)
+		}
+		out[key] = jittered
+	}
+	if out == nil {
+		return attrs
+	}
+	return out
+}
+
+func (s *jitterStage) factor(span *model.Span, key string) float64 {
+	return 1 + (2*spanUnit(s.seed, span, keyDraw(key))-1)*s.config.Attributes
+}
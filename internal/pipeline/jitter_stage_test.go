@@ -0,0 +1,90 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/model"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+func TestJitterStageMovesNumericAttributesWithinBounds(t *testing.T) {
+	stage := NewJitterStage(JitterConfig{Attributes: 0.1, Seed: 7})
+	shared := map[string]attribute.Value{
+		"http.response.size": attribute.Int64Value(1000),
+		"ratio":              attribute.Float64Value(2),
+		"http.route":         attribute.StringValue("/items"),
+	}
+
+	changed := false
+	for range 20 {
+		out, err := stage.process(context.Background(), []model.Span{{Attributes: shared}})
+		if err != nil {
+			t.Fatalf("process() error = %v", err)
+		}
+		attrs := out[0].Attributes
+		size := attrs["http.response.size"]
+		if size.Type() != attribute.INT64 || size.AsInt64() < 900 || size.AsInt64() > 1100 {
+			t.Fatalf("int attribute out of bounds: %v", size.Emit())
+		}
+		if ratio := attrs["ratio"].AsFloat64(); ratio < 1.8 || ratio > 2.2 {
+			t.Fatalf("float attribute out of bounds: %v", ratio)
+		}
+		if attrs["http.route"].AsString() != "/items" {
+			t.Fatalf("string attribute changed: %v", attrs["http.route"].Emit())
+		}
+		changed = changed || size.AsInt64() != 1000
+	}
+	if !changed {
+		t.Fatalf("expected jitter to change the int attribute at least once")
+	}
+	if shared["http.response.size"].AsInt64() != 1000 {
+		t.Fatalf("expected the input attribute map to stay untouched")
+	}
+}
+
+func TestJitterStageDropsOnlyLeafSpans(t *testing.T) {
+	stage := NewJitterStage(JitterConfig{Spans: 1, Seed: 3})
+	start := time.Date(2026, time.March, 1, 10, 0, 0, 0, time.UTC)
+	batch := func(n int) []model.Span {
+		trace := oteltrace.TraceID{0x01, byte(n)}
+		spans := []model.Span{
+			{TraceID: trace, SpanID: oteltrace.SpanID{0x01}, Name: "root", StartTime: start},
+			{TraceID: trace, SpanID: oteltrace.SpanID{0x02}, ParentSpanID: oteltrace.SpanID{0x01}, Name: "middle", StartTime: start},
+		}
+		for i := range 8 {
+			spans = append(spans, model.Span{TraceID: trace, SpanID: oteltrace.SpanID{0x10, byte(i)}, ParentSpanID: oteltrace.SpanID{0x02}, Name: "leaf", StartTime: start})
+		}
+		return spans
+	}
+
+	sizes := map[int]bool{}
+	for n := range 50 {
+		out, err := stage.process(context.Background(), batch(n))
+		if err != nil {
+			t.Fatalf("process() error = %v", err)
+		}
+		if len(out) < 2 || out[0].Name != "root" || out[1].Name != "middle" {
+			t.Fatalf("expected root and parent spans to be kept, got %d spans", len(out))
+		}
+		sizes[len(out)] = true
+	}
+	if len(sizes) < 3 {
+		t.Fatalf("expected batch sizes to vary, got %v", sizes)
+	}
+}
+
+func TestJitterStageIsReproducibleWithSeed(t *testing.T) {
+	attrs := map[string]attribute.Value{"n": attribute.Int64Value(1_000_000)}
+	first := NewJitterStage(JitterConfig{Attributes: 0.5, Seed: 42})
+	second := NewJitterStage(JitterConfig{Attributes: 0.5, Seed: 42})
+	for range 5 {
+		a, _ := first.process(context.Background(), []model.Span{{Attributes: attrs}})
+		b, _ := second.process(context.Background(), []model.Span{{Attributes: attrs}})
+		if a[0].Attributes["n"] != b[0].Attributes["n"] {
+			t.Fatalf("expected identical jitter for the same seed")
+		}
+	}
+}
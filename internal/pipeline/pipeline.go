@@ -2,21 +2,36 @@ package pipeline
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/javiermolinar/tercios/internal/audit"
+	"github.com/javiermolinar/tercios/internal/clock"
+	"github.com/javiermolinar/tercios/internal/idgen"
+	"github.com/javiermolinar/tercios/internal/loadprofile"
 	"github.com/javiermolinar/tercios/internal/metrics"
 	"github.com/javiermolinar/tercios/internal/model"
+	"github.com/javiermolinar/tercios/internal/timefmt"
 	"golang.org/x/sync/errgroup"
 )
 
+// BatchStage transforms one batch. A generating stage returns io.EOF when
+// its source is exhausted, which stops the producer worker without error.
 type BatchStage interface {
 	name() string
 	process(ctx context.Context, spans []model.Span) ([]model.Span, error)
 }
 
+// summaryAnnotator is implemented by stages that add their own counters to
+// the run summary.
+type summaryAnnotator interface {
+	annotate(summary *metrics.Summary)
+}
+
 type ExporterFactory interface {
 	NewBatchExporter(ctx context.Context) (model.BatchExporter, error)
 }
@@ -31,25 +46,140 @@ func New(stages ...BatchStage) *Pipeline {
 }
 
 func (p *Pipeline) Process(ctx context.Context, spans []model.Span) ([]model.Span, error) {
+	record := audit.FromContext(ctx)
 	batch := spans
 	for _, stage := range p.stages {
 		if stage == nil {
 			continue
 		}
+		spansIn := len(batch)
 		var err error
 		batch, err = stage.process(ctx, batch)
 		if err != nil {
 			return nil, fmt.Errorf("stage %s: %w", stage.name(), err)
 		}
+		record.AddStage(stage.name(), spansIn, len(batch))
 	}
 	return batch, nil
 }
 
 type exportResult struct {
+	worker   int
+	started  time.Time
 	duration time.Duration
 	err      error
 	traceIDs []string
 	spans    int
+	attempts int
+	// bytes is the RequestSize of a successful export.
+	bytes int
+	// queued is how long an open-loop batch waited for an exporter after
+	// its scheduled arrival; negative for closed-loop batches.
+	queued time.Duration
+}
+
+// queuedBatch is a batch on its way to an exporter. arrived is the
+// scheduled arrival of an open-loop batch and zero otherwise.
+type queuedBatch struct {
+	batch   model.Batch
+	arrived time.Time
+}
+
+// DefaultMaxInFlight bounds open-loop batches waiting for or in export
+// when RunOptions.MaxInFlight is zero.
+const DefaultMaxInFlight = 1000
+
+// RunOptions controls how a Pipeline run paces, times out, and reports.
+// The zero value sends as fast as possible with no duration limit.
+type RunOptions struct {
+	RequestInterval time.Duration
+	RequestDuration time.Duration
+	RampUpDuration  time.Duration
+	// RampWorkersDuration staggers worker startup over the duration: each
+	// worker opens (and warms up) its exporter and starts sending only at
+	// its turn, so connection handshakes do not all hit the endpoint in
+	// the first instant. Zero opens every exporter before the run starts.
+	RampWorkersDuration time.Duration
+	// TotalSpans stops the run once the batches exported successfully
+	// across all workers add up to at least that many spans. The batch
+	// crossing the target is sent whole, so traces are never cut. Zero
+	// means no span budget.
+	TotalSpans         int64
+	ExportTimeout      time.Duration
+	TraceIDSampleLimit int
+	ProgressInterval   time.Duration
+	ProgressWriter     io.Writer
+	// AlignProgress prints progress lines on wall-clock multiples of
+	// ProgressInterval, each stamped with its boundary, so the lines of
+	// several processes cover the same intervals.
+	AlignProgress bool
+	// SummaryInterval, with OnSummary set, hands the summary of the run
+	// so far to OnSummary at this interval, aligned to the wall clock
+	// like progress lines when AlignProgress is set. OnSummary runs in
+	// the stats loop, so it must return quickly.
+	SummaryInterval time.Duration
+	OnSummary       func(summary metrics.Summary, at time.Time)
+	// TimeFormat controls how durations are rendered in progress lines.
+	TimeFormat timefmt.Options
+	// Warmup sends one throwaway export on every exporter before the
+	// measured phase starts, so connection setup latency is not recorded
+	// against the first requests of each worker. Exporters that do not
+	// implement model.Warmer are opened but not probed.
+	Warmup bool
+	// SlowestRequestsLimit keeps the N slowest export requests in the
+	// summary. Zero disables tracking.
+	SlowestRequestsLimit int
+	// RequestSize, when set, measures every successfully exported batch,
+	// typically as its OTLP payload, for the bytes sent in the summary.
+	// It runs on the export path.
+	RequestSize func(batch model.Batch) int
+	// WorkerBreakdown adds the results of each export worker to the
+	// summary, to spot one bad connection skewing the aggregate.
+	WorkerBreakdown bool
+	// ExportRetries is the number of extra attempts made for a batch whose
+	// export failed. Every retry may duplicate spans the backend already
+	// accepted, so retries are reported separately in the summary.
+	ExportRetries int
+	// RetryBackoff is the delay before the first retry; it grows linearly
+	// with each further attempt.
+	RetryBackoff time.Duration
+	// OnExported, when set, is called by the export worker after each
+	// successful export with the batch and the time the export finished.
+	// It runs on the export path, so it must return quickly and be safe
+	// for concurrent use.
+	OnExported func(batch model.Batch, exportedAt time.Time)
+	// LoadProfile, when set, paces requests across all workers to follow
+	// the profile's total rate. It is applied on top of RequestInterval.
+	LoadProfile *loadprofile.Profile
+	// Audit, when set, receives one record per generated batch describing
+	// its generator, chaos policy hits, and per-stage span counts.
+	Audit *audit.Log
+	// ArrivalRate, when above zero, runs open-loop: batches are generated
+	// at this many requests per second across the run, whether or not
+	// earlier exports have finished, and wait in a queue for a free
+	// exporter. Backend slowness then shows up as queue wait instead of a
+	// lower request rate. The request limit of the runner caps the total
+	// arrivals. It cannot be combined with RequestInterval, RampUpDuration
+	// or LoadProfile.
+	ArrivalRate float64
+	// Heartbeat, when set, is called from the stats loop after each
+	// finished export, and every second while all workers are sleeping
+	// between requests, but never while an export hangs. It feeds
+	// liveness checks such as the systemd watchdog.
+	Heartbeat func()
+	// MaxInFlight bounds the open-loop batches queued or being exported;
+	// arrivals beyond it are dropped and counted in the summary. Zero uses
+	// DefaultMaxInFlight.
+	MaxInFlight int
+	// ContinueOnError keeps the run going when an export still fails
+	// after its retries: the failure is recorded in the summary and the
+	// worker reconnects, shutting its exporter down and opening a new one
+	// for its next batch. Permanent runs such as synthetics mode use it so
+	// an ingest outage is reported rather than ending the run.
+	ContinueOnError bool
+	// OnResult, when set, is called from the stats loop after each export
+	// with the number of spans sent and the export error, if any.
+	OnResult func(spans int, err error)
 }
 
 func (p *Pipeline) Run(ctx context.Context, runner *ConcurrencyRunner, factory ExporterFactory, requestInterval time.Duration, requestDuration time.Duration, rampUpDuration time.Duration, exportTimeout time.Duration, traceIDSampleLimit int) error {
@@ -57,6 +187,18 @@ func (p *Pipeline) Run(ctx context.Context, runner *ConcurrencyRunner, factory E
 }
 
 func (p *Pipeline) RunWithProgress(ctx context.Context, runner *ConcurrencyRunner, factory ExporterFactory, requestInterval time.Duration, requestDuration time.Duration, rampUpDuration time.Duration, exportTimeout time.Duration, traceIDSampleLimit int, progressInterval time.Duration, progressWriter io.Writer) error {
+	return p.RunWithOptions(ctx, runner, factory, RunOptions{
+		RequestInterval:    requestInterval,
+		RequestDuration:    requestDuration,
+		RampUpDuration:     rampUpDuration,
+		ExportTimeout:      exportTimeout,
+		TraceIDSampleLimit: traceIDSampleLimit,
+		ProgressInterval:   progressInterval,
+		ProgressWriter:     progressWriter,
+	})
+}
+
+func (p *Pipeline) RunWithOptions(ctx context.Context, runner *ConcurrencyRunner, factory ExporterFactory, opts RunOptions) error {
 	if runner == nil {
 		return fmt.Errorf("concurrency runner not configured")
 	}
@@ -66,11 +208,42 @@ func (p *Pipeline) RunWithProgress(ctx context.Context, runner *ConcurrencyRunne
 	if runner.Workers() <= 0 {
 		return fmt.Errorf("workers must be > 0")
 	}
+	if opts.ArrivalRate > 0 && (opts.RequestInterval > 0 || opts.RampUpDuration > 0 || opts.LoadProfile != nil) {
+		return fmt.Errorf("arrival rate cannot be combined with a request interval, ramp-up or load profile")
+	}
+
+	requestInterval := opts.RequestInterval
+	requestDuration := opts.RequestDuration
+	rampUpDuration := opts.RampUpDuration
+	exportTimeout := opts.ExportTimeout
+	traceIDSampleLimit := opts.TraceIDSampleLimit
+	progressInterval := opts.ProgressInterval
+	progressWriter := opts.ProgressWriter
 
 	workerCount := runner.Workers()
 	requestsPerWorker := runner.RequestsPerWorker()
 
-	batchChannel := make(chan model.Batch, workerCount*2)
+	rampWorkersDuration := opts.RampWorkersDuration
+	exporters := make([]model.BatchExporter, workerCount)
+	if rampWorkersDuration <= 0 {
+		var err error
+		exporters, err = openExporters(ctx, factory, workerCount, opts.Warmup, exportTimeout)
+		if err != nil {
+			p.summary = metrics.Summary{}
+			return err
+		}
+	}
+
+	batchCapacity := workerCount * 2
+	maxInFlight := opts.MaxInFlight
+	if maxInFlight <= 0 {
+		maxInFlight = DefaultMaxInFlight
+	}
+	if opts.ArrivalRate > 0 {
+		// Arrivals never block: the channel holds every batch in flight.
+		batchCapacity = maxInFlight
+	}
+	batchChannel := make(chan queuedBatch, batchCapacity)
 	summaryChannel := make(chan exportResult, workerCount*4)
 	finalSummary := make(chan metrics.Summary, 1)
 
@@ -78,18 +251,63 @@ func (p *Pipeline) RunWithProgress(ctx context.Context, runner *ConcurrencyRunne
 
 	startTime := time.Now()
 
+	var pacer *loadprofile.Pacer
+	paceCtx := groupCtx
+	if opts.LoadProfile != nil {
+		pacer = loadprofile.NewPacer(*opts.LoadProfile, startTime)
+		if requestDuration > 0 {
+			var cancel context.CancelFunc
+			paceCtx, cancel = context.WithDeadline(groupCtx, startTime.Add(requestDuration))
+			defer cancel()
+		}
+	}
+
+	var runEnd time.Time
+	if requestDuration > 0 {
+		runEnd = startTime.Add(requestDuration)
+	}
+	// nextSend holds, per worker, when a worker sleeping through
+	// RequestInterval will send again (unix nanos, zero while busy), so
+	// progress lines of trickle-traffic runs can say when the next request
+	// is due.
+	nextSend := make([]atomic.Int64, workerCount)
+	var budget *spanBudget
+	if opts.TotalSpans > 0 {
+		budget = &spanBudget{target: opts.TotalSpans}
+	}
+	// inFlight and dropped track open-loop batches between arrival and the
+	// end of their export, and the arrivals skipped over maxInFlight.
+	var inFlight, dropped atomic.Int64
+
 	var producerWG sync.WaitGroup
-	for i := 0; i < workerCount; i++ {
+	if opts.ArrivalRate > 0 {
+		producerWG.Add(1)
+		group.Go(func() error {
+			defer producerWG.Done()
+			return p.produceArrivals(groupCtx, arrivalSchedule{
+				start:       startTime,
+				end:         runEnd,
+				interval:    time.Duration(float64(time.Second) / opts.ArrivalRate),
+				limit:       workerCount * requestsPerWorker,
+				budget:      budget,
+				maxInFlight: int64(maxInFlight),
+			}, opts.Audit, batchChannel, &inFlight, &dropped)
+		})
+	}
+	for i := 0; i < workerCount && opts.ArrivalRate <= 0; i++ {
 		workerID := i
 		producerWG.Add(1)
 		group.Go(func() error {
 			defer producerWG.Done()
 
-			if delay := rampUpDelay(workerID, workerCount, rampUpDuration); delay > 0 {
-				select {
-				case <-groupCtx.Done():
-					return groupCtx.Err()
-				case <-time.After(delay):
+			delay := max(rampUpDelay(workerID, workerCount, rampUpDuration), rampUpDelay(workerID, workerCount, rampWorkersDuration))
+			if delay > 0 {
+				more, err := waitInterval(groupCtx, delay, runEnd)
+				if err != nil {
+					return err
+				}
+				if !more {
+					return nil
 				}
 			}
 
@@ -105,25 +323,59 @@ func (p *Pipeline) RunWithProgress(ctx context.Context, runner *ConcurrencyRunne
 					return groupCtx.Err()
 				default:
 				}
+				if more, err := budget.wait(groupCtx); err != nil || !more {
+					return err
+				}
+				if pacer != nil {
+					if err := pacer.Wait(paceCtx); err != nil {
+						// Either the run was cancelled or its duration
+						// ended while waiting for the next slot.
+						return groupCtx.Err()
+					}
+				}
 
-				batch, err := p.Process(groupCtx, nil)
+				processCtx := idgen.WithWorker(groupCtx, workerID)
+				var record *audit.Record
+				if opts.Audit != nil {
+					record = &audit.Record{Time: time.Now(), Worker: workerID, Request: request}
+					processCtx = audit.WithRecord(processCtx, record)
+				}
+				batch, err := p.Process(processCtx, nil)
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
 				if err != nil {
 					return err
 				}
+				if len(batch) > 0 && !budget.reserve(len(batch)) {
+					// Other workers' batches already cover the budget;
+					// drop this one and wait to see whether they export.
+					continue
+				}
+				if record != nil {
+					record.Spans = len(batch)
+					if err := opts.Audit.Write(record); err != nil {
+						return fmt.Errorf("write audit record: %w", err)
+					}
+				}
 				if len(batch) > 0 {
 					select {
 					case <-groupCtx.Done():
 						return groupCtx.Err()
-					case batchChannel <- model.Batch(batch):
+					case batchChannel <- queuedBatch{batch: batch}:
 					}
 				}
 
 				if requestInterval > 0 {
 					if requestsPerWorker <= 0 || request < requestsPerWorker-1 {
-						select {
-						case <-groupCtx.Done():
-							return groupCtx.Err()
-						case <-time.After(requestInterval):
+						nextSend[workerID].Store(time.Now().Add(requestInterval).UnixNano())
+						more, err := waitInterval(groupCtx, requestInterval, runEnd)
+						nextSend[workerID].Store(0)
+						if err != nil {
+							return err
+						}
+						if !more {
+							return nil
 						}
 					}
 				}
@@ -131,8 +383,12 @@ func (p *Pipeline) RunWithProgress(ctx context.Context, runner *ConcurrencyRunne
 		})
 	}
 
+	// producersDone lets exporters still waiting for their ramp turn
+	// skip opening a connection the run no longer needs.
+	producersDone := make(chan struct{})
 	group.Go(func() error {
 		producerWG.Wait()
+		close(producersDone)
 		close(batchChannel)
 		return nil
 	})
@@ -143,11 +399,30 @@ func (p *Pipeline) RunWithProgress(ctx context.Context, runner *ConcurrencyRunne
 		exporterWG.Add(1)
 		group.Go(func() (err error) {
 			defer exporterWG.Done()
-			exporter, err := factory.NewBatchExporter(groupCtx)
-			if err != nil {
-				return fmt.Errorf("export worker=%d init: %w", workerID, err)
+			exporter := exporters[workerID]
+			if exporter == nil {
+				// Worker 0 has no delay, so batches sent before later
+				// workers open are always drained.
+				if delay := rampUpDelay(workerID, workerCount, rampWorkersDuration); delay > 0 {
+					timer := time.NewTimer(delay)
+					select {
+					case <-groupCtx.Done():
+						timer.Stop()
+						return groupCtx.Err()
+					case <-producersDone:
+						timer.Stop()
+						return nil
+					case <-timer.C:
+					}
+				}
+				if exporter, err = openExporter(groupCtx, factory, workerID, opts.Warmup, exportTimeout); err != nil {
+					return err
+				}
 			}
 			defer func() {
+				if exporter == nil {
+					return
+				}
 				if shutdownErr := exporter.Shutdown(groupCtx); shutdownErr != nil && err == nil {
 					err = fmt.Errorf("export worker=%d shutdown: %w", workerID, shutdownErr)
 				}
@@ -157,32 +432,50 @@ func (p *Pipeline) RunWithProgress(ctx context.Context, runner *ConcurrencyRunne
 				select {
 				case <-groupCtx.Done():
 					return groupCtx.Err()
-				case batch, ok := <-batchChannel:
+				case item, ok := <-batchChannel:
 					if !ok {
 						return nil
 					}
 
-					exportCtx := groupCtx
-					cancel := func() {}
-					if exportTimeout > 0 {
-						exportCtx, cancel = context.WithTimeout(groupCtx, exportTimeout)
-					}
+					batch := item.batch
 					traceIDs := sampleTraceIDs(batch, traceIDSampleLimit)
 					start := time.Now()
-					err := exporter.ExportBatch(exportCtx, batch)
-					cancel()
+					var attempts int
+					var err error
+					if exporter == nil {
+						// The last export failed; reconnect first.
+						exporter, err = openExporter(groupCtx, factory, workerID, false, exportTimeout)
+					}
+					if exporter != nil {
+						attempts, err = exportWithRetry(groupCtx, exporter, batch, exportTimeout, opts.ExportRetries, opts.RetryBackoff)
+					}
+					budget.settle(len(batch), err)
 					if err != nil {
 						err = fmt.Errorf("export worker=%d: %w", workerID, err)
 					}
-					result := exportResult{duration: time.Since(start), err: err, traceIDs: traceIDs, spans: len(batch)}
+					result := exportResult{worker: workerID, started: start, duration: time.Since(start), err: err, traceIDs: traceIDs, spans: len(batch), attempts: attempts, queued: -1}
+					if !item.arrived.IsZero() {
+						result.queued = start.Sub(item.arrived)
+						inFlight.Add(-1)
+					}
+					if err == nil && opts.RequestSize != nil {
+						result.bytes = opts.RequestSize(batch)
+					}
+					if err == nil && opts.OnExported != nil {
+						opts.OnExported(batch, start.Add(result.duration))
+					}
 					select {
 					case <-groupCtx.Done():
 						return groupCtx.Err()
 					case summaryChannel <- result:
 					}
-					if err != nil {
+					if err != nil && (!opts.ContinueOnError || groupCtx.Err() != nil) {
 						return err
 					}
+					if err != nil && exporter != nil {
+						_ = exporter.Shutdown(groupCtx)
+						exporter = nil
+					}
 				}
 			}
 		})
@@ -198,26 +491,82 @@ func (p *Pipeline) RunWithProgress(ctx context.Context, runner *ConcurrencyRunne
 
 	group.Go(func() error {
 		stats := metrics.NewStatsWithTraceIDSampleLimit(traceIDSampleLimit)
+		stats.SetSlowestRequestsLimit(opts.SlowestRequestsLimit)
+		stats.SetWorkerBreakdown(opts.WorkerBreakdown)
+		// Rates of a run cut short are computed over whole intervals of
+		// the request pacing (at least a second), so the final interval
+		// the run stopped in does not count as a slow one.
+		stats.SetRateInterval(startTime, max(requestInterval, time.Second))
 
-		var ticker *time.Ticker
 		var tickCh <-chan time.Time
 		if progressInterval > 0 && progressWriter != nil {
-			ticker = time.NewTicker(progressInterval)
+			ticker := clock.NewTicker(progressInterval, opts.AlignProgress)
 			tickCh = ticker.C
 			defer ticker.Stop()
 		}
+		var summaryCh <-chan time.Time
+		if opts.SummaryInterval > 0 && opts.OnSummary != nil {
+			ticker := clock.NewTicker(opts.SummaryInterval, opts.AlignProgress)
+			summaryCh = ticker.C
+			defer ticker.Stop()
+		}
+		var heartbeatCh <-chan time.Time
+		if opts.Heartbeat != nil {
+			heartbeatTicker := time.NewTicker(time.Second)
+			heartbeatCh = heartbeatTicker.C
+			defer heartbeatTicker.Stop()
+		}
 
 		for {
 			select {
 			case result, ok := <-summaryChannel:
 				if !ok {
-					finalSummary <- stats.SummaryWithElapsed(time.Since(startTime))
+					elapsed := time.Since(startTime)
+					if ctx.Err() != nil || (requestDuration > 0 && elapsed >= requestDuration) {
+						finalSummary <- stats.SummaryExcludingPartialInterval(elapsed)
+					} else {
+						finalSummary <- stats.SummaryWithElapsed(elapsed)
+					}
 					close(finalSummary)
 					return nil
 				}
-				stats.RecordBatchWithTraceIDs(result.duration, result.err, result.traceIDs, result.spans)
-			case <-tickCh:
-				_, _ = fmt.Fprintln(progressWriter, metrics.FormatProgress(stats.SummaryWithElapsed(time.Since(startTime)), expectedTotal))
+				stats.RecordBatchAt(result.started, result.duration, result.err, result.traceIDs, result.spans)
+				stats.RecordRetries(result.attempts-1, result.spans)
+				stats.RecordWorker(result.worker, result.duration, result.err)
+				if result.bytes > 0 {
+					stats.RecordSentBytes(result.started, result.bytes)
+				}
+				if result.queued >= 0 {
+					stats.RecordQueueWait(result.queued)
+				}
+				if opts.OnResult != nil {
+					opts.OnResult(result.spans, result.err)
+				}
+				if opts.Heartbeat != nil {
+					opts.Heartbeat()
+				}
+			case <-heartbeatCh:
+				// A worker sleeping through a long request interval is
+				// healthy even though no export finishes.
+				if _, idle := nextRequestIn(nextSend, time.Now()); idle {
+					opts.Heartbeat()
+				}
+			case tick := <-summaryCh:
+				opts.OnSummary(stats.SummaryWithElapsed(time.Since(startTime)), tick)
+			case tick := <-tickCh:
+				line := metrics.FormatProgressWith(stats.SummaryWithElapsed(time.Since(startTime)), expectedTotal, opts.TimeFormat)
+				if opts.AlignProgress {
+					line += " | At: " + opts.TimeFormat.FormatTime(tick)
+				}
+				// Only worth saying for trickle traffic; sub-second gaps
+				// would just add noise.
+				if next, ok := nextRequestIn(nextSend, time.Now()); ok && next >= time.Second {
+					line += " | Next request in: " + next.Round(time.Second).String()
+				}
+				if opts.ArrivalRate > 0 {
+					line += fmt.Sprintf(" | In flight: %d | Dropped: %d", inFlight.Load(), dropped.Load())
+				}
+				_, _ = fmt.Fprintln(progressWriter, line)
 			}
 		}
 	})
@@ -228,6 +577,12 @@ func (p *Pipeline) RunWithProgress(ctx context.Context, runner *ConcurrencyRunne
 	} else {
 		p.summary = metrics.Summary{}
 	}
+	p.summary.DroppedArrivals = int(dropped.Load())
+	for _, stage := range p.stages {
+		if annotator, ok := stage.(summaryAnnotator); ok {
+			annotator.annotate(&p.summary)
+		}
+	}
 
 	return err
 }
@@ -239,6 +594,236 @@ func (p *Pipeline) Summary() metrics.Summary {
 	return p.summary
 }
 
+// spanBudget enforces RunOptions.TotalSpans on exported spans. Generated
+// batches reserve their spans so workers do not overshoot the target;
+// a failed export gives its reservation back, so only successful exports
+// use up the budget.
+type spanBudget struct {
+	target   int64
+	reserved atomic.Int64
+	exported atomic.Int64
+}
+
+// budgetPoll is how often a worker blocked on a fully reserved budget
+// checks whether the outstanding exports succeeded.
+const budgetPoll = 10 * time.Millisecond
+
+// wait blocks while the budget is fully reserved by exports still in
+// progress. It reports false once the target has been exported. A nil
+// budget never blocks.
+func (b *spanBudget) wait(ctx context.Context) (bool, error) {
+	if b == nil {
+		return true, nil
+	}
+	for b.reserved.Load() >= b.target {
+		if b.exported.Load() >= b.target {
+			return false, nil
+		}
+		timer := time.NewTimer(budgetPoll)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false, ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return true, nil
+}
+
+// reserve claims spans for a batch about to be exported. It fails, taking
+// nothing, when earlier batches already reserve the whole budget; the
+// batch crossing the target is accepted whole.
+func (b *spanBudget) reserve(spans int) bool {
+	if b == nil {
+		return true
+	}
+	n := int64(spans)
+	if b.reserved.Add(n)-n >= b.target {
+		b.reserved.Add(-n)
+		return false
+	}
+	return true
+}
+
+// settle records the outcome of exporting a reserved batch.
+func (b *spanBudget) settle(spans int, err error) {
+	if b == nil {
+		return
+	}
+	if err != nil {
+		b.reserved.Add(-int64(spans))
+		return
+	}
+	b.exported.Add(int64(spans))
+}
+
+// arrivalSchedule describes the open-loop arrivals of a run.
+type arrivalSchedule struct {
+	start    time.Time
+	end      time.Time // zero for no duration limit
+	interval time.Duration
+	// limit caps the arrivals, zero for no limit.
+	limit       int
+	budget      *spanBudget
+	maxInFlight int64
+}
+
+// produceArrivals generates one batch every schedule.interval, measured
+// from schedule.start so a slow generation does not push later arrivals
+// back, and queues it with its scheduled arrival time. Arrivals that find
+// maxInFlight batches already queued or exporting are dropped.
+func (p *Pipeline) produceArrivals(ctx context.Context, schedule arrivalSchedule, auditLog *audit.Log, batches chan<- queuedBatch, inFlight, dropped *atomic.Int64) error {
+	for arrival := 0; ; arrival++ {
+		if schedule.limit > 0 && arrival >= schedule.limit {
+			return nil
+		}
+		due := schedule.start.Add(time.Duration(arrival) * schedule.interval)
+		if !schedule.end.IsZero() && !due.Before(schedule.end) {
+			return nil
+		}
+		if wait := time.Until(due); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+		if more, err := schedule.budget.wait(ctx); err != nil || !more {
+			return err
+		}
+		if inFlight.Load() >= schedule.maxInFlight {
+			dropped.Add(1)
+			continue
+		}
+
+		processCtx := ctx
+		var record *audit.Record
+		if auditLog != nil {
+			record = &audit.Record{Time: time.Now(), Request: arrival}
+			processCtx = audit.WithRecord(ctx, record)
+		}
+		batch, err := p.Process(processCtx, nil)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(batch) > 0 && !schedule.budget.reserve(len(batch)) {
+			continue
+		}
+		if record != nil {
+			record.Spans = len(batch)
+			if err := auditLog.Write(record); err != nil {
+				return fmt.Errorf("write audit record: %w", err)
+			}
+		}
+		if len(batch) == 0 {
+			continue
+		}
+		inFlight.Add(1)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case batches <- queuedBatch{batch: batch, arrived: due}:
+		}
+	}
+}
+
+// openExporters creates one exporter per worker before the measured phase
+// starts. With warmup enabled each exporter that implements model.Warmer
+// is probed once; a failure shuts down every exporter already opened.
+func openExporters(ctx context.Context, factory ExporterFactory, workerCount int, warmup bool, timeout time.Duration) ([]model.BatchExporter, error) {
+	exporters := make([]model.BatchExporter, workerCount)
+	group, groupCtx := errgroup.WithContext(ctx)
+	for i := 0; i < workerCount; i++ {
+		workerID := i
+		group.Go(func() error {
+			exporter, err := openExporter(groupCtx, factory, workerID, warmup, timeout)
+			exporters[workerID] = exporter
+			return err
+		})
+	}
+	if err := group.Wait(); err != nil {
+		for _, exporter := range exporters {
+			if exporter != nil {
+				_ = exporter.Shutdown(ctx)
+			}
+		}
+		return nil, err
+	}
+	return exporters, nil
+}
+
+// openExporter creates the exporter of one worker and, with warmup, sends
+// its throwaway export. A warmup failure shuts the exporter down.
+func openExporter(ctx context.Context, factory ExporterFactory, workerID int, warmup bool, timeout time.Duration) (model.BatchExporter, error) {
+	exporter, err := factory.NewBatchExporter(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("export worker=%d init: %w", workerID, err)
+	}
+	if !warmup {
+		return exporter, nil
+	}
+	warmer, ok := exporter.(model.Warmer)
+	if !ok {
+		return exporter, nil
+	}
+	warmupCtx := ctx
+	cancel := func() {}
+	if timeout > 0 {
+		warmupCtx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	defer cancel()
+	if err := warmer.Warmup(warmupCtx); err != nil {
+		_ = exporter.Shutdown(ctx)
+		return nil, fmt.Errorf("export worker=%d warmup: %w", workerID, err)
+	}
+	return exporter, nil
+}
+
+// waitInterval sleeps for interval. It stops early at runEnd (zero for no
+// limit) and reports false, so an interval of minutes or hours cannot
+// stretch a run past its duration.
+func waitInterval(ctx context.Context, interval time.Duration, runEnd time.Time) (bool, error) {
+	wait := interval
+	reachesEnd := false
+	if !runEnd.IsZero() {
+		if remaining := time.Until(runEnd); remaining <= wait {
+			wait, reachesEnd = max(remaining, 0), true
+		}
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false, ctx.Err()
+	case <-timer.C:
+		return !reachesEnd, nil
+	}
+}
+
+// nextRequestIn returns how long until the next request when every worker
+// is sleeping between requests; ok is false while any worker is busy.
+func nextRequestIn(nextSend []atomic.Int64, now time.Time) (time.Duration, bool) {
+	var earliest int64
+	for i := range nextSend {
+		at := nextSend[i].Load()
+		if at == 0 {
+			return 0, false
+		}
+		if earliest == 0 || at < earliest {
+			earliest = at
+		}
+	}
+	if earliest == 0 {
+		return 0, false
+	}
+	return max(time.Unix(0, earliest).Sub(now), 0), true
+}
+
 func rampUpDelay(workerID int, workerCount int, rampUpDuration time.Duration) time.Duration {
 	if rampUpDuration <= 0 || workerCount <= 1 || workerID <= 0 {
 		return 0
@@ -266,3 +851,29 @@ func sampleTraceIDs(batch model.Batch, limit int) []string {
 	}
 	return traceIDs
 }
+
+// exportWithRetry exports batch, retrying failed attempts up to retries
+// times. It returns the number of attempts made and the last error.
+func exportWithRetry(ctx context.Context, exporter model.BatchExporter, batch model.Batch, timeout time.Duration, retries int, backoff time.Duration) (int, error) {
+	attempts := 0
+	for {
+		attempts++
+		exportCtx := ctx
+		cancel := func() {}
+		if timeout > 0 {
+			exportCtx, cancel = context.WithTimeout(ctx, timeout)
+		}
+		err := exporter.ExportBatch(exportCtx, batch)
+		cancel()
+		if err == nil || attempts > retries || ctx.Err() != nil {
+			return attempts, err
+		}
+		if backoff > 0 {
+			select {
+			case <-ctx.Done():
+				return attempts, err
+			case <-time.After(backoff * time.Duration(attempts)):
+			}
+		}
+	}
+}
@@ -1,15 +1,23 @@
 package pipeline
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"io"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/javiermolinar/tercios/internal/audit"
+	"github.com/javiermolinar/tercios/internal/loadprofile"
+	"github.com/javiermolinar/tercios/internal/metrics"
 	"github.com/javiermolinar/tercios/internal/model"
 	"github.com/javiermolinar/tercios/internal/scenario"
+	"github.com/javiermolinar/tercios/internal/timefmt"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	oteltrace "go.opentelemetry.io/otel/trace"
@@ -191,6 +199,123 @@ func TestPipelineRampUpDelaysLateWorkers(t *testing.T) {
 	}
 }
 
+func TestPipelineLongIntervalDoesNotOutlastDuration(t *testing.T) {
+	var calls int64
+	runner := NewConcurrencyRunner(1, 0)
+	pipe := New(fixedModelStage{})
+	factory := testBatchExporterFactory{calls: &calls}
+
+	start := time.Now()
+	err := pipe.RunWithOptions(context.Background(), runner, factory, RunOptions{
+		RequestInterval: time.Hour,
+		RequestDuration: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("pipeline run error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("expected run to stop at its duration, took %s", elapsed)
+	}
+	if got := atomic.LoadInt64(&calls); got != 1 {
+		t.Fatalf("expected a single request before the interval, got %d", got)
+	}
+}
+
+func TestPipelineProgressReportsNextRequestWhileIdle(t *testing.T) {
+	runner := NewConcurrencyRunner(1, 0)
+	pipe := New(fixedModelStage{})
+	var progress lockedBuffer
+
+	err := pipe.RunWithOptions(context.Background(), runner, noopBatchExporterFactory{}, RunOptions{
+		RequestInterval:  time.Hour,
+		RequestDuration:  80 * time.Millisecond,
+		ProgressInterval: 20 * time.Millisecond,
+		ProgressWriter:   &progress,
+	})
+	if err != nil {
+		t.Fatalf("pipeline run error: %v", err)
+	}
+	if got := progress.String(); !strings.Contains(got, "Next request in: 1h0m0s") {
+		t.Fatalf("expected next request in progress output, got %q", got)
+	}
+}
+
+func TestPipelineAlignedProgressIsStampedWithBoundaries(t *testing.T) {
+	runner := NewConcurrencyRunner(1, 0)
+	pipe := New(fixedModelStage{})
+	var progress lockedBuffer
+
+	err := pipe.RunWithOptions(context.Background(), runner, noopBatchExporterFactory{}, RunOptions{
+		RequestInterval:  time.Hour,
+		RequestDuration:  250 * time.Millisecond,
+		ProgressInterval: 100 * time.Millisecond,
+		ProgressWriter:   &progress,
+		AlignProgress:    true,
+		TimeFormat:       timefmt.Options{Timestamp: timefmt.TimestampRFC3339Nano, Location: time.UTC},
+	})
+	if err != nil {
+		t.Fatalf("pipeline run error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(progress.String()), "\n")
+	if lines[0] == "" {
+		t.Fatalf("expected aligned progress lines")
+	}
+	for _, line := range lines {
+		_, stamp, ok := strings.Cut(line, " | At: ")
+		stamp, _, _ = strings.Cut(stamp, " | ")
+		at, err := time.Parse(time.RFC3339Nano, stamp)
+		if !ok || err != nil || !at.Equal(at.Truncate(100*time.Millisecond)) {
+			t.Fatalf("expected a line stamped on a 100ms boundary, got %q", line)
+		}
+	}
+}
+
+func TestPipelineHandsIntervalSummariesToOnSummary(t *testing.T) {
+	runner := NewConcurrencyRunner(1, 0)
+	pipe := New(fixedModelStage{})
+	var mu sync.Mutex
+	var summaries []metrics.Summary
+
+	err := pipe.RunWithOptions(context.Background(), runner, noopBatchExporterFactory{}, RunOptions{
+		RequestInterval: 20 * time.Millisecond,
+		RequestDuration: 250 * time.Millisecond,
+		SummaryInterval: 100 * time.Millisecond,
+		OnSummary: func(summary metrics.Summary, _ time.Time) {
+			mu.Lock()
+			defer mu.Unlock()
+			summaries = append(summaries, summary)
+		},
+	})
+	if err != nil {
+		t.Fatalf("pipeline run error: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(summaries) == 0 {
+		t.Fatalf("expected interval summaries")
+	}
+	final := pipe.Summary()
+	for _, summary := range summaries {
+		if summary.Total == 0 || summary.Total > final.Total {
+			t.Fatalf("expected interval summaries of the run so far, got %d requests of %d", summary.Total, final.Total)
+		}
+	}
+}
+
+func TestNextRequestInRequiresEveryWorkerIdle(t *testing.T) {
+	now := time.Now()
+	nextSend := make([]atomic.Int64, 2)
+	nextSend[0].Store(now.Add(time.Minute).UnixNano())
+	if _, ok := nextRequestIn(nextSend, now); ok {
+		t.Fatalf("expected no estimate while a worker is busy")
+	}
+	nextSend[1].Store(now.Add(30 * time.Second).UnixNano())
+	got, ok := nextRequestIn(nextSend, now)
+	if !ok || got != 30*time.Second {
+		t.Fatalf("expected earliest next request in 30s, got %s (ok=%v)", got, ok)
+	}
+}
+
 func TestPipelineAppliesPerExportTimeout(t *testing.T) {
 	runner := NewConcurrencyRunner(1, 1)
 	pipe := New(fixedModelStage{})
@@ -223,3 +348,498 @@ func TestPipelineUnlimitedRequestsStopsOnContextCancel(t *testing.T) {
 		t.Fatalf("expected at least one export call before cancel")
 	}
 }
+
+// exhaustibleStage emits fixedModelStage batches until remaining runs out,
+// then returns io.EOF like a replay stage at the end of its recording.
+type exhaustibleStage struct {
+	remaining *atomic.Int64
+}
+
+func (exhaustibleStage) name() string {
+	return "exhaustible"
+}
+
+func (s exhaustibleStage) process(ctx context.Context, spans []model.Span) ([]model.Span, error) {
+	if s.remaining.Add(-1) < 0 {
+		return nil, io.EOF
+	}
+	return fixedModelStage{}.process(ctx, spans)
+}
+
+func TestPipelineStopsWorkersWhenSourceIsExhausted(t *testing.T) {
+	var calls int64
+	var remaining atomic.Int64
+	remaining.Store(7)
+	runner := NewConcurrencyRunner(3, 0)
+	pipe := New(exhaustibleStage{remaining: &remaining})
+	factory := testBatchExporterFactory{calls: &calls}
+
+	if err := pipe.Run(context.Background(), runner, factory, 0, 0, 0, 0, 0); err != nil {
+		t.Fatalf("expected an exhausted source to end the run cleanly, got %v", err)
+	}
+	if got := atomic.LoadInt64(&calls); got != 7 {
+		t.Fatalf("expected 7 export calls, got %d", got)
+	}
+}
+
+type warmingBatchExporterFactory struct {
+	warmups   *int64
+	calls     *int64
+	warmupErr error
+}
+
+func (f warmingBatchExporterFactory) NewBatchExporter(_ context.Context) (model.BatchExporter, error) {
+	return &warmingBatchExporter{warmups: f.warmups, calls: f.calls, warmupErr: f.warmupErr}, nil
+}
+
+type warmingBatchExporter struct {
+	warmups   *int64
+	calls     *int64
+	warmupErr error
+}
+
+func (e *warmingBatchExporter) Warmup(_ context.Context) error {
+	atomic.AddInt64(e.warmups, 1)
+	return e.warmupErr
+}
+
+func (e *warmingBatchExporter) ExportBatch(_ context.Context, _ model.Batch) error {
+	atomic.AddInt64(e.calls, 1)
+	return nil
+}
+
+func (e *warmingBatchExporter) Shutdown(_ context.Context) error {
+	return nil
+}
+
+func TestPipelineWarmupProbesEveryExporterBeforeMeasuring(t *testing.T) {
+	var warmups int64
+	var calls int64
+	runner := NewConcurrencyRunner(3, 2)
+	pipe := New(fixedModelStage{})
+	factory := warmingBatchExporterFactory{warmups: &warmups, calls: &calls}
+
+	if err := pipe.RunWithOptions(context.Background(), runner, factory, RunOptions{Warmup: true}); err != nil {
+		t.Fatalf("pipeline run error: %v", err)
+	}
+	if got := atomic.LoadInt64(&warmups); got != 3 {
+		t.Fatalf("expected 3 warmups (one per exporter), got %d", got)
+	}
+	if got := pipe.Summary().Total; got != 6 {
+		t.Fatalf("expected warmup exports to be excluded from summary (6 requests), got %d", got)
+	}
+}
+
+func TestPipelineSkipsWarmupWhenDisabled(t *testing.T) {
+	var warmups int64
+	var calls int64
+	runner := NewConcurrencyRunner(2, 1)
+	pipe := New(fixedModelStage{})
+	factory := warmingBatchExporterFactory{warmups: &warmups, calls: &calls}
+
+	if err := pipe.RunWithOptions(context.Background(), runner, factory, RunOptions{}); err != nil {
+		t.Fatalf("pipeline run error: %v", err)
+	}
+	if got := atomic.LoadInt64(&warmups); got != 0 {
+		t.Fatalf("expected no warmups, got %d", got)
+	}
+}
+
+func TestPipelineWarmupFailureAbortsBeforeExporting(t *testing.T) {
+	var warmups int64
+	var calls int64
+	runner := NewConcurrencyRunner(2, 1)
+	pipe := New(fixedModelStage{})
+	factory := warmingBatchExporterFactory{warmups: &warmups, calls: &calls, warmupErr: errors.New("connection refused")}
+
+	err := pipe.RunWithOptions(context.Background(), runner, factory, RunOptions{Warmup: true})
+	if err == nil || !strings.Contains(err.Error(), "warmup") {
+		t.Fatalf("expected warmup error, got %v", err)
+	}
+	if got := atomic.LoadInt64(&calls); got != 0 {
+		t.Fatalf("expected no measured exports after warmup failure, got %d", got)
+	}
+}
+
+func TestPipelineRampWorkersOpensExportersInTurn(t *testing.T) {
+	var warmups int64
+	var calls int64
+	runner := NewConcurrencyRunner(3, 1)
+	pipe := New(fixedModelStage{})
+	factory := warmingBatchExporterFactory{warmups: &warmups, calls: &calls}
+
+	start := time.Now()
+	if err := pipe.RunWithOptions(context.Background(), runner, factory, RunOptions{Warmup: true, RampWorkersDuration: 60 * time.Millisecond}); err != nil {
+		t.Fatalf("pipeline run error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Fatalf("expected the last worker to start after the ramp, run took %s", elapsed)
+	}
+	if got := atomic.LoadInt64(&warmups); got != 3 {
+		t.Fatalf("expected every exporter opened and warmed up, got %d", got)
+	}
+	if got := pipe.Summary().Total; got != 3 {
+		t.Fatalf("expected 3 requests, got %d", got)
+	}
+}
+
+func TestPipelineRampWorkersSkipsWorkersPastTheDuration(t *testing.T) {
+	var warmups int64
+	var calls int64
+	runner := NewConcurrencyRunner(2, 1)
+	pipe := New(fixedModelStage{})
+	factory := warmingBatchExporterFactory{warmups: &warmups, calls: &calls}
+
+	opts := RunOptions{Warmup: true, RequestDuration: 100 * time.Millisecond, RampWorkersDuration: time.Second}
+	start := time.Now()
+	if err := pipe.RunWithOptions(context.Background(), runner, factory, opts); err != nil {
+		t.Fatalf("pipeline run error: %v", err)
+	}
+	if got := atomic.LoadInt64(&warmups); got != 1 {
+		t.Fatalf("expected only the first exporter to be opened, got %d", got)
+	}
+	if got := atomic.LoadInt64(&calls); got != 1 {
+		t.Fatalf("expected only the first worker to export, got %d", got)
+	}
+	if elapsed := time.Since(start); elapsed >= 900*time.Millisecond {
+		t.Fatalf("expected the late worker to be skipped at the duration cutoff, run took %s", elapsed)
+	}
+}
+
+func TestPipelineStopsAtTotalSpans(t *testing.T) {
+	var calls int64
+	runner := NewConcurrencyRunner(4, 0)
+	pipe := New(fixedModelStage{})
+	factory := testBatchExporterFactory{calls: &calls}
+
+	if err := pipe.RunWithOptions(context.Background(), runner, factory, RunOptions{TotalSpans: 25}); err != nil {
+		t.Fatalf("pipeline run error: %v", err)
+	}
+	if got := pipe.Summary().TotalSpans; got != 25 {
+		t.Fatalf("expected exactly 25 one-span batches, got %d spans", got)
+	}
+}
+
+func TestPipelineSummaryIncludesWorkerBreakdown(t *testing.T) {
+	var calls int64
+	runner := NewConcurrencyRunner(3, 4)
+	pipe := New(fixedModelStage{})
+	factory := testBatchExporterFactory{calls: &calls}
+
+	if err := pipe.RunWithOptions(context.Background(), runner, factory, RunOptions{WorkerBreakdown: true}); err != nil {
+		t.Fatalf("pipeline run error: %v", err)
+	}
+	requests := 0
+	for _, worker := range pipe.Summary().Workers {
+		requests += worker.Requests
+	}
+	if workers := pipe.Summary().Workers; len(workers) == 0 || requests != 12 {
+		t.Fatalf("expected the 12 requests split across workers, got %+v", workers)
+	}
+}
+
+func TestPipelineSummaryCountsRequestSize(t *testing.T) {
+	var calls int64
+	runner := NewConcurrencyRunner(2, 3)
+	pipe := New(fixedModelStage{})
+	factory := testBatchExporterFactory{calls: &calls}
+
+	opts := RunOptions{RequestSize: func(batch model.Batch) int { return 100 * len(batch) }}
+	if err := pipe.RunWithOptions(context.Background(), runner, factory, opts); err != nil {
+		t.Fatalf("pipeline run error: %v", err)
+	}
+	summary := pipe.Summary()
+	if summary.SentBytes != int64(100*summary.SuccessfulSpans) || summary.SentBytes == 0 {
+		t.Fatalf("expected 100 bytes per span sent, got %d bytes for %d spans", summary.SentBytes, summary.SuccessfulSpans)
+	}
+}
+
+func TestSpanBudgetCountsOnlySuccessfulExports(t *testing.T) {
+	budget := &spanBudget{target: 10}
+	if !budget.reserve(6) || !budget.reserve(6) {
+		t.Fatalf("expected the batch crossing the target to be reserved whole")
+	}
+	if budget.reserve(1) {
+		t.Fatalf("expected no reservation once the budget is fully reserved")
+	}
+
+	budget.settle(6, errors.New("export failed"))
+	more, err := budget.wait(context.Background())
+	if err != nil || !more {
+		t.Fatalf("expected a failed export to free its spans, got more=%v err=%v", more, err)
+	}
+	if !budget.reserve(6) {
+		t.Fatalf("expected the freed spans to be reserved again")
+	}
+	budget.settle(6, nil)
+	budget.settle(6, nil)
+	if more, _ := budget.wait(context.Background()); more {
+		t.Fatalf("expected the run to stop once 12 spans were exported")
+	}
+}
+
+type slowBatchExporterFactory struct {
+	delay time.Duration
+}
+
+func (f slowBatchExporterFactory) NewBatchExporter(_ context.Context) (model.BatchExporter, error) {
+	return slowBatchExporter(f), nil
+}
+
+type slowBatchExporter struct {
+	delay time.Duration
+}
+
+func (e slowBatchExporter) ExportBatch(ctx context.Context, _ model.Batch) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(e.delay):
+		return nil
+	}
+}
+
+func (slowBatchExporter) Shutdown(_ context.Context) error {
+	return nil
+}
+
+func TestPipelineOpenLoopQueuesArrivalsBehindSlowExports(t *testing.T) {
+	runner := NewConcurrencyRunner(1, 10)
+	pipe := New(fixedModelStage{})
+	factory := slowBatchExporterFactory{delay: 20 * time.Millisecond}
+
+	if err := pipe.RunWithOptions(context.Background(), runner, factory, RunOptions{ArrivalRate: 1000}); err != nil {
+		t.Fatalf("pipeline run error: %v", err)
+	}
+	summary := pipe.Summary()
+	if summary.Total != 10 || summary.DroppedArrivals != 0 {
+		t.Fatalf("expected 10 exports and no drops, got %d exports and %d drops", summary.Total, summary.DroppedArrivals)
+	}
+	// Ten arrivals within 10ms behind a 20ms export: the last ones wait
+	// for the exports ahead of them.
+	if summary.P95QueueWait < 100*time.Millisecond {
+		t.Fatalf("expected queue wait to grow behind slow exports, got p95 %s", summary.P95QueueWait)
+	}
+}
+
+func TestPipelineOpenLoopDropsArrivalsOverMaxInFlight(t *testing.T) {
+	runner := NewConcurrencyRunner(1, 10)
+	pipe := New(fixedModelStage{})
+	factory := slowBatchExporterFactory{delay: 50 * time.Millisecond}
+
+	if err := pipe.RunWithOptions(context.Background(), runner, factory, RunOptions{ArrivalRate: 1000, MaxInFlight: 2}); err != nil {
+		t.Fatalf("pipeline run error: %v", err)
+	}
+	summary := pipe.Summary()
+	if summary.DroppedArrivals == 0 {
+		t.Fatalf("expected arrivals over the in-flight limit to be dropped")
+	}
+	if summary.Total+summary.DroppedArrivals != 10 {
+		t.Fatalf("expected exports and drops to add up to 10 arrivals, got %d + %d", summary.Total, summary.DroppedArrivals)
+	}
+}
+
+func TestPipelineOpenLoopRejectsClosedLoopPacing(t *testing.T) {
+	profile, err := loadprofile.Parse("ramp:1-10/1s")
+	if err != nil {
+		t.Fatalf("parse profile: %v", err)
+	}
+	for name, opts := range map[string]RunOptions{
+		"interval": {ArrivalRate: 10, RequestInterval: time.Second},
+		"ramp-up":  {ArrivalRate: 10, RampUpDuration: time.Second},
+		"profile":  {ArrivalRate: 10, LoadProfile: &profile},
+	} {
+		pipe := New(fixedModelStage{})
+		if err := pipe.RunWithOptions(context.Background(), NewConcurrencyRunner(1, 1), noopBatchExporterFactory{}, opts); err == nil {
+			t.Fatalf("%s: expected error combining arrival rate with closed-loop pacing", name)
+		}
+	}
+}
+
+func TestPipelineHeartbeatFollowsFinishedExports(t *testing.T) {
+	var calls int64
+	var beats atomic.Int64
+	pipe := New(fixedModelStage{})
+	factory := testBatchExporterFactory{calls: &calls}
+
+	err := pipe.RunWithOptions(context.Background(), NewConcurrencyRunner(2, 3), factory, RunOptions{Heartbeat: func() { beats.Add(1) }})
+	if err != nil {
+		t.Fatalf("pipeline run error: %v", err)
+	}
+	if got := beats.Load(); got != 6 {
+		t.Fatalf("expected one heartbeat per finished export, got %d", got)
+	}
+}
+
+func TestPipelineHeartbeatStopsWhileExportHangs(t *testing.T) {
+	var beats atomic.Int64
+	pipe := New(fixedModelStage{})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		_ = pipe.RunWithOptions(ctx, NewConcurrencyRunner(1, 1), blockingBatchExporterFactory{}, RunOptions{Heartbeat: func() { beats.Add(1) }})
+		close(done)
+	}()
+
+	// Longer than the one-second idle heartbeat check.
+	time.Sleep(1500 * time.Millisecond)
+	got := beats.Load()
+	cancel()
+	<-done
+	if got != 0 {
+		t.Fatalf("expected no heartbeat while the only export hangs, got %d", got)
+	}
+}
+
+type flakyBatchExporterFactory struct {
+	calls    *int64
+	failures int64
+}
+
+func (f flakyBatchExporterFactory) NewBatchExporter(_ context.Context) (model.BatchExporter, error) {
+	return &flakyBatchExporter{calls: f.calls, failures: f.failures}, nil
+}
+
+// flakyBatchExporter fails the first failures exports and succeeds afterwards.
+type flakyBatchExporter struct {
+	calls    *int64
+	failures int64
+}
+
+func (e *flakyBatchExporter) ExportBatch(_ context.Context, _ model.Batch) error {
+	if atomic.AddInt64(e.calls, 1) <= e.failures {
+		return errors.New("unavailable")
+	}
+	return nil
+}
+
+func (e *flakyBatchExporter) Shutdown(_ context.Context) error {
+	return nil
+}
+
+func TestPipelineRetriesFailedExportsAndCountsDuplicates(t *testing.T) {
+	var calls int64
+	runner := NewConcurrencyRunner(1, 2)
+	pipe := New(fixedModelStage{})
+	factory := flakyBatchExporterFactory{calls: &calls, failures: 2}
+
+	if err := pipe.RunWithOptions(context.Background(), runner, factory, RunOptions{ExportRetries: 2}); err != nil {
+		t.Fatalf("pipeline run error: %v", err)
+	}
+	if got := atomic.LoadInt64(&calls); got != 4 {
+		t.Fatalf("expected 4 export attempts (2 retries + 2 requests), got %d", got)
+	}
+	summary := pipe.Summary()
+	if summary.Total != 2 || summary.Failures != 0 {
+		t.Fatalf("expected 2 successful requests, got total=%d failures=%d", summary.Total, summary.Failures)
+	}
+	if summary.Retries != 2 || summary.RetriedRequests != 1 {
+		t.Fatalf("expected 2 retries on 1 request, got retries=%d retried=%d", summary.Retries, summary.RetriedRequests)
+	}
+	if summary.PotentialDuplicateSpans != 2 {
+		t.Fatalf("expected 2 potential duplicate spans, got %d", summary.PotentialDuplicateSpans)
+	}
+}
+
+func TestPipelineFailsWhenRetriesAreExhausted(t *testing.T) {
+	var calls int64
+	runner := NewConcurrencyRunner(1, 1)
+	pipe := New(fixedModelStage{})
+	factory := flakyBatchExporterFactory{calls: &calls, failures: 5}
+
+	err := pipe.RunWithOptions(context.Background(), runner, factory, RunOptions{ExportRetries: 1})
+	if err == nil {
+		t.Fatalf("expected export error after retries are exhausted")
+	}
+	if got := atomic.LoadInt64(&calls); got != 2 {
+		t.Fatalf("expected 2 export attempts, got %d", got)
+	}
+	if got := pipe.Summary().Retries; got != 1 {
+		t.Fatalf("expected 1 retry, got %d", got)
+	}
+}
+
+// reopeningFactory counts how often a worker opens an exporter.
+type reopeningFactory struct {
+	flakyBatchExporterFactory
+	opens *int64
+}
+
+func (f reopeningFactory) NewBatchExporter(ctx context.Context) (model.BatchExporter, error) {
+	atomic.AddInt64(f.opens, 1)
+	return f.flakyBatchExporterFactory.NewBatchExporter(ctx)
+}
+
+func TestPipelineContinueOnErrorReconnectsAfterFailedExport(t *testing.T) {
+	var calls, opens int64
+	runner := NewConcurrencyRunner(1, 4)
+	pipe := New(fixedModelStage{})
+	factory := reopeningFactory{flakyBatchExporterFactory: flakyBatchExporterFactory{calls: &calls, failures: 2}, opens: &opens}
+	var results, failed int
+
+	err := pipe.RunWithOptions(context.Background(), runner, factory, RunOptions{
+		ContinueOnError: true,
+		OnResult: func(_ int, err error) {
+			results++
+			if err != nil {
+				failed++
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("pipeline run error: %v", err)
+	}
+	summary := pipe.Summary()
+	if summary.Total != 4 || summary.Failures != 2 {
+		t.Fatalf("expected 4 requests with 2 failures, got total=%d failures=%d", summary.Total, summary.Failures)
+	}
+	if got := atomic.LoadInt64(&opens); got != 3 {
+		t.Fatalf("expected the exporter to be reopened after each failure (3 opens), got %d", got)
+	}
+	if results != 4 || failed != 2 {
+		t.Fatalf("expected OnResult for every export, got %d results and %d failures", results, failed)
+	}
+}
+
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestPipelineRunWritesAuditRecordPerBatch(t *testing.T) {
+	var buf lockedBuffer
+	runner := NewConcurrencyRunner(1, 2)
+	pipe := New(fixedModelStage{}, NewFutureTimestampsStage(FutureTimestampsConfig{Fraction: 1, Offset: time.Minute}))
+
+	err := pipe.RunWithOptions(context.Background(), runner, noopBatchExporterFactory{}, RunOptions{Audit: audit.NewLog(&buf)})
+	if err != nil {
+		t.Fatalf("pipeline run error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected one audit record per batch, got %d: %q", len(lines), buf.String())
+	}
+	var record audit.Record
+	if err := json.Unmarshal([]byte(lines[1]), &record); err != nil {
+		t.Fatalf("decode audit record: %v", err)
+	}
+	if record.Request != 1 || record.Spans != 1 || len(record.Stages) != 2 {
+		t.Fatalf("unexpected audit record %+v", record)
+	}
+	if got := record.Stages[1]; got.Stage != "future-timestamps" || got.SpansIn != 1 || got.SpansOut != 1 {
+		t.Fatalf("unexpected stage count %+v", got)
+	}
+}
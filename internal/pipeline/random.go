@@ -0,0 +1,65 @@
+package pipeline
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"hash/fnv"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/model"
+)
+
+// mix64 is the splitmix64 finalizer. Stages feed it a seed combined with an
+// ID to draw reproducible pseudo-random values.
+func mix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// unitFloat maps a random value to a uniform float64 in [0, 1).
+func unitFloat(random uint64) float64 {
+	return float64(random>>11) * (1.0 / (1 << 53))
+}
+
+// DerivedSeed mixes seed with part, so one run seed can drive several
+// independent random sources. It never returns zero, which means "random"
+// everywhere a seed is taken.
+func DerivedSeed(seed int64, part uint64) int64 {
+	return int64(mix64(uint64(seed)^part*0x9e3779b97f4a7c15) | 1)
+}
+
+// NewRunSeed returns a random positive seed for a run that was given none,
+// so the run can be reported and repeated with --seed.
+func NewRunSeed() int64 {
+	var b [8]byte
+	value := uint64(time.Now().UnixNano())
+	if _, err := rand.Read(b[:]); err == nil {
+		value = binary.BigEndian.Uint64(b[:])
+	}
+	return int64(value>>1) | 1
+}
+
+// spanRandom returns the draw-th random value for span. Values are keyed on
+// the span's trace and span IDs rather than on a shared sequence, so a seed
+// gives every span the same values whichever exporter worker processes it
+// and in whatever order.
+func spanRandom(seed uint64, span *model.Span, draw uint64) uint64 {
+	key := mix64(binary.BigEndian.Uint64(span.TraceID[:8])) ^ binary.BigEndian.Uint64(span.TraceID[8:])
+	key = mix64(key) ^ binary.BigEndian.Uint64(span.SpanID[:])
+	return mix64(seed ^ mix64(key^draw*0x9e3779b97f4a7c15))
+}
+
+// spanUnit is spanRandom as a uniform float64 in [0, 1).
+func spanUnit(seed uint64, span *model.Span, draw uint64) float64 {
+	return unitFloat(spanRandom(seed, span, draw))
+}
+
+// keyDraw turns a name, such as an attribute key, into a draw number, so
+// values drawn per name do not depend on map iteration order.
+func keyDraw(name string) uint64 {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(name))
+	return hash.Sum64()
+}
@@ -0,0 +1,80 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/javiermolinar/tercios/internal/model"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// spansWithIDs returns n spans, each in its own trace, numbered from first.
+func spansWithIDs(n, first int) []model.Span {
+	spans := make([]model.Span, n)
+	for i := range spans {
+		binary.BigEndian.PutUint64(spans[i].TraceID[8:], uint64(first+i+1))
+		binary.BigEndian.PutUint64(spans[i].SpanID[:], uint64(first+i+1))
+	}
+	return spans
+}
+
+func TestSeededStagesDoNotDependOnProcessingOrder(t *testing.T) {
+	stages := func() []BatchStage {
+		return []BatchStage{
+			NewJitterStage(JitterConfig{Attributes: 0.5, Seed: 11}),
+			NewEventsStage(EventsConfig{PerSpan: 1, Probability: 0.5, Seed: 12}),
+			NewCardinalityStage(CardinalityConfig{Seed: 13, Attributes: []CardinalityAttribute{{Key: "user.id", Cardinality: 1000}, {Key: "request.id"}}}),
+		}
+	}
+	run := func(order []int) map[oteltrace.SpanID]string {
+		// One stage chain shared by every "worker", fed batches in order,
+		// as exporter workers do when they race for batches.
+		chain := stages()
+		out := map[oteltrace.SpanID]string{}
+		for _, batch := range order {
+			spans := spansWithIDs(4, batch*4)
+			for i := range spans {
+				spans[i].Kind = oteltrace.SpanKindClient
+				spans[i].Attributes = map[string]attribute.Value{"size": attribute.Int64Value(1_000_000), "ratio": attribute.Float64Value(1)}
+			}
+			for _, stage := range chain {
+				var err error
+				if spans, err = stage.process(context.Background(), spans); err != nil {
+					t.Fatalf("%s: process() error = %v", stage.name(), err)
+				}
+			}
+			for _, span := range spans {
+				var line string
+				for _, key := range slices.Sorted(maps.Keys(span.Attributes)) {
+					line += key + "=" + span.Attributes[key].Emit() + " "
+				}
+				out[span.SpanID] = line + fmt.Sprint(span.Events)
+			}
+		}
+		return out
+	}
+
+	forward := run([]int{0, 1, 2, 3, 4, 5, 6, 7})
+	shuffled := run([]int{5, 2, 7, 0, 3, 6, 1, 4})
+	if len(forward) != 32 {
+		t.Fatalf("expected 32 spans, got %d", len(forward))
+	}
+	for id, want := range forward {
+		if got := shuffled[id]; got != want {
+			t.Fatalf("span %s differs with processing order:\n%s\n%s", id, want, got)
+		}
+	}
+}
+
+func TestNewRunSeedIsPositive(t *testing.T) {
+	for range 100 {
+		if seed := NewRunSeed(); seed <= 0 {
+			t.Fatalf("expected a positive seed, got %d", seed)
+		}
+	}
+}
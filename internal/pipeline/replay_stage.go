@@ -0,0 +1,30 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/javiermolinar/tercios/internal/model"
+	"github.com/javiermolinar/tercios/internal/replay"
+)
+
+type replayStage struct {
+	generator *replay.Generator
+}
+
+// NewReplayStage generates batches from a recording. Once the recording is
+// exhausted the stage returns io.EOF, which ends each producer worker.
+func NewReplayStage(generator *replay.Generator) BatchStage {
+	return replayStage{generator: generator}
+}
+
+func (s replayStage) name() string {
+	return "replay"
+}
+
+func (s replayStage) process(ctx context.Context, _ []model.Span) ([]model.Span, error) {
+	if s.generator == nil {
+		return nil, fmt.Errorf("replay generator not configured")
+	}
+	return s.generator.GenerateBatch(ctx)
+}
@@ -3,6 +3,7 @@ package pipeline
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"github.com/javiermolinar/tercios/internal/model"
 	"github.com/javiermolinar/tercios/internal/scenario"
@@ -10,10 +11,38 @@ import (
 
 type scenarioStage struct {
 	generator scenario.BatchGenerator
+	traces    int
+	// spanTarget, when above zero, replaces traces with the average span
+	// count of a batch.
+	spanTarget float64
+	carry      *spanCarry
+}
+
+// spanCarry is the difference between the spans targeted and generated so
+// far, shared by every producer so the run average hits the target.
+type spanCarry struct {
+	mu    sync.Mutex
+	spans float64
 }
 
 func NewScenarioStage(generator scenario.BatchGenerator) BatchStage {
-	return scenarioStage{generator: generator}
+	return scenarioStage{generator: generator, traces: 1}
+}
+
+// NewScenarioStageWithTraces puts that many generated traces in each
+// batch, so every request carries a collector-sized payload instead of one
+// small trace.
+func NewScenarioStageWithTraces(generator scenario.BatchGenerator, traces int) BatchStage {
+	return scenarioStage{generator: generator, traces: max(traces, 1)}
+}
+
+// NewScenarioStageWithSpanTarget fills each batch with whole generated
+// traces until it holds spansPerRequest spans on average, so a run paced
+// in requests sends a span rate. Batches overshoot by at most one trace,
+// and later batches are made smaller to make up for it; a batch whose
+// share is already covered is left empty and not sent.
+func NewScenarioStageWithSpanTarget(generator scenario.BatchGenerator, spansPerRequest float64) BatchStage {
+	return scenarioStage{generator: generator, traces: 1, spanTarget: spansPerRequest, carry: &spanCarry{}}
 }
 
 func (s scenarioStage) name() string {
@@ -24,5 +53,46 @@ func (s scenarioStage) process(ctx context.Context, _ []model.Span) ([]model.Spa
 	if s.generator == nil {
 		return nil, fmt.Errorf("scenario generator not configured")
 	}
-	return s.generator.GenerateBatch(ctx)
+	if s.spanTarget > 0 {
+		return s.fillSpanTarget(ctx)
+	}
+	if s.traces == 1 {
+		return s.generator.GenerateBatch(ctx)
+	}
+	var batch []model.Span
+	for range s.traces {
+		trace, err := s.generator.GenerateBatch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		batch = append(batch, trace...)
+	}
+	return batch, nil
+}
+
+func (s scenarioStage) fillSpanTarget(ctx context.Context) ([]model.Span, error) {
+	// The carry is taken whole and settled after generating, so
+	// concurrent producers never count the same deficit twice.
+	s.carry.mu.Lock()
+	target := s.spanTarget + s.carry.spans
+	s.carry.spans = 0
+	s.carry.mu.Unlock()
+
+	var batch []model.Span
+	for float64(len(batch)) < target {
+		trace, err := s.generator.GenerateBatch(ctx)
+		if err != nil {
+			s.settle(target)
+			return nil, err
+		}
+		batch = append(batch, trace...)
+	}
+	s.settle(target - float64(len(batch)))
+	return batch, nil
+}
+
+func (s scenarioStage) settle(spans float64) {
+	s.carry.mu.Lock()
+	s.carry.spans += spans
+	s.carry.mu.Unlock()
 }
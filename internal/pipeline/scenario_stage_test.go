@@ -5,9 +5,11 @@ import (
 	"testing"
 
 	"github.com/javiermolinar/tercios/internal/scenario"
+	oteltrace "go.opentelemetry.io/otel/trace"
 )
 
-func TestScenarioStageEmitsSpans(t *testing.T) {
+func testScenarioGenerator(t *testing.T) scenario.BatchGenerator {
+	t.Helper()
 	cfg := scenario.Config{
 		Name: "test",
 		Seed: 1,
@@ -27,7 +29,11 @@ func TestScenarioStageEmitsSpans(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Build() error = %v", err)
 	}
-	stage := NewScenarioStage(scenario.NewGenerator(def))
+	return scenario.NewGenerator(def)
+}
+
+func TestScenarioStageEmitsSpans(t *testing.T) {
+	stage := NewScenarioStage(testScenarioGenerator(t))
 
 	batch, err := stage.process(context.Background(), nil)
 	if err != nil {
@@ -37,3 +43,62 @@ func TestScenarioStageEmitsSpans(t *testing.T) {
 		t.Fatalf("expected 2 spans, got %d", len(batch))
 	}
 }
+
+func TestScenarioStageWithTracesEmitsSeveralTraces(t *testing.T) {
+	stage := NewScenarioStageWithTraces(testScenarioGenerator(t), 5)
+
+	batch, err := stage.process(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("process() error = %v", err)
+	}
+	if len(batch) != 10 {
+		t.Fatalf("expected 10 spans, got %d", len(batch))
+	}
+	traces := map[oteltrace.TraceID]int{}
+	for _, span := range batch {
+		traces[span.TraceID]++
+	}
+	if len(traces) != 5 {
+		t.Fatalf("expected 5 distinct traces, got %d", len(traces))
+	}
+}
+
+func TestScenarioStageWithSpanTargetAveragesTarget(t *testing.T) {
+	// Traces have 2 spans; 5 spans per request alternates 6 and 4.
+	stage := NewScenarioStageWithSpanTarget(testScenarioGenerator(t), 5)
+
+	total := 0
+	for i := range 10 {
+		batch, err := stage.process(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("process() error = %v", err)
+		}
+		if len(batch)%2 != 0 || len(batch) < 4 || len(batch) > 6 {
+			t.Fatalf("request %d: got %d spans, want whole traces near 5", i, len(batch))
+		}
+		total += len(batch)
+	}
+	if total != 50 {
+		t.Fatalf("expected 50 spans over 10 requests, got %d", total)
+	}
+}
+
+func TestScenarioStageWithSpanTargetSkipsCoveredRequests(t *testing.T) {
+	// One 2-span trace covers four requests of half a span each.
+	stage := NewScenarioStageWithSpanTarget(testScenarioGenerator(t), 0.5)
+
+	var sizes []int
+	for range 8 {
+		batch, err := stage.process(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("process() error = %v", err)
+		}
+		sizes = append(sizes, len(batch))
+	}
+	want := []int{2, 0, 0, 0, 2, 0, 0, 0}
+	for i := range want {
+		if sizes[i] != want[i] {
+			t.Fatalf("batch sizes = %v, want %v", sizes, want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/javiermolinar/tercios/internal/metrics"
+	"github.com/javiermolinar/tercios/internal/model"
+	"github.com/javiermolinar/tercios/internal/schema"
+)
+
+// SchemaConfig configures the attribute schema check run on every span
+// before export.
+type SchemaConfig struct {
+	Schema schema.Config
+	// FailFast fails the run on the first span that violates the schema.
+	// Otherwise violations are counted for the run summary.
+	FailFast bool
+}
+
+type schemaStage struct {
+	config SchemaConfig
+
+	mu         sync.Mutex
+	spans      int
+	violations map[string]int
+}
+
+func NewSchemaStage(cfg SchemaConfig) BatchStage {
+	return &schemaStage{config: cfg, violations: map[string]int{}}
+}
+
+func (s *schemaStage) name() string {
+	return "schema"
+}
+
+func (s *schemaStage) process(_ context.Context, spans []model.Span) ([]model.Span, error) {
+	for i := range spans {
+		violations := s.config.Schema.Check(spans[i])
+		if len(violations) == 0 {
+			continue
+		}
+		if s.config.FailFast {
+			return nil, fmt.Errorf("span %q (trace %s, span %s) violates the schema: %s", spans[i].Name, spans[i].TraceID, spans[i].SpanID, strings.Join(violations, "; "))
+		}
+		s.mu.Lock()
+		s.spans++
+		for _, violation := range violations {
+			s.violations[violation]++
+		}
+		s.mu.Unlock()
+	}
+	return spans, nil
+}
+
+func (s *schemaStage) annotate(summary *metrics.Summary) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.spans == 0 {
+		return
+	}
+	summary.SchemaViolatingSpans = s.spans
+	summary.SchemaViolations = make(map[string]int, len(s.violations))
+	for violation, count := range s.violations {
+		summary.SchemaViolations[violation] = count
+	}
+}
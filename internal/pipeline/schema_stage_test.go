@@ -0,0 +1,33 @@
+package pipeline
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/javiermolinar/tercios/internal/schema"
+)
+
+func TestPipelineSummaryCountsSchemaViolations(t *testing.T) {
+	runner := NewConcurrencyRunner(1, 3)
+	cfg := SchemaConfig{Schema: schema.Config{Rules: []schema.Rule{{Required: []string{"k", "missing"}}}}}
+	pipe := New(fixedModelStage{}, NewSchemaStage(cfg))
+
+	if err := pipe.RunWithOptions(context.Background(), runner, noopBatchExporterFactory{}, RunOptions{}); err != nil {
+		t.Fatalf("pipeline run error: %v", err)
+	}
+	summary := pipe.Summary()
+	if summary.SchemaViolatingSpans != 3 || summary.SchemaViolations["internal: missing missing"] != 3 || len(summary.SchemaViolations) != 1 {
+		t.Fatalf("expected 3 spans missing one key, got %d %v", summary.SchemaViolatingSpans, summary.SchemaViolations)
+	}
+}
+
+func TestSchemaStageFailFast(t *testing.T) {
+	cfg := SchemaConfig{Schema: schema.Config{Rules: []schema.Rule{{Required: []string{"missing"}}}}, FailFast: true}
+	spans, _ := fixedModelStage{}.process(context.Background(), nil)
+
+	_, err := NewSchemaStage(cfg).process(context.Background(), spans)
+	if err == nil || !strings.Contains(err.Error(), `span "fixed"`) || !strings.Contains(err.Error(), "internal: missing missing") {
+		t.Fatalf("expected a schema violation error, got %v", err)
+	}
+}
@@ -0,0 +1,255 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/javiermolinar/tercios/internal/model"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// SemconvProfile is a set of semantic convention attributes the semconv
+// stage fills in on spans of the kinds it applies to.
+type SemconvProfile string
+
+const (
+	SemconvHTTP      SemconvProfile = "http"
+	SemconvDB        SemconvProfile = "db"
+	SemconvMessaging SemconvProfile = "messaging"
+	SemconvRPC       SemconvProfile = "rpc"
+	SemconvFaaS      SemconvProfile = "faas"
+)
+
+// semconvProfileKinds lists the span kinds each profile describes.
+var semconvProfileKinds = map[SemconvProfile][]oteltrace.SpanKind{
+	SemconvHTTP:      {oteltrace.SpanKindClient, oteltrace.SpanKindServer},
+	SemconvDB:        {oteltrace.SpanKindClient},
+	SemconvMessaging: {oteltrace.SpanKindProducer, oteltrace.SpanKindConsumer},
+	SemconvRPC:       {oteltrace.SpanKindClient, oteltrace.SpanKindServer},
+	SemconvFaaS:      {oteltrace.SpanKindServer},
+}
+
+// ParseSemconvProfiles reads a comma-separated list of profile names.
+func ParseSemconvProfiles(value string) ([]SemconvProfile, error) {
+	var profiles []SemconvProfile
+	seen := map[SemconvProfile]struct{}{}
+	for _, item := range strings.Split(value, ",") {
+		profile := SemconvProfile(strings.ToLower(strings.TrimSpace(item)))
+		if profile == "" {
+			continue
+		}
+		if _, ok := semconvProfileKinds[profile]; !ok {
+			return nil, fmt.Errorf("unsupported semconv profile %q (http, db, messaging, rpc or faas)", profile)
+		}
+		if _, dup := seen[profile]; dup {
+			continue
+		}
+		seen[profile] = struct{}{}
+		profiles = append(profiles, profile)
+	}
+	if len(profiles) == 0 {
+		return nil, fmt.Errorf("at least one semconv profile is required")
+	}
+	return profiles, nil
+}
+
+type semconvProfileStage struct {
+	byKind map[oteltrace.SpanKind][]SemconvProfile
+}
+
+// NewSemconvProfileStage fills in realistic semantic convention attributes
+// for the selected profiles, by span kind, without overwriting attributes
+// the scenario already set. When several profiles describe a kind, a span
+// that already has attributes in one of their namespaces (http.*, db.*,
+// ...) keeps to that profile; other spans get one picked by span ID, so
+// the mix is stable.
+func NewSemconvProfileStage(profiles []SemconvProfile) BatchStage {
+	byKind := map[oteltrace.SpanKind][]SemconvProfile{}
+	for _, profile := range profiles {
+		for _, kind := range semconvProfileKinds[profile] {
+			byKind[kind] = append(byKind[kind], profile)
+		}
+	}
+	return &semconvProfileStage{byKind: byKind}
+}
+
+func (s *semconvProfileStage) name() string {
+	return "semconv-profile"
+}
+
+func (s *semconvProfileStage) process(_ context.Context, spans []model.Span) ([]model.Span, error) {
+	for i := range spans {
+		span := &spans[i]
+		candidates := s.byKind[span.Kind]
+		if len(candidates) == 0 {
+			continue
+		}
+		if matching := profilesInUse(candidates, span.Attributes); len(matching) > 0 {
+			candidates = matching
+		}
+		pick := binary.BigEndian.Uint64(span.SpanID[:])
+		profile := candidates[pick%uint64(len(candidates))]
+		// pick>>8 keeps value choices independent of the profile choice.
+		attrs, resource := semconvAttributes(profile, span, pick>>8)
+		span.Attributes = fillMissing(span.Attributes, attrs)
+		span.ResourceAttributes = fillMissing(span.ResourceAttributes, resource)
+	}
+	return spans, nil
+}
+
+func profilesInUse(candidates []SemconvProfile, attrs map[string]attribute.Value) []SemconvProfile {
+	var out []SemconvProfile
+	for _, profile := range candidates {
+		for key := range attrs {
+			if strings.HasPrefix(key, string(profile)+".") {
+				out = append(out, profile)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// fillMissing returns a copy of attrs with the keys of extra it lacks; the
+// input may be shared with other spans and is left untouched.
+func fillMissing(attrs map[string]attribute.Value, extra []attribute.KeyValue) map[string]attribute.Value {
+	var out map[string]attribute.Value
+	for _, kv := range extra {
+		if _, ok := attrs[string(kv.Key)]; ok {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]attribute.Value, len(attrs)+len(extra))
+			maps.Copy(out, attrs)
+		}
+		out[string(kv.Key)] = kv.Value
+	}
+	if out == nil {
+		return attrs
+	}
+	return out
+}
+
+var (
+	semconvHTTPRoutes  = []string{"/api/items", "/api/items/{id}", "/api/orders", "/api/users/{id}", "/api/cart"}
+	semconvHTTPMethods = []string{"GET", "GET", "GET", "POST", "PUT", "DELETE"}
+	semconvDBQueries   = []struct{ operation, table, query string }{
+		{"SELECT", "items", "SELECT id, name, price FROM items WHERE id = $1"},
+		{"SELECT", "orders", "SELECT * FROM orders WHERE user_id = $1 ORDER BY created_at DESC LIMIT 20"},
+		{"INSERT", "orders", "INSERT INTO orders (user_id, total) VALUES ($1, $2)"},
+		{"UPDATE", "users", "UPDATE users SET last_seen = now() WHERE id = $1"},
+	}
+	semconvRPCMethods = []struct{ service, method string }{
+		{"inventory.v1.InventoryService", "GetItem"},
+		{"inventory.v1.InventoryService", "ListItems"},
+		{"payments.v1.PaymentService", "Charge"},
+		{"users.v1.UserService", "GetUser"},
+	}
+	semconvTopics = []string{"orders", "payments", "inventory-updates"}
+)
+
+func semconvAttributes(profile SemconvProfile, span *model.Span, pick uint64) (attrs []attribute.KeyValue, resource []attribute.KeyValue) {
+	failed := span.StatusCode == codes.Error
+	switch profile {
+	case SemconvHTTP:
+		route := semconvHTTPRoutes[pick%uint64(len(semconvHTTPRoutes))]
+		if existing, ok := span.Attributes["http.route"]; ok && existing.Type() == attribute.STRING {
+			route = existing.AsString()
+		}
+		path := strings.ReplaceAll(route, "{id}", fmt.Sprint(pick%1000))
+		status := 200
+		if failed {
+			status = 500
+		}
+		attrs = []attribute.KeyValue{
+			attribute.String("http.request.method", semconvHTTPMethods[(pick>>8)%uint64(len(semconvHTTPMethods))]),
+			attribute.Int("http.response.status_code", status),
+			attribute.String("network.protocol.version", "1.1"),
+			attribute.String("server.address", "api.internal"),
+			attribute.Int("server.port", 8080),
+		}
+		if span.Kind == oteltrace.SpanKindServer {
+			attrs = append(attrs,
+				attribute.String("http.route", route),
+				attribute.String("url.path", path),
+				attribute.String("url.scheme", "http"),
+				attribute.String("client.address", fmt.Sprintf("10.0.%d.%d", (pick>>16)%256, (pick>>24)%256)),
+				attribute.String("user_agent.original", "Mozilla/5.0 (X11; Linux x86_64) tercios"),
+			)
+		} else {
+			attrs = append(attrs, attribute.String("url.full", "http://api.internal:8080"+path))
+		}
+		if failed {
+			attrs = append(attrs, attribute.String("error.type", "500"))
+		}
+	case SemconvDB:
+		query := semconvDBQueries[pick%uint64(len(semconvDBQueries))]
+		attrs = []attribute.KeyValue{
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.namespace", "shop"),
+			attribute.String("db.operation.name", query.operation),
+			attribute.String("db.collection.name", query.table),
+			attribute.String("db.query.text", query.query),
+			attribute.String("server.address", "postgres.internal"),
+			attribute.Int("server.port", 5432),
+		}
+		if failed {
+			attrs = append(attrs, attribute.String("error.type", "40001"))
+		}
+	case SemconvMessaging:
+		topic := semconvTopics[pick%uint64(len(semconvTopics))]
+		attrs = []attribute.KeyValue{
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.destination.name", topic),
+			attribute.String("messaging.message.id", fmt.Sprintf("%016x", pick)),
+			attribute.Int("messaging.destination.partition.id", int((pick>>8)%12)),
+			attribute.String("server.address", "kafka.internal"),
+		}
+		if span.Kind == oteltrace.SpanKindProducer {
+			attrs = append(attrs, attribute.String("messaging.operation.type", "send"))
+		} else {
+			attrs = append(attrs,
+				attribute.String("messaging.operation.type", "process"),
+				attribute.String("messaging.consumer.group.name", topic+"-consumers"),
+			)
+		}
+	case SemconvRPC:
+		method := semconvRPCMethods[pick%uint64(len(semconvRPCMethods))]
+		status := 0
+		if failed {
+			status = 2
+		}
+		attrs = []attribute.KeyValue{
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.service", method.service),
+			attribute.String("rpc.method", method.method),
+			attribute.Int("rpc.grpc.status_code", status),
+			attribute.String("server.address", "grpc.internal"),
+			attribute.Int("server.port", 50051),
+		}
+	case SemconvFaaS:
+		attrs = []attribute.KeyValue{
+			attribute.String("faas.trigger", "http"),
+			attribute.String("faas.invocation_id", fmt.Sprintf("%016x", pick)),
+			attribute.Bool("faas.coldstart", pick%20 == 0),
+		}
+		service := "function"
+		if name, ok := span.ResourceAttributes["service.name"]; ok {
+			service = name.Emit()
+		}
+		resource = []attribute.KeyValue{
+			attribute.String("cloud.provider", "aws"),
+			attribute.String("cloud.platform", "aws_lambda"),
+			attribute.String("cloud.region", "eu-west-1"),
+			attribute.String("faas.name", service),
+			attribute.String("faas.version", "$LATEST"),
+			attribute.Int("faas.max_memory", 536870912),
+		}
+	}
+	return attrs, resource
+}
@@ -0,0 +1,125 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/javiermolinar/tercios/internal/model"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+func TestSemconvProfileStageFillsAttributesByKind(t *testing.T) {
+	stage := NewSemconvProfileStage([]SemconvProfile{SemconvHTTP, SemconvMessaging})
+	shared := map[string]attribute.Value{"http.route": attribute.StringValue("/checkout")}
+	spans := []model.Span{
+		{SpanID: oteltrace.SpanID{0x01}, Kind: oteltrace.SpanKindServer, Attributes: shared, StatusCode: codes.Error},
+		{SpanID: oteltrace.SpanID{0x02}, Kind: oteltrace.SpanKindProducer},
+		{SpanID: oteltrace.SpanID{0x03}, Kind: oteltrace.SpanKindConsumer},
+		{SpanID: oteltrace.SpanID{0x04}, Kind: oteltrace.SpanKindInternal},
+	}
+
+	out, err := stage.process(context.Background(), spans)
+	if err != nil {
+		t.Fatalf("process() error = %v", err)
+	}
+	server := out[0].Attributes
+	if server["http.route"].AsString() != "/checkout" {
+		t.Fatalf("expected the scenario's http.route to be kept, got %q", server["http.route"].AsString())
+	}
+	if server["http.response.status_code"].AsInt64() != 500 || server["http.request.method"].AsString() == "" || server["url.path"].AsString() == "" {
+		t.Fatalf("unexpected server attributes %v", server)
+	}
+	if len(shared) != 1 {
+		t.Fatalf("expected the shared input map to stay untouched")
+	}
+	if got := out[1].Attributes["messaging.operation.type"].AsString(); got != "send" {
+		t.Fatalf("producer messaging.operation.type = %q, want send", got)
+	}
+	if got := out[2].Attributes["messaging.consumer.group.name"].AsString(); got == "" {
+		t.Fatalf("expected a consumer group on the consumer span, got %v", out[2].Attributes)
+	}
+	if out[3].Attributes != nil {
+		t.Fatalf("expected internal spans to be left alone, got %v", out[3].Attributes)
+	}
+}
+
+func TestSemconvProfileStageMixesProfilesSharingAKind(t *testing.T) {
+	stage := NewSemconvProfileStage([]SemconvProfile{SemconvHTTP, SemconvDB, SemconvRPC})
+	spans := make([]model.Span, 300)
+	for i := range spans {
+		spans[i] = model.Span{SpanID: oteltrace.SpanID{0, 0, 0, 0, 0, 0, byte(i >> 8), byte(i)}, Kind: oteltrace.SpanKindClient}
+	}
+	out, err := stage.process(context.Background(), spans)
+	if err != nil {
+		t.Fatalf("process() error = %v", err)
+	}
+	counts := map[string]int{}
+	for _, span := range out {
+		switch {
+		case span.Attributes["url.full"].AsString() != "":
+			counts["http"]++
+		case span.Attributes["db.system"].AsString() != "":
+			counts["db"]++
+		case span.Attributes["rpc.system"].AsString() != "":
+			counts["rpc"]++
+		}
+	}
+	if counts["http"] != 100 || counts["db"] != 100 || counts["rpc"] != 100 {
+		t.Fatalf("expected client spans split evenly across profiles, got %v", counts)
+	}
+}
+
+func TestSemconvProfileStageAddsFaaSResource(t *testing.T) {
+	stage := NewSemconvProfileStage([]SemconvProfile{SemconvFaaS})
+	out, err := stage.process(context.Background(), []model.Span{{
+		Kind:               oteltrace.SpanKindServer,
+		ResourceAttributes: map[string]attribute.Value{"service.name": attribute.StringValue("resize-image")},
+	}})
+	if err != nil {
+		t.Fatalf("process() error = %v", err)
+	}
+	if got := out[0].ResourceAttributes["faas.name"].AsString(); got != "resize-image" {
+		t.Fatalf("faas.name = %q, want resize-image", got)
+	}
+	if out[0].Attributes["faas.trigger"].AsString() != "http" {
+		t.Fatalf("expected faas.trigger on the span, got %v", out[0].Attributes)
+	}
+}
+
+func TestParseSemconvProfiles(t *testing.T) {
+	got, err := ParseSemconvProfiles(" HTTP, db,http ")
+	if err != nil {
+		t.Fatalf("ParseSemconvProfiles() error = %v", err)
+	}
+	if len(got) != 2 || got[0] != SemconvHTTP || got[1] != SemconvDB {
+		t.Fatalf("unexpected profiles %v", got)
+	}
+	for _, value := range []string{"graphql", ","} {
+		if _, err := ParseSemconvProfiles(value); err == nil {
+			t.Fatalf("expected ParseSemconvProfiles(%q) to fail", value)
+		}
+	}
+}
+
+func TestSemconvProfileStageKeepsToNamespaceInUse(t *testing.T) {
+	stage := NewSemconvProfileStage([]SemconvProfile{SemconvHTTP, SemconvDB})
+	spans := make([]model.Span, 20)
+	for i := range spans {
+		spans[i] = model.Span{
+			SpanID:     oteltrace.SpanID{byte(i)},
+			Kind:       oteltrace.SpanKindClient,
+			Attributes: map[string]attribute.Value{"db.system": attribute.StringValue("redis")},
+		}
+	}
+	out, err := stage.process(context.Background(), spans)
+	if err != nil {
+		t.Fatalf("process() error = %v", err)
+	}
+	for _, span := range out {
+		if _, ok := span.Attributes["url.full"]; ok || span.Attributes["db.system"].AsString() != "redis" || span.Attributes["db.operation.name"].AsString() == "" {
+			t.Fatalf("expected a db client span to get only db attributes, got %v", span.Attributes)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+
+	"github.com/javiermolinar/tercios/internal/metrics"
+	"github.com/javiermolinar/tercios/internal/model"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+type shapeStage struct {
+	mu       sync.Mutex
+	shape    metrics.TraceShape
+	services map[string]struct{}
+}
+
+// NewShapeStage measures the traces passing through it for the run
+// summary's TraceShape, and passes them on unchanged. Traces are counted
+// per batch, as generators never split a trace across batches.
+func NewShapeStage() BatchStage {
+	return &shapeStage{services: map[string]struct{}{}}
+}
+
+func (s *shapeStage) name() string {
+	return "shape"
+}
+
+func (s *shapeStage) process(_ context.Context, spans []model.Span) ([]model.Span, error) {
+	traces := map[oteltrace.TraceID]struct{}{}
+	errors := 0
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, span := range spans {
+		traces[span.TraceID] = struct{}{}
+		if span.StatusCode == codes.Error {
+			errors++
+		}
+		if name, ok := span.ResourceAttributes["service.name"]; ok {
+			s.services[name.Emit()] = struct{}{}
+		}
+	}
+	s.shape.Traces += len(traces)
+	s.shape.Spans += len(spans)
+	s.shape.ErrorSpans += errors
+	return spans, nil
+}
+
+func (s *shapeStage) annotate(summary *metrics.Summary) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	shape := s.shape
+	shape.Services = len(s.services)
+	summary.TraceShape = &shape
+}
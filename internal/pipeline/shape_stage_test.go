@@ -0,0 +1,22 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPipelineSummaryMeasuresTraceShape(t *testing.T) {
+	runner := NewConcurrencyRunner(1, 4)
+	pipe := New(fixedModelStage{}, NewShapeStage())
+
+	if err := pipe.RunWithOptions(context.Background(), runner, noopBatchExporterFactory{}, RunOptions{}); err != nil {
+		t.Fatalf("pipeline run error: %v", err)
+	}
+	shape := pipe.Summary().TraceShape
+	if shape == nil {
+		t.Fatalf("expected a trace shape in the summary")
+	}
+	if shape.Traces != 4 || shape.Spans != 4 || shape.ErrorSpans != 0 || shape.Services != 1 {
+		t.Fatalf("shape = %+v, want 4 one-span traces of one service", *shape)
+	}
+}
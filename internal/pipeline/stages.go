@@ -0,0 +1,201 @@
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/javiermolinar/tercios/internal/chaos"
+	"github.com/javiermolinar/tercios/internal/idgen"
+	"github.com/javiermolinar/tercios/internal/scenario"
+)
+
+// StageOptions selects the stages of a run. The CLI and pkg/determinism
+// both build their pipelines with NewStages, so a determinism check goes
+// through the same stages, in the same order, as a real run.
+type StageOptions struct {
+	// Source, when set, produces the batches instead of the scenarios,
+	// e.g. a replay stage.
+	Source BatchStage
+	// ScenarioFiles are scenario files or builtin:NAME scenarios; empty
+	// uses the embedded default scenario.
+	ScenarioFiles []string
+	// Strategy picks among several scenarios, as --scenario-strategy.
+	Strategy string
+	// RunSeed namespaces scenario IDs, as --scenario-run-seed.
+	RunSeed int64
+	// IDGenerator is the trace and span ID generator, as --id-generator.
+	IDGenerator string
+	// TracesPerRequest is the number of traces in each batch.
+	TracesPerRequest int
+	// SpansPerRequest, when > 0, sizes batches by spans instead.
+	SpansPerRequest float64
+
+	// ChaosPoliciesFile is an optional chaos policies file. Edge chaos in
+	// the scenario files is applied too.
+	ChaosPoliciesFile string
+	// ChaosSeed overrides the seed of the policies file when set.
+	ChaosSeed int64
+	// Seed is the run seed. It seeds chaos when neither ChaosSeed nor the
+	// policies file does.
+	Seed int64
+	// ChaosMarker stamps chaos-modified spans with the policies applied.
+	ChaosMarker bool
+
+	Jitter           *JitterConfig
+	SemconvProfiles  []SemconvProfile
+	Events           *EventsConfig
+	Cardinality      *CardinalityConfig
+	UniqueNames      *UniqueNamesConfig
+	AttributePrefix  string
+	StaticAttributes *StaticAttributesConfig
+	FutureTimestamps *FutureTimestampsConfig
+	Backfill         *BackfillConfig
+	// Shape records trace shapes for the expectations of a run.
+	Shape  bool
+	Schema *SchemaConfig
+}
+
+// NewStages builds the batch stages selected by opts.
+func NewStages(opts StageOptions) ([]BatchStage, error) {
+	stages := make([]BatchStage, 0, 4)
+	if opts.Source != nil {
+		stages = append(stages, opts.Source)
+	} else {
+		source, err := newScenarioStage(opts)
+		if err != nil {
+			return nil, err
+		}
+		stages = append(stages, source)
+	}
+	if opts.Jitter != nil {
+		stages = append(stages, NewJitterStage(*opts.Jitter))
+	}
+	chaosStage, err := newChaosStage(opts)
+	if err != nil {
+		return nil, err
+	}
+	if chaosStage != nil {
+		stages = append(stages, chaosStage)
+	}
+
+	// Semconv attributes go after chaos so status-derived values such as
+	// http.response.status_code agree with the final span status.
+	if len(opts.SemconvProfiles) > 0 {
+		stages = append(stages, NewSemconvProfileStage(opts.SemconvProfiles))
+	}
+	// Events go after chaos so spans it fails get an exception event.
+	if opts.Events != nil {
+		stages = append(stages, NewEventsStage(*opts.Events))
+	}
+	if opts.Cardinality != nil {
+		stages = append(stages, NewCardinalityStage(*opts.Cardinality))
+	}
+	if opts.UniqueNames != nil {
+		stages = append(stages, NewUniqueNamesStage(*opts.UniqueNames))
+	}
+	if opts.AttributePrefix != "" {
+		stages = append(stages, NewAttributePrefixStage(opts.AttributePrefix))
+	}
+	// Static attributes come after the prefix so their keys are sent
+	// exactly as given.
+	if opts.StaticAttributes != nil {
+		stages = append(stages, NewStaticAttributesStage(*opts.StaticAttributes))
+	}
+	if opts.FutureTimestamps != nil {
+		stages = append(stages, NewFutureTimestampsStage(*opts.FutureTimestamps))
+	}
+	if opts.Backfill != nil {
+		stages = append(stages, NewBackfillStage(*opts.Backfill))
+	}
+	if opts.Shape {
+		stages = append(stages, NewShapeStage())
+	}
+	// The schema check runs last so it sees spans exactly as exported.
+	if opts.Schema != nil {
+		stages = append(stages, NewSchemaStage(*opts.Schema))
+	}
+	return stages, nil
+}
+
+// newScenarioStage builds the scenario stage of opts, sizing batches by
+// spans when SpansPerRequest is set and by traces otherwise.
+func newScenarioStage(opts StageOptions) (BatchStage, error) {
+	var generator scenario.BatchGenerator
+	if len(opts.ScenarioFiles) > 0 {
+		strategy, err := scenario.ParseSelectionStrategy(opts.Strategy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid scenario strategy: %w", err)
+		}
+		generator, err = scenario.NewBatchGeneratorFromFilesWithRunSeed(opts.ScenarioFiles, strategy, opts.RunSeed)
+		if err != nil {
+			return nil, fmt.Errorf("invalid scenario setup: %w", err)
+		}
+	} else {
+		var err error
+		generator, err = scenario.DefaultGenerator(opts.RunSeed)
+		if err != nil {
+			return nil, fmt.Errorf("embedded scenario failed: %w", err)
+		}
+	}
+	if err := setIDGenerator(generator, opts.IDGenerator, opts.RunSeed); err != nil {
+		return nil, err
+	}
+	if opts.SpansPerRequest > 0 {
+		return NewScenarioStageWithSpanTarget(generator, opts.SpansPerRequest), nil
+	}
+	return NewScenarioStageWithTraces(generator, opts.TracesPerRequest), nil
+}
+
+// setIDGenerator installs the ID generator named kind on generator. The
+// prefixed generator encodes the low 32 bits of the run seed.
+func setIDGenerator(generator scenario.BatchGenerator, kind string, runSeed int64) error {
+	parsed, err := idgen.ParseKind(kind)
+	if err != nil {
+		return fmt.Errorf("invalid scenario setup: %w", err)
+	}
+	if parsed == idgen.KindSeeded {
+		return nil
+	}
+	ids, err := idgen.New(parsed, uint32(runSeed))
+	if err != nil {
+		return fmt.Errorf("invalid scenario setup: %w", err)
+	}
+	if setter, ok := generator.(interface{ SetIDGenerator(idgen.Generator) }); ok {
+		setter.SetIDGenerator(ids)
+	}
+	return nil
+}
+
+// newChaosStage builds the chaos stage from the policies file and the
+// edge chaos of the scenario files, or returns nil when there are no
+// policies.
+func newChaosStage(opts StageOptions) (BatchStage, error) {
+	chaosCfg := chaos.DefaultConfig()
+	if opts.ChaosPoliciesFile != "" {
+		fileCfg, err := chaos.LoadFromJSON(opts.ChaosPoliciesFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chaos policies: %w", err)
+		}
+		chaosCfg = fileCfg
+	}
+	edgePolicies, err := scenario.LoadChaosPolicies(opts.ScenarioFiles)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scenario edge chaos: %w", err)
+	}
+	chaosCfg.Policies = append(chaosCfg.Policies, edgePolicies...)
+	if len(chaosCfg.Policies) == 0 {
+		return nil, nil
+	}
+	if opts.ChaosMarker && chaosCfg.MarkerAttribute == "" {
+		chaosCfg.MarkerAttribute = chaos.DefaultMarkerAttribute
+	}
+	if opts.ChaosSeed != 0 {
+		chaosCfg.Seed = opts.ChaosSeed
+	} else if chaosCfg.Seed == 0 && opts.Seed != 0 {
+		chaosCfg.Seed = DerivedSeed(opts.Seed, 2)
+	}
+	engine, err := chaos.NewEngine(chaosCfg)
+	if err != nil {
+		return nil, fmt.Errorf("create chaos engine: %w", err)
+	}
+	return NewSeededChaosStage(engine, chaosCfg.Seed), nil
+}
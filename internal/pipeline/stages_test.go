@@ -0,0 +1,47 @@
+package pipeline
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestNewStagesOrdersStagesLikeARun(t *testing.T) {
+	stages, err := NewStages(StageOptions{
+		ScenarioFiles:    []string{"builtin:ecommerce"},
+		TracesPerRequest: 1,
+		Seed:             1,
+		Jitter:           &JitterConfig{Attributes: 0.5, Seed: 1},
+		SemconvProfiles:  []SemconvProfile{SemconvHTTP},
+		Events:           &EventsConfig{PerSpan: 1, Probability: 1, Seed: 1},
+		Cardinality:      &CardinalityConfig{Attributes: []CardinalityAttribute{{Key: "user.id", Cardinality: 10}}, Seed: 1},
+		UniqueNames:      &UniqueNamesConfig{},
+		AttributePrefix:  "test.",
+		StaticAttributes: &StaticAttributesConfig{},
+		FutureTimestamps: &FutureTimestampsConfig{Fraction: 0.1, Offset: time.Hour},
+		Backfill:         &BackfillConfig{Days: 1, RequestsPerDay: 10},
+		Shape:            true,
+	})
+	if err != nil {
+		t.Fatalf("NewStages() error = %v", err)
+	}
+	names := make([]string, 0, len(stages))
+	for _, stage := range stages {
+		names = append(names, stage.name())
+	}
+	want := []string{"scenario", "jitter", "semconv-profile", "events", "cardinality", "unique-names", "attribute-prefix", "static-attributes", "future-timestamps", "backfill", "shape"}
+	if !slices.Equal(names, want) {
+		t.Fatalf("stages = %v, want %v", names, want)
+	}
+}
+
+func TestNewStagesUsesSourceInsteadOfScenarios(t *testing.T) {
+	source := fixedModelStage{}
+	stages, err := NewStages(StageOptions{Source: source})
+	if err != nil {
+		t.Fatalf("NewStages() error = %v", err)
+	}
+	if len(stages) != 1 || stages[0] != source {
+		t.Fatalf("expected only the source stage, got %v", stages)
+	}
+}
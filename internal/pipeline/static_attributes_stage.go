@@ -0,0 +1,130 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+
+	"github.com/javiermolinar/tercios/internal/model"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// StaticAttributesConfig configures fixed attributes set on every span and
+// resource, e.g. a test.run_id that tags load-test traffic so it can be
+// filtered out later.
+type StaticAttributesConfig struct {
+	Span     []attribute.KeyValue
+	Resource []attribute.KeyValue
+}
+
+// AttributeFlags collects repeated key=value:type flags. The type is
+// string, int, float or bool; without a known type suffix the whole value
+// is a string, so values such as URLs may contain colons.
+type AttributeFlags struct {
+	attributes []attribute.KeyValue
+}
+
+func (f *AttributeFlags) String() string {
+	if f == nil {
+		return ""
+	}
+	parts := make([]string, 0, len(f.attributes))
+	for _, kv := range f.attributes {
+		parts = append(parts, string(kv.Key)+"="+kv.Value.Emit()+":"+strings.ToLower(kv.Value.Type().String()))
+	}
+	return strings.Join(parts, ",")
+}
+
+func (f *AttributeFlags) Set(value string) error {
+	key, raw, ok := strings.Cut(value, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return fmt.Errorf("attribute must be key=value or key=value:type")
+	}
+	kv, err := parseTypedAttribute(key, raw)
+	if err != nil {
+		return err
+	}
+	f.attributes = append(f.attributes, kv)
+	return nil
+}
+
+func (f *AttributeFlags) Values() []attribute.KeyValue {
+	if f == nil || len(f.attributes) == 0 {
+		return nil
+	}
+	out := make([]attribute.KeyValue, len(f.attributes))
+	copy(out, f.attributes)
+	return out
+}
+
+func parseTypedAttribute(key, raw string) (attribute.KeyValue, error) {
+	value, typeName := raw, "string"
+	if i := strings.LastIndex(raw, ":"); i >= 0 {
+		switch suffix := strings.ToLower(strings.TrimSpace(raw[i+1:])); suffix {
+		case "string", "int", "float", "bool":
+			value, typeName = raw[:i], suffix
+		}
+	}
+	switch typeName {
+	case "int":
+		parsed, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return attribute.KeyValue{}, fmt.Errorf("attribute %q: expected int value, got %q", key, value)
+		}
+		return attribute.Int64(key, parsed), nil
+	case "float":
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return attribute.KeyValue{}, fmt.Errorf("attribute %q: expected float value, got %q", key, value)
+		}
+		return attribute.Float64(key, parsed), nil
+	case "bool":
+		parsed, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return attribute.KeyValue{}, fmt.Errorf("attribute %q: expected bool value, got %q", key, value)
+		}
+		return attribute.Bool(key, parsed), nil
+	default:
+		return attribute.String(key, value), nil
+	}
+}
+
+type staticAttributesStage struct {
+	config StaticAttributesConfig
+}
+
+// NewStaticAttributesStage sets the configured attributes on every span and
+// its resource, replacing any value the scenario or recording had for the
+// same key.
+func NewStaticAttributesStage(cfg StaticAttributesConfig) BatchStage {
+	return staticAttributesStage{config: cfg}
+}
+
+func (s staticAttributesStage) name() string {
+	return "static-attributes"
+}
+
+func (s staticAttributesStage) process(_ context.Context, spans []model.Span) ([]model.Span, error) {
+	for i := range spans {
+		spans[i].Attributes = withAttributes(spans[i].Attributes, s.config.Span)
+		spans[i].ResourceAttributes = withAttributes(spans[i].ResourceAttributes, s.config.Resource)
+	}
+	return spans, nil
+}
+
+// withAttributes returns a copy of attrs with extra set; the input may be
+// shared with other spans and is left untouched.
+func withAttributes(attrs map[string]attribute.Value, extra []attribute.KeyValue) map[string]attribute.Value {
+	if len(extra) == 0 {
+		return attrs
+	}
+	out := make(map[string]attribute.Value, len(attrs)+len(extra))
+	maps.Copy(out, attrs)
+	for _, kv := range extra {
+		out[string(kv.Key)] = kv.Value
+	}
+	return out
+}
@@ -0,0 +1,69 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/javiermolinar/tercios/internal/model"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestStaticAttributesStageSetsSpanAndResourceAttributes(t *testing.T) {
+	stage := NewStaticAttributesStage(StaticAttributesConfig{
+		Span:     []attribute.KeyValue{attribute.String("test.run_id", "run-42"), attribute.String("http.route", "/override")},
+		Resource: []attribute.KeyValue{attribute.String("deployment.environment", "loadtest")},
+	})
+	shared := map[string]attribute.Value{"http.route": attribute.StringValue("/items")}
+	resource := map[string]attribute.Value{"service.name": attribute.StringValue("api")}
+	spans := []model.Span{
+		{Attributes: shared, ResourceAttributes: resource},
+		{Attributes: shared, ResourceAttributes: resource},
+	}
+
+	out, err := stage.process(context.Background(), spans)
+	if err != nil {
+		t.Fatalf("process() error = %v", err)
+	}
+	for _, span := range out {
+		if span.Attributes["test.run_id"].AsString() != "run-42" || span.Attributes["http.route"].AsString() != "/override" {
+			t.Fatalf("unexpected span attributes %v", span.Attributes)
+		}
+		if span.ResourceAttributes["deployment.environment"].AsString() != "loadtest" || span.ResourceAttributes["service.name"].AsString() != "api" {
+			t.Fatalf("unexpected resource attributes %v", span.ResourceAttributes)
+		}
+	}
+	if shared["http.route"].AsString() != "/items" || len(resource) != 1 {
+		t.Fatalf("expected the shared input maps to stay untouched, got %v and %v", shared, resource)
+	}
+}
+
+func TestAttributeFlags(t *testing.T) {
+	var flags AttributeFlags
+	for _, value := range []string{"test.run_id=run-42", "retries=3:int", "ratio=0.25:float", "canary=true:bool", "url=http://host:8080", "label=a:b:string"} {
+		if err := flags.Set(value); err != nil {
+			t.Fatalf("Set(%q) error = %v", value, err)
+		}
+	}
+	want := []attribute.KeyValue{
+		attribute.String("test.run_id", "run-42"),
+		attribute.Int64("retries", 3),
+		attribute.Float64("ratio", 0.25),
+		attribute.Bool("canary", true),
+		attribute.String("url", "http://host:8080"),
+		attribute.String("label", "a:b"),
+	}
+	got := flags.Values()
+	if len(got) != len(want) {
+		t.Fatalf("expected %d values, got %+v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("value %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	for _, value := range []string{"run_id", "=x", "retries=three:int", "ratio=high:float", "canary=maybe:bool"} {
+		if err := flags.Set(value); err == nil {
+			t.Fatalf("Set(%q) expected error", value)
+		}
+	}
+}
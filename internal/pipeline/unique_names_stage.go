@@ -0,0 +1,80 @@
+package pipeline
+
+import (
+	"context"
+	"maps"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/model"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// UniqueNamesConfig configures the unique span name stress mode, which
+// targets backends' span name dictionaries and autocomplete indexes.
+type UniqueNamesConfig struct {
+	// PerMinute caps how many new names are introduced per minute. Spans
+	// over the budget keep their original name. Zero renames every span.
+	PerMinute float64
+	// ResourceAttribute, when set, is given the same unique value on the
+	// resource of every renamed span.
+	ResourceAttribute string
+	// Token, when set, replaces the run token taken from the start time,
+	// so a reproducibility check sees the same names in every run.
+	Token string
+}
+
+type uniqueNamesStage struct {
+	config UniqueNamesConfig
+	now    func() time.Time
+
+	mu     sync.Mutex
+	start  time.Time
+	token  string
+	issued int64
+}
+
+func NewUniqueNamesStage(cfg UniqueNamesConfig) BatchStage {
+	return &uniqueNamesStage{config: cfg, now: time.Now}
+}
+
+func (s *uniqueNamesStage) name() string {
+	return "unique-names"
+}
+
+func (s *uniqueNamesStage) process(_ context.Context, spans []model.Span) ([]model.Span, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if s.start.IsZero() {
+		s.start = now
+		// The run token keeps names unique across runs against the same
+		// backend, not only within one run.
+		s.token = s.config.Token
+		if s.token == "" {
+			s.token = strconv.FormatInt(now.UnixNano(), 36)
+		}
+	}
+	budget := int64(-1)
+	if s.config.PerMinute > 0 {
+		budget = int64(now.Sub(s.start).Minutes()*s.config.PerMinute) + 1
+	}
+
+	for i := range spans {
+		if budget >= 0 && s.issued >= budget {
+			break
+		}
+		suffix := s.token + "-" + strconv.FormatInt(s.issued, 10)
+		s.issued++
+		spans[i].Name = spans[i].Name + "-" + suffix
+		if s.config.ResourceAttribute != "" {
+			resource := make(map[string]attribute.Value, len(spans[i].ResourceAttributes)+1)
+			maps.Copy(resource, spans[i].ResourceAttributes)
+			resource[s.config.ResourceAttribute] = attribute.StringValue(suffix)
+			spans[i].ResourceAttributes = resource
+		}
+	}
+	return spans, nil
+}
@@ -0,0 +1,86 @@
+package pipeline
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/model"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestUniqueNamesStageRenamesEverySpanWithoutBudget(t *testing.T) {
+	stage := NewUniqueNamesStage(UniqueNamesConfig{ResourceAttribute: "host.name"})
+	shared := map[string]attribute.Value{"service.name": attribute.StringValue("api")}
+
+	seen := map[string]bool{}
+	for batch := 0; batch < 3; batch++ {
+		out, err := stage.process(context.Background(), []model.Span{
+			{Name: "GET /items", ResourceAttributes: shared},
+			{Name: "GET /items", ResourceAttributes: shared},
+		})
+		if err != nil {
+			t.Fatalf("process() error = %v", err)
+		}
+		for _, span := range out {
+			if !strings.HasPrefix(span.Name, "GET /items-") {
+				t.Fatalf("expected renamed span, got %q", span.Name)
+			}
+			if seen[span.Name] {
+				t.Fatalf("duplicate span name %q", span.Name)
+			}
+			seen[span.Name] = true
+			host := span.ResourceAttributes["host.name"].AsString()
+			if !strings.HasSuffix(span.Name, host) {
+				t.Fatalf("expected resource attribute %q to match name %q", host, span.Name)
+			}
+		}
+	}
+	if _, ok := shared["host.name"]; ok {
+		t.Fatalf("expected shared resource map to stay untouched")
+	}
+}
+
+func TestUniqueNamesStageHonorsPerMinuteBudget(t *testing.T) {
+	now := time.Date(2026, time.March, 1, 10, 0, 0, 0, time.UTC)
+	stage := NewUniqueNamesStage(UniqueNamesConfig{PerMinute: 2}).(*uniqueNamesStage)
+	stage.now = func() time.Time { return now }
+
+	countRenamed := func() int {
+		out, err := stage.process(context.Background(), []model.Span{{Name: "a"}, {Name: "b"}, {Name: "c"}})
+		if err != nil {
+			t.Fatalf("process() error = %v", err)
+		}
+		renamed := 0
+		for _, span := range out {
+			if strings.Contains(span.Name, "-") {
+				renamed++
+			}
+		}
+		return renamed
+	}
+
+	if got := countRenamed(); got != 1 {
+		t.Fatalf("expected 1 new name at start, got %d", got)
+	}
+	now = now.Add(20 * time.Second)
+	if got := countRenamed(); got != 0 {
+		t.Fatalf("expected no new names before the next slot, got %d", got)
+	}
+	now = now.Add(40 * time.Second)
+	if got := countRenamed(); got != 2 {
+		t.Fatalf("expected 2 new names after a minute, got %d", got)
+	}
+}
+
+func TestUniqueNamesStageUsesConfiguredToken(t *testing.T) {
+	stage := NewUniqueNamesStage(UniqueNamesConfig{Token: "run7"})
+	out, err := stage.process(context.Background(), []model.Span{{Name: "a"}})
+	if err != nil {
+		t.Fatalf("process() error = %v", err)
+	}
+	if out[0].Name != "a-run7-0" {
+		t.Fatalf("expected the configured token in the name, got %q", out[0].Name)
+	}
+}
@@ -0,0 +1,430 @@
+// Package replay re-exports recorded traces as load. Recordings are files
+// written by tercios' file exporter (-o file://PATH) or by the collector's
+// file exporter, where each recorded request becomes one batch, or Jaeger
+// JSON exports, where each trace does.
+package replay
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/audit"
+	"github.com/javiermolinar/tercios/internal/jaeger"
+	"github.com/javiermolinar/tercios/internal/model"
+	"github.com/javiermolinar/tercios/internal/otlp"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+type Config struct {
+	Files []string
+	// NewIDs rotates trace and span IDs, so replayed traces do not merge
+	// with the recorded ones (or an earlier replay) in the backend.
+	NewIDs bool
+	// Retimestamp shifts every batch so its earliest span starts at the
+	// time it is generated, keeping the recorded offsets between spans.
+	Retimestamp bool
+	// Loop replays the recording again once it is exhausted, until the
+	// run's own limits stop it. Every pass after the first gets new trace
+	// and span IDs and is moved forward in time by the recording's length,
+	// so passes read as distinct, later traffic.
+	Loop bool
+	// Speed replays the recorded timeline: each batch is held back until
+	// its offset from the start of the recording, divided by Speed, has
+	// passed since the first batch was sent. Zero sends batches as fast
+	// as the run's pacing allows.
+	Speed float64
+	// Seed makes the rotated IDs reproducible. Zero picks a random seed.
+	Seed int64
+	// Stream reads the OTLP files one request at a time as batches are
+	// handed out, instead of loading them up front, so memory stays
+	// bounded by the largest request whatever the size of the files. The
+	// Speed timeline then starts at the first batch, and Loop reopens the
+	// files for every pass. Jaeger exports cannot be streamed.
+	Stream bool
+}
+
+// ParseSpeed reads a replay speed such as "2x", "0.5" or "max" (as fast
+// as possible, returned as zero).
+func ParseSpeed(value string) (float64, error) {
+	trimmed := strings.ToLower(strings.TrimSpace(value))
+	if trimmed == "max" {
+		return 0, nil
+	}
+	speed, err := strconv.ParseFloat(strings.TrimSuffix(trimmed, "x"), 64)
+	if err != nil || speed <= 0 {
+		return 0, fmt.Errorf("replay speed must be a positive multiplier such as 0.5x or 2x, or max")
+	}
+	return speed, nil
+}
+
+type recordedBatch struct {
+	source string
+	spans  model.Batch
+	start  time.Time
+}
+
+// Generator hands out the recorded batches in file order, once each, or
+// over and over with Loop. It is safe for concurrent use by every producer
+// worker.
+type Generator struct {
+	config  Config
+	batches []recordedBatch
+	next    atomic.Int64
+	// idSeed derives the ID masks of each pass.
+	idSeed uint64
+	now    func() time.Time
+	// origin is the earliest recorded span start and period the time from
+	// it to the latest span end; started is when the first batch was
+	// handed out. They anchor the Speed timeline and the Loop passes.
+	origin    time.Time
+	period    time.Duration
+	startOnce sync.Once
+	started   time.Time
+	// stream is set when Config.Stream reads the files as they are
+	// replayed.
+	stream *fileStream
+}
+
+// fileStream reads the replay files in order, one request at a time. Its
+// mutex also orders the batches handed to concurrent producers.
+type fileStream struct {
+	mu     sync.Mutex
+	files  []string
+	file   int
+	closer io.Closer
+	reader *otlp.FileReader
+	pass   int64
+	// read counts the batches of the current pass; latest is the latest
+	// span end of the first pass, which sets the Loop period.
+	read   int
+	latest time.Time
+}
+
+// idMasks are XORed into recorded IDs. XOR with a random mask is a
+// bijection, so every recorded ID maps to one new ID across batches without
+// keeping a lookup table, and parent/child and link references stay
+// consistent.
+type idMasks struct {
+	trace oteltrace.TraceID
+	span  oteltrace.SpanID
+}
+
+func NewGenerator(config Config) (*Generator, error) {
+	if len(config.Files) == 0 {
+		return nil, fmt.Errorf("at least one replay file is required")
+	}
+	g := &Generator{config: config, now: time.Now}
+	g.idSeed = uint64(config.Seed)
+	if g.idSeed == 0 {
+		var seed [8]byte
+		_, _ = rand.Read(seed[:])
+		g.idSeed = binary.BigEndian.Uint64(seed[:])
+	}
+	if config.Stream {
+		for _, path := range config.Files {
+			if err := checkStreamable(path); err != nil {
+				return nil, err
+			}
+		}
+		g.stream = &fileStream{files: config.Files}
+		return g, nil
+	}
+	for _, path := range config.Files {
+		batches, err := ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, batch := range batches {
+			start := earliestStart(batch)
+			if g.origin.IsZero() || start.Before(g.origin) {
+				g.origin = start
+			}
+			g.batches = append(g.batches, recordedBatch{source: filepath.Base(path), spans: batch, start: start})
+		}
+	}
+	if len(g.batches) == 0 {
+		return nil, fmt.Errorf("replay files contain no spans")
+	}
+	var latest time.Time
+	for _, recorded := range g.batches {
+		for _, span := range recorded.spans {
+			if span.EndTime.After(latest) {
+				latest = span.EndTime
+			}
+		}
+	}
+	// A pass lasts at least a millisecond, so passes of a recording whose
+	// spans all share one instant still get distinct timestamps.
+	g.period = max(latest.Sub(g.origin), time.Millisecond)
+	return g, nil
+}
+
+// checkStreamable opens path to report a missing file, or a Jaeger export,
+// before the run starts.
+func checkStreamable(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(file, head)
+	if otlp.FileFormatForPath(path) == otlp.FileFormatJSON && jaeger.IsExport(head[:n]) {
+		return fmt.Errorf("%s: Jaeger exports cannot be streamed; replay them without streaming", path)
+	}
+	return nil
+}
+
+// ReadFile reads an OTLP recording, or a Jaeger JSON export when the file
+// starts like one.
+func ReadFile(path string) ([]model.Batch, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(file, head)
+	_ = file.Close()
+	if otlp.FileFormatForPath(path) == otlp.FileFormatJSON && jaeger.IsExport(head[:n]) {
+		return jaeger.ReadFile(path)
+	}
+	return otlp.ReadFile(path)
+}
+
+// Batches returns the number of recorded batches, or zero when they are
+// streamed and not counted up front.
+func (g *Generator) Batches() int {
+	return len(g.batches)
+}
+
+// GenerateBatch returns the next recorded batch, or io.EOF once every
+// batch has been handed out.
+func (g *Generator) GenerateBatch(ctx context.Context) ([]model.Span, error) {
+	var recorded recordedBatch
+	var pass int64
+	var offset time.Duration
+	if g.stream != nil {
+		var err error
+		recorded, pass, offset, err = g.nextStreamed()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		index := g.next.Add(1) - 1
+		count := int64(len(g.batches))
+		if index >= count && !g.config.Loop {
+			return nil, io.EOF
+		}
+		pass = index / count
+		recorded = g.batches[index%count]
+		offset = time.Duration(pass) * g.period
+	}
+	if err := g.wait(ctx, recorded.start.Add(offset)); err != nil {
+		return nil, err
+	}
+	audit.FromContext(ctx).SetGenerator("replay:" + recorded.source)
+
+	out := recorded.spans
+	if g.stream == nil {
+		// Loaded batches are handed out again on every pass.
+		out = make([]model.Span, len(recorded.spans))
+		copy(out, recorded.spans)
+	}
+	if g.config.NewIDs || pass > 0 {
+		masks := g.masks(pass)
+		for i := range out {
+			masks.rotate(&out[i])
+		}
+	}
+	if offset > 0 {
+		shiftBy(out, offset)
+	}
+	if g.config.Retimestamp {
+		shiftToNow(out, g.now())
+	}
+	return out, nil
+}
+
+// nextStreamed reads the next batch from the files, with its pass and
+// the time offset of that pass. The first batch read is the origin of the
+// Speed timeline.
+func (g *Generator) nextStreamed() (recordedBatch, int64, time.Duration, error) {
+	s := g.stream
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for {
+		if s.reader == nil {
+			if s.file == len(s.files) {
+				if s.read == 0 && s.pass == 0 {
+					return recordedBatch{}, 0, 0, fmt.Errorf("replay files contain no spans")
+				}
+				if !g.config.Loop {
+					return recordedBatch{}, 0, 0, io.EOF
+				}
+				if s.pass == 0 {
+					g.period = max(s.latest.Sub(g.origin), time.Millisecond)
+				}
+				s.pass++
+				s.file = 0
+				s.read = 0
+			}
+			file, err := os.Open(s.files[s.file])
+			if err != nil {
+				return recordedBatch{}, 0, 0, err
+			}
+			s.closer = file
+			s.reader = otlp.NewFileReader(file, otlp.FileFormatForPath(s.files[s.file]))
+			s.file++
+		}
+		path := s.files[s.file-1]
+		batch, err := s.reader.Next()
+		if err != nil {
+			_ = s.closer.Close()
+			s.reader = nil
+			if errors.Is(err, io.EOF) {
+				continue
+			}
+			return recordedBatch{}, 0, 0, fmt.Errorf("%s: %w", path, err)
+		}
+		if len(batch) == 0 {
+			continue
+		}
+		start := earliestStart(batch)
+		if s.pass == 0 {
+			if g.origin.IsZero() {
+				g.origin = start
+			}
+			for _, span := range batch {
+				if span.EndTime.After(s.latest) {
+					s.latest = span.EndTime
+				}
+			}
+		}
+		s.read++
+		return recordedBatch{source: filepath.Base(path), spans: batch, start: start}, s.pass, time.Duration(s.pass) * g.period, nil
+	}
+}
+
+// wait holds a batch back until its place on the recorded timeline, scaled
+// by Speed. Batches whose time has passed, e.g. because the recording is
+// not in time order, are sent right away.
+func (g *Generator) wait(ctx context.Context, start time.Time) error {
+	if g.config.Speed <= 0 {
+		return nil
+	}
+	g.startOnce.Do(func() { g.started = g.now() })
+	due := g.started.Add(time.Duration(float64(start.Sub(g.origin)) / g.config.Speed))
+	delay := due.Sub(g.now())
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// masks returns the ID masks of a pass: the same for every batch of the
+// pass, different from every other pass.
+func (g *Generator) masks(pass int64) idMasks {
+	var masks idMasks
+	state := g.idSeed ^ uint64(pass)*0x9e3779b97f4a7c15
+	for i := 0; i < len(masks.trace); i += 8 {
+		state = splitMix(state)
+		binary.BigEndian.PutUint64(masks.trace[i:], state)
+	}
+	binary.BigEndian.PutUint64(masks.span[:], splitMix(state))
+	return masks
+}
+
+func splitMix(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+func (m idMasks) rotate(span *model.Span) {
+	span.TraceID = m.rotateTraceID(span.TraceID)
+	span.SpanID = m.rotateSpanID(span.SpanID)
+	span.ParentSpanID = m.rotateSpanID(span.ParentSpanID)
+	if len(span.Links) == 0 {
+		return
+	}
+	links := make([]model.Link, len(span.Links))
+	for i, link := range span.Links {
+		links[i] = model.Link{
+			SpanContext: link.SpanContext.
+				WithTraceID(m.rotateTraceID(link.SpanContext.TraceID())).
+				WithSpanID(m.rotateSpanID(link.SpanContext.SpanID())),
+			Attributes: link.Attributes,
+		}
+	}
+	span.Links = links
+}
+
+func (m idMasks) rotateTraceID(id oteltrace.TraceID) oteltrace.TraceID {
+	if !id.IsValid() {
+		return id
+	}
+	for i := range id {
+		id[i] ^= m.trace[i]
+	}
+	return id
+}
+
+// rotateSpanID leaves the zero ID alone, so root spans stay roots.
+func (m idMasks) rotateSpanID(id oteltrace.SpanID) oteltrace.SpanID {
+	if !id.IsValid() {
+		return id
+	}
+	for i := range id {
+		id[i] ^= m.span[i]
+	}
+	return id
+}
+
+func earliestStart(spans []model.Span) time.Time {
+	var earliest time.Time
+	for _, span := range spans {
+		if earliest.IsZero() || span.StartTime.Before(earliest) {
+			earliest = span.StartTime
+		}
+	}
+	return earliest
+}
+
+func shiftToNow(spans []model.Span, now time.Time) {
+	shiftBy(spans, now.Sub(earliestStart(spans)))
+}
+
+func shiftBy(spans []model.Span, offset time.Duration) {
+	for i := range spans {
+		span := &spans[i]
+		span.StartTime = span.StartTime.Add(offset)
+		span.EndTime = span.EndTime.Add(offset)
+		if len(span.Events) > 0 {
+			events := make([]model.Event, len(span.Events))
+			copy(events, span.Events)
+			for j := range events {
+				events[j].Time = events[j].Time.Add(offset)
+			}
+			span.Events = events
+		}
+	}
+}
@@ -0,0 +1,341 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/model"
+	"github.com/javiermolinar/tercios/internal/otlp"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+func writeRecording(t *testing.T, name string, batches ...model.Batch) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create recording: %v", err)
+	}
+	defer func() { _ = file.Close() }()
+	exporter, err := otlp.NewFileExporterFactory(otlp.FileFormatForPath(path), file).NewBatchExporter(context.Background())
+	if err != nil {
+		t.Fatalf("NewBatchExporter() error = %v", err)
+	}
+	for _, batch := range batches {
+		if err := exporter.ExportBatch(context.Background(), batch); err != nil {
+			t.Fatalf("ExportBatch() error = %v", err)
+		}
+	}
+	return path
+}
+
+func recordedTrace(start time.Time) model.Batch {
+	traceID := oteltrace.TraceID{0x01}
+	return model.Batch{
+		{TraceID: traceID, SpanID: oteltrace.SpanID{0x0a}, Name: "root", StartTime: start, EndTime: start.Add(50 * time.Millisecond)},
+		{TraceID: traceID, SpanID: oteltrace.SpanID{0x0b}, ParentSpanID: oteltrace.SpanID{0x0a}, Name: "child", StartTime: start.Add(10 * time.Millisecond), EndTime: start.Add(20 * time.Millisecond)},
+	}
+}
+
+func TestGeneratorReplaysEveryBatchOnce(t *testing.T) {
+	start := time.Date(2026, time.January, 27, 12, 0, 0, 0, time.UTC)
+	jsonPath := writeRecording(t, "a.json", recordedTrace(start))
+	protoPath := writeRecording(t, "b.pb", recordedTrace(start), recordedTrace(start))
+
+	generator, err := NewGenerator(Config{Files: []string{jsonPath, protoPath}})
+	if err != nil {
+		t.Fatalf("NewGenerator() error = %v", err)
+	}
+	if generator.Batches() != 3 {
+		t.Fatalf("expected 3 batches, got %d", generator.Batches())
+	}
+	for range 3 {
+		batch, err := generator.GenerateBatch(context.Background())
+		if err != nil {
+			t.Fatalf("GenerateBatch() error = %v", err)
+		}
+		if len(batch) != 2 || batch[0].TraceID != (oteltrace.TraceID{0x01}) || !batch[0].StartTime.Equal(start) {
+			t.Fatalf("recorded batch not replayed as is: %+v", batch)
+		}
+	}
+	if _, err := generator.GenerateBatch(context.Background()); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected io.EOF once the recording is exhausted, got %v", err)
+	}
+}
+
+func TestGeneratorNewIDsKeepsParentReferences(t *testing.T) {
+	start := time.Date(2026, time.January, 27, 12, 0, 0, 0, time.UTC)
+	path := writeRecording(t, "trace.json", recordedTrace(start), recordedTrace(start))
+	generator, err := NewGenerator(Config{Files: []string{path}, NewIDs: true})
+	if err != nil {
+		t.Fatalf("NewGenerator() error = %v", err)
+	}
+
+	first, _ := generator.GenerateBatch(context.Background())
+	second, _ := generator.GenerateBatch(context.Background())
+	root, child := first[0], first[1]
+	if root.TraceID == (oteltrace.TraceID{0x01}) || root.SpanID == (oteltrace.SpanID{0x0a}) {
+		t.Fatalf("expected new IDs, got %s/%s", root.TraceID, root.SpanID)
+	}
+	if child.TraceID != root.TraceID || child.ParentSpanID != root.SpanID {
+		t.Fatalf("child no longer points at root: trace %s parent %s, root %s/%s", child.TraceID, child.ParentSpanID, root.TraceID, root.SpanID)
+	}
+	if root.ParentSpanID.IsValid() {
+		t.Fatalf("root span gained a parent %s", root.ParentSpanID)
+	}
+	if second[0].TraceID != root.TraceID {
+		t.Fatalf("the same recorded trace should map to the same new trace ID")
+	}
+}
+
+func TestGeneratorRetimestampShiftsToNow(t *testing.T) {
+	start := time.Date(2020, time.March, 1, 8, 0, 0, 0, time.UTC)
+	path := writeRecording(t, "trace.json", recordedTrace(start))
+	generator, err := NewGenerator(Config{Files: []string{path}, Retimestamp: true})
+	if err != nil {
+		t.Fatalf("NewGenerator() error = %v", err)
+	}
+	now := time.Date(2026, time.January, 27, 12, 0, 0, 0, time.UTC)
+	generator.now = func() time.Time { return now }
+
+	batch, err := generator.GenerateBatch(context.Background())
+	if err != nil {
+		t.Fatalf("GenerateBatch() error = %v", err)
+	}
+	if !batch[0].StartTime.Equal(now) || batch[0].EndTime.Sub(batch[0].StartTime) != 50*time.Millisecond {
+		t.Fatalf("root not shifted to now: %s - %s", batch[0].StartTime, batch[0].EndTime)
+	}
+	if batch[1].StartTime.Sub(batch[0].StartTime) != 10*time.Millisecond {
+		t.Fatalf("offset between spans not kept: %s", batch[1].StartTime.Sub(batch[0].StartTime))
+	}
+}
+
+func TestNewGeneratorRequiresSpans(t *testing.T) {
+	if _, err := NewGenerator(Config{}); err == nil {
+		t.Fatalf("expected error without files")
+	}
+	path := filepath.Join(t.TempDir(), "empty.json")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := NewGenerator(Config{Files: []string{path}}); err == nil {
+		t.Fatalf("expected error for a recording without spans")
+	}
+}
+
+func TestParseSpeed(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    float64
+		wantErr bool
+	}{
+		{value: "2x", want: 2},
+		{value: "0.5X", want: 0.5},
+		{value: "1.5", want: 1.5},
+		{value: "max", want: 0},
+		{value: "0x", wantErr: true},
+		{value: "-2", wantErr: true},
+		{value: "fast", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSpeed(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("ParseSpeed(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Fatalf("ParseSpeed(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestGeneratorSpeedScalesRecordedGaps(t *testing.T) {
+	start := time.Date(2026, time.January, 27, 12, 0, 0, 0, time.UTC)
+	path := writeRecording(t, "trace.json", recordedTrace(start), recordedTrace(start.Add(200*time.Millisecond)))
+	generator, err := NewGenerator(Config{Files: []string{path}, Speed: 4})
+	if err != nil {
+		t.Fatalf("NewGenerator() error = %v", err)
+	}
+
+	began := time.Now()
+	if _, err := generator.GenerateBatch(context.Background()); err != nil {
+		t.Fatalf("GenerateBatch() error = %v", err)
+	}
+	if _, err := generator.GenerateBatch(context.Background()); err != nil {
+		t.Fatalf("GenerateBatch() error = %v", err)
+	}
+	// 200ms recorded gap at 4x is 50ms.
+	if elapsed := time.Since(began); elapsed < 45*time.Millisecond || elapsed > 500*time.Millisecond {
+		t.Fatalf("expected the second batch about 50ms after the first, got %s", elapsed)
+	}
+}
+
+func TestGeneratorSpeedWaitHonorsCancellation(t *testing.T) {
+	start := time.Date(2026, time.January, 27, 12, 0, 0, 0, time.UTC)
+	path := writeRecording(t, "trace.json", recordedTrace(start), recordedTrace(start.Add(time.Hour)))
+	generator, err := NewGenerator(Config{Files: []string{path}, Speed: 1})
+	if err != nil {
+		t.Fatalf("NewGenerator() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, err := generator.GenerateBatch(ctx); err != nil {
+		t.Fatalf("GenerateBatch() error = %v", err)
+	}
+	cancel()
+	if _, err := generator.GenerateBatch(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled while waiting, got %v", err)
+	}
+}
+
+func TestGeneratorLoopRotatesIDsAndRebasesEachPass(t *testing.T) {
+	start := time.Date(2026, time.January, 27, 12, 0, 0, 0, time.UTC)
+	path := writeRecording(t, "trace.json", recordedTrace(start), recordedTrace(start.Add(time.Second)))
+	generator, err := NewGenerator(Config{Files: []string{path}, Loop: true})
+	if err != nil {
+		t.Fatalf("NewGenerator() error = %v", err)
+	}
+
+	traces := map[oteltrace.TraceID]struct{}{}
+	// The recording runs from start to the second trace's root end.
+	period := time.Second + 50*time.Millisecond
+	for pass := range 3 {
+		for i := range 2 {
+			batch, err := generator.GenerateBatch(context.Background())
+			if err != nil {
+				t.Fatalf("GenerateBatch() pass %d error = %v", pass, err)
+			}
+			root, child := batch[0], batch[1]
+			wantStart := start.Add(time.Duration(i)*time.Second + time.Duration(pass)*period)
+			if !root.StartTime.Equal(wantStart) {
+				t.Fatalf("pass %d batch %d starts at %s, want %s", pass, i, root.StartTime, wantStart)
+			}
+			if child.TraceID != root.TraceID || child.ParentSpanID != root.SpanID || root.ParentSpanID.IsValid() {
+				t.Fatalf("pass %d lost parent references: %+v", pass, batch)
+			}
+			if pass == 0 && root.TraceID != (oteltrace.TraceID{0x01}) {
+				t.Fatalf("first pass should keep recorded IDs without NewIDs, got %s", root.TraceID)
+			}
+			traces[root.TraceID] = struct{}{}
+		}
+	}
+	if len(traces) != 3 {
+		t.Fatalf("expected one trace ID per pass, got %d", len(traces))
+	}
+}
+
+func TestGeneratorSeedMakesNewIDsReproducible(t *testing.T) {
+	start := time.Date(2026, time.January, 27, 12, 0, 0, 0, time.UTC)
+	path := writeRecording(t, "trace.json", recordedTrace(start))
+	traceID := func() oteltrace.TraceID {
+		generator, err := NewGenerator(Config{Files: []string{path}, NewIDs: true, Seed: 7})
+		if err != nil {
+			t.Fatalf("NewGenerator() error = %v", err)
+		}
+		batch, err := generator.GenerateBatch(context.Background())
+		if err != nil {
+			t.Fatalf("GenerateBatch() error = %v", err)
+		}
+		return batch[0].TraceID
+	}
+	if first, second := traceID(), traceID(); first != second || first == (oteltrace.TraceID{0x01}) {
+		t.Fatalf("expected the same rotated trace ID from the same seed, got %s and %s", first, second)
+	}
+}
+
+func TestGeneratorReadsJaegerExport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jaeger.json")
+	export := `{"data": [{"traceID": "0a", "spans": [
+		{"traceID": "0a", "spanID": "01", "operationName": "root", "startTime": 1700000000000000, "duration": 1000, "processID": "p1"},
+		{"traceID": "0a", "spanID": "02", "operationName": "child", "references": [{"refType": "CHILD_OF", "traceID": "0a", "spanID": "01"}], "startTime": 1700000000000100, "duration": 100, "processID": "p1"}
+	], "processes": {"p1": {"serviceName": "frontend"}}}]}`
+	if err := os.WriteFile(path, []byte(export), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	generator, err := NewGenerator(Config{Files: []string{path}})
+	if err != nil {
+		t.Fatalf("NewGenerator() error = %v", err)
+	}
+	batch, err := generator.GenerateBatch(context.Background())
+	if err != nil {
+		t.Fatalf("GenerateBatch() error = %v", err)
+	}
+	if len(batch) != 2 || batch[1].ParentSpanID != batch[0].SpanID || batch[0].ResourceAttributes["service.name"].AsString() != "frontend" {
+		t.Fatalf("Jaeger export not replayed as one trace: %+v", batch)
+	}
+}
+
+func TestGeneratorStreamsFilesInOrder(t *testing.T) {
+	start := time.Date(2026, time.January, 27, 12, 0, 0, 0, time.UTC)
+	jsonPath := writeRecording(t, "a.json", recordedTrace(start))
+	protoPath := writeRecording(t, "b.pb", recordedTrace(start.Add(time.Second)), recordedTrace(start.Add(2*time.Second)))
+
+	generator, err := NewGenerator(Config{Files: []string{jsonPath, protoPath}, Stream: true})
+	if err != nil {
+		t.Fatalf("NewGenerator() error = %v", err)
+	}
+	if generator.Batches() != 0 {
+		t.Fatalf("streamed batches should not be loaded, got %d", generator.Batches())
+	}
+	for i := range 3 {
+		batch, err := generator.GenerateBatch(context.Background())
+		if err != nil {
+			t.Fatalf("GenerateBatch() error = %v", err)
+		}
+		if want := start.Add(time.Duration(i) * time.Second); !batch[0].StartTime.Equal(want) {
+			t.Fatalf("batch %d starts at %s, want %s", i, batch[0].StartTime, want)
+		}
+	}
+	if _, err := generator.GenerateBatch(context.Background()); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected io.EOF after the last streamed batch, got %v", err)
+	}
+}
+
+func TestGeneratorStreamLoopsWithNewIDs(t *testing.T) {
+	start := time.Date(2026, time.January, 27, 12, 0, 0, 0, time.UTC)
+	path := writeRecording(t, "trace.pb", recordedTrace(start), recordedTrace(start.Add(time.Second)))
+	generator, err := NewGenerator(Config{Files: []string{path}, Stream: true, Loop: true})
+	if err != nil {
+		t.Fatalf("NewGenerator() error = %v", err)
+	}
+	period := time.Second + 50*time.Millisecond
+	traces := map[oteltrace.TraceID]struct{}{}
+	for pass := range 3 {
+		for i := range 2 {
+			batch, err := generator.GenerateBatch(context.Background())
+			if err != nil {
+				t.Fatalf("GenerateBatch() pass %d error = %v", pass, err)
+			}
+			wantStart := start.Add(time.Duration(i)*time.Second + time.Duration(pass)*period)
+			if !batch[0].StartTime.Equal(wantStart) {
+				t.Fatalf("pass %d batch %d starts at %s, want %s", pass, i, batch[0].StartTime, wantStart)
+			}
+			traces[batch[0].TraceID] = struct{}{}
+		}
+	}
+	if len(traces) != 3 {
+		t.Fatalf("expected one trace ID per pass, got %d", len(traces))
+	}
+}
+
+func TestGeneratorStreamRejectsJaegerAndEmptyFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jaeger.json")
+	if err := os.WriteFile(path, []byte(`{"data": [{"traceID": "0a", "spans": []}]}`), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := NewGenerator(Config{Files: []string{path}, Stream: true}); err == nil {
+		t.Fatal("expected an error streaming a Jaeger export")
+	}
+
+	empty := writeRecording(t, "empty.pb")
+	generator, err := NewGenerator(Config{Files: []string{empty}, Stream: true, Loop: true})
+	if err != nil {
+		t.Fatalf("NewGenerator() error = %v", err)
+	}
+	if _, err := generator.GenerateBatch(context.Background()); err == nil || errors.Is(err, io.EOF) {
+		t.Fatalf("expected a no-spans error, got %v", err)
+	}
+}
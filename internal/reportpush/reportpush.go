@@ -0,0 +1,121 @@
+// Package reportpush posts run summaries as JSON to an HTTP endpoint for
+// --report-url, so the results of ephemeral CI or Kubernetes jobs can be
+// collected centrally instead of scraped from their logs.
+package reportpush
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/metrics"
+)
+
+// Kinds of pushed report.
+const (
+	// KindInterval is the summary of the run so far, pushed while it runs.
+	KindInterval = "interval"
+	// KindFinal is the summary of the finished run.
+	KindFinal = "final"
+)
+
+// Payload is the JSON body of a push: the --report-file report with the
+// kind of report and the time it was taken.
+type Payload struct {
+	Kind string    `json:"kind"`
+	At   time.Time `json:"at"`
+	metrics.Report
+}
+
+// Pusher posts reports to URL with Headers, which typically carry the
+// endpoint's credentials. Interval reports are queued and sent by Run, so
+// a slow endpoint never holds up the run; the final report is sent
+// directly with Push.
+type Pusher struct {
+	URL     string
+	Headers map[string]string
+	Client  *http.Client
+	queue   chan Payload
+}
+
+func New(url string, headers map[string]string) *Pusher {
+	return &Pusher{
+		URL:     url,
+		Headers: headers,
+		Client:  &http.Client{Timeout: 10 * time.Second},
+		queue:   make(chan Payload, 1),
+	}
+}
+
+// Interval queues the summary of the run so far for Run. While an earlier
+// interval report is still waiting, summary replaces it: every report
+// covers the run from its start, so only the latest one matters.
+func (p *Pusher) Interval(summary metrics.Summary, at time.Time) {
+	payload := Payload{Kind: KindInterval, At: at, Report: metrics.NewReport(summary)}
+	for {
+		select {
+		case p.queue <- payload:
+			return
+		default:
+		}
+		select {
+		case <-p.queue:
+		default:
+		}
+	}
+}
+
+// Run posts queued interval reports until ctx is done, passing delivery
+// errors to onError. A post under way when ctx is done still completes,
+// within the client timeout, so once Run has returned no interval report
+// can reach the endpoint after the final one.
+func (p *Pusher) Run(ctx context.Context, onError func(error)) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case payload := <-p.queue:
+			if err := p.post(context.WithoutCancel(ctx), payload); err != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// Push sends the final report of summary and checks for a 2xx response.
+func (p *Pusher) Push(ctx context.Context, summary metrics.Summary, at time.Time) error {
+	return p.post(ctx, Payload{Kind: KindFinal, At: at, Report: metrics.NewReport(summary)})
+}
+
+func (p *Pusher) post(ctx context.Context, payload Payload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for key, value := range p.Headers {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("push %s report: %w", payload.Kind, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("push %s report to %s: unexpected status %s", payload.Kind, p.URL, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package reportpush
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/metrics"
+)
+
+func TestPushPostsFinalReportWithHeaders(t *testing.T) {
+	var got map[string]any
+	var auth, contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		contentType = r.Header.Get("Content-Type")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	pusher := New(server.URL, map[string]string{"Authorization": "Bearer secret"})
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := pusher.Push(context.Background(), metrics.Summary{Total: 10, Successes: 9, Failures: 1, Seed: 42}, at); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	if auth != "Bearer secret" || contentType != "application/json" {
+		t.Fatalf("expected the auth header and a JSON content type, got %q and %q", auth, contentType)
+	}
+	if got["kind"] != KindFinal || got["at"] != "2026-01-02T03:04:05Z" {
+		t.Fatalf("expected a final report stamped with its time, got %v", got)
+	}
+	if got["total_requests"] != float64(10) || got["failed_requests"] != float64(1) || got["seed"] != float64(42) {
+		t.Fatalf("expected the report fields at the top level, got %v", got)
+	}
+}
+
+func TestPushFailsOnErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	err := New(server.URL, nil).Push(context.Background(), metrics.Summary{}, time.Now())
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("expected an unexpected status error, got %v", err)
+	}
+}
+
+func TestIntervalKeepsOnlyTheLatestPendingReport(t *testing.T) {
+	received := make(chan Payload, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload Payload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		received <- payload
+	}))
+	defer server.Close()
+
+	pusher := New(server.URL, nil)
+	for total := 1; total <= 3; total++ {
+		pusher.Interval(metrics.Summary{Total: total}, time.Now())
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go pusher.Run(ctx, func(err error) { t.Errorf("push: %v", err) })
+
+	select {
+	case payload := <-received:
+		if payload.Kind != KindInterval || payload.TotalRequests != 3 {
+			t.Fatalf("expected the latest interval report, got %+v", payload)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("interval report not pushed")
+	}
+	select {
+	case payload := <-received:
+		t.Fatalf("expected superseded reports to be dropped, got %+v", payload)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestRunFinishesThePostUnderWayBeforeReturning(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+	}))
+	defer server.Close()
+
+	pusher := New(server.URL, nil)
+	pusher.Interval(metrics.Summary{Total: 1}, time.Now())
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pusher.Run(ctx, func(err error) { t.Errorf("push: %v", err) })
+	}()
+
+	<-entered
+	cancel()
+	select {
+	case <-done:
+		t.Fatal("Run returned while an interval report was still being posted")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after its last post")
+	}
+}
@@ -0,0 +1,79 @@
+package scenario
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/javiermolinar/tercios/internal/validation"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// CodeConfig describes the code executed by an internal edge. The first
+// function annotates the edge's span; each further function becomes a
+// nested internal span, so the edge renders as a call chain.
+type CodeConfig struct {
+	Namespace string   `json:"namespace"`
+	Functions []string `json:"functions"`
+	Filepath  string   `json:"filepath,omitempty"`
+	// Threads is the size of the thread pool a call runs on. Each call
+	// picks one thread; every frame of the call shares it.
+	Threads      int    `json:"threads,omitempty"`
+	ThreadPrefix string `json:"thread_prefix,omitempty"`
+}
+
+const defaultThreadPrefix = "worker"
+
+func (c CodeConfig) validate(errs *validation.Errors, path string) {
+	if strings.TrimSpace(c.Namespace) == "" {
+		errs.Addf(path, "namespace is required")
+	}
+	if len(c.Functions) == 0 {
+		errs.Addf(path, "at least one function is required")
+	}
+	for i, function := range c.Functions {
+		if strings.TrimSpace(function) == "" {
+			errs.Addf(validation.Index(validation.Field(path, "functions"), i), "function cannot be empty")
+		}
+	}
+	if c.Threads < 0 {
+		errs.Addf(path, "threads must be >= 0")
+	}
+}
+
+type codeProfile struct {
+	config CodeConfig
+}
+
+func newCodeProfile(cfg *CodeConfig) *codeProfile {
+	if cfg == nil {
+		return nil
+	}
+	profile := &codeProfile{config: *cfg}
+	if profile.config.ThreadPrefix == "" {
+		profile.config.ThreadPrefix = defaultThreadPrefix
+	}
+	return profile
+}
+
+// frames returns the number of spans one call emits.
+func (p *codeProfile) frames() int {
+	return len(p.config.Functions)
+}
+
+// frameAttributes returns base plus the code.* and thread.* attributes of
+// frame depth. The thread is keyed on callID so all frames of a call agree.
+func (p *codeProfile) frameAttributes(base map[string]attribute.Value, callID oteltrace.SpanID, depth int) map[string]attribute.Value {
+	attrs := cloneAttributeValues(base)
+	attrs["code.namespace"] = attribute.StringValue(p.config.Namespace)
+	attrs["code.function"] = attribute.StringValue(p.config.Functions[depth])
+	if p.config.Filepath != "" {
+		attrs["code.filepath"] = attribute.StringValue(p.config.Filepath)
+	}
+	if p.config.Threads > 0 {
+		thread := int64(callRandom(callID, 6)%uint64(p.config.Threads)) + 1
+		attrs["thread.id"] = attribute.Int64Value(thread)
+		attrs["thread.name"] = attribute.StringValue(fmt.Sprintf("%s-%d", p.config.ThreadPrefix, thread))
+	}
+	return attrs
+}
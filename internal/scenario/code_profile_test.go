@@ -0,0 +1,111 @@
+package scenario
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/javiermolinar/tercios/internal/model"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+const codeProfileScenario = `{
+  "name": "code-profile",
+  "seed": 5,
+  "services": {
+    "api": { "resource": { "service.name": { "type": "string", "value": "api" } } },
+    "db": { "resource": { "service.name": { "type": "string", "value": "postgres" } } }
+  },
+  "nodes": {
+    "a": { "service": "api", "span_name": "POST /orders" },
+    "b": { "service": "api", "span_name": "place order" },
+    "c": { "service": "db", "span_name": "INSERT orders" }
+  },
+  "root": "a",
+  "edges": [
+    {
+      "from": "a", "to": "b", "kind": "internal", "repeat": 1, "duration_ms": 30,
+      "span_attributes": { "order.kind": { "type": "string", "value": "standard" } },
+      "code": {
+        "namespace": "com.acme.orders.OrderService",
+        "functions": ["placeOrder", "validateOrder", "reserveStock"],
+        "filepath": "OrderService.java",
+        "threads": 4
+      }
+    },
+    { "from": "b", "to": "c", "kind": "client_database", "repeat": 1, "duration_ms": 10 }
+  ]
+}`
+
+func TestGeneratorEmitsCodeFrames(t *testing.T) {
+	cfg, err := DecodeJSON(strings.NewReader(codeProfileScenario))
+	if err != nil {
+		t.Fatalf("DecodeJSON() error = %v", err)
+	}
+	definition, err := cfg.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	spans, err := NewGenerator(definition).GenerateBatch(context.Background())
+	if err != nil {
+		t.Fatalf("GenerateBatch() error = %v", err)
+	}
+
+	byName := map[string]model.Span{}
+	for _, span := range spans {
+		byName[span.Name] = span
+	}
+	outer, ok := byName["place order"]
+	if !ok {
+		t.Fatalf("expected outer frame named after the node")
+	}
+	validate, reserve := byName["validateOrder"], byName["reserveStock"]
+	if validate.ParentSpanID != outer.SpanID || reserve.ParentSpanID != validate.SpanID {
+		t.Fatalf("expected nested call chain place order > validateOrder > reserveStock")
+	}
+	if got := outer.Attributes["code.function"].AsString(); got != "placeOrder" {
+		t.Fatalf("expected outer code.function=placeOrder, got %q", got)
+	}
+	if _, ok := outer.Attributes["order.kind"]; !ok {
+		t.Fatalf("expected outer frame to keep edge span attributes")
+	}
+	if _, ok := reserve.Attributes["order.kind"]; ok {
+		t.Fatalf("expected nested frames to carry only code attributes")
+	}
+	for _, frame := range []model.Span{outer, validate, reserve} {
+		if got := frame.Attributes["code.namespace"].AsString(); got != "com.acme.orders.OrderService" {
+			t.Fatalf("%s: unexpected code.namespace %q", frame.Name, got)
+		}
+		if frame.Attributes["thread.id"] != outer.Attributes["thread.id"] {
+			t.Fatalf("%s: expected every frame on the same thread", frame.Name)
+		}
+		if id := frame.Attributes["thread.id"].AsInt64(); id < 1 || id > 4 {
+			t.Fatalf("%s: thread.id %d outside pool", frame.Name, id)
+		}
+	}
+
+	for _, span := range spans {
+		if span.Kind == oteltrace.SpanKindClient && span.ParentSpanID != reserve.SpanID {
+			t.Fatalf("expected downstream call to attach to the innermost frame")
+		}
+	}
+}
+
+func TestDecodeJSONRejectsInvalidCodeProfile(t *testing.T) {
+	original := `"functions": ["placeOrder", "validateOrder", "reserveStock"]`
+	tests := map[string]string{
+		`"functions": []`:                 "at least one function",
+		`"functions": ["placeOrder", ""]`: "cannot be empty",
+	}
+	for field, want := range tests {
+		input := strings.Replace(codeProfileScenario, original, field, 1)
+		if _, err := DecodeJSON(strings.NewReader(input)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("%s: expected error containing %q, got %v", field, want, err)
+		}
+	}
+
+	clientServer := strings.Replace(codeProfileScenario, `"kind": "internal"`, `"kind": "client_server"`, 1)
+	if _, err := DecodeJSON(strings.NewReader(clientServer)); err == nil || !strings.Contains(err.Error(), "only supported on internal edges") {
+		t.Fatalf("expected internal-only error, got %v", err)
+	}
+}
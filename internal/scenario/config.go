@@ -1,14 +1,22 @@
 package scenario
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
-	"os"
+	"maps"
+	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 
+	"github.com/javiermolinar/tercios/internal/fileformat"
+	"github.com/javiermolinar/tercios/internal/httpstatus"
+	"github.com/javiermolinar/tercios/internal/jsonschema"
+	"github.com/javiermolinar/tercios/internal/scenario/library"
 	"github.com/javiermolinar/tercios/internal/typedvalue"
+	"github.com/javiermolinar/tercios/internal/validation"
 )
 
 type EdgeKind string
@@ -38,21 +46,42 @@ type TypedValue = typedvalue.TypedValue
 
 type ServiceConfig struct {
 	Resource map[string]TypedValue `json:"resource"`
+	// HTTPStatusCodes is the default status code distribution for
+	// client_server edges that call this service.
+	HTTPStatusCodes []StatusCodeWeight `json:"http_status_codes,omitempty"`
+	// Semconv overrides the conventional attributes generated for edges
+	// that call this service, e.g. db.system for a database or
+	// server.port for an API. Requires the scenario's Semconv.
+	Semconv map[string]TypedValue `json:"semconv,omitempty"`
+	// ResourcePreset adds generated k8s or host resource attributes for
+	// a number of instances of the service; see ResourcePresetConfig.
+	ResourcePreset *ResourcePresetConfig `json:"resource_preset,omitempty"`
 }
 
 type NodeConfig struct {
 	Service  string `json:"service"`
 	SpanName string `json:"span_name"`
+	// Events are added to every span of the node itself: the root span,
+	// the span of an internal edge to it, or the server, consumer or
+	// database side of a pair edge to it.
+	Events []EventConfig `json:"events,omitempty"`
 }
 
 type EventConfig struct {
 	Name       string                `json:"name"`
 	Attributes map[string]TypedValue `json:"attributes,omitempty"`
+	// OffsetMs places the event this long after the span starts, or at
+	// its end for shorter spans. Unset places it mid-span.
+	OffsetMs *int64 `json:"offset_ms,omitempty"`
 }
 
 type LinkConfig struct {
 	Node       string                `json:"node"`
 	Attributes map[string]TypedValue `json:"attributes,omitempty"`
+	// PreviousTraces, when set, links to the span of Node in each of the
+	// last PreviousTraces traces of the scenario instead of the current
+	// one, like a batch consumer linking the requests it processes.
+	PreviousTraces int `json:"previous_traces,omitempty"`
 }
 
 type EdgeConfig struct {
@@ -61,6 +90,9 @@ type EdgeConfig struct {
 	Kind EdgeKind `json:"kind"`
 	// Repeat: how many times this edge fires sequentially.
 	Repeat int `json:"repeat"`
+	// RepeatDistribution replaces Repeat with a count drawn per source
+	// span; see RepeatDistributionConfig.
+	RepeatDistribution *RepeatDistributionConfig `json:"repeat_distribution,omitempty"`
 	// DurationMs: the edge's own work time. Full span duration is
 	// DurationMs + subtreeDuration[To] so the span contains its subtree.
 	DurationMs int64 `json:"duration_ms"`
@@ -72,163 +104,436 @@ type EdgeConfig struct {
 	SpanAttributes   map[string]TypedValue `json:"span_attributes,omitempty"`
 	SpanEvents       []EventConfig         `json:"span_events,omitempty"`
 	SpanLinks        []LinkConfig          `json:"span_links,omitempty"`
+	// Chaos is compiled into chaos policies; see EdgeChaosConfig.
+	Chaos *EdgeChaosConfig `json:"chaos,omitempty"`
+	// HTTPStatusCodes draws http.response.status_code for each call from
+	// a weighted distribution. Only valid on client_server edges; it
+	// overrides the target service's distribution.
+	HTTPStatusCodes []StatusCodeWeight `json:"http_status_codes,omitempty"`
+	// DBQuery fills db.statement and related attributes per call. Only
+	// valid on client_database edges.
+	DBQuery *DBQueryConfig `json:"db_query,omitempty"`
+	// Messaging fills messaging.* attributes on both sides of each
+	// message. Only valid on producer_consumer edges.
+	Messaging *MessagingConfig `json:"messaging,omitempty"`
+	// Code adds code.* and thread.* attributes and nested frame spans.
+	// Only valid on internal edges.
+	Code *CodeConfig `json:"code,omitempty"`
+	// Probability, in (0, 1], is the chance the edge is followed from each
+	// span of its source node, e.g. 0.2 for a call made on cache misses
+	// only. Unset always follows it.
+	Probability float64 `json:"probability,omitempty"`
+	// Weight makes the edge one of a set of alternatives: of the edges
+	// from the same source node with a weight, exactly one is followed per
+	// source span, picked in proportion to the weights.
+	Weight float64 `json:"weight,omitempty"`
+	// ErrorRate, in [0, 1], is the fraction of calls whose spans are
+	// generated with error status. On client_server edges the failed calls
+	// also carry ErrorStatus (default 500) as http.response.status_code.
+	ErrorRate   float64 `json:"error_rate,omitempty"`
+	ErrorStatus int     `json:"error_status,omitempty"`
+	// Parallel starts the edge together with the edge declared before it
+	// from the same node, like concurrent RPCs, instead of after it.
+	Parallel bool `json:"parallel,omitempty"`
+	// Async makes the call fire-and-forget: it starts StartOffsetMs after
+	// the point where the source span's first call would, regardless of
+	// its siblings, and the source span does not wait for it, so it may
+	// end after its parent.
+	Async         bool  `json:"async,omitempty"`
+	StartOffsetMs int64 `json:"start_offset_ms,omitempty"`
+}
+
+const defaultErrorStatus = 500
+
+// maxRepeat returns the most calls the edge makes per source span.
+func (e EdgeConfig) maxRepeat() int {
+	if e.RepeatDistribution != nil {
+		return e.RepeatDistribution.Max
+	}
+	return e.Repeat
+}
+
+// maxPreviousTraces bounds LinkConfig.PreviousTraces, and so the traces a
+// generator keeps for links.
+const maxPreviousTraces = 128
+
+// RootConfig is one entrypoint of a scenario with several. Each trace
+// starts at one of them, picked in proportion to the weights.
+type RootConfig struct {
+	Node   string  `json:"node"`
+	Weight float64 `json:"weight"`
 }
 
 type Config struct {
+	// Schema points editors at the JSON Schema of the file; see
+	// JSONSchema. It is otherwise ignored.
+	Schema string `json:"$schema,omitempty"`
+	// Includes lists scenario fragments merged into this scenario: files,
+	// or directories of .json, .yaml and .yml files, relative to this
+	// file. Fragments hold services, nodes, edges and further includes,
+	// so a large system can be split per team; see resolveIncludes.
+	Includes []string                 `json:"includes,omitempty"`
 	Name     string                   `json:"name"`
 	Seed     int64                    `json:"seed"`
 	Services map[string]ServiceConfig `json:"services"`
 	Nodes    map[string]NodeConfig    `json:"nodes"`
-	Root     string                   `json:"root"`
-	Edges    []EdgeConfig             `json:"edges"`
+	// Root is the entrypoint of every trace. Roots replaces it to mix
+	// several trace types, e.g. GET, POST and DELETE requests.
+	Root  string       `json:"root,omitempty"`
+	Roots []RootConfig `json:"roots,omitempty"`
+	Edges []EdgeConfig `json:"edges"`
+	// HTTPStatusMapping derives span status from edge HTTP status code
+	// attributes. Nil uses httpstatus.DefaultMapping.
+	HTTPStatusMapping *httpstatus.Mapping `json:"http_status_mapping,omitempty"`
+	// SpansPerSecond is this scenario's target span throughput. The rate
+	// selection strategy apportions batches between scenarios by it and
+	// paces the run so the targets add up to the total throughput.
+	SpansPerSecond float64 `json:"spans_per_second,omitempty"`
+	// Semconv fills the semantic convention attributes of each edge from
+	// its kind and target: http.* on client_server edges, db.* on
+	// client_database edges and messaging.* on producer_consumer edges.
+	// Services override them with Semconv and edges with span_attributes.
+	Semconv bool `json:"semconv,omitempty"`
+}
+
+func (EdgeKind) JSONSchema() *jsonschema.Schema {
+	return jsonschema.Enum(EdgeKindClientServer, EdgeKindProducerConsumer, EdgeKindInternal, EdgeKindClientDatabase)
 }
 
+// JSONSchema returns the JSON Schema of scenario files.
+func JSONSchema() *jsonschema.Schema {
+	return jsonschema.Generate(Config{}, "tercios scenario")
+}
+
+// LoadFromJSON reads a scenario config from path. Files ending in .yaml or
+// .yml are parsed as YAML and decoded with the same strict JSON rules, and
+// builtin:NAME loads the embedded scenario NAME of the library package.
 func LoadFromJSON(path string) (Config, error) {
-	file, err := os.Open(path)
+	if name, ok := library.Name(path); ok {
+		return loadBuiltin(name)
+	}
+	file, err := fileformat.Open(path)
 	if err != nil {
 		return Config{}, err
 	}
 	defer func() { _ = file.Close() }()
-	return DecodeJSON(file)
+	cfg, err := decodeConfig(file)
+	if err != nil {
+		return Config{}, err
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return Config{}, err
+	}
+	if err := cfg.resolveIncludes(filepath.Dir(path), map[string]bool{abs: true}); err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
 }
 
+// DecodeJSON decodes and validates a scenario. Includes are resolved
+// against the working directory; LoadFromJSON resolves them against the
+// directory of the file.
 func DecodeJSON(r io.Reader) (Config, error) {
+	cfg, err := decodeConfig(r)
+	if err != nil {
+		return Config{}, err
+	}
+	if err := cfg.resolveIncludes(".", map[string]bool{}); err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// loadBuiltin decodes and validates the embedded scenario name of the
+// library package.
+func loadBuiltin(name string) (Config, error) {
+	data, ok := library.Lookup(name)
+	if !ok {
+		return Config{}, fmt.Errorf("unknown builtin scenario %q (available: %s)", name, strings.Join(library.Names(), ", "))
+	}
+	cfg, err := decodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// decodeConfig strictly decodes one scenario document or fragment,
+// without validating it.
+func decodeConfig(r io.Reader) (Config, error) {
 	var cfg Config
-	decoder := json.NewDecoder(r)
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Config{}, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
 	decoder.DisallowUnknownFields()
 	if err := decoder.Decode(&cfg); err != nil {
-		return Config{}, err
+		return Config{}, JSONSchema().DecodeError(data, err)
 	}
 	if err := decoder.Decode(&struct{}{}); err != io.EOF {
 		return Config{}, fmt.Errorf("invalid JSON: %w", err)
 	}
-	if err := cfg.Validate(); err != nil {
-		return Config{}, err
-	}
 	return cfg, nil
 }
 
+// Validate reports every invalid field at once, each under the JSON path
+// of its service, node or edge. The DAG and timing checks need a
+// well-formed graph, so they only run when everything else is valid.
 func (c Config) Validate() error {
+	var errs validation.Errors
 	if strings.TrimSpace(c.Name) == "" {
-		return fmt.Errorf("name is required")
+		errs.Addf("", "name is required")
 	}
 	if len(c.Services) == 0 {
-		return fmt.Errorf("services are required")
+		errs.Addf("", "services are required")
 	}
 	if len(c.Nodes) == 0 {
-		return fmt.Errorf("nodes are required")
+		errs.Addf("", "nodes are required")
 	}
-	if strings.TrimSpace(c.Root) == "" {
-		return fmt.Errorf("root is required")
-	}
-	if _, ok := c.Nodes[c.Root]; !ok {
-		return fmt.Errorf("root node %q not found", c.Root)
+	switch {
+	case len(c.Roots) > 0:
+		if strings.TrimSpace(c.Root) != "" {
+			errs.Addf("", "root and roots are mutually exclusive")
+		}
+		seen := make(map[string]bool, len(c.Roots))
+		for i, root := range c.Roots {
+			path := validation.Index("roots", i)
+			if strings.TrimSpace(root.Node) == "" {
+				errs.Addf(path, "node is required")
+			} else if _, ok := c.Nodes[root.Node]; !ok {
+				errs.Addf(path, "root node %q not found", root.Node)
+			} else if seen[root.Node] {
+				errs.Addf(path, "duplicate root node %q", root.Node)
+			}
+			seen[root.Node] = true
+			if root.Weight <= 0 {
+				errs.Addf(path, "weight must be > 0")
+			}
+		}
+	case strings.TrimSpace(c.Root) == "":
+		errs.Addf("", "root is required")
+	default:
+		if _, ok := c.Nodes[c.Root]; !ok {
+			errs.Addf("", "root node %q not found", c.Root)
+		}
 	}
 	if len(c.Edges) == 0 {
-		return fmt.Errorf("edges are required")
+		errs.Addf("", "edges are required")
+	}
+	if c.SpansPerSecond < 0 {
+		errs.Addf("", "spans_per_second must be >= 0")
 	}
 
-	for serviceID, service := range c.Services {
+	for _, serviceID := range slices.Sorted(maps.Keys(c.Services)) {
+		service := c.Services[serviceID]
+		path := validation.Key("services", serviceID)
 		if strings.TrimSpace(serviceID) == "" {
-			return fmt.Errorf("service id cannot be empty")
+			errs.Addf(path, "service id cannot be empty")
 		}
-		for key, value := range service.Resource {
-			if err := value.Validate(fmt.Sprintf("service %s resource %q", serviceID, key)); err != nil {
-				return err
-			}
+		for _, key := range slices.Sorted(maps.Keys(service.Resource)) {
+			errs.Add("", service.Resource[key].Validate(validation.Key(validation.Field(path, "resource"), key)))
+		}
+		validateStatusCodes(&errs, validation.Field(path, "http_status_codes"), service.HTTPStatusCodes)
+		if len(service.Semconv) > 0 && !c.Semconv {
+			errs.Addf(path, "semconv requires semconv to be enabled on the scenario")
+		}
+		for _, key := range slices.Sorted(maps.Keys(service.Semconv)) {
+			errs.Add("", service.Semconv[key].Validate(validation.Key(validation.Field(path, "semconv"), key)))
+		}
+		if service.ResourcePreset != nil {
+			service.ResourcePreset.validate(&errs, validation.Field(path, "resource_preset"))
 		}
 	}
 
-	for nodeID, node := range c.Nodes {
+	for _, nodeID := range slices.Sorted(maps.Keys(c.Nodes)) {
+		node := c.Nodes[nodeID]
+		path := validation.Key("nodes", nodeID)
 		if strings.TrimSpace(nodeID) == "" {
-			return fmt.Errorf("node id cannot be empty")
+			errs.Addf(path, "node id cannot be empty")
 		}
 		if strings.TrimSpace(node.Service) == "" {
-			return fmt.Errorf("node %s: service is required", nodeID)
-		}
-		if _, ok := c.Services[node.Service]; !ok {
-			return fmt.Errorf("node %s: unknown service %q", nodeID, node.Service)
+			errs.Addf(path, "service is required")
+		} else if _, ok := c.Services[node.Service]; !ok {
+			errs.Addf(path, "unknown service %q", node.Service)
 		}
+		validateEvents(&errs, validation.Field(path, "events"), node.Events)
 	}
 
 	for i, edge := range c.Edges {
-		if strings.TrimSpace(edge.From) == "" {
-			return fmt.Errorf("edge %d: from is required", i)
-		}
-		if strings.TrimSpace(edge.To) == "" {
-			return fmt.Errorf("edge %d: to is required", i)
-		}
-		if _, ok := c.Nodes[edge.From]; !ok {
-			return fmt.Errorf("edge %d: unknown from node %q", i, edge.From)
-		}
-		if _, ok := c.Nodes[edge.To]; !ok {
-			return fmt.Errorf("edge %d: unknown to node %q", i, edge.To)
+		c.validateEdge(&errs, validation.Index("edges", i), edge)
+	}
+	if errs.Len() > 0 {
+		return errs.Err()
+	}
+
+	// Outgoing-edges index built once, reused by both validators.
+	outgoing := make(map[string][]EdgeConfig, len(c.Nodes))
+	for _, edge := range c.Edges {
+		outgoing[edge.From] = append(outgoing[edge.From], edge)
+	}
+	roots := c.rootNodes()
+	if err := validateDAG(c.Nodes, c.Edges, roots, outgoing); err != nil {
+		return err
+	}
+	// Timing checks require an acyclic, rooted DAG.
+	validateTimings(&errs, c.Edges, computeConfigSubtreeDurations(roots, outgoing))
+	return errs.Err()
+}
+
+func validateEvents(errs *validation.Errors, path string, events []EventConfig) {
+	for i, event := range events {
+		eventPath := validation.Index(path, i)
+		if strings.TrimSpace(event.Name) == "" {
+			errs.Addf(eventPath, "name is required")
 		}
-		if edge.Kind != EdgeKindClientServer && edge.Kind != EdgeKindProducerConsumer && edge.Kind != EdgeKindInternal && edge.Kind != EdgeKindClientDatabase {
-			return fmt.Errorf("edge %d: unsupported kind %q", i, edge.Kind)
+		if event.OffsetMs != nil && *event.OffsetMs < 0 {
+			errs.Addf(eventPath, "offset_ms must be >= 0")
 		}
-		if edge.Repeat <= 0 {
-			return fmt.Errorf("edge %d: repeat must be > 0", i)
+		for _, key := range slices.Sorted(maps.Keys(event.Attributes)) {
+			errs.Add("", event.Attributes[key].Validate(validation.Key(validation.Field(eventPath, "attributes"), key)))
 		}
-		if edge.DurationMs <= 0 {
-			return fmt.Errorf("edge %d: duration_ms must be > 0", i)
+	}
+}
+
+// rootNodes returns the entrypoints of the scenario: the Roots nodes, or
+// Root alone.
+func (c Config) rootNodes() []string {
+	if len(c.Roots) == 0 {
+		return []string{c.Root}
+	}
+	nodes := make([]string, 0, len(c.Roots))
+	for _, root := range c.Roots {
+		nodes = append(nodes, root.Node)
+	}
+	return nodes
+}
+
+func (c Config) validateEdge(errs *validation.Errors, path string, edge EdgeConfig) {
+	if strings.TrimSpace(edge.From) == "" {
+		errs.Addf(path, "from is required")
+	} else if _, ok := c.Nodes[edge.From]; !ok {
+		errs.Addf(path, "unknown from node %q", edge.From)
+	}
+	if strings.TrimSpace(edge.To) == "" {
+		errs.Addf(path, "to is required")
+	} else if _, ok := c.Nodes[edge.To]; !ok {
+		errs.Addf(path, "unknown to node %q", edge.To)
+	}
+	if edge.Kind != EdgeKindClientServer && edge.Kind != EdgeKindProducerConsumer && edge.Kind != EdgeKindInternal && edge.Kind != EdgeKindClientDatabase {
+		errs.Addf(path, "unsupported kind %q", edge.Kind)
+	}
+	switch {
+	case edge.RepeatDistribution != nil:
+		if edge.Repeat != 0 {
+			errs.Addf(path, "repeat and repeat_distribution are mutually exclusive")
 		}
-		if edge.NetworkLatencyMs < 0 {
-			return fmt.Errorf("edge %d: network_latency_ms must be >= 0", i)
+		edge.RepeatDistribution.validate(errs, validation.Field(path, "repeat_distribution"))
+	case edge.Repeat <= 0:
+		errs.Addf(path, "repeat must be > 0")
+	}
+	if edge.DurationMs <= 0 {
+		errs.Addf(path, "duration_ms must be > 0")
+	}
+	if edge.NetworkLatencyMs < 0 {
+		errs.Addf(path, "network_latency_ms must be >= 0")
+	}
+	if edge.NetworkLatencyMs > 0 && edge.Kind == EdgeKindInternal {
+		errs.Addf(path, "network_latency_ms is not supported on internal edges")
+	}
+	// 2*NetworkLatencyMs < DurationMs is checked in validateTimings.
+	if edge.Probability < 0 || edge.Probability > 1 {
+		errs.Addf(path, "probability must be in (0, 1]")
+	}
+	if edge.Weight < 0 {
+		errs.Addf(path, "weight must be >= 0")
+	}
+	if edge.Probability > 0 && edge.Weight > 0 {
+		errs.Addf(path, "probability and weight are mutually exclusive")
+	}
+	if edge.StartOffsetMs < 0 {
+		errs.Addf(path, "start_offset_ms must be >= 0")
+	}
+	if edge.StartOffsetMs > 0 && !edge.Async {
+		errs.Addf(path, "start_offset_ms requires async")
+	}
+	if edge.Async && (edge.Weight > 0 || edge.Parallel) {
+		errs.Addf(path, "async edges cannot have a weight or be parallel")
+	}
+	if edge.ErrorRate < 0 || edge.ErrorRate > 1 {
+		errs.Addf(path, "error_rate must be between 0 and 1")
+	}
+	if edge.ErrorStatus != 0 {
+		if edge.Kind != EdgeKindClientServer {
+			errs.Addf(path, "error_status is only supported on client_server edges")
 		}
-		if edge.NetworkLatencyMs > 0 && edge.Kind == EdgeKindInternal {
-			return fmt.Errorf("edge %d: network_latency_ms is not supported on internal edges", i)
+		if edge.ErrorStatus < 500 || edge.ErrorStatus > 599 {
+			errs.Addf(path, "error_status must be between 500 and 599")
 		}
-		// 2*NetworkLatencyMs < DurationMs is checked in validateTimings.
+	}
 
-		for key, value := range edge.SpanAttributes {
-			if err := value.Validate(fmt.Sprintf("edge %d span attribute %q", i, key)); err != nil {
-				return err
-			}
+	if len(edge.HTTPStatusCodes) > 0 && edge.Kind != EdgeKindClientServer {
+		errs.Addf(path, "http_status_codes is only supported on client_server edges")
+	}
+	validateStatusCodes(errs, validation.Field(path, "http_status_codes"), edge.HTTPStatusCodes)
+
+	if edge.DBQuery != nil {
+		if edge.Kind != EdgeKindClientDatabase {
+			errs.Addf(path, "db_query is only supported on client_database edges")
 		}
-		for j, event := range edge.SpanEvents {
-			if strings.TrimSpace(event.Name) == "" {
-				return fmt.Errorf("edge %d event %d: name is required", i, j)
-			}
-			for key, value := range event.Attributes {
-				if err := value.Validate(fmt.Sprintf("edge %d event %d attribute %q", i, j, key)); err != nil {
-					return err
-				}
-			}
+		edge.DBQuery.validate(errs, validation.Field(path, "db_query"))
+	}
+	if edge.Messaging != nil {
+		if edge.Kind != EdgeKindProducerConsumer {
+			errs.Addf(path, "messaging is only supported on producer_consumer edges")
 		}
-		for j, link := range edge.SpanLinks {
-			if strings.TrimSpace(link.Node) == "" {
-				return fmt.Errorf("edge %d link %d: node is required", i, j)
-			}
-			if _, ok := c.Nodes[link.Node]; !ok {
-				return fmt.Errorf("edge %d link %d: unknown node %q", i, j, link.Node)
-			}
-			for key, value := range link.Attributes {
-				if err := value.Validate(fmt.Sprintf("edge %d link %d attribute %q", i, j, key)); err != nil {
-					return err
-				}
-			}
+		edge.Messaging.validate(errs, validation.Field(path, "messaging"))
+	}
+	if edge.Code != nil {
+		if edge.Kind != EdgeKindInternal {
+			errs.Addf(path, "code is only supported on internal edges")
 		}
+		edge.Code.validate(errs, validation.Field(path, "code"))
 	}
-
-	// Outgoing-edges index built once, reused by both validators.
-	outgoing := make(map[string][]EdgeConfig, len(c.Nodes))
-	for _, edge := range c.Edges {
-		outgoing[edge.From] = append(outgoing[edge.From], edge)
+	if edge.Chaos != nil {
+		edge.Chaos.validate(errs, validation.Field(path, "chaos"))
 	}
-	if err := validateDAG(c.Nodes, c.Edges, c.Root, outgoing); err != nil {
-		return err
+
+	for _, key := range slices.Sorted(maps.Keys(edge.SpanAttributes)) {
+		errs.Add("", edge.SpanAttributes[key].Validate(validation.Key(validation.Field(path, "span_attributes"), key)))
 	}
-	// Timing checks require an acyclic, rooted DAG.
-	if err := validateTimings(c.Edges, computeConfigSubtreeDurations(c.Root, outgoing)); err != nil {
-		return err
+	validateEvents(errs, validation.Field(path, "span_events"), edge.SpanEvents)
+	for j, link := range edge.SpanLinks {
+		linkPath := validation.Index(validation.Field(path, "span_links"), j)
+		if strings.TrimSpace(link.Node) == "" {
+			errs.Addf(linkPath, "node is required")
+		} else if _, ok := c.Nodes[link.Node]; !ok {
+			errs.Addf(linkPath, "unknown node %q", link.Node)
+		}
+		if link.PreviousTraces < 0 {
+			errs.Addf(linkPath, "previous_traces must be >= 0")
+		} else if link.PreviousTraces > maxPreviousTraces {
+			errs.Addf(linkPath, "previous_traces must be <= %d", maxPreviousTraces)
+		}
+		for _, key := range slices.Sorted(maps.Keys(link.Attributes)) {
+			errs.Add("", link.Attributes[key].Validate(validation.Key(validation.Field(linkPath, "attributes"), key)))
+		}
 	}
-	return nil
 }
 
-// validateDAG enforces: (1) acyclic, (2) root has no incoming edges,
-// (3) every node is reachable from root.
-func validateDAG(nodes map[string]NodeConfig, edges []EdgeConfig, root string, outgoing map[string][]EdgeConfig) error {
+// validateDAG enforces: (1) acyclic, (2) roots have no incoming edges,
+// (3) every node is reachable from a root.
+func validateDAG(nodes map[string]NodeConfig, edges []EdgeConfig, roots []string, outgoing map[string][]EdgeConfig) error {
 	indegree := make(map[string]int, len(nodes))
 	for id := range nodes {
 		indegree[id] = 0
@@ -237,8 +542,10 @@ func validateDAG(nodes map[string]NodeConfig, edges []EdgeConfig, root string, o
 		indegree[edge.To]++
 	}
 
-	if indegree[root] > 0 {
-		return fmt.Errorf("root node %q must have no incoming edges, found %d", root, indegree[root])
+	for _, root := range roots {
+		if indegree[root] > 0 {
+			return fmt.Errorf("root node %q must have no incoming edges, found %d", root, indegree[root])
+		}
 	}
 
 	// Kahn's cycle detection: prune in topological order; remainder = cycle.
@@ -268,10 +575,13 @@ func validateDAG(nodes map[string]NodeConfig, edges []EdgeConfig, root string, o
 		return fmt.Errorf("scenario must be a DAG (cycle detected)")
 	}
 
-	// Reachability from root via BFS; anything not visited is dead config.
+	// Reachability from the roots via BFS; anything not visited is dead
+	// config.
 	reached := make(map[string]bool, len(nodes))
-	queue = append(queue[:0], root)
-	reached[root] = true
+	queue = append(queue[:0], roots...)
+	for _, root := range roots {
+		reached[root] = true
+	}
 	for len(queue) > 0 {
 		nodeID := queue[0]
 		queue = queue[1:]
@@ -290,17 +600,20 @@ func validateDAG(nodes map[string]NodeConfig, edges []EdgeConfig, root string, o
 			}
 		}
 		sort.Strings(orphans)
-		return fmt.Errorf("nodes not reachable from root %q: %v", root, orphans)
+		if len(roots) > 1 {
+			return fmt.Errorf("nodes not reachable from roots %q: %v", roots, orphans)
+		}
+		return fmt.Errorf("nodes not reachable from root %q: %v", roots[0], orphans)
 	}
 
 	return nil
 }
 
 // computeConfigSubtreeDurations returns, for every node reachable from
-// rootID, the total scenario-time (ms) consumed by its outgoing subtree.
+// roots, the total scenario-time (ms) consumed by its outgoing subtree.
 // Mirrors generator.computeSubtreeDurations but runs on EdgeConfig so it
 // can be called from Config.Validate. Assumes the graph is acyclic.
-func computeConfigSubtreeDurations(rootID string, outgoing map[string][]EdgeConfig) map[string]int64 {
+func computeConfigSubtreeDurations(roots []string, outgoing map[string][]EdgeConfig) map[string]int64 {
 	out := make(map[string]int64, len(outgoing)+1)
 	var walk func(id string) int64
 	walk = func(id string) int64 {
@@ -312,19 +625,29 @@ func computeConfigSubtreeDurations(rootID string, outgoing map[string][]EdgeConf
 			out[id] = 0
 			return 0
 		}
-		var total int64
+		steps := make([]int64, 0, len(edges))
+		weighted := make([]bool, 0, len(edges))
+		parallel := make([]bool, 0, len(edges))
 		for _, edge := range edges {
+			subtree := walk(edge.To)
+			if edge.Async {
+				continue
+			}
 			d := edge.DurationMs
 			if d <= 0 {
 				d = 1
 			}
-			step := d + walk(edge.To) + 1 // matches the runtime walker's +1ms gap
-			total += int64(edge.Repeat) * step
+			steps = append(steps, int64(edge.maxRepeat())*(d+subtree+1)) // matches the runtime walker's +1ms gap
+			weighted = append(weighted, edge.Weight > 0)
+			parallel = append(parallel, edge.Parallel)
 		}
+		total := siblingsDuration(steps, weighted, parallel)
 		out[id] = total
 		return total
 	}
-	walk(rootID)
+	for _, root := range roots {
+		walk(root)
+	}
 	return out
 }
 
@@ -333,7 +656,7 @@ func computeConfigSubtreeDurations(rootID string, outgoing map[string][]EdgeConf
 // subtree size, but the error message includes the computed server
 // interval and subtree so the user sees the consequence, not just which
 // rule failed.
-func validateTimings(edges []EdgeConfig, subtreeDuration map[string]int64) error {
+func validateTimings(errs *validation.Errors, edges []EdgeConfig, subtreeDuration map[string]int64) {
 	for i, edge := range edges {
 		if edge.NetworkLatencyMs <= 0 {
 			continue
@@ -348,16 +671,15 @@ func validateTimings(edges []EdgeConfig, subtreeDuration map[string]int64) error
 		subtree := subtreeDuration[edge.To]
 		effDur := edge.DurationMs + subtree
 		serverInterval := effDur - 2*edge.NetworkLatencyMs
-		return fmt.Errorf(
-			"edge %d (%s -> %s, kind=%s): network_latency_ms=%d incompatible with duration_ms=%d "+
+		errs.Addf(validation.Index("edges", i),
+			"%s -> %s, kind=%s: network_latency_ms=%d incompatible with duration_ms=%d "+
 				"(effective span duration = duration + subtree(%q) = %d+%d = %dms; "+
 				"server interval would be effDur - 2*latency = %dms, leaving 0 or negative own-work tail; "+
 				"need duration_ms > 2*network_latency_ms)",
-			i, edge.From, edge.To, edge.Kind,
+			edge.From, edge.To, edge.Kind,
 			edge.NetworkLatencyMs, edge.DurationMs,
 			edge.To, edge.DurationMs, subtree, effDur,
 			serverInterval,
 		)
 	}
-	return nil
 }
@@ -1,11 +1,16 @@
 package scenario
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
 )
 
 func TestDecodeJSONAndBuildValidScenario(t *testing.T) {
@@ -105,6 +110,74 @@ func TestDecodeJSONRejectsCycle(t *testing.T) {
 	}
 }
 
+func TestDecodeJSONReportsEveryProblem(t *testing.T) {
+	input := `{
+  "name": "broken",
+  "services": {
+    "api": { "resource": { "service.name": { "type": "string" } } }
+  },
+  "nodes": {
+    "a": { "service": "api", "span_name": "GET /" },
+    "b": { "service": "web", "span_name": "GET /items" }
+  },
+  "root": "a",
+  "edges": [
+    { "from": "a", "to": "b", "kind": "client_server", "repeat": 0, "duration_ms": 10 },
+    { "from": "a", "to": "c", "kind": "client_server", "repeat": 1, "duration_ms": 10,
+      "http_status_codes": [ { "code": 700, "weight": 1 } ] }
+  ]
+}`
+
+	_, err := DecodeJSON(strings.NewReader(input))
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	for _, want := range []string{
+		"5 problems:",
+		`services["api"].resource["service.name"]: value is required`,
+		`nodes["b"]: unknown service "web"`,
+		"edges[0]: repeat must be > 0",
+		`edges[1]: unknown to node "c"`,
+		"edges[1].http_status_codes[0]: code must be between 100 and 599",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected error containing %q, got %v", want, err)
+		}
+	}
+}
+
+func TestDecodeJSONReportsUnknownFieldsWithPaths(t *testing.T) {
+	input := `{
+  "$schema": "../docs/schemas/scenario.schema.json",
+  "name": "typos",
+  "services": { "api": { "resource": { "service.name": { "type": "string", "value": "api" } } } },
+  "nodes": { "a": { "service": "api", "span_name": "GET /" }, "b": { "service": "api", "span_name": "load" } },
+  "root": "a",
+  "edges": [
+    { "from": "a", "to": "b", "kind": "internal", "repeat": 1, "duraton_ms": 10 },
+    { "from": "a", "to": "b", "kind": "rpc", "repeat": 1, "duration_ms": 10 }
+  ]
+}`
+
+	_, err := DecodeJSON(strings.NewReader(input))
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	for _, want := range []string{
+		`edges[0]: unknown field "duraton_ms"`,
+		`edges[1].kind: "rpc" is not one of client_server, producer_consumer, internal, client_database`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected error containing %q, got %v", want, err)
+		}
+	}
+
+	fixed := strings.NewReplacer("duraton_ms", "duration_ms", `"rpc"`, `"internal"`).Replace(input)
+	if _, err := DecodeJSON(strings.NewReader(fixed)); err != nil {
+		t.Fatalf("DecodeJSON() error = %v, want $schema accepted", err)
+	}
+}
+
 func TestDecodeJSONWithArrayAttributes(t *testing.T) {
 	input := `{
   "name": "array-test",
@@ -325,6 +398,53 @@ func TestDecodeJSONRejectsLinkToUnknownNode(t *testing.T) {
 	}
 }
 
+func TestDecodeJSONRejectsInvalidNodeEvents(t *testing.T) {
+	input := `{
+  "name": "bad-events",
+  "services": { "svc": { "resource": { "service.name": { "type": "string", "value": "svc" } } } },
+  "nodes": {
+    "a": { "service": "svc", "span_name": "A", "events": [{ "name": "" }] },
+    "b": { "service": "svc", "span_name": "B" }
+  },
+  "root": "a",
+  "edges": [
+    { "from": "a", "to": "b", "kind": "internal", "repeat": 1, "duration_ms": 10,
+      "span_events": [{ "name": "cache.miss", "offset_ms": -1 }] }
+  ]
+}`
+
+	_, err := DecodeJSON(strings.NewReader(input))
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	for _, want := range []string{
+		`nodes["a"].events[0]: name is required`,
+		"edges[0].span_events[0]: offset_ms must be >= 0",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected error containing %q, got %v", want, err)
+		}
+	}
+}
+
+func TestDecodeJSONRejectsNegativePreviousTraces(t *testing.T) {
+	input := `{
+  "name": "bad-link",
+  "services": { "svc": { "resource": { "service.name": { "type": "string", "value": "svc" } } } },
+  "nodes": { "a": { "service": "svc", "span_name": "A" }, "b": { "service": "svc", "span_name": "B" } },
+  "root": "a",
+  "edges": [
+    { "from": "a", "to": "b", "kind": "internal", "repeat": 1, "duration_ms": 10,
+      "span_links": [{ "node": "b", "previous_traces": -1 }] }
+  ]
+}`
+
+	_, err := DecodeJSON(strings.NewReader(input))
+	if err == nil || !strings.Contains(err.Error(), "edges[0].span_links[0]: previous_traces must be >= 0") {
+		t.Fatalf("expected previous_traces error, got %v", err)
+	}
+}
+
 func TestDecodeJSONRejectsEventWithoutName(t *testing.T) {
 	input := `{
   "name": "bad-event",
@@ -470,6 +590,52 @@ func TestDecodeJSONRejectsUnreachableSubgraph(t *testing.T) {
 	}
 }
 
+func TestDecodeJSONValidatesRoots(t *testing.T) {
+	base := func(roots string) string {
+		return `{
+  "name": "roots",
+  "services": { "s": { "resource": { "service.name": { "type": "string", "value": "s" } } } },
+  "nodes": {
+    "list":   { "service": "s", "span_name": "GET /posts" },
+    "create": { "service": "s", "span_name": "POST /posts" },
+    "db":     { "service": "s", "span_name": "query" }
+  },
+  ` + roots + `,
+  "edges": [
+    { "from": "list", "to": "db", "kind": "internal", "repeat": 1, "duration_ms": 10 },
+    { "from": "create", "to": "db", "kind": "internal", "repeat": 1, "duration_ms": 10 }
+  ]
+}`
+	}
+	tests := []struct {
+		name    string
+		roots   string
+		wantMsg string
+	}{
+		{name: "weighted roots accepted", roots: `"roots": [{ "node": "list", "weight": 4 }, { "node": "create", "weight": 1 }]`},
+		{name: "single root leaves a node unreachable", roots: `"root": "list"`, wantMsg: `nodes not reachable from root "list": [create]`},
+		{name: "root and roots rejected", roots: `"root": "list", "roots": [{ "node": "list", "weight": 1 }, { "node": "create", "weight": 1 }]`, wantMsg: "root and roots are mutually exclusive"},
+		{name: "unknown root rejected", roots: `"roots": [{ "node": "list", "weight": 1 }, { "node": "delete", "weight": 1 }]`, wantMsg: `roots[1]: root node "delete" not found`},
+		{name: "duplicate root rejected", roots: `"roots": [{ "node": "list", "weight": 1 }, { "node": "list", "weight": 1 }]`, wantMsg: `roots[1]: duplicate root node "list"`},
+		{name: "zero weight rejected", roots: `"roots": [{ "node": "list", "weight": 1 }, { "node": "create" }]`, wantMsg: "roots[1]: weight must be > 0"},
+		{name: "root with incoming edges rejected", roots: `"roots": [{ "node": "list", "weight": 1 }, { "node": "create", "weight": 1 }, { "node": "db", "weight": 1 }]`, wantMsg: `root node "db" must have no incoming edges`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeJSON(strings.NewReader(base(tt.roots)))
+			if tt.wantMsg == "" {
+				if err != nil {
+					t.Fatalf("DecodeJSON() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Fatalf("DecodeJSON() error = %v, want %q", err, tt.wantMsg)
+			}
+		})
+	}
+}
+
 func TestDecodeJSONLatencyErrorIncludesSubtreeContext(t *testing.T) {
 	// When the latency check fails, the error message must explain the
 	// consequence in terms the user can act on: effective duration,
@@ -576,6 +742,58 @@ func TestDecodeJSONNetworkLatencyValidation(t *testing.T) {
 			input:   base("client_database", `, "network_latency_ms": 2`),
 			wantErr: false,
 		},
+		{
+			name:    "probability above one rejected",
+			input:   base("internal", `, "probability": 1.5`),
+			wantErr: true,
+			wantMsg: "probability must be in (0, 1]",
+		},
+		{
+			name:    "negative weight rejected",
+			input:   base("internal", `, "weight": -1`),
+			wantErr: true,
+			wantMsg: "weight must be >= 0",
+		},
+		{
+			name:    "error rate above one rejected",
+			input:   base("client_server", `, "error_rate": 2`),
+			wantErr: true,
+			wantMsg: "error_rate must be between 0 and 1",
+		},
+		{
+			name:    "non-5xx error status rejected",
+			input:   base("client_server", `, "error_rate": 0.1, "error_status": 404`),
+			wantErr: true,
+			wantMsg: "error_status must be between 500 and 599",
+		},
+		{
+			name:    "error status on internal edge rejected",
+			input:   base("internal", `, "error_rate": 0.1, "error_status": 503`),
+			wantErr: true,
+			wantMsg: "error_status is only supported on client_server edges",
+		},
+		{
+			name:    "probability with weight rejected",
+			input:   base("internal", `, "probability": 0.5, "weight": 1`),
+			wantErr: true,
+			wantMsg: "mutually exclusive",
+		},
+		{
+			name:  "async edge with start offset accepted",
+			input: base("producer_consumer", `, "async": true, "start_offset_ms": 5`),
+		},
+		{
+			name:    "start offset without async rejected",
+			input:   base("producer_consumer", `, "start_offset_ms": 5`),
+			wantErr: true,
+			wantMsg: "start_offset_ms requires async",
+		},
+		{
+			name:    "parallel async edge rejected",
+			input:   base("producer_consumer", `, "async": true, "parallel": true`),
+			wantErr: true,
+			wantMsg: "async edges cannot have a weight or be parallel",
+		},
 	}
 
 	for _, tt := range tests {
@@ -594,3 +812,262 @@ func TestDecodeJSONNetworkLatencyValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadFromJSONAcceptsYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scenario.yaml")
+	input := `name: yaml-scenario
+seed: 3
+services:
+  frontend:
+    resource:
+      service.name: { type: string, value: frontend }
+  backend:
+    resource:
+      service.name: { type: string, value: backend }
+nodes:
+  a: { service: frontend, span_name: GET /items }
+  b: { service: backend, span_name: GET /items }
+root: a
+edges:
+  - from: a
+    to: b
+    kind: client_server
+    repeat: 2
+    duration_ms: 30
+`
+	if err := os.WriteFile(path, []byte(input), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	cfg, err := LoadFromJSON(path)
+	if err != nil {
+		t.Fatalf("LoadFromJSON() error = %v", err)
+	}
+	if cfg.Name != "yaml-scenario" || cfg.Root != "a" {
+		t.Fatalf("unexpected config: name=%q root=%q", cfg.Name, cfg.Root)
+	}
+	if len(cfg.Edges) != 1 || cfg.Edges[0].Repeat != 2 {
+		t.Fatalf("unexpected edges: %+v", cfg.Edges)
+	}
+}
+
+func TestGeneratorDerivesSpanStatusFromHTTPStatusCode(t *testing.T) {
+	input := `{
+  "name": "http-status",
+  "services": {
+    "web": { "resource": { "service.name": { "type": "string", "value": "web" } } },
+    "api": { "resource": { "service.name": { "type": "string", "value": "api" } } }
+  },
+  "nodes": {
+    "a": { "service": "web", "span_name": "GET /" },
+    "b": { "service": "api", "span_name": "GET /items" }
+  },
+  "root": "a",
+  "edges": [
+    {
+      "from": "a", "to": "b", "kind": "client_server", "repeat": 1, "duration_ms": 20,
+      "span_attributes": { "http.response.status_code": { "type": "int", "value": 404 } }
+    }
+  ]
+}`
+
+	statuses := func(input string) map[oteltrace.SpanKind]codes.Code {
+		cfg, err := DecodeJSON(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("DecodeJSON() error = %v", err)
+		}
+		definition, err := cfg.Build()
+		if err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+		spans, err := NewGenerator(definition).GenerateBatch(context.Background())
+		if err != nil {
+			t.Fatalf("GenerateBatch() error = %v", err)
+		}
+		out := map[oteltrace.SpanKind]codes.Code{}
+		for _, span := range spans {
+			if _, ok := span.Attributes["http.response.status_code"]; ok {
+				out[span.Kind] = span.StatusCode
+			}
+		}
+		return out
+	}
+
+	got := statuses(input)
+	if got[oteltrace.SpanKindClient] != codes.Error || got[oteltrace.SpanKindServer] != codes.Ok {
+		t.Fatalf("default mapping: expected client error and server ok, got %v", got)
+	}
+
+	custom := strings.Replace(input, `"root": "a",`, `"root": "a",
+  "http_status_mapping": { "client_errors": ["5xx"], "server_errors": ["5xx"] },`, 1)
+	got = statuses(custom)
+	if got[oteltrace.SpanKindClient] != codes.Ok {
+		t.Fatalf("custom mapping: expected client 404 to be ok, got %v", got)
+	}
+}
+
+func TestDecodeJSONRejectsServiceSemconvWithoutScenarioSemconv(t *testing.T) {
+	input := `{
+  "name": "semconv",
+  "services": { "db": { "resource": {}, "semconv": { "db.system": { "type": "string", "value": "mysql" } } } },
+  "nodes": { "a": { "service": "db", "span_name": "A" }, "b": { "service": "db", "span_name": "B" } },
+  "root": "a",
+  "edges": [{ "from": "a", "to": "b", "kind": "client_database", "repeat": 1, "duration_ms": 10 }]
+}`
+
+	_, err := DecodeJSON(strings.NewReader(input))
+	if want := `services["db"]: semconv requires semconv to be enabled on the scenario`; err == nil || !strings.Contains(err.Error(), want) {
+		t.Fatalf("expected error containing %q, got %v", want, err)
+	}
+}
+
+func TestDecodeJSONValidatesResourcePresets(t *testing.T) {
+	input := `{
+  "name": "presets",
+  "services": {
+    "api": { "resource": {}, "resource_preset": { "type": "vm", "instances": -1 } },
+    "db": { "resource": {}, "resource_preset": { "type": "host", "namespace": "prod" } }
+  },
+  "nodes": { "a": { "service": "api", "span_name": "A" }, "b": { "service": "db", "span_name": "B" } },
+  "root": "a",
+  "edges": [{ "from": "a", "to": "b", "kind": "client_database", "repeat": 1, "duration_ms": 10 }]
+}`
+
+	_, err := DecodeJSON(strings.NewReader(input))
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	for _, want := range []string{
+		`services["api"].resource_preset: type must be k8s or host, got "vm"`,
+		`services["api"].resource_preset: instances must be between 0 and 10000`,
+		`services["db"].resource_preset: namespace, cluster and nodes are only supported for k8s`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected error containing %q, got %v", want, err)
+		}
+	}
+}
+
+func TestDecodeJSONValidatesRepeatDistributions(t *testing.T) {
+	input := `{
+  "name": "repeats",
+  "services": { "svc": { "resource": {} } },
+  "nodes": { "a": { "service": "svc", "span_name": "A" }, "b": { "service": "svc", "span_name": "B" } },
+  "root": "a",
+  "edges": [
+    { "from": "a", "to": "b", "kind": "internal", "repeat": 2, "duration_ms": 10,
+      "repeat_distribution": { "type": "poisson", "max": 5 } },
+    { "from": "a", "to": "b", "kind": "internal", "duration_ms": 10,
+      "repeat_distribution": { "type": "uniform", "min": 4, "max": 2 } }
+  ]
+}`
+
+	_, err := DecodeJSON(strings.NewReader(input))
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	for _, want := range []string{
+		"edges[0]: repeat and repeat_distribution are mutually exclusive",
+		"edges[0].repeat_distribution: mean must be > 0",
+		"edges[1].repeat_distribution: min must be <= max",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected error containing %q, got %v", want, err)
+		}
+	}
+}
+
+func TestLoadFromJSONMergesIncludes(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("write file: %v", err)
+		}
+	}
+	write("main.json", `{
+  "name": "composed",
+  "includes": ["teams"],
+  "services": {"frontend": {"resource": {"service.name": {"type": "string", "value": "frontend"}}}},
+  "nodes": {"web": {"service": "frontend", "span_name": "GET /checkout"}},
+  "root": "web",
+  "edges": [{"from": "web", "to": "checkout", "kind": "client_server", "repeat": 1, "duration_ms": 40}]
+}`)
+	write("teams/checkout.yaml", `includes: [../shared/db.json]
+services:
+  checkout:
+    resource:
+      service.name: { type: string, value: checkout }
+nodes:
+  checkout: { service: checkout, span_name: POST /orders }
+edges:
+  - { from: checkout, to: orders-db, kind: client_database, repeat: 1, duration_ms: 10 }
+`)
+	write("teams/notes.txt", "ignored")
+	write("shared/db.json", `{
+  "services": {"postgres": {"resource": {"service.name": {"type": "string", "value": "postgres"}}}},
+  "nodes": {"orders-db": {"service": "postgres", "span_name": "INSERT orders"}}
+}`)
+
+	cfg, err := LoadFromJSON(filepath.Join(dir, "main.json"))
+	if err != nil {
+		t.Fatalf("LoadFromJSON() error = %v", err)
+	}
+	if len(cfg.Services) != 3 || len(cfg.Nodes) != 3 {
+		t.Fatalf("expected 3 services and nodes after merging, got %d and %d", len(cfg.Services), len(cfg.Nodes))
+	}
+	if len(cfg.Edges) != 2 || cfg.Edges[0].To != "checkout" || cfg.Edges[1].To != "orders-db" {
+		t.Fatalf("expected the fragment edge after the main edge, got %+v", cfg.Edges)
+	}
+	if cfg.Includes != nil {
+		t.Fatalf("expected includes to be resolved, got %v", cfg.Includes)
+	}
+
+	tests := []struct {
+		name     string
+		fragment string
+		wantErr  string
+	}{
+		{
+			name:     "conflicting service",
+			fragment: `{"services": {"frontend": {"resource": {"service.name": {"type": "string", "value": "other"}}}}}`,
+			wantErr:  `service "frontend" is already defined differently`,
+		},
+		{
+			name:     "scenario field",
+			fragment: `{"root": "web"}`,
+			wantErr:  "a fragment can only set services, nodes, edges and includes",
+		},
+		{
+			name:     "cycle",
+			fragment: `{"includes": ["main.json"]}`,
+			wantErr:  "included more than once",
+		},
+		{
+			name:     "unknown field",
+			fragment: `{"servces": {}}`,
+			wantErr:  "servces",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			write("bad.json", tt.fragment)
+			write("main.json", `{
+  "name": "composed",
+  "includes": ["bad.json"],
+  "services": {"frontend": {"resource": {"service.name": {"type": "string", "value": "frontend"}}}},
+  "nodes": {"web": {"service": "frontend"}},
+  "root": "web",
+  "edges": []
+}`)
+			_, err := LoadFromJSON(filepath.Join(dir, "main.json"))
+			if err == nil || !strings.Contains(err.Error(), "include ") || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("LoadFromJSON() error = %v, want an include error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package scenario
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/javiermolinar/tercios/internal/validation"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// DBQueryConfig describes the statements a client_database edge issues.
+// Each call picks one statement and fills its {id} placeholder, so
+// backends see realistic db.statement values with a bounded number of
+// distinct strings.
+type DBQueryConfig struct {
+	Statements []DBStatementConfig `json:"statements"`
+	// Cardinality is the number of distinct values substituted for {id}
+	// in each template.
+	Cardinality int `json:"cardinality,omitempty"`
+}
+
+type DBStatementConfig struct {
+	Operation string `json:"operation"`
+	Table     string `json:"table,omitempty"`
+	Template  string `json:"template"`
+}
+
+const dbQueryPlaceholder = "{id}"
+
+func (c DBQueryConfig) validate(errs *validation.Errors, path string) {
+	if len(c.Statements) == 0 {
+		errs.Addf(path, "at least one statement is required")
+	}
+	if c.Cardinality < 0 {
+		errs.Addf(path, "cardinality must be >= 0")
+	}
+	for i, statement := range c.Statements {
+		statementPath := validation.Index(validation.Field(path, "statements"), i)
+		if strings.TrimSpace(statement.Operation) == "" {
+			errs.Addf(statementPath, "operation is required")
+		}
+		if strings.TrimSpace(statement.Template) == "" {
+			errs.Addf(statementPath, "template is required")
+		}
+		if strings.Contains(statement.Template, dbQueryPlaceholder) && c.Cardinality == 0 {
+			errs.Addf(statementPath, "template uses %s but cardinality is 0", dbQueryPlaceholder)
+		}
+	}
+}
+
+// dbQuery is a compiled DBQueryConfig. System is resolved once per edge so
+// every call to the same database reports the same db.system.
+type dbQuery struct {
+	system      attribute.Value
+	statements  []DBStatementConfig
+	cardinality uint64
+}
+
+func newDBQuery(cfg *DBQueryConfig, edgeAttrs map[string]attribute.Value, target Service) *dbQuery {
+	if cfg == nil {
+		return nil
+	}
+	query := &dbQuery{
+		statements:  cfg.Statements,
+		cardinality: uint64(cfg.Cardinality),
+	}
+	if system, ok := edgeAttrs["db.system"]; ok {
+		query.system = system
+	} else if system, ok := target.ResourceAttributes["db.system"]; ok {
+		query.system = system
+	}
+	return query
+}
+
+// apply sets the db.* attributes for one call into attrs.
+func (q *dbQuery) apply(attrs map[string]attribute.Value, callID oteltrace.SpanID) {
+	statement := q.statements[callRandom(callID, 1)%uint64(len(q.statements))]
+	text := statement.Template
+	if q.cardinality > 0 {
+		id := callRandom(callID, 2) % q.cardinality
+		text = strings.ReplaceAll(text, dbQueryPlaceholder, strconv.FormatUint(id, 10))
+	}
+	if q.system.Type() != attribute.INVALID {
+		attrs["db.system"] = q.system
+	}
+	attrs["db.operation"] = attribute.StringValue(statement.Operation)
+	if statement.Table != "" {
+		attrs["db.sql.table"] = attribute.StringValue(statement.Table)
+	}
+	attrs["db.statement"] = attribute.StringValue(text)
+}
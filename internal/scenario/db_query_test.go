@@ -0,0 +1,106 @@
+package scenario
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const dbQueryScenario = `{
+  "name": "db-query",
+  "seed": 3,
+  "services": {
+    "api": { "resource": { "service.name": { "type": "string", "value": "api" } } },
+    "db": { "resource": {
+      "service.name": { "type": "string", "value": "postgres" },
+      "db.system": { "type": "string", "value": "postgresql" }
+    } }
+  },
+  "nodes": {
+    "a": { "service": "api", "span_name": "GET /items" },
+    "b": { "service": "db", "span_name": "query" }
+  },
+  "root": "a",
+  "edges": [
+    {
+      "from": "a", "to": "b", "kind": "client_database", "repeat": 40, "duration_ms": 2,
+      "db_query": {
+        "cardinality": 5,
+        "statements": [
+          { "operation": "SELECT", "table": "items", "template": "SELECT * FROM items WHERE id = {id}" },
+          { "operation": "UPDATE", "table": "items", "template": "UPDATE items SET seen = true WHERE id = {id}" }
+        ]
+      }
+    }
+  ]
+}`
+
+func TestGeneratorFillsDBQueryAttributes(t *testing.T) {
+	cfg, err := DecodeJSON(strings.NewReader(dbQueryScenario))
+	if err != nil {
+		t.Fatalf("DecodeJSON() error = %v", err)
+	}
+	definition, err := cfg.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	spans, err := NewGenerator(definition).GenerateBatch(context.Background())
+	if err != nil {
+		t.Fatalf("GenerateBatch() error = %v", err)
+	}
+
+	statements := map[string]struct{}{}
+	operations := map[string]struct{}{}
+	for _, span := range spans {
+		statement, ok := span.Attributes["db.statement"]
+		if !ok {
+			continue
+		}
+		if got := span.Attributes["db.system"]; got.Type() != attribute.STRING || got.AsString() != "postgresql" {
+			t.Fatalf("expected db.system from the target service, got %v", got)
+		}
+		if got := span.Attributes["db.sql.table"].AsString(); got != "items" {
+			t.Fatalf("expected db.sql.table=items, got %q", got)
+		}
+		if strings.Contains(statement.AsString(), dbQueryPlaceholder) {
+			t.Fatalf("expected placeholder to be filled, got %q", statement.AsString())
+		}
+		statements[statement.AsString()] = struct{}{}
+		operations[span.Attributes["db.operation"].AsString()] = struct{}{}
+	}
+
+	if len(operations) != 2 {
+		t.Fatalf("expected both operations to be drawn, got %v", operations)
+	}
+	if len(statements) < 2 || len(statements) > 10 {
+		t.Fatalf("expected between 2 and 10 distinct statements (2 templates x cardinality 5), got %d", len(statements))
+	}
+}
+
+func TestDecodeJSONRejectsInvalidDBQuery(t *testing.T) {
+	tests := map[string]string{
+		`"cardinality": 5,`:  "",
+		`"cardinality": 0,`:  "cardinality is 0",
+		`"cardinality": -1,`: "cardinality must be >= 0",
+	}
+	for field, want := range tests {
+		input := strings.Replace(dbQueryScenario, `"cardinality": 5,`, field, 1)
+		_, err := DecodeJSON(strings.NewReader(input))
+		if want == "" {
+			if err != nil {
+				t.Fatalf("%s: unexpected error %v", field, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("%s: expected error containing %q, got %v", field, want, err)
+		}
+	}
+
+	clientServer := strings.Replace(dbQueryScenario, `"kind": "client_database"`, `"kind": "client_server"`, 1)
+	if _, err := DecodeJSON(strings.NewReader(clientServer)); err == nil || !strings.Contains(err.Error(), "only supported on client_database edges") {
+		t.Fatalf("expected client_database-only error, got %v", err)
+	}
+}
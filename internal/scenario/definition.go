@@ -2,51 +2,94 @@ package scenario
 
 import (
 	"fmt"
+	"maps"
 	"time"
 
+	"github.com/javiermolinar/tercios/internal/httpstatus"
 	"go.opentelemetry.io/otel/attribute"
 )
 
 type EventDef struct {
 	Name       string
 	Attributes []attribute.KeyValue
+	// Offset is the EventConfig OffsetMs, or nil for a mid-span event.
+	Offset *time.Duration
 }
 
 type LinkDef struct {
 	Node       string
 	Attributes []attribute.KeyValue
+	// PreviousTraces is the LinkConfig field of the same name.
+	PreviousTraces int
+}
+
+// RootDef is one weighted entrypoint of a scenario; see RootConfig.
+type RootDef struct {
+	Node   string
+	Weight float64
 }
 
 type Service struct {
 	ID                 string
 	ResourceAttributes map[string]attribute.Value
+	// Instances, when set, holds the full resource attributes of each
+	// instance of a service with a resource preset. Spans use one of them
+	// instead of ResourceAttributes.
+	Instances []map[string]attribute.Value
 }
 
 type Node struct {
 	ID       string
 	Service  string
 	SpanName string
+	Events   []EventDef
 }
 
 type Edge struct {
-	From           string
-	To             string
-	Kind           EdgeKind
+	From string
+	To   string
+	Kind EdgeKind
+	// Repeat is the most calls per source span, which the layout of the
+	// trace reserves time for. Repeats, when set, draws the actual count
+	// of each source span.
 	Repeat         int
+	Repeats        *repeatDistribution
 	Duration       time.Duration
 	NetworkLatency time.Duration
 	SpanAttributes map[string]attribute.Value
 	SpanEvents     []EventDef
 	SpanLinks      []LinkDef
+	StatusCodes    statusDistribution
+	DBQuery        *dbQuery
+	Messaging      *messaging
+	Code           *codeProfile
+	// Probability and Weight are the EdgeConfig fields of the same name.
+	Probability float64
+	Weight      float64
+	// ErrorRate and ErrorStatus are the EdgeConfig fields of the same
+	// name, with ErrorStatus defaulted.
+	ErrorRate   float64
+	ErrorStatus int64
+	Parallel    bool
+	// Async and StartOffset come from the EdgeConfig fields Async and
+	// StartOffsetMs.
+	Async       bool
+	StartOffset time.Duration
 }
 
 type Definition struct {
-	Name     string
-	Seed     int64
-	Root     string
+	Name string
+	Seed int64
+	Root string
+	// Roots, when set, replaces Root: each trace starts at one of them,
+	// picked by weight.
+	Roots    []RootDef
 	Services map[string]Service
 	Nodes    map[string]Node
 	Edges    []Edge
+	// HTTPStatus sets span status from HTTP status code attributes.
+	HTTPStatus     httpstatus.Mapping
+	SpansPerSecond float64
 }
 
 func (c Config) Build() (Definition, error) {
@@ -58,9 +101,19 @@ func (c Config) Build() (Definition, error) {
 		Name:     c.Name,
 		Seed:     c.Seed,
 		Root:     c.Root,
+		Roots:    make([]RootDef, 0, len(c.Roots)),
 		Services: make(map[string]Service, len(c.Services)),
 		Nodes:    make(map[string]Node, len(c.Nodes)),
 		Edges:    make([]Edge, 0, len(c.Edges)),
+
+		SpansPerSecond: c.SpansPerSecond,
+	}
+	for _, root := range c.Roots {
+		definition.Roots = append(definition.Roots, RootDef{Node: root.Node, Weight: root.Weight})
+	}
+	definition.HTTPStatus = httpstatus.DefaultMapping()
+	if c.HTTPStatusMapping != nil {
+		definition.HTTPStatus = *c.HTTPStatusMapping
 	}
 
 	for id, service := range c.Services {
@@ -68,11 +121,25 @@ func (c Config) Build() (Definition, error) {
 		if err != nil {
 			return Definition{}, fmt.Errorf("service %s: %w", id, err)
 		}
-		definition.Services[id] = Service{ID: id, ResourceAttributes: attrs}
+		built := Service{ID: id, ResourceAttributes: attrs}
+		built.Instances = presetInstances(service.ResourcePreset, semconvServiceName(built), attrs)
+		definition.Services[id] = built
+	}
+	semconvOverrides := make(map[string]map[string]attribute.Value, len(c.Services))
+	for id, service := range c.Services {
+		attrs, err := typedMapToAttributes(service.Semconv)
+		if err != nil {
+			return Definition{}, fmt.Errorf("service %s: semconv: %w", id, err)
+		}
+		semconvOverrides[id] = attrs
 	}
 
 	for id, node := range c.Nodes {
-		definition.Nodes[id] = Node{ID: id, Service: node.Service, SpanName: node.SpanName}
+		events, err := buildEventDefs(node.Events)
+		if err != nil {
+			return Definition{}, fmt.Errorf("node %s: %w", id, err)
+		}
+		definition.Nodes[id] = Node{ID: id, Service: node.Service, SpanName: node.SpanName, Events: events}
 	}
 
 	for i, edge := range c.Edges {
@@ -80,24 +147,58 @@ func (c Config) Build() (Definition, error) {
 		if err != nil {
 			return Definition{}, fmt.Errorf("edge %d: %w", i, err)
 		}
-		events, err := buildEventDefs(edge.SpanEvents, i)
+		events, err := buildEventDefs(edge.SpanEvents)
 		if err != nil {
-			return Definition{}, err
+			return Definition{}, fmt.Errorf("edge %d: %w", i, err)
 		}
 		links, err := buildLinkDefs(edge.SpanLinks, i)
 		if err != nil {
 			return Definition{}, err
 		}
+		target := definition.Nodes[edge.To]
+		messagingConfig := edge.Messaging
+		if c.Semconv && edge.Kind != EdgeKindInternal {
+			attrs := semconvAttributes(edge.Kind, target, definition.Services[target.Service])
+			maps.Copy(attrs, semconvOverrides[target.Service])
+			maps.Copy(attrs, spanAttrs)
+			spanAttrs = attrs
+			if edge.Kind == EdgeKindProducerConsumer && messagingConfig == nil {
+				messagingConfig = &MessagingConfig{
+					System:      attrs["messaging.system"].Emit(),
+					Destination: attrs["messaging.destination.name"].Emit(),
+				}
+			}
+		}
+		statusCodes := edge.HTTPStatusCodes
+		if len(statusCodes) == 0 && edge.Kind == EdgeKindClientServer {
+			statusCodes = c.Services[c.Nodes[edge.To].Service].HTTPStatusCodes
+		}
+		errorStatus := edge.ErrorStatus
+		if errorStatus == 0 {
+			errorStatus = defaultErrorStatus
+		}
 		definition.Edges = append(definition.Edges, Edge{
 			From:           edge.From,
 			To:             edge.To,
 			Kind:           edge.Kind,
-			Repeat:         edge.Repeat,
+			Repeat:         edge.maxRepeat(),
+			Repeats:        newRepeatDistribution(edge.RepeatDistribution),
 			Duration:       time.Duration(edge.DurationMs) * time.Millisecond,
 			NetworkLatency: time.Duration(edge.NetworkLatencyMs) * time.Millisecond,
 			SpanAttributes: spanAttrs,
 			SpanEvents:     events,
 			SpanLinks:      links,
+			StatusCodes:    newStatusDistribution(statusCodes),
+			DBQuery:        newDBQuery(edge.DBQuery, spanAttrs, definition.Services[target.Service]),
+			Messaging:      newMessaging(messagingConfig),
+			Code:           newCodeProfile(edge.Code),
+			Probability:    edge.Probability,
+			Weight:         edge.Weight,
+			ErrorRate:      edge.ErrorRate,
+			ErrorStatus:    int64(errorStatus),
+			Parallel:       edge.Parallel,
+			Async:          edge.Async,
+			StartOffset:    time.Duration(edge.StartOffsetMs) * time.Millisecond,
 		})
 	}
 
@@ -124,7 +225,7 @@ func typedValueToAttributeValue(value TypedValue) (attribute.Value, error) {
 	return value.ToAttributeValue()
 }
 
-func buildEventDefs(configs []EventConfig, edgeIndex int) ([]EventDef, error) {
+func buildEventDefs(configs []EventConfig) ([]EventDef, error) {
 	if len(configs) == 0 {
 		return nil, nil
 	}
@@ -132,9 +233,14 @@ func buildEventDefs(configs []EventConfig, edgeIndex int) ([]EventDef, error) {
 	for j, cfg := range configs {
 		attrs, err := typedMapToKeyValues(cfg.Attributes)
 		if err != nil {
-			return nil, fmt.Errorf("edge %d event %d: %w", edgeIndex, j, err)
+			return nil, fmt.Errorf("event %d: %w", j, err)
+		}
+		def := EventDef{Name: cfg.Name, Attributes: attrs}
+		if cfg.OffsetMs != nil {
+			offset := time.Duration(*cfg.OffsetMs) * time.Millisecond
+			def.Offset = &offset
 		}
-		out = append(out, EventDef{Name: cfg.Name, Attributes: attrs})
+		out = append(out, def)
 	}
 	return out, nil
 }
@@ -149,7 +255,7 @@ func buildLinkDefs(configs []LinkConfig, edgeIndex int) ([]LinkDef, error) {
 		if err != nil {
 			return nil, fmt.Errorf("edge %d link %d: %w", edgeIndex, j, err)
 		}
-		out = append(out, LinkDef{Node: cfg.Node, Attributes: attrs})
+		out = append(out, LinkDef{Node: cfg.Node, Attributes: attrs, PreviousTraces: cfg.PreviousTraces})
 	}
 	return out, nil
 }
@@ -168,3 +274,65 @@ func typedMapToKeyValues(values map[string]TypedValue) ([]attribute.KeyValue, er
 	}
 	return out, nil
 }
+
+// SpansPerTrace returns the number of spans one trace of the definition
+// contains, on average when edges have a probability or weight.
+func (d Definition) SpansPerTrace() float64 {
+	outgoing := make(map[string][]Edge, len(d.Nodes))
+	for _, edge := range d.Edges {
+		outgoing[edge.From] = append(outgoing[edge.From], edge)
+	}
+	weights := make(map[string]float64, len(outgoing))
+	for _, edge := range d.Edges {
+		weights[edge.From] += edge.Weight
+	}
+	memo := map[string]float64{}
+	var walk func(id string) float64
+	walk = func(id string) float64 {
+		if value, ok := memo[id]; ok {
+			return value
+		}
+		total := 0.0
+		for _, edge := range outgoing[id] {
+			spans := 2
+			if edge.Kind == EdgeKindInternal {
+				spans = 1
+				if edge.Code != nil {
+					spans = edge.Code.frames()
+				}
+			}
+			share := 1.0
+			switch {
+			case edge.Weight > 0:
+				share = edge.Weight / weights[id]
+			case edge.Probability > 0:
+				share = edge.Probability
+			}
+			total += share * edge.expectedRepeat() * (float64(spans) + walk(edge.To))
+		}
+		memo[id] = total
+		return total
+	}
+	if len(d.Roots) == 0 {
+		return 1 + walk(d.Root)
+	}
+	// Each root contributes in proportion to the traces starting there.
+	var total, spans float64
+	for _, root := range d.Roots {
+		total += root.Weight
+		spans += root.Weight * (1 + walk(root.Node))
+	}
+	return spans / total
+}
+
+// rootNodes returns the nodes traces can start at.
+func (d Definition) rootNodes() []string {
+	if len(d.Roots) == 0 {
+		return []string{d.Root}
+	}
+	nodes := make([]string, 0, len(d.Roots))
+	for _, root := range d.Roots {
+		nodes = append(nodes, root.Node)
+	}
+	return nodes
+}
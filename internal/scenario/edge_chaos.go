@@ -0,0 +1,124 @@
+package scenario
+
+import (
+	"fmt"
+
+	"github.com/javiermolinar/tercios/internal/chaos"
+	"github.com/javiermolinar/tercios/internal/validation"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// EdgeChaosConfig is a shorthand for simple fault shaping on one edge. It is
+// compiled into chaos policies that target the edge's source-side span (the
+// client/producer span, or the single span of an internal edge), so no
+// separate policies file is needed.
+type EdgeChaosConfig struct {
+	// ErrorRate is the probability that the span is marked as an error.
+	ErrorRate    float64 `json:"error_rate,omitempty"`
+	ErrorMessage string  `json:"error_message,omitempty"`
+	// ExtraLatency is a discrete latency distribution: each bucket
+	// independently extends the span by DeltaMs with its probability.
+	ExtraLatency []LatencyBucket `json:"extra_latency,omitempty"`
+}
+
+type LatencyBucket struct {
+	Probability float64 `json:"probability"`
+	DeltaMs     int64   `json:"delta_ms"`
+}
+
+const defaultEdgeErrorMessage = "injected by scenario edge chaos"
+
+func (c EdgeChaosConfig) validate(errs *validation.Errors, path string) {
+	if c.ErrorRate < 0 || c.ErrorRate > 1 {
+		errs.Addf(path, "error_rate must be between 0 and 1")
+	}
+	for i, bucket := range c.ExtraLatency {
+		bucketPath := validation.Index(validation.Field(path, "extra_latency"), i)
+		if bucket.Probability < 0 || bucket.Probability > 1 {
+			errs.Addf(bucketPath, "probability must be between 0 and 1")
+		}
+		if bucket.DeltaMs <= 0 {
+			errs.Addf(bucketPath, "delta_ms must be > 0")
+		}
+	}
+}
+
+// ChaosPolicies compiles the chaos blocks of every edge into chaos policies.
+// It returns nil when no edge declares chaos.
+func (c Config) ChaosPolicies() []chaos.Policy {
+	var policies []chaos.Policy
+	for _, edge := range c.Edges {
+		if edge.Chaos == nil {
+			continue
+		}
+		match := c.edgeSourceMatch(edge)
+		prefix := fmt.Sprintf("%s: edge %s -> %s", c.Name, edge.From, edge.To)
+		if edge.Chaos.ErrorRate > 0 {
+			message := edge.Chaos.ErrorMessage
+			if message == "" {
+				message = defaultEdgeErrorMessage
+			}
+			policies = append(policies, chaos.Policy{
+				Name:        prefix + " error_rate",
+				Probability: edge.Chaos.ErrorRate,
+				Match:       match,
+				Actions:     []chaos.Action{{Type: "set_status", Code: "error", Message: message}},
+			})
+		}
+		for i, bucket := range edge.Chaos.ExtraLatency {
+			if bucket.Probability <= 0 {
+				continue
+			}
+			policies = append(policies, chaos.Policy{
+				Name:        fmt.Sprintf("%s extra_latency[%d]", prefix, i),
+				Probability: bucket.Probability,
+				Match:       match,
+				Actions:     []chaos.Action{{Type: "add_latency", DeltaMs: bucket.DeltaMs}},
+			})
+		}
+	}
+	return policies
+}
+
+// edgeSourceMatch builds a chaos match for the span the generator emits on
+// the source side of edge; see materializePair and edgeSpanName.
+func (c Config) edgeSourceMatch(edge EdgeConfig) chaos.Match {
+	from := c.Nodes[edge.From]
+	to := c.Nodes[edge.To]
+	match := chaos.Match{}
+
+	switch edge.Kind {
+	case EdgeKindInternal:
+		match.SpanName = nodeSpanName(edge.To, to)
+		match.SpanKinds = []string{"internal"}
+		match.ServiceName = c.serviceName(to.Service)
+	case EdgeKindProducerConsumer:
+		match.SpanName = nodeSpanName(edge.From, from) + " -> " + nodeSpanName(edge.To, to)
+		match.SpanKinds = []string{"producer"}
+		match.ServiceName = c.serviceName(from.Service)
+	default:
+		match.SpanName = nodeSpanName(edge.From, from) + " -> " + nodeSpanName(edge.To, to)
+		match.SpanKinds = []string{"client"}
+		match.ServiceName = c.serviceName(from.Service)
+	}
+	return match
+}
+
+func (c Config) serviceName(serviceID string) string {
+	value, ok := c.Services[serviceID].Resource["service.name"]
+	if !ok {
+		return ""
+	}
+	converted, err := value.ToAttributeValue()
+	if err != nil || converted.Type() != attribute.STRING {
+		return ""
+	}
+	return converted.AsString()
+}
+
+func nodeSpanName(id string, node NodeConfig) string {
+	if node.SpanName == "" {
+		return id
+	}
+	return node.SpanName
+}
@@ -0,0 +1,120 @@
+package scenario
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/chaos"
+	"go.opentelemetry.io/otel/codes"
+)
+
+const edgeChaosScenario = `{
+  "name": "edge-chaos",
+  "seed": 1,
+  "services": {
+    "web": { "resource": { "service.name": { "type": "string", "value": "web" } } },
+    "api": { "resource": { "service.name": { "type": "string", "value": "api" } } }
+  },
+  "nodes": {
+    "a": { "service": "web", "span_name": "GET /" },
+    "b": { "service": "api", "span_name": "GET /items" },
+    "c": { "service": "api", "span_name": "render" }
+  },
+  "root": "a",
+  "edges": [
+    {
+      "from": "a", "to": "b", "kind": "client_server", "repeat": 1, "duration_ms": 50,
+      "chaos": {
+        "error_rate": 1,
+        "extra_latency": [{ "probability": 1, "delta_ms": 200 }]
+      }
+    },
+    { "from": "b", "to": "c", "kind": "internal", "repeat": 1, "duration_ms": 10 }
+  ]
+}`
+
+func TestChaosPoliciesTargetEdgeSourceSpan(t *testing.T) {
+	cfg, err := DecodeJSON(strings.NewReader(edgeChaosScenario))
+	if err != nil {
+		t.Fatalf("DecodeJSON() error = %v", err)
+	}
+	policies := cfg.ChaosPolicies()
+	if len(policies) != 2 {
+		t.Fatalf("expected 2 policies, got %d", len(policies))
+	}
+	match := policies[0].Match
+	if match.SpanName != "GET / -> GET /items" || match.ServiceName != "web" || match.SpanKinds[0] != "client" {
+		t.Fatalf("unexpected match: %+v", match)
+	}
+
+	definition, err := cfg.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	spans, err := NewGenerator(definition).GenerateBatch(context.Background())
+	if err != nil {
+		t.Fatalf("GenerateBatch() error = %v", err)
+	}
+	engine, err := chaos.NewEngine(chaos.Config{Policies: policies})
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+	mutated := engine.Apply(spans, nil)
+
+	errored := 0
+	for i, span := range mutated {
+		if span.StatusCode != codes.Error {
+			if span.EndTime != spans[i].EndTime {
+				t.Fatalf("span %q should not be delayed", span.Name)
+			}
+			continue
+		}
+		errored++
+		if span.Name != "GET / -> GET /items" {
+			t.Fatalf("unexpected errored span %q", span.Name)
+		}
+		if span.StatusDescription != defaultEdgeErrorMessage {
+			t.Fatalf("unexpected status message %q", span.StatusDescription)
+		}
+		if got := span.EndTime.Sub(spans[i].EndTime); got != 200*time.Millisecond {
+			t.Fatalf("expected 200ms extra latency, got %s", got)
+		}
+	}
+	if errored != 1 {
+		t.Fatalf("expected exactly 1 errored span, got %d", errored)
+	}
+}
+
+func TestChaosPoliciesMatchInternalEdgeSpan(t *testing.T) {
+	cfg, err := DecodeJSON(strings.NewReader(edgeChaosScenario))
+	if err != nil {
+		t.Fatalf("DecodeJSON() error = %v", err)
+	}
+	cfg.Edges[0].Chaos = nil
+	cfg.Edges[1].Chaos = &EdgeChaosConfig{ErrorRate: 0.5, ErrorMessage: "render failed"}
+
+	policies := cfg.ChaosPolicies()
+	if len(policies) != 1 {
+		t.Fatalf("expected 1 policy, got %d", len(policies))
+	}
+	policy := policies[0]
+	if policy.Probability != 0.5 || policy.Actions[0].Message != "render failed" {
+		t.Fatalf("unexpected policy: %+v", policy)
+	}
+	if policy.Match.SpanName != "render" || policy.Match.ServiceName != "api" || policy.Match.SpanKinds[0] != "internal" {
+		t.Fatalf("unexpected match: %+v", policy.Match)
+	}
+}
+
+func TestDecodeJSONRejectsInvalidEdgeChaos(t *testing.T) {
+	input := strings.Replace(edgeChaosScenario, `"error_rate": 1`, `"error_rate": 1.5`, 1)
+	if _, err := DecodeJSON(strings.NewReader(input)); err == nil || !strings.Contains(err.Error(), "error_rate") {
+		t.Fatalf("expected error_rate validation error, got %v", err)
+	}
+	input = strings.Replace(edgeChaosScenario, `"delta_ms": 200`, `"delta_ms": 0`, 1)
+	if _, err := DecodeJSON(strings.NewReader(input)); err == nil || !strings.Contains(err.Error(), "delta_ms") {
+		t.Fatalf("expected delta_ms validation error, got %v", err)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"os"
 	"time"
+
+	"github.com/javiermolinar/tercios/internal/chaos"
 )
 
 func NewBatchGeneratorFromFiles(paths []string, strategy SelectionStrategy) (BatchGenerator, error) {
@@ -43,6 +45,48 @@ func NewBatchGeneratorFromFilesWithRunSeed(paths []string, strategy SelectionStr
 	return NewMultiGenerator(definitions, strategy, int64(selectionSeed))
 }
 
+// LoadChaosPolicies compiles the edge chaos blocks of every scenario file
+// into chaos policies, in file order.
+func LoadChaosPolicies(paths []string) ([]chaos.Policy, error) {
+	var policies []chaos.Policy
+	for _, path := range paths {
+		cfg, err := LoadFromJSON(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid scenario file %q: %w", path, err)
+		}
+		policies = append(policies, cfg.ChaosPolicies()...)
+	}
+	return policies, nil
+}
+
+// TargetTraceRate returns the traces per second that send the
+// spans_per_second targets of every scenario file: the sum of the targets
+// divided by the mean spans per trace of the mix. The rate strategy paces
+// runs by it.
+func TargetTraceRate(paths []string) (float64, error) {
+	definitions := make([]Definition, 0, len(paths))
+	for _, path := range paths {
+		cfg, err := LoadFromJSON(path)
+		if err != nil {
+			return 0, fmt.Errorf("invalid scenario file %q: %w", path, err)
+		}
+		definition, err := cfg.Build()
+		if err != nil {
+			return 0, fmt.Errorf("invalid scenario definition in %q: %w", path, err)
+		}
+		definitions = append(definitions, definition)
+	}
+	rates, err := traceRates(definitions)
+	if err != nil {
+		return 0, err
+	}
+	var total float64
+	for _, rate := range rates {
+		total += rate
+	}
+	return total, nil
+}
+
 func namespaceSeed(seed int64, runSalt uint64, index uint64) uint64 {
 	return splitmix64(uint64(seed) ^ runSalt ^ (index * 0x9e3779b97f4a7c15))
 }
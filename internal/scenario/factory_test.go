@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/javiermolinar/tercios/internal/model"
@@ -76,6 +77,36 @@ func TestNewBatchGeneratorFromFilesMultiple(t *testing.T) {
 	}
 }
 
+func TestTargetTraceRateSumsScenarioTargets(t *testing.T) {
+	dir := t.TempDir()
+	paths := make([]string, 0, 2)
+	for i, rate := range []string{"200", "100"} {
+		body := strings.Replace(minimalScenarioJSON("s"+strconv.Itoa(i), 1, "root"), `"seed"`, `"spans_per_second": `+rate+`, "seed"`, 1)
+		path := filepath.Join(dir, "scenario-"+strconv.Itoa(i)+".json")
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+		paths = append(paths, path)
+	}
+
+	rate, err := TargetTraceRate(paths)
+	if err != nil {
+		t.Fatalf("TargetTraceRate() error = %v", err)
+	}
+	// 300 spans/s of two-span traces.
+	if rate != 150 {
+		t.Fatalf("expected 150 traces/s, got %v", rate)
+	}
+
+	untargeted := filepath.Join(dir, "untargeted.json")
+	if err := os.WriteFile(untargeted, []byte(minimalScenarioJSON("u", 1, "root")), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if _, err := TargetTraceRate(append(paths, untargeted)); err == nil {
+		t.Fatalf("expected error for scenario without spans_per_second")
+	}
+}
+
 func TestNewBatchGeneratorFromFilesRejectsEmpty(t *testing.T) {
 	_, err := NewBatchGeneratorFromFiles(nil, SelectionStrategyRoundRobin)
 	if err == nil {
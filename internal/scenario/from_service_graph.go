@@ -0,0 +1,190 @@
+package scenario
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+
+	"github.com/javiermolinar/tercios/internal/servicegraph"
+)
+
+// maxServiceGraphRepeat bounds the repeat inferred from call rates, so one
+// noisy ratio cannot make a trace explode.
+const maxServiceGraphRepeat = 100
+
+// FromServiceGraph infers a scenario from service graph edges: one service
+// and one node per service name, and one edge per client/server pair.
+// Traffic from the virtual "user" client marks entry points, weighted by
+// its rate; services nobody calls are entry points too. The ratio between
+// an edge's rate and the rate of its client's spans becomes the repeat
+// count, or a probability below one call per span. Edge durations are the
+// mean server latencies minus the expected time of the server's own
+// calls, and failed requests become the error rate. The metrics carry no
+// span names, so nodes are named after their service.
+//
+// Scenarios are acyclic, so calls that close a cycle, including a service
+// calling itself, are left out; the returned warnings list them.
+func FromServiceGraph(name string, edges []servicegraph.Edge) (Config, []string, error) {
+	var warnings []string
+	entry := map[string]float64{}
+	outgoing := map[string][]servicegraph.Edge{}
+	incoming := map[string]int{}
+	services := map[string]bool{}
+	for _, edge := range edges {
+		if edge.ConnectionType == servicegraph.ConnectionVirtualNode && edge.Client == "user" {
+			entry[edge.Server] += edge.Rate
+			services[edge.Server] = true
+			continue
+		}
+		if edge.Client == edge.Server {
+			warnings = append(warnings, fmt.Sprintf("left out %s calling itself", edge.Client))
+			continue
+		}
+		outgoing[edge.Client] = append(outgoing[edge.Client], edge)
+		incoming[edge.Server]++
+		services[edge.Client] = true
+		services[edge.Server] = true
+	}
+	if len(services) == 0 {
+		return Config{}, nil, fmt.Errorf("service graph has no calls between services")
+	}
+	outRate := func(service string) float64 {
+		total := 0.0
+		for _, edge := range outgoing[service] {
+			total += edge.Rate
+		}
+		return total
+	}
+
+	var roots []string
+	for _, service := range slices.Sorted(maps.Keys(services)) {
+		if entry[service] > 0 || incoming[service] == 0 {
+			roots = append(roots, service)
+		}
+	}
+
+	// A depth-first walk from the roots keeps every edge but those back to
+	// a service on the current path. Services only reachable through a
+	// cycle get the busiest of them as an extra root.
+	const (
+		unvisited = iota
+		onPath
+		done
+	)
+	state := map[string]int{}
+	var kept []servicegraph.Edge
+	var visit func(service string)
+	visit = func(service string) {
+		state[service] = onPath
+		for _, edge := range outgoing[service] {
+			switch state[edge.Server] {
+			case onPath:
+				warnings = append(warnings, fmt.Sprintf("left out %s -> %s, which closes a cycle", edge.Client, edge.Server))
+				continue
+			case unvisited:
+				visit(edge.Server)
+			}
+			kept = append(kept, edge)
+		}
+		state[service] = done
+	}
+	for _, root := range roots {
+		visit(root)
+	}
+	for {
+		var next string
+		for _, service := range slices.Sorted(maps.Keys(services)) {
+			if state[service] == unvisited && (next == "" || outRate(service) > outRate(next)) {
+				next = service
+			}
+		}
+		if next == "" {
+			break
+		}
+		roots = append(roots, next)
+		visit(next)
+	}
+
+	// The span rate of a service is what it receives, or, for entry points
+	// nobody measured, the rate of its busiest call.
+	spanRate := map[string]float64{}
+	keptOutgoing := map[string][]int{}
+	for i, edge := range kept {
+		spanRate[edge.Server] += edge.Rate
+		keptOutgoing[edge.Client] = append(keptOutgoing[edge.Client], i)
+	}
+	for service := range services {
+		spanRate[service] += entry[service]
+		if spanRate[service] == 0 {
+			for _, edge := range outgoing[service] {
+				spanRate[service] = max(spanRate[service], edge.Rate)
+			}
+		}
+	}
+
+	cfg := Config{
+		Name:     name,
+		Seed:     1,
+		Services: map[string]ServiceConfig{},
+		Nodes:    map[string]NodeConfig{},
+	}
+	for service := range services {
+		cfg.Services[service] = ServiceConfig{Resource: map[string]TypedValue{
+			"service.name": {Type: ValueTypeString, Value: service},
+		}}
+		cfg.Nodes[service] = NodeConfig{Service: service, SpanName: service}
+	}
+	expected := make([]float64, len(kept))
+	for i, edge := range kept {
+		calls := edge.Rate / spanRate[edge.Client]
+		config := EdgeConfig{From: edge.Client, To: edge.Server, Kind: serviceGraphEdgeKind(edge.ConnectionType), Repeat: 1}
+		if calls >= 1 {
+			config.Repeat = min(int(math.Round(calls)), maxServiceGraphRepeat)
+			expected[i] = float64(config.Repeat)
+		} else {
+			config.Probability = max(math.Round(calls*1000)/1000, 0.001)
+			expected[i] = config.Probability
+		}
+		if edge.ErrorRate > 0 {
+			config.ErrorRate = math.Round(edge.ErrorRate*10000) / 10000
+		}
+		if edge.Latency == 0 {
+			warnings = append(warnings, fmt.Sprintf("no latency measured for %s -> %s; using 1ms", edge.Client, edge.Server))
+		}
+		cfg.Edges = append(cfg.Edges, config)
+	}
+	for i, edge := range kept {
+		own := float64(edge.Latency.Milliseconds())
+		for _, child := range keptOutgoing[edge.Server] {
+			own -= expected[child] * float64(kept[child].Latency.Milliseconds())
+		}
+		cfg.Edges[i].DurationMs = max(int64(math.Round(own)), 1)
+	}
+
+	if len(roots) == 1 {
+		cfg.Root = roots[0]
+	} else {
+		for _, root := range roots {
+			cfg.Roots = append(cfg.Roots, RootConfig{Node: root, Weight: math.Max(spanRate[root], 0.001)})
+		}
+	}
+	if len(cfg.Edges) == 0 {
+		return Config{}, warnings, fmt.Errorf("service graph has no calls between services")
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, warnings, fmt.Errorf("inferred scenario is invalid: %w", err)
+	}
+	return cfg, warnings, nil
+}
+
+func serviceGraphEdgeKind(connectionType string) EdgeKind {
+	switch connectionType {
+	case servicegraph.ConnectionDatabase:
+		return EdgeKindClientDatabase
+	case servicegraph.ConnectionMessaging:
+		return EdgeKindProducerConsumer
+	default:
+		return EdgeKindClientServer
+	}
+}
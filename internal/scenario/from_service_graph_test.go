@@ -0,0 +1,63 @@
+package scenario
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/servicegraph"
+)
+
+func TestFromServiceGraphInfersEdges(t *testing.T) {
+	edges := []servicegraph.Edge{
+		{Client: "user", Server: "frontend", ConnectionType: servicegraph.ConnectionVirtualNode, Rate: 10, Latency: 100 * time.Millisecond},
+		{Client: "frontend", Server: "checkout", Rate: 2, ErrorRate: 0.05, Latency: 60 * time.Millisecond},
+		{Client: "checkout", Server: "postgres", ConnectionType: servicegraph.ConnectionDatabase, Rate: 6, Latency: 10 * time.Millisecond},
+		{Client: "checkout", Server: "kafka", ConnectionType: servicegraph.ConnectionMessaging, Rate: 2, Latency: 5 * time.Millisecond},
+		{Client: "postgres", Server: "checkout", Rate: 1, Latency: time.Millisecond},
+		{Client: "checkout", Server: "checkout", Rate: 1, Latency: time.Millisecond},
+	}
+	cfg, warnings, err := FromServiceGraph("observed", edges)
+	if err != nil {
+		t.Fatalf("FromServiceGraph: %v", err)
+	}
+	if cfg.Root != "frontend" || len(cfg.Nodes) != 4 || len(cfg.Services) != 4 {
+		t.Fatalf("root=%q nodes=%d services=%d", cfg.Root, len(cfg.Nodes), len(cfg.Services))
+	}
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "checkout calling itself") || !strings.Contains(warnings[1], "postgres -> checkout") {
+		t.Fatalf("warnings = %q", warnings)
+	}
+	byTarget := map[string]EdgeConfig{}
+	for _, edge := range cfg.Edges {
+		byTarget[edge.To] = edge
+	}
+	if len(byTarget) != 3 {
+		t.Fatalf("edges = %+v", cfg.Edges)
+	}
+	if edge := byTarget["checkout"]; edge.Kind != EdgeKindClientServer || edge.Probability != 0.2 || edge.ErrorRate != 0.05 || edge.DurationMs != 25 {
+		t.Fatalf("checkout edge = %+v", edge)
+	}
+	if edge := byTarget["postgres"]; edge.Kind != EdgeKindClientDatabase || edge.Repeat != 3 || edge.DurationMs != 10 {
+		t.Fatalf("postgres edge = %+v", edge)
+	}
+	if edge := byTarget["kafka"]; edge.Kind != EdgeKindProducerConsumer || edge.Repeat != 1 || edge.Probability != 0 {
+		t.Fatalf("kafka edge = %+v", edge)
+	}
+}
+
+func TestFromServiceGraphWeightsSeveralRoots(t *testing.T) {
+	edges := []servicegraph.Edge{
+		{Client: "web", Server: "api", Rate: 3, Latency: 20 * time.Millisecond},
+		{Client: "worker", Server: "api", Rate: 1, Latency: 20 * time.Millisecond},
+	}
+	cfg, _, err := FromServiceGraph("observed", edges)
+	if err != nil {
+		t.Fatalf("FromServiceGraph: %v", err)
+	}
+	if cfg.Root != "" || len(cfg.Roots) != 2 || cfg.Roots[0].Node != "web" || cfg.Roots[0].Weight != 3 || cfg.Roots[1].Weight != 1 {
+		t.Fatalf("roots = %+v", cfg.Roots)
+	}
+	if _, _, err := FromServiceGraph("empty", nil); err == nil {
+		t.Fatal("expected an error for an empty service graph")
+	}
+}
@@ -0,0 +1,290 @@
+package scenario
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/model"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// FromTrace infers a scenario from the spans of one recorded trace: one
+// service per service.name, one node per span, and one edge per call.
+// Client and producer spans are paired with their server or consumer child
+// into client_server and producer_consumer edges; a client span with a
+// db.system attribute and no server child becomes a client_database edge
+// to a service named after the database. Sibling calls with the same
+// target collapse into one edge with a repeat count. Edge durations are
+// the recorded ones minus the time their subtree takes, so generated
+// traces come out close to the recording; span attributes, events and
+// resources are kept, links are not.
+func FromTrace(name string, spans []model.Span) (Config, error) {
+	if len(spans) == 0 {
+		return Config{}, fmt.Errorf("trace has no spans")
+	}
+	ids := make(map[oteltrace.SpanID]struct{}, len(spans))
+	for _, span := range spans {
+		ids[span.SpanID] = struct{}{}
+	}
+	b := &traceInference{
+		cfg: Config{
+			Name:     name,
+			Seed:     1,
+			Services: map[string]ServiceConfig{},
+			Nodes:    map[string]NodeConfig{},
+		},
+		children: map[oteltrace.SpanID][]model.Span{},
+		nodeIDs:  map[string]int{},
+	}
+	var roots []model.Span
+	for _, span := range spans {
+		if _, ok := ids[span.ParentSpanID]; span.ParentSpanID.IsValid() && ok {
+			b.children[span.ParentSpanID] = append(b.children[span.ParentSpanID], span)
+			continue
+		}
+		roots = append(roots, span)
+	}
+	for _, children := range b.children {
+		sort.SliceStable(children, func(i, j int) bool { return children[i].StartTime.Before(children[j].StartTime) })
+	}
+	// A trace with missing spans has several roots; the earliest is the
+	// trace's entry point and the others are left out.
+	sort.SliceStable(roots, func(i, j int) bool { return roots[i].StartTime.Before(roots[j].StartTime) })
+	root := roots[0]
+
+	b.cfg.Root = b.node(root, root.Name)
+	b.walk(b.cfg.Root, root)
+	if len(b.cfg.Edges) == 0 {
+		return Config{}, fmt.Errorf("trace root %q has no child spans to infer calls from", root.Name)
+	}
+	if err := b.cfg.Validate(); err != nil {
+		return Config{}, fmt.Errorf("inferred scenario is invalid: %w", err)
+	}
+	return b.cfg, nil
+}
+
+type traceInference struct {
+	cfg      Config
+	children map[oteltrace.SpanID][]model.Span
+	nodeIDs  map[string]int
+}
+
+// walk adds an edge per distinct call made from span, whose node is
+// nodeID, and returns the scenario time the calls take, in milliseconds,
+// following the generator's layout: calls run one after another with a
+// 1ms gap.
+func (b *traceInference) walk(nodeID string, span model.Span) int64 {
+	type call struct {
+		edge    int
+		subtree int64
+	}
+	var calls []call
+	seen := map[string]int{}
+	for _, child := range b.children[span.SpanID] {
+		kind, target := b.classify(child)
+		targetService := serviceName(target)
+		if kind == EdgeKindClientDatabase {
+			targetService = databaseService(child)
+		}
+		signature := string(kind) + "\x00" + targetService + "\x00" + target.Name
+		if index, ok := seen[signature]; ok {
+			b.cfg.Edges[calls[index].edge].Repeat++
+			continue
+		}
+
+		var toID string
+		if kind == EdgeKindClientDatabase {
+			toID = b.databaseNode(child, targetService)
+		} else {
+			toID = b.node(target, target.Name)
+		}
+		edge := EdgeConfig{
+			From:           nodeID,
+			To:             toID,
+			Kind:           kind,
+			Repeat:         1,
+			SpanAttributes: typedAttributes(child.Attributes, "service.name"),
+			SpanEvents:     eventConfigs(child.Events, child.StartTime),
+		}
+		b.cfg.Edges = append(b.cfg.Edges, edge)
+		index := len(b.cfg.Edges) - 1
+		subtree := int64(0)
+		if kind != EdgeKindClientDatabase {
+			subtree = b.walk(toID, target)
+		}
+		own := max(child.EndTime.Sub(child.StartTime).Milliseconds()-subtree, 1)
+		b.cfg.Edges[index].DurationMs = own
+		if kind != EdgeKindInternal && target.SpanID != child.SpanID {
+			// The server side is inset by the network latency on both sides.
+			latency := min(target.StartTime.Sub(child.StartTime), child.EndTime.Sub(target.EndTime)).Milliseconds()
+			if latency > 0 && 2*latency < own {
+				b.cfg.Edges[index].NetworkLatencyMs = latency
+			}
+		}
+		seen[signature] = len(calls)
+		calls = append(calls, call{edge: index, subtree: subtree})
+	}
+	total := int64(0)
+	for _, c := range calls {
+		edge := b.cfg.Edges[c.edge]
+		total += int64(edge.Repeat) * (edge.DurationMs + c.subtree + 1)
+	}
+	return total
+}
+
+// classify returns the edge kind of the call child makes and the span
+// whose node the call targets: the server or consumer child of a client or
+// producer span, or child itself.
+func (b *traceInference) classify(child model.Span) (EdgeKind, model.Span) {
+	var want oteltrace.SpanKind
+	var kind EdgeKind
+	switch child.Kind {
+	case oteltrace.SpanKindClient:
+		want, kind = oteltrace.SpanKindServer, EdgeKindClientServer
+	case oteltrace.SpanKindProducer:
+		want, kind = oteltrace.SpanKindConsumer, EdgeKindProducerConsumer
+	default:
+		return EdgeKindInternal, child
+	}
+	for _, grandchild := range b.children[child.SpanID] {
+		if grandchild.Kind == want {
+			return kind, grandchild
+		}
+	}
+	if child.Kind == oteltrace.SpanKindClient && databaseSystem(child) != "" {
+		return EdgeKindClientDatabase, child
+	}
+	// An uninstrumented callee: the call stays a single internal span.
+	return EdgeKindInternal, child
+}
+
+// node adds a node for span, and its service when new, and returns the
+// node ID: the span name, numbered when the name is already taken.
+func (b *traceInference) node(span model.Span, spanName string) string {
+	service := serviceName(span)
+	if _, ok := b.cfg.Services[service]; !ok {
+		resource := typedAttributes(span.ResourceAttributes)
+		if resource == nil {
+			resource = map[string]TypedValue{}
+		}
+		resource["service.name"] = TypedValue{Type: ValueTypeString, Value: service}
+		b.cfg.Services[service] = ServiceConfig{Resource: resource}
+	}
+	id := spanName
+	if b.nodeIDs[spanName]++; b.nodeIDs[spanName] > 1 {
+		id = spanName + "#" + strconv.Itoa(b.nodeIDs[spanName])
+	}
+	b.cfg.Nodes[id] = NodeConfig{Service: service, SpanName: spanName}
+	return id
+}
+
+func (b *traceInference) databaseNode(client model.Span, service string) string {
+	if _, ok := b.cfg.Services[service]; !ok {
+		b.cfg.Services[service] = ServiceConfig{Resource: map[string]TypedValue{
+			"service.name": {Type: ValueTypeString, Value: service},
+			"db.system":    {Type: ValueTypeString, Value: databaseSystem(client)},
+		}}
+	}
+	target := model.Span{Name: client.Name, ResourceAttributes: map[string]attribute.Value{"service.name": attribute.StringValue(service)}}
+	return b.node(target, client.Name)
+}
+
+func serviceName(span model.Span) string {
+	if name, ok := span.ResourceAttributes["service.name"]; ok && name.Emit() != "" {
+		return name.Emit()
+	}
+	return "unknown_service"
+}
+
+func databaseSystem(span model.Span) string {
+	for _, key := range []string{"db.system", "db.system.name"} {
+		if value, ok := span.Attributes[key]; ok {
+			return value.Emit()
+		}
+	}
+	return ""
+}
+
+// databaseService names the database a client span calls, preferring the
+// peer the span reports over the database system.
+func databaseService(span model.Span) string {
+	for _, key := range []string{"peer.service", "server.address", "net.peer.name"} {
+		if value, ok := span.Attributes[key]; ok && value.Emit() != "" {
+			return value.Emit()
+		}
+	}
+	return databaseSystem(span)
+}
+
+// eventConfigs converts the recorded events of a span starting at start,
+// keeping when each happened.
+func eventConfigs(events []model.Event, start time.Time) []EventConfig {
+	if len(events) == 0 {
+		return nil
+	}
+	out := make([]EventConfig, 0, len(events))
+	for _, event := range events {
+		config := EventConfig{Name: event.Name, Attributes: typedAttributes(model.AttributesToMap(event.Attributes))}
+		if offset := event.Time.Sub(start).Milliseconds(); !event.Time.IsZero() && offset >= 0 {
+			config.OffsetMs = &offset
+		}
+		out = append(out, config)
+	}
+	return out
+}
+
+// typedAttributes converts recorded attributes into scenario values,
+// leaving out the skipped keys.
+func typedAttributes(attrs map[string]attribute.Value, skip ...string) map[string]TypedValue {
+	out := map[string]TypedValue{}
+	for key, value := range attrs {
+		skipped := false
+		for _, s := range skip {
+			skipped = skipped || key == s
+		}
+		if skipped {
+			continue
+		}
+		if typed, ok := typedValue(value); ok {
+			out[key] = typed
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func typedValue(value attribute.Value) (TypedValue, bool) {
+	switch value.Type() {
+	case attribute.STRING:
+		return TypedValue{Type: ValueTypeString, Value: value.AsString()}, true
+	case attribute.INT64:
+		return TypedValue{Type: ValueTypeInt, Value: value.AsInt64()}, true
+	case attribute.FLOAT64:
+		return TypedValue{Type: ValueTypeFloat, Value: value.AsFloat64()}, true
+	case attribute.BOOL:
+		return TypedValue{Type: ValueTypeBool, Value: value.AsBool()}, true
+	case attribute.STRINGSLICE:
+		return TypedValue{Type: ValueTypeStringArray, Value: toAnySlice(value.AsStringSlice())}, true
+	case attribute.INT64SLICE:
+		return TypedValue{Type: ValueTypeIntArray, Value: toAnySlice(value.AsInt64Slice())}, true
+	case attribute.FLOAT64SLICE:
+		return TypedValue{Type: ValueTypeFloatArray, Value: toAnySlice(value.AsFloat64Slice())}, true
+	case attribute.BOOLSLICE:
+		return TypedValue{Type: ValueTypeBoolArray, Value: toAnySlice(value.AsBoolSlice())}, true
+	default:
+		return TypedValue{}, false
+	}
+}
+
+func toAnySlice[T any](values []T) []any {
+	out := make([]any, len(values))
+	for i, value := range values {
+		out[i] = value
+	}
+	return out
+}
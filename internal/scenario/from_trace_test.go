@@ -0,0 +1,88 @@
+package scenario
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/model"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+func TestFromTraceInfersEdges(t *testing.T) {
+	base := time.Unix(1700000000, 0).UTC()
+	ms := func(n int) time.Time { return base.Add(time.Duration(n) * time.Millisecond) }
+	resource := func(service string) map[string]attribute.Value {
+		return map[string]attribute.Value{"service.name": attribute.StringValue(service)}
+	}
+	span := func(id, parent byte, name, service string, kind oteltrace.SpanKind, start, end int) model.Span {
+		s := model.Span{
+			SpanID:             oteltrace.SpanID{7: id},
+			Name:               name,
+			Kind:               kind,
+			StartTime:          ms(start),
+			EndTime:            ms(end),
+			Attributes:         map[string]attribute.Value{},
+			ResourceAttributes: resource(service),
+		}
+		if parent != 0 {
+			s.ParentSpanID = oteltrace.SpanID{7: parent}
+		}
+		return s
+	}
+	query := func(id byte, start int) model.Span {
+		s := span(id, 4, "SELECT orders", "orders", oteltrace.SpanKindClient, start, start+10)
+		s.Attributes["db.system"] = attribute.StringValue("postgresql")
+		return s
+	}
+	spans := []model.Span{
+		span(1, 0, "GET /checkout", "frontend", oteltrace.SpanKindServer, 0, 100),
+		span(2, 1, "GET /orders", "frontend", oteltrace.SpanKindClient, 10, 80),
+		span(4, 2, "GET /orders", "orders", oteltrace.SpanKindServer, 15, 75),
+		query(5, 20),
+		query(6, 40),
+	}
+	spans[1].Attributes["http.request.method"] = attribute.StringValue("GET")
+
+	cfg, err := FromTrace("checkout", spans)
+	if err != nil {
+		t.Fatalf("FromTrace() error = %v", err)
+	}
+	if cfg.Root != "GET /checkout" || len(cfg.Services) != 3 || len(cfg.Edges) != 2 {
+		t.Fatalf("unexpected scenario %+v", cfg)
+	}
+	call, db := cfg.Edges[0], cfg.Edges[1]
+	if call.Kind != EdgeKindClientServer || call.From != "GET /checkout" || cfg.Nodes[call.To].Service != "orders" {
+		t.Fatalf("unexpected call edge %+v", call)
+	}
+	if call.NetworkLatencyMs != 5 || call.SpanAttributes["http.request.method"].Value != "GET" {
+		t.Fatalf("expected the recorded latency and attributes, got %+v", call)
+	}
+	if db.Kind != EdgeKindClientDatabase || db.Repeat != 2 || db.DurationMs != 10 || cfg.Nodes[db.To].Service != "postgresql" {
+		t.Fatalf("expected the two queries collapsed into one database edge, got %+v", db)
+	}
+
+	definition, err := cfg.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	batch, err := NewGenerator(definition).GenerateBatch(context.Background())
+	if err != nil {
+		t.Fatalf("GenerateBatch() error = %v", err)
+	}
+	// Root, the client/server pair, and a client/database pair per query.
+	if len(batch) != 7 {
+		t.Fatalf("expected 7 generated spans, got %d", len(batch))
+	}
+}
+
+func TestFromTraceRejectsEmptyTraces(t *testing.T) {
+	if _, err := FromTrace("empty", nil); err == nil {
+		t.Fatalf("expected error for no spans")
+	}
+	lone := model.Span{SpanID: oteltrace.SpanID{7: 1}, Name: "lonely", StartTime: time.Unix(1, 0), EndTime: time.Unix(2, 0)}
+	if _, err := FromTrace("lone", []model.Span{lone}); err == nil {
+		t.Fatalf("expected error for a root without children")
+	}
+}
@@ -2,11 +2,13 @@ package scenario
 
 import (
 	"context"
-	"encoding/binary"
 	"fmt"
+	"slices"
 	"sync/atomic"
 	"time"
 
+	"github.com/javiermolinar/tercios/internal/audit"
+	"github.com/javiermolinar/tercios/internal/idgen"
 	"github.com/javiermolinar/tercios/internal/model"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
@@ -34,6 +36,9 @@ type Generator struct {
 	outgoing        map[string][]Edge
 	subtreeDuration map[string]time.Duration
 	counter         atomic.Uint64
+	ids             idgen.Generator
+	// history is nil unless a span link points to previous traces.
+	history *traceHistory
 }
 
 // NextChildren returns the outgoing edges of parentNodeID as ChildSpecs in
@@ -66,11 +71,28 @@ func NewGenerator(definition Definition) *Generator {
 	for _, edge := range definition.Edges {
 		outgoing[edge.From] = append(outgoing[edge.From], edge)
 	}
-	return &Generator{
+	g := &Generator{
 		definition:      definition,
 		outgoing:        outgoing,
-		subtreeDuration: computeSubtreeDurations(definition.Root, outgoing),
+		subtreeDuration: computeSubtreeDurations(definition.rootNodes(), outgoing),
+		ids:             idgen.Seeded{},
 	}
+	previousTraces := 0
+	for _, edge := range definition.Edges {
+		for _, link := range edge.SpanLinks {
+			previousTraces = max(previousTraces, link.PreviousTraces)
+		}
+	}
+	if previousTraces > 0 {
+		g.history = newTraceHistory(previousTraces)
+	}
+	return g
+}
+
+// SetIDGenerator makes the generator draw trace and span IDs from ids
+// instead of the seeded default. It must be called before generating.
+func (g *Generator) SetIDGenerator(ids idgen.Generator) {
+	g.ids = ids
 }
 
 // stepDuration returns the scenario time one repeat of child consumes
@@ -87,7 +109,7 @@ func (g *Generator) stepDuration(child ChildSpec) time.Duration {
 
 // computeSubtreeDurations returns, per node, the total scenario-time
 // consumed by its outgoing subtree. Same recurrence as estimateDuration.
-func computeSubtreeDurations(rootID string, outgoing map[string][]Edge) map[string]time.Duration {
+func computeSubtreeDurations(roots []string, outgoing map[string][]Edge) map[string]time.Duration {
 	out := make(map[string]time.Duration, len(outgoing))
 	var walk func(id string) time.Duration
 	walk = func(id string) time.Duration {
@@ -99,37 +121,81 @@ func computeSubtreeDurations(rootID string, outgoing map[string][]Edge) map[stri
 			out[id] = 0
 			return 0
 		}
-		total := time.Duration(0)
+		steps := make([]time.Duration, 0, len(edges))
+		weighted := make([]bool, 0, len(edges))
+		parallel := make([]bool, 0, len(edges))
 		for _, edge := range edges {
+			subtree := walk(edge.To)
+			if edge.Async {
+				// Async calls may outlive the source span.
+				continue
+			}
 			d := edge.Duration
 			if d <= 0 {
 				d = 1 * time.Millisecond
 			}
-			step := d + walk(edge.To) + 1*time.Millisecond
-			total += time.Duration(edge.Repeat) * step
+			steps = append(steps, time.Duration(edge.Repeat)*(d+subtree+1*time.Millisecond))
+			weighted = append(weighted, edge.Weight > 0)
+			parallel = append(parallel, edge.Parallel)
 		}
+		total := siblingsDuration(steps, weighted, parallel)
 		out[id] = total
 		return total
 	}
-	walk(rootID)
+	for _, root := range roots {
+		walk(root)
+	}
 	return out
 }
 
+// siblingsDuration returns the time one node's child edges take given
+// each edge's full step, laid out as pushChildren does: weighted edges
+// share the slot of the longest one where the first is declared, and a
+// parallel edge runs alongside the sibling before it instead of after.
+// Config.Validate uses it on milliseconds, the generator on durations.
+func siblingsDuration[T ~int64](steps []T, weighted, parallel []bool) T {
+	var choice T
+	for i, step := range steps {
+		if weighted[i] {
+			choice = max(choice, step)
+		}
+	}
+	var total, group T
+	choiceSeen := false
+	for i, step := range steps {
+		if weighted[i] {
+			if choiceSeen {
+				continue
+			}
+			choiceSeen = true
+			step = choice
+		}
+		if parallel[i] {
+			group = max(group, step)
+			continue
+		}
+		total += group
+		group = step
+	}
+	return total + group
+}
+
 // GenerateBatch produces all spans of one trace by constructing a walker
 // and draining its heap immediately (no wall-clock pacing). The streaming
 // exporter uses the same walker via NewStreamingWalker, popping one emit
 // at a time and waiting until each emit's DueAt before forwarding to OTLP.
-func (g *Generator) GenerateBatch(_ context.Context) ([]model.Span, error) {
+func (g *Generator) GenerateBatch(ctx context.Context) ([]model.Span, error) {
 	if g == nil {
 		return nil, fmt.Errorf("scenario generator not configured")
 	}
 	if len(g.definition.Nodes) == 0 {
 		return nil, fmt.Errorf("scenario definition has no nodes")
 	}
-	w, err := g.newWalker(time.Now().UTC())
+	w, err := g.newWalker(ctx, time.Now().UTC())
 	if err != nil {
 		return nil, err
 	}
+	audit.FromContext(ctx).SetGenerator("scenario:" + g.definition.Name)
 	return w.drain(), nil
 }
 
@@ -146,55 +212,39 @@ type walker struct {
 // newWalker constructs a walker for one trace nominally rooted at
 // startedAt. Consumes one sequence number from g.counter so successive
 // walkers from the same Generator emit distinct traces. Pre-allocates
-// the root SpanID and records it in nodeSpans (so descendant links to
+// the root SpanID, picks the root node from it when the scenario has
+// several, and records it in nodeSpans (so descendant links to
 // the root resolve correctly even though the root span itself is
 // materialized last) and seeds the heap with the root sentinel plus
 // root's direct children, siblings staggered by stepDuration so heap-pop
 // order matches the iterative walker's sequential DFS pre-order.
-func (g *Generator) newWalker(startedAt time.Time) (*walker, error) {
-	if _, ok := g.definition.Nodes[g.definition.Root]; !ok {
-		return nil, fmt.Errorf("root node %q not found", g.definition.Root)
+func (g *Generator) newWalker(ctx context.Context, startedAt time.Time) (*walker, error) {
+	for _, root := range g.definition.rootNodes() {
+		if _, ok := g.definition.Nodes[root]; !ok {
+			return nil, fmt.Errorf("root node %q not found", root)
+		}
 	}
 
 	sequence := g.counter.Add(1)
 	trace := &traceState{
-		TraceID:   traceIDFromSeed(g.definition.Seed, sequence),
+		TraceID:   g.ids.TraceID(ctx, uint64(g.definition.Seed), sequence),
 		StartedAt: startedAt,
 		NodeSpans: make(map[string]oteltrace.SpanID),
-		IDState:   newSpanIDState(g.definition.Seed, sequence),
+		IDState:   newSpanIDState(g.ids, g.definition.Seed, sequence),
 	}
 
-	estimated := g.subtreeDuration[g.definition.Root]
+	rootSpanID := trace.IDState.next()
+	trace.Root = g.pickRoot(rootSpanID)
+	trace.NodeSpans[trace.Root] = rootSpanID
+
+	estimated := g.subtreeDuration[trace.Root]
 	if estimated <= 0 {
 		estimated = 100 * time.Millisecond
 	}
 
-	rootSpanID := trace.IDState.next()
-	trace.NodeSpans[g.definition.Root] = rootSpanID
-
 	w := &walker{g: g, trace: trace, heap: &emitHeap{}}
 
-	// Push root's direct children. Each child's DueAt = childBase + effDur
-	// so the heap key is the child's end_time. base advances by the full
-	// step (D + subtree + 1ms) * Repeat between siblings so the next
-	// sibling fires only after every earlier sibling's full subtree drains.
-	base := startedAt.Add(1 * time.Millisecond)
-	for _, child := range g.NextChildren(g.definition.Root) {
-		cd := child.Edge.Duration
-		if cd <= 0 {
-			cd = 1 * time.Millisecond
-		}
-		effDur := cd + g.subtreeDuration[child.Edge.To]
-		w.heap.PushEmit(&pendingEmit{
-			DueAt:            base.Add(effDur),
-			Trace:            trace,
-			Child:            child,
-			ParentSpanID:     rootSpanID,
-			RemainingRepeats: child.Edge.Repeat,
-		})
-		trace.InFlight++
-		base = base.Add(time.Duration(child.Edge.Repeat) * g.stepDuration(child))
-	}
+	w.pushChildren(trace.Root, rootSpanID, startedAt.Add(1*time.Millisecond))
 
 	// Root sentinel last. Its DueAt = startedAt + subtreeDuration[root]
 	// equals the largest descendant end_time; the IsRoot tiebreaker in
@@ -208,6 +258,162 @@ func (g *Generator) newWalker(startedAt time.Time) (*walker, error) {
 	return w, nil
 }
 
+// pushChildren schedules the child edges of one span of nodeID. Each
+// child's DueAt = start + effDur so the heap key is the child's end_time.
+// Siblings start one after another, a full step (D + subtree + 1ms) *
+// Repeat apart, so the next sibling fires only after every earlier
+// sibling's full subtree drains. A parallel edge instead starts with the
+// sibling before it, and the sibling after the group waits for the
+// longest member to finish. Async edges take no part in this layout: they
+// start StartOffset after base and may end after the parent span.
+//
+// An edge with a probability is followed only when its draw for this
+// parent span hits; its slot stays as idle time otherwise, as does the
+// part of the slot an edge with a repeat distribution leaves unused when
+// it draws fewer than its most calls. Weighted edges
+// are alternatives: exactly one of them is followed, and they share one
+// slot, as long as the longest of them, where the first one is declared.
+// Draws are keyed on the parent span ID, so they follow the scenario seed.
+func (w *walker) pushChildren(nodeID string, parentSpanID oteltrace.SpanID, base time.Time) {
+	children := w.g.NextChildren(nodeID)
+	groupStart, groupEnd := base, base
+	choiceScheduled := false
+	for i, child := range children {
+		slot := time.Duration(child.Edge.Repeat) * w.g.stepDuration(child)
+		parallel := child.Edge.Parallel
+		if child.Edge.Async {
+			if follows(child.Edge, parentSpanID, i) {
+				w.pushChild(child, parentSpanID, i, base.Add(child.Edge.StartOffset))
+			}
+			continue
+		}
+		if child.Edge.Weight > 0 {
+			if choiceScheduled {
+				continue
+			}
+			choiceScheduled = true
+			slot = w.g.choiceSlot(children)
+			child = children[pickWeighted(children, parentSpanID)]
+		}
+		start := groupStart
+		if !parallel {
+			start = groupEnd
+			groupStart = start
+		}
+		if end := start.Add(slot); end.After(groupEnd) {
+			groupEnd = end
+		}
+		if child.Edge.Weight <= 0 && !follows(child.Edge, parentSpanID, i) {
+			continue
+		}
+		w.pushChild(child, parentSpanID, i, start)
+	}
+}
+
+// follows draws whether the span parentSpanID follows its i-th edge,
+// which always holds for edges without a probability.
+func follows(edge Edge, parentSpanID oteltrace.SpanID, i int) bool {
+	if edge.Probability <= 0 || edge.Probability >= 1 {
+		return true
+	}
+	roll := float64(callRandom(parentSpanID, edgeProbabilitySalt+uint64(i)<<8)>>11) / (1 << 53)
+	return roll < edge.Probability
+}
+
+// pushChild schedules every repeat of child, the i-th edge of its parent,
+// the first starting at start. With a repeat distribution the count is
+// drawn for the parent span and may be zero.
+func (w *walker) pushChild(child ChildSpec, parentSpanID oteltrace.SpanID, i int, start time.Time) {
+	repeats := child.Edge.Repeat
+	if child.Edge.Repeats != nil {
+		repeats = child.Edge.Repeats.draw(parentSpanID, i)
+	}
+	if repeats == 0 {
+		return
+	}
+	cd := child.Edge.Duration
+	if cd <= 0 {
+		cd = 1 * time.Millisecond
+	}
+	effDur := cd + w.g.subtreeDuration[child.Edge.To]
+	w.heap.PushEmit(&pendingEmit{
+		DueAt:            start.Add(effDur),
+		Trace:            w.trace,
+		Child:            child,
+		ParentSpanID:     parentSpanID,
+		RemainingRepeats: repeats,
+	})
+	w.trace.InFlight++
+}
+
+// Salts for the per-edge draws of pushChildren, pushChild and Edge.fails,
+// and for the root choice of pickRoot; see callRandom.
+const (
+	edgeProbabilitySalt = 7
+	edgeChoiceSalt      = 8
+	edgeErrorSalt       = 9
+	rootChoiceSalt      = 10
+	edgeRepeatSalt      = 11
+)
+
+const edgeErrorDescription = "injected by scenario edge error_rate"
+
+// choiceSlot returns the time the weighted edges among children share:
+// the longest of their steps, since only one of them fires.
+func (g *Generator) choiceSlot(children []ChildSpec) time.Duration {
+	var slot time.Duration
+	for _, child := range children {
+		if child.Edge.Weight > 0 {
+			slot = max(slot, time.Duration(child.Edge.Repeat)*g.stepDuration(child))
+		}
+	}
+	return slot
+}
+
+// pickRoot returns the root node of the trace whose root span is
+// rootSpanID, drawn by weight when the scenario has several.
+func (g *Generator) pickRoot(rootSpanID oteltrace.SpanID) string {
+	roots := g.definition.Roots
+	if len(roots) == 0 {
+		return g.definition.Root
+	}
+	var total float64
+	for _, root := range roots {
+		total += root.Weight
+	}
+	roll := float64(callRandom(rootSpanID, rootChoiceSalt)>>11) / (1 << 53) * total
+	for _, root := range roots {
+		if roll < root.Weight {
+			return root.Node
+		}
+		roll -= root.Weight
+	}
+	// Rounding can leave roll just past the total.
+	return roots[len(roots)-1].Node
+}
+
+// pickWeighted returns the index of the weighted edge among children that
+// the span parentSpanID follows.
+func pickWeighted(children []ChildSpec, parentSpanID oteltrace.SpanID) int {
+	var total float64
+	for _, child := range children {
+		total += child.Edge.Weight
+	}
+	roll := float64(callRandom(parentSpanID, edgeChoiceSalt)>>11) / (1 << 53) * total
+	last := -1
+	for i, child := range children {
+		if child.Edge.Weight <= 0 {
+			continue
+		}
+		last = i
+		if roll < child.Edge.Weight {
+			return i
+		}
+		roll -= child.Edge.Weight
+	}
+	return last
+}
+
 // done reports whether the walker has emitted every span of its trace.
 func (w *walker) done() bool { return w.heap.Len() == 0 }
 
@@ -228,18 +434,17 @@ func (w *walker) popOne() []model.Span {
 	emit := w.heap.PopMin()
 
 	if emit.IsRoot {
-		rootNode := w.g.definition.Nodes[w.g.definition.Root]
-		rootSpanID := w.trace.NodeSpans[w.g.definition.Root]
+		rootNode := w.g.definition.Nodes[w.trace.Root]
+		rootSpanID := w.trace.NodeSpans[w.trace.Root]
 		duration := emit.DueAt.Sub(w.trace.StartedAt)
-		rootSpan := w.g.newSpan(w.trace.TraceID, rootSpanID, oteltrace.SpanID{}, rootNode, oteltrace.SpanKindInternal, w.trace.StartedAt, duration, nil, nil, nil)
-		w.trace.InFlight--
+		rootSpan := w.g.newSpan(w.trace.TraceID, rootSpanID, oteltrace.SpanID{}, rootNode, oteltrace.SpanKindInternal, w.trace.StartedAt, duration, nil, rootNode.Events, nil)
+		w.release()
 		return []model.Span{rootSpan}
 	}
 
-	// Lazy-resolve events/links on first pop; reused across repeats.
+	// Lazy-resolve links on first pop; reused across repeats.
 	if !emit.Resolved {
-		emit.Events = resolveEvents(emit.Child.Edge.SpanEvents)
-		emit.Links = resolveLinks(w.trace.TraceID, emit.Child.Edge.SpanLinks, w.trace.NodeSpans)
+		emit.Links = resolveLinks(w.trace.TraceID, emit.Child.Edge.SpanLinks, w.trace.NodeSpans, w.g.history)
 		emit.Resolved = true
 	}
 
@@ -250,7 +455,7 @@ func (w *walker) popOne() []model.Span {
 	effDur := d + w.g.subtreeDuration[emit.Child.Edge.To]
 	start := emit.DueAt.Add(-effDur)
 
-	result := w.g.materializeChild(emit.Child, w.trace.TraceID, emit.ParentSpanID, start, w.trace.IDState, emit.Events, emit.Links)
+	result := w.g.materializeChild(emit.Child, w.trace.TraceID, emit.ParentSpanID, start, w.trace.IDState, emit.Child.Edge.SpanEvents, emit.Links)
 	w.trace.NodeSpans[emit.Child.Edge.To] = result.TargetSpanID
 
 	// Children attach to the target-side span (server/consumer/db span
@@ -259,53 +464,42 @@ func (w *walker) popOne() []model.Span {
 	// target.start + 1ms = start + latency + 1ms. For Internal edges and
 	// latency==0 pair edges this reduces to start + 1ms.
 	childBase := start.Add(emit.Child.Edge.NetworkLatency).Add(1 * time.Millisecond)
-	for _, child := range w.g.NextChildren(emit.Child.Edge.To) {
-		cd := child.Edge.Duration
-		if cd <= 0 {
-			cd = 1 * time.Millisecond
-		}
-		childEffDur := cd + w.g.subtreeDuration[child.Edge.To]
-		w.heap.PushEmit(&pendingEmit{
-			DueAt:            childBase.Add(childEffDur),
-			Trace:            w.trace,
-			Child:            child,
-			ParentSpanID:     result.TargetSpanID,
-			RemainingRepeats: child.Edge.Repeat,
-		})
-		w.trace.InFlight++
-		childBase = childBase.Add(time.Duration(child.Edge.Repeat) * w.g.stepDuration(child))
-	}
+	w.pushChildren(emit.Child.Edge.To, result.TargetSpanID, childBase)
 
 	emit.RemainingRepeats--
 	if emit.RemainingRepeats > 0 {
 		emit.DueAt = emit.DueAt.Add(w.g.stepDuration(emit.Child))
 		w.heap.PushEmit(emit)
 	} else {
-		w.trace.InFlight--
+		w.release()
 	}
 
 	return result.Spans
 }
 
+// release marks one pending emit of the trace as finished. Once none are
+// left the trace is complete, and it is recorded for links from later
+// traces.
+func (w *walker) release() {
+	w.trace.InFlight--
+	if w.trace.InFlight == 0 && w.g.history != nil {
+		w.g.history.add(pastTrace{TraceID: w.trace.TraceID, NodeSpans: w.trace.NodeSpans})
+	}
+}
+
 type spanIDState struct {
+	ids    idgen.Generator
 	seed   uint64
 	seq    uint64
 	nextID atomic.Uint64
 }
 
-func newSpanIDState(seed int64, sequence uint64) *spanIDState {
-	return &spanIDState{seed: uint64(seed), seq: sequence}
+func newSpanIDState(ids idgen.Generator, seed int64, sequence uint64) *spanIDState {
+	return &spanIDState{ids: ids, seed: uint64(seed), seq: sequence}
 }
 
 func (s *spanIDState) next() oteltrace.SpanID {
-	index := s.nextID.Add(1)
-	v := splitmix64(s.seed ^ s.seq ^ index)
-	if v == 0 {
-		v = 1
-	}
-	var id oteltrace.SpanID
-	binary.BigEndian.PutUint64(id[:], v)
-	return id
+	return s.ids.SpanID(s.seed, s.seq, s.nextID.Add(1))
 }
 
 // materializedChild is the result of expanding one ChildSpec traversal
@@ -327,7 +521,7 @@ func (g *Generator) materializeChild(
 	parentSpanID oteltrace.SpanID,
 	start time.Time,
 	idState *spanIDState,
-	events []model.Event,
+	events []EventDef,
 	links []model.Link,
 ) materializedChild {
 	edge := child.Edge
@@ -340,29 +534,95 @@ func (g *Generator) materializeChild(
 	// contains its descendants.
 	effDur := duration + g.subtreeDuration[edge.To]
 
+	var result materializedChild
 	switch edge.Kind {
 	case EdgeKindClientServer:
-		return g.materializePair(child, traceID, parentSpanID, start, effDur, idState, events, links, oteltrace.SpanKindClient, oteltrace.SpanKindServer)
+		result = g.materializePair(child, traceID, parentSpanID, start, effDur, idState, events, links, oteltrace.SpanKindClient, oteltrace.SpanKindServer)
 	case EdgeKindProducerConsumer:
-		return g.materializePair(child, traceID, parentSpanID, start, effDur, idState, events, links, oteltrace.SpanKindProducer, oteltrace.SpanKindConsumer)
+		result = g.materializePair(child, traceID, parentSpanID, start, effDur, idState, events, links, oteltrace.SpanKindProducer, oteltrace.SpanKindConsumer)
 	case EdgeKindClientDatabase:
-		return g.materializePair(child, traceID, parentSpanID, start, effDur, idState, events, links, oteltrace.SpanKindClient, oteltrace.SpanKindServer)
+		result = g.materializePair(child, traceID, parentSpanID, start, effDur, idState, events, links, oteltrace.SpanKindClient, oteltrace.SpanKindServer)
 	case EdgeKindInternal:
 		internalID := idState.next()
-		internalSpan := g.newSpan(traceID, internalID, parentSpanID, child.TargetNode, oteltrace.SpanKindInternal, start, effDur, edge.SpanAttributes, events, links)
-		return materializedChild{
+		if edge.Code != nil {
+			result = g.materializeCodeFrames(child, traceID, parentSpanID, internalID, start, effDur, idState, events, links)
+			break
+		}
+		internalSpan := g.newSpan(traceID, internalID, parentSpanID, child.TargetNode, oteltrace.SpanKindInternal, start, effDur, edge.SpanAttributes, slices.Concat(events, child.TargetNode.Events), links)
+		result = materializedChild{
 			Spans:        []model.Span{internalSpan},
 			TargetSpanID: internalID,
 		}
+	default:
+		// Unknown edge kinds are rejected by Config.Validate; reaching here
+		// would indicate a programming error rather than user input.
+		return materializedChild{TargetSpanID: parentSpanID}
+	}
+	if len(result.Spans) > 0 && edge.fails(result.Spans[0].SpanID) {
+		failCall(edge, result.Spans)
+	}
+	return result
+}
+
+// fails reports whether the call whose first span is callID is one of the
+// edge's injected failures, drawn from ErrorRate.
+func (e Edge) fails(callID oteltrace.SpanID) bool {
+	if e.ErrorRate <= 0 {
+		return false
 	}
+	return float64(callRandom(callID, edgeErrorSalt)>>11)/(1<<53) < e.ErrorRate
+}
 
-	// Unknown edge kinds are rejected by Config.Validate; reaching here
-	// would indicate a programming error rather than user input.
-	return materializedChild{TargetSpanID: parentSpanID}
+// failCall marks every span of one call as an error. On client_server
+// edges the spans also carry the edge's ErrorStatus as their HTTP status
+// code, which overrides any code drawn from http_status_codes.
+func failCall(edge Edge, spans []model.Span) {
+	for i := range spans {
+		span := &spans[i]
+		span.StatusCode = codes.Error
+		span.StatusDescription = edgeErrorDescription
+		if edge.Kind == EdgeKindClientServer {
+			span.Attributes[statusCodeAttribute] = attribute.Int64Value(edge.ErrorStatus)
+			span.StatusDescription = fmt.Sprintf("HTTP %d", edge.ErrorStatus)
+		}
+	}
+}
+
+// materializeCodeFrames emits an internal edge with a code profile as a
+// chain of nested internal spans, one per configured function. The first
+// frame is the edge's span and keeps its attributes, events and links;
+// deeper frames are named after their function. All frames share the
+// edge's interval, and children attach to the innermost frame.
+func (g *Generator) materializeCodeFrames(
+	child ChildSpec,
+	traceID oteltrace.TraceID,
+	parentSpanID oteltrace.SpanID,
+	callID oteltrace.SpanID,
+	start time.Time,
+	effDur time.Duration,
+	idState *spanIDState,
+	events []EventDef,
+	links []model.Link,
+) materializedChild {
+	edge := child.Edge
+	spans := make([]model.Span, 0, edge.Code.frames())
+	spans = append(spans, g.newSpan(traceID, callID, parentSpanID, child.TargetNode, oteltrace.SpanKindInternal, start, effDur, edge.Code.frameAttributes(edge.SpanAttributes, callID, 0), slices.Concat(events, child.TargetNode.Events), links))
+
+	innermost := callID
+	for depth := 1; depth < edge.Code.frames(); depth++ {
+		frameID := idState.next()
+		frame := g.newSpan(traceID, frameID, innermost, child.TargetNode, oteltrace.SpanKindInternal, start, effDur, edge.Code.frameAttributes(nil, callID, depth), nil, nil)
+		frame.Name = edge.Code.config.Functions[depth]
+		spans = append(spans, frame)
+		innermost = frameID
+	}
+	return materializedChild{Spans: spans, TargetSpanID: innermost}
 }
 
 // materializePair emits the source/target two-span pattern for non-Internal
-// edges. Events and links go on the source span only. With
+// edges. Events and links go on the source span only; attributes drawn
+// per call (see callAttributes) go on both, and messaging attributes are
+// split between the producer and consumer side. With
 // edge.NetworkLatency > 0, the target span is inset by NetworkLatency on
 // both sides of the source span's interval; children attach to the
 // (narrower) target.
@@ -373,7 +633,7 @@ func (g *Generator) materializePair(
 	start time.Time,
 	effDur time.Duration,
 	idState *spanIDState,
-	events []model.Event,
+	events []EventDef,
 	links []model.Link,
 	firstKind oteltrace.SpanKind,
 	secondKind oteltrace.SpanKind,
@@ -381,13 +641,18 @@ func (g *Generator) materializePair(
 	edge := child.Edge
 
 	firstID := idState.next()
-	firstSpan := g.newSpan(traceID, firstID, parentSpanID, child.SourceNode, firstKind, start, effDur, edge.SpanAttributes, events, links)
+	attrs := callAttributes(edge, firstID)
+	targetAttrs := attrs
+	if edge.Messaging != nil {
+		attrs, targetAttrs = edge.Messaging.attributes(attrs, firstID)
+	}
+	firstSpan := g.newSpan(traceID, firstID, parentSpanID, child.SourceNode, firstKind, start, effDur, attrs, events, links)
 	firstSpan.Name = edgeSpanName(child.SourceNode, child.TargetNode)
 
 	secondStart := start.Add(edge.NetworkLatency)
 	secondDur := effDur - 2*edge.NetworkLatency
 	secondID := idState.next()
-	secondSpan := g.newSpan(traceID, secondID, firstID, child.TargetNode, secondKind, secondStart, secondDur, edge.SpanAttributes, nil, nil)
+	secondSpan := g.newSpan(traceID, secondID, firstID, child.TargetNode, secondKind, secondStart, secondDur, targetAttrs, child.TargetNode.Events, nil)
 
 	return materializedChild{
 		Spans:        []model.Span{firstSpan, secondSpan},
@@ -395,6 +660,25 @@ func (g *Generator) materializePair(
 	}
 }
 
+// callAttributes returns the span attributes for one traversal of edge,
+// including the values drawn for this call from its status code
+// distribution and DB query templates. edge.SpanAttributes is shared
+// between calls and never mutated.
+func callAttributes(edge Edge, callID oteltrace.SpanID) map[string]attribute.Value {
+	code, hasCode := edge.StatusCodes.pick(callID)
+	if !hasCode && edge.DBQuery == nil {
+		return edge.SpanAttributes
+	}
+	attrs := cloneAttributeValues(edge.SpanAttributes)
+	if hasCode {
+		attrs[statusCodeAttribute] = attribute.Int64Value(code)
+	}
+	if edge.DBQuery != nil {
+		edge.DBQuery.apply(attrs, callID)
+	}
+	return attrs
+}
+
 func (g *Generator) newSpan(
 	traceID oteltrace.TraceID,
 	spanID oteltrace.SpanID,
@@ -404,11 +688,15 @@ func (g *Generator) newSpan(
 	start time.Time,
 	duration time.Duration,
 	edgeAttrs map[string]attribute.Value,
-	events []model.Event,
+	events []EventDef,
 	links []model.Link,
 ) model.Span {
 	service := g.definition.Services[node.Service]
-	resourceAttrs := cloneAttributeValues(service.ResourceAttributes)
+	resource := service.ResourceAttributes
+	if len(service.Instances) > 0 {
+		resource = service.Instances[pickInstance(traceID, service.ID, len(service.Instances))]
+	}
+	resourceAttrs := cloneAttributeValues(resource)
 	attrs := map[string]attribute.Value{}
 	if serviceName, ok := resourceAttrs["service.name"]; ok {
 		attrs["service.name"] = serviceName
@@ -437,62 +725,67 @@ func (g *Generator) newSpan(
 		Attributes:         attrs,
 		ResourceAttributes: resourceAttrs,
 		StatusCode:         codes.Ok,
-		Events:             eventsWithDefaultTime(events, start.Add(duration/2)),
+		Events:             spanEvents(events, start, duration),
 		Links:              links,
 	}
+	g.definition.HTTPStatus.Apply(&span)
 	return span
 }
 
-func eventsWithDefaultTime(events []model.Event, defaultTime time.Time) []model.Event {
+// spanEvents places events on a span of duration starting at start: at
+// their offset, capped at the span's end, or mid-span without one.
+func spanEvents(events []EventDef, start time.Time, duration time.Duration) []model.Event {
 	if len(events) == 0 {
 		return nil
 	}
 	out := make([]model.Event, len(events))
-	copy(out, events)
-	for i := range out {
-		if out[i].Time.IsZero() {
-			out[i].Time = defaultTime
+	for i, event := range events {
+		at := duration / 2
+		if event.Offset != nil {
+			at = min(*event.Offset, duration)
 		}
+		out[i] = model.Event{Name: event.Name, Time: start.Add(at), Attributes: event.Attributes}
 	}
 	return out
 }
 
-func resolveEvents(defs []EventDef) []model.Event {
-	if len(defs) == 0 {
-		return nil
-	}
-	out := make([]model.Event, len(defs))
-	for i, def := range defs {
-		out[i] = model.Event{
-			Name:       def.Name,
-			Attributes: def.Attributes,
-		}
-	}
-	return out
-}
-
-func resolveLinks(traceID oteltrace.TraceID, defs []LinkDef, nodeSpans map[string]oteltrace.SpanID) []model.Link {
+// resolveLinks turns link definitions into links to spans of the current
+// trace, or of the previous traces in history for links with
+// PreviousTraces. Links to a node with no span are dropped.
+func resolveLinks(traceID oteltrace.TraceID, defs []LinkDef, nodeSpans map[string]oteltrace.SpanID, history *traceHistory) []model.Link {
 	if len(defs) == 0 {
 		return nil
 	}
 	out := make([]model.Link, 0, len(defs))
 	for _, def := range defs {
+		if def.PreviousTraces > 0 {
+			for _, past := range history.recent(def.PreviousTraces) {
+				if spanID, ok := past.NodeSpans[def.Node]; ok {
+					out = append(out, newLink(past.TraceID, spanID, def.Attributes))
+				}
+			}
+			continue
+		}
 		spanID, ok := nodeSpans[def.Node]
 		if !ok {
 			continue
 		}
-		out = append(out, model.Link{
-			SpanContext: oteltrace.NewSpanContext(oteltrace.SpanContextConfig{
-				TraceID:    traceID,
-				SpanID:     spanID,
-				TraceFlags: oteltrace.FlagsSampled,
-			}),
-			Attributes: def.Attributes,
-		})
+		out = append(out, newLink(traceID, spanID, def.Attributes))
 	}
 	return out
 }
 
+func newLink(traceID oteltrace.TraceID, spanID oteltrace.SpanID, attrs []attribute.KeyValue) model.Link {
+	return model.Link{
+		SpanContext: oteltrace.NewSpanContext(oteltrace.SpanContextConfig{
+			TraceID:    traceID,
+			SpanID:     spanID,
+			TraceFlags: oteltrace.FlagsSampled,
+		}),
+		Attributes: attrs,
+	}
+}
+
 func edgeSpanName(from Node, to Node) string {
 	fromName := from.SpanName
 	if fromName == "" {
@@ -548,19 +841,6 @@ func estimateDuration(nodeID string, outgoing map[string][]Edge) time.Duration {
 	return value
 }
 
-func traceIDFromSeed(seed int64, sequence uint64) oteltrace.TraceID {
-	a := splitmix64(uint64(seed) ^ sequence)
-	b := splitmix64(a ^ 0x9e3779b97f4a7c15)
-	var id oteltrace.TraceID
-	binary.BigEndian.PutUint64(id[0:8], a)
-	binary.BigEndian.PutUint64(id[8:16], b)
-	if id.IsValid() {
-		return id
-	}
-	id[15] = 1
-	return id
-}
-
 func splitmix64(x uint64) uint64 {
 	x += 0x9e3779b97f4a7c15
 	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
@@ -2,11 +2,15 @@ package scenario
 
 import (
 	"context"
+	"math"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/javiermolinar/tercios/internal/idgen"
 	"github.com/javiermolinar/tercios/internal/model"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	oteltrace "go.opentelemetry.io/otel/trace"
 )
 
@@ -78,6 +82,29 @@ func TestGeneratorDeterministicTraceIDForFirstBatch(t *testing.T) {
 	}
 }
 
+func TestGeneratorUsesIDGenerator(t *testing.T) {
+	generator := NewGenerator(testDefinition(t))
+	generator.SetIDGenerator(idgen.Sequential{})
+
+	spans, err := generator.GenerateBatch(context.Background())
+	if err != nil {
+		t.Fatalf("GenerateBatch() error = %v", err)
+	}
+	want := idgen.Sequential{}.TraceID(context.Background(), 42, 1)
+	spanIDs := map[oteltrace.SpanID]bool{}
+	for _, span := range spans {
+		if span.TraceID != want {
+			t.Fatalf("expected sequential trace ID %s, got %s", want, span.TraceID)
+		}
+		spanIDs[span.SpanID] = true
+	}
+	for index := uint64(1); index <= uint64(len(spans)); index++ {
+		if id := (idgen.Sequential{}).SpanID(42, 1, index); !spanIDs[id] {
+			t.Fatalf("expected sequential span ID %s among %d spans", id, len(spans))
+		}
+	}
+}
+
 func testDefinition(t testing.TB) Definition {
 	t.Helper()
 	cfg := Config{
@@ -177,6 +204,135 @@ func TestGeneratorEmitsEventsAndLinks(t *testing.T) {
 	}
 }
 
+func TestGeneratorEmitsNodeEventsAtOffsets(t *testing.T) {
+	offset := func(ms int64) *int64 { return &ms }
+	cfg := Config{
+		Name: "node-events",
+		Seed: 5,
+		Services: map[string]ServiceConfig{
+			"api":   {Resource: map[string]TypedValue{"service.name": {Type: ValueTypeString, Value: "api"}}},
+			"users": {Resource: map[string]TypedValue{"service.name": {Type: ValueTypeString, Value: "users"}}},
+		},
+		Nodes: map[string]NodeConfig{
+			"root": {Service: "api", SpanName: "GET /profile", Events: []EventConfig{{Name: "request.accepted", OffsetMs: offset(0)}}},
+			"users": {Service: "users", SpanName: "GET /users/{id}", Events: []EventConfig{
+				{Name: "cache.miss", OffsetMs: offset(2), Attributes: map[string]TypedValue{"cache.key": {Type: ValueTypeString, Value: "user:1"}}},
+				{Name: "retry.attempt", OffsetMs: offset(500)},
+			}},
+		},
+		Root: "root",
+		Edges: []EdgeConfig{
+			{
+				From: "root", To: "users", Kind: EdgeKindClientServer, Repeat: 1, DurationMs: 10,
+				SpanEvents: []EventConfig{{Name: "request.sent"}},
+			},
+		},
+	}
+	definition, err := cfg.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	spans, err := NewGenerator(definition).GenerateBatch(context.Background())
+	if err != nil {
+		t.Fatalf("GenerateBatch() error = %v", err)
+	}
+
+	events := map[string]model.Event{}
+	owners := map[string]model.Span{}
+	for _, span := range spans {
+		for _, event := range span.Events {
+			events[event.Name] = event
+			owners[event.Name] = span
+		}
+	}
+	if len(events) != 4 {
+		t.Fatalf("expected 4 distinct events, got %v", events)
+	}
+	for name, want := range map[string]struct {
+		kind oteltrace.SpanKind
+		at   func(model.Span) time.Time
+	}{
+		"request.accepted": {oteltrace.SpanKindInternal, func(s model.Span) time.Time { return s.StartTime }},
+		"request.sent":     {oteltrace.SpanKindClient, func(s model.Span) time.Time { return s.StartTime.Add(s.EndTime.Sub(s.StartTime) / 2) }},
+		"cache.miss":       {oteltrace.SpanKindServer, func(s model.Span) time.Time { return s.StartTime.Add(2 * time.Millisecond) }},
+		// Offsets past the span's end are capped at it.
+		"retry.attempt": {oteltrace.SpanKindServer, func(s model.Span) time.Time { return s.EndTime }},
+	} {
+		owner := owners[name]
+		if owner.Kind != want.kind {
+			t.Fatalf("%q is on a %s span, want %s", name, owner.Kind, want.kind)
+		}
+		if got := events[name].Time; !got.Equal(want.at(owner)) {
+			t.Fatalf("%q at %s, want %s", name, got, want.at(owner))
+		}
+	}
+	if len(events["cache.miss"].Attributes) != 1 {
+		t.Fatalf("expected cache.miss attributes, got %v", events["cache.miss"].Attributes)
+	}
+}
+
+func TestGeneratorLinksSpansOfPreviousTraces(t *testing.T) {
+	cfg := Config{
+		Name: "batch-consumer",
+		Seed: 9,
+		Services: map[string]ServiceConfig{
+			"api":    {Resource: map[string]TypedValue{"service.name": {Type: ValueTypeString, Value: "api"}}},
+			"worker": {Resource: map[string]TypedValue{"service.name": {Type: ValueTypeString, Value: "worker"}}},
+		},
+		Nodes: map[string]NodeConfig{
+			"request": {Service: "api", SpanName: "POST /orders"},
+			"save":    {Service: "api", SpanName: "save order"},
+			"batch":   {Service: "worker", SpanName: "process batch"},
+		},
+		Root: "request",
+		Edges: []EdgeConfig{
+			{From: "request", To: "save", Kind: EdgeKindInternal, Repeat: 1, DurationMs: 5},
+			{
+				From: "request", To: "batch", Kind: EdgeKindInternal, Repeat: 1, DurationMs: 5,
+				SpanLinks: []LinkConfig{{Node: "save", PreviousTraces: 2}},
+			},
+		},
+	}
+	definition, err := cfg.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	generator := NewGenerator(definition)
+
+	type saved struct {
+		traceID oteltrace.TraceID
+		spanID  oteltrace.SpanID
+	}
+	var history []saved
+	for i := range 4 {
+		spans, err := generator.GenerateBatch(context.Background())
+		if err != nil {
+			t.Fatalf("GenerateBatch() error = %v", err)
+		}
+		var save, batch model.Span
+		for _, span := range spans {
+			switch span.Name {
+			case "save order":
+				save = span
+			case "process batch":
+				batch = span
+			}
+		}
+
+		// Newest first, at most two, none to the trace itself.
+		if want := min(i, 2); len(batch.Links) != want {
+			t.Fatalf("trace %d: %d links, want %d", i, len(batch.Links), want)
+		}
+		for j, link := range batch.Links {
+			want := history[len(history)-1-j]
+			if link.SpanContext.TraceID() != want.traceID || link.SpanContext.SpanID() != want.spanID {
+				t.Fatalf("trace %d link %d points to %s/%s, want %s/%s", i, j, link.SpanContext.TraceID(), link.SpanContext.SpanID(), want.traceID, want.spanID)
+			}
+		}
+		history = append(history, saved{traceID: save.TraceID, spanID: save.SpanID})
+	}
+}
+
 // TestGeneratorParentContainsChild: every span's [Start, End] is
 // contained in its parent's, matching real OTel semantics.
 func TestGeneratorParentContainsChild(t *testing.T) {
@@ -533,3 +689,502 @@ func BenchmarkGenerateBatch(b *testing.B) {
 		}
 	}
 }
+
+func TestGeneratorFollowsEdgesByProbabilityAndWeight(t *testing.T) {
+	cfg := Config{
+		Name: "optional-edges",
+		Seed: 7,
+		Services: map[string]ServiceConfig{
+			"api": {Resource: map[string]TypedValue{"service.name": {Type: ValueTypeString, Value: "api"}}},
+		},
+		Nodes: map[string]NodeConfig{
+			"root":  {Service: "api", SpanName: "GET /item"},
+			"miss":  {Service: "api", SpanName: "load from db"},
+			"hit":   {Service: "api", SpanName: "cache hit"},
+			"fetch": {Service: "api", SpanName: "cache fetch"},
+		},
+		Root: "root",
+		Edges: []EdgeConfig{
+			{From: "root", To: "miss", Kind: EdgeKindInternal, Repeat: 1, DurationMs: 5, Probability: 0.25},
+			{From: "root", To: "hit", Kind: EdgeKindInternal, Repeat: 1, DurationMs: 5, Weight: 3},
+			{From: "root", To: "fetch", Kind: EdgeKindInternal, Repeat: 1, DurationMs: 20, Weight: 1},
+		},
+	}
+	definition, err := cfg.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if got, want := definition.SpansPerTrace(), 2.25; got != want {
+		t.Fatalf("SpansPerTrace() = %v, want %v", got, want)
+	}
+
+	const traces = 2000
+	generator := NewGenerator(definition)
+	counts := map[string]int{}
+	for range traces {
+		spans, err := generator.GenerateBatch(context.Background())
+		if err != nil {
+			t.Fatalf("GenerateBatch() error = %v", err)
+		}
+		choices := 0
+		for _, span := range spans {
+			counts[span.Name]++
+			if span.Name == "cache hit" || span.Name == "cache fetch" {
+				choices++
+			}
+		}
+		if choices != 1 {
+			t.Fatalf("expected exactly one weighted edge per trace, got %d", choices)
+		}
+	}
+
+	for name, want := range map[string]float64{"load from db": 0.25, "cache hit": 0.75, "cache fetch": 0.25} {
+		if got := float64(counts[name]) / traces; got < want-0.05 || got > want+0.05 {
+			t.Fatalf("%q followed in %.3f of traces, want ~%.2f", name, got, want)
+		}
+	}
+}
+
+func TestGeneratorInjectsEdgeErrors(t *testing.T) {
+	cfg := Config{
+		Name: "edge-errors",
+		Seed: 3,
+		Services: map[string]ServiceConfig{
+			"api":   {Resource: map[string]TypedValue{"service.name": {Type: ValueTypeString, Value: "api"}}},
+			"users": {Resource: map[string]TypedValue{"service.name": {Type: ValueTypeString, Value: "users"}}},
+		},
+		Nodes: map[string]NodeConfig{
+			"root":  {Service: "api", SpanName: "GET /profile"},
+			"users": {Service: "users", SpanName: "GET /users"},
+			"parse": {Service: "users", SpanName: "parse"},
+		},
+		Root: "root",
+		Edges: []EdgeConfig{
+			{From: "root", To: "users", Kind: EdgeKindClientServer, Repeat: 1, DurationMs: 10, ErrorRate: 0.3, ErrorStatus: 503,
+				HTTPStatusCodes: []StatusCodeWeight{{Code: 200, Weight: 1}}},
+			{From: "users", To: "parse", Kind: EdgeKindInternal, Repeat: 1, DurationMs: 2, ErrorRate: 1},
+		},
+	}
+	definition, err := cfg.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	const traces = 2000
+	generator := NewGenerator(definition)
+	failed := 0
+	for range traces {
+		spans, err := generator.GenerateBatch(context.Background())
+		if err != nil {
+			t.Fatalf("GenerateBatch() error = %v", err)
+		}
+		httpErrors := 0
+		for _, span := range spans {
+			switch span.Name {
+			case "parse":
+				if span.StatusCode != codes.Error {
+					t.Fatalf("expected the error_rate 1 edge to always fail, got %v", span.StatusCode)
+				}
+			case "GET /profile":
+				if span.StatusCode == codes.Error {
+					t.Fatalf("expected the root span to keep its status")
+				}
+			default:
+				code := span.Attributes[statusCodeAttribute].AsInt64()
+				if span.StatusCode == codes.Error {
+					httpErrors++
+					if code != 503 || span.StatusDescription != "HTTP 503" {
+						t.Fatalf("expected failed call to carry 503, got %d %q", code, span.StatusDescription)
+					}
+				} else if code != 200 {
+					t.Fatalf("expected successful call to keep its drawn code, got %d", code)
+				}
+			}
+		}
+		switch httpErrors {
+		case 0:
+		case 2:
+			failed++
+		default:
+			t.Fatalf("expected client and server span to fail together, got %d error spans", httpErrors)
+		}
+	}
+	if got := float64(failed) / traces; got < 0.25 || got > 0.35 {
+		t.Fatalf("expected ~30%% failed calls, got %.3f", got)
+	}
+}
+
+func TestGeneratorRunsParallelEdgesConcurrently(t *testing.T) {
+	cfg := Config{
+		Name: "fan-out",
+		Seed: 5,
+		Services: map[string]ServiceConfig{
+			"api": {Resource: map[string]TypedValue{"service.name": {Type: ValueTypeString, Value: "api"}}},
+		},
+		Nodes: map[string]NodeConfig{
+			"root":    {Service: "api", SpanName: "GET /page"},
+			"user":    {Service: "api", SpanName: "load user"},
+			"cart":    {Service: "api", SpanName: "load cart"},
+			"render":  {Service: "api", SpanName: "render"},
+			"compose": {Service: "api", SpanName: "compose"},
+		},
+		Root: "root",
+		Edges: []EdgeConfig{
+			{From: "root", To: "user", Kind: EdgeKindInternal, Repeat: 1, DurationMs: 20},
+			{From: "root", To: "cart", Kind: EdgeKindInternal, Repeat: 1, DurationMs: 10, Parallel: true},
+			{From: "root", To: "render", Kind: EdgeKindInternal, Repeat: 1, DurationMs: 5},
+			{From: "root", To: "compose", Kind: EdgeKindInternal, Repeat: 1, DurationMs: 5, Parallel: true},
+		},
+	}
+	definition, err := cfg.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	spans, err := NewGenerator(definition).GenerateBatch(context.Background())
+	if err != nil {
+		t.Fatalf("GenerateBatch() error = %v", err)
+	}
+	byName := make(map[string]model.Span, len(spans))
+	for _, span := range spans {
+		byName[span.Name] = span
+	}
+
+	user, cart, render, compose := byName["load user"], byName["load cart"], byName["render"], byName["compose"]
+	if !cart.StartTime.Equal(user.StartTime) {
+		t.Fatalf("parallel edge starts at %s, want %s with its sibling", cart.StartTime, user.StartTime)
+	}
+	if render.StartTime.Before(user.EndTime) {
+		t.Fatalf("sequential edge starts at %s before the parallel group ends at %s", render.StartTime, user.EndTime)
+	}
+	if !compose.StartTime.Equal(render.StartTime) {
+		t.Fatalf("parallel edge starts at %s, want %s with its sibling", compose.StartTime, render.StartTime)
+	}
+
+	// Two groups of 21ms and 6ms, not four edges back to back.
+	root := byName["GET /page"]
+	if got, want := root.EndTime.Sub(root.StartTime), 27*time.Millisecond; got != want {
+		t.Fatalf("root duration = %s, want %s", got, want)
+	}
+}
+
+func TestGeneratorAsyncEdgesOutliveTheirParent(t *testing.T) {
+	cfg := Config{
+		Name: "fire-and-forget",
+		Seed: 9,
+		Services: map[string]ServiceConfig{
+			"api":    {Resource: map[string]TypedValue{"service.name": {Type: ValueTypeString, Value: "api"}}},
+			"worker": {Resource: map[string]TypedValue{"service.name": {Type: ValueTypeString, Value: "worker"}}},
+		},
+		Nodes: map[string]NodeConfig{
+			"root":    {Service: "api", SpanName: "POST /order"},
+			"save":    {Service: "api", SpanName: "save order"},
+			"publish": {Service: "worker", SpanName: "order created"},
+		},
+		Root: "root",
+		Edges: []EdgeConfig{
+			{From: "root", To: "save", Kind: EdgeKindInternal, Repeat: 1, DurationMs: 10},
+			{From: "root", To: "publish", Kind: EdgeKindProducerConsumer, Repeat: 1, DurationMs: 50, Async: true, StartOffsetMs: 5},
+		},
+	}
+	definition, err := cfg.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	spans, err := NewGenerator(definition).GenerateBatch(context.Background())
+	if err != nil {
+		t.Fatalf("GenerateBatch() error = %v", err)
+	}
+	if len(spans) != 4 {
+		t.Fatalf("expected 4 spans, got %d", len(spans))
+	}
+	var root, save, producer model.Span
+	for _, span := range spans {
+		switch {
+		case span.Name == "POST /order":
+			root = span
+		case span.Name == "save order":
+			save = span
+		case span.Kind == oteltrace.SpanKindProducer:
+			producer = span
+		}
+	}
+
+	// The root only waits for the synchronous call.
+	if got, want := root.EndTime.Sub(root.StartTime), 11*time.Millisecond; got != want {
+		t.Fatalf("root duration = %s, want %s", got, want)
+	}
+	if got, want := producer.StartTime, root.StartTime.Add(6*time.Millisecond); !got.Equal(want) {
+		t.Fatalf("async span starts at %s, want %s", got, want)
+	}
+	if !producer.StartTime.Before(save.EndTime) {
+		t.Fatalf("async span starts at %s, want it to overlap the call ending at %s", producer.StartTime, save.EndTime)
+	}
+	if !producer.EndTime.After(root.EndTime) {
+		t.Fatalf("async span ends at %s, want it to outlive its parent ending at %s", producer.EndTime, root.EndTime)
+	}
+}
+
+func TestGeneratorMixesWeightedRoots(t *testing.T) {
+	cfg := Config{
+		Name: "posts",
+		Seed: 11,
+		Services: map[string]ServiceConfig{
+			"api": {Resource: map[string]TypedValue{"service.name": {Type: ValueTypeString, Value: "api"}}},
+		},
+		Nodes: map[string]NodeConfig{
+			"list":   {Service: "api", SpanName: "GET /posts"},
+			"create": {Service: "api", SpanName: "POST /posts"},
+			"delete": {Service: "api", SpanName: "DELETE /posts"},
+			"db":     {Service: "api", SpanName: "query"},
+		},
+		Roots: []RootConfig{{Node: "list", Weight: 80}, {Node: "create", Weight: 15}, {Node: "delete", Weight: 5}},
+		Edges: []EdgeConfig{
+			{From: "list", To: "db", Kind: EdgeKindInternal, Repeat: 1, DurationMs: 5},
+			{From: "create", To: "db", Kind: EdgeKindInternal, Repeat: 2, DurationMs: 5},
+		},
+	}
+	definition, err := cfg.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if got, want := definition.SpansPerTrace(), 0.8*2+0.15*3+0.05*1; math.Abs(got-want) > 1e-9 {
+		t.Fatalf("SpansPerTrace() = %v, want %v", got, want)
+	}
+
+	const traces = 2000
+	generator := NewGenerator(definition)
+	counts := map[string]int{}
+	for range traces {
+		spans, err := generator.GenerateBatch(context.Background())
+		if err != nil {
+			t.Fatalf("GenerateBatch() error = %v", err)
+		}
+		var roots []model.Span
+		for _, span := range spans {
+			if !span.ParentSpanID.IsValid() {
+				roots = append(roots, span)
+			}
+		}
+		if len(roots) != 1 {
+			t.Fatalf("expected one root span per trace, got %d", len(roots))
+		}
+		counts[roots[0].Name]++
+		if got, want := len(spans), map[string]int{"GET /posts": 2, "POST /posts": 3, "DELETE /posts": 1}[roots[0].Name]; got != want {
+			t.Fatalf("%q trace has %d spans, want %d", roots[0].Name, got, want)
+		}
+	}
+
+	for name, want := range map[string]float64{"GET /posts": 0.80, "POST /posts": 0.15, "DELETE /posts": 0.05} {
+		if got := float64(counts[name]) / traces; got < want-0.03 || got > want+0.03 {
+			t.Fatalf("%q started %.3f of traces, want ~%.2f", name, got, want)
+		}
+	}
+}
+
+func TestGeneratorFillsSemconvAttributesByEdgeKind(t *testing.T) {
+	str := func(value string) TypedValue { return TypedValue{Type: ValueTypeString, Value: value} }
+	cfg := Config{
+		Name:    "semconv",
+		Seed:    3,
+		Semconv: true,
+		Services: map[string]ServiceConfig{
+			"web":    {Resource: map[string]TypedValue{"service.name": str("web")}},
+			"orders": {Resource: map[string]TypedValue{"service.name": str("orders")}},
+			"db": {
+				Resource: map[string]TypedValue{"service.name": str("orders-db")},
+				Semconv:  map[string]TypedValue{"db.system": str("postgresql")},
+			},
+			"worker": {Resource: map[string]TypedValue{"service.name": str("worker")}},
+		},
+		Nodes: map[string]NodeConfig{
+			"web":    {Service: "web", SpanName: "GET /checkout"},
+			"orders": {Service: "orders", SpanName: "POST /orders"},
+			"db":     {Service: "db", SpanName: "INSERT orders"},
+			"worker": {Service: "worker", SpanName: "order.created process"},
+		},
+		Root: "web",
+		Edges: []EdgeConfig{
+			{From: "web", To: "orders", Kind: EdgeKindClientServer, Repeat: 1, DurationMs: 20,
+				SpanAttributes: map[string]TypedValue{"server.port": {Type: ValueTypeInt, Value: 443}}},
+			{From: "orders", To: "db", Kind: EdgeKindClientDatabase, Repeat: 1, DurationMs: 5},
+			{From: "orders", To: "worker", Kind: EdgeKindProducerConsumer, Repeat: 1, DurationMs: 5},
+		},
+	}
+	definition, err := cfg.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	spans, err := NewGenerator(definition).GenerateBatch(context.Background())
+	if err != nil {
+		t.Fatalf("GenerateBatch() error = %v", err)
+	}
+
+	byKind := map[oteltrace.SpanKind][]model.Span{}
+	for _, span := range spans {
+		// The root span and the database side of the query are left out.
+		if span.ParentSpanID.IsValid() && span.Name != "INSERT orders" {
+			byKind[span.Kind] = append(byKind[span.Kind], span)
+		}
+	}
+	want := map[oteltrace.SpanKind]map[string]string{
+		oteltrace.SpanKindServer: {
+			"http.request.method": "POST", "http.route": "/orders", "server.address": "orders",
+			"server.port": "443", "http.response.status_code": "200",
+		},
+		oteltrace.SpanKindProducer: {
+			"messaging.system": "kafka", "messaging.destination.name": "worker", "messaging.operation.type": "publish",
+		},
+		oteltrace.SpanKindConsumer: {
+			"messaging.system": "kafka", "messaging.destination.name": "worker", "messaging.operation.type": "process",
+		},
+	}
+	for kind, attrs := range want {
+		if len(byKind[kind]) != 1 {
+			t.Fatalf("expected one %s span, got %d", kind, len(byKind[kind]))
+		}
+		span := byKind[kind][0]
+		for key, value := range attrs {
+			if got := span.Attributes[key].Emit(); got != value {
+				t.Fatalf("%s span %s = %q, want %q", kind, key, got, value)
+			}
+		}
+	}
+	var dbClient model.Span
+	for _, span := range byKind[oteltrace.SpanKindClient] {
+		if span.Attributes["db.system"].Emit() != "" {
+			dbClient = span
+		}
+	}
+	for key, value := range map[string]string{
+		"db.system": "postgresql", "db.name": "orders-db", "db.operation": "INSERT", "db.statement": "INSERT orders",
+	} {
+		if got := dbClient.Attributes[key].Emit(); got != value {
+			t.Fatalf("database client span %s = %q, want %q", key, got, value)
+		}
+	}
+}
+
+func TestGeneratorSpreadsTracesOverPresetInstances(t *testing.T) {
+	cfg := Config{
+		Name: "instances",
+		Seed: 9,
+		Services: map[string]ServiceConfig{
+			"api": {
+				Resource:       map[string]TypedValue{"service.name": {Type: ValueTypeString, Value: "checkout-api"}},
+				ResourcePreset: &ResourcePresetConfig{Type: "k8s", Instances: 3, Namespace: "shop", Nodes: 2},
+			},
+			"db": {
+				Resource:       map[string]TypedValue{"service.name": {Type: ValueTypeString, Value: "orders-db"}, "host.name": {Type: ValueTypeString, Value: "db.internal"}},
+				ResourcePreset: &ResourcePresetConfig{Type: "host"},
+			},
+		},
+		Nodes: map[string]NodeConfig{
+			"api":   {Service: "api", SpanName: "POST /checkout"},
+			"query": {Service: "db", SpanName: "SELECT orders"},
+		},
+		Root:  "api",
+		Edges: []EdgeConfig{{From: "api", To: "query", Kind: EdgeKindClientDatabase, Repeat: 2, DurationMs: 5}},
+	}
+	definition, err := cfg.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	generator := NewGenerator(definition)
+
+	pods := map[string]bool{}
+	nodes := map[string]bool{}
+	for range 200 {
+		spans, err := generator.GenerateBatch(context.Background())
+		if err != nil {
+			t.Fatalf("GenerateBatch() error = %v", err)
+		}
+		tracePods := map[string]bool{}
+		for _, span := range spans {
+			resource := span.ResourceAttributes
+			if resource["service.name"].Emit() == "orders-db" {
+				if got := resource["host.name"].Emit(); got != "db.internal" {
+					t.Fatalf("explicit host.name was replaced by %q", got)
+				}
+				if resource["host.id"].Emit() == "" || resource["service.instance.id"].Emit() == "" {
+					t.Fatalf("host preset attributes missing: %v", resource)
+				}
+				continue
+			}
+			pod := resource["k8s.pod.name"].Emit()
+			if !strings.HasPrefix(pod, "checkout-api-") || resource["k8s.namespace.name"].Emit() != "shop" {
+				t.Fatalf("unexpected k8s resource %v", resource)
+			}
+			tracePods[pod] = true
+			pods[pod] = true
+			nodes[resource["k8s.node.name"].Emit()] = true
+		}
+		if len(tracePods) != 1 {
+			t.Fatalf("spans of one trace ran on pods %v, want one", tracePods)
+		}
+	}
+	if len(pods) != 3 || len(nodes) != 2 {
+		t.Fatalf("traces used pods %v on nodes %v, want 3 pods on 2 nodes", pods, nodes)
+	}
+}
+
+func TestGeneratorDrawsRepeatsFromDistribution(t *testing.T) {
+	cfg := Config{
+		Name: "repeat-distribution",
+		Seed: 4,
+		Services: map[string]ServiceConfig{
+			"api":   {Resource: map[string]TypedValue{"service.name": {Type: ValueTypeString, Value: "api"}}},
+			"cache": {Resource: map[string]TypedValue{"service.name": {Type: ValueTypeString, Value: "cache"}}},
+		},
+		Nodes: map[string]NodeConfig{
+			"root":  {Service: "api", SpanName: "GET /items"},
+			"cache": {Service: "cache", SpanName: "GET item"},
+		},
+		Root: "root",
+		Edges: []EdgeConfig{{
+			From: "root", To: "cache", Kind: EdgeKindClientServer, DurationMs: 2,
+			RepeatDistribution: &RepeatDistributionConfig{Type: "poisson", Mean: 3, Max: 8},
+		}},
+	}
+	definition, err := cfg.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	generator := NewGenerator(definition)
+
+	const traces = 2000
+	widths := map[int]int{}
+	calls := 0
+	for range traces {
+		spans, err := generator.GenerateBatch(context.Background())
+		if err != nil {
+			t.Fatalf("GenerateBatch() error = %v", err)
+		}
+		var root model.Span
+		for _, span := range spans {
+			if !span.ParentSpanID.IsValid() {
+				root = span
+			}
+		}
+		for _, span := range spans {
+			if span.EndTime.After(root.EndTime) {
+				t.Fatalf("span %q ends after the root span", span.Name)
+			}
+		}
+		width := (len(spans) - 1) / 2
+		if width > 8 {
+			t.Fatalf("trace made %d calls, want at most 8", width)
+		}
+		widths[width]++
+		calls += width
+	}
+	if len(widths) < 6 || widths[0] == 0 {
+		t.Fatalf("expected traces of varied width including none, got %v", widths)
+	}
+	mean := float64(calls) / traces
+	if math.Abs(mean-3) > 0.15 {
+		t.Fatalf("average calls per trace = %.2f, want ~3", mean)
+	}
+	if got := definition.SpansPerTrace(); math.Abs(got-(1+2*3)) > 0.05 {
+		t.Fatalf("SpansPerTrace() = %.3f, want ~7", got)
+	}
+}
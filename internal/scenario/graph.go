@@ -0,0 +1,165 @@
+package scenario
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// GraphFormat is a text format the topology of a scenario is rendered to.
+type GraphFormat string
+
+const (
+	GraphFormatDOT     GraphFormat = "dot"
+	GraphFormatMermaid GraphFormat = "mermaid"
+)
+
+func ParseGraphFormat(value string) (GraphFormat, error) {
+	switch strings.TrimSpace(strings.ToLower(value)) {
+	case "", string(GraphFormatDOT), "graphviz":
+		return GraphFormatDOT, nil
+	case string(GraphFormatMermaid):
+		return GraphFormatMermaid, nil
+	default:
+		return "", fmt.Errorf("unsupported graph format %q (supported: %s, %s)", value, GraphFormatDOT, GraphFormatMermaid)
+	}
+}
+
+// RenderGraph renders the nodes and edges of cfg as a Graphviz DOT or
+// Mermaid flowchart. Nodes are grouped by service and labeled with their
+// span name; edges are labeled with their kind, calls and duration, and
+// drawn dashed when they are optional or async. Roots are drawn bold.
+func RenderGraph(cfg Config, format GraphFormat) string {
+	services := map[string][]string{}
+	for id, node := range cfg.Nodes {
+		services[node.Service] = append(services[node.Service], id)
+	}
+	roots := map[string]bool{cfg.Root: true}
+	for _, root := range cfg.Roots {
+		roots[root.Node] = true
+	}
+	// Node IDs are free-form, so both formats use generated identifiers
+	// and show the node IDs only in labels.
+	ids := map[string]string{}
+	for i, id := range slices.Sorted(maps.Keys(cfg.Nodes)) {
+		ids[id] = "n" + strconv.Itoa(i)
+	}
+	nodeLabel := func(id string) string {
+		name := cfg.Nodes[id].SpanName
+		if name == "" || name == id {
+			return id
+		}
+		return id + "\n" + name
+	}
+
+	var b strings.Builder
+	switch format {
+	case GraphFormatMermaid:
+		b.WriteString("flowchart LR\n")
+		for i, service := range slices.Sorted(maps.Keys(services)) {
+			fmt.Fprintf(&b, "  subgraph s%d[%s]\n", i, mermaidText(service))
+			for _, id := range slices.Sorted(slices.Values(services[service])) {
+				fmt.Fprintf(&b, "    %s[%s]\n", ids[id], mermaidText(nodeLabel(id)))
+			}
+			b.WriteString("  end\n")
+		}
+		for i, edge := range cfg.Edges {
+			arrow := "-->"
+			if edge.dashed() {
+				arrow = "-.->"
+			}
+			fmt.Fprintf(&b, "  %s %s|%s| %s\n", ids[edge.From], arrow, mermaidText(edge.graphLabel()), ids[edge.To])
+			if edge.ErrorRate > 0 {
+				fmt.Fprintf(&b, "  linkStyle %d stroke:#c0392b\n", i)
+			}
+		}
+		for _, id := range slices.Sorted(maps.Keys(roots)) {
+			if ids[id] != "" {
+				fmt.Fprintf(&b, "  style %s stroke-width:3px\n", ids[id])
+			}
+		}
+	default:
+		fmt.Fprintf(&b, "digraph %s {\n", dotText(cfg.Name))
+		b.WriteString("  rankdir=LR;\n  node [shape=box];\n")
+		for i, service := range slices.Sorted(maps.Keys(services)) {
+			fmt.Fprintf(&b, "  subgraph cluster_%d {\n    label=%s;\n", i, dotText(service))
+			for _, id := range slices.Sorted(slices.Values(services[service])) {
+				style := ""
+				if roots[id] {
+					style = ", style=bold"
+				}
+				fmt.Fprintf(&b, "    %s [label=%s%s];\n", ids[id], dotText(nodeLabel(id)), style)
+			}
+			b.WriteString("  }\n")
+		}
+		for _, edge := range cfg.Edges {
+			var attrs []string
+			attrs = append(attrs, "label="+dotText(edge.graphLabel()))
+			if edge.dashed() {
+				attrs = append(attrs, "style=dashed")
+			}
+			if edge.ErrorRate > 0 {
+				attrs = append(attrs, `color="#c0392b"`)
+			}
+			fmt.Fprintf(&b, "  %s -> %s [%s];\n", ids[edge.From], ids[edge.To], strings.Join(attrs, ", "))
+		}
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+// graphLabel summarizes the edge on one or two lines, e.g.
+// "client_server x3, 20ms" and "p=0.2, errors 5%".
+func (e EdgeConfig) graphLabel() string {
+	calls := ""
+	switch {
+	case e.RepeatDistribution != nil && e.RepeatDistribution.Type == repeatDistributionPoisson:
+		calls = fmt.Sprintf(" x~%g", e.RepeatDistribution.Mean)
+	case e.RepeatDistribution != nil:
+		calls = fmt.Sprintf(" x%d-%d", e.RepeatDistribution.Min, e.RepeatDistribution.Max)
+	case e.Repeat > 1:
+		calls = fmt.Sprintf(" x%d", e.Repeat)
+	}
+	label := fmt.Sprintf("%s%s, %dms", e.Kind, calls, e.DurationMs)
+	var details []string
+	if e.Probability > 0 {
+		details = append(details, fmt.Sprintf("p=%g", e.Probability))
+	}
+	if e.Weight > 0 {
+		details = append(details, fmt.Sprintf("weight %g", e.Weight))
+	}
+	if e.Parallel {
+		details = append(details, "parallel")
+	}
+	if e.Async {
+		details = append(details, "async")
+	}
+	if e.ErrorRate > 0 {
+		details = append(details, fmt.Sprintf("errors %g%%", 100*e.ErrorRate))
+	}
+	if len(details) > 0 {
+		label += "\n" + strings.Join(details, ", ")
+	}
+	return label
+}
+
+// dashed reports whether the edge is not always followed synchronously.
+func (e EdgeConfig) dashed() bool {
+	return e.Async || e.Probability > 0 || e.Weight > 0
+}
+
+// dotText quotes s as a DOT string, with newlines as line breaks.
+func dotText(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + strings.ReplaceAll(s, "\n", `\n`) + `"`
+}
+
+// mermaidText quotes s as a Mermaid label, with quotes as entity codes and
+// newlines as line breaks.
+func mermaidText(s string) string {
+	s = strings.ReplaceAll(s, `"`, "#quot;")
+	return `"` + strings.ReplaceAll(s, "\n", "<br/>") + `"`
+}
@@ -0,0 +1,70 @@
+package scenario
+
+import (
+	"strings"
+	"testing"
+)
+
+func graphTestConfig() Config {
+	return Config{
+		Name: "checkout",
+		Services: map[string]ServiceConfig{
+			"frontend": {}, "orders": {},
+		},
+		Nodes: map[string]NodeConfig{
+			"web":    {Service: "frontend", SpanName: `GET "/checkout"`},
+			"create": {Service: "orders", SpanName: "POST /orders"},
+			"audit":  {Service: "orders"},
+		},
+		Root: "web",
+		Edges: []EdgeConfig{
+			{From: "web", To: "create", Kind: EdgeKindClientServer, Repeat: 3, DurationMs: 20, ErrorRate: 0.05},
+			{From: "create", To: "audit", Kind: EdgeKindInternal, RepeatDistribution: &RepeatDistributionConfig{Type: repeatDistributionUniform, Min: 1, Max: 4}, DurationMs: 5, Probability: 0.2, Async: true},
+		},
+	}
+}
+
+func TestRenderGraphDOT(t *testing.T) {
+	graph := RenderGraph(graphTestConfig(), GraphFormatDOT)
+	for _, want := range []string{
+		`digraph "checkout" {`,
+		`label="frontend";`,
+		`n2 [label="web\nGET \"/checkout\"", style=bold];`,
+		`n0 [label="audit"];`,
+		`n2 -> n1 [label="client_server x3, 20ms\nerrors 5%", color="#c0392b"];`,
+		`n1 -> n0 [label="internal x1-4, 5ms\np=0.2, async", style=dashed];`,
+	} {
+		if !strings.Contains(graph, want) {
+			t.Fatalf("DOT graph is missing %q:\n%s", want, graph)
+		}
+	}
+}
+
+func TestRenderGraphMermaid(t *testing.T) {
+	graph := RenderGraph(graphTestConfig(), GraphFormatMermaid)
+	for _, want := range []string{
+		"flowchart LR\n",
+		`subgraph s1["orders"]`,
+		`n2["web<br/>GET #quot;/checkout#quot;"]`,
+		`n2 -->|"client_server x3, 20ms<br/>errors 5%"| n1`,
+		"linkStyle 0 stroke:#c0392b",
+		`n1 -.->|"internal x1-4, 5ms<br/>p=0.2, async"| n0`,
+		"style n2 stroke-width:3px",
+	} {
+		if !strings.Contains(graph, want) {
+			t.Fatalf("Mermaid graph is missing %q:\n%s", want, graph)
+		}
+	}
+}
+
+func TestParseGraphFormat(t *testing.T) {
+	if format, err := ParseGraphFormat("Mermaid"); err != nil || format != GraphFormatMermaid {
+		t.Fatalf("ParseGraphFormat(Mermaid) = %q, %v", format, err)
+	}
+	if format, err := ParseGraphFormat(""); err != nil || format != GraphFormatDOT {
+		t.Fatalf("ParseGraphFormat(\"\") = %q, %v", format, err)
+	}
+	if _, err := ParseGraphFormat("svg"); err == nil {
+		t.Fatal("expected an error for an unsupported format")
+	}
+}
@@ -22,7 +22,6 @@ type pendingEmit struct {
 	Child            ChildSpec
 	ParentSpanID     oteltrace.SpanID
 	RemainingRepeats int
-	Events           []model.Event
 	Links            []model.Link
 	Resolved         bool
 }
@@ -31,7 +30,9 @@ type pendingEmit struct {
 // All fields are accessed only by the single goroutine that owns the
 // walker; no synchronization is required.
 type traceState struct {
-	TraceID   oteltrace.TraceID
+	TraceID oteltrace.TraceID
+	// Root is the node the trace starts at.
+	Root      string
 	StartedAt time.Time
 	NodeSpans map[string]oteltrace.SpanID
 	IDState   *spanIDState
@@ -0,0 +1,49 @@
+package scenario
+
+import (
+	"sync"
+
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// pastTrace is a finished trace as seen by links from later traces.
+type pastTrace struct {
+	TraceID   oteltrace.TraceID
+	NodeSpans map[string]oteltrace.SpanID
+}
+
+// traceHistory keeps the last traces a Generator finished, for links with
+// PreviousTraces. It is safe for concurrent use, as walkers of one
+// Generator may run in several exporters.
+type traceHistory struct {
+	mu     sync.Mutex
+	size   int
+	traces []pastTrace // oldest first
+}
+
+func newTraceHistory(size int) *traceHistory {
+	return &traceHistory{size: size, traces: make([]pastTrace, 0, size)}
+}
+
+// add records a finished trace, forgetting the oldest one when full. The
+// trace's NodeSpans must no longer change.
+func (h *traceHistory) add(trace pastTrace) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.traces) == h.size {
+		h.traces = append(h.traces[:0], h.traces[1:]...)
+	}
+	h.traces = append(h.traces, trace)
+}
+
+// recent returns up to n of the last finished traces, newest first.
+func (h *traceHistory) recent(n int) []pastTrace {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n = min(n, len(h.traces))
+	out := make([]pastTrace, 0, n)
+	for i := len(h.traces) - 1; i >= len(h.traces)-n; i-- {
+		out = append(out, h.traces[i])
+	}
+	return out
+}
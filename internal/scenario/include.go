@@ -0,0 +1,111 @@
+package scenario
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+
+	"github.com/javiermolinar/tercios/internal/fileformat"
+)
+
+// resolveIncludes merges the fragments c.Includes names into c, and the
+// fragments they include in turn. Paths are relative to baseDir; a
+// directory includes its .json, .yaml and .yml files in name order.
+// Services and nodes merge by ID, and fragment edges are appended after
+// the edges of c, in include order. loaded holds the absolute paths of
+// the files read so far, so a file included twice, or a cycle, is an
+// error instead of duplicated edges.
+func (c *Config) resolveIncludes(baseDir string, loaded map[string]bool) error {
+	includes := c.Includes
+	c.Includes = nil
+	for _, include := range includes {
+		path := include
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		files, err := includeFiles(path)
+		if err != nil {
+			return fmt.Errorf("include %s: %w", include, err)
+		}
+		for _, file := range files {
+			if err := c.mergeFragment(file, loaded); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func includeFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && (filepath.Ext(name) == ".json" || fileformat.IsYAML(name)) {
+			files = append(files, filepath.Join(path, name))
+		}
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+func (c *Config) mergeFragment(path string, loaded map[string]bool) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if loaded[abs] {
+		return fmt.Errorf("include %s: file is included more than once", path)
+	}
+	loaded[abs] = true
+
+	file, err := fileformat.Open(path)
+	if err != nil {
+		return fmt.Errorf("include %s: %w", path, err)
+	}
+	fragment, err := decodeConfig(file)
+	_ = file.Close()
+	if err != nil {
+		return fmt.Errorf("include %s: %w", path, err)
+	}
+	if fragment.Name != "" || fragment.Seed != 0 || fragment.Root != "" || len(fragment.Roots) > 0 ||
+		fragment.HTTPStatusMapping != nil || fragment.SpansPerSecond != 0 || fragment.Semconv {
+		return fmt.Errorf("include %s: a fragment can only set services, nodes, edges and includes", path)
+	}
+	if err := fragment.resolveIncludes(filepath.Dir(path), loaded); err != nil {
+		return err
+	}
+
+	if c.Services == nil {
+		c.Services = map[string]ServiceConfig{}
+	}
+	for id, service := range fragment.Services {
+		if existing, ok := c.Services[id]; ok && !reflect.DeepEqual(existing, service) {
+			return fmt.Errorf("include %s: service %q is already defined differently", path, id)
+		}
+		c.Services[id] = service
+	}
+	if c.Nodes == nil {
+		c.Nodes = map[string]NodeConfig{}
+	}
+	for id, node := range fragment.Nodes {
+		if existing, ok := c.Nodes[id]; ok && !reflect.DeepEqual(existing, node) {
+			return fmt.Errorf("include %s: node %q is already defined differently", path, id)
+		}
+		c.Nodes[id] = node
+	}
+	c.Edges = append(c.Edges, fragment.Edges...)
+	return nil
+}
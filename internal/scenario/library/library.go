@@ -0,0 +1,45 @@
+// Package library holds the named example scenarios embedded in tercios,
+// selected with -s builtin:NAME, so realistic multi-service traces can be
+// generated before writing a scenario file. It only stores the scenario
+// documents; the scenario package decodes and validates them.
+package library
+
+import (
+	"embed"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Prefix marks a scenario file path as the name of an embedded scenario.
+const Prefix = "builtin:"
+
+//go:embed *.json
+var files embed.FS
+
+// Names returns the names of the embedded scenarios, sorted.
+func Names() []string {
+	entries, _ := fs.ReadDir(files, ".")
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), path.Ext(entry.Name())))
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Lookup returns the JSON document of the named scenario.
+func Lookup(name string) ([]byte, bool) {
+	if !slices.Contains(Names(), name) {
+		return nil, false
+	}
+	data, err := files.ReadFile(name + ".json")
+	return data, err == nil
+}
+
+// Name returns NAME for a builtin:NAME scenario file path, and whether
+// path is one.
+func Name(path string) (string, bool) {
+	return strings.CutPrefix(path, Prefix)
+}
@@ -0,0 +1,46 @@
+package scenario
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/javiermolinar/tercios/internal/scenario/library"
+)
+
+func TestBuiltinScenariosLoadAndGenerate(t *testing.T) {
+	names := library.Names()
+	if len(names) < 3 {
+		t.Fatalf("expected at least 3 builtin scenarios, got %v", names)
+	}
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := LoadFromJSON(library.Prefix + name)
+			if err != nil {
+				t.Fatalf("LoadFromJSON() error = %v", err)
+			}
+			if cfg.Name != name {
+				t.Fatalf("scenario name = %q, want the file name %q", cfg.Name, name)
+			}
+			definition, err := cfg.Build()
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+			batch, err := NewGenerator(definition).GenerateBatch(context.Background())
+			if err != nil {
+				t.Fatalf("GenerateBatch() error = %v", err)
+			}
+			services := map[string]bool{}
+			for _, span := range batch {
+				services[span.ResourceAttributes["service.name"].Emit()] = true
+			}
+			if len(services) < 3 {
+				t.Fatalf("expected a multi-service trace, got services %v", services)
+			}
+		})
+	}
+
+	if _, err := LoadFromJSON("builtin:missing"); err == nil || !strings.Contains(err.Error(), "available: ") {
+		t.Fatalf("LoadFromJSON(builtin:missing) error = %v, want the available names", err)
+	}
+}
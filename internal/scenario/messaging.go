@@ -0,0 +1,101 @@
+package scenario
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/javiermolinar/tercios/internal/validation"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// MessagingConfig describes the broker behind a producer_consumer edge, so
+// both sides of each message carry consistent messaging.* attributes.
+type MessagingConfig struct {
+	// System is kafka, rabbitmq or aws_sqs ("sqs" is accepted as an alias).
+	System        string `json:"system"`
+	Destination   string `json:"destination"`
+	ConsumerGroup string `json:"consumer_group,omitempty"`
+	// Partitions spreads Kafka messages over this many partitions.
+	Partitions int `json:"partitions,omitempty"`
+	// RoutingKey is the RabbitMQ routing key.
+	RoutingKey string `json:"routing_key,omitempty"`
+}
+
+const (
+	messagingSystemKafka    = "kafka"
+	messagingSystemRabbitMQ = "rabbitmq"
+	messagingSystemSQS      = "aws_sqs"
+)
+
+func normalizeMessagingSystem(system string) string {
+	normalized := strings.ToLower(strings.TrimSpace(system))
+	if normalized == "sqs" {
+		return messagingSystemSQS
+	}
+	return normalized
+}
+
+func (c MessagingConfig) validate(errs *validation.Errors, path string) {
+	system := normalizeMessagingSystem(c.System)
+	switch system {
+	case messagingSystemKafka, messagingSystemRabbitMQ, messagingSystemSQS:
+		if c.Partitions > 0 && system != messagingSystemKafka {
+			errs.Addf(path, "partitions is only supported for kafka")
+		}
+		if c.RoutingKey != "" && system != messagingSystemRabbitMQ {
+			errs.Addf(path, "routing_key is only supported for rabbitmq")
+		}
+	default:
+		errs.Addf(path, "messaging system must be kafka, rabbitmq or aws_sqs, got %q", c.System)
+	}
+	if strings.TrimSpace(c.Destination) == "" {
+		errs.Addf(path, "destination is required")
+	}
+	if c.Partitions < 0 {
+		errs.Addf(path, "partitions must be >= 0")
+	}
+}
+
+type messaging struct {
+	config MessagingConfig
+	system string
+}
+
+func newMessaging(cfg *MessagingConfig) *messaging {
+	if cfg == nil {
+		return nil
+	}
+	return &messaging{config: *cfg, system: normalizeMessagingSystem(cfg.System)}
+}
+
+// attributes returns the producer and consumer attributes for one message.
+// Both start from base, which is not mutated.
+func (m *messaging) attributes(base map[string]attribute.Value, callID oteltrace.SpanID) (map[string]attribute.Value, map[string]attribute.Value) {
+	producer := cloneAttributeValues(base)
+	producer["messaging.system"] = attribute.StringValue(m.system)
+	producer["messaging.destination.name"] = attribute.StringValue(m.config.Destination)
+	producer["messaging.message.id"] = attribute.StringValue(messageID(callID))
+	if m.config.Partitions > 0 {
+		partition := callRandom(callID, 5) % uint64(m.config.Partitions)
+		producer["messaging.destination.partition.id"] = attribute.StringValue(strconv.FormatUint(partition, 10))
+	}
+	if m.config.RoutingKey != "" {
+		producer["messaging.rabbitmq.destination.routing_key"] = attribute.StringValue(m.config.RoutingKey)
+	}
+
+	consumer := cloneAttributeValues(producer)
+	producer["messaging.operation.type"] = attribute.StringValue("publish")
+	consumer["messaging.operation.type"] = attribute.StringValue("process")
+	if m.config.ConsumerGroup != "" {
+		consumer["messaging.consumer.group.name"] = attribute.StringValue(m.config.ConsumerGroup)
+	}
+	return producer, consumer
+}
+
+// messageID formats a UUID-shaped message ID derived from the call.
+func messageID(callID oteltrace.SpanID) string {
+	hi, lo := callRandom(callID, 3), callRandom(callID, 4)
+	return fmt.Sprintf("%08x-%04x-%04x-%04x-%012x", hi>>32, (hi>>16)&0xffff, hi&0xffff, lo>>48, lo&0xffffffffffff)
+}
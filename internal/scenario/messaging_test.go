@@ -0,0 +1,131 @@
+package scenario
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+const messagingScenario = `{
+  "name": "messaging",
+  "seed": 11,
+  "services": {
+    "api": { "resource": { "service.name": { "type": "string", "value": "api" } } },
+    "worker": { "resource": { "service.name": { "type": "string", "value": "worker" } } }
+  },
+  "nodes": {
+    "a": { "service": "api", "span_name": "POST /orders" },
+    "b": { "service": "worker", "span_name": "process order" }
+  },
+  "root": "a",
+  "edges": [
+    {
+      "from": "a", "to": "b", "kind": "producer_consumer", "repeat": 20, "duration_ms": 4,
+      "messaging": { "system": "kafka", "destination": "orders", "consumer_group": "order-workers", "partitions": 3 }
+    }
+  ]
+}`
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+func TestGeneratorFillsMessagingAttributes(t *testing.T) {
+	cfg, err := DecodeJSON(strings.NewReader(messagingScenario))
+	if err != nil {
+		t.Fatalf("DecodeJSON() error = %v", err)
+	}
+	definition, err := cfg.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	spans, err := NewGenerator(definition).GenerateBatch(context.Background())
+	if err != nil {
+		t.Fatalf("GenerateBatch() error = %v", err)
+	}
+
+	producerIDs := map[oteltrace.SpanID]string{}
+	partitions := map[string]struct{}{}
+	for _, span := range spans {
+		if span.Kind != oteltrace.SpanKindProducer {
+			continue
+		}
+		if got := span.Attributes["messaging.operation.type"].AsString(); got != "publish" {
+			t.Fatalf("expected producer operation publish, got %q", got)
+		}
+		if _, ok := span.Attributes["messaging.consumer.group.name"]; ok {
+			t.Fatalf("expected consumer group only on consumer spans")
+		}
+		producerIDs[span.SpanID] = span.Attributes["messaging.message.id"].AsString()
+		partitions[span.Attributes["messaging.destination.partition.id"].AsString()] = struct{}{}
+	}
+	if len(producerIDs) != 20 {
+		t.Fatalf("expected 20 producer spans, got %d", len(producerIDs))
+	}
+
+	seen := map[string]struct{}{}
+	for _, span := range spans {
+		if span.Kind != oteltrace.SpanKindConsumer {
+			continue
+		}
+		id := span.Attributes["messaging.message.id"].AsString()
+		if id != producerIDs[span.ParentSpanID] {
+			t.Fatalf("expected consumer message id %q to match its producer, got %q", producerIDs[span.ParentSpanID], id)
+		}
+		if !uuidPattern.MatchString(id) {
+			t.Fatalf("expected UUID-shaped message id, got %q", id)
+		}
+		seen[id] = struct{}{}
+		if got := span.Attributes["messaging.system"].AsString(); got != "kafka" {
+			t.Fatalf("expected messaging.system=kafka, got %q", got)
+		}
+		if got := span.Attributes["messaging.destination.name"].AsString(); got != "orders" {
+			t.Fatalf("expected destination orders, got %q", got)
+		}
+		if got := span.Attributes["messaging.consumer.group.name"].AsString(); got != "order-workers" {
+			t.Fatalf("expected consumer group, got %q", got)
+		}
+		if got := span.Attributes["messaging.operation.type"].AsString(); got != "process" {
+			t.Fatalf("expected consumer operation process, got %q", got)
+		}
+	}
+	if len(seen) != 20 {
+		t.Fatalf("expected distinct message ids per message, got %d", len(seen))
+	}
+	for partition := range partitions {
+		if partition != "0" && partition != "1" && partition != "2" {
+			t.Fatalf("unexpected partition %q", partition)
+		}
+	}
+}
+
+func TestDecodeJSONRejectsInvalidMessaging(t *testing.T) {
+	original := `"messaging": { "system": "kafka", "destination": "orders", "consumer_group": "order-workers", "partitions": 3 }`
+	tests := map[string]string{
+		`"messaging": { "system": "sqs", "destination": "orders" }`:                          "",
+		`"messaging": { "system": "rabbitmq", "destination": "orders", "routing_key": "o" }`: "",
+		`"messaging": { "system": "nats", "destination": "orders" }`:                         "messaging system must be",
+		`"messaging": { "system": "kafka" }`:                                                 "destination is required",
+		`"messaging": { "system": "sqs", "destination": "orders", "partitions": 2 }`:         "only supported for kafka",
+		`"messaging": { "system": "kafka", "destination": "orders", "routing_key": "o" }`:    "only supported for rabbitmq",
+	}
+	for field, want := range tests {
+		input := strings.Replace(messagingScenario, original, field, 1)
+		_, err := DecodeJSON(strings.NewReader(input))
+		if want == "" {
+			if err != nil {
+				t.Fatalf("%s: unexpected error %v", field, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("%s: expected error containing %q, got %v", field, want, err)
+		}
+	}
+
+	clientServer := strings.Replace(messagingScenario, `"kind": "producer_consumer"`, `"kind": "client_server"`, 1)
+	if _, err := DecodeJSON(strings.NewReader(clientServer)); err == nil || !strings.Contains(err.Error(), "only supported on producer_consumer edges") {
+		t.Fatalf("expected producer_consumer-only error, got %v", err)
+	}
+}
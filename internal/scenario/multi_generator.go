@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"sync/atomic"
 
+	"github.com/javiermolinar/tercios/internal/idgen"
 	"github.com/javiermolinar/tercios/internal/model"
 )
 
@@ -13,13 +14,16 @@ type MultiGenerator struct {
 	strategy   SelectionStrategy
 	seed       uint64
 	counter    atomic.Uint64
+	// cumulative holds the normalized cumulative selection weights for
+	// the rate strategy.
+	cumulative []float64
 }
 
 func NewMultiGenerator(definitions []Definition, strategy SelectionStrategy, seed int64) (*MultiGenerator, error) {
 	if len(definitions) == 0 {
 		return nil, fmt.Errorf("at least one scenario definition is required")
 	}
-	if strategy != SelectionStrategyRoundRobin && strategy != SelectionStrategyRandom {
+	if strategy != SelectionStrategyRoundRobin && strategy != SelectionStrategyRandom && strategy != SelectionStrategyRate {
 		return nil, fmt.Errorf("unsupported selection strategy %q", strategy)
 	}
 
@@ -28,11 +32,61 @@ func NewMultiGenerator(definitions []Definition, strategy SelectionStrategy, see
 		generators = append(generators, NewGenerator(definition))
 	}
 
-	return &MultiGenerator{
+	g := &MultiGenerator{
 		generators: generators,
 		strategy:   strategy,
 		seed:       uint64(seed),
-	}, nil
+	}
+	if strategy == SelectionStrategyRate {
+		cumulative, err := rateWeights(definitions)
+		if err != nil {
+			return nil, err
+		}
+		g.cumulative = cumulative
+	}
+	return g, nil
+}
+
+// SetIDGenerator makes every scenario draw its IDs from ids.
+func (g *MultiGenerator) SetIDGenerator(ids idgen.Generator) {
+	for _, generator := range g.generators {
+		if generator, ok := generator.(*Generator); ok {
+			generator.SetIDGenerator(ids)
+		}
+	}
+}
+
+// rateWeights turns per-scenario span rates into batch selection weights:
+// the share of each scenario in the total trace rate.
+func rateWeights(definitions []Definition) ([]float64, error) {
+	rates, err := traceRates(definitions)
+	if err != nil {
+		return nil, err
+	}
+	cumulative := make([]float64, 0, len(rates))
+	var total float64
+	for _, rate := range rates {
+		total += rate
+		cumulative = append(cumulative, total)
+	}
+	for i := range cumulative {
+		cumulative[i] /= total
+	}
+	return cumulative, nil
+}
+
+// traceRates returns the traces per second each scenario needs to send its
+// spans_per_second target: SpansPerSecond / SpansPerTrace, so scenarios
+// with large traces need fewer traces for the same rate.
+func traceRates(definitions []Definition) ([]float64, error) {
+	rates := make([]float64, 0, len(definitions))
+	for _, definition := range definitions {
+		if definition.SpansPerSecond <= 0 {
+			return nil, fmt.Errorf("scenario %q: spans_per_second is required by the %s strategy", definition.Name, SelectionStrategyRate)
+		}
+		rates = append(rates, definition.SpansPerSecond/definition.SpansPerTrace())
+	}
+	return rates, nil
 }
 
 func (g *MultiGenerator) GenerateBatch(ctx context.Context) ([]model.Span, error) {
@@ -58,6 +112,14 @@ func (g *MultiGenerator) nextIndex() int {
 	case SelectionStrategyRandom:
 		value := splitmix64(g.seed ^ sequence)
 		return int(value % uint64(count))
+	case SelectionStrategyRate:
+		roll := float64(splitmix64(g.seed^sequence)>>11) / (1 << 53)
+		for i, bound := range g.cumulative {
+			if roll < bound {
+				return i
+			}
+		}
+		return count - 1
 	default:
 		return int((sequence - 1) % uint64(count))
 	}
@@ -63,6 +63,52 @@ func TestMultiGeneratorRandomDeterministicSelection(t *testing.T) {
 	}
 }
 
+func TestMultiGeneratorRateApportionsSpans(t *testing.T) {
+	high := testSimpleDefinition(t, "high-traffic", 1, "root-high")
+	high.SpansPerSecond = 300
+	low := testDefinition(t)
+	low.SpansPerSecond = 100
+
+	g, err := NewMultiGenerator([]Definition{high, low}, SelectionStrategyRate, 9)
+	if err != nil {
+		t.Fatalf("NewMultiGenerator() error = %v", err)
+	}
+
+	spans := map[bool]int{}
+	for i := 0; i < 4000; i++ {
+		batch, err := g.GenerateBatch(context.Background())
+		if err != nil {
+			t.Fatalf("GenerateBatch() error = %v", err)
+		}
+		spans[rootSpanName(batch) == "root-high"] += len(batch)
+	}
+
+	share := float64(spans[true]) / float64(spans[true]+spans[false])
+	if share < 0.72 || share > 0.78 {
+		t.Fatalf("expected high-traffic scenario to produce ~75%% of spans, got %.3f", share)
+	}
+}
+
+func TestMultiGeneratorRateRequiresSpansPerSecond(t *testing.T) {
+	defs := []Definition{
+		testSimpleDefinition(t, "scenario-a", 1, "root-a"),
+		testSimpleDefinition(t, "scenario-b", 2, "root-b"),
+	}
+	defs[0].SpansPerSecond = 10
+	if _, err := NewMultiGenerator(defs, SelectionStrategyRate, 1); err == nil {
+		t.Fatalf("expected error for scenario without spans_per_second")
+	}
+}
+
+func TestDefinitionSpansPerTrace(t *testing.T) {
+	if got := testDefinition(t).SpansPerTrace(); got != 9 {
+		t.Fatalf("expected 9 spans per trace, got %v", got)
+	}
+	if got := testSimpleDefinition(t, "simple", 1, "root").SpansPerTrace(); got != 2 {
+		t.Fatalf("expected 2 spans per trace, got %v", got)
+	}
+}
+
 func testSimpleDefinition(t *testing.T, scenarioName string, seed int64, rootSpanName string) Definition {
 	t.Helper()
 
@@ -0,0 +1,117 @@
+package scenario
+
+import (
+	"math"
+	"sort"
+
+	"github.com/javiermolinar/tercios/internal/validation"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// RepeatDistributionConfig draws the number of calls of an edge for each
+// span of its source node, instead of the fixed Repeat, so generated
+// traces vary in width.
+type RepeatDistributionConfig struct {
+	// Type is poisson or uniform.
+	Type string `json:"type"`
+	// Mean is the mean of a poisson distribution.
+	Mean float64 `json:"mean,omitempty"`
+	// Min and Max bound the drawn count; poisson draws outside them are
+	// clamped. Max also sizes the time the edge takes in its parent span,
+	// so a span always contains its calls.
+	Min int `json:"min,omitempty"`
+	Max int `json:"max"`
+}
+
+const (
+	repeatDistributionPoisson = "poisson"
+	repeatDistributionUniform = "uniform"
+
+	// maxRepeatDistribution bounds RepeatDistributionConfig.Max, as the
+	// probability of every count is computed up front.
+	maxRepeatDistribution = 10000
+)
+
+func (c RepeatDistributionConfig) validate(errs *validation.Errors, path string) {
+	switch c.Type {
+	case repeatDistributionPoisson:
+		if c.Mean <= 0 {
+			errs.Addf(path, "mean must be > 0")
+		}
+	case repeatDistributionUniform:
+		if c.Mean != 0 {
+			errs.Addf(path, "mean is only supported for poisson")
+		}
+	default:
+		errs.Addf(path, "type must be poisson or uniform, got %q", c.Type)
+	}
+	if c.Min < 0 {
+		errs.Addf(path, "min must be >= 0")
+	}
+	if c.Max <= 0 || c.Max > maxRepeatDistribution {
+		errs.Addf(path, "max must be between 1 and %d", maxRepeatDistribution)
+	} else if c.Min > c.Max {
+		errs.Addf(path, "min must be <= max")
+	}
+}
+
+// repeatDistribution is a compiled RepeatDistributionConfig: the
+// cumulative probability of each count from min to max.
+type repeatDistribution struct {
+	min        int
+	cumulative []float64
+	mean       float64
+}
+
+func newRepeatDistribution(cfg *RepeatDistributionConfig) *repeatDistribution {
+	if cfg == nil {
+		return nil
+	}
+	weights := make([]float64, cfg.Max-cfg.Min+1)
+	switch cfg.Type {
+	case repeatDistributionPoisson:
+		// Counts below min and above max are clamped onto them.
+		for k := 0; k <= cfg.Max; k++ {
+			lgamma, _ := math.Lgamma(float64(k + 1))
+			p := math.Exp(float64(k)*math.Log(cfg.Mean) - cfg.Mean - lgamma)
+			weights[max(k-cfg.Min, 0)] += p
+		}
+		var total float64
+		for _, w := range weights {
+			total += w
+		}
+		weights[len(weights)-1] += max(1-total, 0)
+	default:
+		for i := range weights {
+			weights[i] = 1
+		}
+	}
+	d := &repeatDistribution{min: cfg.Min, cumulative: make([]float64, len(weights))}
+	var total float64
+	for i, w := range weights {
+		total += w
+		d.cumulative[i] = total
+	}
+	for i := range d.cumulative {
+		d.cumulative[i] /= total
+		d.mean += float64(cfg.Min+i) * weights[i] / total
+	}
+	return d
+}
+
+// draw returns the number of calls the span parentSpanID makes over its
+// i-th edge.
+func (d *repeatDistribution) draw(parentSpanID oteltrace.SpanID, i int) int {
+	roll := float64(callRandom(parentSpanID, edgeRepeatSalt+uint64(i)<<8)>>11) / (1 << 53)
+	index := sort.SearchFloat64s(d.cumulative, roll)
+	// Rounding can leave the last bound just below 1.
+	return d.min + min(index, len(d.cumulative)-1)
+}
+
+// expectedRepeat returns the average number of calls per source span.
+func (e Edge) expectedRepeat() float64 {
+	if e.Repeats != nil {
+		return e.Repeats.mean
+	}
+	return float64(e.Repeat)
+}
@@ -0,0 +1,159 @@
+package scenario
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"github.com/javiermolinar/tercios/internal/validation"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// ResourcePresetConfig generates the resource attributes of the instances
+// of a service, so backends that group by pod or host see a realistic
+// number of them. Each trace uses one instance of each service.
+type ResourcePresetConfig struct {
+	// Type is k8s or host.
+	Type string `json:"type"`
+	// Instances is the number of pods or hosts. Unset means 1.
+	Instances int `json:"instances,omitempty"`
+	// Namespace is the k8s.namespace.name of k8s instances, "default"
+	// when unset.
+	Namespace string `json:"namespace,omitempty"`
+	// Cluster, when set, is the k8s.cluster.name of k8s instances.
+	Cluster string `json:"cluster,omitempty"`
+	// Nodes is the number of k8s nodes the pods are spread over. Unset
+	// gives each pod its own node.
+	Nodes int `json:"nodes,omitempty"`
+}
+
+const (
+	resourcePresetK8s  = "k8s"
+	resourcePresetHost = "host"
+
+	// maxPresetInstances bounds ResourcePresetConfig.Instances, as each
+	// instance's attributes are built up front.
+	maxPresetInstances = 10000
+)
+
+func (c ResourcePresetConfig) validate(errs *validation.Errors, path string) {
+	switch c.Type {
+	case resourcePresetK8s:
+	case resourcePresetHost:
+		if c.Namespace != "" || c.Cluster != "" || c.Nodes != 0 {
+			errs.Addf(path, "namespace, cluster and nodes are only supported for k8s")
+		}
+	default:
+		errs.Addf(path, "type must be k8s or host, got %q", c.Type)
+	}
+	if c.Instances < 0 || c.Instances > maxPresetInstances {
+		errs.Addf(path, "instances must be between 0 and %d", maxPresetInstances)
+	}
+	if c.Nodes < 0 {
+		errs.Addf(path, "nodes must be >= 0")
+	}
+}
+
+// presetInstances returns the resource attributes of each instance of a
+// service named name: the preset's attributes under explicit, which wins
+// for keys both set. Names and IDs derive from the service name and the
+// instance index only, so they are stable across runs.
+func presetInstances(cfg *ResourcePresetConfig, name string, explicit map[string]attribute.Value) []map[string]attribute.Value {
+	if cfg == nil {
+		return nil
+	}
+	count := max(cfg.Instances, 1)
+	base := dnsLabel(name)
+	instances := make([]map[string]attribute.Value, count)
+	for i := range instances {
+		attrs := map[string]attribute.Value{}
+		switch cfg.Type {
+		case resourcePresetK8s:
+			namespace := cfg.Namespace
+			if namespace == "" {
+				namespace = "default"
+			}
+			nodes := cfg.Nodes
+			if nodes == 0 {
+				nodes = count
+			}
+			node := fmt.Sprintf("node-%d", i%nodes+1)
+			replicaSet := base + "-" + k8sSuffix(name, 0, 10)
+			uid := instanceUUID(name, i)
+			attrs["k8s.namespace.name"] = attribute.StringValue(namespace)
+			attrs["k8s.deployment.name"] = attribute.StringValue(base)
+			attrs["k8s.replicaset.name"] = attribute.StringValue(replicaSet)
+			attrs["k8s.pod.name"] = attribute.StringValue(replicaSet + "-" + k8sSuffix(name, i+1, 5))
+			attrs["k8s.pod.uid"] = attribute.StringValue(uid)
+			attrs["k8s.container.name"] = attribute.StringValue(base)
+			attrs["k8s.node.name"] = attribute.StringValue(node)
+			attrs["host.name"] = attribute.StringValue(node)
+			attrs["service.instance.id"] = attribute.StringValue(uid)
+			if cfg.Cluster != "" {
+				attrs["k8s.cluster.name"] = attribute.StringValue(cfg.Cluster)
+			}
+		case resourcePresetHost:
+			attrs["host.name"] = attribute.StringValue(fmt.Sprintf("%s-%02d", base, i+1))
+			attrs["host.id"] = attribute.StringValue(strings.ReplaceAll(instanceUUID(name+"/host", i), "-", ""))
+			attrs["host.arch"] = attribute.StringValue("amd64")
+			attrs["os.type"] = attribute.StringValue("linux")
+			attrs["service.instance.id"] = attribute.StringValue(instanceUUID(name, i))
+		}
+		for key, value := range explicit {
+			attrs[key] = value
+		}
+		instances[i] = attrs
+	}
+	return instances
+}
+
+// pickInstance returns the index of the instance of service serving a
+// trace, so every span of the service in the trace shares one instance.
+func pickInstance(traceID oteltrace.TraceID, service string, instances int) int {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(service))
+	return int(splitmix64(binary.BigEndian.Uint64(traceID[8:])^h.Sum64()) % uint64(instances))
+}
+
+// k8sAlphabet is the alphabet Kubernetes uses for generated name
+// suffixes: no vowels and no characters that read alike.
+const k8sAlphabet = "bcdfghjklmnpqrstvwxz2456789"
+
+func k8sSuffix(name string, index, length int) string {
+	x := instanceHash(name, index)
+	suffix := make([]byte, length)
+	for i := range suffix {
+		suffix[i] = k8sAlphabet[x%uint64(len(k8sAlphabet))]
+		x /= uint64(len(k8sAlphabet))
+	}
+	return string(suffix)
+}
+
+func instanceUUID(name string, index int) string {
+	hi, lo := instanceHash(name, index), splitmix64(instanceHash(name, index))
+	return fmt.Sprintf("%08x-%04x-4%03x-%04x-%012x", hi>>32, (hi>>16)&0xffff, hi&0xfff, 0x8000|(lo>>48)&0x3fff, lo&0xffffffffffff)
+}
+
+func instanceHash(name string, index int) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+	return splitmix64(h.Sum64() ^ uint64(index))
+}
+
+// dnsLabel lowercases name and replaces what a Kubernetes object name
+// cannot hold with dashes.
+func dnsLabel(name string) string {
+	label := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		default:
+			return '-'
+		}
+	}, name)
+	return strings.Trim(label, "-")
+}
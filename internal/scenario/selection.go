@@ -10,6 +10,9 @@ type SelectionStrategy string
 const (
 	SelectionStrategyRoundRobin SelectionStrategy = "round-robin"
 	SelectionStrategyRandom     SelectionStrategy = "random"
+	// SelectionStrategyRate picks scenarios so that each one sends its
+	// spans_per_second target, and paces the run by their sum.
+	SelectionStrategyRate SelectionStrategy = "rate"
 )
 
 func ParseSelectionStrategy(value string) (SelectionStrategy, error) {
@@ -19,7 +22,9 @@ func ParseSelectionStrategy(value string) (SelectionStrategy, error) {
 		return SelectionStrategyRoundRobin, nil
 	case string(SelectionStrategyRandom):
 		return SelectionStrategyRandom, nil
+	case string(SelectionStrategyRate):
+		return SelectionStrategyRate, nil
 	default:
-		return "", fmt.Errorf("unsupported scenario strategy %q (supported: %s, %s)", value, SelectionStrategyRoundRobin, SelectionStrategyRandom)
+		return "", fmt.Errorf("unsupported scenario strategy %q (supported: %s, %s, %s)", value, SelectionStrategyRoundRobin, SelectionStrategyRandom, SelectionStrategyRate)
 	}
 }
@@ -0,0 +1,74 @@
+package scenario
+
+import (
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// semconvHTTPMethods are the span name prefixes read as the request method
+// of a client_server edge, as in "GET /items".
+var semconvHTTPMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+
+// semconvDBOperations are the span name prefixes read as the operation of
+// a client_database edge, as in "SELECT orders".
+var semconvDBOperations = []string{"SELECT", "INSERT", "UPDATE", "DELETE", "UPSERT", "MERGE", "CALL"}
+
+// semconvAttributes returns the conventional attributes of an edge of kind
+// to target, in the dialect the rest of the package uses: the HTTP method
+// and route come from target span names such as "GET /items", database
+// statements from names such as "SELECT orders", and server addresses,
+// database names and destinations from the target service's name. The
+// returned map is new and may be modified.
+func semconvAttributes(kind EdgeKind, target Node, service Service) map[string]attribute.Value {
+	name := semconvServiceName(service)
+	spanName := target.SpanName
+	if spanName == "" {
+		spanName = target.ID
+	}
+	verb, rest, _ := strings.Cut(spanName, " ")
+	verb = strings.ToUpper(verb)
+
+	attrs := map[string]attribute.Value{}
+	switch kind {
+	case EdgeKindClientServer:
+		method := "GET"
+		if slices.Contains(semconvHTTPMethods, verb) {
+			method = verb
+			if route := strings.TrimSpace(rest); strings.HasPrefix(route, "/") {
+				attrs["http.route"] = attribute.StringValue(route)
+				attrs["url.path"] = attribute.StringValue(route)
+			}
+		}
+		attrs["http.request.method"] = attribute.StringValue(method)
+		attrs[statusCodeAttribute] = attribute.Int64Value(200)
+		attrs["server.address"] = attribute.StringValue(name)
+		attrs["server.port"] = attribute.Int64Value(8080)
+		attrs["url.scheme"] = attribute.StringValue("http")
+	case EdgeKindClientDatabase:
+		attrs["db.system"] = attribute.StringValue("other_sql")
+		if system, ok := service.ResourceAttributes["db.system"]; ok {
+			attrs["db.system"] = system
+		}
+		attrs["db.name"] = attribute.StringValue(name)
+		attrs["db.statement"] = attribute.StringValue(spanName)
+		if slices.Contains(semconvDBOperations, verb) {
+			attrs["db.operation"] = attribute.StringValue(verb)
+		}
+		attrs["server.address"] = attribute.StringValue(name)
+	case EdgeKindProducerConsumer:
+		attrs["messaging.system"] = attribute.StringValue(messagingSystemKafka)
+		attrs["messaging.destination.name"] = attribute.StringValue(name)
+	}
+	return attrs
+}
+
+// semconvServiceName is the service.name resource attribute of service, or its
+// ID without one.
+func semconvServiceName(service Service) string {
+	if name, ok := service.ResourceAttributes["service.name"]; ok {
+		return name.Emit()
+	}
+	return service.ID
+}
@@ -0,0 +1,76 @@
+package scenario
+
+import (
+	"encoding/binary"
+
+	"github.com/javiermolinar/tercios/internal/validation"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// StatusCodeWeight is one entry of an HTTP status code distribution. Weights
+// are relative; they do not need to add up to 1 or 100.
+type StatusCodeWeight struct {
+	Code   int     `json:"code"`
+	Weight float64 `json:"weight"`
+}
+
+const statusCodeAttribute = "http.response.status_code"
+
+func validateStatusCodes(errs *validation.Errors, path string, weights []StatusCodeWeight) {
+	for i, weight := range weights {
+		if weight.Code < 100 || weight.Code > 599 {
+			errs.Addf(validation.Index(path, i), "code must be between 100 and 599")
+		}
+		if weight.Weight <= 0 {
+			errs.Addf(validation.Index(path, i), "weight must be > 0")
+		}
+	}
+}
+
+// statusDistribution is a compiled StatusCodeWeight list. The zero value
+// draws nothing, leaving span attributes untouched.
+type statusDistribution struct {
+	codes      []int64
+	cumulative []float64
+}
+
+func newStatusDistribution(weights []StatusCodeWeight) statusDistribution {
+	if len(weights) == 0 {
+		return statusDistribution{}
+	}
+	dist := statusDistribution{
+		codes:      make([]int64, 0, len(weights)),
+		cumulative: make([]float64, 0, len(weights)),
+	}
+	var total float64
+	for _, weight := range weights {
+		total += weight.Weight
+		dist.codes = append(dist.codes, int64(weight.Code))
+		dist.cumulative = append(dist.cumulative, total)
+	}
+	for i := range dist.cumulative {
+		dist.cumulative[i] /= total
+	}
+	return dist
+}
+
+// pick draws a status code keyed on spanID, so the choice follows the
+// scenario seed like trace and span IDs do.
+func (d statusDistribution) pick(spanID oteltrace.SpanID) (int64, bool) {
+	if len(d.codes) == 0 {
+		return 0, false
+	}
+	roll := float64(callRandom(spanID, 0)>>11) / (1 << 53)
+	for i, bound := range d.cumulative {
+		if roll < bound {
+			return d.codes[i], true
+		}
+	}
+	return d.codes[len(d.codes)-1], true
+}
+
+// callRandom derives a pseudo-random value for one call from the ID of its
+// source span. Different salts give independent draws for the same call.
+func callRandom(spanID oteltrace.SpanID, salt uint64) uint64 {
+	return splitmix64(binary.BigEndian.Uint64(spanID[:]) ^ salt)
+}
@@ -0,0 +1,137 @@
+package scenario
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+const statusCodesScenario = `{
+  "name": "status-codes",
+  "seed": 7,
+  "services": {
+    "web": { "resource": { "service.name": { "type": "string", "value": "web" } } },
+    "api": {
+      "resource": { "service.name": { "type": "string", "value": "api" } },
+      "http_status_codes": [ { "code": 200, "weight": 80 }, { "code": 503, "weight": 20 } ]
+    }
+  },
+  "nodes": {
+    "a": { "service": "web", "span_name": "GET /" },
+    "b": { "service": "api", "span_name": "GET /items" },
+    "c": { "service": "api", "span_name": "GET /items/{id}" }
+  },
+  "root": "a",
+  "edges": [
+    { "from": "a", "to": "b", "kind": "client_server", "repeat": 50, "duration_ms": 5 },
+    {
+      "from": "a", "to": "c", "kind": "client_server", "repeat": 50, "duration_ms": 5,
+      "http_status_codes": [ { "code": 404, "weight": 1 } ]
+    }
+  ]
+}`
+
+func TestGeneratorDrawsHTTPStatusCodesFromDistribution(t *testing.T) {
+	cfg, err := DecodeJSON(strings.NewReader(statusCodesScenario))
+	if err != nil {
+		t.Fatalf("DecodeJSON() error = %v", err)
+	}
+	definition, err := cfg.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	spans, err := NewGenerator(definition).GenerateBatch(context.Background())
+	if err != nil {
+		t.Fatalf("GenerateBatch() error = %v", err)
+	}
+
+	counts := map[string]map[int64]int{}
+	for _, span := range spans {
+		if span.Kind != oteltrace.SpanKindClient {
+			continue
+		}
+		value, ok := span.Attributes[statusCodeAttribute]
+		if !ok {
+			t.Fatalf("span %q: missing status code", span.Name)
+		}
+		code := value.AsInt64()
+		if counts[span.Name] == nil {
+			counts[span.Name] = map[int64]int{}
+		}
+		counts[span.Name][code]++
+		if wantErr := code >= 400; wantErr != (span.StatusCode == codes.Error) {
+			t.Fatalf("span %q code %d: unexpected status %v", span.Name, code, span.StatusCode)
+		}
+	}
+
+	items := counts["GET / -> GET /items"]
+	if items[200] == 0 || items[503] == 0 || items[200]+items[503] != 50 {
+		t.Fatalf("service distribution: expected a mix of 200 and 503, got %v", items)
+	}
+	if byID := counts["GET / -> GET /items/{id}"]; byID[404] != 50 {
+		t.Fatalf("edge distribution: expected only 404, got %v", byID)
+	}
+}
+
+func TestGeneratorHTTPStatusCodesAreDeterministic(t *testing.T) {
+	draw := func() []int64 {
+		cfg, err := DecodeJSON(strings.NewReader(statusCodesScenario))
+		if err != nil {
+			t.Fatalf("DecodeJSON() error = %v", err)
+		}
+		definition, err := cfg.Build()
+		if err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+		spans, err := NewGenerator(definition).GenerateBatch(context.Background())
+		if err != nil {
+			t.Fatalf("GenerateBatch() error = %v", err)
+		}
+		var out []int64
+		for _, span := range spans {
+			if value, ok := span.Attributes[statusCodeAttribute]; ok {
+				out = append(out, value.AsInt64())
+			}
+		}
+		return out
+	}
+
+	first, second := draw(), draw()
+	if len(first) != len(second) {
+		t.Fatalf("expected equal lengths, got %d and %d", len(first), len(second))
+	}
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("draw %d differs: %d vs %d", i, first[i], second[i])
+		}
+	}
+}
+
+func TestDecodeJSONRejectsInvalidHTTPStatusCodes(t *testing.T) {
+	tests := map[string]string{
+		`"http_status_codes": [ { "code": 404, "weight": 1 } ]`: "",
+		`"http_status_codes": [ { "code": 99, "weight": 1 } ]`:  "code must be between 100 and 599",
+		`"http_status_codes": [ { "code": 200, "weight": 0 } ]`: "weight must be > 0",
+	}
+	for field, want := range tests {
+		input := strings.Replace(statusCodesScenario, `"http_status_codes": [ { "code": 404, "weight": 1 } ]`, field, 1)
+		_, err := DecodeJSON(strings.NewReader(input))
+		if want == "" {
+			if err != nil {
+				t.Fatalf("%s: unexpected error %v", field, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("%s: expected error containing %q, got %v", field, want, err)
+		}
+	}
+
+	internal := strings.Replace(statusCodesScenario, `"from": "a", "to": "c", "kind": "client_server"`, `"from": "a", "to": "c", "kind": "internal"`, 1)
+	if _, err := DecodeJSON(strings.NewReader(internal)); err == nil || !strings.Contains(err.Error(), "only supported on client_server edges") {
+		t.Fatalf("expected client_server-only error, got %v", err)
+	}
+}
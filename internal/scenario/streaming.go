@@ -1,6 +1,7 @@
 package scenario
 
 import (
+	"context"
 	"time"
 
 	"github.com/javiermolinar/tercios/internal/model"
@@ -22,7 +23,7 @@ type StreamingWalker struct {
 // is nominally placed at startedAt. Consumes one sequence number from
 // the Generator's counter so successive walkers emit distinct traces.
 func (g *Generator) NewStreamingWalker(startedAt time.Time) (*StreamingWalker, error) {
-	w, err := g.newWalker(startedAt)
+	w, err := g.newWalker(context.Background(), startedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,171 @@
+// Package schema checks generated spans against a user-provided attribute
+// schema: which keys spans of a kind must carry and which type each key
+// must have. It keeps tercios output honest when it feeds pipelines that
+// reject or mis-index spans with missing or mistyped attributes.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/javiermolinar/tercios/internal/fileformat"
+	"github.com/javiermolinar/tercios/internal/model"
+	"github.com/javiermolinar/tercios/internal/typedvalue"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type Config struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Rule applies to spans of the listed kinds, or every span when Kinds is
+// empty. Span attributes are checked, and resource attributes when a key
+// is not a span attribute, so service.name and friends can be required
+// too.
+type Rule struct {
+	Kinds []string `json:"kinds,omitempty"`
+	// Required keys must be present.
+	Required []string `json:"required,omitempty"`
+	// Types maps keys to their allowed type. A key only needs the type
+	// when present, so optional keys can be typed without being required.
+	Types map[string]typedvalue.ValueType `json:"types,omitempty"`
+}
+
+var valueTypes = map[typedvalue.ValueType]attribute.Type{
+	typedvalue.ValueTypeString:      attribute.STRING,
+	typedvalue.ValueTypeInt:         attribute.INT64,
+	typedvalue.ValueTypeFloat:       attribute.FLOAT64,
+	typedvalue.ValueTypeBool:        attribute.BOOL,
+	typedvalue.ValueTypeStringArray: attribute.STRINGSLICE,
+	typedvalue.ValueTypeIntArray:    attribute.INT64SLICE,
+	typedvalue.ValueTypeFloatArray:  attribute.FLOAT64SLICE,
+	typedvalue.ValueTypeBoolArray:   attribute.BOOLSLICE,
+}
+
+var spanKinds = []string{"internal", "server", "client", "producer", "consumer"}
+
+func LoadFromJSON(path string) (Config, error) {
+	file, err := fileformat.Open(path)
+	if err != nil {
+		return Config{}, err
+	}
+	defer func() { _ = file.Close() }()
+	return DecodeJSON(file)
+}
+
+func DecodeJSON(r io.Reader) (Config, error) {
+	var cfg Config
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&cfg); err != nil {
+		return Config{}, err
+	}
+	if err := decoder.Decode(&struct{}{}); err != io.EOF {
+		return Config{}, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+func (c Config) Validate() error {
+	if len(c.Rules) == 0 {
+		return fmt.Errorf("at least one rule is required")
+	}
+	for i, rule := range c.Rules {
+		for _, kind := range rule.Kinds {
+			if !validKind(kind) {
+				return fmt.Errorf("rule %d: unsupported span kind %q (%s)", i, kind, strings.Join(spanKinds, ", "))
+			}
+		}
+		if len(rule.Required) == 0 && len(rule.Types) == 0 {
+			return fmt.Errorf("rule %d: required or types must be set", i)
+		}
+		for _, key := range rule.Required {
+			if strings.TrimSpace(key) == "" {
+				return fmt.Errorf("rule %d: required keys must not be empty", i)
+			}
+		}
+		for key, valueType := range rule.Types {
+			if _, ok := valueTypes[valueType]; !ok {
+				return fmt.Errorf("rule %d: key %q: unsupported type %q", i, key, valueType)
+			}
+		}
+	}
+	return nil
+}
+
+func validKind(kind string) bool {
+	for _, known := range spanKinds {
+		if strings.ToLower(strings.TrimSpace(kind)) == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Check returns the schema violations of span, one short description per
+// violated key, e.g. "server: missing http.route". The descriptions carry
+// no values, so they can be counted across spans.
+func (c Config) Check(span model.Span) []string {
+	kind := span.Kind.String()
+	var violations []string
+	for _, rule := range c.Rules {
+		if !rule.applies(kind) {
+			continue
+		}
+		for _, key := range rule.Required {
+			if _, ok := lookup(span, key); !ok {
+				violations = append(violations, fmt.Sprintf("%s: missing %s", kind, key))
+			}
+		}
+		keys := make([]string, 0, len(rule.Types))
+		for key := range rule.Types {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			value, ok := lookup(span, key)
+			if !ok {
+				continue
+			}
+			if want := rule.Types[key]; value.Type() != valueTypes[want] {
+				violations = append(violations, fmt.Sprintf("%s: %s is %s, want %s", kind, key, typeName(value.Type()), want))
+			}
+		}
+	}
+	return violations
+}
+
+func (r Rule) applies(kind string) bool {
+	if len(r.Kinds) == 0 {
+		return true
+	}
+	for _, candidate := range r.Kinds {
+		if strings.ToLower(strings.TrimSpace(candidate)) == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func lookup(span model.Span, key string) (attribute.Value, bool) {
+	if value, ok := span.Attributes[key]; ok {
+		return value, true
+	}
+	value, ok := span.ResourceAttributes[key]
+	return value, ok
+}
+
+func typeName(t attribute.Type) string {
+	for name, candidate := range valueTypes {
+		if candidate == t {
+			return string(name)
+		}
+	}
+	return strings.ToLower(t.String())
+}
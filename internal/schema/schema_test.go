@@ -0,0 +1,73 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/javiermolinar/tercios/internal/model"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+func TestDecodeJSONAndCheck(t *testing.T) {
+	cfg, err := DecodeJSON(strings.NewReader(`{
+  "rules": [
+    {"required": ["service.name"]},
+    {"kinds": ["server"], "required": ["http.route"], "types": {"http.response.status_code": "int", "http.request.method": "string"}}
+  ]
+}`))
+	if err != nil {
+		t.Fatalf("DecodeJSON() error = %v", err)
+	}
+
+	server := model.Span{
+		Kind: oteltrace.SpanKindServer,
+		Attributes: map[string]attribute.Value{
+			"http.route":                attribute.StringValue("/users/{id}"),
+			"http.response.status_code": attribute.Int64Value(200),
+		},
+		ResourceAttributes: map[string]attribute.Value{"service.name": attribute.StringValue("users")},
+	}
+	if got := cfg.Check(server); len(got) != 0 {
+		t.Fatalf("expected a valid server span, got %v", got)
+	}
+
+	server.Attributes = map[string]attribute.Value{"http.response.status_code": attribute.StringValue("200")}
+	server.ResourceAttributes = nil
+	want := []string{
+		"server: missing service.name",
+		"server: missing http.route",
+		"server: http.response.status_code is string, want int",
+	}
+	if got := cfg.Check(server); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Check() = %v, want %v", got, want)
+	}
+
+	// Server-only rules do not apply to client spans.
+	client := model.Span{Kind: oteltrace.SpanKindClient, Attributes: map[string]attribute.Value{"service.name": attribute.StringValue("api")}}
+	if got := cfg.Check(client); len(got) != 0 {
+		t.Fatalf("expected a valid client span, got %v", got)
+	}
+}
+
+func TestDecodeJSONRejectsInvalidSchema(t *testing.T) {
+	tests := map[string]struct {
+		input   string
+		wantMsg string
+	}{
+		"no rules":      {`{"rules": []}`, "at least one rule is required"},
+		"unknown kind":  {`{"rules": [{"kinds": ["backend"], "required": ["k"]}]}`, `unsupported span kind "backend"`},
+		"empty rule":    {`{"rules": [{"kinds": ["server"]}]}`, "required or types must be set"},
+		"unknown type":  {`{"rules": [{"types": {"k": "uuid"}}]}`, `unsupported type "uuid"`},
+		"unknown field": {`{"rules": [{"required": ["k"], "optional": ["j"]}]}`, "unknown field"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := DecodeJSON(strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantMsg, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+// Package servicegraph reads the service graph metrics that Tempo's
+// metrics generator and the OpenTelemetry Collector's servicegraph
+// connector write to Prometheus, so a scenario can be built from the
+// topology, call rates and latencies observed in production.
+package servicegraph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPrefix is the metric name prefix of both Tempo and the collector.
+const DefaultPrefix = "traces_service_graph"
+
+// Connection types set by the servicegraph processors on request metrics.
+const (
+	ConnectionMessaging   = "messaging_system"
+	ConnectionDatabase    = "database"
+	ConnectionVirtualNode = "virtual_node"
+)
+
+// Edge is the traffic observed from one client service to one server
+// service over the query window.
+type Edge struct {
+	Client string
+	Server string
+	// ConnectionType is empty for synchronous calls, or one of the
+	// Connection constants.
+	ConnectionType string
+	// Rate is the number of requests per second.
+	Rate float64
+	// ErrorRate is the fraction of requests that failed.
+	ErrorRate float64
+	// Latency is the mean server-side request duration, or 0 when the
+	// histogram has no samples.
+	Latency time.Duration
+}
+
+// Querier runs instant queries against the Prometheus HTTP API at URL.
+type Querier struct {
+	URL string
+	// Window is the range of the rate() queries.
+	Window time.Duration
+	// Prefix is the metric name prefix; empty means DefaultPrefix.
+	Prefix  string
+	Headers map[string]string
+	Client  *http.Client
+}
+
+func NewQuerier(url string, window time.Duration) Querier {
+	return Querier{
+		URL:    strings.TrimRight(url, "/"),
+		Window: window,
+		Client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Edges returns every client/server pair with traffic in the window,
+// sorted by client and server.
+func (q Querier) Edges(ctx context.Context) ([]Edge, error) {
+	prefix := q.Prefix
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	window := "[" + strconv.FormatInt(int64(q.Window/time.Second), 10) + "s]"
+	rate := func(metric string, labels string) string {
+		return fmt.Sprintf("sum by (%s) (rate(%s_%s%s))", labels, prefix, metric, window)
+	}
+
+	requests, err := q.query(ctx, rate("request_total", "client, server, connection_type"))
+	if err != nil {
+		return nil, err
+	}
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("no %s_request_total samples in the last %s", prefix, q.Window)
+	}
+	failed, err := q.query(ctx, rate("request_failed_total", "client, server"))
+	if err != nil {
+		return nil, err
+	}
+	latency, err := q.query(ctx, rate("request_server_seconds_sum", "client, server")+" / "+rate("request_server_seconds_count", "client, server"))
+	if err != nil {
+		return nil, err
+	}
+
+	pair := func(labels map[string]string) string { return labels["client"] + "\x00" + labels["server"] }
+	failedRates := map[string]float64{}
+	for _, sample := range failed {
+		failedRates[pair(sample.labels)] += sample.value
+	}
+	latencies := map[string]float64{}
+	for _, sample := range latency {
+		latencies[pair(sample.labels)] = sample.value
+	}
+	var edges []Edge
+	for _, sample := range requests {
+		if sample.value <= 0 {
+			continue
+		}
+		key := pair(sample.labels)
+		edge := Edge{
+			Client:         sample.labels["client"],
+			Server:         sample.labels["server"],
+			ConnectionType: sample.labels["connection_type"],
+			Rate:           sample.value,
+			ErrorRate:      min(failedRates[key]/sample.value, 1),
+		}
+		if seconds, ok := latencies[key]; ok && !math.IsNaN(seconds) && !math.IsInf(seconds, 0) && seconds > 0 {
+			edge.Latency = time.Duration(seconds * float64(time.Second))
+		}
+		edges = append(edges, edge)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Client != edges[j].Client {
+			return edges[i].Client < edges[j].Client
+		}
+		return edges[i].Server < edges[j].Server
+	})
+	return edges, nil
+}
+
+type sample struct {
+	labels map[string]string
+	value  float64
+}
+
+// query runs an instant query and returns its vector result.
+func (q Querier) query(ctx context.Context, promQL string) ([]sample, error) {
+	endpoint := q.URL + "/api/v1/query?" + url.Values{"query": {promQL}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range q.Headers {
+		req.Header.Set(key, value)
+	}
+	client := q.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var response struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+		Data   struct {
+			ResultType string `json:"resultType"`
+			Result     []struct {
+				Metric map[string]string `json:"metric"`
+				Value  [2]any            `json:"value"`
+			} `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("query %s: unexpected response (status %s): %w", q.URL, resp.Status, err)
+	}
+	if response.Status != "success" {
+		return nil, fmt.Errorf("query %s: %s", q.URL, response.Error)
+	}
+	if response.Data.ResultType != "vector" {
+		return nil, fmt.Errorf("query %s: unexpected result type %q", q.URL, response.Data.ResultType)
+	}
+	samples := make([]sample, 0, len(response.Data.Result))
+	for _, result := range response.Data.Result {
+		text, ok := result.Value[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("query %s: sample value is not a string", q.URL)
+		}
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("query %s: %w", q.URL, err)
+		}
+		samples = append(samples, sample{labels: result.Metric, value: value})
+	}
+	return samples, nil
+}
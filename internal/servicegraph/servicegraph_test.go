@@ -0,0 +1,71 @@
+package servicegraph
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQuerierEdges(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query" || r.Header.Get("X-Scope-OrgID") != "tenant" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		query := r.URL.Query().Get("query")
+		queries = append(queries, query)
+		result := ""
+		switch {
+		case strings.Contains(query, "request_server_seconds_sum"):
+			result = `{"metric":{"client":"frontend","server":"checkout"},"value":[1,"0.05"]},
+				{"metric":{"client":"user","server":"frontend"},"value":[1,"NaN"]}`
+		case strings.Contains(query, "request_failed_total"):
+			result = `{"metric":{"client":"frontend","server":"checkout"},"value":[1,"0.5"]}`
+		default:
+			result = `{"metric":{"client":"user","server":"frontend","connection_type":"virtual_node"},"value":[1,"4"]},
+				{"metric":{"client":"frontend","server":"checkout","connection_type":""},"value":[1,"2"]},
+				{"metric":{"client":"frontend","server":"idle"},"value":[1,"0"]}`
+		}
+		_, _ = fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[%s]}}`, result)
+	}))
+	defer server.Close()
+
+	querier := NewQuerier(server.URL+"/", 5*time.Minute)
+	querier.Headers = map[string]string{"X-Scope-OrgID": "tenant"}
+	edges, err := querier.Edges(context.Background())
+	if err != nil {
+		t.Fatalf("Edges: %v", err)
+	}
+	if len(queries) != 3 || !strings.Contains(queries[0], "rate(traces_service_graph_request_total[300s])") {
+		t.Fatalf("queries = %q", queries)
+	}
+	want := []Edge{
+		{Client: "frontend", Server: "checkout", Rate: 2, ErrorRate: 0.25, Latency: 50 * time.Millisecond},
+		{Client: "user", Server: "frontend", ConnectionType: ConnectionVirtualNode, Rate: 4},
+	}
+	if len(edges) != len(want) {
+		t.Fatalf("edges = %+v", edges)
+	}
+	for i := range want {
+		if edges[i] != want[i] {
+			t.Fatalf("edge %d = %+v, want %+v", i, edges[i], want[i])
+		}
+	}
+}
+
+func TestQuerierEdgesReportsErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprint(w, `{"status":"error","error":"parse error"}`)
+	}))
+	defer server.Close()
+
+	if _, err := NewQuerier(server.URL, time.Minute).Edges(context.Background()); err == nil || !strings.Contains(err.Error(), "parse error") {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+// Package synthetics supports running tercios permanently at a low rate as
+// a synthetic tracing canary: a health tracker turns export outcomes into
+// periodic status lines that log-based alerting can match, an outage
+// detector reports sustained failure windows to a webhook, and a log file
+// rotates by size so a process running for months cannot fill the disk.
+package synthetics
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/clock"
+)
+
+// Health tracks export outcomes between two reports. It is safe for
+// concurrent use.
+type Health struct {
+	mu sync.Mutex
+	// Counters since the previous report.
+	requests int
+	failures int
+	spans    int
+	// consecutive counts failed exports since the last success, across
+	// reports, so a long outage stays visible.
+	consecutive int
+	lastErr     error
+	lastSuccess time.Time
+	now         func() time.Time
+}
+
+func NewHealth() *Health {
+	return &Health{now: time.Now}
+}
+
+// Record adds one finished export of spans spans, failed when err is set.
+func (h *Health) Record(spans int, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.requests++
+	if err != nil {
+		h.failures++
+		h.consecutive++
+		h.lastErr = err
+		return
+	}
+	h.spans += spans
+	h.consecutive = 0
+	h.lastSuccess = h.now()
+}
+
+// Report returns one status line covering the exports since the previous
+// report and starts a new window. The status is "ok" while the last export
+// succeeded, "failing" once exports fail, and "idle" when nothing finished
+// in the window, e.g. because exports hang.
+func (h *Health) Report() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	status := "ok"
+	switch {
+	case h.consecutive > 0:
+		status = "failing"
+	case h.requests == 0:
+		status = "idle"
+	}
+	fields := []string{
+		"synthetics status=" + status,
+		"requests=" + strconv.Itoa(h.requests),
+		"failed=" + strconv.Itoa(h.failures),
+		"spans=" + strconv.Itoa(h.spans),
+		"consecutive_failures=" + strconv.Itoa(h.consecutive),
+	}
+	if !h.lastSuccess.IsZero() {
+		fields = append(fields, "last_success="+h.lastSuccess.UTC().Format(time.RFC3339))
+	}
+	if h.consecutive > 0 && h.lastErr != nil {
+		fields = append(fields, fmt.Sprintf("last_error=%q", h.lastErr.Error()))
+	}
+	h.requests, h.failures, h.spans = 0, 0, 0
+	return strings.Join(fields, " ")
+}
+
+// ReportEvery calls report with a status line every interval until ctx is
+// done, on wall-clock multiples of interval when align is set.
+func ReportEvery(ctx context.Context, interval time.Duration, align bool, health *Health, report func(line string)) {
+	ticker := clock.NewTicker(interval, align)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report(health.Report())
+		}
+	}
+}
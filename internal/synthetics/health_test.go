@@ -0,0 +1,37 @@
+package synthetics
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHealthReportsWindowAndOutage(t *testing.T) {
+	health := NewHealth()
+	health.now = func() time.Time { return time.Date(2026, time.October, 1, 12, 0, 0, 0, time.UTC) }
+
+	if line := health.Report(); !strings.HasPrefix(line, "synthetics status=idle requests=0") {
+		t.Fatalf("expected an idle report before any export, got %q", line)
+	}
+	health.Record(5, nil)
+	health.Record(5, nil)
+	if line := health.Report(); line != "synthetics status=ok requests=2 failed=0 spans=10 consecutive_failures=0 last_success=2026-10-01T12:00:00Z" {
+		t.Fatalf("unexpected ok report %q", line)
+	}
+
+	health.Record(5, errors.New("connection refused"))
+	health.Record(5, errors.New("connection refused"))
+	line := health.Report()
+	if !strings.HasPrefix(line, "synthetics status=failing requests=2 failed=2 spans=0 consecutive_failures=2") || !strings.Contains(line, `last_error="connection refused"`) {
+		t.Fatalf("unexpected failing report %q", line)
+	}
+	// An outage with no finished export in the window stays failing.
+	if line := health.Report(); !strings.HasPrefix(line, "synthetics status=failing requests=0") {
+		t.Fatalf("expected the outage to carry over, got %q", line)
+	}
+	health.Record(5, nil)
+	if line := health.Report(); !strings.HasPrefix(line, "synthetics status=ok requests=1 failed=0 spans=5 consecutive_failures=0") {
+		t.Fatalf("expected recovery to report ok, got %q", line)
+	}
+}
@@ -0,0 +1,98 @@
+package synthetics
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// LogFile is an append-only log file rotated by size. When a write would
+// grow the file past MaxBytes, the file is renamed to PATH.1, older
+// backups shift up to PATH.N, the oldest is removed and a new file is
+// started. It is safe for concurrent use, so it can back the log package.
+type LogFile struct {
+	path     string
+	maxBytes int64
+	backups  int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// OpenLogFile opens path for appending, keeping up to backups rotated
+// files of at most maxBytes each.
+func OpenLogFile(path string, maxBytes int64, backups int) (*LogFile, error) {
+	if maxBytes <= 0 {
+		return nil, fmt.Errorf("log max bytes must be > 0")
+	}
+	if backups < 0 {
+		return nil, fmt.Errorf("log backups must be >= 0")
+	}
+	l := &LogFile{path: path, maxBytes: maxBytes, backups: backups}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *LogFile) open() error {
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	l.file, l.size = file, info.Size()
+	return nil
+}
+
+func (l *LogFile) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.size > 0 && l.size+int64(len(p)) > l.maxBytes {
+		if err := l.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := l.file.Write(p)
+	l.size += int64(n)
+	return n, err
+}
+
+// rotate closes the current file and shifts it into the backups. With no
+// backups the file is simply started over.
+func (l *LogFile) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+	if l.backups == 0 {
+		if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return l.open()
+	}
+	backup := func(n int) string { return l.path + "." + strconv.Itoa(n) }
+	if err := os.Remove(backup(l.backups)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for n := l.backups - 1; n >= 1; n-- {
+		if err := os.Rename(backup(n), backup(n+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(l.path, backup(1)); err != nil {
+		return err
+	}
+	return l.open()
+}
+
+func (l *LogFile) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
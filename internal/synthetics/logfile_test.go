@@ -0,0 +1,64 @@
+package synthetics
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLogFileRotatesBySizeAndKeepsBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tercios.log")
+	logFile, err := OpenLogFile(path, 20, 2)
+	if err != nil {
+		t.Fatalf("OpenLogFile() error = %v", err)
+	}
+	for _, line := range []string{"first line\n", "second line\n", "third line\n", "fourth line\n"} {
+		if _, err := logFile.Write([]byte(line)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := logFile.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	read := func(name string) string {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		return string(data)
+	}
+	if got := read(path); got != "fourth line\n" {
+		t.Fatalf("current log = %q", got)
+	}
+	if got := read(path + ".1"); got != "third line\n" {
+		t.Fatalf("first backup = %q", got)
+	}
+	if got := read(path + ".2"); got != "second line\n" {
+		t.Fatalf("second backup = %q", got)
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Fatalf("expected only 2 backups, stat .3: %v", err)
+	}
+}
+
+func TestLogFileAppendsToExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tercios.log")
+	if err := os.WriteFile(path, []byte("before restart\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	logFile, err := OpenLogFile(path, 1<<20, 1)
+	if err != nil {
+		t.Fatalf("OpenLogFile() error = %v", err)
+	}
+	_, _ = logFile.Write([]byte("after restart\n"))
+	_ = logFile.Close()
+	data, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(data), "before restart\nafter restart") {
+		t.Fatalf("expected the log to be appended to, got %q", data)
+	}
+	if _, err := OpenLogFile(path, 0, 1); err == nil {
+		t.Fatalf("expected an error for a zero size limit")
+	}
+}
@@ -0,0 +1,173 @@
+package synthetics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event types reported by Outage.
+const (
+	EventOutage    = "outage"
+	EventRecovered = "recovered"
+)
+
+// Event is one transition of an ingest outage. An outage event is sent
+// once exports have failed without a success for the alert window, and a
+// recovered event on the first success after it.
+type Event struct {
+	Type string `json:"event"`
+	// Since is the time of the first failed export of the outage.
+	Since time.Time `json:"since"`
+	At    time.Time `json:"at"`
+	// FailedExports counts the failed exports since Since.
+	FailedExports int    `json:"failed_exports"`
+	LastError     string `json:"last_error,omitempty"`
+}
+
+// String formats the event as a log line, next to the health lines.
+func (e Event) String() string {
+	fields := []string{
+		"synthetics event=" + e.Type,
+		"since=" + e.Since.UTC().Format(time.RFC3339),
+		"duration=" + e.At.Sub(e.Since).Round(time.Second).String(),
+		"failed=" + strconv.Itoa(e.FailedExports),
+	}
+	if e.LastError != "" {
+		fields = append(fields, fmt.Sprintf("last_error=%q", e.LastError))
+	}
+	return strings.Join(fields, " ")
+}
+
+// Outage detects sustained export failure windows. It is safe for
+// concurrent use.
+type Outage struct {
+	after  time.Duration
+	notify func(Event)
+	now    func() time.Time
+
+	mu       sync.Mutex
+	since    time.Time
+	failures int
+	lastErr  error
+	open     bool
+}
+
+// NewOutage returns a detector that calls notify when exports have failed
+// for at least after, and again when they recover. notify is called from
+// Record, so it must not block.
+func NewOutage(after time.Duration, notify func(Event)) *Outage {
+	return &Outage{after: after, notify: notify, now: time.Now}
+}
+
+// Record adds one finished export, failed when err is set. The signature
+// matches Health.Record.
+func (o *Outage) Record(_ int, err error) {
+	o.mu.Lock()
+	now := o.now()
+	var event *Event
+	if err != nil {
+		if o.failures == 0 {
+			o.since = now
+		}
+		o.failures++
+		o.lastErr = err
+		if !o.open && now.Sub(o.since) >= o.after {
+			o.open = true
+			event = o.event(EventOutage, now)
+		}
+	} else {
+		if o.open {
+			event = o.event(EventRecovered, now)
+		}
+		o.open = false
+		o.failures = 0
+		o.lastErr = nil
+	}
+	o.mu.Unlock()
+	if event != nil {
+		o.notify(*event)
+	}
+}
+
+func (o *Outage) event(kind string, now time.Time) *Event {
+	event := &Event{Type: kind, Since: o.since, At: now, FailedExports: o.failures}
+	if o.lastErr != nil {
+		event.LastError = o.lastErr.Error()
+	}
+	return event
+}
+
+// Webhook posts events as JSON to a URL. Events are queued and sent one
+// at a time in order by Run, so a slow endpoint never holds up the run.
+type Webhook struct {
+	URL    string
+	Client *http.Client
+	queue  chan Event
+}
+
+func NewWebhook(url string) *Webhook {
+	return &Webhook{
+		URL:    url,
+		Client: &http.Client{Timeout: 10 * time.Second},
+		queue:  make(chan Event, 16),
+	}
+}
+
+// Notify queues event for Run. It reports false when the queue is full
+// and the event was dropped.
+func (w *Webhook) Notify(event Event) bool {
+	select {
+	case w.queue <- event:
+		return true
+	default:
+		return false
+	}
+}
+
+// Run posts queued events until ctx is done, passing delivery errors to
+// onError. A post cut short by ctx is not reported.
+func (w *Webhook) Run(ctx context.Context, onError func(error)) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-w.queue:
+			if err := w.Post(ctx, event); err != nil && ctx.Err() == nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// Post sends one event and checks for a 2xx response.
+func (w *Webhook) Post(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("alert webhook: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("alert webhook %s: unexpected status %s", w.URL, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package synthetics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestOutageReportsSustainedFailuresOnce(t *testing.T) {
+	start := time.Date(2026, time.October, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	var events []Event
+	outage := NewOutage(time.Minute, func(event Event) { events = append(events, event) })
+	outage.now = func() time.Time { return now }
+
+	// A short blip recovers before the window and is not reported.
+	outage.Record(5, errors.New("connection refused"))
+	now = now.Add(30 * time.Second)
+	outage.Record(5, nil)
+	if len(events) != 0 {
+		t.Fatalf("expected no events for a short blip, got %+v", events)
+	}
+
+	now = now.Add(10 * time.Second)
+	since := now
+	for range 7 {
+		outage.Record(5, errors.New("deadline exceeded"))
+		now = now.Add(10 * time.Second)
+	}
+	outage.Record(5, nil)
+
+	if len(events) != 2 {
+		t.Fatalf("expected one outage and one recovered event, got %+v", events)
+	}
+	if got := events[0]; got.Type != EventOutage || !got.Since.Equal(since) || got.FailedExports != 7 || got.LastError != "deadline exceeded" {
+		t.Fatalf("unexpected outage event %+v", got)
+	}
+	if got := events[1]; got.Type != EventRecovered || !got.Since.Equal(since) || got.FailedExports != 7 {
+		t.Fatalf("unexpected recovered event %+v", got)
+	}
+	if line := events[0].String(); line != `synthetics event=outage since=2026-10-01T12:00:40Z duration=1m0s failed=7 last_error="deadline exceeded"` {
+		t.Fatalf("unexpected event line %q", line)
+	}
+}
+
+func TestWebhookPostsEventsInOrder(t *testing.T) {
+	received := make(chan Event, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event Event
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("decode event: %v", err)
+		}
+		received <- event
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	webhook := NewWebhook(server.URL)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		webhook.Run(ctx, func(err error) { t.Errorf("post: %v", err) })
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+	webhook.Notify(Event{Type: EventOutage, FailedExports: 3})
+	webhook.Notify(Event{Type: EventRecovered, FailedExports: 3})
+
+	for _, want := range []string{EventOutage, EventRecovered} {
+		select {
+		case event := <-received:
+			if event.Type != want || event.FailedExports != 3 {
+				t.Fatalf("expected %s event, got %+v", want, event)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %s event", want)
+		}
+	}
+}
+
+func TestWebhookPostRejectsErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	if err := NewWebhook(server.URL).Post(context.Background(), Event{Type: EventOutage}); err == nil {
+		t.Fatalf("expected an error for a 500 response")
+	}
+}
@@ -0,0 +1,161 @@
+// Package timefmt renders timestamps and durations in the user-selected
+// format, time zone and unit, so every output (dry-run JSON, summary,
+// progress) reads the same way.
+package timefmt
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type TimestampFormat string
+
+const (
+	// TimestampRFC3339Nano always prints nine fractional digits.
+	TimestampRFC3339Nano  TimestampFormat = "rfc3339nano"
+	TimestampRFC3339Milli TimestampFormat = "rfc3339ms"
+	TimestampRFC3339      TimestampFormat = "rfc3339"
+	TimestampUnix         TimestampFormat = "unix"
+	TimestampUnixMilli    TimestampFormat = "unix_ms"
+	TimestampUnixMicro    TimestampFormat = "unix_us"
+	TimestampUnixNano     TimestampFormat = "unix_ns"
+)
+
+// DurationUnit selects the unit durations are printed in. DurationAuto
+// keeps each output's historical unit: milliseconds for latencies and
+// span durations, seconds for wall time.
+type DurationUnit string
+
+const (
+	DurationAuto        DurationUnit = ""
+	DurationNanoseconds DurationUnit = "ns"
+	DurationMicros      DurationUnit = "us"
+	DurationMillis      DurationUnit = "ms"
+	DurationSeconds     DurationUnit = "s"
+)
+
+type Options struct {
+	Timestamp    TimestampFormat
+	Location     *time.Location
+	DurationUnit DurationUnit
+}
+
+func DefaultOptions() Options {
+	return Options{Timestamp: TimestampRFC3339Nano, Location: time.UTC}
+}
+
+func ParseTimestampFormat(value string) (TimestampFormat, error) {
+	normalized := TimestampFormat(strings.ToLower(strings.TrimSpace(value)))
+	switch normalized {
+	case "":
+		return TimestampRFC3339Nano, nil
+	case TimestampRFC3339Nano, TimestampRFC3339Milli, TimestampRFC3339, TimestampUnix, TimestampUnixMilli, TimestampUnixMicro, TimestampUnixNano:
+		return normalized, nil
+	default:
+		return "", fmt.Errorf("unsupported time format %q (supported: rfc3339nano, rfc3339ms, rfc3339, unix, unix_ms, unix_us, unix_ns)", value)
+	}
+}
+
+// ParseLocation accepts UTC, Local or an IANA zone name such as
+// Europe/Madrid.
+func ParseLocation(value string) (*time.Location, error) {
+	trimmed := strings.TrimSpace(value)
+	switch strings.ToLower(trimmed) {
+	case "", "utc":
+		return time.UTC, nil
+	case "local":
+		return time.Local, nil
+	}
+	location, err := time.LoadLocation(trimmed)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported time zone %q: %w", value, err)
+	}
+	return location, nil
+}
+
+func ParseDurationUnit(value string) (DurationUnit, error) {
+	normalized := DurationUnit(strings.ToLower(strings.TrimSpace(value)))
+	switch normalized {
+	case "auto":
+		return DurationAuto, nil
+	case DurationAuto, DurationNanoseconds, DurationMicros, DurationMillis, DurationSeconds:
+		return normalized, nil
+	default:
+		return "", fmt.Errorf("unsupported duration unit %q (supported: auto, ns, us, ms, s)", value)
+	}
+}
+
+func (o Options) location() *time.Location {
+	if o.Location == nil {
+		return time.UTC
+	}
+	return o.Location
+}
+
+// FormatTime renders t as text.
+func (o Options) FormatTime(t time.Time) string {
+	return fmt.Sprint(o.TimeValue(t))
+}
+
+// TimeValue renders t for JSON: an integer for the unix formats and a
+// string otherwise.
+func (o Options) TimeValue(t time.Time) any {
+	switch o.Timestamp {
+	case TimestampUnix:
+		return t.Unix()
+	case TimestampUnixMilli:
+		return t.UnixMilli()
+	case TimestampUnixMicro:
+		return t.UnixMicro()
+	case TimestampUnixNano:
+		return t.UnixNano()
+	case TimestampRFC3339:
+		return t.In(o.location()).Format(time.RFC3339)
+	case TimestampRFC3339Milli:
+		return t.In(o.location()).Format("2006-01-02T15:04:05.000Z07:00")
+	default:
+		return t.In(o.location()).Format("2006-01-02T15:04:05.000000000Z07:00")
+	}
+}
+
+// FormatDuration renders d with its unit suffix. fallback is the unit used
+// when DurationUnit is DurationAuto.
+func (o Options) FormatDuration(d time.Duration, fallback DurationUnit) string {
+	switch o.Unit(fallback) {
+	case DurationNanoseconds:
+		return fmt.Sprintf("%dns", d.Nanoseconds())
+	case DurationMicros:
+		return fmt.Sprintf("%dus", d.Microseconds())
+	case DurationSeconds:
+		return fmt.Sprintf("%.3fs", d.Seconds())
+	default:
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	}
+}
+
+// Unit returns the selected duration unit, or fallback when it is
+// DurationAuto.
+func (o Options) Unit(fallback DurationUnit) DurationUnit {
+	if o.DurationUnit != DurationAuto {
+		return o.DurationUnit
+	}
+	return fallback
+}
+
+// Parse builds Options from the CLI flag values.
+func Parse(timestamp string, zone string, unit string) (Options, error) {
+	format, err := ParseTimestampFormat(timestamp)
+	if err != nil {
+		return Options{}, err
+	}
+	location, err := ParseLocation(zone)
+	if err != nil {
+		return Options{}, err
+	}
+	durationUnit, err := ParseDurationUnit(unit)
+	if err != nil {
+		return Options{}, err
+	}
+	return Options{Timestamp: format, Location: location, DurationUnit: durationUnit}, nil
+}
@@ -0,0 +1,93 @@
+package timefmt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		timestamp string
+		zone      string
+		unit      string
+		wantErr   bool
+	}{
+		{name: "defaults", timestamp: "", zone: "", unit: ""},
+		{name: "auto unit", timestamp: "rfc3339", zone: "UTC", unit: "auto"},
+		{name: "unix with iana zone", timestamp: "unix_ms", zone: "Europe/Madrid", unit: "us"},
+		{name: "local zone", timestamp: "RFC3339NANO", zone: "local", unit: "S"},
+		{name: "invalid format", timestamp: "iso", wantErr: true},
+		{name: "invalid zone", zone: "Mars/Olympus", wantErr: true},
+		{name: "invalid unit", unit: "minutes", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.timestamp, tt.zone, tt.unit)
+			if tt.wantErr && err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestTimeValue(t *testing.T) {
+	ts := time.Date(2026, time.March, 1, 10, 0, 0, 123456789, time.UTC)
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		options Options
+		want    any
+	}{
+		{name: "zero value", options: Options{}, want: "2026-03-01T10:00:00.123456789Z"},
+		{name: "rfc3339", options: Options{Timestamp: TimestampRFC3339}, want: "2026-03-01T10:00:00Z"},
+		{name: "rfc3339ms in zone", options: Options{Timestamp: TimestampRFC3339Milli, Location: madrid}, want: "2026-03-01T11:00:00.123+01:00"},
+		{name: "unix", options: Options{Timestamp: TimestampUnix}, want: ts.Unix()},
+		{name: "unix_ms", options: Options{Timestamp: TimestampUnixMilli}, want: ts.UnixMilli()},
+		{name: "unix_us", options: Options{Timestamp: TimestampUnixMicro}, want: ts.UnixMicro()},
+		{name: "unix_ns ignores zone", options: Options{Timestamp: TimestampUnixNano, Location: madrid}, want: ts.UnixNano()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.options.TimeValue(ts); got != tt.want {
+				t.Fatalf("TimeValue() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	d := 1500 * time.Millisecond
+
+	tests := []struct {
+		name     string
+		unit     DurationUnit
+		fallback DurationUnit
+		want     string
+	}{
+		{name: "auto uses fallback ms", unit: DurationAuto, fallback: DurationMillis, want: "1500ms"},
+		{name: "auto uses fallback s", unit: DurationAuto, fallback: DurationSeconds, want: "1.500s"},
+		{name: "ns", unit: DurationNanoseconds, fallback: DurationMillis, want: "1500000000ns"},
+		{name: "us", unit: DurationMicros, fallback: DurationSeconds, want: "1500000us"},
+		{name: "ms overrides fallback", unit: DurationMillis, fallback: DurationSeconds, want: "1500ms"},
+		{name: "s", unit: DurationSeconds, fallback: DurationMillis, want: "1.500s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := Options{DurationUnit: tt.unit}
+			if got := options.FormatDuration(d, tt.fallback); got != tt.want {
+				t.Fatalf("FormatDuration() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
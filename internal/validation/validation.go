@@ -0,0 +1,112 @@
+// Package validation collects config validation problems so that a file
+// is reported in one pass, with every problem under the JSON path of the
+// field it concerns, instead of one fix-and-rerun cycle per mistake.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Problem is one invalid field.
+type Problem struct {
+	// Path is the JSON path of the field or object, e.g. edges[3] or
+	// services["api"].resource["service.name"]. It is empty for problems
+	// with the document as a whole.
+	Path    string
+	Message string
+}
+
+func (p Problem) Error() string {
+	if p.Path == "" {
+		return p.Message
+	}
+	return p.Path + ": " + p.Message
+}
+
+// List is the error returned for one or more problems. A single problem
+// reads as a plain error; several are listed one per line.
+type List []Problem
+
+func (l List) Error() string {
+	if len(l) == 1 {
+		return l[0].Error()
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d problems:", len(l))
+	for _, problem := range l {
+		b.WriteString("\n  ")
+		b.WriteString(problem.Error())
+	}
+	return b.String()
+}
+
+// Errors accumulates problems. The zero value is ready to use.
+type Errors struct {
+	problems List
+}
+
+// Addf records a problem at path.
+func (e *Errors) Addf(path, format string, args ...any) {
+	e.problems = append(e.problems, Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// Add records err at path and does nothing for a nil err. The problems of
+// a List keep their own paths, nested under path.
+func (e *Errors) Add(path string, err error) {
+	if err == nil {
+		return
+	}
+	var list List
+	if errors.As(err, &list) {
+		for _, problem := range list {
+			e.problems = append(e.problems, Problem{Path: join(path, problem.Path), Message: problem.Message})
+		}
+		return
+	}
+	e.problems = append(e.problems, Problem{Path: path, Message: err.Error()})
+}
+
+// Len returns the number of problems recorded so far, for checks that
+// only make sense on an otherwise valid document.
+func (e *Errors) Len() int {
+	return len(e.problems)
+}
+
+// Err returns nil when no problem was recorded, or a List of them all.
+func (e *Errors) Err() error {
+	if len(e.problems) == 0 {
+		return nil
+	}
+	return e.problems
+}
+
+// Field returns the path of the named field of the object at path.
+func Field(path, name string) string {
+	return join(path, name)
+}
+
+// Index returns the path of element i of the array at path.
+func Index(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
+
+// Key returns the path of entry key of the map at path.
+func Key(path, key string) string {
+	return path + "[" + strconv.Quote(key) + "]"
+}
+
+func join(path, child string) string {
+	switch {
+	case path == "":
+		return child
+	case child == "":
+		return path
+	case strings.HasPrefix(child, "["):
+		return path + child
+	default:
+		return path + "." + child
+	}
+}
@@ -0,0 +1,55 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrorsListsEveryProblemWithItsPath(t *testing.T) {
+	var errs Errors
+	if errs.Err() != nil {
+		t.Fatalf("expected no error before any problem")
+	}
+	errs.Addf("", "name is required")
+	errs.Addf(Index("edges", 3), "repeat must be > 0")
+	errs.Add(Key(Field(Index("edges", 3), "span_attributes"), "http.route"), fmt.Errorf("value is required"))
+	errs.Add("ignored", nil)
+
+	err := errs.Err()
+	want := "3 problems:\n" +
+		"  name is required\n" +
+		"  edges[3]: repeat must be > 0\n" +
+		"  edges[3].span_attributes[\"http.route\"]: value is required"
+	if err == nil || err.Error() != want {
+		t.Fatalf("Err() = %v, want %q", err, want)
+	}
+	if errs.Len() != 3 {
+		t.Fatalf("Len() = %d, want 3", errs.Len())
+	}
+}
+
+func TestErrorsNestsListPaths(t *testing.T) {
+	var inner Errors
+	inner.Addf("", "unsupported policy mode")
+	inner.Addf(Index("policies", 0), "name is required")
+
+	var outer Errors
+	outer.Add("chaos", fmt.Errorf("load: %w", inner.Err()))
+
+	var list List
+	if !errors.As(outer.Err(), &list) || len(list) != 2 {
+		t.Fatalf("expected a list of 2 problems, got %v", outer.Err())
+	}
+	if list[0].Path != "chaos" || list[1].Path != "chaos.policies[0]" {
+		t.Fatalf("unexpected nested paths %q and %q", list[0].Path, list[1].Path)
+	}
+}
+
+func TestSingleProblemReadsAsPlainError(t *testing.T) {
+	var errs Errors
+	errs.Addf("endpoint", "address is required")
+	if got := errs.Err().Error(); got != "endpoint: address is required" {
+		t.Fatalf("Err() = %q", got)
+	}
+}
@@ -0,0 +1,292 @@
+// Package determinism checks that a tercios run is reproducible: the same
+// scenarios, chaos policies and seed must produce byte-identical OTLP
+// requests. Programs that embed or extend tercios can call it from their
+// tests to catch accidental nondeterminism, such as iterating a map or
+// reading an unseeded random source.
+package determinism
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/model"
+	"github.com/javiermolinar/tercios/internal/otlp"
+	"github.com/javiermolinar/tercios/internal/pipeline"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// Epoch is the time every trace is moved to before it is encoded. The
+// generator stamps traces with the wall clock, so only the offsets of a
+// trace's spans and events from its first span can repeat between runs.
+var Epoch = time.Unix(1700000000, 0).UTC()
+
+// Options selects what each run generates.
+type Options struct {
+	// ScenarioFiles are scenario files or builtin:NAME scenarios; empty
+	// uses the embedded default scenario.
+	ScenarioFiles []string
+	// Strategy picks among several scenarios, as --scenario-strategy.
+	Strategy string
+	// ChaosPoliciesFile is an optional chaos policies file. Edge chaos in
+	// the scenario files is applied too.
+	ChaosPoliciesFile string
+	// Seed is the scenario run seed, and seeds chaos, jitter, events and
+	// cardinality values the way --seed does. Zero means 1.
+	Seed int64
+	// Requests is the number of requests of each run. Zero means 10.
+	Requests int
+	// TracesPerRequest is the number of traces in each request. Zero
+	// means 1.
+	TracesPerRequest int
+
+	// JitterAttributes and JitterSpans vary attribute values and drop
+	// spans per request, as --jitter-attributes and --jitter-spans.
+	JitterAttributes float64
+	JitterSpans      float64
+	// EventsPerSpan adds synthetic span events, as --events-per-span, to
+	// spans picked with EventsProbability. A zero probability means 1.
+	EventsPerSpan     int
+	EventsProbability float64
+	// CardinalityAttributes are KEY=N high-cardinality span attributes, as
+	// --cardinality-attribute.
+	CardinalityAttributes []string
+	// SemconvProfiles is a comma-separated list of semantic convention
+	// profiles, as --semconv-profiles.
+	SemconvProfiles string
+	// UniqueSpanNames renames spans with unique names, as
+	// --unique-span-names, at most UniqueSpanNamesPerMinute new names per
+	// minute when set. UniqueResourceAttribute also gets the unique value.
+	// The run token of the names comes from Seed.
+	UniqueSpanNames          bool
+	UniqueSpanNamesPerMinute float64
+	UniqueResourceAttribute  string
+	// FutureFraction of traces are dated FutureOffset ahead, as
+	// --future-fraction and --future-offset. A zero offset means 1h.
+	FutureFraction float64
+	FutureOffset   time.Duration
+	// BackfillDays dates requests day by day into the past, as
+	// --backfill-days, BackfillRequestsPerDay a day. A zero number of
+	// requests means 100.
+	BackfillDays           int
+	BackfillRequestsPerDay int
+}
+
+// Report describes the output both runs agreed on.
+type Report struct {
+	Requests int
+	Spans    int
+	Bytes    int
+}
+
+// MismatchError is returned by Check when the runs differ. Request is the
+// index of the first request that differs, and First and Second are its
+// encoded bodies; either is nil when that run sent fewer requests.
+type MismatchError struct {
+	Request int
+	First   []byte
+	Second  []byte
+}
+
+func (e *MismatchError) Error() string {
+	if e.First == nil || e.Second == nil {
+		return fmt.Sprintf("runs sent a different number of requests: request %d is missing from one of them", e.Request)
+	}
+	offset := 0
+	for offset < len(e.First) && offset < len(e.Second) && e.First[offset] == e.Second[offset] {
+		offset++
+	}
+	return fmt.Sprintf("request %d differs between runs at byte %d (%d and %d bytes)", e.Request, offset, len(e.First), len(e.Second))
+}
+
+// Check runs the generation stages of the pipeline twice with the same
+// seed, each into an in-memory sink, and compares the OTLP protobuf
+// body of every request. It returns a *MismatchError when they differ.
+func Check(ctx context.Context, opts Options) (Report, error) {
+	if opts.Seed == 0 {
+		opts.Seed = 1
+	}
+	if opts.Requests == 0 {
+		opts.Requests = 10
+	}
+	if opts.TracesPerRequest == 0 {
+		opts.TracesPerRequest = 1
+	}
+	if opts.Requests < 0 || opts.TracesPerRequest < 0 {
+		return Report{}, fmt.Errorf("requests and traces per request must be positive")
+	}
+
+	first, err := run(ctx, opts)
+	if err != nil {
+		return Report{}, fmt.Errorf("first run: %w", err)
+	}
+	second, err := run(ctx, opts)
+	if err != nil {
+		return Report{}, fmt.Errorf("second run: %w", err)
+	}
+	for i := range max(len(first.bodies), len(second.bodies)) {
+		if i >= len(first.bodies) || i >= len(second.bodies) || !bytes.Equal(first.bodies[i], second.bodies[i]) {
+			mismatch := &MismatchError{Request: i}
+			if i < len(first.bodies) {
+				mismatch.First = first.bodies[i]
+			}
+			if i < len(second.bodies) {
+				mismatch.Second = second.bodies[i]
+			}
+			return Report{}, mismatch
+		}
+	}
+	report := Report{Requests: len(first.bodies), Spans: first.spans}
+	for _, body := range first.bodies {
+		report.Bytes += len(body)
+	}
+	return report, nil
+}
+
+// AssertDeterministic calls Check and fails the test when the runs
+// differ or cannot be set up.
+func AssertDeterministic(t testing.TB, opts Options) Report {
+	t.Helper()
+	report, err := Check(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("tercios output is not deterministic: %v", err)
+	}
+	return report
+}
+
+// run sends opts.Requests requests from a single worker, so they reach
+// the sink in the order they were generated.
+func run(ctx context.Context, opts Options) (*sink, error) {
+	stages, err := newStages(opts)
+	if err != nil {
+		return nil, err
+	}
+	out := &sink{}
+	runner := pipeline.NewConcurrencyRunner(1, opts.Requests)
+	if err := pipeline.New(stages...).RunWithOptions(ctx, runner, out, pipeline.RunOptions{}); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// newStages builds the stages of opts with the builder the CLI uses.
+func newStages(opts Options) ([]pipeline.BatchStage, error) {
+	stageOpts := pipeline.StageOptions{
+		ScenarioFiles:     opts.ScenarioFiles,
+		Strategy:          opts.Strategy,
+		RunSeed:           opts.Seed,
+		TracesPerRequest:  opts.TracesPerRequest,
+		ChaosPoliciesFile: opts.ChaosPoliciesFile,
+		Seed:              opts.Seed,
+	}
+	if opts.JitterAttributes > 0 || opts.JitterSpans > 0 {
+		stageOpts.Jitter = &pipeline.JitterConfig{
+			Attributes: opts.JitterAttributes,
+			Spans:      opts.JitterSpans,
+			Seed:       pipeline.DerivedSeed(opts.Seed, 3),
+		}
+	}
+	if opts.EventsPerSpan > 0 {
+		probability := opts.EventsProbability
+		if probability == 0 {
+			probability = 1
+		}
+		stageOpts.Events = &pipeline.EventsConfig{PerSpan: opts.EventsPerSpan, Probability: probability, Seed: pipeline.DerivedSeed(opts.Seed, 4)}
+	}
+	if len(opts.CardinalityAttributes) > 0 {
+		var attributes pipeline.CardinalityFlags
+		for _, value := range opts.CardinalityAttributes {
+			if err := attributes.Set(value); err != nil {
+				return nil, fmt.Errorf("invalid cardinality attribute: %w", err)
+			}
+		}
+		stageOpts.Cardinality = &pipeline.CardinalityConfig{Attributes: attributes.Values(), Seed: pipeline.DerivedSeed(opts.Seed, 5)}
+	}
+	if opts.SemconvProfiles != "" {
+		profiles, err := pipeline.ParseSemconvProfiles(opts.SemconvProfiles)
+		if err != nil {
+			return nil, fmt.Errorf("invalid semconv profiles: %w", err)
+		}
+		stageOpts.SemconvProfiles = profiles
+	}
+	if opts.UniqueSpanNames {
+		stageOpts.UniqueNames = &pipeline.UniqueNamesConfig{
+			PerMinute:         opts.UniqueSpanNamesPerMinute,
+			ResourceAttribute: opts.UniqueResourceAttribute,
+			Token:             strconv.FormatUint(uint64(opts.Seed), 36),
+		}
+	}
+	if opts.FutureFraction > 0 {
+		offset := opts.FutureOffset
+		if offset == 0 {
+			offset = time.Hour
+		}
+		stageOpts.FutureTimestamps = &pipeline.FutureTimestampsConfig{Fraction: opts.FutureFraction, Offset: offset}
+	}
+	if opts.BackfillDays > 0 {
+		perDay := opts.BackfillRequestsPerDay
+		if perDay == 0 {
+			perDay = 100
+		}
+		stageOpts.Backfill = &pipeline.BackfillConfig{Days: opts.BackfillDays, RequestsPerDay: perDay}
+	}
+	return pipeline.NewStages(stageOpts)
+}
+
+// sink records the encoded body of every request it is sent.
+type sink struct {
+	mu     sync.Mutex
+	bodies [][]byte
+	spans  int
+}
+
+func (s *sink) NewBatchExporter(context.Context) (model.BatchExporter, error) {
+	return s, nil
+}
+
+func (s *sink) ExportBatch(_ context.Context, batch model.Batch) error {
+	body, err := otlp.MarshalBatch(rebase(batch))
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bodies = append(s.bodies, body)
+	s.spans += len(batch)
+	return nil
+}
+
+func (s *sink) Shutdown(context.Context) error {
+	return nil
+}
+
+// rebase returns a copy of batch with every trace moved so its earliest
+// span starts at Epoch.
+func rebase(batch model.Batch) model.Batch {
+	starts := map[oteltrace.TraceID]time.Time{}
+	for _, span := range batch {
+		if start, ok := starts[span.TraceID]; !ok || span.StartTime.Before(start) {
+			starts[span.TraceID] = span.StartTime
+		}
+	}
+	out := make(model.Batch, len(batch))
+	for i, span := range batch {
+		shift := Epoch.Sub(starts[span.TraceID])
+		span.StartTime = span.StartTime.Add(shift)
+		span.EndTime = span.EndTime.Add(shift)
+		if len(span.Events) > 0 {
+			events := make([]model.Event, len(span.Events))
+			for j, event := range span.Events {
+				event.Time = event.Time.Add(shift)
+				events[j] = event
+			}
+			span.Events = events
+		}
+		out[i] = span
+	}
+	return out
+}
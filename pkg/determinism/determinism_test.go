@@ -0,0 +1,102 @@
+package determinism
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/javiermolinar/tercios/internal/model"
+)
+
+func TestCheckDefaultScenario(t *testing.T) {
+	report := AssertDeterministic(t, Options{Requests: 5, TracesPerRequest: 2})
+	if report.Requests != 5 || report.Spans == 0 || report.Bytes == 0 {
+		t.Fatalf("report = %+v", report)
+	}
+}
+
+func TestCheckWithChaosAndSeveralScenarios(t *testing.T) {
+	policies := filepath.Join(t.TempDir(), "chaos.json")
+	err := os.WriteFile(policies, []byte(`{
+  "policies": [
+    {
+      "name": "slow-errors",
+      "probability": 0.5,
+      "actions": [
+        {"type": "set_status", "code": "error", "message": "simulated failure"},
+        {"type": "add_latency", "delta_ms": 120}
+      ]
+    }
+  ]
+}`), 0o644)
+	if err != nil {
+		t.Fatalf("write policies: %v", err)
+	}
+	AssertDeterministic(t, Options{
+		ScenarioFiles:     []string{"builtin:ecommerce", "builtin:otel-demo"},
+		Strategy:          "random",
+		ChaosPoliciesFile: policies,
+		Seed:              7,
+		Requests:          8,
+	})
+}
+
+func TestCheckWithEveryGenerationStage(t *testing.T) {
+	AssertDeterministic(t, Options{
+		Seed:                  3,
+		Requests:              6,
+		TracesPerRequest:      2,
+		JitterAttributes:      0.5,
+		JitterSpans:           0.2,
+		EventsPerSpan:         2,
+		EventsProbability:     0.5,
+		CardinalityAttributes: []string{"user.id=100", "request.id=0"},
+		SemconvProfiles:       "http,db",
+		UniqueSpanNames:       true,
+		FutureFraction:        0.5,
+		BackfillDays:          2,
+	})
+}
+
+func TestCheckReportsSetupErrors(t *testing.T) {
+	_, err := Check(context.Background(), Options{ScenarioFiles: []string{"builtin:missing"}})
+	var mismatch *MismatchError
+	if err == nil || errors.As(err, &mismatch) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestMismatchError(t *testing.T) {
+	err := &MismatchError{Request: 3, First: []byte("abcd"), Second: []byte("abxd")}
+	if !strings.Contains(err.Error(), "request 3 differs between runs at byte 2") {
+		t.Fatalf("Error() = %q", err.Error())
+	}
+	err = &MismatchError{Request: 4, First: []byte("abcd")}
+	if !strings.Contains(err.Error(), "different number of requests") {
+		t.Fatalf("Error() = %q", err.Error())
+	}
+}
+
+func TestRebaseMovesEachTrace(t *testing.T) {
+	start := time.Now()
+	batch := model.Batch{
+		{TraceID: [16]byte{1}, StartTime: start.Add(5 * time.Millisecond), EndTime: start.Add(9 * time.Millisecond),
+			Events: []model.Event{{Name: "retry", Time: start.Add(6 * time.Millisecond)}}},
+		{TraceID: [16]byte{1}, StartTime: start, EndTime: start.Add(10 * time.Millisecond)},
+		{TraceID: [16]byte{2}, StartTime: start.Add(time.Second), EndTime: start.Add(2 * time.Second)},
+	}
+	out := rebase(batch)
+	if !out[1].StartTime.Equal(Epoch) || !out[0].StartTime.Equal(Epoch.Add(5*time.Millisecond)) || !out[0].Events[0].Time.Equal(Epoch.Add(6*time.Millisecond)) {
+		t.Fatalf("trace 1 = %+v", out[:2])
+	}
+	if !out[2].StartTime.Equal(Epoch) || !out[2].EndTime.Equal(Epoch.Add(time.Second)) {
+		t.Fatalf("trace 2 = %+v", out[2])
+	}
+	if !batch[0].Events[0].Time.Equal(start.Add(6 * time.Millisecond)) {
+		t.Fatal("rebase changed the input batch")
+	}
+}
@@ -0,0 +1,99 @@
+// Package verify queries tracing backends for the traces a run sent, so
+// features such as freshness measurement can check what actually became
+// queryable. Tempo and Jaeger are built in; other backends can be plugged
+// in by implementing VerificationBackend and calling Register.
+package verify
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Span is the backend's view of one stored span.
+type Span struct {
+	SpanID       string
+	ParentSpanID string
+	Name         string
+	ServiceName  string
+	Attributes   map[string]string
+}
+
+// Trace is the backend's view of one stored trace.
+type Trace struct {
+	TraceID string
+	Spans   []Span
+}
+
+// VerificationBackend reads traces back from a tracing backend. Trace IDs
+// are 32-character lowercase hex strings.
+type VerificationBackend interface {
+	// FindTrace returns the stored trace. found is false, with a nil
+	// error, when the backend does not know the trace yet.
+	FindTrace(ctx context.Context, traceID string) (trace Trace, found bool, err error)
+	// CountSpans returns how many spans of the trace are stored; zero when
+	// the trace is unknown.
+	CountSpans(ctx context.Context, traceID string) (int, error)
+	// SearchByAttr returns up to limit trace IDs having a span or resource
+	// attribute key equal to value.
+	SearchByAttr(ctx context.Context, key string, value string, limit int) ([]string, error)
+}
+
+// Factory builds a backend for the given base URL.
+type Factory func(url string) (VerificationBackend, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{
+		"tempo": func(url string) (VerificationBackend, error) {
+			return NewTempo(url), nil
+		},
+		"jaeger": func(url string) (VerificationBackend, error) {
+			return NewJaeger(url), nil
+		},
+	}
+)
+
+// Register makes a backend available to New under name. It fails when the
+// name is already taken.
+func Register(name string, factory Factory) error {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || factory == nil {
+		return fmt.Errorf("backend name and factory are required")
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, exists := registry[name]; exists {
+		return fmt.Errorf("verification backend %q already registered", name)
+	}
+	registry[name] = factory
+	return nil
+}
+
+// New returns the backend registered under name, pointed at url.
+func New(name string, url string) (VerificationBackend, error) {
+	registryMu.RLock()
+	factory, ok := registry[strings.ToLower(strings.TrimSpace(name))]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unsupported verification backend %q (supported: %s)", name, strings.Join(Names(), ", "))
+	}
+	if strings.TrimSpace(url) == "" {
+		return nil, fmt.Errorf("verification backend %q needs a URL", name)
+	}
+	return factory(url)
+}
+
+// Names lists the registered backends in alphabetical order.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
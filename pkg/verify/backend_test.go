@@ -0,0 +1,57 @@
+package verify
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type stubBackend struct{}
+
+func (stubBackend) FindTrace(context.Context, string) (Trace, bool, error) {
+	return Trace{}, false, nil
+}
+
+func (stubBackend) CountSpans(context.Context, string) (int, error) {
+	return 0, nil
+}
+
+func (stubBackend) SearchByAttr(context.Context, string, string, int) ([]string, error) {
+	return nil, nil
+}
+
+func TestNewBuiltinBackends(t *testing.T) {
+	backend, err := New("Tempo", "http://localhost:3200/")
+	if err != nil {
+		t.Fatalf("New(tempo) error = %v", err)
+	}
+	if tempo, ok := backend.(Tempo); !ok || tempo.URL != "http://localhost:3200" {
+		t.Fatalf("expected Tempo backend with trimmed URL, got %#v", backend)
+	}
+	if _, err := New("jaeger", "http://localhost:16686"); err != nil {
+		t.Fatalf("New(jaeger) error = %v", err)
+	}
+	if _, err := New("zipkin", "http://localhost:9411"); err == nil || !strings.Contains(err.Error(), "jaeger, tempo") {
+		t.Fatalf("expected unsupported backend error listing names, got %v", err)
+	}
+	if _, err := New("tempo", " "); err == nil {
+		t.Fatalf("expected error for empty URL")
+	}
+}
+
+func TestRegisterExternalBackend(t *testing.T) {
+	factory := func(string) (VerificationBackend, error) { return stubBackend{}, nil }
+	if err := Register("stub-test", factory); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := Register("stub-test", factory); err == nil {
+		t.Fatalf("expected duplicate registration to fail")
+	}
+	backend, err := New("stub-test", "http://example")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, ok := backend.(stubBackend); !ok {
+		t.Fatalf("expected registered backend, got %#v", backend)
+	}
+}
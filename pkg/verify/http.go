@@ -0,0 +1,67 @@
+package verify
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+func newHTTPClient() *http.Client {
+	return &http.Client{Timeout: 10 * time.Second}
+}
+
+// getJSON fetches url and decodes the body into out. found is false when
+// the backend answered 404.
+func getJSON(ctx context.Context, client *http.Client, url string, out any) (found bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("query %s: unexpected status %s", url, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return false, fmt.Errorf("query %s: %w", url, err)
+	}
+	return true, nil
+}
+
+// normalizeID returns id as lowercase hex. OTLP JSON from protobuf
+// marshalers encodes IDs as base64, while most query APIs use hex.
+func normalizeID(id string, size int) string {
+	if len(id) == hex.EncodedLen(size) {
+		if _, err := hex.DecodeString(id); err == nil {
+			return strings.ToLower(id)
+		}
+	}
+	if raw, err := base64.StdEncoding.DecodeString(id); err == nil && len(raw) == size {
+		return hex.EncodeToString(raw)
+	}
+	return strings.ToLower(id)
+}
+
+// padID restores leading zeros that some APIs trim from hex IDs.
+func padID(id string, width int) string {
+	id = strings.ToLower(id)
+	if len(id) >= width {
+		return id
+	}
+	return strings.Repeat("0", width-len(id)) + id
+}
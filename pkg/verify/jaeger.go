@@ -0,0 +1,124 @@
+package verify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Jaeger queries the Jaeger query service's HTTP API.
+type Jaeger struct {
+	// URL is the query service base address, e.g. http://localhost:16686.
+	URL string
+	// Service scopes SearchByAttr, since Jaeger only searches within one
+	// service. It is not needed when searching by service.name.
+	Service string
+	Client  *http.Client
+}
+
+func NewJaeger(url string) Jaeger {
+	return Jaeger{URL: strings.TrimRight(url, "/"), Client: newHTTPClient()}
+}
+
+func (j Jaeger) FindTrace(ctx context.Context, traceID string) (Trace, bool, error) {
+	var body jaegerResponse
+	found, err := getJSON(ctx, j.Client, j.URL+"/api/traces/"+traceID, &body)
+	if err != nil || !found || len(body.Data) == 0 {
+		return Trace{}, false, err
+	}
+	return body.Data[0].toTrace(traceID), true, nil
+}
+
+func (j Jaeger) CountSpans(ctx context.Context, traceID string) (int, error) {
+	trace, _, err := j.FindTrace(ctx, traceID)
+	return len(trace.Spans), err
+}
+
+func (j Jaeger) SearchByAttr(ctx context.Context, key string, value string, limit int) ([]string, error) {
+	query := url.Values{}
+	if key == "service.name" {
+		query.Set("service", value)
+	} else {
+		if j.Service == "" {
+			return nil, fmt.Errorf("jaeger search by %q needs a service", key)
+		}
+		tags, err := json.Marshal(map[string]string{key: value})
+		if err != nil {
+			return nil, err
+		}
+		query.Set("service", j.Service)
+		query.Set("tags", string(tags))
+	}
+	if limit > 0 {
+		query.Set("limit", fmt.Sprint(limit))
+	}
+	var body jaegerResponse
+	if _, err := getJSON(ctx, j.Client, j.URL+"/api/traces?"+query.Encode(), &body); err != nil {
+		return nil, err
+	}
+	traceIDs := make([]string, 0, len(body.Data))
+	for _, trace := range body.Data {
+		traceIDs = append(traceIDs, padID(trace.TraceID, 32))
+	}
+	return traceIDs, nil
+}
+
+type jaegerResponse struct {
+	Data []jaegerTrace `json:"data"`
+}
+
+type jaegerTrace struct {
+	TraceID   string                   `json:"traceID"`
+	Spans     []jaegerSpan             `json:"spans"`
+	Processes map[string]jaegerProcess `json:"processes"`
+}
+
+type jaegerSpan struct {
+	SpanID        string            `json:"spanID"`
+	OperationName string            `json:"operationName"`
+	References    []jaegerReference `json:"references"`
+	ProcessID     string            `json:"processID"`
+	Tags          []jaegerTag       `json:"tags"`
+}
+
+type jaegerReference struct {
+	RefType string `json:"refType"`
+	SpanID  string `json:"spanID"`
+}
+
+type jaegerProcess struct {
+	ServiceName string `json:"serviceName"`
+}
+
+type jaegerTag struct {
+	Key   string `json:"key"`
+	Value any    `json:"value"`
+}
+
+func (t jaegerTrace) toTrace(traceID string) Trace {
+	trace := Trace{TraceID: traceID, Spans: make([]Span, 0, len(t.Spans))}
+	for _, span := range t.Spans {
+		out := Span{
+			SpanID:      padID(span.SpanID, 16),
+			Name:        span.OperationName,
+			ServiceName: t.Processes[span.ProcessID].ServiceName,
+		}
+		for _, ref := range span.References {
+			if ref.RefType == "CHILD_OF" {
+				out.ParentSpanID = padID(ref.SpanID, 16)
+				break
+			}
+		}
+		if len(span.Tags) > 0 {
+			out.Attributes = make(map[string]string, len(span.Tags))
+			for _, tag := range span.Tags {
+				out.Attributes[tag.Key] = fmt.Sprint(tag.Value)
+			}
+		}
+		trace.Spans = append(trace.Spans, out)
+	}
+	return trace
+}
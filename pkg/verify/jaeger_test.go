@@ -0,0 +1,65 @@
+package verify
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestJaegerFindTrace(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/traces/0102" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[{"traceID":"0102","spans":[
+			{"spanID":"a1","operationName":"GET /items","processID":"p1","tags":[{"key":"http.response.status_code","type":"int64","value":200}]},
+			{"spanID":"b2","operationName":"SELECT items","processID":"p2","references":[{"refType":"CHILD_OF","spanID":"a1"}]}
+		],"processes":{"p1":{"serviceName":"api"},"p2":{"serviceName":"db"}}}]}`))
+	}))
+	defer server.Close()
+	jaeger := NewJaeger(server.URL)
+
+	trace, found, err := jaeger.FindTrace(context.Background(), "0102")
+	if err != nil || !found {
+		t.Fatalf("FindTrace() = found %v, error %v", found, err)
+	}
+	if len(trace.Spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(trace.Spans))
+	}
+	if root := trace.Spans[0]; root.SpanID != "00000000000000a1" || root.ServiceName != "api" || root.Attributes["http.response.status_code"] != "200" {
+		t.Fatalf("unexpected root span: %+v", root)
+	}
+	if child := trace.Spans[1]; child.ParentSpanID != "00000000000000a1" || child.ServiceName != "db" {
+		t.Fatalf("unexpected child span: %+v", child)
+	}
+
+	if count, err := jaeger.CountSpans(context.Background(), "ffff"); err != nil || count != 0 {
+		t.Fatalf("expected missing trace to count 0 spans, got %d, %v", count, err)
+	}
+}
+
+func TestJaegerSearchByAttr(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("service") != "api" || query.Get("tags") != `{"tercios.run":"abc"}` {
+			t.Errorf("unexpected search request %s", r.URL)
+		}
+		_, _ = w.Write([]byte(`{"data":[{"traceID":"2b3c"}]}`))
+	}))
+	defer server.Close()
+
+	jaeger := NewJaeger(server.URL)
+	if _, err := jaeger.SearchByAttr(context.Background(), "tercios.run", "abc", 0); err == nil {
+		t.Fatalf("expected error without a service to search in")
+	}
+	jaeger.Service = "api"
+	traceIDs, err := jaeger.SearchByAttr(context.Background(), "tercios.run", "abc", 0)
+	if err != nil {
+		t.Fatalf("SearchByAttr() error = %v", err)
+	}
+	if len(traceIDs) != 1 || traceIDs[0] != "00000000000000000000000000002b3c" {
+		t.Fatalf("unexpected trace IDs: %v", traceIDs)
+	}
+}
@@ -0,0 +1,119 @@
+package verify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Tempo queries Grafana Tempo's HTTP API.
+type Tempo struct {
+	// URL is Tempo's HTTP base address, e.g. http://localhost:3200.
+	URL    string
+	Client *http.Client
+}
+
+func NewTempo(url string) Tempo {
+	return Tempo{URL: strings.TrimRight(url, "/"), Client: newHTTPClient()}
+}
+
+func (t Tempo) FindTrace(ctx context.Context, traceID string) (Trace, bool, error) {
+	var body tempoTrace
+	found, err := getJSON(ctx, t.Client, t.URL+"/api/traces/"+traceID, &body)
+	if err != nil || !found {
+		return Trace{}, false, err
+	}
+	trace := Trace{TraceID: traceID}
+	// Older Tempo releases use "batches" and "instrumentationLibrarySpans"
+	// instead of "resourceSpans" and "scopeSpans".
+	for _, resource := range append(body.Batches, body.ResourceSpans...) {
+		resourceAttrs := otlpAttributes(resource.Resource.Attributes)
+		for _, scope := range append(resource.ScopeSpans, resource.InstrumentationLibrarySpans...) {
+			for _, span := range scope.Spans {
+				trace.Spans = append(trace.Spans, Span{
+					SpanID:       normalizeID(span.SpanID, 8),
+					ParentSpanID: normalizeID(span.ParentSpanID, 8),
+					Name:         span.Name,
+					ServiceName:  resourceAttrs["service.name"],
+					Attributes:   otlpAttributes(span.Attributes),
+				})
+			}
+		}
+	}
+	return trace, true, nil
+}
+
+func (t Tempo) CountSpans(ctx context.Context, traceID string) (int, error) {
+	trace, _, err := t.FindTrace(ctx, traceID)
+	return len(trace.Spans), err
+}
+
+// SearchByAttr uses Tempo's tag search, which matches span and resource
+// attributes alike.
+func (t Tempo) SearchByAttr(ctx context.Context, key string, value string, limit int) ([]string, error) {
+	query := url.Values{}
+	query.Set("tags", fmt.Sprintf("%s=%q", key, value))
+	if limit > 0 {
+		query.Set("limit", fmt.Sprint(limit))
+	}
+	var body struct {
+		Traces []struct {
+			TraceID string `json:"traceID"`
+		} `json:"traces"`
+	}
+	if _, err := getJSON(ctx, t.Client, t.URL+"/api/search?"+query.Encode(), &body); err != nil {
+		return nil, err
+	}
+	traceIDs := make([]string, 0, len(body.Traces))
+	for _, trace := range body.Traces {
+		// Tempo trims leading zeros from trace IDs in search results.
+		traceIDs = append(traceIDs, padID(trace.TraceID, 32))
+	}
+	return traceIDs, nil
+}
+
+type tempoTrace struct {
+	Batches       []otlpResourceSpans `json:"batches"`
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	} `json:"resource"`
+	ScopeSpans                  []otlpScopeSpans `json:"scopeSpans"`
+	InstrumentationLibrarySpans []otlpScopeSpans `json:"instrumentationLibrarySpans"`
+}
+
+type otlpScopeSpans struct {
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpSpan struct {
+	SpanID       string         `json:"spanId"`
+	ParentSpanID string         `json:"parentSpanId"`
+	Name         string         `json:"name"`
+	Attributes   []otlpKeyValue `json:"attributes"`
+}
+
+type otlpKeyValue struct {
+	Key   string         `json:"key"`
+	Value map[string]any `json:"value"`
+}
+
+// otlpAttributes flattens OTLP JSON attributes to strings, keeping only
+// the scalar value of each AnyValue.
+func otlpAttributes(kvs []otlpKeyValue) map[string]string {
+	if len(kvs) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(kvs))
+	for _, kv := range kvs {
+		for _, value := range kv.Value {
+			out[kv.Key] = fmt.Sprint(value)
+		}
+	}
+	return out
+}
@@ -0,0 +1,77 @@
+package verify
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTempoFindTrace(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/traces/current":
+			_, _ = w.Write([]byte(`{"batches":[{
+				"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"api"}}]},
+				"scopeSpans":[{"spans":[
+					{"spanId":"AQIDBAUGBwg=","name":"GET /items","attributes":[{"key":"http.response.status_code","value":{"intValue":"200"}}]},
+					{"spanId":"0000000000000002","parentSpanId":"AQIDBAUGBwg=","name":"SELECT items"}
+				]}]}]}`))
+		case "/api/traces/legacy":
+			_, _ = w.Write([]byte(`{"batches":[{"instrumentationLibrarySpans":[{"spans":[{},{}]}]}]}`))
+		case "/api/traces/otlp":
+			_, _ = w.Write([]byte(`{"resourceSpans":[{"scopeSpans":[{"spans":[{}]}]}]}`))
+		case "/api/traces/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	tempo := NewTempo(server.URL)
+
+	trace, found, err := tempo.FindTrace(context.Background(), "current")
+	if err != nil || !found {
+		t.Fatalf("FindTrace() = found %v, error %v", found, err)
+	}
+	if len(trace.Spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(trace.Spans))
+	}
+	root := trace.Spans[0]
+	if root.SpanID != "0102030405060708" || root.ServiceName != "api" || root.Attributes["http.response.status_code"] != "200" {
+		t.Fatalf("unexpected root span: %+v", root)
+	}
+	if trace.Spans[1].ParentSpanID != "0102030405060708" {
+		t.Fatalf("expected hex parent span ID, got %q", trace.Spans[1].ParentSpanID)
+	}
+
+	counts := map[string]int{"legacy": 2, "otlp": 1, "missing": 0}
+	for traceID, want := range counts {
+		got, err := tempo.CountSpans(context.Background(), traceID)
+		if err != nil || got != want {
+			t.Fatalf("%s: CountSpans() = %d, %v; want %d", traceID, got, err, want)
+		}
+	}
+	if _, err := tempo.CountSpans(context.Background(), "broken"); err == nil {
+		t.Fatalf("expected error for failing backend")
+	}
+}
+
+func TestTempoSearchByAttr(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/search" || r.URL.Query().Get("tags") != `tercios.run="abc"` || r.URL.Query().Get("limit") != "5" {
+			t.Errorf("unexpected search request %s", r.URL)
+		}
+		_, _ = w.Write([]byte(`{"traces":[{"traceID":"2b3c"},{"traceID":"0123456789abcdef0123456789ABCDEF"}]}`))
+	}))
+	defer server.Close()
+
+	traceIDs, err := NewTempo(server.URL).SearchByAttr(context.Background(), "tercios.run", "abc", 5)
+	if err != nil {
+		t.Fatalf("SearchByAttr() error = %v", err)
+	}
+	want := []string{"00000000000000000000000000002b3c", "0123456789abcdef0123456789abcdef"}
+	if len(traceIDs) != 2 || traceIDs[0] != want[0] || traceIDs[1] != want[1] {
+		t.Fatalf("SearchByAttr() = %v, want %v", traceIDs, want)
+	}
+}